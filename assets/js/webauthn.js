@@ -0,0 +1,141 @@
+"use strict";
+
+// WebAuthn helpers used to sign in and register security keys and passkeys.
+// The console sends the options with binary fields encoded as base64url and
+// expects the browser response encoded the same way. Errors returned by the
+// console are HTML fragments that replace the #error element, as any htmx
+// request does.
+
+function base64urlToBuffer(value) {
+  const base64 = value.replace(/-/g, "+").replace(/_/g, "/");
+  const padded = base64 + "=".repeat((4 - (base64.length % 4)) % 4);
+  const binary = atob(padded);
+  const bytes = new Uint8Array(binary.length);
+  for (let i = 0; i < binary.length; i++) {
+    bytes[i] = binary.charCodeAt(i);
+  }
+  return bytes.buffer;
+}
+
+function bufferToBase64url(buffer) {
+  const bytes = new Uint8Array(buffer);
+  let binary = "";
+  for (let i = 0; i < bytes.length; i++) {
+    binary += String.fromCharCode(bytes[i]);
+  }
+  return btoa(binary).replace(/\+/g, "-").replace(/\//g, "_").replace(/=+$/, "");
+}
+
+function decodeDescriptors(descriptors) {
+  return (descriptors || []).map((d) => ({ ...d, id: base64urlToBuffer(d.id) }));
+}
+
+function credentialToJSON(credential) {
+  const response = credential.response;
+  const json = {
+    id: credential.id,
+    rawId: bufferToBase64url(credential.rawId),
+    type: credential.type,
+    authenticatorAttachment: credential.authenticatorAttachment,
+    clientExtensionResults: credential.getClientExtensionResults(),
+    response: {
+      clientDataJSON: bufferToBase64url(response.clientDataJSON),
+    },
+  };
+
+  if (response.attestationObject) {
+    json.response.attestationObject = bufferToBase64url(response.attestationObject);
+    json.response.transports = response.getTransports ? response.getTransports() : [];
+  } else {
+    json.response.authenticatorData = bufferToBase64url(response.authenticatorData);
+    json.response.signature = bufferToBase64url(response.signature);
+    if (response.userHandle) {
+      json.response.userHandle = bufferToBase64url(response.userHandle);
+    }
+  }
+
+  return json;
+}
+
+// webAuthnBegin asks the console for the options of the ceremony, it returns
+// null if the console answered with an error that has already been shown
+async function webAuthnBegin(url, values) {
+  const response = await fetch(url, {
+    method: "POST",
+    headers: { "HX-Request": "true" },
+    body: new URLSearchParams(values),
+  });
+
+  const contentType = response.headers.get("Content-Type") || "";
+  if (!contentType.includes("application/json")) {
+    htmx.swap("#error", await response.text(), { swapStyle: "outerHTML" });
+    return null;
+  }
+
+  return response.json();
+}
+
+// webAuthnFinish sends the result of the ceremony to the console, browser
+// errors are sent too so the console can show a translated message
+function webAuthnFinish(url, target, values) {
+  return htmx.ajax("POST", url, {
+    source: document.body,
+    target: target,
+    swap: "outerHTML",
+    values: values,
+  });
+}
+
+globalThis.signInWithPasskey = async function (beginURL, finishURL) {
+  if (!window.PublicKeyCredential) {
+    return webAuthnFinish(finishURL, "body", { error: "NotSupportedError" });
+  }
+
+  const options = await webAuthnBegin(beginURL, {});
+  if (!options) {
+    return;
+  }
+
+  const publicKey = options.publicKey;
+  publicKey.challenge = base64urlToBuffer(publicKey.challenge);
+  publicKey.allowCredentials = decodeDescriptors(publicKey.allowCredentials);
+
+  let credential;
+  try {
+    credential = await navigator.credentials.get({ publicKey });
+  } catch (err) {
+    return webAuthnFinish(finishURL, "body", { error: err.name || "Error" });
+  }
+
+  return webAuthnFinish(finishURL, "body", {
+    credential: JSON.stringify(credentialToJSON(credential)),
+  });
+};
+
+globalThis.registerPasskey = async function (beginURL, finishURL, name) {
+  if (!window.PublicKeyCredential) {
+    return webAuthnFinish(finishURL, "#main", { error: "NotSupportedError", "passkey-name": name });
+  }
+
+  const options = await webAuthnBegin(beginURL, { "passkey-name": name });
+  if (!options) {
+    return;
+  }
+
+  const publicKey = options.publicKey;
+  publicKey.challenge = base64urlToBuffer(publicKey.challenge);
+  publicKey.user.id = base64urlToBuffer(publicKey.user.id);
+  publicKey.excludeCredentials = decodeDescriptors(publicKey.excludeCredentials);
+
+  let credential;
+  try {
+    credential = await navigator.credentials.create({ publicKey });
+  } catch (err) {
+    return webAuthnFinish(finishURL, "#main", { error: err.name || "Error", "passkey-name": name });
+  }
+
+  return webAuthnFinish(finishURL, "#main", {
+    credential: JSON.stringify(credentialToJSON(credential)),
+    "passkey-name": name,
+  });
+};
@@ -0,0 +1,130 @@
+// Package alerts defines the conditions alert rules can check, how their
+// thresholds are validated and the e-mail sent when agents match a rule.
+package alerts
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+const (
+	// TypeAgentNotReported matches agents that haven't reported for Threshold hours
+	TypeAgentNotReported = "agent_not_reported"
+	// TypeDiskUsage matches logical disks whose usage is at least Threshold percent
+	TypeDiskUsage = "disk_usage"
+	// TypeAntivirusOutdated matches agents whose antivirus database is not up to date
+	TypeAntivirusOutdated = "antivirus_outdated"
+	// TypeUpdatesPending matches agents with pending updates and no updates installed
+	// in the last Threshold days. Agents only report if updates are pending, not how many
+	TypeUpdatesPending = "updates_pending"
+)
+
+// Types contains the conditions a rule can check
+var Types = []string{TypeAgentNotReported, TypeDiskUsage, TypeAntivirusOutdated, TypeUpdatesPending}
+
+// EvaluationInterval is how often each rule is evaluated
+const EvaluationInterval = 15 * time.Minute
+
+// Match is an object that matches a rule, Subject identifies it so alerts can be throttled
+type Match struct {
+	Subject  string
+	Hostname string
+	Detail   string
+}
+
+// DefaultThreshold returns the threshold suggested for a type
+func DefaultThreshold(ruleType string) int {
+	switch ruleType {
+	case TypeAgentNotReported:
+		return 24
+	case TypeDiskUsage:
+		return 90
+	case TypeUpdatesPending:
+		return 7
+	default:
+		return 0
+	}
+}
+
+// ValidThreshold reports if the threshold makes sense for the type
+func ValidThreshold(ruleType string, threshold int) bool {
+	switch ruleType {
+	case TypeAgentNotReported:
+		return threshold >= 1 && threshold <= 24*365
+	case TypeDiskUsage:
+		return threshold >= 1 && threshold <= 100
+	case TypeAntivirusOutdated:
+		return threshold == 0
+	case TypeUpdatesPending:
+		return threshold >= 0 && threshold <= 365
+	default:
+		return false
+	}
+}
+
+// ParseRecipients splits a list of e-mail addresses separated by commas, semicolons or new lines
+func ParseRecipients(value string) ([]string, error) {
+	recipients := []string{}
+	for _, r := range strings.FieldsFunc(value, func(c rune) bool { return c == ',' || c == ';' || c == '\n' }) {
+		r = strings.TrimSpace(r)
+		if r == "" {
+			continue
+		}
+		address, err := mail.ParseAddress(r)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a valid e-mail address", r)
+		}
+		recipients = append(recipients, address.Address)
+	}
+	if len(recipients) == 0 {
+		return nil, fmt.Errorf("at least one recipient is required")
+	}
+	return recipients, nil
+}
+
+// Throttled returns the matches that haven't been alerted since the throttle window started
+func Throttled(matches []Match, alerted map[string]bool) []Match {
+	pending := []Match{}
+	for _, m := range matches {
+		if !alerted[m.Subject] {
+			pending = append(pending, m)
+		}
+	}
+	return pending
+}
+
+// Subject returns the subject of the e-mail sent for a rule
+func Subject(ruleName string, matches []Match) string {
+	if len(matches) == 1 {
+		return fmt.Sprintf("OpenUEM alert: %s (%s)", ruleName, matches[0].Hostname)
+	}
+	return fmt.Sprintf("OpenUEM alert: %s (%d agents)", ruleName, len(matches))
+}
+
+// Message returns the text sent for a match
+func Message(ruleType string, threshold int, m Match) string {
+	switch ruleType {
+	case TypeAgentNotReported:
+		return fmt.Sprintf("%s hasn't reported for more than %d hours, last report: %s", m.Hostname, threshold, m.Detail)
+	case TypeDiskUsage:
+		return fmt.Sprintf("%s: disk %s is over %d%% usage", m.Hostname, m.Detail, threshold)
+	case TypeAntivirusOutdated:
+		return fmt.Sprintf("%s: the %s antivirus database is outdated", m.Hostname, m.Detail)
+	case TypeUpdatesPending:
+		return fmt.Sprintf("%s has pending updates, last updates installed: %s", m.Hostname, m.Detail)
+	default:
+		return m.Hostname
+	}
+}
+
+// Body returns the text of the e-mail sent for a rule
+func Body(ruleName, ruleType string, threshold int, matches []Match) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "The %s alert rule matches the following agents:\n\n", ruleName)
+	for _, m := range matches {
+		fmt.Fprintf(&b, "- %s\n", Message(ruleType, threshold, m))
+	}
+	return b.String()
+}
@@ -0,0 +1,47 @@
+package alerts
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidThreshold(t *testing.T) {
+	assert.True(t, ValidThreshold(TypeAgentNotReported, 24))
+	assert.False(t, ValidThreshold(TypeAgentNotReported, 0))
+	assert.True(t, ValidThreshold(TypeDiskUsage, 90))
+	assert.False(t, ValidThreshold(TypeDiskUsage, 101))
+	assert.True(t, ValidThreshold(TypeAntivirusOutdated, 0))
+	assert.False(t, ValidThreshold(TypeAntivirusOutdated, 5))
+	assert.True(t, ValidThreshold(TypeUpdatesPending, 0))
+	assert.False(t, ValidThreshold("unknown", 1))
+
+	for _, ruleType := range Types {
+		assert.True(t, ValidThreshold(ruleType, DefaultThreshold(ruleType)), "default threshold should be valid for %s", ruleType)
+	}
+}
+
+func TestParseRecipients(t *testing.T) {
+	recipients, err := ParseRecipients("admin@example.com, Helpdesk <helpdesk@example.com>;ops@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"admin@example.com", "helpdesk@example.com", "ops@example.com"}, recipients)
+
+	_, err = ParseRecipients("admin@example.com, not-an-email")
+	assert.Error(t, err)
+
+	_, err = ParseRecipients(" , ")
+	assert.Error(t, err)
+}
+
+func TestThrottled(t *testing.T) {
+	matches := []Match{{Subject: "agent1"}, {Subject: "agent2"}, {Subject: "agent3"}}
+	pending := Throttled(matches, map[string]bool{"agent2": true})
+	assert.Equal(t, []Match{{Subject: "agent1"}, {Subject: "agent3"}}, pending)
+}
+
+func TestBody(t *testing.T) {
+	matches := []Match{{Subject: "agent1:C:", Hostname: "pc1", Detail: "C:"}}
+	assert.Equal(t, "OpenUEM alert: Disks (pc1)", Subject("Disks", matches))
+	assert.Contains(t, Body("Disks", TypeDiskUsage, 90, matches), "pc1: disk C: is over 90% usage")
+	assert.Equal(t, "OpenUEM alert: Disks (2 agents)", Subject("Disks", append(matches, Match{Hostname: "pc2"})))
+}
@@ -0,0 +1,86 @@
+// Package bulkactions defines the actions that can be run on several computers
+// selected in the computers list and the result reported for each of them.
+package bulkactions
+
+import (
+	"slices"
+	"strings"
+)
+
+// Actions that can be run on the selected computers
+const (
+	ActionTagAdd       = "tag-add"
+	ActionTagRemove    = "tag-remove"
+	ActionMoveSite     = "move-site"
+	ActionReboot       = "reboot"
+	ActionPowerOff     = "power-off"
+	ActionWakeOnLAN    = "wol"
+	ActionForceReport  = "force-report"
+	ActionRunProfile   = "run-profile"
+	ActionEndpointType = "endpoint-type"
+	ActionMetadata     = "metadata"
+)
+
+// Actions contains the actions in the order they're offered
+var Actions = []string{ActionTagAdd, ActionTagRemove, ActionMoveSite, ActionReboot, ActionPowerOff, ActionWakeOnLAN, ActionForceReport, ActionRunProfile, ActionEndpointType, ActionMetadata}
+
+// RemoteActions are the actions that only need the permission to assist users remotely
+var RemoteActions = []string{ActionReboot, ActionPowerOff, ActionWakeOnLAN, ActionForceReport}
+
+// MaxTargets is the maximum number of computers an action can be run on at once
+const MaxTargets = 500
+
+// Status of the action for a computer
+const (
+	StatusDone   = "done"
+	StatusQueued = "queued"
+	StatusFailed = "failed"
+)
+
+// Result is the outcome of the action for one of the selected computers
+type Result struct {
+	AgentID  string
+	Hostname string
+	Status   string
+	Message  string
+}
+
+// Summary counts the results by status
+type Summary struct {
+	Done   int
+	Queued int
+	Failed int
+}
+
+// Summarize counts the results by status
+func Summarize(results []Result) Summary {
+	s := Summary{}
+	for _, r := range results {
+		switch r.Status {
+		case StatusDone:
+			s.Done++
+		case StatusQueued:
+			s.Queued++
+		case StatusFailed:
+			s.Failed++
+		}
+	}
+	return s
+}
+
+// ParseTargets returns the agent IDs of a comma separated list, without blanks or duplicates
+func ParseTargets(value string) []string {
+	targets := []string{}
+	for id := range strings.SplitSeq(value, ",") {
+		id = strings.TrimSpace(id)
+		if id != "" && !slices.Contains(targets, id) {
+			targets = append(targets, id)
+		}
+	}
+	return targets
+}
+
+// IsValid reports if the action is one of the known actions
+func IsValid(action string) bool {
+	return slices.Contains(Actions, action)
+}
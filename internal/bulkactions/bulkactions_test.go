@@ -0,0 +1,27 @@
+package bulkactions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTargets(t *testing.T) {
+	assert.Equal(t, []string{"a", "b"}, ParseTargets(" a,b,,a ,"))
+	assert.Equal(t, []string{}, ParseTargets(""))
+}
+
+func TestSummarize(t *testing.T) {
+	results := []Result{
+		{AgentID: "a", Status: StatusDone},
+		{AgentID: "b", Status: StatusFailed},
+		{AgentID: "c", Status: StatusQueued},
+		{AgentID: "d", Status: StatusDone},
+	}
+	assert.Equal(t, Summary{Done: 2, Queued: 1, Failed: 1}, Summarize(results))
+}
+
+func TestIsValid(t *testing.T) {
+	assert.True(t, IsValid(ActionMoveSite))
+	assert.False(t, IsValid("delete"))
+}
@@ -0,0 +1,176 @@
+// Package claims reads values from the claims sent by an OpenID Connect provider
+// using a small subset of JSONPath
+package claims
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidPath = errors.New("the claim path is not valid")
+
+// step is a part of a path, a key of an object or an index of an array.
+// An index of -1 selects every element
+type step struct {
+	key   string
+	index int
+	isKey bool
+}
+
+// Extract returns the values found at the path. The path is a list of keys separated by dots with
+// an optional $. prefix, keys with dots like URLs go between brackets and quotes ["https://..."]
+// and arrays can be indexed with [n] or [*]. Arrays found at the end are flattened and the keys of
+// an object are returned as its values, Zitadel sends the roles as an object keyed by role name.
+// A missing claim isn't an error, there are no values
+func Extract(claims map[string]any, path string) ([]string, error) {
+	steps, err := parse(path)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := []any{claims}
+	for _, s := range steps {
+		next := []any{}
+		for _, n := range nodes {
+			next = append(next, s.apply(n)...)
+		}
+		nodes = next
+	}
+
+	values := []string{}
+	for _, n := range nodes {
+		for _, v := range flatten(n) {
+			if !slices.Contains(values, v) {
+				values = append(values, v)
+			}
+		}
+	}
+
+	return values, nil
+}
+
+// ValidPath reports whether the path can be used with Extract
+func ValidPath(path string) bool {
+	_, err := parse(path)
+	return err == nil
+}
+
+func (s step) apply(node any) []any {
+	if s.isKey {
+		if object, ok := node.(map[string]any); ok {
+			if v, ok := object[s.key]; ok {
+				return []any{v}
+			}
+		}
+		return nil
+	}
+
+	array, ok := node.([]any)
+	if !ok {
+		return nil
+	}
+
+	if s.index == -1 {
+		return array
+	}
+
+	if s.index < len(array) {
+		return []any{array[s.index]}
+	}
+
+	return nil
+}
+
+func flatten(node any) []string {
+	switch v := node.(type) {
+	case nil:
+		return nil
+	case string:
+		return []string{v}
+	case []any:
+		values := []string{}
+		for _, e := range v {
+			values = append(values, flatten(e)...)
+		}
+		return values
+	case map[string]any:
+		keys := []string{}
+		for k := range v {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		return keys
+	default:
+		return []string{fmt.Sprint(v)}
+	}
+}
+
+func parse(path string) ([]step, error) {
+	path = strings.TrimSpace(path)
+	if path == "$" || path == "" {
+		return nil, ErrInvalidPath
+	}
+	path = strings.TrimPrefix(path, "$.")
+	if strings.HasPrefix(path, "$[") {
+		path = path[1:]
+	}
+
+	steps := []step{}
+	for path != "" {
+		switch path[0] {
+		case '.':
+			if len(steps) == 0 {
+				return nil, ErrInvalidPath
+			}
+			path = path[1:]
+			if path == "" || path[0] == '.' || path[0] == '[' {
+				return nil, ErrInvalidPath
+			}
+		case '[':
+			end := strings.IndexByte(path, ']')
+			if end == -1 {
+				return nil, ErrInvalidPath
+			}
+
+			// quoted keys may contain a closing bracket
+			inside := path[1:end]
+			if strings.HasPrefix(inside, `"`) || strings.HasPrefix(inside, `'`) {
+				quote := inside[0]
+				closing := strings.IndexByte(path[2:], quote)
+				if closing == -1 || len(path) < closing+4 || path[closing+3] != ']' {
+					return nil, ErrInvalidPath
+				}
+				key := path[2 : closing+2]
+				if key == "" {
+					return nil, ErrInvalidPath
+				}
+				steps = append(steps, step{key: key, isKey: true})
+				path = path[closing+4:]
+				continue
+			}
+
+			if inside == "*" {
+				steps = append(steps, step{index: -1})
+			} else {
+				index, err := strconv.Atoi(inside)
+				if err != nil || index < 0 {
+					return nil, ErrInvalidPath
+				}
+				steps = append(steps, step{index: index})
+			}
+			path = path[end+1:]
+		default:
+			end := strings.IndexAny(path, ".[")
+			if end == -1 {
+				end = len(path)
+			}
+			steps = append(steps, step{key: path[:end], isKey: true})
+			path = path[end:]
+		}
+	}
+
+	return steps, nil
+}
@@ -0,0 +1,72 @@
+package claims
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testClaims(t *testing.T) map[string]any {
+	data := `{
+		"preferred_username": "jdoe",
+		"groups": ["openuem-admins", "it"],
+		"realm_access": {"roles": ["operator", "offline_access"]},
+		"urn:zitadel:iam:org:project:roles": {"helpdesk": {"1234": "example.com"}, "auditor": {"1234": "example.com"}},
+		"https://example.com/claims": {"teams": [{"name": "emea"}, {"name": "apac"}]},
+		"level": 3,
+		"admin": true
+	}`
+
+	claims := map[string]any{}
+	assert.NoError(t, json.Unmarshal([]byte(data), &claims))
+	return claims
+}
+
+func TestExtract(t *testing.T) {
+	claims := testClaims(t)
+
+	values, err := Extract(claims, "groups")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"openuem-admins", "it"}, values)
+
+	values, err = Extract(claims, "$.realm_access.roles")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"operator", "offline_access"}, values)
+
+	values, err = Extract(claims, "realm_access.roles[0]")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"operator"}, values)
+
+	// Zitadel sends the roles as the keys of an object
+	values, err = Extract(claims, `["urn:zitadel:iam:org:project:roles"]`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"auditor", "helpdesk"}, values)
+
+	values, err = Extract(claims, `$["https://example.com/claims"].teams[*].name`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"emea", "apac"}, values)
+
+	values, err = Extract(claims, "level")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"3"}, values)
+
+	values, err = Extract(claims, "admin")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"true"}, values)
+
+	values, err = Extract(claims, "roles.missing[2]")
+	assert.NoError(t, err)
+	assert.Empty(t, values)
+}
+
+func TestValidPath(t *testing.T) {
+	assert.True(t, ValidPath("groups"))
+	assert.True(t, ValidPath(`$['https://example.com/roles'][*]`))
+	assert.False(t, ValidPath(""))
+	assert.False(t, ValidPath("$"))
+	assert.False(t, ValidPath("groups."))
+	assert.False(t, ValidPath("groups[x]"))
+	assert.False(t, ValidPath(`["unterminated]`))
+	assert.False(t, ValidPath(".groups"))
+}
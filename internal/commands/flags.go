@@ -129,5 +129,15 @@ func StartConsoleFlags() []cli.Flag {
 			Usage:   "master key used to encrypt sensitive fields in the database, need to be 32 bytes long (for example 32 ASCII characters)",
 			EnvVars: []string{"ENCRYPTION_MASTER_KEY"},
 		},
+		&cli.StringFlag{
+			Name:    "reports-dir",
+			Usage:   "folder where scheduled reports can be saved, scheduled reports can only be e-mailed if it's not set",
+			EnvVars: []string{"REPORTS_DIR"},
+		},
+		&cli.StringFlag{
+			Name:    "vulnerability-feed-dir",
+			Usage:   "folder with the offline vulnerability feed, NVD JSON 2.0 files or OSV records, the installed software isn't scanned if it's not set",
+			EnvVars: []string{"VULNERABILITY_FEED_DIR"},
+		},
 	}
 }
@@ -54,6 +54,8 @@ func (w *Worker) GenerateConsoleConfigFromCLI(cCtx *cli.Context) error {
 	w.ResetOpenUEMUser = cCtx.Bool("reset-openuem-user")
 	w.Version = "0.12.0"
 	w.EncryptionMasterKey = cCtx.String("encryption-master-key")
+	w.ReportsDir = cCtx.String("reports-dir")
+	w.VulnerabilityFeedDir = cCtx.String("vulnerability-feed-dir")
 
 	return nil
 }
@@ -174,6 +174,16 @@ func (w *Worker) GenerateConsoleConfig() error {
 		}
 	}
 
+	key, err = cfg.Section("Console").GetKey("reportsdir")
+	if err == nil {
+		w.ReportsDir = key.String()
+	}
+
+	key, err = cfg.Section("Console").GetKey("vulnerabilityfeeddir")
+	if err == nil {
+		w.VulnerabilityFeedDir = key.String()
+	}
+
 	key, err = cfg.Section("Server").GetKey("Version")
 	if err != nil {
 		return err
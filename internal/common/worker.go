@@ -42,6 +42,8 @@ type Worker struct {
 	ReverseProxyAuthPort              string
 	ReverseProxyServer                string
 	ServerReleasesFolder              string
+	ReportsDir                        string
+	VulnerabilityFeedDir              string
 	DownloadWingetDBJob               gocron.Job
 	DownloadWingetJobDuration         time.Duration
 	DownloadServerReleasesJob         gocron.Job
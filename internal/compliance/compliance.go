@@ -0,0 +1,224 @@
+// Package compliance evaluates the applications reported by an agent against the
+// software policies of its tenant, which list the applications that must be installed
+// and the ones that mustn't.
+package compliance
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	// TypeRequired policies need a matching application, optionally with a minimum version
+	TypeRequired = "required"
+	// TypeForbidden policies are violated by every matching application
+	TypeForbidden = "forbidden"
+)
+
+// Types contains the policy types in the order they're shown
+var Types = []string{TypeRequired, TypeForbidden}
+
+const (
+	// ReasonMissing is used when no application matches a required policy
+	ReasonMissing = "missing"
+	// ReasonOutdated is used when the matching applications are older than the minimum version
+	ReasonOutdated = "outdated"
+	// ReasonForbidden is used for each application that matches a forbidden policy
+	ReasonForbidden = "forbidden"
+)
+
+// Reasons contains why an agent can violate a policy
+var Reasons = []string{ReasonMissing, ReasonOutdated, ReasonForbidden}
+
+// EvaluationInterval is how often the applications of the agents are evaluated
+const EvaluationInterval = 15 * time.Minute
+
+// Policy is what an agent is evaluated against. Patterns are matched against the
+// whole name or publisher ignoring case, * matches any text and an empty pattern
+// matches everything
+type Policy struct {
+	ID               int
+	Name             string
+	Type             string
+	AppPattern       string
+	PublisherPattern string
+	MinVersion       string
+}
+
+// App is an application reported by an agent
+type App struct {
+	Name      string
+	Version   string
+	Publisher string
+}
+
+// Violation is a policy an agent doesn't comply with. App and Version are empty
+// when the required application is missing
+type Violation struct {
+	PolicyID   int
+	PolicyName string
+	PolicyType string
+	Reason     string
+	App        string
+	Version    string
+}
+
+// Matches reports if the application matches the patterns of the policy
+func (p Policy) Matches(a App) bool {
+	return MatchPattern(p.AppPattern, a.Name) && MatchPattern(p.PublisherPattern, a.Publisher)
+}
+
+// Evaluate returns the violations of the applications against the policies, in
+// the order of the policies
+func Evaluate(policies []Policy, apps []App) []Violation {
+	violations := []Violation{}
+
+	for _, p := range policies {
+		matching := []App{}
+		for _, a := range apps {
+			if p.Matches(a) {
+				matching = append(matching, a)
+			}
+		}
+
+		switch p.Type {
+		case TypeRequired:
+			if len(matching) == 0 {
+				violations = append(violations, p.violation(ReasonMissing, App{}))
+				continue
+			}
+
+			if p.MinVersion == "" {
+				continue
+			}
+
+			// any installed version that is recent enough satisfies the policy
+			newest := matching[0]
+			for _, a := range matching[1:] {
+				if CompareVersions(a.Version, newest.Version) > 0 {
+					newest = a
+				}
+			}
+			if CompareVersions(newest.Version, p.MinVersion) < 0 {
+				violations = append(violations, p.violation(ReasonOutdated, newest))
+			}
+		case TypeForbidden:
+			for _, a := range matching {
+				violations = append(violations, p.violation(ReasonForbidden, a))
+			}
+		}
+	}
+
+	return violations
+}
+
+func (p Policy) violation(reason string, a App) Violation {
+	return Violation{PolicyID: p.ID, PolicyName: p.Name, PolicyType: p.Type, Reason: reason, App: a.Name, Version: a.Version}
+}
+
+// ValidPattern reports if the pattern can be used in a policy, it must contain
+// something other than wildcards and spaces
+func ValidPattern(pattern string) bool {
+	return strings.Trim(pattern, "* ") != ""
+}
+
+// MatchPattern reports if value matches the pattern ignoring case
+func MatchPattern(pattern, value string) bool {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	if pattern == "" {
+		return true
+	}
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return value == pattern
+	}
+
+	if !strings.HasPrefix(value, parts[0]) {
+		return false
+	}
+	value = value[len(parts[0]):]
+
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		index := strings.Index(value, part)
+		if index == -1 {
+			return false
+		}
+		value = value[index+len(part):]
+	}
+
+	return len(value) >= len(last) && strings.HasSuffix(value, last)
+}
+
+// CompareVersions compares two versions segment by segment, numeric segments are
+// compared as numbers and the rest as text. A missing segment counts as zero so
+// 1.2 and 1.2.0 are equal. It returns -1, 0 or 1 like strings.Compare
+func CompareVersions(a, b string) int {
+	sa, sb := versionSegments(a), versionSegments(b)
+
+	for i := 0; i < max(len(sa), len(sb)); i++ {
+		x, y := "0", "0"
+		if i < len(sa) {
+			x = sa[i]
+		}
+		if i < len(sb) {
+			y = sb[i]
+		}
+
+		nx, errX := strconv.ParseUint(x, 10, 64)
+		ny, errY := strconv.ParseUint(y, 10, 64)
+		switch {
+		case errX == nil && errY == nil:
+			if nx != ny {
+				if nx < ny {
+					return -1
+				}
+				return 1
+			}
+		case errX == nil:
+			// a number is newer than a pre-release tag like beta
+			return 1
+		case errY == nil:
+			return -1
+		default:
+			if c := strings.Compare(strings.ToLower(x), strings.ToLower(y)); c != 0 {
+				return c
+			}
+		}
+	}
+
+	return 0
+}
+
+// versionSegments splits a version at any character that isn't a letter or a digit
+// and between digits and letters, so 1.2.3-rc1 gives 1, 2, 3, rc and 1
+func versionSegments(version string) []string {
+	segments := []string{}
+	current := []rune{}
+
+	flush := func() {
+		if len(current) > 0 {
+			segments = append(segments, string(current))
+			current = current[:0]
+		}
+	}
+
+	for _, r := range strings.TrimPrefix(strings.TrimSpace(strings.ToLower(version)), "v") {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case len(current) > 0 && unicode.IsDigit(r) != unicode.IsDigit(current[len(current)-1]):
+			flush()
+			current = append(current, r)
+		default:
+			current = append(current, r)
+		}
+	}
+	flush()
+
+	return segments
+}
@@ -0,0 +1,66 @@
+package compliance
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchPattern(t *testing.T) {
+	assert.True(t, MatchPattern("Mozilla Firefox", "mozilla firefox"))
+	assert.False(t, MatchPattern("Firefox", "Mozilla Firefox"))
+	assert.True(t, MatchPattern("*firefox*", "Mozilla Firefox (x64 es-ES)"))
+	assert.True(t, MatchPattern("7-Zip*", "7-Zip 23.01 (x64)"))
+	assert.False(t, MatchPattern("7-Zip*", "Igor Pavlov 7-Zip"))
+	assert.True(t, MatchPattern("*Torrent", "uTorrent"))
+	assert.True(t, MatchPattern("Microsoft*Office*", "Microsoft 365 Office"))
+	assert.False(t, MatchPattern("a*a", "a"))
+	assert.True(t, MatchPattern("", "anything"))
+	assert.True(t, MatchPattern("*", ""))
+}
+
+func TestValidPattern(t *testing.T) {
+	assert.True(t, ValidPattern("*chrome*"))
+	assert.False(t, ValidPattern(" * "))
+	assert.False(t, ValidPattern(""))
+}
+
+func TestCompareVersions(t *testing.T) {
+	assert.Equal(t, 0, CompareVersions("1.2", "1.2.0"))
+	assert.Equal(t, -1, CompareVersions("1.9", "1.10"))
+	assert.Equal(t, 1, CompareVersions("128.0.1", "128.0"))
+	assert.Equal(t, 1, CompareVersions("v2.0", "1.99"))
+	assert.Equal(t, -1, CompareVersions("3.0-beta1", "3.0"))
+	assert.Equal(t, -1, CompareVersions("3.0-alpha", "3.0-beta"))
+	assert.Equal(t, -1, CompareVersions("", "1.0"))
+}
+
+func TestEvaluate(t *testing.T) {
+	policies := []Policy{
+		{ID: 1, Name: "Antivirus", Type: TypeRequired, AppPattern: "*Defender*"},
+		{ID: 2, Name: "Browser", Type: TypeRequired, AppPattern: "Mozilla Firefox*", MinVersion: "128.0"},
+		{ID: 3, Name: "P2P", Type: TypeForbidden, AppPattern: "*torrent*"},
+		{ID: 4, Name: "Games", Type: TypeForbidden, AppPattern: "*", PublisherPattern: "Valve*"},
+		{ID: 5, Name: "Archiver", Type: TypeRequired, AppPattern: "7-Zip*", MinVersion: "23.01"},
+	}
+
+	apps := []App{
+		{Name: "Mozilla Firefox (x64)", Version: "127.0.2", Publisher: "Mozilla"},
+		{Name: "Mozilla Firefox ESR", Version: "115.12", Publisher: "Mozilla"},
+		{Name: "qBittorrent", Version: "4.6.5", Publisher: "The qBittorrent project"},
+		{Name: "uTorrent", Version: "3.6", Publisher: "BitTorrent"},
+		{Name: "Steam", Version: "2.10", Publisher: "Valve Corporation"},
+		{Name: "7-Zip 23.01 (x64)", Version: "23.01", Publisher: "Igor Pavlov"},
+		{Name: "7-Zip 19.00", Version: "19.00", Publisher: "Igor Pavlov"},
+	}
+
+	assert.Equal(t, []Violation{
+		{PolicyID: 1, PolicyName: "Antivirus", PolicyType: TypeRequired, Reason: ReasonMissing},
+		{PolicyID: 2, PolicyName: "Browser", PolicyType: TypeRequired, Reason: ReasonOutdated, App: "Mozilla Firefox (x64)", Version: "127.0.2"},
+		{PolicyID: 3, PolicyName: "P2P", PolicyType: TypeForbidden, Reason: ReasonForbidden, App: "qBittorrent", Version: "4.6.5"},
+		{PolicyID: 3, PolicyName: "P2P", PolicyType: TypeForbidden, Reason: ReasonForbidden, App: "uTorrent", Version: "3.6"},
+		{PolicyID: 4, PolicyName: "Games", PolicyType: TypeForbidden, Reason: ReasonForbidden, App: "Steam", Version: "2.10"},
+	}, Evaluate(policies, apps))
+
+	assert.Empty(t, Evaluate(policies[2:4], apps[5:]))
+}
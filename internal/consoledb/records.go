@@ -0,0 +1,578 @@
+package consoledb
+
+import "time"
+
+// APITokenPrefix identifies OpenUEM personal API tokens. A token has the form
+// ouem_<prefix>_<secret>, the prefix is stored in clear to find the token and
+// the secret is hashed with argon2id like user passwords
+const APITokenPrefix = "ouem_"
+
+// SCIMTokenPrefix identifies the token identity providers use to provision users
+// with SCIM. There's a single token, its secret is hashed with argon2id
+const SCIMTokenPrefix = "ouemscim_"
+
+type APIToken struct {
+	ID       int
+	UserID   string
+	Name     string
+	Prefix   string
+	Hash     string
+	TenantID int
+	Created  time.Time
+	Expiry   time.Time
+	LastUsed time.Time
+}
+
+// RoleAssignment grants a role to a user in a tenant and site. A TenantID of -1
+// means every tenant and a SiteID of -1 means every site in the tenant. Source is empty for
+// the roles assigned by an admin, RoleSourceOIDC for the roles granted by the claim mapping and
+// RoleSourceSCIM for the roles granted by the identity provider with the SCIM groups
+type RoleAssignment struct {
+	ID       int
+	UserID   string
+	Role     string
+	TenantID int
+	SiteID   int
+	Created  time.Time
+	Source   string
+}
+
+// RoleSourceOIDC marks the role assignments managed by the OpenID Connect claim mapping,
+// they're added and removed every time the user logs in
+const RoleSourceOIDC = "oidc"
+
+// RoleSourceSCIM marks the role assignments managed by the identity provider with the SCIM
+// groups, the identity provider can't change the roles assigned by other sources
+const RoleSourceSCIM = "scim"
+
+// AuditEntry records an action done by a console user. TenantID and SiteID are -1
+// for actions in the global configuration, Before and After hold the changed values
+type AuditEntry struct {
+	ID       int
+	Created  time.Time
+	UserID   string
+	IP       string
+	TenantID int
+	SiteID   int
+	Action   string
+	Target   string
+	Before   string
+	After    string
+}
+
+// Webhook sends console events to an HTTP endpoint. Webhooks with a TenantID
+// of -1 receive the events of every tenant and the global ones
+type Webhook struct {
+	ID       int
+	TenantID int
+	Name     string
+	URL      string
+	Secret   string
+	Events   []string
+	Enabled  bool
+	Created  time.Time
+}
+
+// Webhook delivery status
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySending   = "sending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// WebhookDelivery is an event queued to be sent to a webhook, failed requests
+// are retried at NextAttempt until the maximum number of attempts is reached
+type WebhookDelivery struct {
+	ID          int
+	WebhookID   int
+	TenantID    int
+	Event       string
+	Payload     string
+	Status      string
+	Attempts    int
+	NextAttempt time.Time
+	StatusCode  int
+	Error       string
+	Created     time.Time
+	Delivered   time.Time
+}
+
+// AlertRule sends an e-mail to its recipients when the agents of a tenant, or of one of
+// its sites if SiteID isn't -1, match the condition. The meaning of Threshold depends on Type
+type AlertRule struct {
+	ID              int
+	TenantID        int
+	SiteID          int
+	Name            string
+	Type            string
+	Threshold       int
+	Recipients      []string
+	ThrottleMinutes int
+	Enabled         bool
+	LastRun         time.Time
+	Created         time.Time
+}
+
+// Alert history status
+const (
+	AlertSent   = "sent"
+	AlertFailed = "failed"
+)
+
+// AlertHistoryEntry records an alert raised by a rule for a subject, usually an agent
+type AlertHistoryEntry struct {
+	ID         int
+	RuleID     int
+	TenantID   int
+	SiteID     int
+	RuleName   string
+	Type       string
+	Subject    string
+	Hostname   string
+	Message    string
+	Recipients string
+	Status     string
+	Error      string
+	Created    time.Time
+}
+
+// ReportSchedule generates a report with the filters saved when it was created on
+// a cron schedule, and e-mails it or saves it in a folder. Filters is the JSON of
+// the filter used by the report
+type ReportSchedule struct {
+	ID         int
+	TenantID   int
+	SiteID     int
+	Name       string
+	Report     string
+	Format     string
+	Filters    string
+	SortBy     string
+	SortOrder  string
+	Cron       string
+	Delivery   string
+	Recipients []string
+	Folder     string
+	Language   string
+	Enabled    bool
+	LastRun    time.Time
+	LastStatus string
+	LastError  string
+	CreatedBy  string
+	Created    time.Time
+}
+
+// Report schedule last run status
+const (
+	ReportScheduleSucceeded = "succeeded"
+	ReportScheduleFailed    = "failed"
+)
+
+// InventoryChange is an inventory item of an agent that was added, removed or changed
+// between two reports. Type and Action take the values defined in the inventory package
+type InventoryChange struct {
+	ID       int
+	AgentID  string
+	TenantID int
+	SiteID   int
+	Hostname string
+	Type     string
+	Action   string
+	Item     string
+	Before   string
+	After    string
+	Created  time.Time
+}
+
+// SavedView is a named combination of filters, sorting and columns of a list. Query
+// holds the encoded filters and sorting, Columns the columns shown in the computers
+// list. Views are private to the user that saved them unless they're shared with
+// every user of the tenant
+type SavedView struct {
+	ID       int
+	TenantID int
+	UserID   string
+	List     string
+	Name     string
+	Query    string
+	Columns  []string
+	Shared   bool
+	Created  time.Time
+}
+
+// Rollout updates the agents of a tenant to Version in stages. The agents with the tag or
+// in the site identified by PilotValue are updated first, then the rest in Waves, the
+// cumulative percentages of agents updated by each wave. The next wave is sent when
+// SoakMinutes have passed since the current one started, and the rollout is halted if
+// the percentage of agents that failed to update exceeds FailureThreshold
+type Rollout struct {
+	ID               int
+	TenantID         int
+	Name             string
+	Version          string
+	Channel          string
+	PilotType        string
+	PilotValue       int
+	Waves            []int
+	SoakMinutes      int
+	FailureThreshold int
+	Status           string
+	CurrentWave      int
+	WaveStarted      time.Time
+	HaltReason       string
+	Language         string
+	CreatedBy        string
+	Created          time.Time
+}
+
+// RolloutAgent is an agent updated by a rollout, Status takes the values defined in
+// the rollouts package
+type RolloutAgent struct {
+	ID        int
+	RolloutID int
+	AgentID   string
+	Hostname  string
+	Wave      int
+	Status    string
+	Error     string
+	Sent      time.Time
+	Finished  time.Time
+}
+
+// MaintenanceWindow is a weekly recurring period, in a time zone, attached to the site or
+// tag identified by TargetType and TargetID. Days use the numbers of time.Weekday and
+// Start is the number of minutes after midnight
+type MaintenanceWindow struct {
+	ID         int
+	TenantID   int
+	Name       string
+	TargetType string
+	TargetID   int
+	Days       []time.Weekday
+	Start      int
+	Duration   int
+	TimeZone   string
+	Enabled    bool
+	Created    time.Time
+}
+
+// MaintenanceAction is a request for an agent that waits for its next maintenance window.
+// Payload holds the NATS request as JSON and Status takes the values defined in the
+// maintenance package
+type MaintenanceAction struct {
+	ID          int
+	TenantID    int
+	SiteID      int
+	AgentID     string
+	Hostname    string
+	Action      string
+	Description string
+	Payload     string
+	NotBefore   time.Time
+	Status      string
+	Error       string
+	Language    string
+	CreatedBy   string
+	Created     time.Time
+	Sent        time.Time
+}
+
+// DeploymentJob groups the agents a package install or uninstall was requested for at
+// once, Action takes the values defined in the deployjobs package
+type DeploymentJob struct {
+	ID              int
+	TenantID        int
+	SiteID          int
+	Action          string
+	PackageID       string
+	PackageName     string
+	PackageBranch   string
+	PackageBrewType string
+	PackageVerified bool
+	CreatedBy       string
+	Created         time.Time
+}
+
+// DeploymentJobTarget is an agent of a deployment job. MaintenanceActionID is set while
+// the request waits in the maintenance queue and Status takes the values defined in the
+// deployjobs package
+type DeploymentJobTarget struct {
+	ID                  int
+	JobID               int
+	AgentID             string
+	Hostname            string
+	Status              string
+	Error               string
+	MaintenanceActionID int
+	Sent                time.Time
+	Finished            time.Time
+}
+
+// CompliancePolicy lists an application that must be installed, optionally with a minimum
+// version, or that mustn't be installed in the agents of a tenant. Type takes the values
+// defined in the compliance package
+type CompliancePolicy struct {
+	ID               int
+	TenantID         int
+	Name             string
+	Type             string
+	AppPattern       string
+	PublisherPattern string
+	MinVersion       string
+	Enabled          bool
+	Created          time.Time
+}
+
+// ComplianceStatus is the result of the last evaluation of an agent against the policies of
+// its tenant. Violations is the number of ComplianceViolation saved for the agent
+type ComplianceStatus struct {
+	AgentID    string
+	TenantID   int
+	SiteID     int
+	Hostname   string
+	Violations int
+	Evaluated  time.Time
+}
+
+// ComplianceViolation is a policy an agent didn't comply with in its last evaluation. Reason
+// takes the values defined in the compliance package, App and Version are empty when a
+// required application is missing
+type ComplianceViolation struct {
+	ID         int
+	AgentID    string
+	TenantID   int
+	SiteID     int
+	Hostname   string
+	PolicyID   int
+	PolicyName string
+	PolicyType string
+	Reason     string
+	App        string
+	Version    string
+	Evaluated  time.Time
+}
+
+// VulnerabilityFeed is the state of the offline feed the last time it was loaded. Error
+// is set when the feed folder couldn't be read, the previous feed is still used then
+type VulnerabilityFeed struct {
+	Fingerprint     string
+	Files           int
+	Vulnerabilities int
+	Imported        time.Time
+	Error           string
+}
+
+// VulnerabilityStatus is the result of the last scan of an agent. Findings is the number
+// of VulnerabilityFinding saved for the agent
+type VulnerabilityStatus struct {
+	AgentID  string
+	TenantID int
+	SiteID   int
+	Hostname string
+	Findings int
+	Scanned  time.Time
+}
+
+// VulnerabilityFinding is a vulnerability of the feed that affects an application or the
+// operating system of an agent. Kind and Severity take the values defined in the
+// vulnerabilities package
+type VulnerabilityFinding struct {
+	ID              int
+	AgentID         string
+	TenantID        int
+	SiteID          int
+	Hostname        string
+	VulnerabilityID string
+	Summary         string
+	Score           float64
+	Severity        string
+	Kind            string
+	Software        string
+	Version         string
+	FixedVersion    string
+	Published       time.Time
+	Scanned         time.Time
+}
+
+// License is the entitlement of a tenant for a software product, the applications it
+// covers are matched with the patterns like the ones of the compliance policies.
+// Expiry is zero if the license doesn't expire
+type License struct {
+	ID               int
+	TenantID         int
+	Name             string
+	AppPattern       string
+	PublisherPattern string
+	Seats            int
+	Expiry           time.Time
+	CostCenter       string
+	Created          time.Time
+}
+
+// HardwareLifecycle holds the purchase and warranty dates of a computer, which aren't
+// reported by the agent. A zero date means that it's unknown
+type HardwareLifecycle struct {
+	ID                 int
+	AgentID            string
+	TenantID           int
+	PurchaseDate       time.Time
+	WarrantyExpiry     time.Time
+	PlannedReplacement time.Time
+	Updated            time.Time
+}
+
+// Dashboard is a named set of widgets composed by a user. Dashboards are private to the
+// user that created them unless they're shared with every user of the tenant
+type Dashboard struct {
+	ID       int
+	TenantID int
+	UserID   string
+	Name     string
+	Shared   bool
+	Created  time.Time
+}
+
+// DashboardWidget is a widget of a dashboard, shown in Position order. Query holds the
+// encoded filters of the computers list the widget is computed over, Dimension the one
+// the computers are broken down by and Limit how many of its values are shown
+type DashboardWidget struct {
+	ID          int
+	DashboardID int
+	Position    int
+	Title       string
+	Kind        string
+	Dimension   string
+	Query       string
+	Limit       int
+}
+
+// FleetMetrics is the daily snapshot of the fleet counters of a tenant, or of one of its
+// sites. SiteID is -1 for the snapshot of the whole tenant
+type FleetMetrics struct {
+	ID                int
+	TenantID          int
+	SiteID            int
+	Day               time.Time
+	Agents            int
+	Reported24h       int
+	PendingUpdates    int
+	DisabledAntivirus int
+	OutdatedAgents    int
+}
+
+// WebAuthnCredential is a passkey or security key registered by a user. CredentialID is
+// the base64url encoded id of the credential and Data the JSON encoded credential as it's
+// needed to verify the assertions signed with it
+type WebAuthnCredential struct {
+	ID           int
+	UserID       string
+	Name         string
+	CredentialID string
+	Data         string
+	Created      time.Time
+	LastUsed     time.Time
+}
+
+// LDAPSettings is the configuration of the LDAP or Active Directory server console users
+// can sign in with. BindPassword is encrypted with the encryption master key. When Sync
+// is set the console users are created, updated and disabled to match the members of
+// the directory that match GroupFilter
+type LDAPSettings struct {
+	Enabled            bool
+	URL                string
+	StartTLS           bool
+	InsecureSkipVerify bool
+	BindDN             string
+	BindPassword       string
+	SearchBase         string
+	UserFilter         string
+	GroupFilter        string
+	UsernameAttribute  string
+	NameAttribute      string
+	EmailAttribute     string
+	PhoneAttribute     string
+	Sync               bool
+	LastSync           time.Time
+	LastSyncResult     LDAPSyncResult
+}
+
+// LDAPSyncResult counts the users changed by the last sync with the directory. Skipped
+// users exist in the console but aren't managed by the directory
+type LDAPSyncResult struct {
+	Created  int    `json:"created"`
+	Updated  int    `json:"updated"`
+	Disabled int    `json:"disabled"`
+	Skipped  int    `json:"skipped"`
+	Error    string `json:"error,omitempty"`
+}
+
+// DirectoryUser is a console user managed by the LDAP directory. Disabled users are no
+// longer allowed to use the console by the directory and can't sign in
+type DirectoryUser struct {
+	ID       int
+	UserID   string
+	DN       string
+	Disabled bool
+	Synced   time.Time
+}
+
+// SCIMSettings holds the configuration of the SCIM provisioning endpoint
+type SCIMSettings struct {
+	Enabled      bool
+	TokenHash    string
+	TokenCreated time.Time
+	LastUsed     time.Time
+}
+
+// SCIMUser is a console user managed by an identity provider with SCIM. Users that
+// aren't active have been deactivated by the identity provider and can't sign in
+type SCIMUser struct {
+	ID         int
+	UserID     string
+	ExternalID string
+	Active     bool
+	Created    time.Time
+	Modified   time.Time
+}
+
+// OIDCClaimSettings tells which claim of the OpenID Connect provider holds the groups or
+// roles of the user. When DenyUnmapped is set users without a mapped value can't log in
+type OIDCClaimSettings struct {
+	Enabled      bool
+	Claim        string
+	DenyUnmapped bool
+}
+
+// OIDCRoleMapping grants a role to the users that have Value in the claim, TenantID and
+// SiteID are -1 to grant the role in every tenant or site
+type OIDCRoleMapping struct {
+	ID       int
+	Value    string
+	Role     string
+	TenantID int
+	SiteID   int
+	Created  time.Time
+}
+
+// SessionInfo records the device a session is used from. Sessions are identified by the
+// SHA-256 hash of their token so the token itself is never stored twice
+type SessionInfo struct {
+	ID        int
+	TokenHash string
+	UserID    string
+	UserAgent string
+	IPAddress string
+	Created   time.Time
+	LastSeen  time.Time
+}
+
+// UserSession is a live session of a user. Token is the key used by the session store, it's
+// encrypted when an encryption master key is used. Info is empty for the sessions that haven't
+// been used since the console started recording them
+type UserSession struct {
+	Token     string
+	TokenHash string
+	Expiry    time.Time
+	Info      SessionInfo
+}
@@ -0,0 +1,862 @@
+// Package consoledb holds the tables owned by the console and the records stored in them.
+// The shared ent schema (github.com/open-uem/ent) is used by every OpenUEM component, so
+// the tables only used by the console are declared here and migrated separately.
+// Table names use the console_ prefix to avoid collisions with the shared schema.
+package consoledb
+
+import (
+	"entgo.io/ent/dialect/sql/schema"
+	"entgo.io/ent/schema/field"
+)
+
+var (
+	// APITokensColumns holds the columns for the "console_api_tokens" table.
+	APITokensColumns = []*schema.Column{
+		{Name: "id", Type: field.TypeInt, Increment: true},
+		{Name: "user_id", Type: field.TypeString},
+		{Name: "name", Type: field.TypeString},
+		{Name: "prefix", Type: field.TypeString, Unique: true},
+		{Name: "hash", Type: field.TypeString},
+		{Name: "tenant_id", Type: field.TypeInt, Default: -1},
+		{Name: "created", Type: field.TypeTime},
+		{Name: "expiry", Type: field.TypeTime},
+		{Name: "last_used", Type: field.TypeTime, Nullable: true},
+	}
+	// APITokensTable holds the schema information for the "console_api_tokens" table.
+	APITokensTable = &schema.Table{
+		Name:       "console_api_tokens",
+		Columns:    APITokensColumns,
+		PrimaryKey: []*schema.Column{APITokensColumns[0]},
+		Indexes: []*schema.Index{
+			{Name: "console_api_tokens_user_id", Columns: []*schema.Column{APITokensColumns[1]}},
+		},
+	}
+	// RoleAssignmentsColumns holds the columns for the "console_role_assignments" table.
+	RoleAssignmentsColumns = []*schema.Column{
+		{Name: "id", Type: field.TypeInt, Increment: true},
+		{Name: "user_id", Type: field.TypeString},
+		{Name: "role", Type: field.TypeString},
+		{Name: "tenant_id", Type: field.TypeInt, Default: -1},
+		{Name: "site_id", Type: field.TypeInt, Default: -1},
+		{Name: "created", Type: field.TypeTime},
+		{Name: "source", Type: field.TypeString, Default: ""},
+	}
+	// RoleAssignmentsTable holds the schema information for the "console_role_assignments" table.
+	RoleAssignmentsTable = &schema.Table{
+		Name:       "console_role_assignments",
+		Columns:    RoleAssignmentsColumns,
+		PrimaryKey: []*schema.Column{RoleAssignmentsColumns[0]},
+		Indexes: []*schema.Index{
+			{Name: "console_role_assignments_user_id_role_tenant_id_site_id", Unique: true, Columns: []*schema.Column{RoleAssignmentsColumns[1], RoleAssignmentsColumns[2], RoleAssignmentsColumns[3], RoleAssignmentsColumns[4]}},
+		},
+	}
+	// AuditLogColumns holds the columns for the "console_audit_log" table.
+	AuditLogColumns = []*schema.Column{
+		{Name: "id", Type: field.TypeInt, Increment: true},
+		{Name: "created", Type: field.TypeTime},
+		{Name: "user_id", Type: field.TypeString},
+		{Name: "ip", Type: field.TypeString, Default: ""},
+		{Name: "tenant_id", Type: field.TypeInt, Default: -1},
+		{Name: "site_id", Type: field.TypeInt, Default: -1},
+		{Name: "action", Type: field.TypeString},
+		{Name: "target", Type: field.TypeString, Default: ""},
+		{Name: "before_value", Type: field.TypeString, Size: 2147483647, Default: ""},
+		{Name: "after_value", Type: field.TypeString, Size: 2147483647, Default: ""},
+	}
+	// AuditLogTable holds the schema information for the "console_audit_log" table.
+	AuditLogTable = &schema.Table{
+		Name:       "console_audit_log",
+		Columns:    AuditLogColumns,
+		PrimaryKey: []*schema.Column{AuditLogColumns[0]},
+		Indexes: []*schema.Index{
+			{Name: "console_audit_log_created", Columns: []*schema.Column{AuditLogColumns[1]}},
+			{Name: "console_audit_log_target", Columns: []*schema.Column{AuditLogColumns[7]}},
+		},
+	}
+	// WebhooksColumns holds the columns for the "console_webhooks" table.
+	WebhooksColumns = []*schema.Column{
+		{Name: "id", Type: field.TypeInt, Increment: true},
+		{Name: "tenant_id", Type: field.TypeInt, Default: -1},
+		{Name: "name", Type: field.TypeString},
+		{Name: "url", Type: field.TypeString, Size: 2048},
+		{Name: "secret", Type: field.TypeString, Size: 2048},
+		{Name: "events", Type: field.TypeString, Size: 2048, Default: ""},
+		{Name: "enabled", Type: field.TypeBool, Default: true},
+		{Name: "created", Type: field.TypeTime},
+	}
+	// WebhooksTable holds the schema information for the "console_webhooks" table.
+	WebhooksTable = &schema.Table{
+		Name:       "console_webhooks",
+		Columns:    WebhooksColumns,
+		PrimaryKey: []*schema.Column{WebhooksColumns[0]},
+		Indexes: []*schema.Index{
+			{Name: "console_webhooks_tenant_id", Columns: []*schema.Column{WebhooksColumns[1]}},
+		},
+	}
+	// WebhookDeliveriesColumns holds the columns for the "console_webhook_deliveries" table.
+	WebhookDeliveriesColumns = []*schema.Column{
+		{Name: "id", Type: field.TypeInt, Increment: true},
+		{Name: "webhook_id", Type: field.TypeInt},
+		{Name: "tenant_id", Type: field.TypeInt, Default: -1},
+		{Name: "event", Type: field.TypeString},
+		{Name: "payload", Type: field.TypeString, Size: 2147483647},
+		{Name: "status", Type: field.TypeString},
+		{Name: "attempts", Type: field.TypeInt, Default: 0},
+		{Name: "next_attempt", Type: field.TypeTime},
+		{Name: "status_code", Type: field.TypeInt, Default: 0},
+		{Name: "error", Type: field.TypeString, Size: 2048, Default: ""},
+		{Name: "created", Type: field.TypeTime},
+		{Name: "delivered", Type: field.TypeTime, Nullable: true},
+	}
+	// WebhookDeliveriesTable holds the schema information for the "console_webhook_deliveries" table.
+	WebhookDeliveriesTable = &schema.Table{
+		Name:       "console_webhook_deliveries",
+		Columns:    WebhookDeliveriesColumns,
+		PrimaryKey: []*schema.Column{WebhookDeliveriesColumns[0]},
+		Indexes: []*schema.Index{
+			{Name: "console_webhook_deliveries_status_next_attempt", Columns: []*schema.Column{WebhookDeliveriesColumns[5], WebhookDeliveriesColumns[7]}},
+			{Name: "console_webhook_deliveries_tenant_id", Columns: []*schema.Column{WebhookDeliveriesColumns[2]}},
+		},
+	}
+	// WebhookStatesColumns holds the columns for the "console_webhook_states" table.
+	WebhookStatesColumns = []*schema.Column{
+		{Name: "id", Type: field.TypeInt, Increment: true},
+		{Name: "tenant_id", Type: field.TypeInt, Default: -1},
+		{Name: "event", Type: field.TypeString},
+		{Name: "subject", Type: field.TypeString},
+		{Name: "created", Type: field.TypeTime},
+	}
+	// WebhookStatesTable holds the schema information for the "console_webhook_states" table.
+	WebhookStatesTable = &schema.Table{
+		Name:       "console_webhook_states",
+		Columns:    WebhookStatesColumns,
+		PrimaryKey: []*schema.Column{WebhookStatesColumns[0]},
+		Indexes: []*schema.Index{
+			{Name: "console_webhook_states_tenant_id_event_subject", Unique: true, Columns: []*schema.Column{WebhookStatesColumns[1], WebhookStatesColumns[2], WebhookStatesColumns[3]}},
+		},
+	}
+	// AlertRulesColumns holds the columns for the "console_alert_rules" table.
+	AlertRulesColumns = []*schema.Column{
+		{Name: "id", Type: field.TypeInt, Increment: true},
+		{Name: "tenant_id", Type: field.TypeInt},
+		{Name: "site_id", Type: field.TypeInt, Default: -1},
+		{Name: "name", Type: field.TypeString},
+		{Name: "type", Type: field.TypeString},
+		{Name: "threshold", Type: field.TypeInt, Default: 0},
+		{Name: "recipients", Type: field.TypeString, Size: 2048},
+		{Name: "throttle_minutes", Type: field.TypeInt, Default: 1440},
+		{Name: "enabled", Type: field.TypeBool, Default: true},
+		{Name: "last_run", Type: field.TypeTime, Nullable: true},
+		{Name: "created", Type: field.TypeTime},
+	}
+	// AlertRulesTable holds the schema information for the "console_alert_rules" table.
+	AlertRulesTable = &schema.Table{
+		Name:       "console_alert_rules",
+		Columns:    AlertRulesColumns,
+		PrimaryKey: []*schema.Column{AlertRulesColumns[0]},
+		Indexes: []*schema.Index{
+			{Name: "console_alert_rules_tenant_id", Columns: []*schema.Column{AlertRulesColumns[1]}},
+		},
+	}
+	// AlertHistoryColumns holds the columns for the "console_alert_history" table.
+	AlertHistoryColumns = []*schema.Column{
+		{Name: "id", Type: field.TypeInt, Increment: true},
+		{Name: "rule_id", Type: field.TypeInt},
+		{Name: "tenant_id", Type: field.TypeInt},
+		{Name: "site_id", Type: field.TypeInt, Default: -1},
+		{Name: "rule_name", Type: field.TypeString},
+		{Name: "type", Type: field.TypeString},
+		{Name: "subject", Type: field.TypeString},
+		{Name: "hostname", Type: field.TypeString, Default: ""},
+		{Name: "message", Type: field.TypeString, Size: 2048, Default: ""},
+		{Name: "recipients", Type: field.TypeString, Size: 2048, Default: ""},
+		{Name: "status", Type: field.TypeString},
+		{Name: "error", Type: field.TypeString, Size: 2048, Default: ""},
+		{Name: "created", Type: field.TypeTime},
+	}
+	// AlertHistoryTable holds the schema information for the "console_alert_history" table.
+	AlertHistoryTable = &schema.Table{
+		Name:       "console_alert_history",
+		Columns:    AlertHistoryColumns,
+		PrimaryKey: []*schema.Column{AlertHistoryColumns[0]},
+		Indexes: []*schema.Index{
+			{Name: "console_alert_history_rule_id_subject_created", Columns: []*schema.Column{AlertHistoryColumns[1], AlertHistoryColumns[6], AlertHistoryColumns[12]}},
+			{Name: "console_alert_history_tenant_id_created", Columns: []*schema.Column{AlertHistoryColumns[2], AlertHistoryColumns[12]}},
+		},
+	}
+	// ReportSchedulesColumns holds the columns for the "console_report_schedules" table.
+	ReportSchedulesColumns = []*schema.Column{
+		{Name: "id", Type: field.TypeInt, Increment: true},
+		{Name: "tenant_id", Type: field.TypeInt},
+		{Name: "site_id", Type: field.TypeInt, Default: -1},
+		{Name: "name", Type: field.TypeString},
+		{Name: "report", Type: field.TypeString},
+		{Name: "format", Type: field.TypeString},
+		{Name: "filters", Type: field.TypeString, Size: 8192, Default: "{}"},
+		{Name: "sort_by", Type: field.TypeString, Default: ""},
+		{Name: "sort_order", Type: field.TypeString, Default: ""},
+		{Name: "cron", Type: field.TypeString},
+		{Name: "delivery", Type: field.TypeString},
+		{Name: "recipients", Type: field.TypeString, Size: 2048, Default: ""},
+		{Name: "folder", Type: field.TypeString, Default: ""},
+		{Name: "language", Type: field.TypeString, Default: "en"},
+		{Name: "enabled", Type: field.TypeBool, Default: true},
+		{Name: "last_run", Type: field.TypeTime, Nullable: true},
+		{Name: "last_status", Type: field.TypeString, Default: ""},
+		{Name: "last_error", Type: field.TypeString, Size: 2048, Default: ""},
+		{Name: "created_by", Type: field.TypeString, Default: ""},
+		{Name: "created", Type: field.TypeTime},
+	}
+	// ReportSchedulesTable holds the schema information for the "console_report_schedules" table.
+	ReportSchedulesTable = &schema.Table{
+		Name:       "console_report_schedules",
+		Columns:    ReportSchedulesColumns,
+		PrimaryKey: []*schema.Column{ReportSchedulesColumns[0]},
+		Indexes: []*schema.Index{
+			{Name: "console_report_schedules_tenant_id", Columns: []*schema.Column{ReportSchedulesColumns[1]}},
+		},
+	}
+	// InventorySnapshotsColumns holds the columns for the "console_inventory_snapshots" table.
+	InventorySnapshotsColumns = []*schema.Column{
+		{Name: "id", Type: field.TypeInt, Increment: true},
+		{Name: "agent_id", Type: field.TypeString, Unique: true},
+		{Name: "hash", Type: field.TypeString},
+		{Name: "data", Type: field.TypeString, Size: 2147483647},
+		{Name: "updated", Type: field.TypeTime},
+	}
+	// InventorySnapshotsTable holds the schema information for the "console_inventory_snapshots" table.
+	InventorySnapshotsTable = &schema.Table{
+		Name:       "console_inventory_snapshots",
+		Columns:    InventorySnapshotsColumns,
+		PrimaryKey: []*schema.Column{InventorySnapshotsColumns[0]},
+	}
+	// InventoryChangesColumns holds the columns for the "console_inventory_changes" table.
+	InventoryChangesColumns = []*schema.Column{
+		{Name: "id", Type: field.TypeInt, Increment: true},
+		{Name: "agent_id", Type: field.TypeString},
+		{Name: "tenant_id", Type: field.TypeInt, Default: -1},
+		{Name: "site_id", Type: field.TypeInt, Default: -1},
+		{Name: "hostname", Type: field.TypeString, Default: ""},
+		{Name: "type", Type: field.TypeString},
+		{Name: "action", Type: field.TypeString},
+		{Name: "item", Type: field.TypeString, Size: 1024},
+		{Name: "before_value", Type: field.TypeString, Size: 2048, Default: ""},
+		{Name: "after_value", Type: field.TypeString, Size: 2048, Default: ""},
+		{Name: "created", Type: field.TypeTime},
+	}
+	// InventoryChangesTable holds the schema information for the "console_inventory_changes" table.
+	InventoryChangesTable = &schema.Table{
+		Name:       "console_inventory_changes",
+		Columns:    InventoryChangesColumns,
+		PrimaryKey: []*schema.Column{InventoryChangesColumns[0]},
+		Indexes: []*schema.Index{
+			{Name: "console_inventory_changes_agent_id_created", Columns: []*schema.Column{InventoryChangesColumns[1], InventoryChangesColumns[10]}},
+			{Name: "console_inventory_changes_tenant_id_created", Columns: []*schema.Column{InventoryChangesColumns[2], InventoryChangesColumns[10]}},
+		},
+	}
+	// SavedViewsColumns holds the columns for the "console_saved_views" table.
+	SavedViewsColumns = []*schema.Column{
+		{Name: "id", Type: field.TypeInt, Increment: true},
+		{Name: "tenant_id", Type: field.TypeInt},
+		{Name: "user_id", Type: field.TypeString},
+		{Name: "list", Type: field.TypeString},
+		{Name: "name", Type: field.TypeString},
+		{Name: "query", Type: field.TypeString, Size: 8192, Default: ""},
+		{Name: "columns", Type: field.TypeString, Size: 2048, Default: ""},
+		{Name: "shared", Type: field.TypeBool, Default: false},
+		{Name: "created", Type: field.TypeTime},
+	}
+	// SavedViewsTable holds the schema information for the "console_saved_views" table.
+	SavedViewsTable = &schema.Table{
+		Name:       "console_saved_views",
+		Columns:    SavedViewsColumns,
+		PrimaryKey: []*schema.Column{SavedViewsColumns[0]},
+		Indexes: []*schema.Index{
+			{Name: "console_saved_views_tenant_id_list", Columns: []*schema.Column{SavedViewsColumns[1], SavedViewsColumns[3]}},
+		},
+	}
+	// SavedViewDefaultsColumns holds the columns for the "console_saved_view_defaults" table.
+	SavedViewDefaultsColumns = []*schema.Column{
+		{Name: "id", Type: field.TypeInt, Increment: true},
+		{Name: "tenant_id", Type: field.TypeInt},
+		{Name: "user_id", Type: field.TypeString},
+		{Name: "list", Type: field.TypeString},
+		{Name: "view_id", Type: field.TypeInt},
+	}
+	// SavedViewDefaultsTable holds the schema information for the "console_saved_view_defaults" table.
+	SavedViewDefaultsTable = &schema.Table{
+		Name:       "console_saved_view_defaults",
+		Columns:    SavedViewDefaultsColumns,
+		PrimaryKey: []*schema.Column{SavedViewDefaultsColumns[0]},
+		Indexes: []*schema.Index{
+			{Name: "console_saved_view_defaults_tenant_id_user_id_list", Unique: true, Columns: []*schema.Column{SavedViewDefaultsColumns[1], SavedViewDefaultsColumns[2], SavedViewDefaultsColumns[3]}},
+		},
+	}
+	// RolloutsColumns holds the columns for the "console_rollouts" table.
+	RolloutsColumns = []*schema.Column{
+		{Name: "id", Type: field.TypeInt, Increment: true},
+		{Name: "tenant_id", Type: field.TypeInt},
+		{Name: "name", Type: field.TypeString},
+		{Name: "version", Type: field.TypeString},
+		{Name: "channel", Type: field.TypeString},
+		{Name: "pilot_type", Type: field.TypeString},
+		{Name: "pilot_value", Type: field.TypeInt},
+		{Name: "waves", Type: field.TypeString},
+		{Name: "soak_minutes", Type: field.TypeInt, Default: 0},
+		{Name: "failure_threshold", Type: field.TypeInt, Default: 0},
+		{Name: "status", Type: field.TypeString},
+		{Name: "current_wave", Type: field.TypeInt, Default: 0},
+		{Name: "wave_started", Type: field.TypeTime},
+		{Name: "halt_reason", Type: field.TypeString, Size: 2048, Default: ""},
+		{Name: "language", Type: field.TypeString, Default: "en"},
+		{Name: "created_by", Type: field.TypeString, Default: ""},
+		{Name: "created", Type: field.TypeTime},
+	}
+	// RolloutsTable holds the schema information for the "console_rollouts" table.
+	RolloutsTable = &schema.Table{
+		Name:       "console_rollouts",
+		Columns:    RolloutsColumns,
+		PrimaryKey: []*schema.Column{RolloutsColumns[0]},
+		Indexes: []*schema.Index{
+			{Name: "console_rollouts_tenant_id", Columns: []*schema.Column{RolloutsColumns[1]}},
+			{Name: "console_rollouts_status", Columns: []*schema.Column{RolloutsColumns[10]}},
+		},
+	}
+	// RolloutAgentsColumns holds the columns for the "console_rollout_agents" table.
+	RolloutAgentsColumns = []*schema.Column{
+		{Name: "id", Type: field.TypeInt, Increment: true},
+		{Name: "rollout_id", Type: field.TypeInt},
+		{Name: "agent_id", Type: field.TypeString},
+		{Name: "hostname", Type: field.TypeString, Default: ""},
+		{Name: "wave", Type: field.TypeInt},
+		{Name: "status", Type: field.TypeString},
+		{Name: "error", Type: field.TypeString, Size: 2048, Default: ""},
+		{Name: "sent", Type: field.TypeTime, Nullable: true},
+		{Name: "finished", Type: field.TypeTime, Nullable: true},
+	}
+	// RolloutAgentsTable holds the schema information for the "console_rollout_agents" table.
+	RolloutAgentsTable = &schema.Table{
+		Name:       "console_rollout_agents",
+		Columns:    RolloutAgentsColumns,
+		PrimaryKey: []*schema.Column{RolloutAgentsColumns[0]},
+		Indexes: []*schema.Index{
+			{Name: "console_rollout_agents_rollout_id_agent_id", Unique: true, Columns: []*schema.Column{RolloutAgentsColumns[1], RolloutAgentsColumns[2]}},
+			{Name: "console_rollout_agents_rollout_id_wave_status", Columns: []*schema.Column{RolloutAgentsColumns[1], RolloutAgentsColumns[4], RolloutAgentsColumns[5]}},
+		},
+	}
+	// MaintenanceWindowsColumns holds the columns for the "console_maintenance_windows" table.
+	MaintenanceWindowsColumns = []*schema.Column{
+		{Name: "id", Type: field.TypeInt, Increment: true},
+		{Name: "tenant_id", Type: field.TypeInt},
+		{Name: "name", Type: field.TypeString},
+		{Name: "target_type", Type: field.TypeString},
+		{Name: "target_id", Type: field.TypeInt},
+		{Name: "days", Type: field.TypeString},
+		{Name: "start_minute", Type: field.TypeInt},
+		{Name: "duration_minutes", Type: field.TypeInt},
+		{Name: "timezone", Type: field.TypeString},
+		{Name: "enabled", Type: field.TypeBool, Default: true},
+		{Name: "created", Type: field.TypeTime},
+	}
+	// MaintenanceWindowsTable holds the schema information for the "console_maintenance_windows" table.
+	MaintenanceWindowsTable = &schema.Table{
+		Name:       "console_maintenance_windows",
+		Columns:    MaintenanceWindowsColumns,
+		PrimaryKey: []*schema.Column{MaintenanceWindowsColumns[0]},
+		Indexes: []*schema.Index{
+			{Name: "console_maintenance_windows_tenant_id_target", Columns: []*schema.Column{MaintenanceWindowsColumns[1], MaintenanceWindowsColumns[3], MaintenanceWindowsColumns[4]}},
+		},
+	}
+	// MaintenanceQueueColumns holds the columns for the "console_maintenance_queue" table.
+	MaintenanceQueueColumns = []*schema.Column{
+		{Name: "id", Type: field.TypeInt, Increment: true},
+		{Name: "tenant_id", Type: field.TypeInt},
+		{Name: "site_id", Type: field.TypeInt},
+		{Name: "agent_id", Type: field.TypeString},
+		{Name: "hostname", Type: field.TypeString, Default: ""},
+		{Name: "action", Type: field.TypeString},
+		{Name: "description", Type: field.TypeString, Size: 1024, Default: ""},
+		{Name: "payload", Type: field.TypeString, Size: 2147483647},
+		{Name: "not_before", Type: field.TypeTime},
+		{Name: "status", Type: field.TypeString},
+		{Name: "error", Type: field.TypeString, Size: 2048, Default: ""},
+		{Name: "language", Type: field.TypeString, Default: "en"},
+		{Name: "created_by", Type: field.TypeString, Default: ""},
+		{Name: "created", Type: field.TypeTime},
+		{Name: "sent", Type: field.TypeTime, Nullable: true},
+	}
+	// MaintenanceQueueTable holds the schema information for the "console_maintenance_queue" table.
+	MaintenanceQueueTable = &schema.Table{
+		Name:       "console_maintenance_queue",
+		Columns:    MaintenanceQueueColumns,
+		PrimaryKey: []*schema.Column{MaintenanceQueueColumns[0]},
+		Indexes: []*schema.Index{
+			{Name: "console_maintenance_queue_status_not_before", Columns: []*schema.Column{MaintenanceQueueColumns[9], MaintenanceQueueColumns[8]}},
+			{Name: "console_maintenance_queue_tenant_id_site_id", Columns: []*schema.Column{MaintenanceQueueColumns[1], MaintenanceQueueColumns[2]}},
+		},
+	}
+	// DeploymentJobsColumns holds the columns for the "console_deployment_jobs" table.
+	DeploymentJobsColumns = []*schema.Column{
+		{Name: "id", Type: field.TypeInt, Increment: true},
+		{Name: "tenant_id", Type: field.TypeInt},
+		{Name: "site_id", Type: field.TypeInt, Default: -1},
+		{Name: "action", Type: field.TypeString},
+		{Name: "package_id", Type: field.TypeString},
+		{Name: "package_name", Type: field.TypeString},
+		{Name: "package_branch", Type: field.TypeString, Default: ""},
+		{Name: "package_brew_type", Type: field.TypeString, Default: ""},
+		{Name: "package_verified", Type: field.TypeBool, Default: false},
+		{Name: "created_by", Type: field.TypeString, Default: ""},
+		{Name: "created", Type: field.TypeTime},
+	}
+	// DeploymentJobsTable holds the schema information for the "console_deployment_jobs" table.
+	DeploymentJobsTable = &schema.Table{
+		Name:       "console_deployment_jobs",
+		Columns:    DeploymentJobsColumns,
+		PrimaryKey: []*schema.Column{DeploymentJobsColumns[0]},
+		Indexes: []*schema.Index{
+			{Name: "console_deployment_jobs_tenant_id_site_id", Columns: []*schema.Column{DeploymentJobsColumns[1], DeploymentJobsColumns[2]}},
+		},
+	}
+	// DeploymentJobTargetsColumns holds the columns for the "console_deployment_job_targets" table.
+	DeploymentJobTargetsColumns = []*schema.Column{
+		{Name: "id", Type: field.TypeInt, Increment: true},
+		{Name: "job_id", Type: field.TypeInt},
+		{Name: "agent_id", Type: field.TypeString},
+		{Name: "hostname", Type: field.TypeString, Default: ""},
+		{Name: "status", Type: field.TypeString},
+		{Name: "error", Type: field.TypeString, Size: 2048, Default: ""},
+		{Name: "maintenance_action_id", Type: field.TypeInt, Default: 0},
+		{Name: "sent", Type: field.TypeTime, Nullable: true},
+		{Name: "finished", Type: field.TypeTime, Nullable: true},
+	}
+	// DeploymentJobTargetsTable holds the schema information for the "console_deployment_job_targets" table.
+	DeploymentJobTargetsTable = &schema.Table{
+		Name:       "console_deployment_job_targets",
+		Columns:    DeploymentJobTargetsColumns,
+		PrimaryKey: []*schema.Column{DeploymentJobTargetsColumns[0]},
+		Indexes: []*schema.Index{
+			{Name: "console_deployment_job_targets_job_id_agent_id", Unique: true, Columns: []*schema.Column{DeploymentJobTargetsColumns[1], DeploymentJobTargetsColumns[2]}},
+			{Name: "console_deployment_job_targets_status", Columns: []*schema.Column{DeploymentJobTargetsColumns[4]}},
+		},
+	}
+	// CompliancePoliciesColumns holds the columns for the "console_compliance_policies" table.
+	CompliancePoliciesColumns = []*schema.Column{
+		{Name: "id", Type: field.TypeInt, Increment: true},
+		{Name: "tenant_id", Type: field.TypeInt},
+		{Name: "name", Type: field.TypeString},
+		{Name: "type", Type: field.TypeString},
+		{Name: "app_pattern", Type: field.TypeString, Size: 512},
+		{Name: "publisher_pattern", Type: field.TypeString, Size: 512, Default: ""},
+		{Name: "min_version", Type: field.TypeString, Default: ""},
+		{Name: "enabled", Type: field.TypeBool, Default: true},
+		{Name: "created", Type: field.TypeTime},
+	}
+	// CompliancePoliciesTable holds the schema information for the "console_compliance_policies" table.
+	CompliancePoliciesTable = &schema.Table{
+		Name:       "console_compliance_policies",
+		Columns:    CompliancePoliciesColumns,
+		PrimaryKey: []*schema.Column{CompliancePoliciesColumns[0]},
+		Indexes: []*schema.Index{
+			{Name: "console_compliance_policies_tenant_id", Columns: []*schema.Column{CompliancePoliciesColumns[1]}},
+		},
+	}
+	// ComplianceStatusColumns holds the columns for the "console_compliance_status" table.
+	ComplianceStatusColumns = []*schema.Column{
+		{Name: "id", Type: field.TypeInt, Increment: true},
+		{Name: "agent_id", Type: field.TypeString, Unique: true},
+		{Name: "tenant_id", Type: field.TypeInt},
+		{Name: "site_id", Type: field.TypeInt, Default: -1},
+		{Name: "hostname", Type: field.TypeString, Default: ""},
+		{Name: "violations", Type: field.TypeInt, Default: 0},
+		{Name: "evaluated", Type: field.TypeTime},
+	}
+	// ComplianceStatusTable holds the schema information for the "console_compliance_status" table.
+	ComplianceStatusTable = &schema.Table{
+		Name:       "console_compliance_status",
+		Columns:    ComplianceStatusColumns,
+		PrimaryKey: []*schema.Column{ComplianceStatusColumns[0]},
+		Indexes: []*schema.Index{
+			{Name: "console_compliance_status_tenant_id_site_id", Columns: []*schema.Column{ComplianceStatusColumns[2], ComplianceStatusColumns[3]}},
+		},
+	}
+	// ComplianceViolationsColumns holds the columns for the "console_compliance_violations" table.
+	ComplianceViolationsColumns = []*schema.Column{
+		{Name: "id", Type: field.TypeInt, Increment: true},
+		{Name: "agent_id", Type: field.TypeString},
+		{Name: "tenant_id", Type: field.TypeInt},
+		{Name: "site_id", Type: field.TypeInt, Default: -1},
+		{Name: "hostname", Type: field.TypeString, Default: ""},
+		{Name: "policy_id", Type: field.TypeInt},
+		{Name: "policy_name", Type: field.TypeString},
+		{Name: "policy_type", Type: field.TypeString},
+		{Name: "reason", Type: field.TypeString},
+		{Name: "app", Type: field.TypeString, Size: 1024, Default: ""},
+		{Name: "version", Type: field.TypeString, Default: ""},
+		{Name: "evaluated", Type: field.TypeTime},
+	}
+	// ComplianceViolationsTable holds the schema information for the "console_compliance_violations" table.
+	ComplianceViolationsTable = &schema.Table{
+		Name:       "console_compliance_violations",
+		Columns:    ComplianceViolationsColumns,
+		PrimaryKey: []*schema.Column{ComplianceViolationsColumns[0]},
+		Indexes: []*schema.Index{
+			{Name: "console_compliance_violations_agent_id", Columns: []*schema.Column{ComplianceViolationsColumns[1]}},
+			{Name: "console_compliance_violations_tenant_id_site_id", Columns: []*schema.Column{ComplianceViolationsColumns[2], ComplianceViolationsColumns[3]}},
+		},
+	}
+	// VulnerabilityFeedColumns holds the columns for the "console_vulnerability_feed" table.
+	VulnerabilityFeedColumns = []*schema.Column{
+		{Name: "id", Type: field.TypeInt, Increment: true},
+		{Name: "fingerprint", Type: field.TypeString, Default: ""},
+		{Name: "files", Type: field.TypeInt, Default: 0},
+		{Name: "vulnerabilities", Type: field.TypeInt, Default: 0},
+		{Name: "imported", Type: field.TypeTime, Nullable: true},
+		{Name: "error", Type: field.TypeString, Size: 1024, Default: ""},
+	}
+	// VulnerabilityFeedTable holds the schema information for the "console_vulnerability_feed" table.
+	VulnerabilityFeedTable = &schema.Table{
+		Name:       "console_vulnerability_feed",
+		Columns:    VulnerabilityFeedColumns,
+		PrimaryKey: []*schema.Column{VulnerabilityFeedColumns[0]},
+	}
+	// VulnerabilityStatusColumns holds the columns for the "console_vulnerability_status" table.
+	VulnerabilityStatusColumns = []*schema.Column{
+		{Name: "id", Type: field.TypeInt, Increment: true},
+		{Name: "agent_id", Type: field.TypeString, Unique: true},
+		{Name: "tenant_id", Type: field.TypeInt},
+		{Name: "site_id", Type: field.TypeInt, Default: -1},
+		{Name: "hostname", Type: field.TypeString, Default: ""},
+		{Name: "findings", Type: field.TypeInt, Default: 0},
+		{Name: "scanned", Type: field.TypeTime},
+	}
+	// VulnerabilityStatusTable holds the schema information for the "console_vulnerability_status" table.
+	VulnerabilityStatusTable = &schema.Table{
+		Name:       "console_vulnerability_status",
+		Columns:    VulnerabilityStatusColumns,
+		PrimaryKey: []*schema.Column{VulnerabilityStatusColumns[0]},
+		Indexes: []*schema.Index{
+			{Name: "console_vulnerability_status_tenant_id_site_id", Columns: []*schema.Column{VulnerabilityStatusColumns[2], VulnerabilityStatusColumns[3]}},
+		},
+	}
+	// VulnerabilityFindingsColumns holds the columns for the "console_vulnerability_findings" table.
+	VulnerabilityFindingsColumns = []*schema.Column{
+		{Name: "id", Type: field.TypeInt, Increment: true},
+		{Name: "agent_id", Type: field.TypeString},
+		{Name: "tenant_id", Type: field.TypeInt},
+		{Name: "site_id", Type: field.TypeInt, Default: -1},
+		{Name: "hostname", Type: field.TypeString, Default: ""},
+		{Name: "vulnerability_id", Type: field.TypeString},
+		{Name: "summary", Type: field.TypeString, Size: 1024, Default: ""},
+		{Name: "score", Type: field.TypeFloat64, Default: 0},
+		{Name: "severity", Type: field.TypeString},
+		{Name: "kind", Type: field.TypeString},
+		{Name: "software", Type: field.TypeString, Size: 1024},
+		{Name: "version", Type: field.TypeString, Default: ""},
+		{Name: "fixed_version", Type: field.TypeString, Default: ""},
+		{Name: "published", Type: field.TypeTime, Nullable: true},
+		{Name: "scanned", Type: field.TypeTime},
+	}
+	// VulnerabilityFindingsTable holds the schema information for the "console_vulnerability_findings" table.
+	VulnerabilityFindingsTable = &schema.Table{
+		Name:       "console_vulnerability_findings",
+		Columns:    VulnerabilityFindingsColumns,
+		PrimaryKey: []*schema.Column{VulnerabilityFindingsColumns[0]},
+		Indexes: []*schema.Index{
+			{Name: "console_vulnerability_findings_agent_id", Columns: []*schema.Column{VulnerabilityFindingsColumns[1]}},
+			{Name: "console_vulnerability_findings_tenant_id_site_id", Columns: []*schema.Column{VulnerabilityFindingsColumns[2], VulnerabilityFindingsColumns[3]}},
+		},
+	}
+	// LicensesColumns holds the columns for the "console_licenses" table.
+	LicensesColumns = []*schema.Column{
+		{Name: "id", Type: field.TypeInt, Increment: true},
+		{Name: "tenant_id", Type: field.TypeInt},
+		{Name: "name", Type: field.TypeString},
+		{Name: "app_pattern", Type: field.TypeString, Size: 512, Default: ""},
+		{Name: "publisher_pattern", Type: field.TypeString, Size: 512, Default: ""},
+		{Name: "seats", Type: field.TypeInt, Default: 0},
+		{Name: "expiry", Type: field.TypeTime, Nullable: true},
+		{Name: "cost_center", Type: field.TypeString, Default: ""},
+		{Name: "created", Type: field.TypeTime},
+	}
+	// LicensesTable holds the schema information for the "console_licenses" table.
+	LicensesTable = &schema.Table{
+		Name:       "console_licenses",
+		Columns:    LicensesColumns,
+		PrimaryKey: []*schema.Column{LicensesColumns[0]},
+		Indexes: []*schema.Index{
+			{Name: "console_licenses_tenant_id", Columns: []*schema.Column{LicensesColumns[1]}},
+		},
+	}
+	// HardwareLifecycleColumns holds the columns for the "console_hardware_lifecycle" table.
+	HardwareLifecycleColumns = []*schema.Column{
+		{Name: "id", Type: field.TypeInt, Increment: true},
+		{Name: "agent_id", Type: field.TypeString, Unique: true},
+		{Name: "tenant_id", Type: field.TypeInt},
+		{Name: "purchase_date", Type: field.TypeTime, Nullable: true},
+		{Name: "warranty_expiry", Type: field.TypeTime, Nullable: true},
+		{Name: "planned_replacement", Type: field.TypeTime, Nullable: true},
+		{Name: "updated", Type: field.TypeTime},
+	}
+	// HardwareLifecycleTable holds the schema information for the "console_hardware_lifecycle" table.
+	HardwareLifecycleTable = &schema.Table{
+		Name:       "console_hardware_lifecycle",
+		Columns:    HardwareLifecycleColumns,
+		PrimaryKey: []*schema.Column{HardwareLifecycleColumns[0]},
+		Indexes: []*schema.Index{
+			{Name: "console_hardware_lifecycle_tenant_id", Columns: []*schema.Column{HardwareLifecycleColumns[2]}},
+		},
+	}
+	// DashboardsColumns holds the columns for the "console_dashboards" table.
+	DashboardsColumns = []*schema.Column{
+		{Name: "id", Type: field.TypeInt, Increment: true},
+		{Name: "tenant_id", Type: field.TypeInt},
+		{Name: "user_id", Type: field.TypeString},
+		{Name: "name", Type: field.TypeString},
+		{Name: "shared", Type: field.TypeBool, Default: false},
+		{Name: "created", Type: field.TypeTime},
+	}
+	// DashboardsTable holds the schema information for the "console_dashboards" table.
+	DashboardsTable = &schema.Table{
+		Name:       "console_dashboards",
+		Columns:    DashboardsColumns,
+		PrimaryKey: []*schema.Column{DashboardsColumns[0]},
+		Indexes: []*schema.Index{
+			{Name: "console_dashboards_tenant_id_user_id", Columns: []*schema.Column{DashboardsColumns[1], DashboardsColumns[2]}},
+		},
+	}
+	// DashboardWidgetsColumns holds the columns for the "console_dashboard_widgets" table.
+	DashboardWidgetsColumns = []*schema.Column{
+		{Name: "id", Type: field.TypeInt, Increment: true},
+		{Name: "dashboard_id", Type: field.TypeInt},
+		{Name: "position", Type: field.TypeInt},
+		{Name: "title", Type: field.TypeString},
+		{Name: "kind", Type: field.TypeString},
+		{Name: "dimension", Type: field.TypeString, Default: ""},
+		{Name: "query", Type: field.TypeString, Size: 8192, Default: ""},
+		{Name: "max_values", Type: field.TypeInt, Default: 0},
+	}
+	// DashboardWidgetsTable holds the schema information for the "console_dashboard_widgets" table.
+	DashboardWidgetsTable = &schema.Table{
+		Name:       "console_dashboard_widgets",
+		Columns:    DashboardWidgetsColumns,
+		PrimaryKey: []*schema.Column{DashboardWidgetsColumns[0]},
+		Indexes: []*schema.Index{
+			{Name: "console_dashboard_widgets_dashboard_id_position", Columns: []*schema.Column{DashboardWidgetsColumns[1], DashboardWidgetsColumns[2]}},
+		},
+	}
+	// FleetMetricsColumns holds the columns for the "console_fleet_metrics" table.
+	FleetMetricsColumns = []*schema.Column{
+		{Name: "id", Type: field.TypeInt, Increment: true},
+		{Name: "tenant_id", Type: field.TypeInt},
+		{Name: "site_id", Type: field.TypeInt},
+		{Name: "day", Type: field.TypeTime},
+		{Name: "agents", Type: field.TypeInt, Default: 0},
+		{Name: "reported_24h", Type: field.TypeInt, Default: 0},
+		{Name: "pending_updates", Type: field.TypeInt, Default: 0},
+		{Name: "disabled_antivirus", Type: field.TypeInt, Default: 0},
+		{Name: "outdated_agents", Type: field.TypeInt, Default: 0},
+	}
+	// FleetMetricsTable holds the schema information for the "console_fleet_metrics" table.
+	FleetMetricsTable = &schema.Table{
+		Name:       "console_fleet_metrics",
+		Columns:    FleetMetricsColumns,
+		PrimaryKey: []*schema.Column{FleetMetricsColumns[0]},
+		Indexes: []*schema.Index{
+			{Name: "console_fleet_metrics_tenant_id_site_id_day", Unique: true, Columns: []*schema.Column{FleetMetricsColumns[1], FleetMetricsColumns[2], FleetMetricsColumns[3]}},
+		},
+	}
+	// WebAuthnCredentialsColumns holds the columns for the "console_webauthn_credentials" table.
+	WebAuthnCredentialsColumns = []*schema.Column{
+		{Name: "id", Type: field.TypeInt, Increment: true},
+		{Name: "user_id", Type: field.TypeString},
+		{Name: "name", Type: field.TypeString},
+		{Name: "credential_id", Type: field.TypeString, Size: 1024, Unique: true},
+		{Name: "data", Type: field.TypeString, Size: 2147483647},
+		{Name: "created", Type: field.TypeTime},
+		{Name: "last_used", Type: field.TypeTime, Nullable: true},
+	}
+	// WebAuthnCredentialsTable holds the schema information for the "console_webauthn_credentials" table.
+	WebAuthnCredentialsTable = &schema.Table{
+		Name:       "console_webauthn_credentials",
+		Columns:    WebAuthnCredentialsColumns,
+		PrimaryKey: []*schema.Column{WebAuthnCredentialsColumns[0]},
+		Indexes: []*schema.Index{
+			{Name: "console_webauthn_credentials_user_id", Columns: []*schema.Column{WebAuthnCredentialsColumns[1]}},
+		},
+	}
+	// AuthPolicyColumns holds the columns for the "console_auth_policy" table.
+	AuthPolicyColumns = []*schema.Column{
+		{Name: "id", Type: field.TypeInt, Increment: true},
+		{Name: "require_phishing_resistant_admins", Type: field.TypeBool, Default: false},
+		{Name: "max_sessions", Type: field.TypeInt, Default: 0},
+	}
+	// AuthPolicyTable holds the schema information for the "console_auth_policy" table.
+	AuthPolicyTable = &schema.Table{
+		Name:       "console_auth_policy",
+		Columns:    AuthPolicyColumns,
+		PrimaryKey: []*schema.Column{AuthPolicyColumns[0]},
+	}
+	// LDAPSettingsColumns holds the columns for the "console_ldap_settings" table.
+	LDAPSettingsColumns = []*schema.Column{
+		{Name: "id", Type: field.TypeInt, Increment: true},
+		{Name: "enabled", Type: field.TypeBool, Default: false},
+		{Name: "url", Type: field.TypeString, Default: ""},
+		{Name: "start_tls", Type: field.TypeBool, Default: false},
+		{Name: "insecure_skip_verify", Type: field.TypeBool, Default: false},
+		{Name: "bind_dn", Type: field.TypeString, Size: 1024, Default: ""},
+		{Name: "bind_password", Type: field.TypeString, Size: 1024, Default: ""},
+		{Name: "search_base", Type: field.TypeString, Size: 1024, Default: ""},
+		{Name: "user_filter", Type: field.TypeString, Size: 1024, Default: ""},
+		{Name: "group_filter", Type: field.TypeString, Size: 1024, Default: ""},
+		{Name: "username_attribute", Type: field.TypeString, Default: ""},
+		{Name: "name_attribute", Type: field.TypeString, Default: ""},
+		{Name: "email_attribute", Type: field.TypeString, Default: ""},
+		{Name: "phone_attribute", Type: field.TypeString, Default: ""},
+		{Name: "sync", Type: field.TypeBool, Default: false},
+		{Name: "last_sync", Type: field.TypeTime, Nullable: true},
+		{Name: "last_sync_result", Type: field.TypeString, Size: 2048, Default: ""},
+	}
+	// LDAPSettingsTable holds the schema information for the "console_ldap_settings" table.
+	LDAPSettingsTable = &schema.Table{
+		Name:       "console_ldap_settings",
+		Columns:    LDAPSettingsColumns,
+		PrimaryKey: []*schema.Column{LDAPSettingsColumns[0]},
+	}
+	// DirectoryUsersColumns holds the columns for the "console_directory_users" table.
+	DirectoryUsersColumns = []*schema.Column{
+		{Name: "id", Type: field.TypeInt, Increment: true},
+		{Name: "user_id", Type: field.TypeString, Unique: true},
+		{Name: "dn", Type: field.TypeString, Size: 1024},
+		{Name: "disabled", Type: field.TypeBool, Default: false},
+		{Name: "synced", Type: field.TypeTime},
+	}
+	// DirectoryUsersTable holds the schema information for the "console_directory_users" table.
+	DirectoryUsersTable = &schema.Table{
+		Name:       "console_directory_users",
+		Columns:    DirectoryUsersColumns,
+		PrimaryKey: []*schema.Column{DirectoryUsersColumns[0]},
+	}
+	// SCIMSettingsColumns holds the columns for the "console_scim_settings" table.
+	SCIMSettingsColumns = []*schema.Column{
+		{Name: "id", Type: field.TypeInt, Increment: true},
+		{Name: "enabled", Type: field.TypeBool, Default: false},
+		{Name: "token_hash", Type: field.TypeString, Default: ""},
+		{Name: "token_created", Type: field.TypeTime, Nullable: true},
+		{Name: "last_used", Type: field.TypeTime, Nullable: true},
+	}
+	// SCIMSettingsTable holds the schema information for the "console_scim_settings" table.
+	SCIMSettingsTable = &schema.Table{
+		Name:       "console_scim_settings",
+		Columns:    SCIMSettingsColumns,
+		PrimaryKey: []*schema.Column{SCIMSettingsColumns[0]},
+	}
+	// SCIMUsersColumns holds the columns for the "console_scim_users" table.
+	SCIMUsersColumns = []*schema.Column{
+		{Name: "id", Type: field.TypeInt, Increment: true},
+		{Name: "user_id", Type: field.TypeString, Unique: true},
+		{Name: "external_id", Type: field.TypeString, Default: ""},
+		{Name: "active", Type: field.TypeBool, Default: true},
+		{Name: "created", Type: field.TypeTime},
+		{Name: "modified", Type: field.TypeTime},
+	}
+	// SCIMUsersTable holds the schema information for the "console_scim_users" table.
+	SCIMUsersTable = &schema.Table{
+		Name:       "console_scim_users",
+		Columns:    SCIMUsersColumns,
+		PrimaryKey: []*schema.Column{SCIMUsersColumns[0]},
+	}
+	// OIDCClaimSettingsColumns holds the columns for the "console_oidc_claim_settings" table.
+	OIDCClaimSettingsColumns = []*schema.Column{
+		{Name: "id", Type: field.TypeInt, Increment: true},
+		{Name: "enabled", Type: field.TypeBool, Default: false},
+		{Name: "claim", Type: field.TypeString, Default: "groups"},
+		{Name: "deny_unmapped", Type: field.TypeBool, Default: false},
+	}
+	// OIDCClaimSettingsTable holds the schema information for the "console_oidc_claim_settings" table.
+	OIDCClaimSettingsTable = &schema.Table{
+		Name:       "console_oidc_claim_settings",
+		Columns:    OIDCClaimSettingsColumns,
+		PrimaryKey: []*schema.Column{OIDCClaimSettingsColumns[0]},
+	}
+	// OIDCRoleMappingsColumns holds the columns for the "console_oidc_role_mappings" table.
+	OIDCRoleMappingsColumns = []*schema.Column{
+		{Name: "id", Type: field.TypeInt, Increment: true},
+		{Name: "value", Type: field.TypeString},
+		{Name: "role", Type: field.TypeString},
+		{Name: "tenant_id", Type: field.TypeInt, Default: -1},
+		{Name: "site_id", Type: field.TypeInt, Default: -1},
+		{Name: "created", Type: field.TypeTime},
+	}
+	// OIDCRoleMappingsTable holds the schema information for the "console_oidc_role_mappings" table.
+	OIDCRoleMappingsTable = &schema.Table{
+		Name:       "console_oidc_role_mappings",
+		Columns:    OIDCRoleMappingsColumns,
+		PrimaryKey: []*schema.Column{OIDCRoleMappingsColumns[0]},
+		Indexes: []*schema.Index{
+			{Name: "console_oidc_role_mappings_value_role_tenant_id_site_id", Unique: true, Columns: []*schema.Column{OIDCRoleMappingsColumns[1], OIDCRoleMappingsColumns[2], OIDCRoleMappingsColumns[3], OIDCRoleMappingsColumns[4]}},
+		},
+	}
+	// SessionInfoColumns holds the columns for the "console_session_info" table.
+	SessionInfoColumns = []*schema.Column{
+		{Name: "id", Type: field.TypeInt, Increment: true},
+		{Name: "token_hash", Type: field.TypeString, Unique: true},
+		{Name: "user_id", Type: field.TypeString},
+		{Name: "user_agent", Type: field.TypeString, Size: 1024, Default: ""},
+		{Name: "ip_address", Type: field.TypeString, Default: ""},
+		{Name: "created", Type: field.TypeTime},
+		{Name: "last_seen", Type: field.TypeTime},
+	}
+	// SessionInfoTable holds the schema information for the "console_session_info" table.
+	SessionInfoTable = &schema.Table{
+		Name:       "console_session_info",
+		Columns:    SessionInfoColumns,
+		PrimaryKey: []*schema.Column{SessionInfoColumns[0]},
+		Indexes: []*schema.Index{
+			{Name: "console_session_info_user_id", Columns: []*schema.Column{SessionInfoColumns[2]}},
+		},
+	}
+)
+
+// Tables contains the tables owned by the console
+var Tables = []*schema.Table{
+	APITokensTable,
+	RoleAssignmentsTable,
+	AuditLogTable,
+	WebhooksTable,
+	WebhookDeliveriesTable,
+	WebhookStatesTable,
+	AlertRulesTable,
+	AlertHistoryTable,
+	ReportSchedulesTable,
+	InventorySnapshotsTable,
+	InventoryChangesTable,
+	SavedViewsTable,
+	SavedViewDefaultsTable,
+	RolloutsTable,
+	RolloutAgentsTable,
+	MaintenanceWindowsTable,
+	MaintenanceQueueTable,
+	DeploymentJobsTable,
+	DeploymentJobTargetsTable,
+	CompliancePoliciesTable,
+	ComplianceStatusTable,
+	ComplianceViolationsTable,
+	VulnerabilityFeedTable,
+	VulnerabilityStatusTable,
+	VulnerabilityFindingsTable,
+	LicensesTable,
+	HardwareLifecycleTable,
+	DashboardsTable,
+	DashboardWidgetsTable,
+	FleetMetricsTable,
+	WebAuthnCredentialsTable,
+	AuthPolicyTable,
+	LDAPSettingsTable,
+	DirectoryUsersTable,
+	SCIMSettingsTable,
+	SCIMUsersTable,
+	OIDCClaimSettingsTable,
+	OIDCRoleMappingsTable,
+	SessionInfoTable,
+}
@@ -15,6 +15,7 @@ import (
 	mw "github.com/labstack/echo/v4/middleware"
 	"github.com/open-uem/openuem-console/internal/controllers/router/middleware"
 	"github.com/open-uem/openuem-console/internal/controllers/sessions"
+	"github.com/open-uem/openuem-console/internal/controllers/webserver/handlers"
 	"github.com/open-uem/openuem-console/internal/views"
 	"github.com/open-uem/openuem-console/internal/views/locales"
 	"github.com/open-uem/utils"
@@ -52,6 +53,12 @@ func New(s *sessions.SessionManager, server, port, maxUploadSize string) *echo.E
 
 	// Add CSRF middleware
 	e.Use(mw.CSRFWithConfig(mw.CSRFConfig{
+		// Requests authenticated with an API or SCIM token don't use cookies and
+		// browsers never add the Authorization header on their own
+		Skipper: func(c echo.Context) bool {
+			path := c.Request().URL.Path
+			return (strings.HasPrefix(path, "/api/") || strings.HasPrefix(path, "/scim/")) && strings.HasPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+		},
 		TokenLookup:    "cookie:_csrf",
 		CookiePath:     "/",
 		CookieSecure:   true,
@@ -120,6 +127,12 @@ func staticAssets(e *echo.Echo, cwd string) string {
 }
 
 func customHTTPErrorHandler(err error, c echo.Context) {
+	// API clients expect JSON errors instead of HTML pages
+	if strings.HasPrefix(c.Request().URL.Path, "/api/") {
+		apiErrorHandler(err, c)
+		return
+	}
+
 	if he, ok := err.(*echo.HTTPError); ok {
 		c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTML)
 		switch he.Code {
@@ -176,3 +189,20 @@ func customHTTPErrorHandler(err error, c echo.Context) {
 		c.Logger().Error(err)
 	}
 }
+
+func apiErrorHandler(err error, c echo.Context) {
+	code := http.StatusInternalServerError
+	message := err.Error()
+
+	if he, ok := err.(*echo.HTTPError); ok {
+		code = he.Code
+		message = http.StatusText(he.Code)
+		if m, ok := he.Message.(string); ok {
+			message = m
+		}
+	}
+
+	if err := handlers.RenderAPIError(c, code, message); err != nil {
+		c.Logger().Error(err)
+	}
+}
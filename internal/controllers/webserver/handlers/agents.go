@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"net/http"
 	"net/url"
 	"os"
 	"strconv"
@@ -16,9 +17,12 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/open-uem/ent"
 	openuem_nats "github.com/open-uem/nats"
+	"github.com/open-uem/openuem-console/internal/rbac"
+	"github.com/open-uem/openuem-console/internal/savedviews"
 	"github.com/open-uem/openuem-console/internal/views/agents_views"
 	"github.com/open-uem/openuem-console/internal/views/filters"
 	"github.com/open-uem/openuem-console/internal/views/partials"
+	"github.com/open-uem/openuem-console/internal/webhooks"
 	"github.com/open-uem/utils"
 )
 
@@ -31,6 +35,12 @@ func (h *Handler) ListAgents(c echo.Context, successMessage, errMessage string,
 		return err
 	}
 
+	if !comesFromDialog {
+		if u := h.applySavedView(c, savedviews.ListAgents, commonInfo); u != "" {
+			return c.Redirect(http.StatusFound, u)
+		}
+	}
+
 	currentPage := c.FormValue("page")
 	pageSize := c.FormValue("pageSize")
 	sortBy := c.FormValue("sortBy")
@@ -181,6 +191,10 @@ func (h *Handler) ListAgents(c echo.Context, successMessage, errMessage string,
 
 	tagId := c.FormValue("tagId")
 	agentId := c.FormValue("agentId")
+	if tagId != "" && agentId != "" && !commonInfo.Can(rbac.PermissionManage) {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "roles.forbidden"), false))
+	}
+
 	if c.Request().Method == "POST" && tagId != "" && agentId != "" {
 		err := h.Model.AddTagToAgent(agentId, tagId, commonInfo)
 		if err != nil {
@@ -243,6 +257,8 @@ func (h *Handler) ListAgents(c echo.Context, successMessage, errMessage string,
 		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "settings.could_not_get_sftp_general_setting"), true))
 	}
 
+	views := h.savedViewsMenu(c, savedviews.ListAgents, currentListValues(c, comesFromDialog), commonInfo)
+
 	if comesFromDialog {
 		currentUrl := c.Request().Header.Get("Hx-Current-Url")
 		if currentUrl != "" {
@@ -251,12 +267,12 @@ func (h *Handler) ListAgents(c echo.Context, successMessage, errMessage string,
 				q.Del("page")
 				q.Add("page", "1")
 				u.RawQuery = q.Encode()
-				return RenderViewWithReplaceUrl(c, agents_views.AgentsIndex("| Agents", agents_views.Agents(c, p, f, agents, availableTags, appliedTags, availableOSes, sftpDisabled, successMessage, errMessage, refreshTime, itemsPerPage, commonInfo), commonInfo), u)
+				return RenderViewWithReplaceUrl(c, agents_views.AgentsIndex("| Agents", agents_views.Agents(c, p, f, agents, availableTags, appliedTags, availableOSes, sftpDisabled, successMessage, errMessage, refreshTime, itemsPerPage, views, commonInfo), commonInfo), u)
 			}
 		}
 	}
 
-	return RenderView(c, agents_views.AgentsIndex("| Agents", agents_views.Agents(c, p, f, agents, availableTags, appliedTags, availableOSes, sftpDisabled, successMessage, errMessage, refreshTime, itemsPerPage, commonInfo), commonInfo))
+	return RenderView(c, agents_views.AgentsIndex("| Agents", agents_views.Agents(c, p, f, agents, availableTags, appliedTags, availableOSes, sftpDisabled, successMessage, errMessage, refreshTime, itemsPerPage, views, commonInfo), commonInfo))
 }
 
 func (h *Handler) AgentDelete(c echo.Context) error {
@@ -293,6 +309,12 @@ func (h *Handler) AgentConfirmDelete(c echo.Context) error {
 
 	deleteAction := c.FormValue("agent-delete-action")
 
+	target := agentId
+	agent, err := h.Model.GetAgentById(agentId, commonInfo)
+	if err == nil {
+		target = auditAgent(agent)
+	}
+
 	if deleteAction == "delete-and-uninstall" || deleteAction == "keep-and-uninstall" {
 		if h.NATSConnection == nil || !h.NATSConnection.IsConnected() {
 			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "nats.not_connected"), false))
@@ -303,6 +325,7 @@ func (h *Handler) AgentConfirmDelete(c echo.Context) error {
 		if _, err := h.JetStream.Publish(ctx, "agent.uninstall."+agentId, nil); err != nil {
 			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "agents.could_not_send_request_to_uninstall"), true))
 		}
+		h.Audit(c, AuditAgentUninstall, target, "", deleteAction)
 	}
 
 	if deleteAction == "delete-and-uninstall" || deleteAction == "delete-and-keep" {
@@ -310,6 +333,10 @@ func (h *Handler) AgentConfirmDelete(c echo.Context) error {
 		if err != nil {
 			return h.ListAgents(c, "", err.Error(), true)
 		}
+		h.Audit(c, AuditAgentDelete, target, "", deleteAction)
+		if agent != nil {
+			h.NotifyAgentWebhooks(commonInfo, webhooks.EventAgentDeleted, agent)
+		}
 	}
 
 	return h.ListAgents(c, i18n.T(c.Request().Context(), "agents.deleted"), "", true)
@@ -442,6 +469,8 @@ func (h *Handler) AgentsAdmit(c echo.Context) error {
 					}
 				}
 
+				h.NotifyAgentWebhooks(commonInfo, webhooks.EventAgentAdmitted, agent)
+
 			} else {
 				log.Printf("[ERROR]: agent %s is not in a valid state\n", agentId)
 				errorsFound = true
@@ -546,6 +575,8 @@ func (h *Handler) AgentsDisable(c echo.Context) error {
 				if err := h.Model.DisableAgent(agentId, commonInfo); err != nil {
 					return RenderError(c, partials.ErrorMessage(err.Error(), false))
 				}
+
+				h.NotifyAgentWebhooks(commonInfo, webhooks.EventAgentDisabled, agent)
 			} else {
 				log.Printf("[ERROR]: agent %s is not in a valid state\n", agentId)
 				errorsFound = true
@@ -604,6 +635,10 @@ func (h *Handler) AgentConfirmDisable(c echo.Context) error {
 	}
 
 	agentId := c.Param("uuid")
+	agent, err := h.Model.GetAgentById(agentId, commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "agents.could_not_get_agent"), false))
+	}
 
 	if h.NATSConnection == nil || !h.NATSConnection.IsConnected() {
 		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "nats.not_connected"), false))
@@ -619,6 +654,10 @@ func (h *Handler) AgentConfirmDisable(c echo.Context) error {
 		return RenderError(c, partials.ErrorMessage(err.Error(), false))
 	}
 
+	h.Audit(c, AuditAgentDisable, auditAgent(agent), "", "")
+
+	h.NotifyAgentWebhooks(commonInfo, webhooks.EventAgentDisabled, agent)
+
 	return h.ListAgents(c, i18n.T(c.Request().Context(), "agents.has_been_disabled"), "", true)
 }
 
@@ -683,6 +722,12 @@ func (h *Handler) AgentConfirmAdmission(c echo.Context, regenerate bool) error {
 		return RenderError(c, partials.ErrorMessage(err.Error(), false))
 	}
 
+	h.Audit(c, AuditAgentAdmit, auditAgent(agent), "", "")
+
+	if !regenerate {
+		h.NotifyAgentWebhooks(commonInfo, webhooks.EventAgentAdmitted, agent)
+	}
+
 	if regenerate {
 		return h.ListAgents(c, i18n.T(c.Request().Context(), "agents.certs_regenerated"), "", true)
 	}
@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/invopop/ctxi18n/i18n"
+	"github.com/labstack/echo/v4"
+	"github.com/open-uem/openuem-console/internal/alerts"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/models"
+	"github.com/open-uem/openuem-console/internal/views/admin_views"
+	"github.com/open-uem/openuem-console/internal/views/filters"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+)
+
+func (h *Handler) ListAlertRules(c echo.Context) error {
+	return h.RenderAlertRules(c, "", "")
+}
+
+func (h *Handler) AddAlertRule(c echo.Context) error {
+	tenantID, err := strconv.Atoi(c.Param("tenant"))
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "tenants.could_not_convert_to_int", err.Error()), true))
+	}
+
+	name := strings.TrimSpace(c.FormValue("alert-name"))
+	if name == "" {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "alerts.empty_name"), true))
+	}
+
+	ruleType := c.FormValue("alert-type")
+	if !slices.Contains(alerts.Types, ruleType) {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "alerts.invalid_type"), true))
+	}
+
+	threshold := 0
+	if ruleType != alerts.TypeAntivirusOutdated {
+		threshold, err = strconv.Atoi(c.FormValue("alert-threshold"))
+		if err != nil || !alerts.ValidThreshold(ruleType, threshold) {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "alerts.invalid_threshold"), true))
+		}
+	}
+
+	siteID, err := strconv.Atoi(c.FormValue("alert-site"))
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "alerts.invalid_site"), true))
+	}
+	if siteID != -1 {
+		if _, err := h.Model.GetSiteById(tenantID, siteID); err != nil {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "alerts.invalid_site"), true))
+		}
+	}
+
+	recipients, err := alerts.ParseRecipients(c.FormValue("alert-recipients"))
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "alerts.invalid_recipients", err.Error()), true))
+	}
+
+	throttle, err := strconv.Atoi(c.FormValue("alert-throttle"))
+	if err != nil || throttle < 1 || throttle > 24*30 {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "alerts.invalid_throttle"), true))
+	}
+
+	r := consoledb.AlertRule{
+		TenantID:        tenantID,
+		SiteID:          siteID,
+		Name:            name,
+		Type:            ruleType,
+		Threshold:       threshold,
+		Recipients:      recipients,
+		ThrottleMinutes: throttle * 60,
+		Enabled:         true,
+	}
+
+	if err := h.Model.AddAlertRule(r); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "alerts.could_not_add", err.Error()), true))
+	}
+
+	h.Audit(c, AuditAlertRuleAdd, name, "", fmt.Sprintf("%s %d", ruleType, threshold))
+
+	return h.RenderAlertRules(c, i18n.T(c.Request().Context(), "alerts.added"), "")
+}
+
+func (h *Handler) EnableAlertRule(c echo.Context) error {
+	r, err := h.getAlertRule(c)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), true))
+	}
+
+	enabled, err := strconv.ParseBool(c.FormValue("alert-enabled"))
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "alerts.invalid_enabled"), true))
+	}
+
+	if err := h.Model.SetAlertRuleEnabled(r.ID, r.TenantID, enabled); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "alerts.could_not_update", err.Error()), true))
+	}
+
+	if enabled {
+		return h.RenderAlertRules(c, i18n.T(c.Request().Context(), "alerts.has_been_enabled"), "")
+	}
+	return h.RenderAlertRules(c, i18n.T(c.Request().Context(), "alerts.has_been_disabled"), "")
+}
+
+func (h *Handler) AlertRuleDelete(c echo.Context) error {
+	r, err := h.getAlertRule(c)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), true))
+	}
+
+	return RenderConfirm(c, partials.ConfirmDelete(c, i18n.T(c.Request().Context(), "alerts.confirm_delete", r.Name), "", fmt.Sprintf("/tenant/%d/admin/alerts/%d", r.TenantID, r.ID)))
+}
+
+func (h *Handler) AlertRuleConfirmDelete(c echo.Context) error {
+	r, err := h.getAlertRule(c)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), true))
+	}
+
+	if err := h.Model.DeleteAlertRule(r.ID, r.TenantID); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "alerts.could_not_delete", err.Error()), true))
+	}
+
+	h.Audit(c, AuditAlertRuleDelete, r.Name, fmt.Sprintf("%s %d", r.Type, r.Threshold), "")
+
+	return h.RenderAlertRules(c, i18n.T(c.Request().Context(), "alerts.deleted"), "")
+}
+
+func (h *Handler) RenderAlertRules(c echo.Context, successMessage, errMessage string) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	tenantID, err := strconv.Atoi(c.Param("tenant"))
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "tenants.could_not_convert_to_int", err.Error()), true))
+	}
+	commonInfo.TenantID = c.Param("tenant")
+
+	rules, err := h.Model.GetAlertRules(tenantID)
+	if err != nil {
+		successMessage = ""
+		errMessage = i18n.T(c.Request().Context(), "alerts.could_not_get", err.Error())
+	}
+
+	sites, err := h.Model.GetSites(tenantID)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), false))
+	}
+
+	agentsExists, err := h.Model.AgentsExists(commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), false))
+	}
+
+	serversExists, err := h.Model.ServersExists()
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), false))
+	}
+
+	return RenderView(c, admin_views.AlertsIndex(" | Alerts", admin_views.AlertRules(c, rules, sites, successMessage, errMessage, agentsExists, serversExists, commonInfo, h.GetAdminTenantName(commonInfo)), commonInfo))
+}
+
+func (h *Handler) ListAlertHistory(c echo.Context) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	tenantID, err := strconv.Atoi(c.Param("tenant"))
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "tenants.could_not_convert_to_int", err.Error()), true))
+	}
+	commonInfo.TenantID = c.Param("tenant")
+
+	errMessage := ""
+
+	itemsPerPage, err := h.Model.GetDefaultItemsPerPage()
+	if err != nil {
+		log.Println("[ERROR]: could not get items per page from database")
+		itemsPerPage = 5
+	}
+
+	p := partials.NewPaginationAndSort(itemsPerPage)
+	p.GetPaginationAndSortParams(c.FormValue("page"), c.FormValue("pageSize"), c.FormValue("sortBy"), c.FormValue("sortOrder"), c.FormValue("currentSortBy"), itemsPerPage)
+
+	f := filters.AlertHistoryFilter{
+		Rule:        c.FormValue("filterByRule"),
+		Hostname:    c.FormValue("filterByHostname"),
+		Status:      strings.TrimPrefix(c.FormValue("filterByStatus0"), "alerts.status_"),
+		CreatedFrom: c.FormValue("filterByCreatedDateFrom"),
+		CreatedTo:   c.FormValue("filterByCreatedDateTo"),
+	}
+
+	p.NItems, err = h.Model.CountAlertHistory(tenantID, f)
+	if err != nil {
+		errMessage = i18n.T(c.Request().Context(), "alerts.could_not_get_history", err.Error())
+	}
+
+	history, err := h.Model.GetAlertHistoryByPage(p, tenantID, f)
+	if err != nil {
+		errMessage = i18n.T(c.Request().Context(), "alerts.could_not_get_history", err.Error())
+	}
+
+	agentsExists, err := h.Model.AgentsExists(commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), false))
+	}
+
+	serversExists, err := h.Model.ServersExists()
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), false))
+	}
+
+	return RenderView(c, admin_views.AlertsIndex(" | Alerts", admin_views.AlertHistory(c, p, f, history, errMessage, agentsExists, serversExists, itemsPerPage, commonInfo, h.GetAdminTenantName(commonInfo)), commonInfo))
+}
+
+func (h *Handler) getAlertRule(c echo.Context) (consoledb.AlertRule, error) {
+	tenantID, err := strconv.Atoi(c.Param("tenant"))
+	if err != nil {
+		return consoledb.AlertRule{}, errors.New(i18n.T(c.Request().Context(), "tenants.could_not_convert_to_int", err.Error()))
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return consoledb.AlertRule{}, errors.New(i18n.T(c.Request().Context(), "alerts.invalid_id"))
+	}
+
+	r, err := h.Model.GetAlertRule(id, tenantID)
+	if err != nil {
+		if errors.Is(err, models.ErrAlertRuleNotFound) {
+			return consoledb.AlertRule{}, errors.New(i18n.T(c.Request().Context(), "alerts.not_found"))
+		}
+		return consoledb.AlertRule{}, errors.New(i18n.T(c.Request().Context(), "alerts.could_not_get", err.Error()))
+	}
+
+	return r, nil
+}
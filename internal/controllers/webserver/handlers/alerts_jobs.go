@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-co-op/gocron/v2"
+	"github.com/open-uem/openuem-console/internal/alerts"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+)
+
+const (
+	alertCheckInterval = 5 * time.Minute
+	alertHistoryKept   = 90 * 24 * time.Hour
+)
+
+// StartAlertsJob schedules the job that evaluates the alert rules. Rules are claimed
+// before being evaluated so several console instances don't send the same alert
+func (h *Handler) StartAlertsJob() error {
+	if _, err := h.TaskScheduler.NewJob(
+		gocron.DurationJob(alertCheckInterval),
+		gocron.NewTask(h.EvaluateAlertRules),
+		gocron.WithSingletonMode(gocron.LimitModeReschedule),
+	); err != nil {
+		log.Printf("[ERROR]: could not schedule the job that evaluates alert rules, reason: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// EvaluateAlertRules evaluates the rules that are due and e-mails the matches
+// that haven't been alerted within the rule's throttle window
+func (h *Handler) EvaluateAlertRules() {
+	rules, err := h.Model.GetEnabledAlertRules()
+	if err != nil {
+		log.Printf("[ERROR]: could not get the alert rules, reason: %v", err)
+		return
+	}
+
+	for _, r := range rules {
+		claimed, err := h.Model.ClaimAlertRule(r.ID, alerts.EvaluationInterval)
+		if err != nil {
+			log.Printf("[ERROR]: could not claim alert rule %d, reason: %v", r.ID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		h.evaluateAlertRule(r)
+	}
+
+	if err := h.Model.DeleteOldAlertHistory(time.Now().Add(-alertHistoryKept)); err != nil {
+		log.Printf("[ERROR]: could not delete old alert history, reason: %v", err)
+	}
+}
+
+func (h *Handler) evaluateAlertRule(r consoledb.AlertRule) {
+	matches, err := h.Model.GetAlertMatches(r)
+	if err != nil {
+		log.Printf("[ERROR]: could not evaluate alert rule %d, reason: %v", r.ID, err)
+		return
+	}
+
+	alerted, err := h.Model.GetAlertedSubjects(r.ID, time.Now().Add(-time.Duration(r.ThrottleMinutes)*time.Minute))
+	if err != nil {
+		log.Printf("[ERROR]: could not get the alerts sent by rule %d, reason: %v", r.ID, err)
+		return
+	}
+
+	matches = alerts.Throttled(matches, alerted)
+	if len(matches) == 0 {
+		return
+	}
+
+	status := consoledb.AlertSent
+	errMessage := ""
+	if err := h.SendEmail(strconv.Itoa(r.TenantID), r.Recipients, alerts.Subject(r.Name, matches), alerts.Body(r.Name, r.Type, r.Threshold, matches)); err != nil {
+		log.Printf("[ERROR]: could not send the e-mail for alert rule %d, reason: %v", r.ID, err)
+		status = consoledb.AlertFailed
+		errMessage = err.Error()
+	}
+
+	entries := []consoledb.AlertHistoryEntry{}
+	for _, m := range matches {
+		entries = append(entries, consoledb.AlertHistoryEntry{
+			RuleID:     r.ID,
+			TenantID:   r.TenantID,
+			SiteID:     r.SiteID,
+			RuleName:   r.Name,
+			Type:       r.Type,
+			Subject:    m.Subject,
+			Hostname:   m.Hostname,
+			Message:    alerts.Message(r.Type, r.Threshold, m),
+			Recipients: strings.Join(r.Recipients, ", "),
+			Status:     status,
+			Error:      errMessage,
+		})
+	}
+
+	if err := h.Model.AddAlertHistory(entries); err != nil {
+		log.Printf("[ERROR]: could not save the alert history of rule %d, reason: %v", r.ID, err)
+	}
+}
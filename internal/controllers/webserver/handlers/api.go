@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/invopop/ctxi18n/i18n"
+	"github.com/labstack/echo/v4"
+	"github.com/open-uem/ent"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+)
+
+// APIPrefix is the path prefix shared by all the versioned JSON endpoints
+const APIPrefix = "/api/v1"
+
+type APIError struct {
+	Message string `json:"message"`
+}
+
+type APIPage struct {
+	Page     int `json:"page"`
+	PageSize int `json:"page_size"`
+	Total    int `json:"total"`
+	Items    any `json:"items"`
+}
+
+// RegisterAPI adds the JSON REST API that mirrors the HTMX routes. Every endpoint
+// is available globally, for a tenant and for a site inside a tenant so the same
+// scoping rules used by the console apply to the API
+func (h *Handler) RegisterAPI(e *echo.Echo) {
+	for _, prefix := range []string{APIPrefix, APIPrefix + "/tenant/:tenant", APIPrefix + "/tenant/:tenant/site/:site"} {
+		api := e.Group(prefix, h.IsAuthenticated)
+
+		api.GET("/agents", h.APIListAgents)
+		api.GET("/agents/:uuid", h.APIGetAgent)
+		api.GET("/computers", h.APIListComputers)
+		api.GET("/computers/:uuid/deployments", h.APIListComputerDeployments)
+		api.GET("/software", h.APIListSoftware)
+
+		api.GET("/profiles", h.APIListProfiles)
+		api.POST("/profiles", h.APIAddProfile)
+		api.GET("/profiles/:profile", h.APIGetProfile)
+		api.PUT("/profiles/:profile", h.APIUpdateProfile)
+		api.DELETE("/profiles/:profile", h.APIDeleteProfile)
+		api.GET("/profiles/:profile/tasks", h.APIListTasks)
+		api.POST("/profiles/:profile/tasks", h.APIAddTask)
+		api.GET("/profiles/:profile/tasks/:id", h.APIGetTask)
+		api.PUT("/profiles/:profile/tasks/:id", h.APIUpdateTask)
+		api.DELETE("/profiles/:profile/tasks/:id", h.APIDeleteTask)
+	}
+}
+
+func IsAPIRequest(c echo.Context) bool {
+	return strings.HasPrefix(c.Request().URL.Path, APIPrefix+"/")
+}
+
+// RenderAPIError sends the JSON error body of the REST API, the router uses it too
+// for the errors returned by the API handlers
+func RenderAPIError(c echo.Context, code int, message string) error {
+	return c.JSON(code, APIError{Message: message})
+}
+
+// GetAPICommonInfo builds the tenant and site scope for an API request. Unlike GetCommonInfo
+// it doesn't fallback to the default tenant or site when the ones requested don't exist,
+// global scope (tenant -1) is only allowed for resources that can be global like profiles
+func (h *Handler) GetAPICommonInfo(c echo.Context, allowGlobal bool) (*partials.CommonInfo, error) {
+	var err error
+	var tenant *ent.Tenant
+
+	info := partials.CommonInfo{
+		SM:             h.SessionManager,
+		CurrentVersion: h.Version,
+		TenantID:       "-1",
+		SiteID:         "-1",
+	}
+
+	info.Access, err = h.GetUserAccess(c)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	tenantID := c.Param("tenant")
+	siteID := c.Param("site")
+
+	if tenantID == "" {
+		if allowGlobal {
+			info.Permission = info.Access.Level(-1, -1)
+			return &info, nil
+		}
+
+		tenant, err = h.Model.GetDefaultTenant()
+		if err != nil {
+			return nil, echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+	} else {
+		id, err := strconv.Atoi(tenantID)
+		if err != nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, i18n.T(c.Request().Context(), "tenants.could_not_convert_to_int", tenantID))
+		}
+
+		tenant, err = h.Model.GetTenantByID(id)
+		if err != nil {
+			return nil, echo.NewHTTPError(http.StatusNotFound, i18n.T(c.Request().Context(), "tenants.tenant_not_found", err.Error()))
+		}
+	}
+	info.TenantID = strconv.Itoa(tenant.ID)
+
+	if siteID != "" {
+		id, err := strconv.Atoi(siteID)
+		if err != nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, i18n.T(c.Request().Context(), "sites.could_not_convert_site_to_int", siteID))
+		}
+
+		if _, err := h.Model.GetSiteById(tenant.ID, id); err != nil {
+			return nil, echo.NewHTTPError(http.StatusNotFound, i18n.T(c.Request().Context(), "sites.site_not_found", err.Error()))
+		}
+		info.SiteID = siteID
+	}
+	info.ProfileSiteID = info.SiteID
+
+	currentSiteID, err := strconv.Atoi(info.SiteID)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, i18n.T(c.Request().Context(), "sites.could_not_convert_site_to_int", info.SiteID))
+	}
+	info.Permission = info.Access.Level(tenant.ID, currentSiteID)
+
+	return &info, nil
+}
+
+// GetAPIPagination reads the same pagination and sort parameters used by the HTMX views
+func (h *Handler) GetAPIPagination(c echo.Context) partials.PaginationAndSort {
+	itemsPerPage, err := h.Model.GetDefaultItemsPerPage()
+	if err != nil {
+		log.Println("[ERROR]: could not get items per page from database")
+		itemsPerPage = 5
+	}
+
+	p := partials.NewPaginationAndSort(itemsPerPage)
+	p.GetPaginationAndSortParams(c.QueryParam("page"), c.QueryParam("pageSize"), c.QueryParam("sortBy"), c.QueryParam("sortOrder"), "", itemsPerPage)
+
+	return p
+}
+
+// apiError reports missing resources as 404, any other error is returned so the router
+// renders it with RenderAPIError
+func apiError(c echo.Context, err error) error {
+	if ent.IsNotFound(err) {
+		return RenderAPIError(c, http.StatusNotFound, err.Error())
+	}
+	return err
+}
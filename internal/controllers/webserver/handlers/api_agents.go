@@ -0,0 +1,339 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/invopop/ctxi18n/i18n"
+	"github.com/labstack/echo/v4"
+	"github.com/open-uem/ent"
+	"github.com/open-uem/openuem-console/internal/models"
+	"github.com/open-uem/openuem-console/internal/views/filters"
+)
+
+type APITag struct {
+	ID          int    `json:"id"`
+	Tag         string `json:"tag"`
+	Description string `json:"description,omitempty"`
+	Color       string `json:"color,omitempty"`
+}
+
+type APIAgent struct {
+	ID           string    `json:"id"`
+	Hostname     string    `json:"hostname"`
+	Nickname     string    `json:"nickname"`
+	Description  string    `json:"description,omitempty"`
+	OS           string    `json:"os"`
+	IP           string    `json:"ip"`
+	MAC          string    `json:"mac"`
+	Status       string    `json:"status"`
+	Version      string    `json:"version,omitempty"`
+	EndpointType string    `json:"endpoint_type,omitempty"`
+	IsRemote     bool      `json:"is_remote"`
+	FirstContact time.Time `json:"first_contact"`
+	LastContact  time.Time `json:"last_contact"`
+	SiteID       int       `json:"site_id"`
+	Tags         []APITag  `json:"tags"`
+}
+
+type APIAgentComputer struct {
+	Manufacturer   string `json:"manufacturer"`
+	Model          string `json:"model"`
+	Serial         string `json:"serial"`
+	Memory         uint64 `json:"memory"`
+	Processor      string `json:"processor"`
+	ProcessorCores int64  `json:"processor_cores"`
+	ProcessorArch  string `json:"processor_arch"`
+}
+
+type APIAgentOS struct {
+	Type           string    `json:"type"`
+	Version        string    `json:"version"`
+	Description    string    `json:"description"`
+	Edition        string    `json:"edition"`
+	Arch           string    `json:"arch"`
+	Username       string    `json:"username"`
+	Domain         string    `json:"domain"`
+	InstallDate    time.Time `json:"install_date"`
+	LastBootupTime time.Time `json:"last_bootup_time"`
+}
+
+type APIAgentDetail struct {
+	APIAgent
+	Computer        *APIAgentComputer `json:"computer,omitempty"`
+	OperatingSystem *APIAgentOS       `json:"operating_system,omitempty"`
+}
+
+type APIComputer struct {
+	ID           string    `json:"id"`
+	Hostname     string    `json:"hostname"`
+	Nickname     string    `json:"nickname"`
+	OS           string    `json:"os"`
+	Version      string    `json:"version"`
+	IP           string    `json:"ip"`
+	MAC          string    `json:"mac"`
+	Username     string    `json:"username"`
+	Manufacturer string    `json:"manufacturer"`
+	Model        string    `json:"model"`
+	Serial       string    `json:"serial"`
+	IsRemote     bool      `json:"is_remote"`
+	LastContact  time.Time `json:"last_contact"`
+	SiteID       int       `json:"site_id"`
+	Tags         []APITag  `json:"tags"`
+}
+
+type APIDeployment struct {
+	ID        int       `json:"id"`
+	PackageID string    `json:"package_id"`
+	Name      string    `json:"name"`
+	Version   string    `json:"version"`
+	Installed time.Time `json:"installed"`
+	Updated   time.Time `json:"updated"`
+	Failed    bool      `json:"failed"`
+	ByProfile bool      `json:"by_profile"`
+}
+
+func (h *Handler) APIListAgents(c echo.Context) error {
+	commonInfo, err := h.GetAPICommonInfo(c, false)
+	if err != nil {
+		return apiError(c, err)
+	}
+
+	p := h.GetAPIPagination(c)
+	f := GetAPIAgentFilter(c)
+
+	agents, err := h.Model.GetAgentsByPage(p, f, false, commonInfo)
+	if err != nil {
+		return RenderAPIError(c, http.StatusInternalServerError, err.Error())
+	}
+
+	p.NItems, err = h.Model.CountAllAgents(f, false, commonInfo)
+	if err != nil {
+		return RenderAPIError(c, http.StatusInternalServerError, err.Error())
+	}
+
+	items := []APIAgent{}
+	for _, a := range agents {
+		items = append(items, toAPIAgent(a))
+	}
+
+	return c.JSON(http.StatusOK, APIPage{Page: p.CurrentPage, PageSize: p.PageSize, Total: p.NItems, Items: items})
+}
+
+func (h *Handler) APIGetAgent(c echo.Context) error {
+	commonInfo, err := h.GetAPICommonInfo(c, false)
+	if err != nil {
+		return apiError(c, err)
+	}
+
+	agentId := c.Param("uuid")
+	if agentId == "" {
+		return RenderAPIError(c, http.StatusBadRequest, i18n.T(c.Request().Context(), "agents.no_empty_id"))
+	}
+
+	a, err := h.Model.GetAgentById(agentId, commonInfo)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return RenderAPIError(c, http.StatusNotFound, i18n.T(c.Request().Context(), "agents.not_found"))
+		}
+		return RenderAPIError(c, http.StatusInternalServerError, err.Error())
+	}
+
+	detail := APIAgentDetail{APIAgent: toAPIAgent(a)}
+
+	if a.Edges.Computer != nil {
+		detail.Computer = &APIAgentComputer{
+			Manufacturer:   a.Edges.Computer.Manufacturer,
+			Model:          a.Edges.Computer.Model,
+			Serial:         a.Edges.Computer.Serial,
+			Memory:         a.Edges.Computer.Memory,
+			Processor:      a.Edges.Computer.Processor,
+			ProcessorCores: a.Edges.Computer.ProcessorCores,
+			ProcessorArch:  a.Edges.Computer.ProcessorArch,
+		}
+	}
+
+	if a.Edges.Operatingsystem != nil {
+		detail.OperatingSystem = &APIAgentOS{
+			Type:           a.Edges.Operatingsystem.Type,
+			Version:        a.Edges.Operatingsystem.Version,
+			Description:    a.Edges.Operatingsystem.Description,
+			Edition:        a.Edges.Operatingsystem.Edition,
+			Arch:           a.Edges.Operatingsystem.Arch,
+			Username:       a.Edges.Operatingsystem.Username,
+			Domain:         a.Edges.Operatingsystem.Domain,
+			InstallDate:    a.Edges.Operatingsystem.InstallDate,
+			LastBootupTime: a.Edges.Operatingsystem.LastBootupTime,
+		}
+	}
+
+	return c.JSON(http.StatusOK, detail)
+}
+
+func (h *Handler) APIListComputers(c echo.Context) error {
+	commonInfo, err := h.GetAPICommonInfo(c, false)
+	if err != nil {
+		return apiError(c, err)
+	}
+
+	p := h.GetAPIPagination(c)
+	f := GetAPIAgentFilter(c)
+
+	computers, err := h.Model.GetComputersByPage(p, f, commonInfo)
+	if err != nil {
+		return RenderAPIError(c, http.StatusInternalServerError, err.Error())
+	}
+
+	p.NItems, err = h.Model.CountAllComputers(f, commonInfo)
+	if err != nil {
+		return RenderAPIError(c, http.StatusInternalServerError, err.Error())
+	}
+
+	items := []APIComputer{}
+	for _, computer := range computers {
+		items = append(items, toAPIComputer(computer))
+	}
+
+	return c.JSON(http.StatusOK, APIPage{Page: p.CurrentPage, PageSize: p.PageSize, Total: p.NItems, Items: items})
+}
+
+func (h *Handler) APIListComputerDeployments(c echo.Context) error {
+	commonInfo, err := h.GetAPICommonInfo(c, false)
+	if err != nil {
+		return apiError(c, err)
+	}
+
+	agentId := c.Param("uuid")
+	if agentId == "" {
+		return RenderAPIError(c, http.StatusBadRequest, i18n.T(c.Request().Context(), "agents.no_empty_id"))
+	}
+
+	if _, err := h.Model.GetAgentById(agentId, commonInfo); err != nil {
+		if ent.IsNotFound(err) {
+			return RenderAPIError(c, http.StatusNotFound, i18n.T(c.Request().Context(), "agents.not_found"))
+		}
+		return RenderAPIError(c, http.StatusInternalServerError, err.Error())
+	}
+
+	p := h.GetAPIPagination(c)
+
+	deployments, err := h.Model.GetDeploymentsForAgent(agentId, p, commonInfo)
+	if err != nil {
+		return RenderAPIError(c, http.StatusInternalServerError, err.Error())
+	}
+
+	p.NItems, err = h.Model.CountDeploymentsForAgent(agentId, commonInfo)
+	if err != nil {
+		return RenderAPIError(c, http.StatusInternalServerError, err.Error())
+	}
+
+	items := []APIDeployment{}
+	for _, d := range deployments {
+		items = append(items, APIDeployment{
+			ID:        d.ID,
+			PackageID: d.PackageID,
+			Name:      d.Name,
+			Version:   d.Version,
+			Installed: d.Installed,
+			Updated:   d.Updated,
+			Failed:    d.Failed,
+			ByProfile: d.ByProfile,
+		})
+	}
+
+	return c.JSON(http.StatusOK, APIPage{Page: p.CurrentPage, PageSize: p.PageSize, Total: p.NItems, Items: items})
+}
+
+// GetAPIAgentFilter maps query params to the same AgentFilter used by the agents and computers views.
+// Multi-valued filters are passed repeating the param e.g ?os=windows&os=linux
+func GetAPIAgentFilter(c echo.Context) filters.AgentFilter {
+	q := c.QueryParams()
+
+	f := filters.AgentFilter{
+		Nickname:                 q.Get("nickname"),
+		Username:                 q.Get("username"),
+		Search:                   q.Get("search"),
+		ContactFrom:              q.Get("contactFrom"),
+		ContactTo:                q.Get("contactTo"),
+		WithApplication:          q.Get("application"),
+		WithApplicationPublisher: q.Get("publisher"),
+		AgentStatusOptions:       q["status"],
+		AgentOSVersions:          q["os"],
+		OSVersions:               q["osVersion"],
+		ComputerManufacturers:    q["manufacturer"],
+		ComputerModels:           q["model"],
+		IsRemote:                 q["remote"],
+	}
+
+	for _, status := range f.AgentStatusOptions {
+		if status == "No Contact" {
+			f.NoContact = true
+		}
+	}
+
+	for _, tag := range q["tag"] {
+		if id, err := strconv.Atoi(tag); err == nil {
+			f.Tags = append(f.Tags, id)
+		}
+	}
+
+	return f
+}
+
+func toAPIAgent(a *ent.Agent) APIAgent {
+	item := APIAgent{
+		ID:           a.ID,
+		Hostname:     a.Hostname,
+		Nickname:     a.Nickname,
+		Description:  a.Description,
+		OS:           a.Os,
+		IP:           a.IP,
+		MAC:          a.MAC,
+		Status:       a.AgentStatus.String(),
+		EndpointType: a.EndpointType.String(),
+		IsRemote:     a.IsRemote,
+		FirstContact: a.FirstContact,
+		LastContact:  a.LastContact,
+		SiteID:       -1,
+		Tags:         toAPITags(a.Edges.Tags),
+	}
+
+	if a.Edges.Release != nil {
+		item.Version = a.Edges.Release.Version
+	}
+
+	if len(a.Edges.Site) == 1 {
+		item.SiteID = a.Edges.Site[0].ID
+	}
+
+	return item
+}
+
+func toAPIComputer(computer models.Computer) APIComputer {
+	return APIComputer{
+		ID:           computer.ID,
+		Hostname:     computer.Hostname,
+		Nickname:     computer.Nickname,
+		OS:           computer.OS,
+		Version:      computer.Version,
+		IP:           computer.IP,
+		MAC:          computer.MAC,
+		Username:     computer.Username,
+		Manufacturer: computer.Manufacturer,
+		Model:        computer.Model,
+		Serial:       computer.Serial,
+		IsRemote:     computer.IsRemote,
+		LastContact:  computer.LastContact,
+		SiteID:       computer.SiteID,
+		Tags:         toAPITags(computer.Tags),
+	}
+}
+
+func toAPITags(tags []*ent.Tag) []APITag {
+	items := []APITag{}
+	for _, t := range tags {
+		items = append(items, APITag{ID: t.ID, Tag: t.Tag, Description: t.Description, Color: t.Color})
+	}
+	return items
+}
@@ -0,0 +1,329 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/invopop/ctxi18n/i18n"
+	"github.com/labstack/echo/v4"
+	"github.com/open-uem/ent"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+	"github.com/open-uem/utils"
+)
+
+type APIProfile struct {
+	ID         int      `json:"id"`
+	Name       string   `json:"name"`
+	ApplyToAll bool     `json:"apply_to_all"`
+	Type       string   `json:"type,omitempty"`
+	Disabled   bool     `json:"disabled"`
+	Tags       []APITag `json:"tags"`
+	Tasks      int      `json:"tasks"`
+}
+
+// Profiles and tasks accept the same form fields used by the HTMX forms so the
+// validations are shared, requests must be sent as application/x-www-form-urlencoded
+
+func (h *Handler) APIListProfiles(c echo.Context) error {
+	commonInfo, err := h.GetAPICommonInfo(c, true)
+	if err != nil {
+		return apiError(c, err)
+	}
+
+	p := h.GetAPIPagination(c)
+
+	profiles, err := h.Model.GetProfilesByPage(p, commonInfo)
+	if err != nil {
+		return RenderAPIError(c, http.StatusInternalServerError, err.Error())
+	}
+
+	p.NItems, err = h.Model.CountAllProfiles(commonInfo)
+	if err != nil {
+		return RenderAPIError(c, http.StatusInternalServerError, err.Error())
+	}
+
+	items := []APIProfile{}
+	for _, profile := range profiles {
+		items = append(items, toAPIProfile(profile))
+	}
+
+	return c.JSON(http.StatusOK, APIPage{Page: p.CurrentPage, PageSize: p.PageSize, Total: p.NItems, Items: items})
+}
+
+func (h *Handler) APIAddProfile(c echo.Context) error {
+	commonInfo, err := h.GetAPICommonInfo(c, true)
+	if err != nil {
+		return apiError(c, err)
+	}
+
+	siteID, err := strconv.Atoi(commonInfo.SiteID)
+	if err != nil {
+		return RenderAPIError(c, http.StatusBadRequest, i18n.T(c.Request().Context(), "sites.could_not_convert_site_to_int", commonInfo.SiteID))
+	}
+
+	tenantID, err := strconv.Atoi(commonInfo.TenantID)
+	if err != nil {
+		return RenderAPIError(c, http.StatusBadRequest, i18n.T(c.Request().Context(), "tenants.could_not_convert_to_int", commonInfo.TenantID))
+	}
+
+	description := c.FormValue("profile-description")
+	if description == "" {
+		return RenderAPIError(c, http.StatusBadRequest, i18n.T(c.Request().Context(), "profiles.new.empty"))
+	}
+
+	profile, err := h.Model.AddProfile(siteID, tenantID, description)
+	if err != nil {
+		return RenderAPIError(c, http.StatusInternalServerError, i18n.T(c.Request().Context(), "profiles.new.could_not_save"))
+	}
+
+	profile, err = h.Model.GetProfileByIdAndScope(profile.ID, commonInfo)
+	if err != nil {
+		return RenderAPIError(c, http.StatusInternalServerError, i18n.T(c.Request().Context(), "profiles.profile_not_found", err.Error()))
+	}
+
+	return c.JSON(http.StatusCreated, toAPIProfile(profile))
+}
+
+func (h *Handler) APIGetProfile(c echo.Context) error {
+	profile, _, err := h.getAPIProfile(c)
+	if err != nil {
+		return apiError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, toAPIProfile(profile))
+}
+
+func (h *Handler) APIUpdateProfile(c echo.Context) error {
+	profile, commonInfo, err := h.getAPIProfile(c)
+	if err != nil {
+		return apiError(c, err)
+	}
+
+	description := c.FormValue("profile-description")
+	if description == "" {
+		return RenderAPIError(c, http.StatusBadRequest, i18n.T(c.Request().Context(), "profiles.edit.empty"))
+	}
+
+	if err := h.Model.UpdateProfile(profile.ID, description, c.FormValue("profile-assignment"), commonInfo); err != nil {
+		return RenderAPIError(c, http.StatusInternalServerError, i18n.T(c.Request().Context(), "profiles.edit.could_not_save"))
+	}
+
+	profile, err = h.Model.GetProfileByIdAndScope(profile.ID, commonInfo)
+	if err != nil {
+		return RenderAPIError(c, http.StatusInternalServerError, i18n.T(c.Request().Context(), "profiles.profile_not_found", err.Error()))
+	}
+
+	return c.JSON(http.StatusOK, toAPIProfile(profile))
+}
+
+func (h *Handler) APIDeleteProfile(c echo.Context) error {
+	profile, commonInfo, err := h.getAPIProfile(c)
+	if err != nil {
+		return apiError(c, err)
+	}
+
+	if err := h.Model.DeleteProfile(profile.ID, commonInfo); err != nil {
+		return RenderAPIError(c, http.StatusInternalServerError, i18n.T(c.Request().Context(), "profiles.edit.could_not_delete"))
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *Handler) APIListTasks(c echo.Context) error {
+	profile, _, err := h.getAPIProfile(c)
+	if err != nil {
+		return apiError(c, err)
+	}
+
+	p := h.GetAPIPagination(c)
+
+	tasks, err := h.Model.GetTasksForProfileByPage(p, profile.ID)
+	if err != nil {
+		return RenderAPIError(c, http.StatusInternalServerError, i18n.T(c.Request().Context(), "profiles.edit.retrieve_tasks_err"))
+	}
+
+	p.NItems, err = h.Model.CountAllTasksForProfile(profile.ID)
+	if err != nil {
+		return RenderAPIError(c, http.StatusInternalServerError, i18n.T(c.Request().Context(), "profiles.edit.retrieve_tasks_err"))
+	}
+
+	items := []*ent.Task{}
+	for _, t := range tasks {
+		items = append(items, toAPITask(t))
+	}
+
+	return c.JSON(http.StatusOK, APIPage{Page: p.CurrentPage, PageSize: p.PageSize, Total: p.NItems, Items: items})
+}
+
+func (h *Handler) APIAddTask(c echo.Context) error {
+	profile, _, err := h.getAPIProfile(c)
+	if err != nil {
+		return apiError(c, err)
+	}
+
+	t, err := validateTaskForm(c)
+	if err != nil {
+		return RenderAPIError(c, http.StatusBadRequest, err.Error())
+	}
+
+	// encrypt local user password if not empty
+	if h.EncryptionMasterKey != "" && t.LocalUserPassword != "" {
+		t.LocalUserPassword, err = utils.EncryptSensitiveField(t.LocalUserPassword, h.EncryptionMasterKey)
+		if err != nil {
+			return RenderAPIError(c, http.StatusInternalServerError, i18n.T(c.Request().Context(), "tasks.local_user_password_could_not_encrypt"))
+		}
+	}
+
+	if err := h.Model.AddTaskToProfile(c, profile.ID, *t); err != nil {
+		return RenderAPIError(c, http.StatusInternalServerError, fmt.Sprintf("%s : %v", i18n.T(c.Request().Context(), "tasks.new.could_not_save"), err))
+	}
+
+	task, err := h.Model.GetLasTaskOrderInProfile(profile.ID)
+	if err != nil {
+		return RenderAPIError(c, http.StatusInternalServerError, i18n.T(c.Request().Context(), "tasks.edit.could_not_get"))
+	}
+
+	return c.JSON(http.StatusCreated, toAPITask(task))
+}
+
+func (h *Handler) APIGetTask(c echo.Context) error {
+	task, err := h.getAPITask(c)
+	if err != nil {
+		return apiError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, toAPITask(task))
+}
+
+func (h *Handler) APIUpdateTask(c echo.Context) error {
+	task, err := h.getAPITask(c)
+	if err != nil {
+		return apiError(c, err)
+	}
+
+	t, err := validateTaskForm(c)
+	if err != nil {
+		return RenderAPIError(c, http.StatusBadRequest, err.Error())
+	}
+
+	// encrypt local user password if not empty
+	if h.EncryptionMasterKey != "" && t.LocalUserPassword != "" {
+		isPasswordEncrypted, err := utils.IsSensitiveFieldEncrypted(t.LocalUserPassword, h.EncryptionMasterKey)
+		if err != nil {
+			return RenderAPIError(c, http.StatusInternalServerError, err.Error())
+		}
+
+		if !isPasswordEncrypted {
+			t.LocalUserPassword, err = utils.EncryptSensitiveField(t.LocalUserPassword, h.EncryptionMasterKey)
+			if err != nil {
+				return RenderAPIError(c, http.StatusInternalServerError, i18n.T(c.Request().Context(), "tasks.local_user_password_could_not_encrypt"))
+			}
+		}
+	}
+
+	if err := h.Model.UpdateProfileTask(c, task.ID, *t); err != nil {
+		return RenderAPIError(c, http.StatusInternalServerError, i18n.T(c.Request().Context(), "tasks.edit.could_not_save", err.Error()))
+	}
+
+	task, err = h.Model.GetTasksById(task.ID)
+	if err != nil {
+		return RenderAPIError(c, http.StatusInternalServerError, i18n.T(c.Request().Context(), "tasks.edit.could_not_get"))
+	}
+
+	return c.JSON(http.StatusOK, toAPITask(task))
+}
+
+func (h *Handler) APIDeleteTask(c echo.Context) error {
+	task, err := h.getAPITask(c)
+	if err != nil {
+		return apiError(c, err)
+	}
+
+	if err := h.Model.DeleteTask(task.Edges.Profile.ID, task.ID); err != nil {
+		return RenderAPIError(c, http.StatusInternalServerError, i18n.T(c.Request().Context(), "tasks.edit.could_not_delete"))
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// getAPIProfile returns the profile in the URL if it's visible in the tenant and site requested
+func (h *Handler) getAPIProfile(c echo.Context) (*ent.Profile, *partials.CommonInfo, error) {
+	commonInfo, err := h.GetAPICommonInfo(c, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	id := c.Param("profile")
+	if id == "" {
+		return nil, nil, echo.NewHTTPError(http.StatusBadRequest, i18n.T(c.Request().Context(), "profiles.edit.empty_id"))
+	}
+
+	profileID, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, nil, echo.NewHTTPError(http.StatusBadRequest, i18n.T(c.Request().Context(), "tasks.new.invalid_profile"))
+	}
+
+	profile, err := h.Model.GetProfileByIdAndScope(profileID, commonInfo)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return nil, nil, echo.NewHTTPError(http.StatusNotFound, i18n.T(c.Request().Context(), "profiles.profile_not_found", err.Error()))
+		}
+		return nil, nil, echo.NewHTTPError(http.StatusInternalServerError, i18n.T(c.Request().Context(), "profiles.edit.retrieve_err"))
+	}
+
+	return profile, commonInfo, nil
+}
+
+// getAPITask returns the task in the URL only if it belongs to the profile in the URL
+func (h *Handler) getAPITask(c echo.Context) (*ent.Task, error) {
+	profile, _, err := h.getAPIProfile(c)
+	if err != nil {
+		return nil, err
+	}
+
+	id := c.Param("id")
+	if id == "" {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, i18n.T(c.Request().Context(), "tasks.edit.empty_task"))
+	}
+
+	taskID, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, i18n.T(c.Request().Context(), "tasks.edit.invalid_task"))
+	}
+
+	task, err := h.Model.GetTasksById(taskID)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return nil, echo.NewHTTPError(http.StatusNotFound, i18n.T(c.Request().Context(), "tasks.edit.could_not_get"))
+		}
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, i18n.T(c.Request().Context(), "tasks.edit.could_not_get"))
+	}
+
+	if task.Edges.Profile == nil || task.Edges.Profile.ID != profile.ID {
+		return nil, echo.NewHTTPError(http.StatusNotFound, i18n.T(c.Request().Context(), "tasks.edit.could_not_get"))
+	}
+
+	return task, nil
+}
+
+func toAPIProfile(profile *ent.Profile) APIProfile {
+	return APIProfile{
+		ID:         profile.ID,
+		Name:       profile.Name,
+		ApplyToAll: profile.ApplyToAll,
+		Type:       profile.Type.String(),
+		Disabled:   profile.Disabled,
+		Tags:       toAPITags(profile.Edges.Tags),
+		Tasks:      len(profile.Edges.Tasks),
+	}
+}
+
+// toAPITask returns a copy of the task without its edges and secrets, passwords are never sent back
+func toAPITask(t *ent.Task) *ent.Task {
+	item := *t
+	item.LocalUserPassword = ""
+	item.LocalUserSSHKeyPassphrase = ""
+	item.Edges = ent.TaskEdges{}
+	return &item
+}
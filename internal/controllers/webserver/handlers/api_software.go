@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/open-uem/openuem-console/internal/views/filters"
+)
+
+type APIApp struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	Publisher string `json:"publisher"`
+	Source    string `json:"source,omitempty"`
+	Installs  int    `json:"installs"`
+}
+
+func (h *Handler) APIListSoftware(c echo.Context) error {
+	commonInfo, err := h.GetAPICommonInfo(c, false)
+	if err != nil {
+		return apiError(c, err)
+	}
+
+	p := h.GetAPIPagination(c)
+	if p.SortBy == "" {
+		p.SortBy = "name"
+		p.SortOrder = "asc"
+	}
+
+	f := filters.ApplicationsFilter{
+		AppName: c.QueryParam("name"),
+		Vendor:  c.QueryParam("publisher"),
+		Version: c.QueryParam("version"),
+		Search:  c.QueryParam("search"),
+	}
+
+	apps, err := h.Model.GetAppsByPage(p, f, commonInfo)
+	if err != nil {
+		return RenderAPIError(c, http.StatusInternalServerError, err.Error())
+	}
+
+	p.NItems, err = h.Model.CountAllApps(f, commonInfo)
+	if err != nil {
+		return RenderAPIError(c, http.StatusInternalServerError, err.Error())
+	}
+
+	items := []APIApp{}
+	for _, a := range apps {
+		items = append(items, APIApp{ID: a.ID, Name: a.Name, Publisher: a.Publisher, Source: a.Source, Installs: a.Count})
+	}
+
+	return c.JSON(http.StatusOK, APIPage{Page: p.CurrentPage, PageSize: p.PageSize, Total: p.NItems, Items: items})
+}
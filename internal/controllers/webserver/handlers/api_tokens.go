@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/invopop/ctxi18n/i18n"
+	"github.com/labstack/echo/v4"
+	"github.com/open-uem/openuem-console/internal/models"
+	"github.com/open-uem/openuem-console/internal/views/account_views"
+	"github.com/open-uem/openuem-console/internal/views/admin_views"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+)
+
+// GetBearerToken returns the token sent in the Authorization header, if any
+func GetBearerToken(c echo.Context) (string, bool) {
+	return strings.CutPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+}
+
+// AuthenticateAPIToken is used by IsAuthenticated when a request carries a personal API token.
+// Tokens restricted to a tenant can only be used with the /tenant/:tenant routes of that tenant
+func (h *Handler) AuthenticateAPIToken(c echo.Context, next echo.HandlerFunc, bearer string) error {
+	token, err := h.Model.ValidateAPIToken(bearer)
+	if err != nil {
+		if errors.Is(err, models.ErrExpiredAPIToken) {
+			return RenderAPIError(c, http.StatusUnauthorized, i18n.T(c.Request().Context(), "api_tokens.expired"))
+		}
+		if !errors.Is(err, models.ErrInvalidAPIToken) {
+			log.Printf("[ERROR]: could not validate API token, reason: %v", err)
+		}
+		return RenderAPIError(c, http.StatusUnauthorized, i18n.T(c.Request().Context(), "api.unauthorized"))
+	}
+
+	if _, err := h.Model.GetUserById(token.UserID); err != nil {
+		return RenderAPIError(c, http.StatusUnauthorized, i18n.T(c.Request().Context(), "api.unauthorized"))
+	}
+
+	disabled, err := h.Model.IsUserDisabled(token.UserID)
+	if err != nil {
+		log.Printf("[ERROR]: could not check if user %s has been disabled, reason: %v", token.UserID, err)
+	}
+
+	if err != nil || disabled {
+		return RenderAPIError(c, http.StatusUnauthorized, i18n.T(c.Request().Context(), "api.unauthorized"))
+	}
+
+	if token.TenantID != -1 && c.Param("tenant") != strconv.Itoa(token.TenantID) {
+		return RenderAPIError(c, http.StatusForbidden, i18n.T(c.Request().Context(), "api_tokens.tenant_not_allowed"))
+	}
+
+	c.Set("uid", token.UserID)
+	c.Set("api-token", token)
+
+	return h.IsAuthorized(c, next, token.UserID)
+}
+
+func (h *Handler) MyAccountNewAPIToken(c echo.Context) error {
+	username := h.SessionManager.Manager.GetString(c.Request().Context(), "uid")
+	if username == "" {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "login.username_empty"), true))
+	}
+
+	name := c.FormValue("api-token-name")
+	if name == "" {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "api_tokens.empty_name"), true))
+	}
+
+	days, err := strconv.Atoi(c.FormValue("api-token-expiry"))
+	if err != nil || days < 1 || days > 365 {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "api_tokens.invalid_expiry"), true))
+	}
+
+	tenantID, err := strconv.Atoi(c.FormValue("api-token-tenant"))
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "api_tokens.invalid_tenant"), true))
+	}
+
+	if tenantID != -1 {
+		if _, err := h.Model.GetTenantByID(tenantID); err != nil {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "api_tokens.invalid_tenant"), true))
+		}
+	}
+
+	token, err := h.Model.AddAPIToken(username, name, tenantID, time.Now().AddDate(0, 0, days))
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "api_tokens.could_not_create", err.Error()), true))
+	}
+
+	return h.RenderMyAccount(c, username, token, i18n.T(c.Request().Context(), "api_tokens.created_success"))
+}
+
+func (h *Handler) MyAccountAPITokenDelete(c echo.Context) error {
+	id := c.Param("id")
+	if _, err := strconv.Atoi(id); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "api_tokens.invalid_id"), true))
+	}
+
+	return RenderConfirm(c, partials.ConfirmDelete(c, i18n.T(c.Request().Context(), "api_tokens.confirm_revoke"), "/myaccount", fmt.Sprintf("/myaccount/tokens/%s", id)))
+}
+
+func (h *Handler) MyAccountAPITokenConfirmDelete(c echo.Context) error {
+	username := h.SessionManager.Manager.GetString(c.Request().Context(), "uid")
+	if username == "" {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "login.username_empty"), true))
+	}
+
+	tokenID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "api_tokens.invalid_id"), true))
+	}
+
+	if err := h.Model.DeleteAPIToken(tokenID, username); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "api_tokens.could_not_revoke", err.Error()), true))
+	}
+
+	return h.RenderMyAccount(c, username, "", i18n.T(c.Request().Context(), "api_tokens.revoked"))
+}
+
+// RenderMyAccount renders the account page, newToken is only shown once right after the token is created
+func (h *Handler) RenderMyAccount(c echo.Context, username, newToken, successMessage string) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	user, err := h.Model.GetUserById(username)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "login.could_not_find_user"), true))
+	}
+
+	defaultCountry, err := h.Model.GetDefaultCountry()
+	if err != nil {
+		return err
+	}
+
+	tokens, err := h.Model.GetAPITokensForUser(username)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "api_tokens.could_not_get", err.Error()), true))
+	}
+
+	passkeys, err := h.Model.GetWebAuthnCredentialsForUser(username)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "passkeys.could_not_get", err.Error()), true))
+	}
+
+	passkeyRequired := h.PhishingResistantAuthRequired(c)
+
+	userSessions, err := h.Model.GetUserSessions(username, h.EncryptionMasterKey)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "user_sessions.could_not_get", err.Error()), true))
+	}
+	currentSession := models.SessionTokenHash(h.SessionManager.Manager.Token(c.Request().Context()))
+
+	return RenderView(c, account_views.MyAccountIndex("| My Account", account_views.MyAccount(c, user, defaultCountry, tokens, newToken, passkeys, passkeyRequired, userSessions, currentSession, commonInfo, successMessage), commonInfo))
+}
+
+func (h *Handler) ListAPITokens(c echo.Context, successMessage string) error {
+	var err error
+
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	errMessage := ""
+
+	itemsPerPage, err := h.Model.GetDefaultItemsPerPage()
+	if err != nil {
+		log.Println("[ERROR]: could not get items per page from database")
+		itemsPerPage = 5
+	}
+
+	p := partials.NewPaginationAndSort(itemsPerPage)
+	p.GetPaginationAndSortParams(c.FormValue("page"), c.FormValue("pageSize"), c.FormValue("sortBy"), c.FormValue("sortOrder"), c.FormValue("currentSortBy"), itemsPerPage)
+
+	p.NItems, err = h.Model.CountAllAPITokens()
+	if err != nil {
+		errMessage = err.Error()
+	}
+
+	tokens, err := h.Model.GetAPITokensByPage(p)
+	if err != nil {
+		successMessage = ""
+		errMessage = err.Error()
+	}
+
+	agentsExists, err := h.Model.AgentsExists(commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), false))
+	}
+
+	serversExists, err := h.Model.ServersExists()
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), false))
+	}
+
+	return RenderView(c, admin_views.APITokensIndex(" | API Tokens", admin_views.APITokens(c, p, tokens, successMessage, errMessage, agentsExists, serversExists, itemsPerPage, commonInfo), commonInfo))
+}
+
+func (h *Handler) APITokenDelete(c echo.Context) error {
+	id := c.Param("id")
+	if _, err := strconv.Atoi(id); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "api_tokens.invalid_id"), true))
+	}
+
+	return RenderConfirm(c, partials.ConfirmDelete(c, i18n.T(c.Request().Context(), "api_tokens.confirm_revoke"), "/admin/api-tokens", fmt.Sprintf("/admin/api-tokens/%s", id)))
+}
+
+func (h *Handler) APITokenConfirmDelete(c echo.Context) error {
+	tokenID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "api_tokens.invalid_id"), true))
+	}
+
+	if err := h.Model.DeleteAPIToken(tokenID, ""); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "api_tokens.could_not_revoke", err.Error()), true))
+	}
+
+	h.Audit(c, AuditAPITokenRevoke, strconv.Itoa(tokenID), "", "")
+
+	return h.ListAPITokens(c, i18n.T(c.Request().Context(), "api_tokens.revoked"))
+}
@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/invopop/ctxi18n/i18n"
+	"github.com/labstack/echo/v4"
+	openuem_ent "github.com/open-uem/ent"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/views/admin_views"
+	"github.com/open-uem/openuem-console/internal/views/filters"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+)
+
+// Actions recorded in the audit log
+const (
+	AuditAgentAdmit              = "agent.admit"
+	AuditAgentDisable            = "agent.disable"
+	AuditAgentDelete             = "agent.delete"
+	AuditAgentUninstall          = "agent.uninstall"
+	AuditCertificateRevoke       = "certificate.revoke"
+	AuditComputerPower           = "computer.power"
+	AuditDeployInstall           = "deploy.install"
+	AuditDeployUninstall         = "deploy.uninstall"
+	AuditTenantDelete            = "tenant.delete"
+	AuditSiteDelete              = "site.delete"
+	AuditFileDelete              = "file.delete"
+	AuditSettingsUpdate          = "settings.update"
+	AuditSMTPUpdate              = "smtp.update"
+	AuditAuthUpdate              = "authentication.update"
+	AuditUserDelete              = "user.delete"
+	AuditUserProvision           = "user.provision"
+	AuditUserUpdate              = "user.update"
+	AuditRoleAdd                 = "role.add"
+	AuditRoleDelete              = "role.delete"
+	AuditOIDCMappingAdd          = "oidc_mapping.add"
+	AuditOIDCMappingDelete       = "oidc_mapping.delete"
+	AuditAPITokenRevoke          = "api_token.revoke"
+	AuditWebhookAdd              = "webhook.add"
+	AuditWebhookDelete           = "webhook.delete"
+	AuditAlertRuleAdd            = "alert_rule.add"
+	AuditAlertRuleDelete         = "alert_rule.delete"
+	AuditReportScheduleAdd       = "report_schedule.add"
+	AuditReportScheduleDelete    = "report_schedule.delete"
+	AuditRolloutAdd              = "rollout.add"
+	AuditRolloutStatus           = "rollout.status"
+	AuditMaintenanceWindowAdd    = "maintenance_window.add"
+	AuditMaintenanceWindowDelete = "maintenance_window.delete"
+	AuditMaintenanceActionCancel = "maintenance_action.cancel"
+	AuditComputerBulkAction      = "computer.bulk_action"
+	AuditDeployJobRetry          = "deploy_job.retry"
+	AuditDeployJobCancel         = "deploy_job.cancel"
+	AuditCompliancePolicyAdd     = "compliance_policy.add"
+	AuditCompliancePolicyDelete  = "compliance_policy.delete"
+	AuditLicenseAdd              = "license.add"
+	AuditLicenseUpdate           = "license.update"
+	AuditLicenseDelete           = "license.delete"
+	AuditComputerLifecycle       = "computer.lifecycle"
+	AuditLifecycleImport         = "lifecycle.import"
+)
+
+const auditMaskedValue = "********"
+
+// Audit records an action done by the authenticated user. Errors are only logged
+// as the action has already been done and must not fail because of the audit log
+func (h *Handler) Audit(c echo.Context, action, target, before, after string) {
+	entry := consoledb.AuditEntry{
+		UserID:   h.GetUserID(c),
+		IP:       c.RealIP(),
+		TenantID: -1,
+		SiteID:   -1,
+		Action:   action,
+		Target:   target,
+		Before:   before,
+		After:    after,
+	}
+
+	tenantID, siteID, err := h.GetRouteScope(c)
+	if err == nil {
+		entry.TenantID = tenantID
+		entry.SiteID = siteID
+	}
+
+	if err := h.Model.AddAuditEntry(entry); err != nil {
+		log.Printf("[ERROR]: could not save the %s action in the audit log, reason: %v", action, err)
+	}
+}
+
+// AuditChanges records the fields that differ between two versions of the same object,
+// nothing is recorded if nothing changed. Passwords, secrets, tokens and keys are masked
+func (h *Handler) AuditChanges(c echo.Context, action, target string, before, after any) {
+	beforeFields, err := auditFields(before)
+	if err != nil {
+		log.Printf("[ERROR]: could not read the previous values for the audit log, reason: %v", err)
+		return
+	}
+
+	afterFields, err := auditFields(after)
+	if err != nil {
+		log.Printf("[ERROR]: could not read the new values for the audit log, reason: %v", err)
+		return
+	}
+
+	changedBefore := map[string]any{}
+	changedAfter := map[string]any{}
+	for k, v := range afterFields {
+		if reflect.DeepEqual(beforeFields[k], v) {
+			continue
+		}
+		changedBefore[k] = auditMask(k, beforeFields[k])
+		changedAfter[k] = auditMask(k, v)
+	}
+	for k, v := range beforeFields {
+		if _, ok := afterFields[k]; !ok {
+			changedBefore[k] = auditMask(k, v)
+		}
+	}
+
+	if len(changedBefore) == 0 && len(changedAfter) == 0 {
+		return
+	}
+
+	beforeValue, _ := json.Marshal(changedBefore)
+	afterValue, _ := json.Marshal(changedAfter)
+	h.Audit(c, action, target, string(beforeValue), string(afterValue))
+}
+
+func auditFields(v any) (map[string]any, error) {
+	fields := map[string]any{}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	// ent entities marshal their edges and the modification time, these aren't settings
+	delete(fields, "edges")
+	delete(fields, "modified")
+	return fields, nil
+}
+
+func auditMask(field string, value any) any {
+	if value == nil || value == "" {
+		return value
+	}
+
+	field = strings.ToLower(field)
+	for _, sensitive := range []string{"password", "secret", "token", "key"} {
+		if strings.Contains(field, sensitive) {
+			return auditMaskedValue
+		}
+	}
+	return value
+}
+
+// auditAgent identifies an agent in the audit log by its hostname and uuid
+func auditAgent(agent *openuem_ent.Agent) string {
+	return fmt.Sprintf("%s (%s)", agent.Hostname, agent.ID)
+}
+
+func (h *Handler) ListAuditLog(c echo.Context) error {
+	var err error
+	errMessage := ""
+
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	itemsPerPage, err := h.Model.GetDefaultItemsPerPage()
+	if err != nil {
+		log.Println("[ERROR]: could not get items per page from database")
+		itemsPerPage = 5
+	}
+
+	p := partials.NewPaginationAndSort(itemsPerPage)
+	p.GetPaginationAndSortParams(c.FormValue("page"), c.FormValue("pageSize"), c.FormValue("sortBy"), c.FormValue("sortOrder"), c.FormValue("currentSortBy"), itemsPerPage)
+
+	f := getAuditFilters(c)
+
+	p.NItems, err = h.Model.CountAuditEntries(f)
+	if err != nil {
+		errMessage = err.Error()
+	}
+
+	entries, err := h.Model.GetAuditEntriesByPage(p, f)
+	if err != nil {
+		errMessage = err.Error()
+	}
+
+	allTenants, err := h.Model.GetTenants()
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), false))
+	}
+
+	agentsExists, err := h.Model.AgentsExists(commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), false))
+	}
+
+	serversExists, err := h.Model.ServersExists()
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), false))
+	}
+
+	return RenderView(c, admin_views.AuditIndex(" | Audit", admin_views.Audit(c, p, f, entries, allTenants, errMessage, agentsExists, serversExists, itemsPerPage, commonInfo), commonInfo))
+}
+
+func (h *Handler) GenerateAuditCSVReport(c echo.Context) error {
+	fileName := uuid.NewString() + ".csv"
+	dstPath := filepath.Join(h.DownloadDir, fileName)
+	csvFile, err := os.Create(dstPath)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_create_file"), false))
+	}
+	defer func() {
+		if err := csvFile.Close(); err != nil {
+			log.Printf("[ERROR]: could not close CSV file, reason: %v", err)
+		}
+	}()
+
+	w := csv.NewWriter(csvFile)
+
+	p := partials.PaginationAndSort{}
+	p.GetPaginationAndSortParams("0", "0", c.FormValue("sortBy"), c.FormValue("sortOrder"), "", 5)
+
+	entries, err := h.Model.GetAuditEntries(p, getAuditFilters(c))
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "audit.could_not_get_entries", err.Error()), false))
+	}
+
+	w.Write([]string{"date", "user", "ip", "tenant_id", "site_id", "action", "target", "before", "after"})
+
+	for _, e := range entries {
+		record := []string{e.Created.Format("2006-01-02T15:04:05"), e.UserID, e.IP, strconv.Itoa(e.TenantID), strconv.Itoa(e.SiteID), e.Action, e.Target, e.Before, e.After}
+		if err := w.Write(record); err != nil {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_write_to_csv"), false))
+		}
+	}
+
+	w.Flush()
+
+	if err := w.Error(); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_write_to_csv"), false))
+	}
+
+	// Redirect to file
+	url := "/download/" + fileName
+	c.Response().Header().Set("HX-Redirect", url)
+
+	return c.String(http.StatusOK, "")
+}
+
+func getAuditFilters(c echo.Context) filters.AuditFilter {
+	return filters.AuditFilter{
+		Username:    c.FormValue("filterByUsername"),
+		Action:      c.FormValue("filterByAction"),
+		Target:      c.FormValue("filterByTarget"),
+		IP:          c.FormValue("filterByIP"),
+		CreatedFrom: c.FormValue("filterByCreatedDateFrom"),
+		CreatedTo:   c.FormValue("filterByCreatedDateTo"),
+	}
+}
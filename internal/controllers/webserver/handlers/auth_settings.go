@@ -12,14 +12,8 @@ import (
 )
 
 func (h *Handler) AuthenticationSettings(c echo.Context) error {
-	var err error
 	var successMessage string
 
-	commonInfo, err := h.GetCommonInfo(c)
-	if err != nil {
-		return err
-	}
-
 	if c.Request().Method == "POST" {
 		oidcProvider := c.FormValue("authentication-oidc-provider")
 		oidcServer := c.FormValue("authentication-oidc-server")
@@ -46,6 +40,16 @@ func (h *Handler) AuthenticationSettings(c echo.Context) error {
 			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "authentication.could_not_parse_use_passwords"), true))
 		}
 
+		requirePhishingResistant, err := strconv.ParseBool(c.FormValue("authentication-require-phishing-resistant-admins"))
+		if err != nil {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "authentication.could_not_parse_require_phishing_resistant"), true))
+		}
+
+		maxSessions, err := strconv.Atoi(c.FormValue("authentication-max-sessions"))
+		if err != nil || maxSessions < 0 {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "user_sessions.could_not_parse_max_sessions"), true))
+		}
+
 		if !useCertificates && !useOIDC {
 			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "authentication.at_least_one_auth_method"), true))
 		}
@@ -98,18 +102,83 @@ func (h *Handler) AuthenticationSettings(c echo.Context) error {
 			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "authentication.role_required"), true))
 		}
 
+		before, err := h.Model.GetAuthenticationSettings()
+		if err != nil {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "authentication.settings_not_saved", err.Error()), true))
+		}
+
 		if err := h.Model.SaveAuthenticationSettings(useCertificates, allowRegister, useOIDC, oidcProvider, oidcServer, oidcClientID, oidcRole, autoCreate, autoApprove, usePasswd, h.EncryptionMasterKey); err != nil {
 			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "authentication.settings_not_saved", err.Error()), true))
 		}
 
+		if after, err := h.Model.GetAuthenticationSettings(); err == nil {
+			h.AuditChanges(c, AuditAuthUpdate, "authentication", before, after)
+		}
+
+		requiredBefore, err := h.Model.RequirePhishingResistantAdmins()
+		if err != nil {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "authentication.settings_not_saved", err.Error()), true))
+		}
+
+		if err := h.Model.SaveRequirePhishingResistantAdmins(requirePhishingResistant); err != nil {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "authentication.settings_not_saved", err.Error()), true))
+		}
+
+		h.AuditChanges(c, AuditAuthUpdate, "authentication",
+			map[string]bool{"require_phishing_resistant_admins": requiredBefore},
+			map[string]bool{"require_phishing_resistant_admins": requirePhishingResistant})
+
+		maxSessionsBefore, err := h.Model.GetMaxUserSessions()
+		if err != nil {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "authentication.settings_not_saved", err.Error()), true))
+		}
+
+		if err := h.Model.SaveMaxUserSessions(maxSessions); err != nil {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "authentication.settings_not_saved", err.Error()), true))
+		}
+
+		h.AuditChanges(c, AuditAuthUpdate, "authentication",
+			map[string]int{"max_sessions": maxSessionsBefore},
+			map[string]int{"max_sessions": maxSessions})
+
 		successMessage = i18n.T(c.Request().Context(), "authentication.settings_saved")
 	}
 
+	return h.RenderAuthenticationSettings(c, "", successMessage)
+}
+
+// RenderAuthenticationSettings renders the authentication page, scimToken is only shown once right after it's generated
+func (h *Handler) RenderAuthenticationSettings(c echo.Context, scimToken, successMessage string) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
 	settings, err := h.Model.GetAuthenticationSettings()
 	if err != nil {
 		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "authentication.could_not_get_settings", err.Error()), true))
 	}
 
+	ldapSettings, err := h.Model.GetLDAPSettings()
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "ldap.could_not_get_settings", err.Error()), true))
+	}
+
+	scimSettings, err := h.Model.GetSCIMSettings()
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "scim.could_not_get_settings", err.Error()), true))
+	}
+
+	requirePhishingResistant, err := h.Model.RequirePhishingResistantAdmins()
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "authentication.could_not_get_settings", err.Error()), true))
+	}
+
+	maxSessions, err := h.Model.GetMaxUserSessions()
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "authentication.could_not_get_settings", err.Error()), true))
+	}
+
 	agentsExists, err := h.Model.AgentsExists(commonInfo)
 	if err != nil {
 		return RenderError(c, partials.ErrorMessage(err.Error(), false))
@@ -120,5 +189,5 @@ func (h *Handler) AuthenticationSettings(c echo.Context) error {
 		return RenderError(c, partials.ErrorMessage(err.Error(), false))
 	}
 
-	return RenderView(c, admin_views.AuthenticationSettingsIndex(" | Authentication Settings", admin_views.AuthenticationSettings(c, settings, agentsExists, serversExists, commonInfo, successMessage), commonInfo))
+	return RenderView(c, admin_views.AuthenticationSettingsIndex(" | Authentication Settings", admin_views.AuthenticationSettings(c, settings, requirePhishingResistant, maxSessions, ldapSettings, scimSettings, SCIMBaseURL(c), scimToken, agentsExists, serversExists, commonInfo, successMessage), commonInfo))
 }
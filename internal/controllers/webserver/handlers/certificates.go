@@ -24,32 +24,14 @@ func (h *Handler) GetCertificates(c echo.Context, successMessage string) error {
 		return err
 	}
 
-	f := filters.CertificateFilter{}
-
-	f.Description = c.FormValue("filterByDescription")
-	f.Username = c.FormValue("filterByUsername")
-
 	certTypes, err := h.Model.GetCertificatesTypes()
 	if err != nil {
 		return RenderError(c, partials.ErrorMessage(err.Error(), false))
 	}
 
-	filteredCertTypesOptions := []string{}
-	for index := range certTypes {
-		value := c.FormValue(fmt.Sprintf("filterByType%d", index))
-		if value != "" {
-			filteredCertTypesOptions = append(filteredCertTypesOptions, value)
-		}
-	}
-	f.TypeOptions = filteredCertTypesOptions
-
-	expiryFrom := c.FormValue("filterByExpiryDateFrom")
-	if expiryFrom != "" {
-		f.ExpiryFrom = expiryFrom
-	}
-	expiryTo := c.FormValue("filterByExpiryDateTo")
-	if expiryTo != "" {
-		f.ExpiryTo = expiryTo
+	f, err := h.GetCertificateFilters(c)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), false))
 	}
 
 	itemsPerPage, err := h.Model.GetDefaultItemsPerPage()
@@ -125,5 +107,40 @@ func (h *Handler) RevocateCertificate(c echo.Context) error {
 		return RenderError(c, partials.ErrorMessage(err.Error(), false))
 	}
 
+	h.Audit(c, AuditCertificateRevoke, fmt.Sprintf("%s (%s)", cert.Description, serial), "", "")
+
 	return h.GetCertificates(c, i18n.T(c.Request().Context(), "certificates.revocation_success"))
 }
+
+// GetCertificateFilters reads the filters applied to the certificates list
+func (h *Handler) GetCertificateFilters(c echo.Context) (filters.CertificateFilter, error) {
+	f := filters.CertificateFilter{}
+
+	f.Description = c.FormValue("filterByDescription")
+	f.Username = c.FormValue("filterByUsername")
+
+	certTypes, err := h.Model.GetCertificatesTypes()
+	if err != nil {
+		return f, err
+	}
+
+	filteredCertTypesOptions := []string{}
+	for index := range certTypes {
+		value := c.FormValue(fmt.Sprintf("filterByType%d", index))
+		if value != "" {
+			filteredCertTypesOptions = append(filteredCertTypesOptions, value)
+		}
+	}
+	f.TypeOptions = filteredCertTypesOptions
+
+	expiryFrom := c.FormValue("filterByExpiryDateFrom")
+	if expiryFrom != "" {
+		f.ExpiryFrom = expiryFrom
+	}
+	expiryTo := c.FormValue("filterByExpiryDateTo")
+	if expiryTo != "" {
+		f.ExpiryTo = expiryTo
+	}
+
+	return f, nil
+}
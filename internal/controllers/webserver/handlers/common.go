@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"errors"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -54,15 +55,22 @@ func (h *Handler) GetCommonInfo(c echo.Context) (*partials.CommonInfo, error) {
 	tenantID := c.Param("tenant")
 	siteID := c.Param("site")
 
+	info.Access, err = h.GetUserAccess(c)
+	if err != nil {
+		return nil, err
+	}
+
 	info.Tenants, err = h.Model.GetTenants()
 	if err != nil {
 		return nil, err
 	}
+	info.Tenants = slices.DeleteFunc(info.Tenants, func(t *ent.Tenant) bool { return !info.Access.CanAccessTenant(t.ID) })
 
 	if tenantID == "" {
 		if info.IsAdmin || info.IsProfile || info.IsTask {
 			info.TenantID = "-1"
 			info.SiteID = "-1"
+			info.Permission = info.Access.Level(-1, -1)
 			return &info, nil
 		}
 		tenant, err = h.Model.GetDefaultTenant()
@@ -92,6 +100,7 @@ func (h *Handler) GetCommonInfo(c echo.Context) (*partials.CommonInfo, error) {
 	if err != nil {
 		return nil, err
 	}
+	info.Sites = slices.DeleteFunc(info.Sites, func(s *ent.Site) bool { return !info.Access.CanAccessSite(tenant.ID, s.ID) })
 
 	if siteID != "" {
 		id, err := strconv.Atoi(siteID)
@@ -123,6 +132,12 @@ func (h *Handler) GetCommonInfo(c echo.Context) (*partials.CommonInfo, error) {
 		}
 	}
 
+	currentSiteID, err := strconv.Atoi(info.SiteID)
+	if err != nil {
+		currentSiteID = -1
+	}
+	info.Permission = info.Access.Level(tenant.ID, currentSiteID)
+
 	info.DetectRemoteAgents, err = h.Model.GetDefaultDetectRemoteAgents(info.TenantID)
 	if err != nil {
 		return nil, errors.New(i18n.T(c.Request().Context(), "settings.could_not_get_detect_remote_agents_setting"))
@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/invopop/ctxi18n/i18n"
+	"github.com/labstack/echo/v4"
+	"github.com/open-uem/openuem-console/internal/compliance"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/models"
+	"github.com/open-uem/openuem-console/internal/views/admin_views"
+	"github.com/open-uem/openuem-console/internal/views/compliance_views"
+	"github.com/open-uem/openuem-console/internal/views/filters"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+)
+
+func (h *Handler) ListCompliancePolicies(c echo.Context) error {
+	return h.RenderCompliancePolicies(c, "", "")
+}
+
+func (h *Handler) AddCompliancePolicy(c echo.Context) error {
+	tenantID, err := strconv.Atoi(c.Param("tenant"))
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "tenants.could_not_convert_to_int", err.Error()), true))
+	}
+
+	name := strings.TrimSpace(c.FormValue("policy-name"))
+	if name == "" {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "compliance.empty_name"), true))
+	}
+
+	policyType := c.FormValue("policy-type")
+	if !slices.Contains(compliance.Types, policyType) {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "compliance.invalid_type"), true))
+	}
+
+	appPattern := strings.TrimSpace(c.FormValue("policy-app"))
+	publisherPattern := strings.TrimSpace(c.FormValue("policy-publisher"))
+	if !compliance.ValidPattern(appPattern) && !compliance.ValidPattern(publisherPattern) {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "compliance.empty_pattern"), true))
+	}
+
+	minVersion := ""
+	if policyType == compliance.TypeRequired {
+		minVersion = strings.TrimSpace(c.FormValue("policy-min-version"))
+	}
+
+	p := consoledb.CompliancePolicy{
+		TenantID:         tenantID,
+		Name:             name,
+		Type:             policyType,
+		AppPattern:       appPattern,
+		PublisherPattern: publisherPattern,
+		MinVersion:       minVersion,
+		Enabled:          true,
+	}
+
+	if err := h.Model.AddCompliancePolicy(p); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "compliance.could_not_add", err.Error()), true))
+	}
+
+	h.Audit(c, AuditCompliancePolicyAdd, name, "", compliancePolicyDescription(p))
+	go h.EvaluateTenantCompliance(tenantID)
+
+	return h.RenderCompliancePolicies(c, i18n.T(c.Request().Context(), "compliance.added"), "")
+}
+
+func (h *Handler) EnableCompliancePolicy(c echo.Context) error {
+	p, err := h.getCompliancePolicy(c)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), true))
+	}
+
+	enabled, err := strconv.ParseBool(c.FormValue("policy-enabled"))
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "compliance.invalid_enabled"), true))
+	}
+
+	if err := h.Model.SetCompliancePolicyEnabled(p.ID, p.TenantID, enabled); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "compliance.could_not_update", err.Error()), true))
+	}
+
+	go h.EvaluateTenantCompliance(p.TenantID)
+
+	if enabled {
+		return h.RenderCompliancePolicies(c, i18n.T(c.Request().Context(), "compliance.has_been_enabled"), "")
+	}
+	return h.RenderCompliancePolicies(c, i18n.T(c.Request().Context(), "compliance.has_been_disabled"), "")
+}
+
+func (h *Handler) CompliancePolicyDelete(c echo.Context) error {
+	p, err := h.getCompliancePolicy(c)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), true))
+	}
+
+	return RenderConfirm(c, partials.ConfirmDelete(c, i18n.T(c.Request().Context(), "compliance.confirm_delete", p.Name), "", fmt.Sprintf("/tenant/%d/admin/compliance/%d", p.TenantID, p.ID)))
+}
+
+func (h *Handler) CompliancePolicyConfirmDelete(c echo.Context) error {
+	p, err := h.getCompliancePolicy(c)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), true))
+	}
+
+	if err := h.Model.DeleteCompliancePolicy(p.ID, p.TenantID); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "compliance.could_not_delete", err.Error()), true))
+	}
+
+	h.Audit(c, AuditCompliancePolicyDelete, p.Name, compliancePolicyDescription(p), "")
+	go h.EvaluateTenantCompliance(p.TenantID)
+
+	return h.RenderCompliancePolicies(c, i18n.T(c.Request().Context(), "compliance.deleted"), "")
+}
+
+func (h *Handler) RenderCompliancePolicies(c echo.Context, successMessage, errMessage string) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	tenantID, err := strconv.Atoi(c.Param("tenant"))
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "tenants.could_not_convert_to_int", err.Error()), true))
+	}
+	commonInfo.TenantID = c.Param("tenant")
+
+	policies, err := h.Model.GetCompliancePolicies(tenantID)
+	if err != nil {
+		successMessage = ""
+		errMessage = i18n.T(c.Request().Context(), "compliance.could_not_get", err.Error())
+	}
+
+	agentsExists, err := h.Model.AgentsExists(commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), false))
+	}
+
+	serversExists, err := h.Model.ServersExists()
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), false))
+	}
+
+	return RenderView(c, admin_views.CompliancePoliciesIndex(" | Compliance", admin_views.CompliancePolicies(c, policies, successMessage, errMessage, agentsExists, serversExists, commonInfo, h.GetAdminTenantName(commonInfo)), commonInfo))
+}
+
+func (h *Handler) ComplianceDashboard(c echo.Context) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	errMessage := ""
+
+	itemsPerPage, err := h.Model.GetDefaultItemsPerPage()
+	if err != nil {
+		log.Println("[ERROR]: could not get items per page from database")
+		itemsPerPage = 5
+	}
+
+	p := partials.NewPaginationAndSort(itemsPerPage)
+	p.GetPaginationAndSortParams(c.FormValue("page"), c.FormValue("pageSize"), c.FormValue("sortBy"), c.FormValue("sortOrder"), c.FormValue("currentSortBy"), itemsPerPage)
+
+	f := getComplianceViolationFilter(c)
+
+	summary, err := h.Model.GetComplianceSummary(commonInfo)
+	if err != nil {
+		errMessage = i18n.T(c.Request().Context(), "compliance.could_not_get_results", err.Error())
+	}
+
+	byPolicy, err := h.Model.GetPolicyViolations(commonInfo)
+	if err != nil {
+		errMessage = i18n.T(c.Request().Context(), "compliance.could_not_get_results", err.Error())
+	}
+
+	p.NItems, err = h.Model.CountComplianceViolations(f, commonInfo)
+	if err != nil {
+		errMessage = i18n.T(c.Request().Context(), "compliance.could_not_get_results", err.Error())
+	}
+
+	violations, err := h.Model.GetComplianceViolationsByPage(p, f, commonInfo)
+	if err != nil {
+		errMessage = i18n.T(c.Request().Context(), "compliance.could_not_get_results", err.Error())
+	}
+
+	return RenderView(c, compliance_views.ComplianceIndex(" | Compliance", compliance_views.ComplianceDashboard(c, p, f, summary, byPolicy, violations, errMessage, itemsPerPage, commonInfo), commonInfo))
+}
+
+func getComplianceViolationFilter(c echo.Context) filters.ComplianceViolationFilter {
+	return filters.ComplianceViolationFilter{
+		Hostname: c.FormValue("filterByHostname"),
+		Policy:   c.FormValue("filterByPolicy"),
+		App:      c.FormValue("filterByApp"),
+		Reasons:  filteredOptions(c, "Reason", "compliance.reason_", compliance.Reasons),
+	}
+}
+
+func (h *Handler) getCompliancePolicy(c echo.Context) (consoledb.CompliancePolicy, error) {
+	tenantID, err := strconv.Atoi(c.Param("tenant"))
+	if err != nil {
+		return consoledb.CompliancePolicy{}, errors.New(i18n.T(c.Request().Context(), "tenants.could_not_convert_to_int", err.Error()))
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return consoledb.CompliancePolicy{}, errors.New(i18n.T(c.Request().Context(), "compliance.invalid_id"))
+	}
+
+	p, err := h.Model.GetCompliancePolicy(id, tenantID)
+	if err != nil {
+		if errors.Is(err, models.ErrCompliancePolicyNotFound) {
+			return consoledb.CompliancePolicy{}, errors.New(i18n.T(c.Request().Context(), "compliance.not_found"))
+		}
+		return consoledb.CompliancePolicy{}, errors.New(i18n.T(c.Request().Context(), "compliance.could_not_get", err.Error()))
+	}
+
+	return p, nil
+}
+
+// compliancePolicyDescription is how a policy is shown in the audit log
+func compliancePolicyDescription(p consoledb.CompliancePolicy) string {
+	description := fmt.Sprintf("%s app=%q publisher=%q", p.Type, p.AppPattern, p.PublisherPattern)
+	if p.MinVersion != "" {
+		description += fmt.Sprintf(" min_version=%q", p.MinVersion)
+	}
+	return description
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"log"
+	"time"
+
+	"github.com/go-co-op/gocron/v2"
+	"github.com/open-uem/openuem-console/internal/compliance"
+	"github.com/open-uem/openuem-console/internal/models"
+)
+
+const complianceAgentsBatch = 100
+
+// StartComplianceJob schedules the job that evaluates the applications of the agents
+// against the compliance policies of their tenant
+func (h *Handler) StartComplianceJob() error {
+	if _, err := h.TaskScheduler.NewJob(
+		gocron.DurationJob(compliance.EvaluationInterval),
+		gocron.NewTask(h.EvaluateCompliance),
+		gocron.WithSingletonMode(gocron.LimitModeReschedule),
+	); err != nil {
+		log.Printf("[ERROR]: could not schedule the job that evaluates software compliance, reason: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// EvaluateCompliance evaluates the agents of every tenant
+func (h *Handler) EvaluateCompliance() {
+	tenants, err := h.Model.GetTenants()
+	if err != nil {
+		log.Printf("[ERROR]: could not get the tenants to evaluate software compliance, reason: %v", err)
+		return
+	}
+
+	policies, err := h.Model.GetEnabledCompliancePolicies()
+	if err != nil {
+		log.Printf("[ERROR]: could not get the compliance policies, reason: %v", err)
+		return
+	}
+
+	for _, t := range tenants {
+		h.evaluateTenantCompliance(t.ID, policies[t.ID])
+	}
+}
+
+// EvaluateTenantCompliance evaluates the agents of the tenant right away, it's used
+// when the policies of the tenant change
+func (h *Handler) EvaluateTenantCompliance(tenantID int) {
+	policies, err := h.Model.GetEnabledCompliancePolicies()
+	if err != nil {
+		log.Printf("[ERROR]: could not get the compliance policies, reason: %v", err)
+		return
+	}
+
+	h.evaluateTenantCompliance(tenantID, policies[tenantID])
+}
+
+// evaluateTenantCompliance saves the violations of every agent of the tenant and removes
+// the results of the agents that no longer belong to it. A tenant without enabled
+// policies has no results
+func (h *Handler) evaluateTenantCompliance(tenantID int, policies []compliance.Policy) {
+	if len(policies) == 0 {
+		if err := h.Model.DeleteComplianceResults(tenantID, time.Time{}); err != nil {
+			log.Printf("[ERROR]: could not delete the compliance results of tenant %d, reason: %v", tenantID, err)
+		}
+		return
+	}
+
+	start := time.Now()
+
+	for offset := 0; ; offset += complianceAgentsBatch {
+		agents, err := h.Model.GetComplianceAgents(tenantID, offset, complianceAgentsBatch)
+		if err != nil {
+			log.Printf("[ERROR]: could not get the applications of the agents of tenant %d, reason: %v", tenantID, err)
+			return
+		}
+
+		for _, a := range agents {
+			violations := compliance.Evaluate(policies, models.AgentComplianceApps(a))
+			if err := h.Model.SaveComplianceEvaluation(a, tenantID, violations); err != nil {
+				log.Printf("[ERROR]: could not save the compliance of agent %s, reason: %v", a.ID, err)
+			}
+		}
+
+		if len(agents) < complianceAgentsBatch {
+			break
+		}
+	}
+
+	if err := h.Model.DeleteComplianceResults(tenantID, start); err != nil {
+		log.Printf("[ERROR]: could not delete the old compliance results of tenant %d, reason: %v", tenantID, err)
+	}
+}
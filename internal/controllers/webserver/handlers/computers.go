@@ -25,9 +25,15 @@ import (
 	"github.com/open-uem/ent/task"
 	openuem_nats "github.com/open-uem/nats"
 	ansiblecfg "github.com/open-uem/openuem-ansible-config/ansible"
+	"github.com/open-uem/openuem-console/internal/dashboards"
+	"github.com/open-uem/openuem-console/internal/lifecycle"
+	"github.com/open-uem/openuem-console/internal/maintenance"
+	"github.com/open-uem/openuem-console/internal/rbac"
+	"github.com/open-uem/openuem-console/internal/savedviews"
 	"github.com/open-uem/openuem-console/internal/views/computers_views"
 	"github.com/open-uem/openuem-console/internal/views/filters"
 	"github.com/open-uem/openuem-console/internal/views/partials"
+	"github.com/open-uem/openuem-console/internal/webhooks"
 	"github.com/open-uem/utils"
 	"github.com/open-uem/wingetcfg/wingetcfg"
 	"gopkg.in/yaml.v3"
@@ -148,9 +154,14 @@ func (h *Handler) Overview(c echo.Context) error {
 	}
 	netbird := settings.AccessToken != ""
 
+	changes, err := h.Model.GetAgentInventoryChanges(agentId, agentTimelineLength)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "inventory_changes.could_not_get_changes", err.Error()), true))
+	}
+
 	offline := h.IsAgentOffline(c)
 
-	return RenderView(c, computers_views.InventoryIndex(" | Inventory", computers_views.Overview(c, p, agent, higherVersion, confirmDelete, successMessage, commonInfo, currentTenant, currentSite, allTenants, allSites, netbird, offline), commonInfo))
+	return RenderView(c, computers_views.InventoryIndex(" | Inventory", computers_views.Overview(c, p, agent, higherVersion, confirmDelete, successMessage, commonInfo, currentTenant, currentSite, allTenants, allSites, changes, netbird, offline), commonInfo))
 }
 
 func (h *Handler) Computer(c echo.Context) error {
@@ -186,8 +197,13 @@ func (h *Handler) Computer(c echo.Context) error {
 	}
 	netbird := settings.AccessToken != ""
 
+	hardwareLifecycle, err := h.Model.GetHardwareLifecycle(agentId)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "lifecycle.could_not_get", err.Error()), false))
+	}
+
 	offline := h.IsAgentOffline(c)
-	return RenderView(c, computers_views.InventoryIndex(" | Inventory", computers_views.Computer(c, p, agent, confirmDelete, commonInfo, netbird, offline), commonInfo))
+	return RenderView(c, computers_views.InventoryIndex(" | Inventory", computers_views.Computer(c, p, agent, hardwareLifecycle, confirmDelete, commonInfo, netbird, offline), commonInfo))
 }
 
 func (h *Handler) OperatingSystem(c echo.Context) error {
@@ -534,6 +550,11 @@ func (h *Handler) Apps(c echo.Context) error {
 		return RenderError(c, partials.ErrorMessage(err.Error(), true))
 	}
 
+	violations, err := h.Model.GetAgentComplianceViolations(agentId)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "compliance.could_not_get_results", err.Error()), true))
+	}
+
 	confirmDelete := c.QueryParam("delete") != ""
 
 	tenantID, err := strconv.Atoi(commonInfo.TenantID)
@@ -548,7 +569,7 @@ func (h *Handler) Apps(c echo.Context) error {
 
 	offline := h.IsAgentOffline(c)
 
-	return RenderView(c, computers_views.InventoryIndex(" | Inventory", computers_views.Apps(c, p, *f, a, apps, confirmDelete, itemsPerPage, commonInfo, netbird, offline), commonInfo))
+	return RenderView(c, computers_views.InventoryIndex(" | Inventory", computers_views.Apps(c, p, *f, a, apps, confirmDelete, itemsPerPage, commonInfo, netbird, offline, violations), commonInfo))
 }
 
 func (h *Handler) RemoteAssistance(c echo.Context) error {
@@ -607,6 +628,12 @@ func (h *Handler) ComputersList(c echo.Context, successMessage string, comesFrom
 		return err
 	}
 
+	if !comesFromDialog {
+		if u := h.applySavedView(c, savedviews.ListComputers, commonInfo); u != "" {
+			return c.Redirect(http.StatusFound, u)
+		}
+	}
+
 	currentPage := c.FormValue("page")
 	pageSize := c.FormValue("pageSize")
 	sortBy := c.FormValue("sortBy")
@@ -778,6 +805,37 @@ func (h *Handler) ComputersList(c echo.Context, successMessage string, comesFrom
 	}
 	f.IsRemote = filteredIsRemote
 
+	// the options of the lifecycle filters are translation keys
+	filteredHardwareAges := []string{}
+	for index := range lifecycle.Ages {
+		value := c.FormValue(fmt.Sprintf("filterByHardwareAge%d", index))
+		if comesFromDialog {
+			u, err := url.Parse(c.Request().Header.Get("Hx-Current-Url"))
+			if err == nil {
+				value = u.Query().Get(fmt.Sprintf("filterByHardwareAge%d", index))
+			}
+		}
+		if age := strings.TrimPrefix(value, "lifecycle.age_"); slices.Contains(lifecycle.Ages, age) {
+			filteredHardwareAges = append(filteredHardwareAges, age)
+		}
+	}
+	f.HardwareAges = filteredHardwareAges
+
+	filteredWarranties := []string{}
+	for index := range lifecycle.Warranties {
+		value := c.FormValue(fmt.Sprintf("filterByWarranty%d", index))
+		if comesFromDialog {
+			u, err := url.Parse(c.Request().Header.Get("Hx-Current-Url"))
+			if err == nil {
+				value = u.Query().Get(fmt.Sprintf("filterByWarranty%d", index))
+			}
+		}
+		if warranty := strings.TrimPrefix(value, "lifecycle.warranty_"); slices.Contains(lifecycle.Warranties, warranty) {
+			filteredWarranties = append(filteredWarranties, warranty)
+		}
+	}
+	f.Warranties = filteredWarranties
+
 	if c.FormValue("selectedApp") != "" {
 		f.WithApplication = c.FormValue("selectedApp")
 	}
@@ -817,8 +875,15 @@ func (h *Handler) ComputersList(c echo.Context, successMessage string, comesFrom
 		}
 	}
 
+	// the metadata filters are set by the links of the custom dashboards
+	f.Metadata = dashboards.Numbered(currentListValues(c, comesFromDialog), "Metadata")
+
 	tagId := c.FormValue("tagId")
 	agentId := c.FormValue("agentId")
+	if tagId != "" && agentId != "" && !commonInfo.Can(rbac.PermissionManage) {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "roles.forbidden"), false))
+	}
+
 	if c.Request().Method == "POST" && tagId != "" && agentId != "" {
 		err := h.Model.AddTagToAgent(agentId, tagId, commonInfo)
 		if err != nil {
@@ -833,6 +898,32 @@ func (h *Handler) ComputersList(c echo.Context, successMessage string, comesFrom
 		}
 	}
 
+	if comesFromDialog {
+		u, err := url.Parse(c.Request().Header.Get("Hx-Current-Url"))
+		if err == nil {
+			f.SelectedItems, err = strconv.Atoi(u.Query().Get("filterBySelectedItems"))
+			if err != nil {
+				f.SelectedItems = 0
+			}
+		}
+	} else {
+		f.SelectedItems, err = strconv.Atoi(c.FormValue("filterBySelectedItems"))
+		if err != nil {
+			f.SelectedItems = 0
+		}
+	}
+
+	// the IDs of every computer in the list are needed to select them all for a bulk action
+	allComputers, err := h.Model.GetComputerIDs(f, commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), false))
+	}
+	tmpAllComputers := []string{}
+	for _, id := range allComputers {
+		tmpAllComputers = append(tmpAllComputers, "\""+id+"\"")
+	}
+	f.SelectedAllAgents = "[" + strings.Join(tmpAllComputers, ",") + "]"
+
 	computers, err := h.Model.GetComputersByPage(p, f, commonInfo)
 	if err != nil {
 		return RenderView(c, computers_views.InventoryIndex(" | Inventory", partials.Error(c, err.Error(), "Computers", partials.GetNavigationUrl(commonInfo, "/computers"), commonInfo), commonInfo))
@@ -849,6 +940,13 @@ func (h *Handler) ComputersList(c echo.Context, successMessage string, comesFrom
 		refreshTime = 5
 	}
 
+	columns, err := h.computerColumns(c, computers, commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "saved_views.could_not_get_columns", err.Error()), false))
+	}
+
+	views := h.savedViewsMenu(c, savedviews.ListComputers, currentListValues(c, comesFromDialog), commonInfo)
+
 	if comesFromDialog {
 		currentUrl := c.Request().Header.Get("Hx-Current-Url")
 		if currentUrl != "" {
@@ -857,7 +955,7 @@ func (h *Handler) ComputersList(c echo.Context, successMessage string, comesFrom
 				q.Del("page")
 				q.Add("page", "1")
 				u.RawQuery = q.Encode()
-				return RenderViewWithReplaceUrl(c, computers_views.InventoryIndex("| Inventory", computers_views.Computers(c, p, f, computers, versions, vendors, models, tags, availableOSes, refreshTime, itemsPerPage, successMessage, commonInfo), commonInfo), u)
+				return RenderViewWithReplaceUrl(c, computers_views.InventoryIndex("| Inventory", computers_views.Computers(c, p, f, computers, versions, vendors, models, tags, availableOSes, refreshTime, itemsPerPage, successMessage, columns, views, commonInfo), commonInfo), u)
 			}
 		}
 	}
@@ -878,7 +976,7 @@ func (h *Handler) ComputersList(c echo.Context, successMessage string, comesFrom
 		return RenderError(c, partials.ErrorMessage(err.Error(), false))
 	}
 
-	return RenderView(c, computers_views.InventoryIndex(" | Inventory", computers_views.Computers(c, p, f, computers, versions, vendors, models, tags, availableOSes, refreshTime, itemsPerPage, successMessage, commonInfo), commonInfo))
+	return RenderView(c, computers_views.InventoryIndex(" | Inventory", computers_views.Computers(c, p, f, computers, versions, vendors, models, tags, availableOSes, refreshTime, itemsPerPage, successMessage, columns, views, commonInfo), commonInfo))
 }
 
 func (h *Handler) ComputerDeploy(c echo.Context, successMessage string) error {
@@ -1321,12 +1419,10 @@ func (h *Handler) PowerManagement(c echo.Context) error {
 			return RenderError(c, partials.ErrorMessage(err.Error(), false))
 		}
 
+		h.Audit(c, AuditComputerPower, auditAgent(agent), "", "wol")
+
 		return RenderSuccess(c, partials.SuccessMessage(i18n.T(c.Request().Context(), "agents.wol_success")))
 	case "off":
-		if h.NATSConnection == nil || !h.NATSConnection.IsConnected() {
-			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "nats.not_connected"), false))
-		}
-
 		action := openuem_nats.RebootOrRestart{}
 		var whenTime time.Time
 		when := c.FormValue("when")
@@ -1342,6 +1438,24 @@ func (h *Handler) PowerManagement(c echo.Context) error {
 			action.Date = whenTime
 		}
 
+		// a queued request is run as soon as the maintenance window opens
+		at := time.Now()
+		if !whenTime.IsZero() {
+			at = whenTime
+		}
+		next, err := h.queueForMaintenance(c, agent, maintenance.ActionPowerOff, openuem_nats.RebootOrRestart{}, when, at, commonInfo)
+		if err != nil {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "maintenance.could_not_queue", err.Error()), true))
+		}
+		if !next.IsZero() {
+			h.Audit(c, AuditComputerPower, auditAgent(agent), "", strings.TrimSpace("off "+when))
+			return RenderSuccess(c, partials.SuccessMessage(maintenanceQueuedMessage(c, next, commonInfo)))
+		}
+
+		if h.NATSConnection == nil || !h.NATSConnection.IsConnected() {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "nats.not_connected"), false))
+		}
+
 		data, err := json.Marshal(action)
 		if err != nil {
 			log.Printf("[ERROR]: could not marshall the Power Off request, reason: %v\n", err)
@@ -1352,12 +1466,10 @@ func (h *Handler) PowerManagement(c echo.Context) error {
 			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "nats.request_error", err.Error()), true))
 		}
 
+		h.Audit(c, AuditComputerPower, auditAgent(agent), "", strings.TrimSpace("off "+when))
+
 		return RenderSuccess(c, partials.SuccessMessage(i18n.T(c.Request().Context(), "agents.poweroff_success")))
 	case "reboot":
-		if h.NATSConnection == nil || !h.NATSConnection.IsConnected() {
-			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "nats.not_connected"), false))
-		}
-
 		action := openuem_nats.RebootOrRestart{}
 		var whenTime time.Time
 		when := c.FormValue("when")
@@ -1373,6 +1485,24 @@ func (h *Handler) PowerManagement(c echo.Context) error {
 			action.Date = whenTime
 		}
 
+		// a queued request is run as soon as the maintenance window opens
+		at := time.Now()
+		if !whenTime.IsZero() {
+			at = whenTime
+		}
+		next, err := h.queueForMaintenance(c, agent, maintenance.ActionReboot, openuem_nats.RebootOrRestart{}, when, at, commonInfo)
+		if err != nil {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "maintenance.could_not_queue", err.Error()), true))
+		}
+		if !next.IsZero() {
+			h.Audit(c, AuditComputerPower, auditAgent(agent), "", strings.TrimSpace("reboot "+when))
+			return RenderSuccess(c, partials.SuccessMessage(maintenanceQueuedMessage(c, next, commonInfo)))
+		}
+
+		if h.NATSConnection == nil || !h.NATSConnection.IsConnected() {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "nats.not_connected"), false))
+		}
+
 		data, err := json.Marshal(action)
 		if err != nil {
 			log.Printf("[ERROR]: could not marshall the Reboot request, reason: %v\n", err)
@@ -1383,6 +1513,8 @@ func (h *Handler) PowerManagement(c echo.Context) error {
 			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "nats.request_error", err.Error()), true))
 		}
 
+		h.Audit(c, AuditComputerPower, auditAgent(agent), "", strings.TrimSpace("reboot "+when))
+
 		return RenderSuccess(c, partials.SuccessMessage(i18n.T(c.Request().Context(), "agents.reboot_success")))
 	default:
 		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "agents.no_allowed_power_action"), false))
@@ -1548,10 +1680,17 @@ func (h *Handler) ComputerConfirmDelete(c echo.Context) error {
 		return h.ListAgents(c, "", "an error occurred getting uuid param", true)
 	}
 
+	agent, err := h.Model.GetAgentById(agentId, commonInfo)
+	if err != nil {
+		return h.ListAgents(c, "", err.Error(), true)
+	}
+
 	if err := h.Model.DeleteAgent(agentId, commonInfo); err != nil {
 		return h.ListAgents(c, "", err.Error(), true)
 	}
 
+	h.NotifyAgentWebhooks(commonInfo, webhooks.EventAgentDeleted, agent)
+
 	return h.ComputersList(c, i18n.T(c.Request().Context(), "computers.deleted"), true)
 }
 
@@ -2108,11 +2247,13 @@ func (h *Handler) RunProfile(c echo.Context) error {
 		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "profiles.invalid"), true))
 	}
 
-	if _, err := h.Model.GetAgentById(agentID, commonInfo); err != nil {
+	agentInfo, err := h.Model.GetAgentById(agentID, commonInfo)
+	if err != nil {
 		return RenderView(c, computers_views.InventoryIndex(" | Inventory", partials.Error(c, err.Error(), "Computers", partials.GetNavigationUrl(commonInfo, "/computers"), commonInfo), commonInfo))
 	}
 
-	if _, err := h.Model.GetProfileById(profileID, commonInfo); err != nil {
+	profile, err := h.Model.GetProfileById(profileID, commonInfo)
+	if err != nil {
 		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "profiles.not_found", err), true))
 	}
 
@@ -2122,6 +2263,14 @@ func (h *Handler) RunProfile(c echo.Context) error {
 		ProfileID: profileID,
 	}
 
+	next, err := h.queueForMaintenance(c, agentInfo, maintenance.ActionRunProfile, config, profile.Name, time.Now(), commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "maintenance.could_not_queue", err.Error()), true))
+	}
+	if !next.IsZero() {
+		return h.ComputerTasks(c, maintenanceQueuedMessage(c, next, commonInfo))
+	}
+
 	data, err := json.Marshal(config)
 	if err != nil {
 		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "profiles.could_not_marshal_config", err), true))
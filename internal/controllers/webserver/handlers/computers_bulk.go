@@ -0,0 +1,362 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/invopop/ctxi18n/i18n"
+	"github.com/labstack/echo/v4"
+	"github.com/linde12/gowol"
+	openuem_ent "github.com/open-uem/ent"
+	openuem_agent "github.com/open-uem/ent/agent"
+	openuem_nats "github.com/open-uem/nats"
+	"github.com/open-uem/openuem-console/internal/bulkactions"
+	"github.com/open-uem/openuem-console/internal/maintenance"
+	"github.com/open-uem/openuem-console/internal/views/computers_views"
+	"github.com/open-uem/openuem-console/internal/views/filters"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+)
+
+// bulkParams holds the validated parameters of a bulk action, shared by every target
+type bulkParams struct {
+	tag           *openuem_ent.Tag
+	site          *openuem_ent.Site
+	profile       *openuem_ent.Profile
+	endpointType  string
+	metadata      *openuem_ent.OrgMetadata
+	metadataValue string
+}
+
+// detail describes the parameters for the audit log
+func (p bulkParams) detail(action string) string {
+	switch action {
+	case bulkactions.ActionTagAdd, bulkactions.ActionTagRemove:
+		return p.tag.Tag
+	case bulkactions.ActionMoveSite:
+		return p.site.Description
+	case bulkactions.ActionRunProfile:
+		return p.profile.Name
+	case bulkactions.ActionEndpointType:
+		return p.endpointType
+	case bulkactions.ActionMetadata:
+		return p.metadata.Name + "=" + p.metadataValue
+	default:
+		return ""
+	}
+}
+
+// ComputersBulkForm shows the actions that can be run on the computers selected in the list
+func (h *Handler) ComputersBulkForm(c echo.Context) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	tags, err := h.Model.GetAllTags(commonInfo, filters.AgentFilter{})
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), false))
+	}
+
+	sites, err := h.bulkSites(commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "agents.could_not_get_sites"), false))
+	}
+
+	profiles, err := h.bulkProfiles(commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), false))
+	}
+
+	orgMetadata, err := h.Model.GetAllOrgMetadata(commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), false))
+	}
+
+	return RenderConfirm(c, computers_views.ComputersBulkForm(c, tags, sites, profiles, orgMetadata, commonInfo))
+}
+
+// ComputersBulkAction runs the action on each selected computer and reports the result of each one,
+// an error with one of the computers doesn't stop the action for the rest
+func (h *Handler) ComputersBulkAction(c echo.Context, action string) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	if !bulkactions.IsValid(action) {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "bulk.invalid_action"), false))
+	}
+
+	targets := bulkactions.ParseTargets(c.FormValue("agents"))
+	if len(targets) == 0 {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "bulk.no_targets"), false))
+	}
+	if len(targets) > bulkactions.MaxTargets {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "bulk.too_many_targets", bulkactions.MaxTargets), false))
+	}
+
+	params, err := h.bulkActionParams(c, action, commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), false))
+	}
+
+	results := []bulkactions.Result{}
+	for _, agentID := range targets {
+		result := bulkactions.Result{AgentID: agentID, Hostname: agentID}
+
+		agent, err := h.Model.GetAgentById(agentID, commonInfo)
+		if err != nil {
+			result.Status = bulkactions.StatusFailed
+			result.Message = i18n.T(c.Request().Context(), "bulk.agent_not_found")
+			results = append(results, result)
+			continue
+		}
+		result.Hostname = agent.Nickname
+		if result.Hostname == "" {
+			result.Hostname = agent.Hostname
+		}
+
+		result.Status, result.Message = h.runBulkAction(c, action, agent, params, commonInfo)
+		if result.Status != bulkactions.StatusFailed {
+			h.Audit(c, AuditComputerBulkAction, auditAgent(agent), "", strings.TrimSpace(action+" "+params.detail(action)))
+		}
+		results = append(results, result)
+	}
+
+	return RenderView(c, computers_views.InventoryIndex(" | Inventory", computers_views.ComputersBulkResults(c, action, params.detail(action), results, bulkactions.Summarize(results), commonInfo), commonInfo))
+}
+
+// bulkActionParams reads and validates the parameters of the action before it's run on any computer
+func (h *Handler) bulkActionParams(c echo.Context, action string, commonInfo *partials.CommonInfo) (bulkParams, error) {
+	params := bulkParams{}
+
+	switch action {
+	case bulkactions.ActionTagAdd, bulkactions.ActionTagRemove:
+		tags, err := h.Model.GetAllTags(commonInfo, filters.AgentFilter{})
+		if err != nil {
+			return params, err
+		}
+		for _, t := range tags {
+			if strconv.Itoa(t.ID) == c.FormValue("bulk-tag") {
+				params.tag = t
+			}
+		}
+		if params.tag == nil {
+			return params, errors.New(i18n.T(c.Request().Context(), "bulk.invalid_tag"))
+		}
+	case bulkactions.ActionMoveSite:
+		sites, err := h.bulkSites(commonInfo)
+		if err != nil {
+			return params, err
+		}
+		for _, s := range sites {
+			if strconv.Itoa(s.ID) == c.FormValue("bulk-site") {
+				params.site = s
+			}
+		}
+		if params.site == nil {
+			return params, errors.New(i18n.T(c.Request().Context(), "bulk.invalid_site"))
+		}
+	case bulkactions.ActionRunProfile:
+		profiles, err := h.bulkProfiles(commonInfo)
+		if err != nil {
+			return params, err
+		}
+		for _, p := range profiles {
+			if strconv.Itoa(p.ID) == c.FormValue("bulk-profile") {
+				params.profile = p
+			}
+		}
+		if params.profile == nil {
+			return params, errors.New(i18n.T(c.Request().Context(), "bulk.invalid_profile"))
+		}
+	case bulkactions.ActionEndpointType:
+		params.endpointType = c.FormValue("bulk-endpoint-type")
+		if openuem_agent.EndpointTypeValidator(openuem_agent.EndpointType(params.endpointType)) != nil {
+			return params, errors.New(i18n.T(c.Request().Context(), "agents.overview_endpoint_type_invalid"))
+		}
+	case bulkactions.ActionMetadata:
+		orgMetadata, err := h.Model.GetAllOrgMetadata(commonInfo)
+		if err != nil {
+			return params, err
+		}
+		for _, m := range orgMetadata {
+			if strconv.Itoa(m.ID) == c.FormValue("bulk-metadata") {
+				params.metadata = m
+			}
+		}
+		if params.metadata == nil {
+			return params, errors.New(i18n.T(c.Request().Context(), "bulk.invalid_metadata"))
+		}
+		params.metadataValue = c.FormValue("bulk-metadata-value")
+	}
+
+	return params, nil
+}
+
+// runBulkAction runs the action on one computer and returns its status and a message for the user
+func (h *Handler) runBulkAction(c echo.Context, action string, agent *openuem_ent.Agent, params bulkParams, commonInfo *partials.CommonInfo) (string, string) {
+	ctx := c.Request().Context()
+
+	switch action {
+	case bulkactions.ActionTagAdd:
+		if slices.ContainsFunc(agent.Edges.Tags, func(t *openuem_ent.Tag) bool { return t.ID == params.tag.ID }) {
+			return bulkactions.StatusDone, i18n.T(ctx, "bulk.tag_already_applied")
+		}
+		if err := h.Model.AddTagToAgent(agent.ID, strconv.Itoa(params.tag.ID), commonInfo); err != nil {
+			return bulkactions.StatusFailed, err.Error()
+		}
+		return bulkactions.StatusDone, i18n.T(ctx, "bulk.tag_added")
+	case bulkactions.ActionTagRemove:
+		if !slices.ContainsFunc(agent.Edges.Tags, func(t *openuem_ent.Tag) bool { return t.ID == params.tag.ID }) {
+			return bulkactions.StatusDone, i18n.T(ctx, "bulk.tag_not_applied")
+		}
+		if err := h.Model.RemoveTagFromAgent(agent.ID, strconv.Itoa(params.tag.ID), commonInfo); err != nil {
+			return bulkactions.StatusFailed, err.Error()
+		}
+		return bulkactions.StatusDone, i18n.T(ctx, "bulk.tag_removed")
+	case bulkactions.ActionMoveSite:
+		if len(agent.Edges.Site) == 1 && agent.Edges.Site[0].ID == params.site.ID {
+			return bulkactions.StatusDone, i18n.T(ctx, "bulk.already_in_site")
+		}
+		if err := h.Model.AssociateToTenantAndSite(agent.ID, commonInfo.TenantID, strconv.Itoa(params.site.ID)); err != nil {
+			return bulkactions.StatusFailed, err.Error()
+		}
+		return bulkactions.StatusDone, i18n.T(ctx, "agents.association_success")
+	case bulkactions.ActionReboot, bulkactions.ActionPowerOff:
+		queuedAction, subject, success := maintenance.ActionReboot, "agent.reboot.", "agents.reboot_success"
+		if action == bulkactions.ActionPowerOff {
+			queuedAction, subject, success = maintenance.ActionPowerOff, "agent.poweroff.", "agents.poweroff_success"
+		}
+
+		next, err := h.queueForMaintenance(c, agent, queuedAction, openuem_nats.RebootOrRestart{}, "", time.Now(), commonInfo)
+		if err != nil {
+			return bulkactions.StatusFailed, i18n.T(ctx, "maintenance.could_not_queue", err.Error())
+		}
+		if !next.IsZero() {
+			return bulkactions.StatusQueued, maintenanceQueuedMessage(c, next, commonInfo)
+		}
+
+		if h.NATSConnection == nil || !h.NATSConnection.IsConnected() {
+			return bulkactions.StatusFailed, i18n.T(ctx, "nats.not_connected")
+		}
+		data, err := json.Marshal(openuem_nats.RebootOrRestart{})
+		if err != nil {
+			return bulkactions.StatusFailed, err.Error()
+		}
+		if _, err := h.NATSConnection.Request(subject+agent.ID, data, time.Duration(h.NATSTimeout)*time.Second); err != nil {
+			return bulkactions.StatusFailed, i18n.T(ctx, "nats.request_error", err.Error())
+		}
+		return bulkactions.StatusDone, i18n.T(ctx, success)
+	case bulkactions.ActionWakeOnLAN:
+		macs := []string{}
+		if agent.MAC != "" {
+			macs = append(macs, agent.MAC)
+		}
+		for _, n := range agent.Edges.Networkadapters {
+			if n.MACAddress != "" && !slices.Contains(macs, n.MACAddress) {
+				macs = append(macs, n.MACAddress)
+			}
+		}
+
+		sent := 0
+		for _, mac := range macs {
+			if _, err := net.ParseMAC(mac); err != nil {
+				continue
+			}
+			packet, err := gowol.NewMagicPacket(mac)
+			if err != nil {
+				continue
+			}
+			if err := packet.Send("255.255.255.255"); err != nil {
+				return bulkactions.StatusFailed, err.Error()
+			}
+			sent++
+		}
+		if sent == 0 {
+			return bulkactions.StatusFailed, i18n.T(ctx, "bulk.no_mac")
+		}
+		return bulkactions.StatusDone, i18n.T(ctx, "agents.wol_success")
+	case bulkactions.ActionForceReport:
+		if h.NATSConnection == nil || !h.NATSConnection.IsConnected() {
+			return bulkactions.StatusFailed, i18n.T(ctx, "nats.not_connected")
+		}
+		publishCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if _, err := h.JetStream.Publish(publishCtx, "agent.report."+agent.ID, nil); err != nil {
+			return bulkactions.StatusFailed, err.Error()
+		}
+		return bulkactions.StatusDone, i18n.T(ctx, "agents.force_run_success")
+	case bulkactions.ActionRunProfile:
+		config := openuem_nats.CfgProfiles{AgentID: agent.ID, ProfileID: params.profile.ID}
+
+		next, err := h.queueForMaintenance(c, agent, maintenance.ActionRunProfile, config, params.profile.Name, time.Now(), commonInfo)
+		if err != nil {
+			return bulkactions.StatusFailed, i18n.T(ctx, "maintenance.could_not_queue", err.Error())
+		}
+		if !next.IsZero() {
+			return bulkactions.StatusQueued, maintenanceQueuedMessage(c, next, commonInfo)
+		}
+
+		if h.NATSConnection == nil || !h.NATSConnection.IsConnected() {
+			return bulkactions.StatusFailed, i18n.T(ctx, "nats.not_connected")
+		}
+		data, err := json.Marshal(config)
+		if err != nil {
+			return bulkactions.StatusFailed, i18n.T(ctx, "profiles.could_not_marshal_config", err)
+		}
+		if _, err := h.NATSConnection.Request("agent.runprofile."+agent.ID, data, time.Duration(h.NATSTimeout)*time.Second); err != nil {
+			return bulkactions.StatusFailed, i18n.T(ctx, "profiles.could_not_send_profile_request", err)
+		}
+		return bulkactions.StatusDone, i18n.T(ctx, "profiles.profile_run_request_sent")
+	case bulkactions.ActionEndpointType:
+		if err := h.Model.SaveEndpointType(agent.ID, params.endpointType, commonInfo); err != nil {
+			return bulkactions.StatusFailed, err.Error()
+		}
+		return bulkactions.StatusDone, i18n.T(ctx, "agents.overview_endpoint_type_success")
+	case bulkactions.ActionMetadata:
+		if err := h.Model.SaveMetadata(agent.ID, params.metadata.ID, params.metadataValue); err != nil {
+			return bulkactions.StatusFailed, err.Error()
+		}
+		return bulkactions.StatusDone, i18n.T(ctx, "agents.metadata_save_success")
+	default:
+		return bulkactions.StatusFailed, fmt.Sprintf("unknown action %s", action)
+	}
+}
+
+// bulkSites returns the sites the selected computers can be moved to, those of the current tenant
+func (h *Handler) bulkSites(commonInfo *partials.CommonInfo) ([]*openuem_ent.Site, error) {
+	tenantID, err := strconv.Atoi(commonInfo.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	return h.Model.GetSites(tenantID)
+}
+
+// bulkProfiles returns the profiles the user can run on the computers of the current tenant
+func (h *Handler) bulkProfiles(commonInfo *partials.CommonInfo) ([]*openuem_ent.Profile, error) {
+	profiles, err := h.Model.GetAllProfiles(commonInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := []*openuem_ent.Profile{}
+	for _, p := range profiles {
+		if len(p.Edges.Tenant) > 0 && strconv.Itoa(p.Edges.Tenant[0].ID) != commonInfo.TenantID {
+			continue
+		}
+		if len(p.Edges.Site) > 0 && commonInfo.SiteID != "-1" && strconv.Itoa(p.Edges.Site[0].ID) != commonInfo.SiteID {
+			continue
+		}
+		allowed = append(allowed, p)
+	}
+
+	return allowed, nil
+}
@@ -3,13 +3,17 @@ package handlers
 import (
 	"log"
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/metrics"
 	"github.com/open-uem/openuem-console/internal/views/charts"
 	"github.com/open-uem/openuem-console/internal/views/dashboard_views"
 	"github.com/open-uem/openuem-console/internal/views/filters"
+	"github.com/open-uem/openuem-console/internal/views/partials"
 )
 
 func (h *Handler) Dashboard(c echo.Context) error {
@@ -145,6 +149,28 @@ func (h *Handler) Dashboard(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
+	licenseWarnings, err := h.Model.GetLicenseWarnings(commonInfo)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	data.NOverDeployedLicenses = licenseWarnings.OverDeployed
+	data.NExpiredLicenses = licenseWarnings.Expired
+
+	warrantyWarnings, err := h.Model.GetWarrantyWarnings(commonInfo)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	data.NExpiringWarranties = warrantyWarnings.Expiring
+	data.NExpiredWarranties = warrantyWarnings.Expired
+
+	data.TrendPeriod = metrics.ParsePeriod(c.QueryParam("trend"))
+	snapshots, err := h.getFleetMetrics(commonInfo, data.TrendPeriod)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	data.NFleetSnapshots = len(snapshots)
+	data.Charts.FleetTrend = charts.FleetTrend(c.Request().Context(), commonInfo.Translator, snapshots)
+
 	h.CheckNATSComponentStatus(&data)
 
 	return RenderView(c, dashboard_views.DashboardIndex("| Dashboard", dashboard_views.Dashboard(c, data, commonInfo), commonInfo))
@@ -222,3 +248,17 @@ func (h *Handler) CheckNATSComponentStatus(data *dashboard_views.DashboardData)
 		wg.Wait()
 	}
 }
+
+// getFleetMetrics returns the snapshots of the selected tenant, or site, taken in the period
+func (h *Handler) getFleetMetrics(commonInfo *partials.CommonInfo, period int) ([]consoledb.FleetMetrics, error) {
+	tenantID, err := strconv.Atoi(commonInfo.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	siteID, err := strconv.Atoi(commonInfo.SiteID)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.Model.GetFleetMetrics(tenantID, siteID, metrics.Since(period, time.Now()))
+}
@@ -0,0 +1,451 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/invopop/ctxi18n/i18n"
+	"github.com/labstack/echo/v4"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/dashboards"
+	"github.com/open-uem/openuem-console/internal/models"
+	"github.com/open-uem/openuem-console/internal/savedviews"
+	"github.com/open-uem/openuem-console/internal/views/charts"
+	"github.com/open-uem/openuem-console/internal/views/custom_dashboards_views"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+)
+
+func (h *Handler) ListDashboards(c echo.Context) error {
+	return h.RenderDashboards(c, "", "")
+}
+
+// RenderDashboards shows the dashboards of the user and the ones shared in the tenant
+func (h *Handler) RenderDashboards(c echo.Context, successMessage, errMessage string) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	tenantID, err := strconv.Atoi(commonInfo.TenantID)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "tenants.could_not_convert_to_int", err.Error()), true))
+	}
+
+	items, err := h.Model.GetDashboards(h.GetUserID(c), tenantID)
+	if err != nil {
+		successMessage = ""
+		errMessage = i18n.T(c.Request().Context(), "dashboards.could_not_get", err.Error())
+	}
+
+	return RenderView(c, custom_dashboards_views.DashboardsIndex(" | Dashboards", custom_dashboards_views.Dashboards(c, items, h.GetUserID(c), successMessage, errMessage, commonInfo), commonInfo))
+}
+
+func (h *Handler) AddDashboard(c echo.Context) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	tenantID, err := strconv.Atoi(commonInfo.TenantID)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "tenants.could_not_convert_to_int", err.Error()), true))
+	}
+
+	d, err := getDashboardForm(c)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), true))
+	}
+	d.TenantID = tenantID
+	d.UserID = h.GetUserID(c)
+
+	d.ID, err = h.Model.AddDashboard(d)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "dashboards.could_not_add", err.Error()), true))
+	}
+
+	c.Response().Header().Set("HX-Push-Url", partials.GetNavigationUrl(commonInfo, fmt.Sprintf("/dashboards/%d", d.ID)))
+
+	return h.RenderDashboard(c, d, commonInfo, i18n.T(c.Request().Context(), "dashboards.added"), "")
+}
+
+func (h *Handler) ShowDashboard(c echo.Context) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	d, err := h.getDashboard(c, commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), true))
+	}
+
+	return h.RenderDashboard(c, d, commonInfo, "", "")
+}
+
+// RenderDashboard shows the widgets of a dashboard. The owner of the dashboard can also
+// rename it, share it and add, move or remove its widgets
+func (h *Handler) RenderDashboard(c echo.Context, d consoledb.Dashboard, commonInfo *partials.CommonInfo, successMessage, errMessage string) error {
+	orgMetadata, err := h.Model.GetAllOrgMetadata(commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), true))
+	}
+
+	catalog := custom_dashboards_views.WidgetCatalog{}
+	for _, dimension := range dashboards.Dimensions {
+		catalog.Dimensions = append(catalog.Dimensions, custom_dashboards_views.Option{Value: dimension, Label: i18n.T(c.Request().Context(), "dashboards.dimension_"+dimension)})
+	}
+	for _, m := range orgMetadata {
+		catalog.Dimensions = append(catalog.Dimensions, custom_dashboards_views.Option{Value: dashboards.MetadataDimension(m.ID), Label: m.Name})
+	}
+
+	owner := d.UserID == h.GetUserID(c)
+	if owner {
+		catalog.Views, err = h.Model.GetSavedViews(d.UserID, d.TenantID, savedviews.ListComputers)
+		if err != nil {
+			return RenderError(c, partials.ErrorMessage(err.Error(), true))
+		}
+	}
+
+	items, err := h.Model.GetDashboardWidgets(d.ID)
+	if err != nil {
+		successMessage = ""
+		errMessage = i18n.T(c.Request().Context(), "dashboards.could_not_get", err.Error())
+	}
+
+	widgets := []custom_dashboards_views.Widget{}
+	for _, item := range items {
+		widgets = append(widgets, h.renderWidget(c, item, catalog.Dimensions, commonInfo))
+	}
+
+	return RenderView(c, custom_dashboards_views.DashboardsIndex(" | Dashboards", custom_dashboards_views.Dashboard(c, d, widgets, catalog, owner, successMessage, errMessage, commonInfo), commonInfo))
+}
+
+func (h *Handler) UpdateDashboard(c echo.Context) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	current, err := h.getOwnDashboard(c, commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), true))
+	}
+
+	d, err := getDashboardForm(c)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), true))
+	}
+	d.ID = current.ID
+	d.TenantID = current.TenantID
+	d.UserID = current.UserID
+
+	if err := h.Model.UpdateDashboard(d); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "dashboards.could_not_update", err.Error()), true))
+	}
+
+	return h.RenderDashboard(c, d, commonInfo, i18n.T(c.Request().Context(), "dashboards.updated"), "")
+}
+
+func (h *Handler) DashboardDelete(c echo.Context) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	d, err := h.getOwnDashboard(c, commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), true))
+	}
+
+	return RenderConfirm(c, partials.ConfirmDelete(c, i18n.T(c.Request().Context(), "dashboards.confirm_delete", d.Name), "", partials.GetNavigationUrl(commonInfo, fmt.Sprintf("/dashboards/%d", d.ID))))
+}
+
+func (h *Handler) DashboardConfirmDelete(c echo.Context) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	d, err := h.getOwnDashboard(c, commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), true))
+	}
+
+	if err := h.Model.DeleteDashboard(d.ID, d.UserID, d.TenantID); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "dashboards.could_not_delete", err.Error()), true))
+	}
+
+	c.Response().Header().Set("HX-Push-Url", partials.GetNavigationUrl(commonInfo, "/dashboards"))
+
+	return h.RenderDashboards(c, i18n.T(c.Request().Context(), "dashboards.deleted"), "")
+}
+
+// AddDashboardWidget adds a widget of the catalog to a dashboard. The widget counts the
+// computers that match the filters of a saved view of the computers list, or every
+// computer if no view is chosen. The filters are copied, so changing or deleting the
+// view later doesn't change the widget
+func (h *Handler) AddDashboardWidget(c echo.Context) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	d, err := h.getOwnDashboard(c, commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), true))
+	}
+
+	w := consoledb.DashboardWidget{
+		DashboardID: d.ID,
+		Title:       strings.TrimSpace(c.FormValue("widget-title")),
+		Kind:        c.FormValue("widget-kind"),
+		Dimension:   c.FormValue("widget-dimension"),
+		Limit:       dashboards.DefaultLimit,
+	}
+
+	if w.Title == "" {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "dashboards.empty_title"), true))
+	}
+	if len(w.Title) > 100 {
+		w.Title = w.Title[:100]
+	}
+
+	if limit := strings.TrimSpace(c.FormValue("widget-limit")); limit != "" {
+		if w.Limit, err = strconv.Atoi(limit); err != nil {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "dashboards.invalid_limit", dashboards.MaxLimit), true))
+		}
+	}
+
+	orgMetadata, err := h.Model.GetAllOrgMetadata(commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), true))
+	}
+	ids := []int{}
+	for _, m := range orgMetadata {
+		ids = append(ids, m.ID)
+	}
+
+	if err := dashboards.Validate(w.Kind, w.Dimension, w.Limit, ids); err != nil {
+		switch {
+		case errors.Is(err, dashboards.ErrInvalidKind):
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "dashboards.invalid_kind"), true))
+		case errors.Is(err, dashboards.ErrInvalidDimension):
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "dashboards.invalid_dimension"), true))
+		default:
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "dashboards.invalid_limit", dashboards.MaxLimit), true))
+		}
+	}
+	if w.Kind == dashboards.KindCounter {
+		w.Dimension = ""
+		w.Limit = 0
+	}
+
+	if view := c.FormValue("widget-view"); view != "" {
+		id, err := strconv.Atoi(view)
+		if err != nil {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "saved_views.not_found"), true))
+		}
+		v, err := h.Model.GetSavedView(id, d.UserID, d.TenantID, savedviews.ListComputers)
+		if err != nil {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "saved_views.not_found"), true))
+		}
+		w.Query = v.Query
+	}
+
+	if err := h.Model.AddDashboardWidget(w); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "dashboards.could_not_add_widget", err.Error()), true))
+	}
+
+	return h.RenderDashboard(c, d, commonInfo, i18n.T(c.Request().Context(), "dashboards.widget_added"), "")
+}
+
+func (h *Handler) DeleteDashboardWidget(c echo.Context) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	d, err := h.getOwnDashboard(c, commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), true))
+	}
+
+	id, err := strconv.Atoi(c.Param("widget"))
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "dashboards.widget_not_found"), true))
+	}
+
+	if err := h.Model.DeleteDashboardWidget(id, d.ID); err != nil {
+		if errors.Is(err, models.ErrDashboardWidgetNotFound) {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "dashboards.widget_not_found"), true))
+		}
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "dashboards.could_not_delete_widget", err.Error()), true))
+	}
+
+	return h.RenderDashboard(c, d, commonInfo, i18n.T(c.Request().Context(), "dashboards.widget_deleted"), "")
+}
+
+// MoveDashboardWidget swaps a widget with the previous one, or the next one if up is false
+func (h *Handler) MoveDashboardWidget(c echo.Context, up bool) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	d, err := h.getOwnDashboard(c, commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), true))
+	}
+
+	id, err := strconv.Atoi(c.Param("widget"))
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "dashboards.widget_not_found"), true))
+	}
+
+	if err := h.Model.MoveDashboardWidget(id, d.ID, up); err != nil {
+		if errors.Is(err, models.ErrDashboardWidgetNotFound) {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "dashboards.widget_not_found"), true))
+		}
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "dashboards.could_not_update", err.Error()), true))
+	}
+
+	return h.RenderDashboard(c, d, commonInfo, "", "")
+}
+
+// renderWidget counts the computers of a widget. Errors are shown inside the widget so
+// the rest of the dashboard is still shown
+func (h *Handler) renderWidget(c echo.Context, w consoledb.DashboardWidget, dimensions []custom_dashboards_views.Option, commonInfo *partials.CommonInfo) custom_dashboards_views.Widget {
+	widget := custom_dashboards_views.Widget{
+		DashboardWidget: w,
+		Url:             computersListUrl(commonInfo, w.Query),
+	}
+
+	f := dashboards.Filter(w.Query)
+
+	if w.Kind == dashboards.KindCounter {
+		count, err := h.Model.CountAllComputers(f, commonInfo)
+		if err != nil {
+			widget.Error = i18n.T(c.Request().Context(), "dashboards.could_not_count", err.Error())
+		}
+		widget.Count = count
+		return widget
+	}
+
+	for _, d := range dimensions {
+		if d.Value == w.Dimension {
+			widget.DimensionLabel = d.Label
+		}
+	}
+
+	items, err := h.Model.CountComputersBy(w.Dimension, f, commonInfo)
+	if err != nil {
+		widget.Error = i18n.T(c.Request().Context(), "dashboards.could_not_count", err.Error())
+		return widget
+	}
+	if len(items) > w.Limit {
+		items = items[:w.Limit]
+	}
+
+	links := map[string]string{}
+	for i := range items {
+		items[i].Label = widgetLabel(c, w.Dimension, items[i])
+		widget.Count += items[i].Count
+
+		link := ""
+		if items[i].Value != "" {
+			link = computersListUrl(commonInfo, dashboards.DrillDown(w.Query, w.Dimension, items[i].Value))
+			links[items[i].Label] = link
+		}
+		widget.Items = append(widget.Items, custom_dashboards_views.WidgetItem{Label: items[i].Label, Count: items[i].Count, Url: link})
+	}
+
+	switch w.Kind {
+	case dashboards.KindPie:
+		widget.Chart = charts.WidgetPie(w.Title, items, links)
+	case dashboards.KindBar:
+		widget.Chart = charts.WidgetBar(w.Title, items, links)
+	}
+
+	return widget
+}
+
+// widgetLabel translates the values of the dimensions that are shown as translation keys
+func widgetLabel(c echo.Context, dimension string, item models.Breakdown) string {
+	switch {
+	case item.Value == "":
+		return i18n.T(c.Request().Context(), "Unknown")
+	case dimension == dashboards.DimensionRemote:
+		return i18n.T(c.Request().Context(), item.Value)
+	case dimension == dashboards.DimensionHardwareAge:
+		return i18n.T(c.Request().Context(), "lifecycle.age_"+item.Value)
+	case dimension == dashboards.DimensionWarranty:
+		return i18n.T(c.Request().Context(), "lifecycle.warranty_"+item.Value)
+	}
+	return item.Label
+}
+
+// computersListUrl returns the url of the computers list with the filters of a query
+func computersListUrl(commonInfo *partials.CommonInfo, query string) string {
+	u := partials.GetNavigationUrl(commonInfo, "/computers")
+	if query != "" {
+		u += "?" + query
+	}
+	return u
+}
+
+// getDashboardForm reads and validates the name and sharing of a dashboard
+func getDashboardForm(c echo.Context) (consoledb.Dashboard, error) {
+	d := consoledb.Dashboard{
+		Name:   strings.TrimSpace(c.FormValue("dashboard-name")),
+		Shared: c.FormValue("dashboard-shared") == "on",
+	}
+
+	if d.Name == "" {
+		return consoledb.Dashboard{}, errors.New(i18n.T(c.Request().Context(), "dashboards.empty_name"))
+	}
+	if len(d.Name) > 100 {
+		d.Name = d.Name[:100]
+	}
+
+	return d, nil
+}
+
+// getDashboard returns the dashboard of the request if the user can open it
+func (h *Handler) getDashboard(c echo.Context, commonInfo *partials.CommonInfo) (consoledb.Dashboard, error) {
+	tenantID, err := strconv.Atoi(commonInfo.TenantID)
+	if err != nil {
+		return consoledb.Dashboard{}, errors.New(i18n.T(c.Request().Context(), "tenants.could_not_convert_to_int", err.Error()))
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return consoledb.Dashboard{}, errors.New(i18n.T(c.Request().Context(), "dashboards.not_found"))
+	}
+
+	d, err := h.Model.GetDashboard(id, h.GetUserID(c), tenantID)
+	if err != nil {
+		if errors.Is(err, models.ErrDashboardNotFound) {
+			return consoledb.Dashboard{}, errors.New(i18n.T(c.Request().Context(), "dashboards.not_found"))
+		}
+		return consoledb.Dashboard{}, errors.New(i18n.T(c.Request().Context(), "dashboards.could_not_get", err.Error()))
+	}
+
+	return d, nil
+}
+
+// getOwnDashboard returns the dashboard of the request if the user created it, shared
+// dashboards can only be changed by their owner
+func (h *Handler) getOwnDashboard(c echo.Context, commonInfo *partials.CommonInfo) (consoledb.Dashboard, error) {
+	d, err := h.getDashboard(c, commonInfo)
+	if err != nil {
+		return consoledb.Dashboard{}, err
+	}
+
+	if d.UserID != h.GetUserID(c) {
+		return consoledb.Dashboard{}, errors.New(i18n.T(c.Request().Context(), "dashboards.not_owner"))
+	}
+
+	return d, nil
+}
@@ -11,6 +11,8 @@ import (
 	"github.com/invopop/ctxi18n/i18n"
 	"github.com/labstack/echo/v4"
 	openuem_nats "github.com/open-uem/nats"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/deployjobs"
 	"github.com/open-uem/openuem-console/internal/views/deploy_views"
 	"github.com/open-uem/openuem-console/internal/views/filters"
 	"github.com/open-uem/openuem-console/internal/views/partials"
@@ -246,7 +248,12 @@ func (h *Handler) DeployPackageToSelectedAgents(c echo.Context) error {
 		}
 	}
 
-	agents := strings.Split(checkedItems, ",")
+	agents := []string{}
+	for _, agent := range strings.Split(checkedItems, ",") {
+		if agent != "" && !slices.Contains(agents, agent) {
+			agents = append(agents, agent)
+		}
+	}
 	if len(agents) == 0 {
 		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "agents.no_selected_agents_to_deploy"), true))
 	}
@@ -256,63 +263,84 @@ func (h *Handler) DeployPackageToSelectedAgents(c echo.Context) error {
 		return RenderError(c, partials.ErrorMessage(err.Error(), true))
 	}
 
+	hostnames := map[string]string{}
 	for _, agent := range agents {
-		action := openuem_nats.DeployAction{
-			AgentId:         agent,
-			PackageId:       packageId,
-			PackageName:     packageName,
-			PackageBranch:   packageBranch,
-			PackageBrewType: packageBrewType,
-			PackageVerified: isPackageVerified,
-			// Repository:  "winget",
-		}
-
-		if install {
-			action.Action = "install"
-		} else {
-			action.Action = "uninstall"
-		}
-
-		actionBytes, err := json.Marshal(action)
+		agentInfo, err := h.Model.GetAgentById(agent, commonInfo)
 		if err != nil {
 			return RenderError(c, partials.ErrorMessage(err.Error(), true))
 		}
+		hostnames[agent] = agentInfo.Hostname
+	}
 
-		deploymentFailed, err := h.Model.DeploymentFailed(agent, packageId, commonInfo)
-		if err != nil {
-			return RenderError(c, partials.ErrorMessage(err.Error(), true))
-		}
+	tenantID, err := strconv.Atoi(commonInfo.TenantID)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "tenants.could_not_convert_to_int", err.Error()), true))
+	}
+
+	siteID, err := strconv.Atoi(commonInfo.SiteID)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "sites.could_not_convert_to_int", err.Error()), true))
+	}
+
+	job := consoledb.DeploymentJob{
+		TenantID:        tenantID,
+		SiteID:          siteID,
+		Action:          deployjobs.ActionUninstall,
+		PackageID:       packageId,
+		PackageName:     packageName,
+		PackageBranch:   packageBranch,
+		PackageBrewType: packageBrewType,
+		PackageVerified: isPackageVerified,
+		CreatedBy:       h.GetUserID(c),
+	}
+	if install {
+		job.Action = deployjobs.ActionInstall
+	}
+
+	job.ID, err = h.Model.AddDeploymentJob(job, hostnames)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "deploy_jobs.could_not_save", err.Error()), true))
+	}
 
-		if install {
-			if h.NATSConnection == nil || !h.NATSConnection.IsConnected() {
-				return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "nats.not_connected"), false))
-			}
-
-			if err := h.NATSConnection.Publish("agent.installpackage."+agent, actionBytes); err != nil {
-				return RenderError(c, partials.ErrorMessage(err.Error(), true))
-			}
-
-			if err := h.Model.SaveDeployInfo(&action, deploymentFailed, commonInfo); err != nil {
-				return RenderError(c, partials.ErrorMessage(err.Error(), true))
-			}
-		} else {
-			if h.NATSConnection == nil || !h.NATSConnection.IsConnected() {
-				return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "nats.not_connected"), false))
-			}
-
-			if err := h.NATSConnection.Publish("agent.uninstallpackage."+agent, actionBytes); err != nil {
-				return RenderError(c, partials.ErrorMessage(err.Error(), true))
-			}
-
-			if err := h.Model.SaveDeployInfo(&action, deploymentFailed, commonInfo); err != nil {
-				return RenderError(c, partials.ErrorMessage(err.Error(), true))
-			}
+	queued := 0
+	for _, agent := range agents {
+		if h.dispatchDeploymentJobTarget(c, job, agent, commonInfo) {
+			queued++
 		}
 	}
 
+	successMessage := i18n.T(c.Request().Context(), "uninstall.requested")
 	if install {
-		return RenderView(c, deploy_views.DeployIndex("| Deploy", deploy_views.Deploy(c, true, i18n.T(c.Request().Context(), "install.requested"), commonInfo), commonInfo))
-	} else {
-		return RenderView(c, deploy_views.DeployIndex("| Deploy", deploy_views.Deploy(c, false, i18n.T(c.Request().Context(), "uninstall.requested"), commonInfo), commonInfo))
+		successMessage = i18n.T(c.Request().Context(), "install.requested")
+	}
+	if queued > 0 {
+		successMessage += " " + i18n.T(c.Request().Context(), "maintenance.queued_count", queued)
+	}
+
+	c.Response().Header().Set("HX-Push-Url", partials.GetNavigationUrl(commonInfo, fmt.Sprintf("/deploy/jobs/%d", job.ID)))
+	return h.RenderDeploymentJob(c, job, successMessage, commonInfo)
+}
+
+// sendDeployAction asks the agent to install or uninstall the package and records the deployment
+func (h *Handler) sendDeployAction(action openuem_nats.DeployAction, commonInfo *partials.CommonInfo) error {
+	actionBytes, err := json.Marshal(action)
+	if err != nil {
+		return err
 	}
+
+	deploymentFailed, err := h.Model.DeploymentFailed(action.AgentId, action.PackageId, commonInfo)
+	if err != nil {
+		return err
+	}
+
+	subject := "agent.uninstallpackage." + action.AgentId
+	if action.Action == "install" {
+		subject = "agent.installpackage." + action.AgentId
+	}
+
+	if err := h.NATSConnection.Publish(subject, actionBytes); err != nil {
+		return err
+	}
+
+	return h.Model.SaveDeployInfo(&action, deploymentFailed, commonInfo)
 }
@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/invopop/ctxi18n/i18n"
+	"github.com/labstack/echo/v4"
+	openuem_nats "github.com/open-uem/nats"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/deployjobs"
+	"github.com/open-uem/openuem-console/internal/maintenance"
+	"github.com/open-uem/openuem-console/internal/models"
+	"github.com/open-uem/openuem-console/internal/views/deploy_views"
+	"github.com/open-uem/openuem-console/internal/views/filters"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+)
+
+func (h *Handler) ListDeploymentJobs(c echo.Context) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	itemsPerPage, err := h.Model.GetDefaultItemsPerPage()
+	if err != nil {
+		log.Println("[ERROR]: could not get items per page from database")
+		itemsPerPage = 5
+	}
+
+	p := partials.NewPaginationAndSort(itemsPerPage)
+	p.GetPaginationAndSortParams(c.FormValue("page"), c.FormValue("pageSize"), c.FormValue("sortBy"), c.FormValue("sortOrder"), c.FormValue("currentSortBy"), itemsPerPage)
+	if c.FormValue("sortBy") == "" {
+		p.SortBy = "created"
+		p.SortOrder = "desc"
+	}
+
+	p.NItems, err = h.Model.CountDeploymentJobs(commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "deploy_jobs.could_not_get", err.Error()), true))
+	}
+
+	jobs, err := h.Model.GetDeploymentJobsByPage(p, commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "deploy_jobs.could_not_get", err.Error()), true))
+	}
+
+	ids := []int{}
+	for _, j := range jobs {
+		ids = append(ids, j.ID)
+	}
+
+	progress, err := h.Model.GetDeploymentJobsProgress(ids)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "deploy_jobs.could_not_get", err.Error()), true))
+	}
+
+	return RenderView(c, deploy_views.DeployIndex("| Deploy", deploy_views.DeploymentJobs(c, p, jobs, progress, itemsPerPage, commonInfo), commonInfo))
+}
+
+func (h *Handler) ShowDeploymentJob(c echo.Context) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	j, err := h.getDeploymentJob(c, commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), true))
+	}
+
+	return h.RenderDeploymentJob(c, j, "", commonInfo)
+}
+
+// RetryDeploymentJob sends the request again to the agents of the job that failed or timed out
+func (h *Handler) RetryDeploymentJob(c echo.Context) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	j, err := h.getDeploymentJob(c, commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), true))
+	}
+
+	targets, err := h.Model.GetDeploymentJobTargets(j.ID, []string{deployjobs.TargetFailed, deployjobs.TargetTimedOut})
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "deploy_jobs.could_not_get", err.Error()), true))
+	}
+
+	retried := 0
+	for _, t := range targets {
+		reset, err := h.Model.RetryDeploymentJobTarget(j.ID, t.AgentID)
+		if err != nil {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "deploy_jobs.could_not_update", err.Error()), true))
+		}
+		if !reset {
+			continue
+		}
+
+		h.dispatchDeploymentJobTarget(c, j, t.AgentID, commonInfo)
+		retried++
+	}
+
+	h.Audit(c, AuditDeployJobRetry, strconv.Itoa(j.ID), "", fmt.Sprintf("%s (%s)", j.PackageName, j.PackageID))
+
+	return h.RenderDeploymentJob(c, j, i18n.T(c.Request().Context(), "deploy_jobs.retried", retried), commonInfo)
+}
+
+// CancelDeploymentJob stops the request for the agents of the job it hasn't been sent to yet,
+// the requests already sent can't be recalled from the agents
+func (h *Handler) CancelDeploymentJob(c echo.Context) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	j, err := h.getDeploymentJob(c, commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), true))
+	}
+
+	targets, err := h.Model.GetDeploymentJobTargets(j.ID, []string{deployjobs.TargetPending})
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "deploy_jobs.could_not_get", err.Error()), true))
+	}
+
+	cancelled := 0
+	for _, t := range targets {
+		if t.MaintenanceActionID != 0 {
+			// the queued action may have been sent meanwhile, then the agent is no longer pending
+			if err := h.Model.CancelMaintenanceAction(t.MaintenanceActionID, commonInfo); err != nil && !errors.Is(err, models.ErrMaintenanceActionNotFound) {
+				return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "deploy_jobs.could_not_update", err.Error()), true))
+			}
+		}
+
+		ok, err := h.Model.FinishDeploymentJobTarget(j.ID, t.AgentID, []string{deployjobs.TargetPending}, deployjobs.TargetCancelled, "")
+		if err != nil {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "deploy_jobs.could_not_update", err.Error()), true))
+		}
+		if ok {
+			cancelled++
+		}
+	}
+
+	h.Audit(c, AuditDeployJobCancel, strconv.Itoa(j.ID), "", fmt.Sprintf("%s (%s)", j.PackageName, j.PackageID))
+
+	return h.RenderDeploymentJob(c, j, i18n.T(c.Request().Context(), "deploy_jobs.cancelled", cancelled), commonInfo)
+}
+
+// RenderDeploymentJob shows how many agents of the job are in each status and the agents
+func (h *Handler) RenderDeploymentJob(c echo.Context, j consoledb.DeploymentJob, successMessage string, commonInfo *partials.CommonInfo) error {
+	errMessage := ""
+
+	if err := h.Model.SyncDeploymentJobTargets(j, time.Now()); err != nil {
+		log.Printf("[ERROR]: could not sync the agents of deployment job %d, reason: %v", j.ID, err)
+	}
+
+	itemsPerPage, err := h.Model.GetDefaultItemsPerPage()
+	if err != nil {
+		log.Println("[ERROR]: could not get items per page from database")
+		itemsPerPage = 5
+	}
+
+	p := partials.NewPaginationAndSort(itemsPerPage)
+	p.GetPaginationAndSortParams(c.FormValue("page"), c.FormValue("pageSize"), c.FormValue("sortBy"), c.FormValue("sortOrder"), c.FormValue("currentSortBy"), itemsPerPage)
+
+	f := filters.DeploymentJobTargetFilter{
+		Hostname: c.FormValue("filterByHostname"),
+		Statuses: filteredOptions(c, "Status", "deploy_jobs.status_", deployjobs.TargetStatuses),
+	}
+
+	progress, err := h.Model.GetDeploymentJobsProgress([]int{j.ID})
+	if err != nil {
+		errMessage = i18n.T(c.Request().Context(), "deploy_jobs.could_not_get", err.Error())
+	}
+
+	p.NItems, err = h.Model.CountDeploymentJobTargets(j.ID, f)
+	if err != nil {
+		errMessage = i18n.T(c.Request().Context(), "deploy_jobs.could_not_get", err.Error())
+	}
+
+	targets, err := h.Model.GetDeploymentJobTargetsByPage(p, j.ID, f)
+	if err != nil {
+		errMessage = i18n.T(c.Request().Context(), "deploy_jobs.could_not_get", err.Error())
+	}
+
+	refreshTime, err := h.Model.GetDefaultRefreshTime()
+	if err != nil {
+		log.Println("[ERROR]: could not get refresh time from database")
+		refreshTime = 5
+	}
+
+	return RenderView(c, deploy_views.DeployIndex("| Deploy", deploy_views.DeploymentJob(c, p, f, j, progress[j.ID], targets, refreshTime, itemsPerPage, successMessage, errMessage, commonInfo), commonInfo))
+}
+
+// dispatchDeploymentJobTarget sends the request of the job to a pending agent, or queues it
+// if the agent is outside its maintenance windows. The result is recorded in the job so
+// an agent that can't be reached doesn't stop the request for the others. It reports if the
+// request was queued
+func (h *Handler) dispatchDeploymentJobTarget(c echo.Context, j consoledb.DeploymentJob, agentID string, commonInfo *partials.CommonInfo) bool {
+	pending := []string{deployjobs.TargetPending}
+
+	fail := func(reason string) {
+		if _, err := h.Model.FinishDeploymentJobTarget(j.ID, agentID, pending, deployjobs.TargetFailed, reason); err != nil {
+			log.Printf("[ERROR]: could not save the result of agent %s in deployment job %d, reason: %v", agentID, j.ID, err)
+		}
+	}
+
+	action := openuem_nats.DeployAction{
+		AgentId:         agentID,
+		Action:          j.Action,
+		PackageId:       j.PackageID,
+		PackageName:     j.PackageName,
+		PackageBranch:   j.PackageBranch,
+		PackageBrewType: j.PackageBrewType,
+		PackageVerified: j.PackageVerified,
+	}
+
+	queueAction := maintenance.ActionUninstall
+	auditAction := AuditDeployUninstall
+	if j.Action == deployjobs.ActionInstall {
+		queueAction = maintenance.ActionInstall
+		auditAction = AuditDeployInstall
+	}
+
+	agentInfo, err := h.Model.GetAgentById(agentID, commonInfo)
+	if err != nil {
+		fail(err.Error())
+		return false
+	}
+
+	next, queuedID, err := h.queueMaintenanceAction(c, agentInfo, queueAction, action, fmt.Sprintf("%s (%s)", j.PackageName, j.PackageID), time.Now(), commonInfo)
+	if err != nil {
+		fail(i18n.T(c.Request().Context(), "maintenance.could_not_queue", err.Error()))
+		return false
+	}
+
+	if !next.IsZero() {
+		if err := h.Model.QueueDeploymentJobTarget(j.ID, agentID, queuedID); err != nil {
+			log.Printf("[ERROR]: could not link agent %s of deployment job %d to the maintenance queue, reason: %v", agentID, j.ID, err)
+		}
+	} else {
+		if h.NATSConnection == nil || !h.NATSConnection.IsConnected() {
+			fail(i18n.T(c.Request().Context(), "nats.not_connected"))
+			return false
+		}
+
+		if err := h.sendDeployAction(action, commonInfo); err != nil {
+			fail(err.Error())
+			return false
+		}
+
+		if _, err := h.Model.SendDeploymentJobTarget(j.ID, agentID, time.Now()); err != nil {
+			log.Printf("[ERROR]: could not save the result of agent %s in deployment job %d, reason: %v", agentID, j.ID, err)
+		}
+	}
+
+	h.Audit(c, auditAction, agentID, "", fmt.Sprintf("%s (%s)", j.PackageName, j.PackageID))
+
+	return !next.IsZero()
+}
+
+func (h *Handler) getDeploymentJob(c echo.Context, commonInfo *partials.CommonInfo) (consoledb.DeploymentJob, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return consoledb.DeploymentJob{}, errors.New(i18n.T(c.Request().Context(), "deploy_jobs.not_found"))
+	}
+
+	j, err := h.Model.GetDeploymentJob(id, commonInfo)
+	if err != nil {
+		if errors.Is(err, models.ErrDeploymentJobNotFound) {
+			return consoledb.DeploymentJob{}, errors.New(i18n.T(c.Request().Context(), "deploy_jobs.not_found"))
+		}
+		return consoledb.DeploymentJob{}, errors.New(i18n.T(c.Request().Context(), "deploy_jobs.could_not_get", err.Error()))
+	}
+
+	return j, nil
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"log"
+	"time"
+
+	"github.com/go-co-op/gocron/v2"
+	"github.com/open-uem/openuem-console/internal/deployjobs"
+)
+
+// StartDeploymentJobsJob schedules the job that records the results of the agents of running deployment jobs
+func (h *Handler) StartDeploymentJobsJob() error {
+	if _, err := h.TaskScheduler.NewJob(
+		gocron.DurationJob(deployjobs.CheckInterval),
+		gocron.NewTask(h.ProcessDeploymentJobs),
+		gocron.WithSingletonMode(gocron.LimitModeReschedule),
+	); err != nil {
+		log.Printf("[ERROR]: could not schedule the job that tracks deployment jobs, reason: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// ProcessDeploymentJobs records what the agents of every running deployment job reported,
+// so jobs finish and time out even if nobody is watching them
+func (h *Handler) ProcessDeploymentJobs() {
+	items, err := h.Model.GetRunningDeploymentJobs()
+	if err != nil {
+		log.Printf("[ERROR]: could not get the running deployment jobs, reason: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for _, j := range items {
+		if err := h.Model.SyncDeploymentJobTargets(j, now); err != nil {
+			log.Printf("[ERROR]: could not sync the agents of deployment job %d, reason: %v", j.ID, err)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/go-co-op/gocron/v2"
+	"github.com/open-uem/openuem-console/internal/metrics"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+)
+
+// StartFleetMetricsJob schedules the job that saves the daily snapshot of the fleet
+// counters shown in the trend charts of the dashboard
+func (h *Handler) StartFleetMetricsJob() error {
+	if _, err := h.TaskScheduler.NewJob(
+		gocron.DurationJob(metrics.SnapshotInterval),
+		gocron.NewTask(h.SnapshotFleetMetrics),
+		gocron.WithStartAt(gocron.WithStartImmediately()),
+		gocron.WithSingletonMode(gocron.LimitModeReschedule),
+	); err != nil {
+		log.Printf("[ERROR]: could not schedule the job that saves the fleet metrics, reason: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// SnapshotFleetMetrics saves the counters of every tenant and of each of its sites, and
+// removes the snapshots older than the retention
+func (h *Handler) SnapshotFleetMetrics() {
+	tenants, err := h.Model.GetTenants()
+	if err != nil {
+		log.Printf("[ERROR]: could not get the tenants to save the fleet metrics, reason: %v", err)
+		return
+	}
+
+	for _, t := range tenants {
+		sites, err := h.Model.GetSites(t.ID)
+		if err != nil {
+			log.Printf("[ERROR]: could not get the sites of tenant %d to save the fleet metrics, reason: %v", t.ID, err)
+			continue
+		}
+
+		siteIDs := []int{-1}
+		for _, s := range sites {
+			siteIDs = append(siteIDs, s.ID)
+		}
+
+		for _, siteID := range siteIDs {
+			h.snapshotFleetMetrics(t.ID, siteID)
+		}
+	}
+
+	if err := h.Model.DeleteOldFleetMetrics(time.Now().Add(-metrics.Retention)); err != nil {
+		log.Printf("[ERROR]: could not delete the old fleet metrics, reason: %v", err)
+	}
+}
+
+// snapshotFleetMetrics saves the counters of a tenant, or of one of its sites
+func (h *Handler) snapshotFleetMetrics(tenantID int, siteID int) {
+	commonInfo := &partials.CommonInfo{TenantID: strconv.Itoa(tenantID), SiteID: strconv.Itoa(siteID)}
+
+	snapshot, err := h.Model.TakeFleetMetrics(commonInfo)
+	if err != nil {
+		log.Printf("[ERROR]: could not count the fleet metrics of tenant %d and site %d, reason: %v", tenantID, siteID, err)
+		return
+	}
+
+	snapshot.TenantID = tenantID
+	snapshot.SiteID = siteID
+	if err := h.Model.SaveFleetMetrics(snapshot); err != nil {
+		log.Printf("[ERROR]: could not save the fleet metrics of tenant %d and site %d, reason: %v", tenantID, siteID, err)
+	}
+}
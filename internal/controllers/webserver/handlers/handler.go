@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/signal"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -17,6 +18,7 @@ import (
 	openuem_nats "github.com/open-uem/nats"
 	"github.com/open-uem/openuem-console/internal/controllers/sessions"
 	"github.com/open-uem/openuem-console/internal/models"
+	"github.com/open-uem/openuem-console/internal/vulnerabilities"
 )
 
 type Handler struct {
@@ -59,9 +61,15 @@ type Handler struct {
 	OIDCRedirectURI      string
 	CommonAppsJob        gocron.Job
 	EncryptionMasterKey  string
+	ReportsDir           string
+	reportJobsMutex      sync.Mutex
+	VulnerabilityFeedDir string
+	vulnerabilityFeed    *vulnerabilities.Feed
+	vulnerabilityFeedID  string
+	sessionsSeen         sync.Map
 }
 
-func NewHandler(model *models.Model, natsServers string, s *sessions.SessionManager, ts gocron.Scheduler, jwtKey, certPath, keyPath, sftpKeyPath, caCertPath, server, consolePort, authPort, tmpDownloadDir, domain, orgName, orgProvince, orgLocality, orgAddress, country, reverseProxyAuthPort, reverseProxyServer, serverReleasesFolder, commonFolder, version, encryptionMasterKey string, reEnableCertAuth, reEnablePasswdAuth bool, authLogger *log.Logger) *Handler {
+func NewHandler(model *models.Model, natsServers string, s *sessions.SessionManager, ts gocron.Scheduler, jwtKey, certPath, keyPath, sftpKeyPath, caCertPath, server, consolePort, authPort, tmpDownloadDir, domain, orgName, orgProvince, orgLocality, orgAddress, country, reverseProxyAuthPort, reverseProxyServer, serverReleasesFolder, commonFolder, reportsDir, vulnerabilityFeedDir, version, encryptionMasterKey string, reEnableCertAuth, reEnablePasswdAuth bool, authLogger *log.Logger) *Handler {
 
 	// Get NATS request timeout seconds
 	timeout, err := model.GetNATSTimeout()
@@ -103,6 +111,8 @@ func NewHandler(model *models.Model, natsServers string, s *sessions.SessionMana
 		ReenablePasswdAuth:   reEnablePasswdAuth,
 		AuthLogger:           authLogger,
 		EncryptionMasterKey:  encryptionMasterKey,
+		ReportsDir:           reportsDir,
+		VulnerabilityFeedDir: vulnerabilityFeedDir,
 	}
 
 	// Try to create the NATS Connection and start a job if it can't be possible to connect
@@ -110,6 +120,55 @@ func NewHandler(model *models.Model, natsServers string, s *sessions.SessionMana
 		log.Fatalf("[FATAL]: could not start NATS Connect job")
 	}
 
+	// Start the jobs that detect and send webhook events
+	if err := h.StartWebhookJobs(); err != nil {
+		log.Fatalf("[FATAL]: could not start webhook jobs")
+	}
+
+	// Start the job that evaluates the alert rules
+	if err := h.StartAlertsJob(); err != nil {
+		log.Fatalf("[FATAL]: could not start alerts job")
+	}
+
+	// Start the jobs that generate the scheduled reports
+	if err := h.StartReportScheduleJobs(); err != nil {
+		log.Fatalf("[FATAL]: could not start scheduled reports jobs")
+	}
+
+	// Start the job that records the inventory changes
+	if err := h.StartInventoryChangesJob(); err != nil {
+		log.Fatalf("[FATAL]: could not start inventory changes job")
+	}
+
+	// Start the job that sends the waves of the agent update rollouts
+	if err := h.StartRolloutsJob(); err != nil {
+		log.Fatalf("[FATAL]: could not start agent update rollouts job")
+	}
+
+	if err := h.StartMaintenanceQueueJob(); err != nil {
+		log.Fatalf("[FATAL]: could not start maintenance queue job")
+	}
+
+	if err := h.StartDeploymentJobsJob(); err != nil {
+		log.Fatalf("[FATAL]: could not start deployment jobs job")
+	}
+
+	if err := h.StartComplianceJob(); err != nil {
+		log.Fatalf("[FATAL]: could not start software compliance job")
+	}
+
+	if err := h.StartVulnerabilitiesJob(); err != nil {
+		log.Fatalf("[FATAL]: could not start vulnerabilities job")
+	}
+
+	if err := h.StartFleetMetricsJob(); err != nil {
+		log.Fatalf("[FATAL]: could not start fleet metrics job")
+	}
+
+	if err := h.StartLDAPSyncJob(); err != nil {
+		log.Fatalf("[FATAL]: could not start LDAP sync job")
+	}
+
 	return &h
 }
 
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/invopop/ctxi18n/i18n"
+	"github.com/labstack/echo/v4"
+	"github.com/open-uem/openuem-console/internal/inventory"
+	"github.com/open-uem/openuem-console/internal/views/filters"
+	"github.com/open-uem/openuem-console/internal/views/inventory_views"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+)
+
+// agentTimelineLength is the number of changes shown in the overview of a computer
+const agentTimelineLength = 20
+
+func (h *Handler) ListInventoryChanges(c echo.Context) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	errMessage := ""
+
+	itemsPerPage, err := h.Model.GetDefaultItemsPerPage()
+	if err != nil {
+		log.Println("[ERROR]: could not get items per page from database")
+		itemsPerPage = 5
+	}
+
+	p := partials.NewPaginationAndSort(itemsPerPage)
+	p.GetPaginationAndSortParams(c.FormValue("page"), c.FormValue("pageSize"), c.FormValue("sortBy"), c.FormValue("sortOrder"), c.FormValue("currentSortBy"), itemsPerPage)
+
+	f := filters.InventoryChangeFilter{
+		Hostname:    c.FormValue("filterByHostname"),
+		Item:        c.FormValue("filterByItem"),
+		Types:       filteredOptions(c, "Type", "inventory_changes.type_", inventory.Types),
+		Actions:     filteredOptions(c, "Action", "inventory_changes.action_", inventory.Actions),
+		CreatedFrom: c.FormValue("filterByCreatedDateFrom"),
+		CreatedTo:   c.FormValue("filterByCreatedDateTo"),
+	}
+
+	p.NItems, err = h.Model.CountInventoryChanges(f, commonInfo)
+	if err != nil {
+		errMessage = i18n.T(c.Request().Context(), "inventory_changes.could_not_get_changes", err.Error())
+	}
+
+	changes, err := h.Model.GetInventoryChangesByPage(p, f, commonInfo)
+	if err != nil {
+		errMessage = i18n.T(c.Request().Context(), "inventory_changes.could_not_get_changes", err.Error())
+	}
+
+	return RenderView(c, inventory_views.InventoryChangesIndex(" | Inventory", inventory_views.InventoryChanges(c, p, f, changes, errMessage, itemsPerPage, commonInfo), commonInfo))
+}
+
+// filteredOptions returns the values checked in a FilterByOptions whose options are translation
+// keys made of the prefix and a value. Unknown values are ignored
+func filteredOptions(c echo.Context, field, prefix string, values []string) []string {
+	filtered := []string{}
+	for index := range values {
+		value := strings.TrimPrefix(c.FormValue(fmt.Sprintf("filterBy%s%d", field, index)), prefix)
+		if slices.Contains(values, value) {
+			filtered = append(filtered, value)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"log"
+
+	"github.com/go-co-op/gocron/v2"
+	"github.com/open-uem/openuem-console/internal/inventory"
+)
+
+const inventoryAgentsBatch = 100
+
+// StartInventoryChangesJob schedules the job that records the changes in the inventory
+// reported by the agents. Agents overwrite their inventory on every report, so the console
+// compares it with a snapshot saved the previous time
+func (h *Handler) StartInventoryChangesJob() error {
+	if _, err := h.TaskScheduler.NewJob(
+		gocron.DurationJob(inventory.DetectionInterval),
+		gocron.NewTask(h.DetectInventoryChanges),
+		gocron.WithSingletonMode(gocron.LimitModeReschedule),
+	); err != nil {
+		log.Printf("[ERROR]: could not schedule the job that detects inventory changes, reason: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// DetectInventoryChanges compares the inventory of every admitted agent with its snapshot
+func (h *Handler) DetectInventoryChanges() {
+	for offset := 0; ; offset += inventoryAgentsBatch {
+		agents, err := h.Model.GetInventoryAgents(offset, inventoryAgentsBatch)
+		if err != nil {
+			log.Printf("[ERROR]: could not get the inventory of the agents, reason: %v", err)
+			return
+		}
+
+		for _, a := range agents {
+			if _, err := h.Model.DetectInventoryChanges(a); err != nil {
+				log.Printf("[ERROR]: could not detect inventory changes for agent %s, reason: %v", a.ID, err)
+			}
+		}
+
+		if len(agents) < inventoryAgentsBatch {
+			return
+		}
+	}
+}
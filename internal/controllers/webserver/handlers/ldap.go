@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/invopop/ctxi18n/i18n"
+	"github.com/labstack/echo/v4"
+	"github.com/open-uem/ent"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/directory"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+	"github.com/open-uem/utils"
+)
+
+func (h *Handler) SaveLDAPSettings(c echo.Context) error {
+	before, err := h.Model.GetLDAPSettings()
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "ldap.could_not_get_settings", err.Error()), true))
+	}
+
+	s, err := h.ldapSettingsFromForm(c, before)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), true))
+	}
+
+	if err := h.Model.SaveLDAPSettings(s); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "ldap.settings_not_saved", err.Error()), true))
+	}
+
+	h.AuditChanges(c, AuditAuthUpdate, "ldap", before, s)
+
+	return h.RenderAuthenticationSettings(c, "", i18n.T(c.Request().Context(), "ldap.settings_saved"))
+}
+
+// TestLDAPSettings connects to the directory with the settings of the form, without
+// saving them, and counts the users allowed to use the console
+func (h *Handler) TestLDAPSettings(c echo.Context) error {
+	current, err := h.Model.GetLDAPSettings()
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "ldap.could_not_get_settings", err.Error()), true))
+	}
+
+	s, err := h.ldapSettingsFromForm(c, current)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), true))
+	}
+
+	members, err := h.directoryMembers(s)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "ldap.test_failed", err.Error()), true))
+	}
+
+	return RenderSuccess(c, partials.SuccessMessage(i18n.T(c.Request().Context(), "ldap.test_succeeded", len(members))))
+}
+
+// SyncLDAPUsers syncs the console users with the directory right away
+func (h *Handler) SyncLDAPUsers(c echo.Context) error {
+	s, err := h.Model.GetLDAPSettings()
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "ldap.could_not_get_settings", err.Error()), true))
+	}
+
+	if !s.Enabled {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "ldap.not_enabled"), true))
+	}
+
+	result := h.syncDirectoryUsers(s)
+	if result.Error != "" {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "ldap.sync_failed", result.Error), true))
+	}
+
+	return h.RenderAuthenticationSettings(c, "", i18n.T(c.Request().Context(), "ldap.sync_done", result.Created, result.Updated, result.Disabled, result.Skipped))
+}
+
+// ldapSettingsFromForm reads the directory settings of the authentication page, the bind
+// password is only replaced when a new one is entered
+func (h *Handler) ldapSettingsFromForm(c echo.Context, current consoledb.LDAPSettings) (consoledb.LDAPSettings, error) {
+	var err error
+	s := current
+
+	for _, option := range []struct {
+		name  string
+		value *bool
+	}{
+		{"ldap-enabled", &s.Enabled},
+		{"ldap-start-tls", &s.StartTLS},
+		{"ldap-insecure-skip-verify", &s.InsecureSkipVerify},
+		{"ldap-sync", &s.Sync},
+	} {
+		if *option.value, err = strconv.ParseBool(c.FormValue(option.name)); err != nil {
+			return s, errors.New(i18n.T(c.Request().Context(), "ldap.could_not_parse_option", option.name))
+		}
+	}
+
+	s.URL = strings.TrimSpace(c.FormValue("ldap-url"))
+	s.BindDN = strings.TrimSpace(c.FormValue("ldap-bind-dn"))
+	s.SearchBase = strings.TrimSpace(c.FormValue("ldap-search-base"))
+	s.UserFilter = strings.TrimSpace(c.FormValue("ldap-user-filter"))
+	s.GroupFilter = strings.TrimSpace(c.FormValue("ldap-group-filter"))
+	s.UsernameAttribute = strings.TrimSpace(c.FormValue("ldap-username-attribute"))
+	s.NameAttribute = strings.TrimSpace(c.FormValue("ldap-name-attribute"))
+	s.EmailAttribute = strings.TrimSpace(c.FormValue("ldap-email-attribute"))
+	s.PhoneAttribute = strings.TrimSpace(c.FormValue("ldap-phone-attribute"))
+
+	if password := c.FormValue("ldap-bind-password"); password != "" {
+		if h.EncryptionMasterKey == "" {
+			return s, errors.New(i18n.T(c.Request().Context(), "ldap.empty_encryption_master_key"))
+		}
+
+		s.BindPassword, err = utils.EncryptSensitiveField(password, h.EncryptionMasterKey)
+		if err != nil {
+			return s, errors.New(i18n.T(c.Request().Context(), "ldap.settings_not_saved", err.Error()))
+		}
+	}
+
+	if s.BindDN == "" {
+		s.BindPassword = ""
+	}
+
+	if !s.Enabled {
+		return s, nil
+	}
+
+	if !directory.ValidURL(s.URL) {
+		return s, errors.New(i18n.T(c.Request().Context(), "ldap.url_not_valid"))
+	}
+
+	if s.SearchBase == "" {
+		return s, errors.New(i18n.T(c.Request().Context(), "ldap.search_base_required"))
+	}
+
+	if s.GroupFilter == "" {
+		return s, errors.New(i18n.T(c.Request().Context(), "ldap.group_filter_required"))
+	}
+
+	if !directory.ValidFilter(s.UserFilter) || !directory.ValidFilter(s.GroupFilter) {
+		return s, errors.New(i18n.T(c.Request().Context(), "ldap.filter_not_valid"))
+	}
+
+	if s.UsernameAttribute == "" {
+		return s, errors.New(i18n.T(c.Request().Context(), "ldap.username_attribute_required"))
+	}
+
+	return s, nil
+}
+
+// directoryConfig returns the settings used to connect to the directory with the bind
+// password decrypted
+func (h *Handler) directoryConfig(s consoledb.LDAPSettings) (directory.Config, error) {
+	password := s.BindPassword
+	if password != "" {
+		var err error
+		password, err = utils.DecryptSensitiveField(password, h.EncryptionMasterKey)
+		if err != nil {
+			return directory.Config{}, err
+		}
+	}
+
+	return directory.Config{
+		URL:                s.URL,
+		StartTLS:           s.StartTLS,
+		InsecureSkipVerify: s.InsecureSkipVerify,
+		BindDN:             s.BindDN,
+		BindPassword:       password,
+		SearchBase:         s.SearchBase,
+		UserFilter:         s.UserFilter,
+		GroupFilter:        s.GroupFilter,
+		UsernameAttribute:  s.UsernameAttribute,
+		NameAttribute:      s.NameAttribute,
+		EmailAttribute:     s.EmailAttribute,
+		PhoneAttribute:     s.PhoneAttribute,
+	}, nil
+}
+
+// directoryLogin checks the password of the user with the directory and creates, updates
+// or enables again its console account
+func (h *Handler) directoryLogin(s consoledb.LDAPSettings, username, password string) (*ent.User, error) {
+	config, err := h.directoryConfig(s)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := directory.Authenticate(config, username, password)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := h.Model.SaveDirectoryUser(*entry); err != nil {
+		return nil, err
+	}
+
+	return h.Model.GetUserById(entry.Username)
+}
+
+// disableDirectoryUser prevents a user the directory no longer allows to use the console
+// from signing in, and signs the user out
+func (h *Handler) disableDirectoryUser(userID string) error {
+	if err := h.Model.DisableDirectoryUser(userID); err != nil {
+		return err
+	}
+
+	if err := h.Model.DeleteUserSessions(userID); err != nil {
+		log.Printf("[ERROR]: could not delete the sessions of user %s disabled by the directory, reason: %v", userID, err)
+	}
+
+	if h.AuthLogger != nil {
+		h.AuthLogger.Printf("user %s has been disabled as the directory no longer allows it to use the console", userID)
+	}
+
+	return nil
+}
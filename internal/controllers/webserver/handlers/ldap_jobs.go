@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+
+	"github.com/go-co-op/gocron/v2"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/directory"
+	"github.com/open-uem/openuem-console/internal/models"
+)
+
+// StartLDAPSyncJob schedules the job that creates, updates and disables the console
+// users to match the users the directory allows to use the console
+func (h *Handler) StartLDAPSyncJob() error {
+	if _, err := h.TaskScheduler.NewJob(
+		gocron.DurationJob(directory.SyncInterval),
+		gocron.NewTask(h.SyncDirectoryUsers),
+		gocron.WithSingletonMode(gocron.LimitModeReschedule),
+	); err != nil {
+		log.Printf("[ERROR]: could not schedule the job that syncs the users with the directory, reason: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// SyncDirectoryUsers syncs the console users if the directory is enabled and the
+// sync has been turned on
+func (h *Handler) SyncDirectoryUsers() {
+	s, err := h.Model.GetLDAPSettings()
+	if err != nil {
+		log.Printf("[ERROR]: could not get the directory settings to sync the users, reason: %v", err)
+		return
+	}
+
+	if !s.Enabled || !s.Sync {
+		return
+	}
+
+	h.syncDirectoryUsers(s)
+}
+
+func (h *Handler) syncDirectoryUsers(s consoledb.LDAPSettings) consoledb.LDAPSyncResult {
+	result := consoledb.LDAPSyncResult{}
+
+	members, err := h.directoryMembers(s)
+	if err != nil {
+		log.Printf("[ERROR]: could not get the users allowed to use the console from the directory, reason: %v", err)
+		result.Error = err.Error()
+		h.saveDirectorySyncResult(result)
+		return result
+	}
+
+	allowed := map[string]bool{}
+	for _, e := range members {
+		allowed[e.Username] = true
+
+		change, err := h.Model.SaveDirectoryUser(e)
+		if err != nil {
+			if errors.Is(err, models.ErrDirectoryUserConflict) {
+				log.Printf("[INFO]: user %s of the directory was skipped as a console user with the same username exists", e.Username)
+			} else {
+				log.Printf("[ERROR]: could not save user %s of the directory, reason: %v", e.Username, err)
+			}
+			result.Skipped++
+			continue
+		}
+
+		switch change {
+		case models.DirectoryUserCreated:
+			result.Created++
+		case models.DirectoryUserUpdated:
+			result.Updated++
+		}
+	}
+
+	// a search that returns nobody is more likely a wrong filter than an empty group,
+	// disabling every user would lock everyone out of the console
+	if len(members) == 0 {
+		result.Error = directory.ErrNoMembers.Error()
+		h.saveDirectorySyncResult(result)
+		return result
+	}
+
+	users, err := h.Model.GetDirectoryUsers()
+	if err != nil {
+		log.Printf("[ERROR]: could not get the users managed by the directory, reason: %v", err)
+		result.Error = err.Error()
+		h.saveDirectorySyncResult(result)
+		return result
+	}
+
+	for _, u := range users {
+		if u.Disabled || allowed[u.UserID] {
+			continue
+		}
+
+		if err := h.disableDirectoryUser(u.UserID); err != nil {
+			log.Printf("[ERROR]: could not disable user %s, reason: %v", u.UserID, err)
+			continue
+		}
+		result.Disabled++
+	}
+
+	h.saveDirectorySyncResult(result)
+	return result
+}
+
+func (h *Handler) directoryMembers(s consoledb.LDAPSettings) ([]directory.Entry, error) {
+	config, err := h.directoryConfig(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return directory.Members(config)
+}
+
+func (h *Handler) saveDirectorySyncResult(result consoledb.LDAPSyncResult) {
+	if err := h.Model.SaveLDAPSyncResult(result); err != nil {
+		log.Printf("[ERROR]: could not save the result of the sync with the directory, reason: %v", err)
+	}
+}
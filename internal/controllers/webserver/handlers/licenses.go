@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/invopop/ctxi18n/i18n"
+	"github.com/labstack/echo/v4"
+	"github.com/open-uem/openuem-console/internal/compliance"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/licenses"
+	"github.com/open-uem/openuem-console/internal/models"
+	"github.com/open-uem/openuem-console/internal/views/admin_views"
+	"github.com/open-uem/openuem-console/internal/views/filters"
+	"github.com/open-uem/openuem-console/internal/views/licenses_views"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+)
+
+func (h *Handler) ListLicenses(c echo.Context) error {
+	return h.RenderLicenses(c, consoledb.License{}, "", "")
+}
+
+func (h *Handler) AddLicense(c echo.Context) error {
+	l, err := getLicenseForm(c)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), true))
+	}
+
+	if err := h.Model.AddLicense(l); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "licenses.could_not_add", err.Error()), true))
+	}
+
+	h.Audit(c, AuditLicenseAdd, l.Name, "", licenseDescription(l))
+
+	return h.RenderLicenses(c, consoledb.License{}, i18n.T(c.Request().Context(), "licenses.added"), "")
+}
+
+func (h *Handler) EditLicense(c echo.Context) error {
+	l, err := h.getLicense(c)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), true))
+	}
+
+	return h.RenderLicenses(c, l, "", "")
+}
+
+func (h *Handler) UpdateLicense(c echo.Context) error {
+	current, err := h.getLicense(c)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), true))
+	}
+
+	l, err := getLicenseForm(c)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), true))
+	}
+	l.ID = current.ID
+
+	if err := h.Model.UpdateLicense(l); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "licenses.could_not_update", err.Error()), true))
+	}
+
+	h.Audit(c, AuditLicenseUpdate, l.Name, licenseDescription(current), licenseDescription(l))
+
+	return h.RenderLicenses(c, consoledb.License{}, i18n.T(c.Request().Context(), "licenses.updated"), "")
+}
+
+func (h *Handler) LicenseDelete(c echo.Context) error {
+	l, err := h.getLicense(c)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), true))
+	}
+
+	return RenderConfirm(c, partials.ConfirmDelete(c, i18n.T(c.Request().Context(), "licenses.confirm_delete", l.Name), "", fmt.Sprintf("/tenant/%d/admin/licenses/%d", l.TenantID, l.ID)))
+}
+
+func (h *Handler) LicenseConfirmDelete(c echo.Context) error {
+	l, err := h.getLicense(c)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), true))
+	}
+
+	if err := h.Model.DeleteLicense(l.ID, l.TenantID); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "licenses.could_not_delete", err.Error()), true))
+	}
+
+	h.Audit(c, AuditLicenseDelete, l.Name, licenseDescription(l), "")
+
+	return h.RenderLicenses(c, consoledb.License{}, i18n.T(c.Request().Context(), "licenses.deleted"), "")
+}
+
+// RenderLicenses shows the licenses of the tenant, the form is filled with the license
+// being edited, if any
+func (h *Handler) RenderLicenses(c echo.Context, editing consoledb.License, successMessage, errMessage string) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	tenantID, err := strconv.Atoi(c.Param("tenant"))
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "tenants.could_not_convert_to_int", err.Error()), true))
+	}
+	commonInfo.TenantID = c.Param("tenant")
+
+	items, err := h.Model.GetLicenses(tenantID)
+	if err != nil {
+		successMessage = ""
+		errMessage = i18n.T(c.Request().Context(), "licenses.could_not_get", err.Error())
+	}
+
+	agentsExists, err := h.Model.AgentsExists(commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), false))
+	}
+
+	serversExists, err := h.Model.ServersExists()
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), false))
+	}
+
+	return RenderView(c, admin_views.LicensesIndex(" | Licenses", admin_views.Licenses(c, items, editing, successMessage, errMessage, agentsExists, serversExists, commonInfo, h.GetAdminTenantName(commonInfo)), commonInfo))
+}
+
+func (h *Handler) LicensePositions(c echo.Context) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	errMessage := ""
+
+	itemsPerPage, err := h.Model.GetDefaultItemsPerPage()
+	if err != nil {
+		log.Println("[ERROR]: could not get items per page from database")
+		itemsPerPage = 5
+	}
+
+	p := partials.NewPaginationAndSort(itemsPerPage)
+	p.GetPaginationAndSortParams(c.FormValue("page"), c.FormValue("pageSize"), c.FormValue("sortBy"), c.FormValue("sortOrder"), c.FormValue("currentSortBy"), itemsPerPage)
+
+	f := getLicenseFilter(c)
+
+	positions, err := h.Model.GetLicensePositions(p, f, commonInfo)
+	if err != nil {
+		errMessage = i18n.T(c.Request().Context(), "licenses.could_not_get_positions", err.Error())
+	}
+	p.NItems = len(positions)
+
+	warnings, err := h.Model.GetLicenseWarnings(commonInfo)
+	if err != nil {
+		errMessage = i18n.T(c.Request().Context(), "licenses.could_not_get_positions", err.Error())
+	}
+
+	return RenderView(c, licenses_views.LicensesIndex(" | Licenses", licenses_views.LicensePositions(c, p, f, warnings, models.LicensePositionsPage(positions, p), errMessage, itemsPerPage, commonInfo), commonInfo))
+}
+
+func getLicenseFilter(c echo.Context) filters.LicenseFilter {
+	return filters.LicenseFilter{
+		Name:       c.FormValue("filterByName"),
+		CostCenter: c.FormValue("filterByCostCenter"),
+		Statuses:   filteredOptions(c, "Status", "licenses.status_", licenses.Statuses),
+	}
+}
+
+// getLicenseForm reads and validates the license sent by the form
+func getLicenseForm(c echo.Context) (consoledb.License, error) {
+	tenantID, err := strconv.Atoi(c.Param("tenant"))
+	if err != nil {
+		return consoledb.License{}, errors.New(i18n.T(c.Request().Context(), "tenants.could_not_convert_to_int", err.Error()))
+	}
+
+	l := consoledb.License{
+		TenantID:         tenantID,
+		Name:             strings.TrimSpace(c.FormValue("license-name")),
+		AppPattern:       strings.TrimSpace(c.FormValue("license-app")),
+		PublisherPattern: strings.TrimSpace(c.FormValue("license-publisher")),
+		CostCenter:       strings.TrimSpace(c.FormValue("license-cost-center")),
+	}
+
+	if l.Name == "" {
+		return consoledb.License{}, errors.New(i18n.T(c.Request().Context(), "licenses.empty_name"))
+	}
+
+	if !compliance.ValidPattern(l.AppPattern) && !compliance.ValidPattern(l.PublisherPattern) {
+		return consoledb.License{}, errors.New(i18n.T(c.Request().Context(), "licenses.empty_pattern"))
+	}
+
+	l.Seats, err = strconv.Atoi(strings.TrimSpace(c.FormValue("license-seats")))
+	if err != nil || l.Seats < 0 {
+		return consoledb.License{}, errors.New(i18n.T(c.Request().Context(), "licenses.invalid_seats"))
+	}
+
+	if expiry := strings.TrimSpace(c.FormValue("license-expiry")); expiry != "" {
+		l.Expiry, err = time.ParseInLocation("2006-01-02", expiry, time.Local)
+		if err != nil {
+			return consoledb.License{}, errors.New(i18n.T(c.Request().Context(), "licenses.invalid_expiry"))
+		}
+	}
+
+	return l, nil
+}
+
+func (h *Handler) getLicense(c echo.Context) (consoledb.License, error) {
+	tenantID, err := strconv.Atoi(c.Param("tenant"))
+	if err != nil {
+		return consoledb.License{}, errors.New(i18n.T(c.Request().Context(), "tenants.could_not_convert_to_int", err.Error()))
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return consoledb.License{}, errors.New(i18n.T(c.Request().Context(), "licenses.invalid_id"))
+	}
+
+	l, err := h.Model.GetLicense(id, tenantID)
+	if err != nil {
+		if errors.Is(err, models.ErrLicenseNotFound) {
+			return consoledb.License{}, errors.New(i18n.T(c.Request().Context(), "licenses.not_found"))
+		}
+		return consoledb.License{}, errors.New(i18n.T(c.Request().Context(), "licenses.could_not_get", err.Error()))
+	}
+
+	return l, nil
+}
+
+// licenseDescription is how a license is shown in the audit log
+func licenseDescription(l consoledb.License) string {
+	description := fmt.Sprintf("app=%q publisher=%q seats=%d cost_center=%q", l.AppPattern, l.PublisherPattern, l.Seats, l.CostCenter)
+	if !l.Expiry.IsZero() {
+		description += fmt.Sprintf(" expiry=%s", l.Expiry.Format("2006-01-02"))
+	}
+	return description
+}
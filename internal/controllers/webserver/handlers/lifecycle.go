@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/invopop/ctxi18n/i18n"
+	"github.com/labstack/echo/v4"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/lifecycle"
+	"github.com/open-uem/openuem-console/internal/models"
+	"github.com/open-uem/openuem-console/internal/views/filters"
+	"github.com/open-uem/openuem-console/internal/views/lifecycle_views"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+)
+
+func (h *Handler) HardwareLifecycle(c echo.Context) error {
+	return h.RenderHardwareLifecycle(c, "")
+}
+
+// RenderHardwareLifecycle shows the computers of the tenant or site with their lifecycle dates
+func (h *Handler) RenderHardwareLifecycle(c echo.Context, successMessage string) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	errMessage := ""
+
+	itemsPerPage, err := h.Model.GetDefaultItemsPerPage()
+	if err != nil {
+		log.Println("[ERROR]: could not get items per page from database")
+		itemsPerPage = 5
+	}
+
+	p := partials.NewPaginationAndSort(itemsPerPage)
+	p.GetPaginationAndSortParams(c.FormValue("page"), c.FormValue("pageSize"), c.FormValue("sortBy"), c.FormValue("sortOrder"), c.FormValue("currentSortBy"), itemsPerPage)
+
+	f := getHardwareRefreshFilter(c)
+
+	items, err := h.Model.GetHardwareRefresh(p, f, commonInfo)
+	if err != nil {
+		errMessage = i18n.T(c.Request().Context(), "lifecycle.could_not_get", err.Error())
+	}
+	p.NItems = len(items)
+
+	warnings, err := h.Model.GetWarrantyWarnings(commonInfo)
+	if err != nil {
+		errMessage = i18n.T(c.Request().Context(), "lifecycle.could_not_get", err.Error())
+	}
+
+	return RenderView(c, lifecycle_views.HardwareLifecycleIndex(" | Hardware lifecycle", lifecycle_views.HardwareLifecycle(c, p, f, warnings, models.HardwareRefreshPage(items, p), successMessage, errMessage, itemsPerPage, commonInfo), commonInfo))
+}
+
+// ImportHardwareLifecycle reads a CSV file with the serial number, purchase date, warranty
+// expiry and planned replacement of the computers of the tenant or site. Lines for unknown
+// serial numbers are reported once the rest of the file has been imported
+func (h *Handler) ImportHardwareLifecycle(c echo.Context) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	file, err := c.FormFile("csvFile")
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "lifecycle.import_no_file"), false))
+	}
+	src, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	r := csv.NewReader(src)
+	r.FieldsPerRecord = -1
+
+	records := []lifecycle.Record{}
+	for index := 1; ; index++ {
+		line, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return RenderError(c, partials.ErrorMessage(err.Error(), false))
+		}
+
+		if index == 1 && lifecycle.IsHeader(line) {
+			continue
+		}
+
+		record, err := lifecycle.ParseRecord(line)
+		if err != nil {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "lifecycle.import_wrong_line", index, lifecycleImportError(c, err)), false))
+		}
+		records = append(records, record)
+	}
+
+	updated, notFound, err := h.Model.ImportHardwareLifecycle(records, commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "lifecycle.could_not_save", err.Error()), false))
+	}
+
+	h.Audit(c, AuditLifecycleImport, file.Filename, "", fmt.Sprintf("%d computers", updated))
+
+	if len(notFound) > 0 {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "lifecycle.import_not_found", updated, strings.Join(notFound, ", ")), false))
+	}
+
+	return h.RenderHardwareLifecycle(c, i18n.T(c.Request().Context(), "lifecycle.import_success", updated))
+}
+
+// ComputerLifecycle saves the lifecycle dates set in the hardware tab of a computer
+func (h *Handler) ComputerLifecycle(c echo.Context) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	agentID := c.Param("uuid")
+	if _, err := h.Model.GetAgentById(agentID, commonInfo); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "agents.could_not_get_agent"), false))
+	}
+
+	tenantID, err := strconv.Atoi(commonInfo.TenantID)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "tenants.could_not_convert_to_int", err.Error()), true))
+	}
+
+	current, err := h.Model.GetHardwareLifecycle(agentID)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "lifecycle.could_not_get", err.Error()), false))
+	}
+
+	l := consoledb.HardwareLifecycle{AgentID: agentID, TenantID: tenantID}
+	if l.PurchaseDate, err = lifecycle.ParseDate(c.FormValue("lifecycle-purchase-date")); err != nil {
+		return RenderError(c, partials.ErrorMessage(lifecycleImportError(c, err), false))
+	}
+	if l.WarrantyExpiry, err = lifecycle.ParseDate(c.FormValue("lifecycle-warranty-expiry")); err != nil {
+		return RenderError(c, partials.ErrorMessage(lifecycleImportError(c, err), false))
+	}
+	if l.PlannedReplacement, err = lifecycle.ParseDate(c.FormValue("lifecycle-planned-replacement")); err != nil {
+		return RenderError(c, partials.ErrorMessage(lifecycleImportError(c, err), false))
+	}
+
+	if err := h.Model.SaveHardwareLifecycle(l); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "lifecycle.could_not_save", err.Error()), false))
+	}
+
+	h.Audit(c, AuditComputerLifecycle, agentID, lifecycleDescription(current), lifecycleDescription(l))
+
+	return RenderSuccess(c, partials.SuccessMessage(i18n.T(c.Request().Context(), "lifecycle.updated")))
+}
+
+func getHardwareRefreshFilter(c echo.Context) filters.HardwareRefreshFilter {
+	return filters.HardwareRefreshFilter{
+		Nickname:   c.FormValue("filterByNickname"),
+		Ages:       filteredOptions(c, "Age", "lifecycle.age_", lifecycle.Ages),
+		Warranties: filteredOptions(c, "Warranty", "lifecycle.warranty_", lifecycle.Warranties),
+	}
+}
+
+// lifecycleImportError translates the errors found reading the lifecycle dates
+func lifecycleImportError(c echo.Context, err error) string {
+	switch {
+	case errors.Is(err, lifecycle.ErrWrongFormat):
+		return i18n.T(c.Request().Context(), "lifecycle.wrong_format")
+	case errors.Is(err, lifecycle.ErrEmptySerial):
+		return i18n.T(c.Request().Context(), "lifecycle.empty_serial")
+	}
+
+	var dateErr *lifecycle.DateError
+	if errors.As(err, &dateErr) {
+		return i18n.T(c.Request().Context(), "lifecycle.invalid_date", dateErr.Value)
+	}
+
+	return err.Error()
+}
+
+// lifecycleDescription is the value of the lifecycle dates saved in the audit log
+func lifecycleDescription(l consoledb.HardwareLifecycle) string {
+	return fmt.Sprintf("purchase: %s, warranty: %s, replacement: %s", formatLifecycleDate(l.PurchaseDate), formatLifecycleDate(l.WarrantyExpiry), formatLifecycleDate(l.PlannedReplacement))
+}
+
+// formatLifecycleDate writes a lifecycle date like the imported files, unknown dates are empty
+func formatLifecycleDate(date time.Time) string {
+	if date.IsZero() {
+		return ""
+	}
+	return date.Format(lifecycle.DateFormat)
+}
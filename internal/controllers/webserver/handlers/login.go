@@ -5,6 +5,7 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"image/png"
 	"io"
@@ -20,6 +21,7 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/open-uem/ent"
 	openuem_nats "github.com/open-uem/nats"
+	"github.com/open-uem/openuem-console/internal/directory"
 	"github.com/open-uem/openuem-console/internal/views/login_views"
 	"github.com/open-uem/openuem-console/internal/views/partials"
 	"github.com/open-uem/utils"
@@ -64,7 +66,12 @@ func (h *Handler) Login(c echo.Context) error {
 
 	isTurnstileEnabled := turnstileSecretKey != "" && turnstileSiteKey != ""
 
-	return RenderLogin(c, login_views.LoginIndex(login_views.Login(settings, turnstileSiteKey, turnstileSecretKey), csrfToken, isTurnstileEnabled))
+	ldapSettings, err := h.Model.GetLDAPSettings()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, i18n.T(c.Request().Context(), "ldap.could_not_get_settings", err.Error()))
+	}
+
+	return RenderLogin(c, login_views.LoginIndex(login_views.Login(settings, ldapSettings.Enabled, turnstileSiteKey, turnstileSecretKey), csrfToken, isTurnstileEnabled))
 }
 
 func (h *Handler) LoginPasswordAuth(c echo.Context) error {
@@ -96,27 +103,52 @@ func (h *Handler) LoginPasswordAuth(c echo.Context) error {
 		}
 	}
 
-	user, err := h.Model.GetUserById(username)
+	ldapSettings, err := h.Model.GetLDAPSettings()
 	if err != nil {
-		log.Printf("[ERROR]: could not get user account for username %s, reason: %v", username, err)
-		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "login.wrong_username_or_password"), true))
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "ldap.could_not_get_settings", err.Error()), true))
 	}
 
-	if user.Hash == "" {
-		log.Println("[ERROR]: hash is empty, maybe there was an issue with migration!")
-		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "login.wrong_username_or_password"), true))
-	}
+	user, err := h.Model.GetUserById(username)
 
-	// Check if passwords match
-	match, err := argon2id.ComparePasswordAndHash(password, user.Hash)
-	if err != nil {
-		log.Printf("[ERROR]: could not compare password and hash for user %s, reason: %v", username, err)
-		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "login.wrong_username_or_password"), true))
-	}
+	// the passwords of the users managed by the directory, and of the users the console
+	// doesn't know yet, are checked by the directory
+	if ldapSettings.Enabled && (ent.IsNotFound(err) || h.Model.IsDirectoryUser(username)) {
+		user, err = h.directoryLogin(ldapSettings, username, password)
+		if err != nil {
+			switch {
+			case errors.Is(err, directory.ErrInvalidCredentials):
+				h.AuthLogger.Printf("user %s entered a wrong password", username)
+			case errors.Is(err, directory.ErrUserNotFound):
+				h.AuthLogger.Printf("user %s is not allowed to use the console by the directory", username)
+			default:
+				log.Printf("[ERROR]: could not authenticate user %s with the directory, reason: %v", username, err)
+			}
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "login.wrong_username_or_password"), true))
+		}
+
+		h.AuthLogger.Printf("user %s has been authenticated by the directory", user.ID)
+	} else {
+		if err != nil {
+			log.Printf("[ERROR]: could not get user account for username %s, reason: %v", username, err)
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "login.wrong_username_or_password"), true))
+		}
+
+		if user.Hash == "" {
+			log.Println("[ERROR]: hash is empty, maybe there was an issue with migration!")
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "login.wrong_username_or_password"), true))
+		}
 
-	if !match {
-		h.AuthLogger.Printf("user %s entered a wrong password", username)
-		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "login.wrong_username_or_password"), true))
+		// Check if passwords match
+		match, err := argon2id.ComparePasswordAndHash(password, user.Hash)
+		if err != nil {
+			log.Printf("[ERROR]: could not compare password and hash for user %s, reason: %v", username, err)
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "login.wrong_username_or_password"), true))
+		}
+
+		if !match {
+			h.AuthLogger.Printf("user %s entered a wrong password", username)
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "login.wrong_username_or_password"), true))
+		}
 	}
 
 	// Check if user is forced to change password
@@ -134,6 +166,13 @@ func (h *Handler) LoginPasswordAuth(c echo.Context) error {
 		return RenderLogin(c, login_views.LoginIndex(login_views.ChangePassword(tsSiteKey, tsSecretKey), csrfToken, isTurnstileEnabled))
 	}
 
+	// users with security keys must use one of them, or their TOTP code, as second factor
+	hasSecurityKeys, err := h.Model.HasWebAuthnCredentials(user.ID)
+	if err != nil {
+		log.Printf("[ERROR]: could not check the security keys of user %s, reason: %v", user.ID, err)
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "passkeys.could_not_get", err.Error()), true))
+	}
+
 	// Passwords match, create a new session
 	if err := h.NewSession(c, user); err != nil {
 		log.Printf("[ERROR]: could not create a new session after passwords match, reason: %v", err)
@@ -142,12 +181,16 @@ func (h *Handler) LoginPasswordAuth(c echo.Context) error {
 
 	if user.Use2fa {
 		if user.TotpSecretConfirmed {
-			return RenderLoginPartial(c, login_views.Use2FA(username, tsSiteKey, tsSecretKey))
-		} else {
+			return RenderLoginPartial(c, login_views.Use2FA(username, tsSiteKey, tsSecretKey, true, hasSecurityKeys))
+		} else if !hasSecurityKeys {
 			return h.Register2FA(c)
 		}
 	}
 
+	if hasSecurityKeys {
+		return RenderLoginPartial(c, login_views.Use2FA(username, tsSiteKey, tsSecretKey, false, true))
+	}
+
 	return h.AccessGranted(c, user)
 }
 
@@ -514,6 +557,7 @@ func (h *Handler) NewSession(c echo.Context, user *ent.User) error {
 		h.SessionManager.Manager.Put(c.Request().Context(), "usepasswd", user.Passwd)
 		h.SessionManager.Manager.Put(c.Request().Context(), "email", user.Email)
 		h.SessionManager.Manager.Put(c.Request().Context(), "twofa", false)
+		h.SessionManager.Manager.Put(c.Request().Context(), "webauthn", false)
 		token, expiry, err := h.SessionManager.Manager.Commit(c.Request().Context())
 		if err != nil {
 			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
@@ -525,7 +569,30 @@ func (h *Handler) NewSession(c echo.Context, user *ent.User) error {
 }
 
 func (h *Handler) AccessGranted(c echo.Context, user *ent.User) error {
-	err := h.SessionManager.Manager.RenewToken(c.Request().Context())
+	// users the directory or the identity provider no longer allow to use the console can't sign in with any method
+	disabled, err := h.Model.IsDirectoryUserDisabled(user.ID)
+	if err != nil {
+		log.Printf("[ERROR]: could not check if user %s has been disabled by the directory, reason: %v", user.ID, err)
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "ldap.could_not_check_user", err.Error()), true))
+	}
+
+	if disabled {
+		h.AuthLogger.Printf("user %s tried to log in but has been disabled by the directory", user.ID)
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "ldap.user_disabled"), true))
+	}
+
+	inactive, err := h.Model.IsSCIMUserInactive(user.ID)
+	if err != nil {
+		log.Printf("[ERROR]: could not check if user %s has been deactivated by the identity provider, reason: %v", user.ID, err)
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "scim.could_not_check_user", err.Error()), true))
+	}
+
+	if inactive {
+		h.AuthLogger.Printf("user %s tried to log in but has been deactivated by the identity provider", user.ID)
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "scim.user_inactive"), true))
+	}
+
+	err = h.SessionManager.Manager.RenewToken(c.Request().Context())
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
@@ -536,7 +603,10 @@ func (h *Handler) AccessGranted(c echo.Context, user *ent.User) error {
 	h.SessionManager.Manager.Put(c.Request().Context(), "usepasswd", user.Passwd)
 	h.SessionManager.Manager.Put(c.Request().Context(), "email", user.Email)
 	h.SessionManager.Manager.Put(c.Request().Context(), "ip-address", c.Request().RemoteAddr)
-	if user.Use2fa {
+
+	// a security key is either the second factor or a passwordless login on its own
+	securityKey := h.SessionManager.Manager.GetBool(c.Request().Context(), "webauthn")
+	if user.Use2fa || securityKey {
 		h.SessionManager.Manager.Put(c.Request().Context(), "twofa", true)
 	}
 	token, expiry, err := h.SessionManager.Manager.Commit(c.Request().Context())
@@ -568,7 +638,9 @@ func (h *Handler) AccessGranted(c echo.Context, user *ent.User) error {
 	}
 
 	if h.AuthLogger != nil {
-		if user.Passwd {
+		if securityKey {
+			h.AuthLogger.Printf("user %s has logged in with a security key", user.ID)
+		} else if user.Passwd {
 			if user.Use2fa {
 				h.AuthLogger.Printf("user %s has logged in with a password and using 2FA", user.ID)
 			} else {
@@ -0,0 +1,310 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/invopop/ctxi18n/i18n"
+	"github.com/labstack/echo/v4"
+	openuem_ent "github.com/open-uem/ent"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/maintenance"
+	"github.com/open-uem/openuem-console/internal/models"
+	"github.com/open-uem/openuem-console/internal/views/admin_views"
+	"github.com/open-uem/openuem-console/internal/views/filters"
+	"github.com/open-uem/openuem-console/internal/views/maintenance_views"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+)
+
+func (h *Handler) ListMaintenanceWindows(c echo.Context) error {
+	return h.RenderMaintenanceWindows(c, "", "")
+}
+
+func (h *Handler) AddMaintenanceWindow(c echo.Context) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	tenantID, err := strconv.Atoi(c.Param("tenant"))
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "tenants.could_not_convert_to_int", err.Error()), true))
+	}
+	commonInfo.TenantID = c.Param("tenant")
+
+	name := strings.TrimSpace(c.FormValue("window-name"))
+	if name == "" {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "maintenance.empty_name"), true))
+	}
+
+	targetType, value, _ := strings.Cut(c.FormValue("window-target"), ":")
+	targetID, err := strconv.Atoi(value)
+	if err != nil || !slices.Contains(maintenance.TargetTypes, targetType) {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "maintenance.invalid_target"), true))
+	}
+
+	switch targetType {
+	case maintenance.TargetSite:
+		if _, err := h.Model.GetSiteById(tenantID, targetID); err != nil {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "maintenance.invalid_target"), true))
+		}
+	case maintenance.TargetTag:
+		tags, err := h.Model.GetAllTags(commonInfo, filters.AgentFilter{})
+		if err != nil || !slices.ContainsFunc(tags, func(t *openuem_ent.Tag) bool { return t.ID == targetID }) {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "maintenance.invalid_target"), true))
+		}
+	}
+
+	form, err := c.FormParams()
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), true))
+	}
+
+	days, err := maintenance.ParseDays(form["window-days"])
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "maintenance.invalid_days"), true))
+	}
+
+	start, err := maintenance.ParseStart(c.FormValue("window-start"))
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "maintenance.invalid_time"), true))
+	}
+
+	end, err := maintenance.ParseStart(c.FormValue("window-end"))
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "maintenance.invalid_time"), true))
+	}
+
+	// the window ends the next day if the end is before the start, and lasts a day if they're equal
+	duration := (end - start + maintenance.MaxDurationMinutes) % maintenance.MaxDurationMinutes
+	if duration == 0 {
+		duration = maintenance.MaxDurationMinutes
+	}
+
+	timezone := strings.TrimSpace(c.FormValue("window-timezone"))
+	if _, err := time.LoadLocation(timezone); err != nil || timezone == "" || timezone == "Local" {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "maintenance.invalid_timezone"), true))
+	}
+
+	w := consoledb.MaintenanceWindow{
+		TenantID:   tenantID,
+		Name:       name,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Days:       days,
+		Start:      start,
+		Duration:   duration,
+		TimeZone:   timezone,
+		Enabled:    true,
+	}
+
+	if err := h.Model.AddMaintenanceWindow(w); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "maintenance.could_not_add", err.Error()), true))
+	}
+
+	h.Audit(c, AuditMaintenanceWindowAdd, name, "", maintenanceWindowDetail(w))
+
+	return h.RenderMaintenanceWindows(c, i18n.T(c.Request().Context(), "maintenance.added"), "")
+}
+
+func (h *Handler) EnableMaintenanceWindow(c echo.Context) error {
+	w, err := h.getMaintenanceWindow(c)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), true))
+	}
+
+	enabled, err := strconv.ParseBool(c.FormValue("window-enabled"))
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "maintenance.invalid_enabled"), true))
+	}
+
+	if err := h.Model.SetMaintenanceWindowEnabled(w.ID, w.TenantID, enabled); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "maintenance.could_not_update", err.Error()), true))
+	}
+
+	if enabled {
+		return h.RenderMaintenanceWindows(c, i18n.T(c.Request().Context(), "maintenance.has_been_enabled"), "")
+	}
+	return h.RenderMaintenanceWindows(c, i18n.T(c.Request().Context(), "maintenance.has_been_disabled"), "")
+}
+
+func (h *Handler) MaintenanceWindowDelete(c echo.Context) error {
+	w, err := h.getMaintenanceWindow(c)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), true))
+	}
+
+	return RenderConfirm(c, partials.ConfirmDelete(c, i18n.T(c.Request().Context(), "maintenance.confirm_delete", w.Name), "", fmt.Sprintf("/tenant/%d/admin/maintenance-windows/%d", w.TenantID, w.ID)))
+}
+
+func (h *Handler) MaintenanceWindowConfirmDelete(c echo.Context) error {
+	w, err := h.getMaintenanceWindow(c)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), true))
+	}
+
+	if err := h.Model.DeleteMaintenanceWindow(w.ID, w.TenantID); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "maintenance.could_not_delete", err.Error()), true))
+	}
+
+	h.Audit(c, AuditMaintenanceWindowDelete, w.Name, maintenanceWindowDetail(w), "")
+
+	return h.RenderMaintenanceWindows(c, i18n.T(c.Request().Context(), "maintenance.deleted"), "")
+}
+
+func (h *Handler) RenderMaintenanceWindows(c echo.Context, successMessage, errMessage string) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	tenantID, err := strconv.Atoi(c.Param("tenant"))
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "tenants.could_not_convert_to_int", err.Error()), true))
+	}
+	commonInfo.TenantID = c.Param("tenant")
+
+	windows, err := h.Model.GetMaintenanceWindows(tenantID)
+	if err != nil {
+		successMessage = ""
+		errMessage = i18n.T(c.Request().Context(), "maintenance.could_not_get", err.Error())
+	}
+
+	tags, err := h.Model.GetAllTags(commonInfo, filters.AgentFilter{})
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), false))
+	}
+
+	sites, err := h.Model.GetSites(tenantID)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), false))
+	}
+
+	agentsExists, err := h.Model.AgentsExists(commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), false))
+	}
+
+	serversExists, err := h.Model.ServersExists()
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), false))
+	}
+
+	return RenderView(c, admin_views.MaintenanceWindowsIndex(" | Maintenance windows", admin_views.MaintenanceWindows(c, windows, tags, sites, successMessage, errMessage, agentsExists, serversExists, commonInfo, h.GetAdminTenantName(commonInfo)), commonInfo))
+}
+
+func (h *Handler) ListMaintenanceQueue(c echo.Context) error {
+	return h.RenderMaintenanceQueue(c, "")
+}
+
+func (h *Handler) CancelMaintenanceAction(c echo.Context) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "maintenance.action_not_found"), true))
+	}
+
+	a, err := h.Model.GetMaintenanceAction(id, commonInfo)
+	if err != nil {
+		if errors.Is(err, models.ErrMaintenanceActionNotFound) {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "maintenance.action_not_found"), true))
+		}
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "maintenance.could_not_get_queue", err.Error()), true))
+	}
+
+	if err := h.Model.CancelMaintenanceAction(a.ID, commonInfo); err != nil {
+		if errors.Is(err, models.ErrMaintenanceActionNotFound) {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "maintenance.action_not_found"), true))
+		}
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "maintenance.could_not_cancel", err.Error()), true))
+	}
+
+	h.Audit(c, AuditMaintenanceActionCancel, a.AgentID, strings.TrimSpace(a.Action+" "+a.Description), "")
+
+	return h.RenderMaintenanceQueue(c, i18n.T(c.Request().Context(), "maintenance.action_cancelled"))
+}
+
+// RenderMaintenanceQueue shows the actions waiting for a maintenance window and the
+// result of those already sent
+func (h *Handler) RenderMaintenanceQueue(c echo.Context, successMessage string) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	errMessage := ""
+
+	itemsPerPage, err := h.Model.GetDefaultItemsPerPage()
+	if err != nil {
+		log.Println("[ERROR]: could not get items per page from database")
+		itemsPerPage = 5
+	}
+
+	p := partials.NewPaginationAndSort(itemsPerPage)
+	p.GetPaginationAndSortParams(c.FormValue("page"), c.FormValue("pageSize"), c.FormValue("sortBy"), c.FormValue("sortOrder"), c.FormValue("currentSortBy"), itemsPerPage)
+
+	f := filters.MaintenanceQueueFilter{
+		Hostname: c.FormValue("filterByHostname"),
+		Actions:  filteredOptions(c, "Action", "maintenance.action_", maintenance.Actions),
+		Statuses: filteredOptions(c, "Status", "maintenance.status_", maintenance.Statuses),
+	}
+
+	// the actions scheduled furthest in the future come first, so pending actions are above the sent ones
+	if p.SortBy == "" {
+		p.SortBy = "not_before"
+		p.SortOrder = "desc"
+	}
+
+	p.NItems, err = h.Model.CountMaintenanceActions(f, commonInfo)
+	if err != nil {
+		errMessage = i18n.T(c.Request().Context(), "maintenance.could_not_get_queue", err.Error())
+	}
+
+	items, err := h.Model.GetMaintenanceActionsByPage(p, f, commonInfo)
+	if err != nil {
+		errMessage = i18n.T(c.Request().Context(), "maintenance.could_not_get_queue", err.Error())
+	}
+
+	refreshTime, err := h.Model.GetDefaultRefreshTime()
+	if err != nil {
+		log.Println("[ERROR]: could not get refresh time from database")
+		refreshTime = 5
+	}
+
+	return RenderView(c, maintenance_views.MaintenanceQueueIndex(" | Maintenance queue", maintenance_views.MaintenanceQueue(c, p, f, items, refreshTime, itemsPerPage, successMessage, errMessage, commonInfo), commonInfo))
+}
+
+func (h *Handler) getMaintenanceWindow(c echo.Context) (consoledb.MaintenanceWindow, error) {
+	tenantID, err := strconv.Atoi(c.Param("tenant"))
+	if err != nil {
+		return consoledb.MaintenanceWindow{}, errors.New(i18n.T(c.Request().Context(), "tenants.could_not_convert_to_int", err.Error()))
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return consoledb.MaintenanceWindow{}, errors.New(i18n.T(c.Request().Context(), "maintenance.not_found"))
+	}
+
+	w, err := h.Model.GetMaintenanceWindow(id, tenantID)
+	if err != nil {
+		if errors.Is(err, models.ErrMaintenanceWindowNotFound) {
+			return consoledb.MaintenanceWindow{}, errors.New(i18n.T(c.Request().Context(), "maintenance.not_found"))
+		}
+		return consoledb.MaintenanceWindow{}, errors.New(i18n.T(c.Request().Context(), "maintenance.could_not_get", err.Error()))
+	}
+
+	return w, nil
+}
+
+func maintenanceWindowDetail(w consoledb.MaintenanceWindow) string {
+	return fmt.Sprintf("%s:%d %s %s+%dm %s", w.TargetType, w.TargetID, maintenance.FormatDays(w.Days), maintenance.FormatStart(w.Start), w.Duration, w.TimeZone)
+}
@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/go-co-op/gocron/v2"
+	"github.com/invopop/ctxi18n"
+	"github.com/invopop/ctxi18n/i18n"
+	"github.com/labstack/echo/v4"
+	openuem_ent "github.com/open-uem/ent"
+	openuem_nats "github.com/open-uem/nats"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/maintenance"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+)
+
+// maintenanceQueueBatch is the number of due actions sent each time the queue is checked
+const maintenanceQueueBatch = 200
+
+// StartMaintenanceQueueJob schedules the job that sends the queued actions whose window has opened
+func (h *Handler) StartMaintenanceQueueJob() error {
+	if _, err := h.TaskScheduler.NewJob(
+		gocron.DurationJob(maintenance.CheckInterval),
+		gocron.NewTask(h.ProcessMaintenanceQueue),
+		gocron.WithSingletonMode(gocron.LimitModeReschedule),
+	); err != nil {
+		log.Printf("[ERROR]: could not schedule the job that sends the maintenance queue, reason: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// ProcessMaintenanceQueue sends the queued actions whose maintenance window has opened. The
+// windows are checked again before sending as they may have changed since the action was queued
+func (h *Handler) ProcessMaintenanceQueue() {
+	if h.NATSConnection == nil || !h.NATSConnection.IsConnected() {
+		return
+	}
+
+	now := time.Now()
+	items, err := h.Model.GetDueMaintenanceActions(now, maintenanceQueueBatch)
+	if err != nil {
+		log.Printf("[ERROR]: could not get the due actions of the maintenance queue, reason: %v", err)
+		return
+	}
+
+	for _, item := range items {
+		h.processMaintenanceAction(item, now)
+	}
+}
+
+func (h *Handler) processMaintenanceAction(item consoledb.MaintenanceAction, now time.Time) {
+	commonInfo := &partials.CommonInfo{TenantID: strconv.Itoa(item.TenantID), SiteID: "-1"}
+
+	agentInfo, err := h.Model.GetAgentById(item.AgentID, commonInfo)
+	if err != nil && !openuem_ent.IsNotFound(err) {
+		log.Printf("[ERROR]: could not get agent %s of the queued action %d, reason: %v", item.AgentID, item.ID, err)
+		return
+	}
+
+	if agentInfo != nil {
+		next, err := h.nextMaintenanceWindow(agentInfo, now)
+		if err != nil {
+			log.Printf("[ERROR]: could not get the maintenance windows of agent %s, reason: %v", item.AgentID, err)
+			return
+		}
+		if next.After(now) {
+			if err := h.Model.RescheduleMaintenanceAction(item.ID, next); err != nil {
+				log.Printf("[ERROR]: could not reschedule the queued action %d, reason: %v", item.ID, err)
+			}
+			return
+		}
+	}
+
+	claimed, err := h.Model.ClaimMaintenanceAction(item.ID)
+	if err != nil {
+		log.Printf("[ERROR]: could not claim the queued action %d, reason: %v", item.ID, err)
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	// the agent may have been deleted or moved to another tenant while the action was queued
+	if agentInfo == nil {
+		err = errors.New("the agent doesn't exist")
+	} else {
+		err = h.sendMaintenanceAction(item, commonInfo)
+	}
+
+	if err != nil {
+		log.Printf("[ERROR]: could not send the queued %s action to agent %s, reason: %v", item.Action, item.AgentID, err)
+		if err := h.Model.FailMaintenanceAction(item.ID, err.Error()); err != nil {
+			log.Printf("[ERROR]: could not save the result of the queued action %d, reason: %v", item.ID, err)
+		}
+	}
+}
+
+func (h *Handler) sendMaintenanceAction(item consoledb.MaintenanceAction, commonInfo *partials.CommonInfo) error {
+	ctx, err := ctxi18n.WithLocale(context.Background(), item.Language)
+	if err != nil {
+		return err
+	}
+
+	switch item.Action {
+	case maintenance.ActionPowerOff:
+		_, err := h.NATSConnection.Request("agent.poweroff."+item.AgentID, []byte(item.Payload), time.Duration(h.NATSTimeout)*time.Second)
+		return err
+	case maintenance.ActionReboot:
+		_, err := h.NATSConnection.Request("agent.reboot."+item.AgentID, []byte(item.Payload), time.Duration(h.NATSTimeout)*time.Second)
+		return err
+	case maintenance.ActionRunProfile:
+		_, err := h.NATSConnection.Request("agent.runprofile."+item.AgentID, []byte(item.Payload), time.Duration(h.NATSTimeout)*time.Second)
+		return err
+	case maintenance.ActionInstall, maintenance.ActionUninstall:
+		action := openuem_nats.DeployAction{}
+		if err := json.Unmarshal([]byte(item.Payload), &action); err != nil {
+			return err
+		}
+		return h.sendDeployAction(action, commonInfo)
+	case maintenance.ActionAgentUpdate:
+		updateRequest := openuem_nats.OpenUEMUpdateRequest{}
+		if err := json.Unmarshal([]byte(item.Payload), &updateRequest); err != nil {
+			return err
+		}
+		return h.publishAgentUpdate(ctx, item.AgentID, updateRequest, commonInfo)
+	default:
+		return fmt.Errorf("unknown action %s", item.Action)
+	}
+}
+
+// nextMaintenanceWindow returns the given time if the agent can receive requests at that time,
+// because one of its windows is open or it has no windows, or the time its next window opens
+func (h *Handler) nextMaintenanceWindow(agentInfo *openuem_ent.Agent, at time.Time) (time.Time, error) {
+	windows, err := h.Model.GetAgentMaintenanceWindows(agentInfo)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return maintenance.NextOpen(windows, at), nil
+}
+
+// queueForMaintenance queues the request when the agent has maintenance windows and none of
+// them is open at the requested time. It returns the time the request will be sent, a zero
+// time means the agent isn't restricted and the request must be sent as usual
+func (h *Handler) queueForMaintenance(c echo.Context, agentInfo *openuem_ent.Agent, action string, payload any, description string, at time.Time, commonInfo *partials.CommonInfo) (time.Time, error) {
+	next, _, err := h.queueMaintenanceAction(c, agentInfo, action, payload, description, at, commonInfo)
+	return next, err
+}
+
+// queueMaintenanceAction works as queueForMaintenance and also returns the id of the queued action
+func (h *Handler) queueMaintenanceAction(c echo.Context, agentInfo *openuem_ent.Agent, action string, payload any, description string, at time.Time, commonInfo *partials.CommonInfo) (time.Time, int, error) {
+	next, err := h.nextMaintenanceWindow(agentInfo, at)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	if !next.After(at) {
+		return time.Time{}, 0, nil
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+
+	tenantID, err := strconv.Atoi(commonInfo.TenantID)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+
+	siteID, err := strconv.Atoi(commonInfo.SiteID)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	if siteID == -1 && len(agentInfo.Edges.Site) == 1 {
+		siteID = agentInfo.Edges.Site[0].ID
+	}
+
+	item := consoledb.MaintenanceAction{
+		TenantID:    tenantID,
+		SiteID:      siteID,
+		AgentID:     agentInfo.ID,
+		Hostname:    agentInfo.Hostname,
+		Action:      action,
+		Description: description,
+		Payload:     string(data),
+		NotBefore:   next,
+		Language:    ctxi18n.Locale(c.Request().Context()).Code().String(),
+		CreatedBy:   h.GetUserID(c),
+	}
+
+	id, err := h.Model.QueueMaintenanceAction(item)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+
+	return next, id, nil
+}
+
+// maintenanceQueuedMessage tells the user when a queued request will be sent
+func maintenanceQueuedMessage(c echo.Context, next time.Time, commonInfo *partials.CommonInfo) string {
+	return i18n.T(c.Request().Context(), "maintenance.queued", commonInfo.Translator.FmtDateMedium(next.Local())+" "+commonInfo.Translator.FmtTimeShort(next.Local()))
+}
@@ -23,23 +23,12 @@ func (h *Handler) MyAccount(c echo.Context) error {
 		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "login.username_empty"), true))
 	}
 
-	user, err := h.Model.GetUserById(username)
-	if err != nil {
+	if _, err := h.Model.GetUserById(username); err != nil {
 		log.Printf("[ERROR]: could not get user account for username %s, reason: %v", username, err)
 		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "login.totp_wrong_setup"), true))
 	}
 
-	commonInfo, err := h.GetCommonInfo(c)
-	if err != nil {
-		return err
-	}
-
-	defaultCountry, err := h.Model.GetDefaultCountry()
-	if err != nil {
-		return err
-	}
-
-	return RenderView(c, account_views.MyAccountIndex("| My Account", account_views.MyAccount(c, user, defaultCountry, commonInfo, ""), commonInfo))
+	return h.RenderMyAccount(c, username, "", "")
 }
 
 func (h *Handler) UpdatePersonalInfo(c echo.Context) error {
@@ -48,28 +37,13 @@ func (h *Handler) UpdatePersonalInfo(c echo.Context) error {
 		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "login.username_empty"), true))
 	}
 
-	commonInfo, err := h.GetCommonInfo(c)
-	if err != nil {
-		return err
-	}
-
-	defaultCountry, err := h.Model.GetDefaultCountry()
-	if err != nil {
-		return err
-	}
-
 	if err := h.Model.UpdateUser(username, c.FormValue("name"), c.FormValue("email"), c.FormValue("phone"), c.FormValue("country")); err != nil {
 		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "login.could_not_update_personal_info", err.Error()), true))
 	}
 
 	h.SessionManager.Manager.Put(c.Request().Context(), "email", c.FormValue("email"))
 
-	user, err := h.Model.GetUserById(username)
-	if err != nil {
-		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "login.could_not_find_user"), true))
-	}
-
-	return RenderView(c, account_views.MyAccountIndex("| My Account", account_views.MyAccount(c, user, defaultCountry, commonInfo, i18n.T(c.Request().Context(), "login.personal_info_updated")), commonInfo))
+	return h.RenderMyAccount(c, username, "", i18n.T(c.Request().Context(), "login.personal_info_updated"))
 }
 
 func (h *Handler) MyAccountPassword(c echo.Context) error {
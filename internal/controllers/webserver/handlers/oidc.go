@@ -24,6 +24,7 @@ import (
 	"github.com/open-uem/ent"
 	"github.com/open-uem/nats"
 	"github.com/open-uem/openuem-console/internal/auth"
+	"github.com/open-uem/openuem-console/internal/consoledb"
 	"github.com/open-uem/openuem-console/internal/views/partials"
 	"github.com/open-uem/utils"
 	"golang.org/x/oauth2"
@@ -52,6 +53,8 @@ type UserInfoResponse struct {
 	Error             string   `json:"error,omitempty"`
 	ErrorDescription  string   `json:"error_description,omitempty"`
 	Groups            []string `json:"groups"`
+	// Claims holds every claim sent by the endpoint, they're read by the claim mapping
+	Claims map[string]any `json:"-"`
 }
 
 type ZitadelRolesResponse struct {
@@ -70,6 +73,10 @@ type OIDCSessionInfo struct {
 	TokenExpiry   int
 	IDToken       string
 	EmailVerified bool
+	// MapRoles is set when the claim mapping is enabled, RoleMappings holds the mappings
+	// that matched the claims of the user and are applied once the user is approved
+	MapRoles     bool
+	RoleMappings []consoledb.OIDCRoleMapping
 }
 
 func (h *Handler) OIDCLogIn(c echo.Context) error {
@@ -273,6 +280,27 @@ func (h *Handler) OIDCCallback(c echo.Context) error {
 		}
 	}
 
+	// Map the groups or roles found in the configured claim to console roles
+	claimSettings, err := h.Model.GetOIDCClaimSettings()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, i18n.T(c.Request().Context(), "oidc_mappings.could_not_get_settings", err.Error()))
+	}
+
+	if claimSettings.Enabled {
+		mappings, err := h.MapOIDCRoles(claimSettings, OIDCClaims(provider, settings.OIDCClientID, oAuth2TokenResponse.IDToken, u))
+		if err != nil {
+			log.Printf("[ERROR]: could not map the OIDC claims of user %s, reason: %v", oidcUser.ID, err)
+			return echo.NewHTTPError(http.StatusInternalServerError, "could not map the OIDC claims to roles")
+		}
+
+		if len(mappings) == 0 && claimSettings.DenyUnmapped {
+			return echo.NewHTTPError(http.StatusUnauthorized, "user has no permission to log in to OpenUEM")
+		}
+
+		oidcUser.MapRoles = true
+		oidcUser.RoleMappings = mappings
+	}
+
 	// Manage session
 	return h.ManageOIDCSession(c, &oidcUser)
 }
@@ -499,6 +527,13 @@ func (h *Handler) ManageOIDCSession(c echo.Context, u *OIDCSessionInfo) error {
 
 	// If user has been approved by admin, auto approve is on or user already logged in (register completed)
 	if account.Register == nats.REGISTER_APPROVED || settings.OIDCAutoApprove || account.Register == nats.REGISTER_COMPLETE {
+		if u.MapRoles {
+			if err := h.SyncOIDCRoles(c, u.ID, u.RoleMappings); err != nil {
+				log.Printf("[ERROR]: could not sync the roles of user %s with the OIDC claims, reason: %v", u.ID, err)
+				return echo.NewHTTPError(http.StatusInternalServerError, "could not sync the roles with the OIDC claims")
+			}
+		}
+
 		if err := h.CreateSession(c, account); err != nil {
 			log.Printf("[ERROR]: could not create session, reason: %v", err)
 			return echo.NewHTTPError(http.StatusInternalServerError, "could not create session")
@@ -608,6 +643,11 @@ func GetUserInfo(accessToken string, endpoint string) (*UserInfoResponse, error)
 		return nil, errors.New(user.Error)
 	}
 
+	if err := json.Unmarshal(body, &user.Claims); err != nil {
+		log.Printf("[ERROR]: could not decode the claims from user info endpoint, reason: %v", err)
+		return nil, err
+	}
+
 	return &user, nil
 }
 
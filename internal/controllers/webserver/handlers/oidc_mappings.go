@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"strconv"
+	"strings"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/invopop/ctxi18n/i18n"
+	"github.com/labstack/echo/v4"
+	"github.com/open-uem/openuem-console/internal/claims"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/models"
+	"github.com/open-uem/openuem-console/internal/rbac"
+	"github.com/open-uem/openuem-console/internal/views/admin_views"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+)
+
+// OIDCMappingActor is the user recorded in the audit log for the roles granted and removed
+// by the claim mapping when a user logs in with OpenID Connect
+const OIDCMappingActor = "oidc"
+
+// OIDCClaims returns the claims of the user, the claims of the ID token are overridden by the
+// ones sent by the user info endpoint. Some providers only add the groups to the ID token, it's
+// only used once its signature has been verified
+func OIDCClaims(provider *oidc.Provider, clientID string, idToken string, u *UserInfoResponse) map[string]any {
+	all := map[string]any{}
+
+	if idToken != "" {
+		token, err := provider.Verifier(&oidc.Config{ClientID: clientID}).Verify(context.Background(), idToken)
+		if err != nil {
+			log.Printf("[ERROR]: could not verify the OIDC ID token, its claims won't be mapped, reason: %v", err)
+		} else if err := token.Claims(&all); err != nil {
+			log.Printf("[ERROR]: could not read the claims of the OIDC ID token, reason: %v", err)
+		}
+	}
+
+	maps.Copy(all, u.Claims)
+	return all
+}
+
+// MapOIDCRoles returns the role mappings that match the values found in the configured claim
+func (h *Handler) MapOIDCRoles(s consoledb.OIDCClaimSettings, userClaims map[string]any) ([]consoledb.OIDCRoleMapping, error) {
+	values, err := claims.Extract(userClaims, s.Claim)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.Model.GetOIDCRoleMappingsForValues(values)
+}
+
+// SyncOIDCRoles grants and removes the roles of the user according to the claim mapping,
+// the changes are recorded in the audit log as done by the identity provider
+func (h *Handler) SyncOIDCRoles(c echo.Context, userID string, mappings []consoledb.OIDCRoleMapping) error {
+	added, removed, err := h.Model.SyncOIDCRoleAssignments(userID, mappings)
+
+	for _, a := range added {
+		h.auditOIDCRole(c, AuditRoleAdd, userID, "", auditRole(a.Role, a.TenantID, a.SiteID))
+	}
+
+	for _, a := range removed {
+		h.auditOIDCRole(c, AuditRoleDelete, userID, auditRole(a.Role, a.TenantID, a.SiteID), "")
+	}
+
+	if len(added) > 0 || len(removed) > 0 {
+		if h.AuthLogger != nil {
+			h.AuthLogger.Printf("the roles of user %s have been updated with the OpenID claim mapping, %d added and %d removed", userID, len(added), len(removed))
+		}
+	}
+
+	return err
+}
+
+// auditOIDCRole can't use Audit as there's no session yet when the roles are synced
+func (h *Handler) auditOIDCRole(c echo.Context, action, target, before, after string) {
+	entry := consoledb.AuditEntry{
+		UserID:   OIDCMappingActor,
+		IP:       c.RealIP(),
+		TenantID: -1,
+		SiteID:   -1,
+		Action:   action,
+		Target:   target,
+		Before:   before,
+		After:    after,
+	}
+
+	if err := h.Model.AddAuditEntry(entry); err != nil {
+		log.Printf("[ERROR]: could not save the %s action in the audit log, reason: %v", action, err)
+	}
+}
+
+func (h *Handler) ListOIDCRoleMappings(c echo.Context, successMessage, errMessage string) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	settings, err := h.Model.GetOIDCClaimSettings()
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "oidc_mappings.could_not_get_settings", err.Error()), false))
+	}
+
+	mappings, err := h.Model.GetOIDCRoleMappings()
+	if err != nil {
+		successMessage = ""
+		errMessage = err.Error()
+	}
+
+	allTenants, err := h.Model.GetTenants()
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), false))
+	}
+
+	allSites := map[int]string{}
+	for _, t := range allTenants {
+		sites, err := h.Model.GetSites(t.ID)
+		if err != nil {
+			return RenderError(c, partials.ErrorMessage(err.Error(), false))
+		}
+		for _, s := range sites {
+			allSites[s.ID] = s.Description
+		}
+	}
+
+	agentsExists, err := h.Model.AgentsExists(commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), false))
+	}
+
+	serversExists, err := h.Model.ServersExists()
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), false))
+	}
+
+	return RenderView(c, admin_views.RolesIndex(" | OIDC", admin_views.OIDCRoleMappings(c, settings, mappings, allTenants, allSites, successMessage, errMessage, agentsExists, serversExists, commonInfo), commonInfo))
+}
+
+// SaveOIDCClaimSettings saves which claim is mapped. When the mapping is enabled and no role has been
+// assigned yet, the admin becomes a global admin as the first login would restrict the console
+func (h *Handler) SaveOIDCClaimSettings(c echo.Context) error {
+	before, err := h.Model.GetOIDCClaimSettings()
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "oidc_mappings.could_not_get_settings", err.Error()), true))
+	}
+
+	after := consoledb.OIDCClaimSettings{Claim: strings.TrimSpace(c.FormValue("claim-path"))}
+
+	after.Enabled, err = strconv.ParseBool(c.FormValue("claim-enabled"))
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "oidc_mappings.could_not_parse_option", "claim-enabled"), true))
+	}
+
+	after.DenyUnmapped, err = strconv.ParseBool(c.FormValue("claim-deny-unmapped"))
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "oidc_mappings.could_not_parse_option", "claim-deny-unmapped"), true))
+	}
+
+	if !claims.ValidPath(after.Claim) {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "oidc_mappings.invalid_claim", after.Claim), true))
+	}
+
+	successMessage := i18n.T(c.Request().Context(), "oidc_mappings.settings_saved")
+	if after.Enabled {
+		count, err := h.Model.CountAllRoleAssignments()
+		if err != nil {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "oidc_mappings.settings_not_saved", err.Error()), true))
+		}
+
+		if count == 0 {
+			current := h.SessionManager.Manager.GetString(c.Request().Context(), "uid")
+			if err := h.Model.AddRoleAssignment(current, rbac.RoleGlobalAdmin, -1, -1); err != nil {
+				return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "roles.could_not_add", err.Error()), true))
+			}
+			h.Audit(c, AuditRoleAdd, current, "", auditRole(string(rbac.RoleGlobalAdmin), -1, -1))
+			successMessage = i18n.T(c.Request().Context(), "roles.added_first", current)
+		}
+	}
+
+	if err := h.Model.SaveOIDCClaimSettings(after); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "oidc_mappings.settings_not_saved", err.Error()), true))
+	}
+
+	h.AuditChanges(c, AuditAuthUpdate, "oidc_claims", before, after)
+
+	return h.ListOIDCRoleMappings(c, successMessage, "")
+}
+
+func (h *Handler) AddOIDCRoleMapping(c echo.Context) error {
+	value := strings.TrimSpace(c.FormValue("claim-value"))
+	if value == "" {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "oidc_mappings.value_required"), true))
+	}
+
+	role := rbac.Role(c.FormValue("role"))
+	if !role.IsValid() {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "roles.invalid_role"), true))
+	}
+
+	tenantID, siteID, err := h.parseRoleScope(c, role)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), true))
+	}
+
+	if err := h.Model.AddOIDCRoleMapping(value, role, tenantID, siteID); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "oidc_mappings.could_not_add", err.Error()), true))
+	}
+
+	h.Audit(c, AuditOIDCMappingAdd, value, "", auditRole(string(role), tenantID, siteID))
+
+	return h.ListOIDCRoleMappings(c, i18n.T(c.Request().Context(), "oidc_mappings.added"), "")
+}
+
+func (h *Handler) OIDCRoleMappingDelete(c echo.Context) error {
+	id := c.Param("id")
+	if _, err := strconv.Atoi(id); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "oidc_mappings.invalid_id"), true))
+	}
+
+	return RenderConfirm(c, partials.ConfirmDelete(c, i18n.T(c.Request().Context(), "oidc_mappings.confirm_delete"), "/admin/roles/oidc", fmt.Sprintf("/admin/roles/oidc/%s", id)))
+}
+
+// OIDCRoleMappingConfirmDelete removes the mapping, the roles it granted are removed
+// the next time each user logs in
+func (h *Handler) OIDCRoleMappingConfirmDelete(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "oidc_mappings.invalid_id"), true))
+	}
+
+	mapping, err := h.Model.GetOIDCRoleMapping(id)
+	if err != nil {
+		if errors.Is(err, models.ErrOIDCRoleMappingNotFound) {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "oidc_mappings.invalid_id"), true))
+		}
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "oidc_mappings.could_not_delete", err.Error()), true))
+	}
+
+	if err := h.Model.DeleteOIDCRoleMapping(id); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "oidc_mappings.could_not_delete", err.Error()), true))
+	}
+
+	h.Audit(c, AuditOIDCMappingDelete, mapping.Value, auditRole(mapping.Role, mapping.TenantID, mapping.SiteID), "")
+
+	return h.ListOIDCRoleMappings(c, i18n.T(c.Request().Context(), "oidc_mappings.deleted"), "")
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"net/url"
+	"slices"
 	"strconv"
 
 	"github.com/invopop/ctxi18n/i18n"
@@ -11,6 +12,7 @@ import (
 	"github.com/open-uem/ent"
 	"github.com/open-uem/ent/task"
 	"github.com/open-uem/nats"
+	"github.com/open-uem/openuem-console/internal/rbac"
 	"github.com/open-uem/openuem-console/internal/views/filters"
 	"github.com/open-uem/openuem-console/internal/views/partials"
 	"github.com/open-uem/openuem-console/internal/views/profiles_views"
@@ -168,7 +170,7 @@ func (h *Handler) EditProfile(c echo.Context, method string, id string, successM
 
 	confirmDelete := false
 
-	allProfiles, err := h.Model.GetAllProfiles()
+	allProfiles, err := h.Model.GetAllProfiles(commonInfo)
 	if err != nil {
 		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "tasks.all_profiles_error", err), true))
 	}
@@ -332,7 +334,7 @@ func (h *Handler) ConfirmDeleteTask(c echo.Context) error {
 	confirmDelete := true
 	confirmClone := false
 
-	allProfiles, err := h.Model.GetAllProfiles()
+	allProfiles, err := h.Model.GetAllProfiles(commonInfo)
 	if err != nil {
 		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "tasks.all_profiles_error", err), true))
 	}
@@ -628,11 +630,13 @@ func (h *Handler) CloneProfile(c echo.Context) error {
 		return RenderError(c, partials.ErrorMessage(fmt.Sprintf("%s : %v", i18n.T(c.Request().Context(), "profiles.clone.profile_is_not_valid"), err), true))
 	}
 
-	// TODO-Steve we must filter which tenants are available for a role
 	allTenants, err := h.Model.GetTenants()
 	if err != nil {
 		return RenderError(c, partials.ErrorMessage(fmt.Sprintf("%s : %v", i18n.T(c.Request().Context(), "profiles.clone.all_profiles_error"), err), true))
 	}
+	allTenants = slices.DeleteFunc(allTenants, func(t *ent.Tenant) bool {
+		return commonInfo.Access.Level(t.ID, -1) < rbac.PermissionManage
+	})
 
 	if c.Request().Method == "POST" {
 		profileDescription := c.FormValue("profile-description")
@@ -660,6 +664,10 @@ func (h *Handler) CloneProfile(c echo.Context) error {
 
 		}
 
+		if commonInfo.Access.Level(tenantID, siteID) < rbac.PermissionManage {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "roles.forbidden"), true))
+		}
+
 		if err := h.Model.CloneProfile(profileID, profileDescription, tenantID, siteID); err != nil {
 			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "profiles.clone.could_not_clone", err), true))
 		}
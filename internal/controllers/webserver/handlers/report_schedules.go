@@ -0,0 +1,314 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/invopop/ctxi18n"
+	"github.com/invopop/ctxi18n/i18n"
+	"github.com/labstack/echo/v4"
+	"github.com/open-uem/openuem-console/internal/alerts"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/models"
+	"github.com/open-uem/openuem-console/internal/reports"
+	"github.com/open-uem/openuem-console/internal/views/filters"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+	"github.com/open-uem/openuem-console/internal/views/reports_views"
+)
+
+func (h *Handler) ListReportSchedules(c echo.Context) error {
+	return h.RenderReportSchedules(c, "", "")
+}
+
+// NewReportSchedule shows the form to schedule a report, the filters applied to the
+// list the user was viewing are captured so the scheduled report contains the same rows
+func (h *Handler) NewReportSchedule(c echo.Context) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	report := c.Param("report")
+	if !slices.Contains(reports.Types, report) {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.invalid_report_selected"), true))
+	}
+
+	f, err := h.getReportFilters(c, report)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_apply_filters"), true))
+	}
+
+	data, err := json.Marshal(f)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_apply_filters"), true))
+	}
+
+	form := reports_views.ReportScheduleForm{
+		Report:    report,
+		Filters:   string(data),
+		SortBy:    c.FormValue("sortBy"),
+		SortOrder: c.FormValue("sortOrder"),
+	}
+
+	return RenderView(c, reports_views.ReportsIndex(" | Scheduled reports", reports_views.NewReportSchedule(c, form, h.ReportsDir != "", commonInfo), commonInfo))
+}
+
+func (h *Handler) AddReportSchedule(c echo.Context) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	tenantID, siteID, err := reportScheduleScope(commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "tenants.could_not_convert_to_int", err.Error()), true))
+	}
+
+	name := strings.TrimSpace(c.FormValue("report-name"))
+	if name == "" {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "scheduled_reports.empty_name"), true))
+	}
+
+	report := c.FormValue("report-type")
+	if !slices.Contains(reports.Types, report) {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.invalid_report_selected"), true))
+	}
+
+	filtersData := c.FormValue("report-filters")
+	if _, err := decodeReportFilters(report, filtersData); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_apply_filters"), true))
+	}
+
+	format := c.FormValue("report-format")
+	if !slices.Contains(reports.Formats, format) {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "scheduled_reports.invalid_format"), true))
+	}
+
+	cron := strings.TrimSpace(c.FormValue("report-cron"))
+	if _, err := reports.ParseSchedule(cron); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "scheduled_reports.invalid_cron", err.Error()), true))
+	}
+
+	s := consoledb.ReportSchedule{
+		TenantID:  tenantID,
+		SiteID:    siteID,
+		Name:      name,
+		Report:    report,
+		Format:    format,
+		Filters:   filtersData,
+		SortBy:    c.FormValue("report-sort-by"),
+		SortOrder: c.FormValue("report-sort-order"),
+		Cron:      cron,
+		Delivery:  c.FormValue("report-delivery"),
+		Language:  ctxi18n.Locale(c.Request().Context()).Code().String(),
+		Enabled:   true,
+		CreatedBy: h.GetUserID(c),
+	}
+
+	switch s.Delivery {
+	case reports.DeliveryEmail:
+		s.Recipients, err = alerts.ParseRecipients(c.FormValue("report-recipients"))
+		if err != nil {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "scheduled_reports.invalid_recipients", err.Error()), true))
+		}
+	case reports.DeliveryFolder:
+		if h.ReportsDir == "" {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "scheduled_reports.no_reports_dir"), true))
+		}
+		s.Folder = strings.TrimSpace(c.FormValue("report-folder"))
+		if !reports.ValidFolder(s.Folder) {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "scheduled_reports.invalid_folder"), true))
+		}
+	default:
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "scheduled_reports.invalid_delivery"), true))
+	}
+
+	if err := h.Model.AddReportSchedule(s); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "scheduled_reports.could_not_add", err.Error()), true))
+	}
+
+	h.Audit(c, AuditReportScheduleAdd, name, "", fmt.Sprintf("%s %s %s", report, format, cron))
+	h.SyncReportScheduleJobs()
+
+	return h.RenderReportSchedules(c, i18n.T(c.Request().Context(), "scheduled_reports.added"), "")
+}
+
+func (h *Handler) EnableReportSchedule(c echo.Context) error {
+	s, err := h.getReportSchedule(c)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), true))
+	}
+
+	enabled, err := strconv.ParseBool(c.FormValue("report-enabled"))
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "scheduled_reports.invalid_enabled"), true))
+	}
+
+	if err := h.Model.SetReportScheduleEnabled(s.ID, s.TenantID, enabled); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "scheduled_reports.could_not_update", err.Error()), true))
+	}
+
+	h.SyncReportScheduleJobs()
+
+	if enabled {
+		return h.RenderReportSchedules(c, i18n.T(c.Request().Context(), "scheduled_reports.has_been_enabled"), "")
+	}
+	return h.RenderReportSchedules(c, i18n.T(c.Request().Context(), "scheduled_reports.has_been_disabled"), "")
+}
+
+// RunReportSchedule generates and delivers a scheduled report right now
+func (h *Handler) RunReportSchedule(c echo.Context) error {
+	s, err := h.getReportSchedule(c)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), true))
+	}
+
+	if err := h.generateScheduledReport(s); err != nil {
+		h.saveReportScheduleResult(s, err)
+		return h.RenderReportSchedules(c, "", i18n.T(c.Request().Context(), "scheduled_reports.could_not_run", err.Error()))
+	}
+	h.saveReportScheduleResult(s, nil)
+
+	return h.RenderReportSchedules(c, i18n.T(c.Request().Context(), "scheduled_reports.has_run"), "")
+}
+
+func (h *Handler) ReportScheduleDelete(c echo.Context) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	s, err := h.getReportSchedule(c)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), true))
+	}
+
+	return RenderConfirm(c, partials.ConfirmDelete(c, i18n.T(c.Request().Context(), "scheduled_reports.confirm_delete", s.Name), "", partials.GetNavigationUrl(commonInfo, fmt.Sprintf("/scheduled-reports/%d", s.ID))))
+}
+
+func (h *Handler) ReportScheduleConfirmDelete(c echo.Context) error {
+	s, err := h.getReportSchedule(c)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), true))
+	}
+
+	if err := h.Model.DeleteReportSchedule(s.ID, s.TenantID); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "scheduled_reports.could_not_delete", err.Error()), true))
+	}
+
+	h.Audit(c, AuditReportScheduleDelete, s.Name, fmt.Sprintf("%s %s %s", s.Report, s.Format, s.Cron), "")
+	h.SyncReportScheduleJobs()
+
+	return h.RenderReportSchedules(c, i18n.T(c.Request().Context(), "scheduled_reports.deleted"), "")
+}
+
+func (h *Handler) RenderReportSchedules(c echo.Context, successMessage, errMessage string) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	tenantID, siteID, err := reportScheduleScope(commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "tenants.could_not_convert_to_int", err.Error()), true))
+	}
+
+	schedules, err := h.Model.GetReportSchedules(tenantID)
+	if err != nil {
+		successMessage = ""
+		errMessage = i18n.T(c.Request().Context(), "scheduled_reports.could_not_get", err.Error())
+	}
+
+	// Inside a site only the reports scheduled for that site are shown
+	if siteID != -1 {
+		schedules = slices.DeleteFunc(schedules, func(s consoledb.ReportSchedule) bool { return s.SiteID != siteID })
+	}
+
+	return RenderView(c, reports_views.ReportsIndex(" | Scheduled reports", reports_views.ReportSchedules(c, schedules, successMessage, errMessage, commonInfo), commonInfo))
+}
+
+func (h *Handler) getReportSchedule(c echo.Context) (consoledb.ReportSchedule, error) {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return consoledb.ReportSchedule{}, err
+	}
+
+	tenantID, siteID, err := reportScheduleScope(commonInfo)
+	if err != nil {
+		return consoledb.ReportSchedule{}, errors.New(i18n.T(c.Request().Context(), "tenants.could_not_convert_to_int", err.Error()))
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return consoledb.ReportSchedule{}, errors.New(i18n.T(c.Request().Context(), "scheduled_reports.invalid_id"))
+	}
+
+	s, err := h.Model.GetReportSchedule(id, tenantID)
+	if err == nil && siteID != -1 && s.SiteID != siteID {
+		err = models.ErrReportScheduleNotFound
+	}
+	if err != nil {
+		if errors.Is(err, models.ErrReportScheduleNotFound) {
+			return consoledb.ReportSchedule{}, errors.New(i18n.T(c.Request().Context(), "scheduled_reports.not_found"))
+		}
+		return consoledb.ReportSchedule{}, errors.New(i18n.T(c.Request().Context(), "scheduled_reports.could_not_get", err.Error()))
+	}
+
+	return s, nil
+}
+
+// getReportFilters reads the filters of the list a report is generated from
+func (h *Handler) getReportFilters(c echo.Context, report string) (any, error) {
+	switch report {
+	case reports.ReportAgents:
+		return h.GetAgentFilters(c)
+	case reports.ReportComputers:
+		return h.GetComputerFilters(c)
+	case reports.ReportAntivirus:
+		return h.GetEDRFilters(c)
+	case reports.ReportUpdates:
+		f, _, _, err := h.GetSystemUpdatesFilters(c)
+		return f, err
+	case reports.ReportSoftware:
+		return h.GetSoftwareFilters(c)
+	default:
+		return nil, errors.New("unknown report")
+	}
+}
+
+// decodeReportFilters decodes the filters saved with a scheduled report, software
+// reports use the applications filter and the rest the agents filter
+func decodeReportFilters(report, data string) (any, error) {
+	if report == reports.ReportSoftware {
+		f := filters.ApplicationsFilter{}
+		err := json.Unmarshal([]byte(data), &f)
+		return f, err
+	}
+
+	f := filters.AgentFilter{}
+	err := json.Unmarshal([]byte(data), &f)
+	return f, err
+}
+
+// reportScheduleScope returns the tenant and the site, -1 for every site, the
+// reports are scheduled for
+func reportScheduleScope(commonInfo *partials.CommonInfo) (int, int, error) {
+	tenantID, err := strconv.Atoi(commonInfo.TenantID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	siteID := -1
+	if commonInfo.SiteID != "" && commonInfo.SiteID != "-1" {
+		siteID, err = strconv.Atoi(commonInfo.SiteID)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	return tenantID, siteID, nil
+}
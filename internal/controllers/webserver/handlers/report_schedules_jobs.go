@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-co-op/gocron/v2"
+	"github.com/invopop/ctxi18n"
+	"github.com/invopop/ctxi18n/i18n"
+	"github.com/johnfercher/maroto/v2/pkg/core"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/reports"
+	"github.com/open-uem/openuem-console/internal/views/filters"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+)
+
+const (
+	reportScheduleJobTag       = "report-schedule"
+	reportScheduleSyncInterval = 5 * time.Minute
+	// reportScheduleClaim prevents several console instances from generating the same report
+	reportScheduleClaim = 30 * time.Second
+)
+
+// StartReportScheduleJobs schedules a job for every enabled scheduled report and a job
+// that keeps them in sync with the database, so changes made in other console instances
+// are picked up
+func (h *Handler) StartReportScheduleJobs() error {
+	h.SyncReportScheduleJobs()
+
+	if _, err := h.TaskScheduler.NewJob(
+		gocron.DurationJob(reportScheduleSyncInterval),
+		gocron.NewTask(h.SyncReportScheduleJobs),
+		gocron.WithSingletonMode(gocron.LimitModeReschedule),
+	); err != nil {
+		log.Printf("[ERROR]: could not schedule the job that syncs scheduled reports, reason: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// SyncReportScheduleJobs adds the jobs of the scheduled reports that have been enabled
+// and removes the jobs of those that have been disabled or deleted
+func (h *Handler) SyncReportScheduleJobs() {
+	h.reportJobsMutex.Lock()
+	defer h.reportJobsMutex.Unlock()
+
+	schedules, err := h.Model.GetEnabledReportSchedules()
+	if err != nil {
+		log.Printf("[ERROR]: could not get the scheduled reports, reason: %v", err)
+		return
+	}
+
+	wanted := map[string]consoledb.ReportSchedule{}
+	for _, s := range schedules {
+		wanted[reportScheduleTag(s)] = s
+	}
+
+	for _, job := range h.TaskScheduler.Jobs() {
+		tags := job.Tags()
+		if len(tags) != 2 || tags[0] != reportScheduleJobTag {
+			continue
+		}
+
+		if _, ok := wanted[tags[1]]; ok {
+			delete(wanted, tags[1])
+			continue
+		}
+
+		if err := h.TaskScheduler.RemoveJob(job.ID()); err != nil {
+			log.Printf("[ERROR]: could not remove the job of a scheduled report, reason: %v", err)
+		}
+	}
+
+	for tag, s := range wanted {
+		if _, err := h.TaskScheduler.NewJob(
+			gocron.CronJob(s.Cron, false),
+			gocron.NewTask(h.RunScheduledReport, s.ID, s.TenantID),
+			gocron.WithTags(reportScheduleJobTag, tag),
+			gocron.WithSingletonMode(gocron.LimitModeReschedule),
+		); err != nil {
+			log.Printf("[ERROR]: could not schedule report %d, reason: %v", s.ID, err)
+		}
+	}
+}
+
+// RunScheduledReport generates and delivers a scheduled report if no other console
+// instance has just done it
+func (h *Handler) RunScheduledReport(id, tenantID int) {
+	s, err := h.Model.GetReportSchedule(id, tenantID)
+	if err != nil {
+		log.Printf("[ERROR]: could not get scheduled report %d, reason: %v", id, err)
+		return
+	}
+
+	if !s.Enabled {
+		return
+	}
+
+	claimed, err := h.Model.ClaimReportSchedule(s.ID, reportScheduleClaim)
+	if err != nil {
+		log.Printf("[ERROR]: could not claim scheduled report %d, reason: %v", s.ID, err)
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	err = h.generateScheduledReport(s)
+	if err != nil {
+		log.Printf("[ERROR]: could not generate scheduled report %d, reason: %v", s.ID, err)
+	}
+	h.saveReportScheduleResult(s, err)
+}
+
+func (h *Handler) saveReportScheduleResult(s consoledb.ReportSchedule, err error) {
+	status := consoledb.ReportScheduleSucceeded
+	errMessage := ""
+	if err != nil {
+		status = consoledb.ReportScheduleFailed
+		errMessage = err.Error()
+	}
+
+	if err := h.Model.SaveReportScheduleResult(s.ID, status, errMessage); err != nil {
+		log.Printf("[ERROR]: could not save the result of scheduled report %d, reason: %v", s.ID, err)
+	}
+}
+
+// generateScheduledReport generates the report in the language it was scheduled with
+// and e-mails it or saves it in the tenant's folder inside the reports directory
+func (h *Handler) generateScheduledReport(s consoledb.ReportSchedule) error {
+	ctx, err := ctxi18n.WithLocale(context.Background(), s.Language)
+	if err != nil {
+		return err
+	}
+
+	fileName := reports.FileName(s.Name, s.Format, time.Now())
+
+	if s.Delivery == reports.DeliveryFolder {
+		if h.ReportsDir == "" || !reports.ValidFolder(s.Folder) {
+			return errors.New("the reports directory is not configured or the folder is not valid")
+		}
+
+		dir := filepath.Join(h.ReportsDir, strconv.Itoa(s.TenantID), s.Folder)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+
+		return h.writeScheduledReport(ctx, s, filepath.Join(dir, fileName))
+	}
+
+	dir, err := os.MkdirTemp(h.DownloadDir, "report")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			log.Printf("[ERROR]: could not remove the scheduled report temporary folder, reason: %v", err)
+		}
+	}()
+
+	path := filepath.Join(dir, fileName)
+	if err := h.writeScheduledReport(ctx, s, path); err != nil {
+		return err
+	}
+
+	reportName := i18n.T(ctx, "scheduled_reports.type_"+s.Report)
+	subject := i18n.T(ctx, "scheduled_reports.email_subject", s.Name)
+	body := i18n.T(ctx, "scheduled_reports.email_body", reportName, s.Name)
+
+	return h.SendEmail(strconv.Itoa(s.TenantID), s.Recipients, subject, body, path)
+}
+
+// writeScheduledReport gets the rows matching the saved filters and writes them to path
+func (h *Handler) writeScheduledReport(ctx context.Context, s consoledb.ReportSchedule, path string) error {
+	commonInfo := &partials.CommonInfo{TenantID: strconv.Itoa(s.TenantID), SiteID: strconv.Itoa(s.SiteID)}
+
+	itemsPerPage, err := h.Model.GetDefaultItemsPerPage()
+	if err != nil {
+		log.Println("[ERROR]: could not get items per page from database")
+		itemsPerPage = 5
+	}
+
+	p := partials.PaginationAndSort{}
+	p.GetPaginationAndSortParams("0", "0", s.SortBy, s.SortOrder, "", itemsPerPage)
+
+	decoded, err := decodeReportFilters(s.Report, s.Filters)
+	if err != nil {
+		return err
+	}
+
+	if s.Report == reports.ReportSoftware {
+		apps, err := h.Model.GetAppsByPage(p, decoded.(filters.ApplicationsFilter), commonInfo)
+		if err != nil {
+			return err
+		}
+		if s.Format == reports.FormatCSV {
+			return saveCSVReport(path, func(w *csv.Writer) error { return writeSoftwareCSV(w, apps) })
+		}
+		return savePDFReport(path)(GetSoftwareReport(ctx, apps))
+	}
+
+	f := decoded.(filters.AgentFilter)
+	switch s.Report {
+	case reports.ReportAgents:
+		agents, err := h.Model.GetAgentsByPage(p, f, true, commonInfo)
+		if err != nil {
+			return err
+		}
+		if s.Format == reports.FormatCSV {
+			return saveCSVReport(path, func(w *csv.Writer) error { return writeAgentsCSV(w, agents) })
+		}
+		return savePDFReport(path)(GetAgentsReport(ctx, agents))
+	case reports.ReportComputers:
+		computers, err := h.Model.GetComputersByPage(p, f, commonInfo)
+		if err != nil {
+			return err
+		}
+		if s.Format == reports.FormatCSV {
+			return saveCSVReport(path, func(w *csv.Writer) error { return writeComputersCSV(w, computers) })
+		}
+		return savePDFReport(path)(GetComputersReport(ctx, computers))
+	case reports.ReportAntivirus:
+		antiviri, err := h.Model.GetAntiviriByPage(p, f, commonInfo)
+		if err != nil {
+			return err
+		}
+		if s.Format == reports.FormatCSV {
+			return saveCSVReport(path, func(w *csv.Writer) error { return writeAntiviriCSV(w, antiviri) })
+		}
+		return savePDFReport(path)(GetAntiviriReport(ctx, antiviri))
+	case reports.ReportUpdates:
+		updates, err := h.Model.GetSystemUpdatesByPage(p, f, commonInfo)
+		if err != nil {
+			return err
+		}
+		if s.Format == reports.FormatCSV {
+			return saveCSVReport(path, func(w *csv.Writer) error { return writeSystemUpdatesCSV(ctx, w, updates) })
+		}
+		return savePDFReport(path)(GetSystemUpdatesReport(ctx, updates))
+	default:
+		return fmt.Errorf("unknown report %s", s.Report)
+	}
+}
+
+// reportScheduleTag identifies the job of a scheduled report, the cron expression is
+// part of it so the job is replaced if the schedule changes
+func reportScheduleTag(s consoledb.ReportSchedule) string {
+	return fmt.Sprintf("%s-%d-%s", reportScheduleJobTag, s.ID, strings.ReplaceAll(s.Cron, " ", "_"))
+}
+
+func saveCSVReport(path string, write func(w *csv.Writer) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := write(csv.NewWriter(file)); err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+func savePDFReport(path string) func(m core.Maroto, err error) error {
+	return func(m core.Maroto, err error) error {
+		if err != nil {
+			return err
+		}
+
+		document, err := m.Generate()
+		if err != nil {
+			return err
+		}
+
+		return document.Save(path)
+	}
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"encoding/csv"
 	"fmt"
 	"log"
@@ -8,6 +9,7 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -20,17 +22,21 @@ import (
 	"github.com/johnfercher/maroto/v2/pkg/config"
 	"github.com/johnfercher/maroto/v2/pkg/consts/align"
 	"github.com/johnfercher/maroto/v2/pkg/consts/border"
+	"github.com/johnfercher/maroto/v2/pkg/consts/extension"
 	"github.com/johnfercher/maroto/v2/pkg/consts/fontstyle"
 	"github.com/johnfercher/maroto/v2/pkg/consts/orientation"
 	"github.com/johnfercher/maroto/v2/pkg/core"
 	"github.com/johnfercher/maroto/v2/pkg/props"
 	"github.com/labstack/echo/v4"
 	"github.com/open-uem/ent"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/metrics"
 	"github.com/open-uem/openuem-console/internal/models"
 	"github.com/open-uem/openuem-console/internal/views/agents_views"
 	"github.com/open-uem/openuem-console/internal/views/filters"
 	"github.com/open-uem/openuem-console/internal/views/partials"
 	"github.com/open-uem/openuem-console/internal/views/reports_views"
+	"github.com/open-uem/openuem-console/internal/vulnerabilities"
 	"github.com/open-uem/utils"
 	"github.com/xuri/excelize/v2"
 )
@@ -72,6 +78,16 @@ func (h *Handler) GenerateCSVReports(c echo.Context) error {
 		return h.GenerateAntivirusCSVReport(c, w, fileName)
 	case "updates":
 		return h.GenerateUpdatesCSVReport(c, w, fileName)
+	case "compliance":
+		return h.GenerateComplianceCSVReport(c, w, fileName)
+	case "vulnerabilities":
+		return h.GenerateVulnerabilitiesCSVReport(c, w, fileName)
+	case "licenses":
+		return h.GenerateLicensesCSVReport(c, w, fileName)
+	case "hardware-lifecycle":
+		return h.GenerateHardwareLifecycleCSVReport(c, w, fileName)
+	case "fleet-trends":
+		return h.GenerateFleetTrendsCSVReport(c, w, fileName)
 	default:
 		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.invalid_report_selected"), false))
 	}
@@ -103,18 +119,7 @@ func (h *Handler) GenerateAgentsCSVReport(c echo.Context, w *csv.Writer, fileNam
 		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_get_all_agents"), false))
 	}
 
-	w.Write([]string{"name", "status", "os", "version", "ip", "last_contact"})
-
-	for _, agent := range allAgents {
-		record := []string{agent.Nickname, string(agent.AgentStatus), agent.Os, agent.Edges.Release.Version, agent.IP, agent.LastContact.Format("2006-01-02T15:03:04")}
-		if err := w.Write(record); err != nil {
-			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_write_to_csv"), false))
-		}
-	}
-
-	w.Flush()
-
-	if err := w.Error(); err != nil {
+	if err := writeAgentsCSV(w, allAgents); err != nil {
 		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_write_to_csv"), false))
 	}
 
@@ -150,18 +155,7 @@ func (h *Handler) GenerateComputersCSVReport(c echo.Context, w *csv.Writer, file
 		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_get_all_computers"), false))
 	}
 
-	w.Write([]string{"name", "os", "version", "username", "manufacturer", "model", "serial_number"})
-
-	for _, computer := range allComputers {
-		record := []string{computer.Nickname, computer.OS, computer.Version, computer.Username, computer.Manufacturer, computer.Model, computer.Serial}
-		if err := w.Write(record); err != nil {
-			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_write_to_csv"), false))
-		}
-	}
-
-	w.Flush()
-
-	if err := w.Error(); err != nil {
+	if err := writeComputersCSV(w, allComputers); err != nil {
 		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_write_to_csv"), false))
 	}
 
@@ -197,18 +191,7 @@ func (h *Handler) GenerateSoftwareCSVReport(c echo.Context, w *csv.Writer, fileN
 		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_get_all_software"), false))
 	}
 
-	w.Write([]string{"name", "publisher", "#installations"})
-
-	for _, software := range allSoftware {
-		record := []string{software.Name, software.Publisher, strconv.Itoa(software.Count)}
-		if err := w.Write(record); err != nil {
-			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_write_to_csv"), false))
-		}
-	}
-
-	w.Flush()
-
-	if err := w.Error(); err != nil {
+	if err := writeSoftwareCSV(w, allSoftware); err != nil {
 		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_write_to_csv"), false))
 	}
 
@@ -244,18 +227,7 @@ func (h *Handler) GenerateAntivirusCSVReport(c echo.Context, w *csv.Writer, file
 		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_get_all_antiviri"), false))
 	}
 
-	w.Write([]string{"name", "os", "antivirus", "antivirus_enabled", "antivirus_updated"})
-
-	for _, antivirus := range allAntiviri {
-		record := []string{antivirus.Nickname, antivirus.OS, antivirus.Name, strconv.FormatBool(antivirus.IsActive), strconv.FormatBool(antivirus.IsUpdated)}
-		if err := w.Write(record); err != nil {
-			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_write_to_csv"), false))
-		}
-	}
-
-	w.Flush()
-
-	if err := w.Error(); err != nil {
+	if err := writeAntiviriCSV(w, allAntiviri); err != nil {
 		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_write_to_csv"), false))
 	}
 
@@ -291,28 +263,153 @@ func (h *Handler) GenerateUpdatesCSVReport(c echo.Context, w *csv.Writer, fileNa
 		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_get_system_updates"), false))
 	}
 
-	w.Write([]string{"name", "os", "antivirus", "antivirus_enabled", "antivirus_updated"})
+	if err := writeSystemUpdatesCSV(c.Request().Context(), w, allSystemUpdates); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_write_to_csv"), false))
+	}
 
-	for _, update := range allSystemUpdates {
-		lastSearch := update.LastSearch.Format("2006-01-02T15:03:04")
-		if update.LastSearch.IsZero() {
-			lastSearch = "-"
-		}
+	// Redirect to file
+	url := "/download/" + fileName
+	c.Response().Header().Set("HX-Redirect", url)
 
-		lastInstall := update.LastInstall.Format("2006-01-02T15:03:04")
-		if update.LastInstall.IsZero() {
-			lastInstall = "-"
-		}
+	return c.String(http.StatusOK, "")
+}
 
-		record := []string{update.Nickname, update.OS, i18n.T(c.Request().Context(), update.SystemUpdateStatus), lastSearch, lastInstall, strconv.FormatBool(update.PendingUpdates)}
-		if err := w.Write(record); err != nil {
-			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_write_to_csv"), false))
-		}
+func (h *Handler) GenerateComplianceCSVReport(c echo.Context, w *csv.Writer, fileName string) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	itemsPerPage, err := h.Model.GetDefaultItemsPerPage()
+	if err != nil {
+		log.Println("[ERROR]: could not get items per page from database")
+		itemsPerPage = 5
+	}
+
+	p := partials.PaginationAndSort{}
+	p.GetPaginationAndSortParams("0", "0", c.FormValue("sortBy"), c.FormValue("sortOrder"), "", itemsPerPage)
+
+	violations, err := h.Model.GetComplianceViolationsByPage(p, getComplianceViolationFilter(c), commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_get_compliance"), false))
+	}
+
+	if err := writeComplianceCSV(w, violations); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_write_to_csv"), false))
+	}
+
+	// Redirect to file
+	url := "/download/" + fileName
+	c.Response().Header().Set("HX-Redirect", url)
+
+	return c.String(http.StatusOK, "")
+}
+
+func (h *Handler) GenerateVulnerabilitiesCSVReport(c echo.Context, w *csv.Writer, fileName string) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	itemsPerPage, err := h.Model.GetDefaultItemsPerPage()
+	if err != nil {
+		log.Println("[ERROR]: could not get items per page from database")
+		itemsPerPage = 5
+	}
+
+	p := partials.PaginationAndSort{}
+	p.GetPaginationAndSortParams("0", "0", c.FormValue("sortBy"), c.FormValue("sortOrder"), "", itemsPerPage)
+
+	findings, err := h.Model.GetVulnerabilityFindingsByPage(p, getVulnerabilityFindingFilter(c), commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_get_vulnerabilities"), false))
+	}
+
+	if err := writeVulnerabilitiesCSV(w, findings); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_write_to_csv"), false))
+	}
+
+	// Redirect to file
+	url := "/download/" + fileName
+	c.Response().Header().Set("HX-Redirect", url)
+
+	return c.String(http.StatusOK, "")
+}
+
+func (h *Handler) GenerateLicensesCSVReport(c echo.Context, w *csv.Writer, fileName string) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	itemsPerPage, err := h.Model.GetDefaultItemsPerPage()
+	if err != nil {
+		log.Println("[ERROR]: could not get items per page from database")
+		itemsPerPage = 5
+	}
+
+	p := partials.PaginationAndSort{}
+	p.GetPaginationAndSortParams("0", "0", c.FormValue("sortBy"), c.FormValue("sortOrder"), "", itemsPerPage)
+
+	positions, err := h.Model.GetLicensePositions(p, getLicenseFilter(c), commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_get_licenses"), false))
+	}
+
+	if err := writeLicensesCSV(w, positions); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_write_to_csv"), false))
+	}
+
+	// Redirect to file
+	url := "/download/" + fileName
+	c.Response().Header().Set("HX-Redirect", url)
+
+	return c.String(http.StatusOK, "")
+}
+
+func (h *Handler) GenerateHardwareLifecycleCSVReport(c echo.Context, w *csv.Writer, fileName string) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	itemsPerPage, err := h.Model.GetDefaultItemsPerPage()
+	if err != nil {
+		log.Println("[ERROR]: could not get items per page from database")
+		itemsPerPage = 5
+	}
+
+	p := partials.PaginationAndSort{}
+	p.GetPaginationAndSortParams("0", "0", c.FormValue("sortBy"), c.FormValue("sortOrder"), "", itemsPerPage)
+
+	items, err := h.Model.GetHardwareRefresh(p, getHardwareRefreshFilter(c), commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_get_hardware_lifecycle"), false))
+	}
+
+	if err := writeHardwareLifecycleCSV(w, items); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_write_to_csv"), false))
+	}
+
+	// Redirect to file
+	url := "/download/" + fileName
+	c.Response().Header().Set("HX-Redirect", url)
+
+	return c.String(http.StatusOK, "")
+}
+
+func (h *Handler) GenerateFleetTrendsCSVReport(c echo.Context, w *csv.Writer, fileName string) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
 	}
 
-	w.Flush()
+	items, err := h.getFleetMetrics(commonInfo, metrics.ParsePeriod(c.FormValue("filterByPeriod")))
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_get_fleet_metrics"), false))
+	}
 
-	if err := w.Error(); err != nil {
+	if err := writeFleetTrendsCSV(w, items); err != nil {
 		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_write_to_csv"), false))
 	}
 
@@ -323,6 +420,112 @@ func (h *Handler) GenerateUpdatesCSVReport(c echo.Context, w *csv.Writer, fileNa
 	return c.String(http.StatusOK, "")
 }
 
+func writeAgentsCSV(w *csv.Writer, agents []*ent.Agent) error {
+	records := [][]string{{"name", "status", "os", "version", "ip", "last_contact"}}
+	for _, agent := range agents {
+		version := ""
+		if agent.Edges.Release != nil {
+			version = agent.Edges.Release.Version
+		}
+		records = append(records, []string{agent.Nickname, string(agent.AgentStatus), agent.Os, version, agent.IP, agent.LastContact.Format("2006-01-02T15:03:04")})
+	}
+	return w.WriteAll(records)
+}
+
+func writeComputersCSV(w *csv.Writer, computers []models.Computer) error {
+	records := [][]string{{"name", "os", "version", "username", "manufacturer", "model", "serial_number"}}
+	for _, computer := range computers {
+		records = append(records, []string{computer.Nickname, computer.OS, computer.Version, computer.Username, computer.Manufacturer, computer.Model, computer.Serial})
+	}
+	return w.WriteAll(records)
+}
+
+func writeSoftwareCSV(w *csv.Writer, apps []models.App) error {
+	records := [][]string{{"name", "publisher", "#installations"}}
+	for _, software := range apps {
+		records = append(records, []string{software.Name, software.Publisher, strconv.Itoa(software.Count)})
+	}
+	return w.WriteAll(records)
+}
+
+func writeComplianceCSV(w *csv.Writer, violations []consoledb.ComplianceViolation) error {
+	records := [][]string{{"hostname", "policy", "policy_type", "reason", "application", "version", "evaluated"}}
+	for _, v := range violations {
+		records = append(records, []string{v.Hostname, v.PolicyName, v.PolicyType, v.Reason, v.App, v.Version, v.Evaluated.Format(time.RFC3339)})
+	}
+	return w.WriteAll(records)
+}
+
+func writeVulnerabilitiesCSV(w *csv.Writer, findings []consoledb.VulnerabilityFinding) error {
+	records := [][]string{{"hostname", "vulnerability", "score", "severity", "kind", "software", "version", "fixed_version", "published", "scanned"}}
+	for _, f := range findings {
+		published := ""
+		if !f.Published.IsZero() {
+			published = f.Published.Format(time.RFC3339)
+		}
+		records = append(records, []string{f.Hostname, f.VulnerabilityID, strconv.FormatFloat(f.Score, 'f', 1, 64), f.Severity, f.Kind, f.Software, f.Version, f.FixedVersion, published, f.Scanned.Format(time.RFC3339)})
+	}
+	return w.WriteAll(records)
+}
+
+func writeLicensesCSV(w *csv.Writer, positions []models.LicensePosition) error {
+	records := [][]string{{"name", "app_pattern", "publisher_pattern", "seats", "installed", "available", "status", "expiry", "cost_center"}}
+	for _, l := range positions {
+		expiry := ""
+		if !l.Expiry.IsZero() {
+			expiry = l.Expiry.Format("2006-01-02")
+		}
+		records = append(records, []string{l.Name, l.AppPattern, l.PublisherPattern, strconv.Itoa(l.Seats), strconv.Itoa(l.Installed), strconv.Itoa(l.Available), l.Status, expiry, l.CostCenter})
+	}
+	return w.WriteAll(records)
+}
+
+func writeHardwareLifecycleCSV(w *csv.Writer, items []models.HardwareRefresh) error {
+	records := [][]string{{"name", "manufacturer", "model", "serial_number", "purchase_date", "age", "warranty_expiry", "warranty", "planned_replacement"}}
+	for _, i := range items {
+		records = append(records, []string{i.Nickname, i.Manufacturer, i.Model, i.Serial, formatLifecycleDate(i.PurchaseDate), i.Age, formatLifecycleDate(i.WarrantyExpiry), i.Warranty, formatLifecycleDate(i.PlannedReplacement)})
+	}
+	return w.WriteAll(records)
+}
+
+func writeFleetTrendsCSV(w *csv.Writer, items []consoledb.FleetMetrics) error {
+	records := [][]string{append([]string{"day"}, metrics.KPIs...)}
+	for _, item := range items {
+		record := []string{item.Day.Format("2006-01-02")}
+		for _, kpi := range metrics.KPIs {
+			record = append(record, strconv.Itoa(metrics.Value(item, kpi)))
+		}
+		records = append(records, record)
+	}
+	return w.WriteAll(records)
+}
+
+func writeAntiviriCSV(w *csv.Writer, antiviri []models.Antivirus) error {
+	records := [][]string{{"name", "os", "antivirus", "antivirus_enabled", "antivirus_updated"}}
+	for _, antivirus := range antiviri {
+		records = append(records, []string{antivirus.Nickname, antivirus.OS, antivirus.Name, strconv.FormatBool(antivirus.IsActive), strconv.FormatBool(antivirus.IsUpdated)})
+	}
+	return w.WriteAll(records)
+}
+
+func writeSystemUpdatesCSV(ctx context.Context, w *csv.Writer, updates []models.SystemUpdate) error {
+	records := [][]string{{"name", "os", "status", "last_search", "last_install", "pending_updates"}}
+	for _, update := range updates {
+		lastSearch := update.LastSearch.Format("2006-01-02T15:03:04")
+		if update.LastSearch.IsZero() {
+			lastSearch = "-"
+		}
+
+		lastInstall := update.LastInstall.Format("2006-01-02T15:03:04")
+		if update.LastInstall.IsZero() {
+			lastInstall = "-"
+		}
+
+		records = append(records, []string{update.Nickname, update.OS, i18n.T(ctx, update.SystemUpdateStatus), lastSearch, lastInstall, strconv.FormatBool(update.PendingUpdates)})
+	}
+	return w.WriteAll(records)
+}
+
 func (h *Handler) GenerateAgentsReport(c echo.Context) error {
 	commonInfo, err := h.GetCommonInfo(c)
 	if err != nil {
@@ -351,7 +554,7 @@ func (h *Handler) GenerateAgentsReport(c echo.Context) error {
 		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_get_all_agents"), false))
 	}
 
-	m, err := GetAgentsReport(c, allAgents)
+	m, err := GetAgentsReport(c.Request().Context(), allAgents)
 	if err != nil {
 		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_initiate_report"), false))
 	}
@@ -373,7 +576,7 @@ func (h *Handler) GenerateAgentsReport(c echo.Context) error {
 	return c.String(http.StatusOK, "")
 }
 
-func GetAgentsReport(c echo.Context, agents []*ent.Agent) (core.Maroto, error) {
+func GetAgentsReport(ctx context.Context, agents []*ent.Agent) (core.Maroto, error) {
 	cfg := config.NewBuilder().
 		WithPageNumber().
 		WithLeftMargin(10).
@@ -386,14 +589,14 @@ func GetAgentsReport(c echo.Context, agents []*ent.Agent) (core.Maroto, error) {
 	m := maroto.NewMetricsDecorator(mrt)
 
 	tableHeader := []core.Row{
-		getPageHeader(i18n.T(c.Request().Context(), "Agents")),
+		getPageHeader(i18n.T(ctx, "Agents")),
 		row.New(5).Add(
-			text.NewCol(2, i18n.T(c.Request().Context(), "agents.nickname"), props.Text{Size: 9, Align: align.Left, Left: 3, Style: fontstyle.Bold, Color: &props.WhiteColor}),
-			text.NewCol(2, i18n.T(c.Request().Context(), "Status"), props.Text{Size: 9, Align: align.Center, Style: fontstyle.Bold, Color: &props.WhiteColor}),
-			text.NewCol(2, i18n.T(c.Request().Context(), "agents.os"), props.Text{Size: 9, Align: align.Center, Style: fontstyle.Bold, Color: &props.WhiteColor}),
-			text.NewCol(2, i18n.T(c.Request().Context(), "agents.version"), props.Text{Size: 9, Align: align.Center, Style: fontstyle.Bold, Color: &props.WhiteColor}),
-			text.NewCol(2, i18n.T(c.Request().Context(), "IP Address"), props.Text{Size: 9, Align: align.Center, Style: fontstyle.Bold, Color: &props.WhiteColor}),
-			text.NewCol(2, i18n.T(c.Request().Context(), "agents.last_contact"), props.Text{Size: 9, Align: align.Center, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+			text.NewCol(2, i18n.T(ctx, "agents.nickname"), props.Text{Size: 9, Align: align.Left, Left: 3, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+			text.NewCol(2, i18n.T(ctx, "Status"), props.Text{Size: 9, Align: align.Center, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+			text.NewCol(2, i18n.T(ctx, "agents.os"), props.Text{Size: 9, Align: align.Center, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+			text.NewCol(2, i18n.T(ctx, "agents.version"), props.Text{Size: 9, Align: align.Center, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+			text.NewCol(2, i18n.T(ctx, "IP Address"), props.Text{Size: 9, Align: align.Center, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+			text.NewCol(2, i18n.T(ctx, "agents.last_contact"), props.Text{Size: 9, Align: align.Center, Style: fontstyle.Bold, Color: &props.WhiteColor}),
 		).WithStyle(&props.Cell{BackgroundColor: getDarkGreenColor()}),
 	}
 
@@ -535,7 +738,7 @@ func (h *Handler) GenerateComputersReport(c echo.Context) error {
 		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_get_all_computers"), false))
 	}
 
-	m, err := GetComputersReport(c, allComputers)
+	m, err := GetComputersReport(c.Request().Context(), allComputers)
 	if err != nil {
 		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_initiate_report"), false))
 	}
@@ -557,7 +760,7 @@ func (h *Handler) GenerateComputersReport(c echo.Context) error {
 	return c.String(http.StatusOK, "")
 }
 
-func GetComputersReport(c echo.Context, computers []models.Computer) (core.Maroto, error) {
+func GetComputersReport(ctx context.Context, computers []models.Computer) (core.Maroto, error) {
 	cfg := config.NewBuilder().
 		WithPageNumber().
 		WithLeftMargin(10).
@@ -570,14 +773,14 @@ func GetComputersReport(c echo.Context, computers []models.Computer) (core.Marot
 	m := maroto.NewMetricsDecorator(mrt)
 
 	tableHeader := []core.Row{
-		getPageHeader(i18n.T(c.Request().Context(), "Computers")),
+		getPageHeader(i18n.T(ctx, "Computers")),
 		row.New(5).Add(
-			text.NewCol(2, i18n.T(c.Request().Context(), "agents.nickname"), props.Text{Size: 9, Left: 3, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+			text.NewCol(2, i18n.T(ctx, "agents.nickname"), props.Text{Size: 9, Left: 3, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
 			text.NewCol(1, "OS", props.Text{Size: 9, Align: align.Center, Style: fontstyle.Bold, Color: &props.WhiteColor}),
-			text.NewCol(2, i18n.T(c.Request().Context(), "agents.version"), props.Text{Size: 9, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
-			text.NewCol(2, i18n.T(c.Request().Context(), "agents.username"), props.Text{Size: 9, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
-			text.NewCol(2, i18n.T(c.Request().Context(), "agents.manufacturer"), props.Text{Size: 9, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
-			text.NewCol(1, i18n.T(c.Request().Context(), "agents.model"), props.Text{Size: 9, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+			text.NewCol(2, i18n.T(ctx, "agents.version"), props.Text{Size: 9, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+			text.NewCol(2, i18n.T(ctx, "agents.username"), props.Text{Size: 9, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+			text.NewCol(2, i18n.T(ctx, "agents.manufacturer"), props.Text{Size: 9, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+			text.NewCol(1, i18n.T(ctx, "agents.model"), props.Text{Size: 9, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
 			text.NewCol(2, "S/N", props.Text{Size: 9, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
 		).WithStyle(&props.Cell{BackgroundColor: getDarkGreenColor()}),
 	}
@@ -730,7 +933,7 @@ func (h *Handler) GenerateAntivirusReport(c echo.Context) error {
 		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_get_all_antiviri"), false))
 	}
 
-	m, err := GetAntiviriReport(c, allAntiviri)
+	m, err := GetAntiviriReport(c.Request().Context(), allAntiviri)
 	if err != nil {
 		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_initiate_report"), false))
 	}
@@ -752,7 +955,7 @@ func (h *Handler) GenerateAntivirusReport(c echo.Context) error {
 	return c.String(http.StatusOK, "")
 }
 
-func GetAntiviriReport(c echo.Context, antiviri []models.Antivirus) (core.Maroto, error) {
+func GetAntiviriReport(ctx context.Context, antiviri []models.Antivirus) (core.Maroto, error) {
 	cfg := config.NewBuilder().
 		WithPageNumber().
 		WithLeftMargin(10).
@@ -765,13 +968,13 @@ func GetAntiviriReport(c echo.Context, antiviri []models.Antivirus) (core.Maroto
 	m := maroto.NewMetricsDecorator(mrt)
 
 	tableHeader := []core.Row{
-		getPageHeader(i18n.T(c.Request().Context(), "Antivirus")),
+		getPageHeader(i18n.T(ctx, "Antivirus")),
 		row.New(5).Add(
-			text.NewCol(3, i18n.T(c.Request().Context(), "agents.nickname"), props.Text{Size: 9, Left: 3, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+			text.NewCol(3, i18n.T(ctx, "agents.nickname"), props.Text{Size: 9, Left: 3, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
 			text.NewCol(2, "OS", props.Text{Size: 9, Align: align.Center, Style: fontstyle.Bold, Color: &props.WhiteColor}),
-			text.NewCol(3, i18n.T(c.Request().Context(), "Antivirus"), props.Text{Size: 9, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
-			text.NewCol(2, i18n.T(c.Request().Context(), "antivirus.enabled"), props.Text{Size: 9, Align: align.Center, Style: fontstyle.Bold, Color: &props.WhiteColor}),
-			text.NewCol(2, i18n.T(c.Request().Context(), "antivirus.updated"), props.Text{Size: 9, Align: align.Center, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+			text.NewCol(3, i18n.T(ctx, "Antivirus"), props.Text{Size: 9, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+			text.NewCol(2, i18n.T(ctx, "antivirus.enabled"), props.Text{Size: 9, Align: align.Center, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+			text.NewCol(2, i18n.T(ctx, "antivirus.updated"), props.Text{Size: 9, Align: align.Center, Style: fontstyle.Bold, Color: &props.WhiteColor}),
 		).WithStyle(&props.Cell{BackgroundColor: getDarkGreenColor()}),
 	}
 
@@ -849,7 +1052,7 @@ func (h *Handler) GenerateUpdatesReport(c echo.Context) error {
 		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_get_system_updates"), false))
 	}
 
-	m, err := GetSystemUpdatesReport(c, allSystemUpdates)
+	m, err := GetSystemUpdatesReport(c.Request().Context(), allSystemUpdates)
 	if err != nil {
 		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_initiate_report"), false))
 	}
@@ -871,7 +1074,7 @@ func (h *Handler) GenerateUpdatesReport(c echo.Context) error {
 	return c.String(http.StatusOK, "")
 }
 
-func GetSystemUpdatesReport(c echo.Context, updates []models.SystemUpdate) (core.Maroto, error) {
+func GetSystemUpdatesReport(ctx context.Context, updates []models.SystemUpdate) (core.Maroto, error) {
 	cfg := config.NewBuilder().
 		WithPageNumber().
 		WithLeftMargin(10).
@@ -884,14 +1087,14 @@ func GetSystemUpdatesReport(c echo.Context, updates []models.SystemUpdate) (core
 	m := maroto.NewMetricsDecorator(mrt)
 
 	tableHeader := []core.Row{
-		getPageHeader(i18n.T(c.Request().Context(), "updates.title")),
+		getPageHeader(i18n.T(ctx, "updates.title")),
 		row.New(5).Add(
-			text.NewCol(2, i18n.T(c.Request().Context(), "agents.nickname"), props.Text{Size: 9, Left: 3, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+			text.NewCol(2, i18n.T(ctx, "agents.nickname"), props.Text{Size: 9, Left: 3, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
 			text.NewCol(1, "OS", props.Text{Size: 9, Align: align.Center, Style: fontstyle.Bold, Color: &props.WhiteColor}),
-			text.NewCol(3, i18n.T(c.Request().Context(), "updates.status"), props.Text{Size: 9, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
-			text.NewCol(2, i18n.T(c.Request().Context(), "updates.last_search"), props.Text{Size: 9, Align: align.Center, Style: fontstyle.Bold, Color: &props.WhiteColor}),
-			text.NewCol(2, i18n.T(c.Request().Context(), "updates.last_install"), props.Text{Size: 9, Align: align.Center, Style: fontstyle.Bold, Color: &props.WhiteColor}),
-			text.NewCol(2, i18n.T(c.Request().Context(), "updates.pending_updates"), props.Text{Size: 9, Align: align.Center, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+			text.NewCol(3, i18n.T(ctx, "updates.status"), props.Text{Size: 9, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+			text.NewCol(2, i18n.T(ctx, "updates.last_search"), props.Text{Size: 9, Align: align.Center, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+			text.NewCol(2, i18n.T(ctx, "updates.last_install"), props.Text{Size: 9, Align: align.Center, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+			text.NewCol(2, i18n.T(ctx, "updates.pending_updates"), props.Text{Size: 9, Align: align.Center, Style: fontstyle.Bold, Color: &props.WhiteColor}),
 		).WithStyle(&props.Cell{BackgroundColor: getDarkGreenColor()}),
 	}
 
@@ -899,12 +1102,12 @@ func GetSystemUpdatesReport(c echo.Context, updates []models.SystemUpdate) (core
 		return nil, err
 	}
 
-	m.AddRows(getSystemUpdatesTransactions(c, updates)...)
+	m.AddRows(getSystemUpdatesTransactions(ctx, updates)...)
 
 	return m, nil
 }
 
-func getSystemUpdatesTransactions(c echo.Context, updates []models.SystemUpdate) []core.Row {
+func getSystemUpdatesTransactions(ctx context.Context, updates []models.SystemUpdate) []core.Row {
 	rows := []core.Row{}
 
 	var contentsRow []core.Row
@@ -928,7 +1131,7 @@ func getSystemUpdatesTransactions(c echo.Context, updates []models.SystemUpdate)
 				Center:  true,
 				Percent: 75,
 			}),
-			text.NewCol(3, i18n.T(c.Request().Context(), update.SystemUpdateStatus), props.Text{Size: 8, Align: align.Left}),
+			text.NewCol(3, i18n.T(ctx, update.SystemUpdateStatus), props.Text{Size: 8, Align: align.Left}),
 			text.NewCol(2, lastSearch, props.Text{Size: 8, Align: align.Center}),
 			text.NewCol(2, lastInstall, props.Text{Size: 8, Align: align.Center}),
 			image.NewFromFileCol(2, getWarningEmoji(update.PendingUpdates), props.Rect{
@@ -977,7 +1180,7 @@ func (h *Handler) GenerateSoftwareReport(c echo.Context) error {
 		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_get_all_software"), false))
 	}
 
-	m, err := GetSoftwareReport(c, allSoftware)
+	m, err := GetSoftwareReport(c.Request().Context(), allSoftware)
 	if err != nil {
 		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_initiate_report"), false))
 	}
@@ -999,7 +1202,7 @@ func (h *Handler) GenerateSoftwareReport(c echo.Context) error {
 	return c.String(http.StatusOK, "")
 }
 
-func GetSoftwareReport(c echo.Context, software []models.App) (core.Maroto, error) {
+func GetSoftwareReport(ctx context.Context, software []models.App) (core.Maroto, error) {
 	cfg := config.NewBuilder().
 		WithPageNumber().
 		WithLeftMargin(10).
@@ -1012,11 +1215,11 @@ func GetSoftwareReport(c echo.Context, software []models.App) (core.Maroto, erro
 	m := maroto.NewMetricsDecorator(mrt)
 
 	tableHeader := []core.Row{
-		getPageHeader(i18n.T(c.Request().Context(), "Software")),
+		getPageHeader(i18n.T(ctx, "Software")),
 		row.New(5).Add(
-			text.NewCol(4, i18n.T(c.Request().Context(), "apps.name"), props.Text{Size: 9, Left: 3, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
-			text.NewCol(4, i18n.T(c.Request().Context(), "apps.publisher"), props.Text{Size: 9, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
-			text.NewCol(4, i18n.T(c.Request().Context(), "apps.num_installations"), props.Text{Size: 9, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+			text.NewCol(4, i18n.T(ctx, "apps.name"), props.Text{Size: 9, Left: 3, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+			text.NewCol(4, i18n.T(ctx, "apps.publisher"), props.Text{Size: 9, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+			text.NewCol(4, i18n.T(ctx, "apps.num_installations"), props.Text{Size: 9, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
 		).WithStyle(&props.Cell{BackgroundColor: getDarkGreenColor()}),
 	}
 	if err := m.RegisterHeader(tableHeader...); err != nil {
@@ -1052,6 +1255,603 @@ func getSoftwareTransactions(apps []models.App) []core.Row {
 	return rows
 }
 
+func (h *Handler) GenerateComplianceReport(c echo.Context) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	fileName := uuid.NewString() + ".pdf"
+	dstPath := filepath.Join(h.DownloadDir, fileName)
+
+	itemsPerPage, err := h.Model.GetDefaultItemsPerPage()
+	if err != nil {
+		log.Println("[ERROR]: could not get items per page from database")
+		itemsPerPage = 5
+	}
+
+	p := partials.PaginationAndSort{}
+	p.GetPaginationAndSortParams("0", "0", c.FormValue("sortBy"), c.FormValue("sortOrder"), "", itemsPerPage)
+
+	summary, err := h.Model.GetComplianceSummary(commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_get_compliance"), false))
+	}
+
+	violations, err := h.Model.GetComplianceViolationsByPage(p, getComplianceViolationFilter(c), commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_get_compliance"), false))
+	}
+
+	m, err := GetComplianceReport(c.Request().Context(), summary, violations)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_initiate_report"), false))
+	}
+
+	document, err := m.Generate()
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_generate_report"), false))
+	}
+
+	err = document.Save(dstPath)
+	if err != nil {
+		return err
+	}
+
+	// Redirect to file
+	url := "/download/" + fileName
+	c.Response().Header().Set("HX-Redirect", url)
+
+	return c.String(http.StatusOK, "")
+}
+
+func GetComplianceReport(ctx context.Context, summary models.ComplianceSummary, violations []consoledb.ComplianceViolation) (core.Maroto, error) {
+	cfg := config.NewBuilder().
+		WithPageNumber().
+		WithLeftMargin(10).
+		WithTopMargin(10).
+		WithOrientation(orientation.Horizontal).
+		WithRightMargin(10).
+		Build()
+
+	mrt := maroto.New(cfg)
+	m := maroto.NewMetricsDecorator(mrt)
+
+	tableHeader := []core.Row{
+		getPageHeader(i18n.T(ctx, "compliance.title")),
+		row.New(5).Add(
+			text.NewCol(3, i18n.T(ctx, "compliance.hostname"), props.Text{Size: 9, Left: 3, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+			text.NewCol(3, i18n.T(ctx, "compliance.policy"), props.Text{Size: 9, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+			text.NewCol(2, i18n.T(ctx, "compliance.reason"), props.Text{Size: 9, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+			text.NewCol(3, i18n.T(ctx, "compliance.app"), props.Text{Size: 9, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+			text.NewCol(1, i18n.T(ctx, "compliance.version"), props.Text{Size: 9, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+		).WithStyle(&props.Cell{BackgroundColor: getDarkGreenColor()}),
+	}
+	if err := m.RegisterHeader(tableHeader...); err != nil {
+		return nil, err
+	}
+
+	m.AddRows(getComplianceTransactions(ctx, violations)...)
+
+	// the number of agents in each state closes the report
+	m.AddRows(row.New(8).Add(
+		text.NewCol(4, i18n.T(ctx, "compliance.evaluated_agents", summary.Evaluated), props.Text{Size: 9, Top: 3, Left: 3, Align: align.Left, Style: fontstyle.Bold}),
+		text.NewCol(4, i18n.T(ctx, "compliance.compliant_agents", summary.Compliant), props.Text{Size: 9, Top: 3, Align: align.Left, Style: fontstyle.Bold}),
+		text.NewCol(4, i18n.T(ctx, "compliance.non_compliant_agents", summary.NonCompliant), props.Text{Size: 9, Top: 3, Align: align.Left, Style: fontstyle.Bold}),
+	))
+
+	return m, nil
+}
+
+func getComplianceTransactions(ctx context.Context, violations []consoledb.ComplianceViolation) []core.Row {
+	rows := []core.Row{}
+
+	for i, v := range violations {
+		r := row.New(4).Add(
+			text.NewCol(3, v.Hostname, props.Text{Size: 8, Left: 3, Align: align.Left}),
+			text.NewCol(3, v.PolicyName, props.Text{Size: 8, Align: align.Left}),
+			text.NewCol(2, i18n.T(ctx, "compliance.reason_"+v.Reason), props.Text{Size: 8, Align: align.Left}),
+			text.NewCol(3, v.App, props.Text{Size: 8, Align: align.Left}),
+			text.NewCol(1, v.Version, props.Text{Size: 8, Align: align.Left}),
+		)
+		if i%2 == 0 {
+			r.WithStyle(&props.Cell{BackgroundColor: getLightGreenColor()})
+		}
+		rows = append(rows, r)
+	}
+
+	return rows
+}
+
+func (h *Handler) GenerateVulnerabilitiesReport(c echo.Context) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	fileName := uuid.NewString() + ".pdf"
+	dstPath := filepath.Join(h.DownloadDir, fileName)
+
+	itemsPerPage, err := h.Model.GetDefaultItemsPerPage()
+	if err != nil {
+		log.Println("[ERROR]: could not get items per page from database")
+		itemsPerPage = 5
+	}
+
+	p := partials.PaginationAndSort{}
+	p.GetPaginationAndSortParams("0", "0", c.FormValue("sortBy"), c.FormValue("sortOrder"), "", itemsPerPage)
+
+	summary, err := h.Model.GetVulnerabilitySummary(commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_get_vulnerabilities"), false))
+	}
+
+	findings, err := h.Model.GetVulnerabilityFindingsByPage(p, getVulnerabilityFindingFilter(c), commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_get_vulnerabilities"), false))
+	}
+
+	m, err := GetVulnerabilitiesReport(c.Request().Context(), summary, findings)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_initiate_report"), false))
+	}
+
+	document, err := m.Generate()
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_generate_report"), false))
+	}
+
+	err = document.Save(dstPath)
+	if err != nil {
+		return err
+	}
+
+	// Redirect to file
+	url := "/download/" + fileName
+	c.Response().Header().Set("HX-Redirect", url)
+
+	return c.String(http.StatusOK, "")
+}
+
+func GetVulnerabilitiesReport(ctx context.Context, summary models.VulnerabilitySummary, findings []consoledb.VulnerabilityFinding) (core.Maroto, error) {
+	cfg := config.NewBuilder().
+		WithPageNumber().
+		WithLeftMargin(10).
+		WithTopMargin(10).
+		WithOrientation(orientation.Horizontal).
+		WithRightMargin(10).
+		Build()
+
+	mrt := maroto.New(cfg)
+	m := maroto.NewMetricsDecorator(mrt)
+
+	tableHeader := []core.Row{
+		getPageHeader(i18n.T(ctx, "vulnerabilities.title")),
+		row.New(5).Add(
+			text.NewCol(2, i18n.T(ctx, "vulnerabilities.hostname"), props.Text{Size: 9, Left: 3, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+			text.NewCol(2, i18n.T(ctx, "vulnerabilities.vulnerability"), props.Text{Size: 9, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+			text.NewCol(2, i18n.T(ctx, "vulnerabilities.score"), props.Text{Size: 9, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+			text.NewCol(3, i18n.T(ctx, "vulnerabilities.software"), props.Text{Size: 9, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+			text.NewCol(2, i18n.T(ctx, "vulnerabilities.version"), props.Text{Size: 9, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+			text.NewCol(1, i18n.T(ctx, "vulnerabilities.fixed_version"), props.Text{Size: 9, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+		).WithStyle(&props.Cell{BackgroundColor: getDarkGreenColor()}),
+	}
+	if err := m.RegisterHeader(tableHeader...); err != nil {
+		return nil, err
+	}
+
+	m.AddRows(getVulnerabilitiesTransactions(ctx, findings)...)
+
+	// the number of exposed agents and the findings of every severity close the report
+	m.AddRows(row.New(8).Add(
+		text.NewCol(4, i18n.T(ctx, "vulnerabilities.scanned_agents", summary.Scanned), props.Text{Size: 9, Top: 3, Left: 3, Align: align.Left, Style: fontstyle.Bold}),
+		text.NewCol(4, i18n.T(ctx, "vulnerabilities.exposed_agents", summary.Exposed), props.Text{Size: 9, Top: 3, Align: align.Left, Style: fontstyle.Bold}),
+	))
+
+	severities := row.New(8)
+	for _, severity := range vulnerabilities.Severities {
+		severities.Add(text.NewCol(2, i18n.T(ctx, "vulnerabilities.severity_"+severity)+": "+strconv.Itoa(summary.Severities[severity]), props.Text{Size: 9, Left: 3, Align: align.Left, Style: fontstyle.Bold}))
+	}
+	m.AddRows(severities)
+
+	return m, nil
+}
+
+func getVulnerabilitiesTransactions(ctx context.Context, findings []consoledb.VulnerabilityFinding) []core.Row {
+	rows := []core.Row{}
+
+	for i, f := range findings {
+		fixed := f.FixedVersion
+		if fixed == "" {
+			fixed = "-"
+		}
+
+		r := row.New(4).Add(
+			text.NewCol(2, f.Hostname, props.Text{Size: 8, Left: 3, Align: align.Left}),
+			text.NewCol(2, f.VulnerabilityID, props.Text{Size: 8, Align: align.Left}),
+			text.NewCol(2, strconv.FormatFloat(f.Score, 'f', 1, 64)+" "+i18n.T(ctx, "vulnerabilities.severity_"+f.Severity), props.Text{Size: 8, Align: align.Left}),
+			text.NewCol(3, f.Software, props.Text{Size: 8, Align: align.Left}),
+			text.NewCol(2, f.Version, props.Text{Size: 8, Align: align.Left}),
+			text.NewCol(1, fixed, props.Text{Size: 8, Align: align.Left}),
+		)
+		if i%2 == 0 {
+			r.WithStyle(&props.Cell{BackgroundColor: getLightGreenColor()})
+		}
+		rows = append(rows, r)
+	}
+
+	return rows
+}
+
+func (h *Handler) GenerateLicensesReport(c echo.Context) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	fileName := uuid.NewString() + ".pdf"
+	dstPath := filepath.Join(h.DownloadDir, fileName)
+
+	itemsPerPage, err := h.Model.GetDefaultItemsPerPage()
+	if err != nil {
+		log.Println("[ERROR]: could not get items per page from database")
+		itemsPerPage = 5
+	}
+
+	p := partials.PaginationAndSort{}
+	p.GetPaginationAndSortParams("0", "0", c.FormValue("sortBy"), c.FormValue("sortOrder"), "", itemsPerPage)
+
+	positions, err := h.Model.GetLicensePositions(p, getLicenseFilter(c), commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_get_licenses"), false))
+	}
+
+	m, err := GetLicensesReport(c.Request().Context(), positions)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_initiate_report"), false))
+	}
+
+	document, err := m.Generate()
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_generate_report"), false))
+	}
+
+	err = document.Save(dstPath)
+	if err != nil {
+		return err
+	}
+
+	// Redirect to file
+	url := "/download/" + fileName
+	c.Response().Header().Set("HX-Redirect", url)
+
+	return c.String(http.StatusOK, "")
+}
+
+func GetLicensesReport(ctx context.Context, positions []models.LicensePosition) (core.Maroto, error) {
+	cfg := config.NewBuilder().
+		WithPageNumber().
+		WithLeftMargin(10).
+		WithTopMargin(10).
+		WithOrientation(orientation.Horizontal).
+		WithRightMargin(10).
+		Build()
+
+	mrt := maroto.New(cfg)
+	m := maroto.NewMetricsDecorator(mrt)
+
+	tableHeader := []core.Row{
+		getPageHeader(i18n.T(ctx, "licenses.title")),
+		row.New(5).Add(
+			text.NewCol(3, i18n.T(ctx, "licenses.name"), props.Text{Size: 9, Left: 3, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+			text.NewCol(1, i18n.T(ctx, "licenses.seats"), props.Text{Size: 9, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+			text.NewCol(1, i18n.T(ctx, "licenses.installed"), props.Text{Size: 9, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+			text.NewCol(1, i18n.T(ctx, "licenses.available"), props.Text{Size: 9, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+			text.NewCol(2, i18n.T(ctx, "licenses.expiry"), props.Text{Size: 9, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+			text.NewCol(2, i18n.T(ctx, "licenses.cost_center"), props.Text{Size: 9, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+			text.NewCol(2, i18n.T(ctx, "licenses.status"), props.Text{Size: 9, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+		).WithStyle(&props.Cell{BackgroundColor: getDarkGreenColor()}),
+	}
+	if err := m.RegisterHeader(tableHeader...); err != nil {
+		return nil, err
+	}
+
+	m.AddRows(getLicensesTransactions(ctx, positions)...)
+
+	// the purchased and installed seats of all the licenses close the report
+	seats, installed := 0, 0
+	for _, l := range positions {
+		seats += l.Seats
+		installed += l.Installed
+	}
+	m.AddRows(row.New(8).Add(
+		text.NewCol(4, i18n.T(ctx, "licenses.purchased_seats", seats), props.Text{Size: 9, Top: 3, Left: 3, Align: align.Left, Style: fontstyle.Bold}),
+		text.NewCol(4, i18n.T(ctx, "licenses.installed_seats", installed), props.Text{Size: 9, Top: 3, Align: align.Left, Style: fontstyle.Bold}),
+	))
+
+	return m, nil
+}
+
+func getLicensesTransactions(ctx context.Context, positions []models.LicensePosition) []core.Row {
+	rows := []core.Row{}
+
+	for i, l := range positions {
+		expiry := i18n.T(ctx, "licenses.no_expiry")
+		if !l.Expiry.IsZero() {
+			expiry = l.Expiry.Format("2006-01-02")
+		}
+
+		r := row.New(4).Add(
+			text.NewCol(3, l.Name, props.Text{Size: 8, Left: 3, Align: align.Left}),
+			text.NewCol(1, strconv.Itoa(l.Seats), props.Text{Size: 8, Align: align.Left}),
+			text.NewCol(1, strconv.Itoa(l.Installed), props.Text{Size: 8, Align: align.Left}),
+			text.NewCol(1, strconv.Itoa(l.Available), props.Text{Size: 8, Align: align.Left}),
+			text.NewCol(2, expiry, props.Text{Size: 8, Align: align.Left}),
+			text.NewCol(2, l.CostCenter, props.Text{Size: 8, Align: align.Left}),
+			text.NewCol(2, i18n.T(ctx, "licenses.status_"+l.Status), props.Text{Size: 8, Align: align.Left}),
+		)
+		if i%2 == 0 {
+			r.WithStyle(&props.Cell{BackgroundColor: getLightGreenColor()})
+		}
+		rows = append(rows, r)
+	}
+
+	return rows
+}
+
+func (h *Handler) GenerateHardwareLifecycleReport(c echo.Context) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	fileName := uuid.NewString() + ".pdf"
+	dstPath := filepath.Join(h.DownloadDir, fileName)
+
+	itemsPerPage, err := h.Model.GetDefaultItemsPerPage()
+	if err != nil {
+		log.Println("[ERROR]: could not get items per page from database")
+		itemsPerPage = 5
+	}
+
+	p := partials.PaginationAndSort{}
+	p.GetPaginationAndSortParams("0", "0", c.FormValue("sortBy"), c.FormValue("sortOrder"), "", itemsPerPage)
+
+	items, err := h.Model.GetHardwareRefresh(p, getHardwareRefreshFilter(c), commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_get_hardware_lifecycle"), false))
+	}
+
+	m, err := GetHardwareLifecycleReport(c.Request().Context(), items)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_initiate_report"), false))
+	}
+
+	document, err := m.Generate()
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_generate_report"), false))
+	}
+
+	err = document.Save(dstPath)
+	if err != nil {
+		return err
+	}
+
+	// Redirect to file
+	url := "/download/" + fileName
+	c.Response().Header().Set("HX-Redirect", url)
+
+	return c.String(http.StatusOK, "")
+}
+
+// GetHardwareLifecycleReport lists the computers with their lifecycle dates so the hardware
+// refresh can be planned
+func GetHardwareLifecycleReport(ctx context.Context, items []models.HardwareRefresh) (core.Maroto, error) {
+	cfg := config.NewBuilder().
+		WithPageNumber().
+		WithLeftMargin(10).
+		WithTopMargin(10).
+		WithOrientation(orientation.Horizontal).
+		WithRightMargin(10).
+		Build()
+
+	mrt := maroto.New(cfg)
+	m := maroto.NewMetricsDecorator(mrt)
+
+	tableHeader := []core.Row{
+		getPageHeader(i18n.T(ctx, "lifecycle.title")),
+		row.New(5).Add(
+			text.NewCol(2, i18n.T(ctx, "agents.nickname"), props.Text{Size: 9, Left: 3, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+			text.NewCol(2, i18n.T(ctx, "inventory.hardware.model"), props.Text{Size: 9, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+			text.NewCol(2, i18n.T(ctx, "inventory.hardware.serial"), props.Text{Size: 9, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+			text.NewCol(2, i18n.T(ctx, "lifecycle.purchase_date"), props.Text{Size: 9, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+			text.NewCol(2, i18n.T(ctx, "lifecycle.warranty_expiry"), props.Text{Size: 9, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+			text.NewCol(2, i18n.T(ctx, "lifecycle.planned_replacement"), props.Text{Size: 9, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+		).WithStyle(&props.Cell{BackgroundColor: getDarkGreenColor()}),
+	}
+	if err := m.RegisterHeader(tableHeader...); err != nil {
+		return nil, err
+	}
+
+	m.AddRows(getHardwareLifecycleTransactions(ctx, items)...)
+
+	return m, nil
+}
+
+func getHardwareLifecycleTransactions(ctx context.Context, items []models.HardwareRefresh) []core.Row {
+	rows := []core.Row{}
+
+	for i, item := range items {
+		purchase := i18n.T(ctx, "lifecycle.age_"+item.Age)
+		if !item.PurchaseDate.IsZero() {
+			purchase = fmt.Sprintf("%s (%s)", formatLifecycleDate(item.PurchaseDate), purchase)
+		}
+
+		warranty := i18n.T(ctx, "lifecycle.warranty_"+item.Warranty)
+		if !item.WarrantyExpiry.IsZero() {
+			warranty = fmt.Sprintf("%s (%s)", formatLifecycleDate(item.WarrantyExpiry), warranty)
+		}
+
+		r := row.New(4).Add(
+			text.NewCol(2, item.Nickname, props.Text{Size: 8, Left: 3, Align: align.Left}),
+			text.NewCol(2, strings.TrimSpace(item.Manufacturer+" "+item.Model), props.Text{Size: 8, Align: align.Left}),
+			text.NewCol(2, item.Serial, props.Text{Size: 8, Align: align.Left}),
+			text.NewCol(2, purchase, props.Text{Size: 8, Align: align.Left}),
+			text.NewCol(2, warranty, props.Text{Size: 8, Align: align.Left}),
+			text.NewCol(2, formatLifecycleDate(item.PlannedReplacement), props.Text{Size: 8, Align: align.Left}),
+		)
+		if i%2 == 0 {
+			r.WithStyle(&props.Cell{BackgroundColor: getLightGreenColor()})
+		}
+		rows = append(rows, r)
+	}
+
+	return rows
+}
+
+func (h *Handler) GenerateFleetTrendsReport(c echo.Context) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	fileName := uuid.NewString() + ".pdf"
+	dstPath := filepath.Join(h.DownloadDir, fileName)
+
+	period := metrics.ParsePeriod(c.FormValue("filterByPeriod"))
+	items, err := h.getFleetMetrics(commonInfo, period)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_get_fleet_metrics"), false))
+	}
+
+	m, err := GetFleetTrendsReport(c.Request().Context(), period, items)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_initiate_report"), false))
+	}
+
+	document, err := m.Generate()
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_generate_report"), false))
+	}
+
+	err = document.Save(dstPath)
+	if err != nil {
+		return err
+	}
+
+	// Redirect to file
+	url := "/download/" + fileName
+	c.Response().Header().Set("HX-Redirect", url)
+
+	return c.String(http.StatusOK, "")
+}
+
+// GetFleetTrendsReport draws the trend of the fleet counters in the period, compares the
+// first and the last snapshot and lists the snapshot of every day
+func GetFleetTrendsReport(ctx context.Context, period int, items []consoledb.FleetMetrics) (core.Maroto, error) {
+	cfg := config.NewBuilder().
+		WithPageNumber().
+		WithLeftMargin(10).
+		WithTopMargin(10).
+		WithOrientation(orientation.Horizontal).
+		WithRightMargin(10).
+		Build()
+
+	mrt := maroto.New(cfg)
+	m := maroto.NewMetricsDecorator(mrt)
+
+	if err := m.RegisterHeader(getPageHeader(i18n.T(ctx, "fleet_metrics.report_title", period))); err != nil {
+		return nil, err
+	}
+
+	if len(items) == 0 {
+		m.AddRows(text.NewRow(10, i18n.T(ctx, "fleet_metrics.no_snapshots"), props.Text{Top: 3, Size: 9, Align: align.Center}))
+		return m, nil
+	}
+
+	chart, err := metrics.TrendImage(items, 1100, 320)
+	if err != nil {
+		return nil, err
+	}
+
+	m.AddRows(image.NewFromBytesRow(60, chart, extension.Png, props.Rect{Center: true, Percent: 100}))
+	m.AddRows(row.New(5).Add(
+		text.NewCol(6, formatMetricsDay(items[0].Day), props.Text{Size: 8, Align: align.Left}),
+		text.NewCol(6, formatMetricsDay(items[len(items)-1].Day), props.Text{Size: 8, Align: align.Right}),
+	))
+	m.AddRows(getFleetTrendsLegend(ctx))
+	m.AddRows(getFleetTrendsSummary(ctx, items)...)
+	m.AddRows(getFleetTrendsTransactions(ctx, items)...)
+
+	return m, nil
+}
+
+// getFleetTrendsLegend names the counters with the color of their line in the chart
+func getFleetTrendsLegend(ctx context.Context) core.Row {
+	cols := []core.Col{col.New(1)}
+	for _, kpi := range metrics.KPIs {
+		c := metrics.Color(kpi)
+		cols = append(cols, text.NewCol(2, i18n.T(ctx, "fleet_metrics."+kpi), props.Text{Top: 1, Size: 8, Style: fontstyle.Bold, Align: align.Center, Color: &props.Color{Red: int(c.R), Green: int(c.G), Blue: int(c.B)}}))
+	}
+	return row.New(7).Add(cols...)
+}
+
+// getFleetTrendsSummary shows how much each counter changed between the first and the
+// last snapshot of the period
+func getFleetTrendsSummary(ctx context.Context, items []consoledb.FleetMetrics) []core.Row {
+	rows := []core.Row{
+		row.New(5).Add(
+			text.NewCol(3, i18n.T(ctx, "fleet_metrics.kpi"), props.Text{Size: 9, Left: 3, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+			text.NewCol(3, formatMetricsDay(items[0].Day), props.Text{Size: 9, Align: align.Center, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+			text.NewCol(3, formatMetricsDay(items[len(items)-1].Day), props.Text{Size: 9, Align: align.Center, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+			text.NewCol(3, i18n.T(ctx, "fleet_metrics.change"), props.Text{Size: 9, Align: align.Center, Style: fontstyle.Bold, Color: &props.WhiteColor}),
+		).WithStyle(&props.Cell{BackgroundColor: getDarkGreenColor()}),
+	}
+
+	for i, kpi := range metrics.KPIs {
+		first, last := metrics.Value(items[0], kpi), metrics.Value(items[len(items)-1], kpi)
+		r := row.New(4).Add(
+			text.NewCol(3, i18n.T(ctx, "fleet_metrics."+kpi), props.Text{Size: 8, Left: 3, Align: align.Left}),
+			text.NewCol(3, strconv.Itoa(first), props.Text{Size: 8, Align: align.Center}),
+			text.NewCol(3, strconv.Itoa(last), props.Text{Size: 8, Align: align.Center}),
+			text.NewCol(3, fmt.Sprintf("%+d", last-first), props.Text{Size: 8, Align: align.Center}),
+		)
+		if i%2 == 0 {
+			r.WithStyle(&props.Cell{BackgroundColor: getLightGreenColor()})
+		}
+		rows = append(rows, r)
+	}
+
+	return append(rows, row.New(5))
+}
+
+func getFleetTrendsTransactions(ctx context.Context, items []consoledb.FleetMetrics) []core.Row {
+	header := []core.Col{text.NewCol(2, i18n.T(ctx, "fleet_metrics.day"), props.Text{Size: 9, Left: 3, Align: align.Left, Style: fontstyle.Bold, Color: &props.WhiteColor})}
+	for _, kpi := range metrics.KPIs {
+		header = append(header, text.NewCol(2, i18n.T(ctx, "fleet_metrics."+kpi), props.Text{Size: 9, Align: align.Center, Style: fontstyle.Bold, Color: &props.WhiteColor}))
+	}
+	rows := []core.Row{row.New(5).Add(header...).WithStyle(&props.Cell{BackgroundColor: getDarkGreenColor()})}
+
+	for i, item := range items {
+		cols := []core.Col{text.NewCol(2, formatMetricsDay(item.Day), props.Text{Size: 8, Left: 3, Align: align.Left})}
+		for _, kpi := range metrics.KPIs {
+			cols = append(cols, text.NewCol(2, strconv.Itoa(metrics.Value(item, kpi)), props.Text{Size: 8, Align: align.Center}))
+		}
+		r := row.New(4).Add(cols...)
+		if i%2 == 0 {
+			r.WithStyle(&props.Cell{BackgroundColor: getLightGreenColor()})
+		}
+		rows = append(rows, r)
+	}
+
+	return rows
+}
+
+// formatMetricsDay formats the day of a snapshot, which is saved in UTC
+func formatMetricsDay(day time.Time) string {
+	return day.UTC().Format("2006-01-02")
+}
+
 func getPageHeader(title string) core.Row {
 	cwd, err := utils.GetWd()
 	if err != nil {
@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/invopop/ctxi18n/i18n"
+	"github.com/labstack/echo/v4"
+	"github.com/open-uem/openuem-console/internal/models"
+	"github.com/open-uem/openuem-console/internal/rbac"
+	"github.com/open-uem/openuem-console/internal/views/admin_views"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+)
+
+// GetUserAccess returns the roles of the authenticated user, they're read once per request
+// and stored in the context
+func (h *Handler) GetUserAccess(c echo.Context) (rbac.Access, error) {
+	if access, ok := c.Get("access").(rbac.Access); ok {
+		return access, nil
+	}
+
+	access, err := h.Model.GetUserAccess(h.GetUserID(c))
+	if err != nil {
+		return rbac.Access{}, err
+	}
+	c.Set("access", access)
+
+	return access, nil
+}
+
+// GetUserID returns the authenticated user, API requests store it in the context
+func (h *Handler) GetUserID(c echo.Context) string {
+	if username, ok := c.Get("uid").(string); ok && username != "" {
+		return username
+	}
+	return h.SessionManager.Manager.GetString(c.Request().Context(), "uid")
+}
+
+// GetRouteScope returns the tenant and site the route works with. Routes without tenant work with
+// the default tenant except the global configuration, profiles and tasks that use the global scope (-1)
+func (h *Handler) GetRouteScope(c echo.Context) (int, int, error) {
+	hasTenant, hasSite, path := rbac.Scope(c.Path())
+
+	if !hasTenant {
+		if strings.HasPrefix(path, "/admin") || strings.HasPrefix(path, "/profiles") || strings.HasPrefix(path, "/tasks") {
+			return -1, -1, nil
+		}
+
+		tenant, err := h.Model.GetDefaultTenant()
+		if err != nil {
+			return 0, 0, err
+		}
+		return tenant.ID, -1, nil
+	}
+
+	tenantID, err := strconv.Atoi(c.Param("tenant"))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	siteID := -1
+	if hasSite {
+		siteID, err = strconv.Atoi(c.Param("site"))
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	return tenantID, siteID, nil
+}
+
+func (h *Handler) ListRoles(c echo.Context, successMessage, errMessage string) error {
+	var err error
+
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	itemsPerPage, err := h.Model.GetDefaultItemsPerPage()
+	if err != nil {
+		log.Println("[ERROR]: could not get items per page from database")
+		itemsPerPage = 5
+	}
+
+	p := partials.NewPaginationAndSort(itemsPerPage)
+	p.GetPaginationAndSortParams(c.FormValue("page"), c.FormValue("pageSize"), c.FormValue("sortBy"), c.FormValue("sortOrder"), c.FormValue("currentSortBy"), itemsPerPage)
+
+	p.NItems, err = h.Model.CountAllRoleAssignments()
+	if err != nil {
+		errMessage = err.Error()
+	}
+
+	assignments, err := h.Model.GetRoleAssignmentsByPage(p)
+	if err != nil {
+		successMessage = ""
+		errMessage = err.Error()
+	}
+
+	users, err := h.Model.GetAllUsers()
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), false))
+	}
+
+	allTenants, err := h.Model.GetTenants()
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), false))
+	}
+
+	allSites := map[int]string{}
+	for _, t := range allTenants {
+		sites, err := h.Model.GetSites(t.ID)
+		if err != nil {
+			return RenderError(c, partials.ErrorMessage(err.Error(), false))
+		}
+		for _, s := range sites {
+			allSites[s.ID] = s.Description
+		}
+	}
+
+	agentsExists, err := h.Model.AgentsExists(commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), false))
+	}
+
+	serversExists, err := h.Model.ServersExists()
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), false))
+	}
+
+	return RenderView(c, admin_views.RolesIndex(" | Roles", admin_views.Roles(c, p, assignments, users, allTenants, allSites, successMessage, errMessage, agentsExists, serversExists, itemsPerPage, commonInfo), commonInfo))
+}
+
+func (h *Handler) AddRoleAssignment(c echo.Context) error {
+	username := c.FormValue("role-user")
+	if _, err := h.Model.GetUserById(username); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "roles.invalid_user"), true))
+	}
+
+	role := rbac.Role(c.FormValue("role"))
+	if !role.IsValid() {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "roles.invalid_role"), true))
+	}
+
+	tenantID, siteID, err := h.parseRoleScope(c, role)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), true))
+	}
+
+	count, err := h.Model.CountAllRoleAssignments()
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "roles.could_not_add", err.Error()), true))
+	}
+
+	// When the first role is assigned the console stops being unrestricted, the admin
+	// assigning it becomes a global admin so nobody is locked out of the console
+	successMessage := i18n.T(c.Request().Context(), "roles.added")
+	if count == 0 {
+		current := h.SessionManager.Manager.GetString(c.Request().Context(), "uid")
+		if current != username || role != rbac.RoleGlobalAdmin {
+			if err := h.Model.AddRoleAssignment(current, rbac.RoleGlobalAdmin, -1, -1); err != nil {
+				return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "roles.could_not_add", err.Error()), true))
+			}
+			successMessage = i18n.T(c.Request().Context(), "roles.added_first", current)
+		}
+	}
+
+	if err := h.Model.AddRoleAssignment(username, role, tenantID, siteID); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "roles.could_not_add", err.Error()), true))
+	}
+
+	h.Audit(c, AuditRoleAdd, username, "", auditRole(string(role), tenantID, siteID))
+
+	return h.ListRoles(c, successMessage, "")
+}
+
+func (h *Handler) RoleAssignmentDelete(c echo.Context) error {
+	id := c.Param("id")
+	if _, err := strconv.Atoi(id); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "roles.invalid_id"), true))
+	}
+
+	return RenderConfirm(c, partials.ConfirmDelete(c, i18n.T(c.Request().Context(), "roles.confirm_delete"), "/admin/roles", fmt.Sprintf("/admin/roles/%s", id)))
+}
+
+func (h *Handler) RoleAssignmentConfirmDelete(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "roles.invalid_id"), true))
+	}
+
+	assignment, err := h.Model.GetRoleAssignment(id)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "roles.could_not_delete", err.Error()), true))
+	}
+
+	if err := h.Model.DeleteRoleAssignment(id); err != nil {
+		if errors.Is(err, models.ErrLastGlobalAdmin) {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "roles.last_global_admin"), true))
+		}
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "roles.could_not_delete", err.Error()), true))
+	}
+
+	h.Audit(c, AuditRoleDelete, assignment.UserID, auditRole(assignment.Role, assignment.TenantID, assignment.SiteID), "")
+
+	return h.ListRoles(c, i18n.T(c.Request().Context(), "roles.deleted"), "")
+}
+
+// parseRoleScope reads the tenant-id and site-id form values of a role assignment, an empty
+// value means every tenant or site. The errors are translated to be shown to the user
+func (h *Handler) parseRoleScope(c echo.Context, role rbac.Role) (int, int, error) {
+	tenantID := -1
+	if tenant := c.FormValue("tenant-id"); tenant != "" {
+		id, err := strconv.Atoi(tenant)
+		if err != nil {
+			return 0, 0, errors.New(i18n.T(c.Request().Context(), "tenants.could_not_convert_to_int", err.Error()))
+		}
+		if _, err := h.Model.GetTenantByID(id); err != nil {
+			return 0, 0, errors.New(i18n.T(c.Request().Context(), "tenants.tenant_not_found"))
+		}
+		tenantID = id
+	}
+
+	siteID := -1
+	if site := c.FormValue("site-id"); site != "" && tenantID != -1 {
+		id, err := strconv.Atoi(site)
+		if err != nil {
+			return 0, 0, errors.New(i18n.T(c.Request().Context(), "sites.could_not_convert_to_int", err.Error()))
+		}
+		if _, err := h.Model.GetSiteById(tenantID, id); err != nil {
+			return 0, 0, errors.New(i18n.T(c.Request().Context(), "sites.site_not_found"))
+		}
+		siteID = id
+	}
+
+	if role == rbac.RoleTenantAdmin && siteID != -1 {
+		return 0, 0, errors.New(i18n.T(c.Request().Context(), "roles.tenant_admin_site"))
+	}
+
+	return tenantID, siteID, nil
+}
+
+// auditRole describes a role assignment in the audit log
+func auditRole(role string, tenantID, siteID int) string {
+	return fmt.Sprintf("%s tenant=%d site=%d", role, tenantID, siteID)
+}
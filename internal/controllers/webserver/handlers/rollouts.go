@@ -0,0 +1,298 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/invopop/ctxi18n"
+	"github.com/invopop/ctxi18n/i18n"
+	"github.com/labstack/echo/v4"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/models"
+	"github.com/open-uem/openuem-console/internal/rollouts"
+	"github.com/open-uem/openuem-console/internal/views/admin_views"
+	"github.com/open-uem/openuem-console/internal/views/filters"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+)
+
+func (h *Handler) ListRollouts(c echo.Context) error {
+	return h.RenderRollouts(c, "", "")
+}
+
+// AddRollout plans a staged update of the agents of the tenant that don't run the
+// version yet. The pilot wave is sent by the rollouts job
+func (h *Handler) AddRollout(c echo.Context) error {
+	tenantID, err := strconv.Atoi(c.Param("tenant"))
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "tenants.could_not_convert_to_int", err.Error()), true))
+	}
+
+	name := strings.TrimSpace(c.FormValue("rollout-name"))
+	if name == "" {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "rollouts.empty_name"), true))
+	}
+
+	version := c.FormValue("rollout-version")
+	releases, err := h.Model.GetAgentsReleases()
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), true))
+	}
+	if !slices.Contains(releases, version) {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "rollouts.invalid_version"), true))
+	}
+
+	pilotType, value, _ := strings.Cut(c.FormValue("rollout-pilot"), ":")
+	pilotValue, err := strconv.Atoi(value)
+	if err != nil || !slices.Contains(rollouts.PilotTypes, pilotType) {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "rollouts.invalid_pilot"), true))
+	}
+
+	waves, err := rollouts.ParseWaves(c.FormValue("rollout-waves"))
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "rollouts.invalid_waves"), true))
+	}
+
+	soak, err := strconv.Atoi(c.FormValue("rollout-soak"))
+	if err != nil || !rollouts.ValidSoak(soak*60) {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "rollouts.invalid_soak"), true))
+	}
+
+	threshold, err := strconv.Atoi(c.FormValue("rollout-threshold"))
+	if err != nil || !rollouts.ValidThreshold(threshold) {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "rollouts.invalid_threshold"), true))
+	}
+
+	channel, err := h.Model.GetDefaultUpdateChannel()
+	if err != nil {
+		log.Println("[ERROR]: could not get updates channel settings")
+		channel = "stable"
+	}
+
+	agents, pilot, err := h.Model.GetRolloutTargets(tenantID, version, pilotType, pilotValue)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "rollouts.could_not_add", err.Error()), true))
+	}
+
+	ids := []string{}
+	hostnames := map[string]string{}
+	for _, a := range agents {
+		ids = append(ids, a.ID)
+		hostnames[a.ID] = a.Hostname
+	}
+
+	plan, err := rollouts.Plan(ids, pilot, waves)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "rollouts.empty_pilot"), true))
+	}
+
+	r := consoledb.Rollout{
+		TenantID:         tenantID,
+		Name:             name,
+		Version:          version,
+		Channel:          channel,
+		PilotType:        pilotType,
+		PilotValue:       pilotValue,
+		Waves:            waves,
+		SoakMinutes:      soak * 60,
+		FailureThreshold: threshold,
+		Language:         ctxi18n.Locale(c.Request().Context()).Code().String(),
+		CreatedBy:        h.GetUserID(c),
+	}
+
+	if _, err := h.Model.AddRollout(r, plan, hostnames); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "rollouts.could_not_add", err.Error()), true))
+	}
+
+	h.Audit(c, AuditRolloutAdd, name, "", fmt.Sprintf("%s %s:%d %s", version, pilotType, pilotValue, rollouts.FormatWaves(waves)))
+
+	return h.RenderRollouts(c, i18n.T(c.Request().Context(), "rollouts.added", len(plan)), "")
+}
+
+func (h *Handler) PauseRollout(c echo.Context) error {
+	return h.changeRolloutStatus(c, []string{rollouts.StatusRunning}, rollouts.StatusPaused, "rollouts.paused")
+}
+
+// ResumeRollout runs a paused or halted rollout again, the soak time of the current
+// wave starts again so admins can watch the agents before the next wave is sent
+func (h *Handler) ResumeRollout(c echo.Context) error {
+	return h.changeRolloutStatus(c, []string{rollouts.StatusPaused, rollouts.StatusHalted}, rollouts.StatusRunning, "rollouts.resumed")
+}
+
+// CancelRollout stops a rollout for good, the queued agents are not updated
+func (h *Handler) CancelRollout(c echo.Context) error {
+	return h.changeRolloutStatus(c, []string{rollouts.StatusRunning, rollouts.StatusPaused, rollouts.StatusHalted}, rollouts.StatusCancelled, "rollouts.cancelled")
+}
+
+func (h *Handler) changeRolloutStatus(c echo.Context, from []string, status string, successKey string) error {
+	r, err := h.getRollout(c)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), true))
+	}
+
+	if err := h.Model.SetRolloutStatus(r.ID, r.TenantID, from, status); err != nil {
+		if errors.Is(err, models.ErrRolloutNotFound) {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "rollouts.invalid_status"), true))
+		}
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "rollouts.could_not_update", err.Error()), true))
+	}
+
+	h.Audit(c, AuditRolloutStatus, r.Name, r.Status, status)
+
+	return h.RenderRollout(c, i18n.T(c.Request().Context(), successKey))
+}
+
+func (h *Handler) RenderRollouts(c echo.Context, successMessage, errMessage string) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	tenantID, err := strconv.Atoi(c.Param("tenant"))
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "tenants.could_not_convert_to_int", err.Error()), true))
+	}
+	commonInfo.TenantID = c.Param("tenant")
+
+	items, err := h.Model.GetRollouts(tenantID)
+	if err != nil {
+		successMessage = ""
+		errMessage = i18n.T(c.Request().Context(), "rollouts.could_not_get", err.Error())
+	}
+
+	releases, err := h.Model.GetAgentsReleases()
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), false))
+	}
+
+	tags, err := h.Model.GetAppliedTags(commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), false))
+	}
+
+	sites, err := h.Model.GetSites(tenantID)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), false))
+	}
+
+	agentsExists, err := h.Model.AgentsExists(commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), false))
+	}
+
+	serversExists, err := h.Model.ServersExists()
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), false))
+	}
+
+	return RenderView(c, admin_views.RolloutsIndex(" | Rollouts", admin_views.Rollouts(c, items, releases, tags, sites, successMessage, errMessage, agentsExists, serversExists, commonInfo, h.GetAdminTenantName(commonInfo)), commonInfo))
+}
+
+// RenderRollout shows the progress of each wave of a rollout and its agents
+func (h *Handler) RenderRollout(c echo.Context, successMessage string) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+	commonInfo.TenantID = c.Param("tenant")
+
+	r, err := h.getRollout(c)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), true))
+	}
+
+	errMessage := ""
+
+	itemsPerPage, err := h.Model.GetDefaultItemsPerPage()
+	if err != nil {
+		log.Println("[ERROR]: could not get items per page from database")
+		itemsPerPage = 5
+	}
+
+	p := partials.NewPaginationAndSort(itemsPerPage)
+	p.GetPaginationAndSortParams(c.FormValue("page"), c.FormValue("pageSize"), c.FormValue("sortBy"), c.FormValue("sortOrder"), c.FormValue("currentSortBy"), itemsPerPage)
+
+	f := filters.RolloutAgentFilter{
+		Hostname: c.FormValue("filterByHostname"),
+		Statuses: filteredOptions(c, "Status", "rollouts.agent_status_", rollouts.AgentStatuses),
+	}
+
+	progress, err := h.Model.GetRolloutProgress(r.ID)
+	if err != nil {
+		errMessage = i18n.T(c.Request().Context(), "rollouts.could_not_get", err.Error())
+	}
+
+	p.NItems, err = h.Model.CountRolloutAgents(r.ID, f)
+	if err != nil {
+		errMessage = i18n.T(c.Request().Context(), "rollouts.could_not_get", err.Error())
+	}
+
+	agents, err := h.Model.GetRolloutAgentsByPage(p, r.ID, f)
+	if err != nil {
+		errMessage = i18n.T(c.Request().Context(), "rollouts.could_not_get", err.Error())
+	}
+
+	pilotName := strconv.Itoa(r.PilotValue)
+	switch r.PilotType {
+	case rollouts.PilotTag:
+		tags, err := h.Model.GetAppliedTags(commonInfo)
+		if err == nil {
+			for _, t := range tags {
+				if t.ID == r.PilotValue {
+					pilotName = t.Tag
+				}
+			}
+		}
+	case rollouts.PilotSite:
+		if s, err := h.Model.GetSiteById(r.TenantID, r.PilotValue); err == nil {
+			pilotName = s.Description
+		}
+	}
+
+	refreshTime, err := h.Model.GetDefaultRefreshTime()
+	if err != nil {
+		log.Println("[ERROR]: could not get refresh time from database")
+		refreshTime = 5
+	}
+
+	agentsExists, err := h.Model.AgentsExists(commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), false))
+	}
+
+	serversExists, err := h.Model.ServersExists()
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), false))
+	}
+
+	return RenderView(c, admin_views.RolloutsIndex(" | Rollouts", admin_views.RolloutProgress(c, p, f, r, pilotName, progress, agents, refreshTime, itemsPerPage, successMessage, errMessage, agentsExists, serversExists, commonInfo, h.GetAdminTenantName(commonInfo)), commonInfo))
+}
+
+func (h *Handler) ShowRollout(c echo.Context) error {
+	return h.RenderRollout(c, "")
+}
+
+func (h *Handler) getRollout(c echo.Context) (consoledb.Rollout, error) {
+	tenantID, err := strconv.Atoi(c.Param("tenant"))
+	if err != nil {
+		return consoledb.Rollout{}, errors.New(i18n.T(c.Request().Context(), "tenants.could_not_convert_to_int", err.Error()))
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return consoledb.Rollout{}, errors.New(i18n.T(c.Request().Context(), "rollouts.not_found"))
+	}
+
+	r, err := h.Model.GetRollout(id, tenantID)
+	if err != nil {
+		if errors.Is(err, models.ErrRolloutNotFound) {
+			return consoledb.Rollout{}, errors.New(i18n.T(c.Request().Context(), "rollouts.not_found"))
+		}
+		return consoledb.Rollout{}, errors.New(i18n.T(c.Request().Context(), "rollouts.could_not_get", err.Error()))
+	}
+
+	return r, nil
+}
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/go-co-op/gocron/v2"
+	"github.com/invopop/ctxi18n"
+	"github.com/invopop/ctxi18n/i18n"
+	openuem_nats "github.com/open-uem/nats"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/rollouts"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+	"github.com/open-uem/openuem-console/internal/webhooks"
+)
+
+// StartRolloutsJob schedules the job that sends the waves of the running rollouts
+func (h *Handler) StartRolloutsJob() error {
+	if _, err := h.TaskScheduler.NewJob(
+		gocron.DurationJob(rollouts.CheckInterval),
+		gocron.NewTask(h.ProcessRollouts),
+		gocron.WithSingletonMode(gocron.LimitModeReschedule),
+	); err != nil {
+		log.Printf("[ERROR]: could not schedule the job that processes agent update rollouts, reason: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// ProcessRollouts records the results of the agents of every running rollout, halts the
+// rollouts whose failure rate exceeds their threshold and sends the waves that are due
+func (h *Handler) ProcessRollouts() {
+	items, err := h.Model.GetRunningRollouts()
+	if err != nil {
+		log.Printf("[ERROR]: could not get the running rollouts, reason: %v", err)
+		return
+	}
+
+	for _, r := range items {
+		h.processRollout(r)
+	}
+}
+
+func (h *Handler) processRollout(r consoledb.Rollout) {
+	ctx, err := ctxi18n.WithLocale(context.Background(), r.Language)
+	if err != nil {
+		log.Printf("[ERROR]: could not use the %s locale for rollout %d, reason: %v", r.Language, r.ID, err)
+		return
+	}
+
+	if err := h.Model.SyncRolloutAgents(r); err != nil {
+		log.Printf("[ERROR]: could not sync the agents of rollout %d, reason: %v", r.ID, err)
+		return
+	}
+
+	progress, err := h.Model.GetRolloutProgress(r.ID)
+	if err != nil {
+		log.Printf("[ERROR]: could not get the progress of rollout %d, reason: %v", r.ID, err)
+		return
+	}
+
+	sent, failed := 0, 0
+	for _, p := range progress {
+		sent += p.Sent + p.Succeeded + p.Failed
+		failed += p.Failed
+	}
+
+	if rollouts.ShouldHalt(failed, sent, r.FailureThreshold) {
+		reason := i18n.T(ctx, "rollouts.halt_reason", failed, sent, rollouts.FailureRate(failed, sent), r.FailureThreshold)
+		halted, err := h.Model.HaltRollout(r.ID, reason)
+		if err != nil {
+			log.Printf("[ERROR]: could not halt rollout %d, reason: %v", r.ID, err)
+			return
+		}
+		if halted {
+			log.Printf("[INFO]: rollout %d has been halted, %d of %d agents failed to update", r.ID, failed, sent)
+			h.NotifyWebhooks(r.TenantID, webhooks.EventRolloutHalted, map[string]any{
+				"rollout_id": r.ID,
+				"name":       r.Name,
+				"version":    r.Version,
+				"wave":       r.CurrentWave,
+				"sent":       sent,
+				"failed":     failed,
+				"threshold":  r.FailureThreshold,
+			})
+		}
+		return
+	}
+
+	// agents of the current wave that were not sent, e.g. because NATS was not available
+	h.sendRolloutWave(ctx, r)
+
+	current := rollouts.WaveProgress{}
+	for _, p := range progress {
+		if p.Wave == r.CurrentWave {
+			current = p
+		}
+	}
+
+	// empty waves, when there are few agents, don't need to soak
+	if current.Total() > 0 && !rollouts.WaveDue(r.WaveStarted, r.SoakMinutes, time.Now()) {
+		return
+	}
+
+	if r.CurrentWave >= len(r.Waves) {
+		if _, err := h.Model.CompleteRollout(r.ID); err != nil {
+			log.Printf("[ERROR]: could not complete rollout %d, reason: %v", r.ID, err)
+		}
+		return
+	}
+
+	claimed, err := h.Model.ClaimRolloutWave(r.ID, r.CurrentWave)
+	if err != nil {
+		log.Printf("[ERROR]: could not start the next wave of rollout %d, reason: %v", r.ID, err)
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	r.CurrentWave++
+	h.sendRolloutWave(ctx, r)
+}
+
+// sendRolloutWave sends the update to the queued agents of the waves up to the current
+// one whose maintenance window is open. Agents are claimed one by one so several console
+// instances don't send the same request, and nothing is claimed while NATS is not available
+func (h *Handler) sendRolloutWave(ctx context.Context, r consoledb.Rollout) {
+	if h.NATSConnection == nil || !h.NATSConnection.IsConnected() {
+		return
+	}
+
+	queued, err := h.Model.GetQueuedRolloutAgents(r.ID, r.CurrentWave)
+	if err != nil {
+		log.Printf("[ERROR]: could not get the queued agents of rollout %d, reason: %v", r.ID, err)
+		return
+	}
+
+	commonInfo := &partials.CommonInfo{TenantID: strconv.Itoa(r.TenantID), SiteID: "-1"}
+	now := time.Now()
+
+	for _, a := range queued {
+		// agents with maintenance windows stay queued until one of their windows opens
+		if agentInfo, err := h.Model.GetAgentById(a.AgentID, commonInfo); err == nil {
+			next, err := h.nextMaintenanceWindow(agentInfo, now)
+			if err != nil {
+				log.Printf("[ERROR]: could not get the maintenance windows of agent %s, reason: %v", a.AgentID, err)
+				continue
+			}
+			if next.After(now) {
+				continue
+			}
+		}
+
+		claimed, err := h.Model.ClaimRolloutAgent(r.ID, a.AgentID)
+		if err != nil {
+			log.Printf("[ERROR]: could not claim agent %s of rollout %d, reason: %v", a.AgentID, r.ID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		if err := h.sendRolloutUpdate(ctx, r, a.AgentID, commonInfo); err != nil {
+			if err := h.Model.FinishRolloutAgent(r.ID, a.AgentID, rollouts.AgentFailed, err.Error()); err != nil {
+				log.Printf("[ERROR]: could not save the result of agent %s of rollout %d, reason: %v", a.AgentID, r.ID, err)
+			}
+		}
+	}
+}
+
+func (h *Handler) sendRolloutUpdate(ctx context.Context, r consoledb.Rollout, agentID string, commonInfo *partials.CommonInfo) error {
+	agentInfo, err := h.Model.GetAgentById(agentID, commonInfo)
+	if err != nil {
+		return err
+	}
+
+	releaseToBeApplied, err := h.agentUpdateRelease(agentInfo, r.Channel, r.Version)
+	if err != nil {
+		return err
+	}
+
+	updateRequest := openuem_nats.OpenUEMUpdateRequest{
+		DownloadFrom: releaseToBeApplied.FileURL,
+		DownloadHash: releaseToBeApplied.Checksum,
+		Version:      releaseToBeApplied.Version,
+		UpdateNow:    true,
+	}
+
+	return h.publishAgentUpdate(ctx, agentID, updateRequest, commonInfo)
+}
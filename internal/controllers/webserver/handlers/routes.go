@@ -1,13 +1,20 @@
 package handlers
 
 import (
+	"fmt"
+	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/invopop/ctxi18n/i18n"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/open-uem/openuem-console/internal/bulkactions"
+	"github.com/open-uem/openuem-console/internal/rbac"
+	"github.com/open-uem/openuem-console/internal/savedviews"
 	"github.com/open-uem/openuem-console/internal/views/login_views"
+	"github.com/open-uem/openuem-console/internal/views/partials"
 	"golang.org/x/time/rate"
 )
 
@@ -22,6 +29,9 @@ func (h *Handler) Register(e *echo.Echo, registerRateLimit float64) {
 	e.GET("/agents", func(c echo.Context) error { return h.ListAgents(c, "", "", false) }, h.IsAuthenticated)
 	e.POST("/agents", func(c echo.Context) error { return h.ListAgents(c, "", "", false) }, h.IsAuthenticated)
 	e.DELETE("/agents", func(c echo.Context) error { return h.ListAgents(c, "", "", false) }, h.IsAuthenticated)
+	e.POST("/agents/views", func(c echo.Context) error { return h.SaveView(c, savedviews.ListAgents) }, h.IsAuthenticated)
+	e.POST("/agents/views/:id/default", func(c echo.Context) error { return h.ToggleDefaultView(c, savedviews.ListAgents) }, h.IsAuthenticated)
+	e.DELETE("/agents/views/:id", func(c echo.Context) error { return h.DeleteView(c, savedviews.ListAgents) }, h.IsAuthenticated)
 	e.GET("/agents/admit", h.AgentsAdmit, h.IsAuthenticated)
 	e.POST("/agents/admit", h.AgentsAdmit, h.IsAuthenticated)
 	e.GET("/agents/enable", h.AgentsEnable, h.IsAuthenticated)
@@ -45,6 +55,9 @@ func (h *Handler) Register(e *echo.Echo, registerRateLimit float64) {
 	e.GET("/tenant/:tenant/agents", func(c echo.Context) error { return h.ListAgents(c, "", "", false) }, h.IsAuthenticated)
 	e.POST("/tenant/:tenant/agents", func(c echo.Context) error { return h.ListAgents(c, "", "", false) }, h.IsAuthenticated)
 	e.DELETE("/tenant/:tenant/agents", func(c echo.Context) error { return h.ListAgents(c, "", "", false) }, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/agents/views", func(c echo.Context) error { return h.SaveView(c, savedviews.ListAgents) }, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/agents/views/:id/default", func(c echo.Context) error { return h.ToggleDefaultView(c, savedviews.ListAgents) }, h.IsAuthenticated)
+	e.DELETE("/tenant/:tenant/agents/views/:id", func(c echo.Context) error { return h.DeleteView(c, savedviews.ListAgents) }, h.IsAuthenticated)
 	e.GET("/tenant/:tenant/agents/admit", h.AgentsAdmit, h.IsAuthenticated)
 	e.POST("/tenant/:tenant/agents/admit", h.AgentsAdmit, h.IsAuthenticated)
 	e.GET("/tenant/:tenant/agents/enable", h.AgentsEnable, h.IsAuthenticated)
@@ -68,6 +81,9 @@ func (h *Handler) Register(e *echo.Echo, registerRateLimit float64) {
 	e.GET("/tenant/:tenant/site/:site/agents", func(c echo.Context) error { return h.ListAgents(c, "", "", false) }, h.IsAuthenticated)
 	e.POST("/tenant/:tenant/site/:site/agents", func(c echo.Context) error { return h.ListAgents(c, "", "", false) }, h.IsAuthenticated)
 	e.DELETE("/tenant/:tenant/site/:site/agents", func(c echo.Context) error { return h.ListAgents(c, "", "", false) }, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/site/:site/agents/views", func(c echo.Context) error { return h.SaveView(c, savedviews.ListAgents) }, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/site/:site/agents/views/:id/default", func(c echo.Context) error { return h.ToggleDefaultView(c, savedviews.ListAgents) }, h.IsAuthenticated)
+	e.DELETE("/tenant/:tenant/site/:site/agents/views/:id", func(c echo.Context) error { return h.DeleteView(c, savedviews.ListAgents) }, h.IsAuthenticated)
 	e.GET("/tenant/:tenant/site/:site/agents/admit", h.AgentsAdmit, h.IsAuthenticated)
 	e.POST("/tenant/:tenant/site/:site/agents/admit", h.AgentsAdmit, h.IsAuthenticated)
 	e.GET("/tenant/:tenant/site/:site/agents/enable", h.AgentsEnable, h.IsAuthenticated)
@@ -92,6 +108,7 @@ func (h *Handler) Register(e *echo.Echo, registerRateLimit float64) {
 	e.POST("/admin", func(c echo.Context) error { return h.ListUsers(c, "", "") }, h.IsAuthenticated)
 	e.GET("/admin/users", func(c echo.Context) error { return h.ListUsers(c, "", "") }, h.IsAuthenticated)
 	e.POST("/admin/users", func(c echo.Context) error { return h.ListUsers(c, "", "") }, h.IsAuthenticated)
+	e.POST("/admin/users/xlsx", h.GenerateUsersXLSXReport, h.IsAuthenticated)
 	e.GET("/admin/tenants", func(c echo.Context) error { return h.ListTenants(c, "", "", false) }, h.IsAuthenticated)
 	e.POST("/admin/tenants", func(c echo.Context) error { return h.ListTenants(c, "", "", false) }, h.IsAuthenticated)
 	e.GET("/admin/users/new", h.NewUser, h.IsAuthenticated)
@@ -103,8 +120,19 @@ func (h *Handler) Register(e *echo.Echo, registerRateLimit float64) {
 	e.POST("/admin/users/:uid/confirmemail", h.SetEmailConfirmed, h.IsAuthenticated)
 	e.POST("/admin/users/:uid/approve", h.ApproveAccount, h.IsAuthenticated)
 	e.POST("/admin/users/:uid/resendpasslink", h.ResendPasswordLink, h.IsAuthenticated)
+	e.POST("/admin/users/:uid/resetpasskeys", h.ResetUserPasskeys, h.IsAuthenticated)
 	e.DELETE("/admin/users/:uid", h.DeleteUser, h.IsAuthenticated)
 
+	e.GET("/admin/roles", func(c echo.Context) error { return h.ListRoles(c, "", "") }, h.IsAuthenticated)
+	e.POST("/admin/roles", h.AddRoleAssignment, h.IsAuthenticated)
+	e.GET("/admin/roles/:id/delete", h.RoleAssignmentDelete, h.IsAuthenticated)
+	e.DELETE("/admin/roles/:id", h.RoleAssignmentConfirmDelete, h.IsAuthenticated)
+	e.GET("/admin/roles/oidc", func(c echo.Context) error { return h.ListOIDCRoleMappings(c, "", "") }, h.IsAuthenticated)
+	e.POST("/admin/roles/oidc", h.AddOIDCRoleMapping, h.IsAuthenticated)
+	e.POST("/admin/roles/oidc/settings", h.SaveOIDCClaimSettings, h.IsAuthenticated)
+	e.GET("/admin/roles/oidc/:id/delete", h.OIDCRoleMappingDelete, h.IsAuthenticated)
+	e.DELETE("/admin/roles/oidc/:id", h.OIDCRoleMappingConfirmDelete, h.IsAuthenticated)
+
 	e.GET("/admin/tenants/new", h.NewTenant, h.IsAuthenticated)
 	e.POST("/admin/tenants/new", h.AddTenant, h.IsAuthenticated)
 	e.POST("/admin/tenants/import", h.ImportTenants, h.IsAuthenticated)
@@ -116,6 +144,23 @@ func (h *Handler) Register(e *echo.Echo, registerRateLimit float64) {
 	e.GET("/admin/sessions", func(c echo.Context) error { successMessage := ""; return h.ListSessions(c, successMessage) }, h.IsAuthenticated)
 	e.GET("/admin/sessions/:token/delete", h.SessionDelete)
 	e.DELETE("/admin/sessions/:token", h.SessionConfirmDelete, h.IsAuthenticated)
+
+	e.GET("/admin/api-tokens", func(c echo.Context) error { successMessage := ""; return h.ListAPITokens(c, successMessage) }, h.IsAuthenticated)
+	e.GET("/admin/api-tokens/:id/delete", h.APITokenDelete, h.IsAuthenticated)
+	e.DELETE("/admin/api-tokens/:id", h.APITokenConfirmDelete, h.IsAuthenticated)
+
+	e.GET("/admin/audit", h.ListAuditLog, h.IsAuthenticated)
+	e.POST("/admin/audit/csv", h.GenerateAuditCSVReport, h.IsAuthenticated)
+
+	e.GET("/admin/webhooks", h.ListWebhooks, h.IsAuthenticated)
+	e.POST("/admin/webhooks", h.AddWebhook, h.IsAuthenticated)
+	e.GET("/admin/webhooks/deliveries", h.ListWebhookDeliveries, h.IsAuthenticated)
+	e.POST("/admin/webhooks/deliveries/:id/retry", h.RetryWebhookDelivery, h.IsAuthenticated)
+	e.POST("/admin/webhooks/:id/enable", h.EnableWebhook, h.IsAuthenticated)
+	e.POST("/admin/webhooks/:id/test", h.TestWebhook, h.IsAuthenticated)
+	e.GET("/admin/webhooks/:id/delete", h.WebhookDelete, h.IsAuthenticated)
+	e.DELETE("/admin/webhooks/:id", h.WebhookConfirmDelete, h.IsAuthenticated)
+
 	e.GET("/admin/smtp", h.SMTPSettings, h.IsAuthenticated)
 	e.POST("/admin/smtp", h.SMTPSettings, h.IsAuthenticated)
 	e.POST("/admin/smtp/test", h.TestSMTPSettings, h.IsAuthenticated)
@@ -123,9 +168,15 @@ func (h *Handler) Register(e *echo.Echo, registerRateLimit float64) {
 	e.POST("/admin/settings", h.GeneralSettings, h.IsAuthenticated)
 	e.GET("/admin/certificates", h.ListCertificates, h.IsAuthenticated)
 	e.POST("/admin/certificates", h.CertificateConfirmRevocation, h.IsAuthenticated)
+	e.POST("/admin/certificates/xlsx", h.GenerateCertificatesXLSXReport, h.IsAuthenticated)
 	e.DELETE("/admin/certificates", h.RevocateCertificate, h.IsAuthenticated)
 	e.GET("/admin/authentication", h.AuthenticationSettings, h.IsAuthenticated)
 	e.POST("/admin/authentication", h.AuthenticationSettings, h.IsAuthenticated)
+	e.POST("/admin/authentication/ldap", h.SaveLDAPSettings, h.IsAuthenticated)
+	e.POST("/admin/authentication/ldap/test", h.TestLDAPSettings, h.IsAuthenticated)
+	e.POST("/admin/authentication/ldap/sync", h.SyncLDAPUsers, h.IsAuthenticated)
+	e.POST("/admin/authentication/scim", h.SaveSCIMSettings, h.IsAuthenticated)
+	e.POST("/admin/authentication/scim/token", h.NewSCIMToken, h.IsAuthenticated)
 	e.GET("/admin/update-servers", h.UpdateServers, h.IsAuthenticated)
 	e.POST("/admin/update-servers", h.UpdateServers, h.IsAuthenticated)
 	e.DELETE("/admin/update-servers/:serverId", h.UpdateServers, h.IsAuthenticated)
@@ -153,6 +204,12 @@ func (h *Handler) Register(e *echo.Echo, registerRateLimit float64) {
 	e.POST("/tenant/:tenant/admin/update-agents", h.UpdateAgents, h.IsAuthenticated)
 	e.DELETE("/tenant/:tenant/admin/update-agents", h.UpdateAgents, h.IsAuthenticated)
 	e.POST("/tenant/:tenant/admin/update-agents/confirm", h.UpdateAgentsConfirm, h.IsAuthenticated)
+	e.GET("/tenant/:tenant/admin/rollouts", h.ListRollouts, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/admin/rollouts", h.AddRollout, h.IsAuthenticated)
+	e.GET("/tenant/:tenant/admin/rollouts/:id", h.ShowRollout, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/admin/rollouts/:id/pause", h.PauseRollout, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/admin/rollouts/:id/resume", h.ResumeRollout, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/admin/rollouts/:id/cancel", h.CancelRollout, h.IsAuthenticated)
 	e.POST("/tenant/:tenant/admin/inherit", h.ApplyGlobalSettings, h.IsAuthenticated)
 	e.GET("/tenant/:tenant/admin/sites", func(c echo.Context) error { return h.ListSites(c, "", "", false) }, h.IsAuthenticated)
 	e.GET("/tenant/:tenant/admin/sites/new", h.NewSite, h.IsAuthenticated)
@@ -165,6 +222,36 @@ func (h *Handler) Register(e *echo.Echo, registerRateLimit float64) {
 	e.POST("/tenant/:tenant/admin/rustdesk/inherit", h.ApplyGlobalRustDeskSettings, h.IsAuthenticated)
 	e.GET("/tenant/:tenant/admin/netbird", h.NetbirdSettings, h.IsAuthenticated)
 	e.POST("/tenant/:tenant/admin/netbird", h.NetbirdSettings, h.IsAuthenticated)
+	e.GET("/tenant/:tenant/admin/webhooks", h.ListWebhooks, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/admin/webhooks", h.AddWebhook, h.IsAuthenticated)
+	e.GET("/tenant/:tenant/admin/webhooks/deliveries", h.ListWebhookDeliveries, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/admin/webhooks/deliveries/:id/retry", h.RetryWebhookDelivery, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/admin/webhooks/:id/enable", h.EnableWebhook, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/admin/webhooks/:id/test", h.TestWebhook, h.IsAuthenticated)
+	e.GET("/tenant/:tenant/admin/webhooks/:id/delete", h.WebhookDelete, h.IsAuthenticated)
+	e.DELETE("/tenant/:tenant/admin/webhooks/:id", h.WebhookConfirmDelete, h.IsAuthenticated)
+	e.GET("/tenant/:tenant/admin/alerts", h.ListAlertRules, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/admin/alerts", h.AddAlertRule, h.IsAuthenticated)
+	e.GET("/tenant/:tenant/admin/alerts/history", h.ListAlertHistory, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/admin/alerts/:id/enable", h.EnableAlertRule, h.IsAuthenticated)
+	e.GET("/tenant/:tenant/admin/alerts/:id/delete", h.AlertRuleDelete, h.IsAuthenticated)
+	e.DELETE("/tenant/:tenant/admin/alerts/:id", h.AlertRuleConfirmDelete, h.IsAuthenticated)
+	e.GET("/tenant/:tenant/admin/compliance", h.ListCompliancePolicies, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/admin/compliance", h.AddCompliancePolicy, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/admin/compliance/:id/enable", h.EnableCompliancePolicy, h.IsAuthenticated)
+	e.GET("/tenant/:tenant/admin/compliance/:id/delete", h.CompliancePolicyDelete, h.IsAuthenticated)
+	e.DELETE("/tenant/:tenant/admin/compliance/:id", h.CompliancePolicyConfirmDelete, h.IsAuthenticated)
+	e.GET("/tenant/:tenant/admin/licenses", h.ListLicenses, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/admin/licenses", h.AddLicense, h.IsAuthenticated)
+	e.GET("/tenant/:tenant/admin/licenses/:id/edit", h.EditLicense, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/admin/licenses/:id", h.UpdateLicense, h.IsAuthenticated)
+	e.GET("/tenant/:tenant/admin/licenses/:id/delete", h.LicenseDelete, h.IsAuthenticated)
+	e.DELETE("/tenant/:tenant/admin/licenses/:id", h.LicenseConfirmDelete, h.IsAuthenticated)
+	e.GET("/tenant/:tenant/admin/maintenance-windows", h.ListMaintenanceWindows, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/admin/maintenance-windows", h.AddMaintenanceWindow, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/admin/maintenance-windows/:id/enable", h.EnableMaintenanceWindow, h.IsAuthenticated)
+	e.GET("/tenant/:tenant/admin/maintenance-windows/:id/delete", h.MaintenanceWindowDelete, h.IsAuthenticated)
+	e.DELETE("/tenant/:tenant/admin/maintenance-windows/:id", h.MaintenanceWindowConfirmDelete, h.IsAuthenticated)
 
 	e.GET("/dashboard", h.Dashboard, h.IsAuthenticated)
 	e.GET("/tenant/:tenant/dashboard", h.Dashboard, h.IsAuthenticated)
@@ -179,6 +266,10 @@ func (h *Handler) Register(e *echo.Echo, registerRateLimit float64) {
 	e.POST("/deploy/searchuninstall", func(c echo.Context) error { return h.SearchPackagesAction(c, false) }, h.IsAuthenticated)
 	e.GET("/deploy/selectpackagedeployment", h.SelectPackageDeployment, h.IsAuthenticated)
 	e.POST("/deploy/selectpackagedeployment", h.DeployPackageToSelectedAgents, h.IsAuthenticated)
+	e.GET("/deploy/jobs", h.ListDeploymentJobs, h.IsAuthenticated)
+	e.GET("/deploy/jobs/:id", h.ShowDeploymentJob, h.IsAuthenticated)
+	e.POST("/deploy/jobs/:id/retry", h.RetryDeploymentJob, h.IsAuthenticated)
+	e.POST("/deploy/jobs/:id/cancel", h.CancelDeploymentJob, h.IsAuthenticated)
 
 	e.GET("/tenant/:tenant/deploy", h.DeployInstall, h.IsAuthenticated)
 	e.GET("/tenant/:tenant/deploy/install", h.DeployInstall, h.IsAuthenticated)
@@ -189,7 +280,10 @@ func (h *Handler) Register(e *echo.Echo, registerRateLimit float64) {
 	e.POST("/tenant/:tenant/deploy/searchuninstall", func(c echo.Context) error { return h.SearchPackagesAction(c, false) }, h.IsAuthenticated)
 	e.GET("/tenant/:tenant/deploy/selectpackagedeployment", h.SelectPackageDeployment, h.IsAuthenticated)
 	e.POST("/tenant/:tenant/deploy/selectpackagedeployment", h.DeployPackageToSelectedAgents, h.IsAuthenticated)
-	// TODO-Steve check if user has permissions to get the sites for this tenant
+	e.GET("/tenant/:tenant/deploy/jobs", h.ListDeploymentJobs, h.IsAuthenticated)
+	e.GET("/tenant/:tenant/deploy/jobs/:id", h.ShowDeploymentJob, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/deploy/jobs/:id/retry", h.RetryDeploymentJob, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/deploy/jobs/:id/cancel", h.CancelDeploymentJob, h.IsAuthenticated)
 	e.POST("/tenant/sites", h.GetTenantSites, h.IsAuthenticated)
 
 	e.GET("/tenant/:tenant/site/:site/deploy", h.DeployInstall, h.IsAuthenticated)
@@ -201,16 +295,29 @@ func (h *Handler) Register(e *echo.Echo, registerRateLimit float64) {
 	e.POST("/tenant/:tenant/site/:site/deploy/searchuninstall", func(c echo.Context) error { return h.SearchPackagesAction(c, false) }, h.IsAuthenticated)
 	e.GET("/tenant/:tenant/site/:site/deploy/selectpackagedeployment", h.SelectPackageDeployment, h.IsAuthenticated)
 	e.POST("/tenant/:tenant/site/:site/deploy/selectpackagedeployment", h.DeployPackageToSelectedAgents, h.IsAuthenticated)
+	e.GET("/tenant/:tenant/site/:site/deploy/jobs", h.ListDeploymentJobs, h.IsAuthenticated)
+	e.GET("/tenant/:tenant/site/:site/deploy/jobs/:id", h.ShowDeploymentJob, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/site/:site/deploy/jobs/:id/retry", h.RetryDeploymentJob, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/site/:site/deploy/jobs/:id/cancel", h.CancelDeploymentJob, h.IsAuthenticated)
 
 	e.GET("/computers", func(c echo.Context) error { return h.ComputersList(c, "", false) }, h.IsAuthenticated)
 	e.POST("/computers", func(c echo.Context) error { return h.ComputersList(c, "", false) }, h.IsAuthenticated)
 	e.DELETE("/computers", func(c echo.Context) error { return h.ComputersList(c, "", false) }, h.IsAuthenticated)
+	e.GET("/computers/bulk", h.ComputersBulkForm, h.IsAuthenticated)
+	for _, action := range bulkactions.Actions {
+		e.POST("/computers/bulk/"+action, func(c echo.Context) error { return h.ComputersBulkAction(c, action) }, h.IsAuthenticated)
+	}
+	e.POST("/computers/columns", h.SaveComputerColumns, h.IsAuthenticated)
+	e.POST("/computers/views", func(c echo.Context) error { return h.SaveView(c, savedviews.ListComputers) }, h.IsAuthenticated)
+	e.POST("/computers/views/:id/default", func(c echo.Context) error { return h.ToggleDefaultView(c, savedviews.ListComputers) }, h.IsAuthenticated)
+	e.DELETE("/computers/views/:id", func(c echo.Context) error { return h.DeleteView(c, savedviews.ListComputers) }, h.IsAuthenticated)
 	e.GET("/computers/:uuid", h.Overview, h.IsAuthenticated)
 	e.DELETE("/computers/:uuid", h.ComputerConfirmDelete, h.IsAuthenticated)
 	e.GET("/computers/:uuid/overview", h.Overview, h.IsAuthenticated)
 	e.POST("/computers/:uuid/overview", h.Overview, h.IsAuthenticated)
 	e.GET("/computers/:uuid/software", h.Apps, h.IsAuthenticated)
 	e.POST("/computers/:uuid/software", h.Apps, h.IsAuthenticated)
+	e.GET("/computers/:uuid/vulnerabilities", h.ComputerVulnerabilities, h.IsAuthenticated)
 	e.GET("/computers/:uuid/hardware", h.Computer, h.IsAuthenticated)
 	e.GET("/computers/:uuid/logical-disks", h.LogicalDisks, h.IsAuthenticated)
 	e.POST("/computers/:uuid/logical-disks", h.BrowseLogicalDisk, h.IsAuthenticated)
@@ -235,6 +342,7 @@ func (h *Handler) Register(e *echo.Echo, registerRateLimit float64) {
 	e.POST("/computers/:uuid/power/:action", h.PowerManagement, h.IsAuthenticated)
 	e.GET("/computers/:uuid/notes", h.Notes, h.IsAuthenticated)
 	e.POST("/computers/:uuid/notes", h.Notes, h.IsAuthenticated)
+	e.POST("/computers/:uuid/lifecycle", h.ComputerLifecycle, h.IsAuthenticated)
 	e.GET("/computers/:uuid/deploy", func(c echo.Context) error { return h.ComputerDeploy(c, "") }, h.IsAuthenticated)
 	e.POST("/computers/:uuid/deploy", func(c echo.Context) error { return h.ComputerDeploy(c, "") }, h.IsAuthenticated)
 	e.GET("/computers/:uuid/deploy/searchinstall", func(c echo.Context) error { return h.ComputerDeploy(c, "") }, h.IsAuthenticated)
@@ -270,12 +378,21 @@ func (h *Handler) Register(e *echo.Echo, registerRateLimit float64) {
 	e.GET("/tenant/:tenant/computers", func(c echo.Context) error { return h.ComputersList(c, "", false) }, h.IsAuthenticated)
 	e.POST("/tenant/:tenant/computers", func(c echo.Context) error { return h.ComputersList(c, "", false) }, h.IsAuthenticated)
 	e.DELETE("/tenant/:tenant/computers", func(c echo.Context) error { return h.ComputersList(c, "", false) }, h.IsAuthenticated)
+	e.GET("/tenant/:tenant/computers/bulk", h.ComputersBulkForm, h.IsAuthenticated)
+	for _, action := range bulkactions.Actions {
+		e.POST("/tenant/:tenant/computers/bulk/"+action, func(c echo.Context) error { return h.ComputersBulkAction(c, action) }, h.IsAuthenticated)
+	}
+	e.POST("/tenant/:tenant/computers/columns", h.SaveComputerColumns, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/computers/views", func(c echo.Context) error { return h.SaveView(c, savedviews.ListComputers) }, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/computers/views/:id/default", func(c echo.Context) error { return h.ToggleDefaultView(c, savedviews.ListComputers) }, h.IsAuthenticated)
+	e.DELETE("/tenant/:tenant/computers/views/:id", func(c echo.Context) error { return h.DeleteView(c, savedviews.ListComputers) }, h.IsAuthenticated)
 	e.GET("/tenant/:tenant/computers/:uuid", h.Overview, h.IsAuthenticated)
 	e.DELETE("/tenant/:tenant/computers/:uuid", h.ComputerConfirmDelete, h.IsAuthenticated)
 	e.GET("/tenant/:tenant/computers/:uuid/overview", h.Overview, h.IsAuthenticated)
 	e.POST("/tenant/:tenant/computers/:uuid/overview", h.Overview, h.IsAuthenticated)
 	e.GET("/tenant/:tenant/computers/:uuid/software", h.Apps, h.IsAuthenticated)
 	e.POST("/tenant/:tenant/computers/:uuid/software", h.Apps, h.IsAuthenticated)
+	e.GET("/tenant/:tenant/computers/:uuid/vulnerabilities", h.ComputerVulnerabilities, h.IsAuthenticated)
 	e.GET("/tenant/:tenant/computers/:uuid/hardware", h.Computer, h.IsAuthenticated)
 	e.GET("/tenant/:tenant/computers/:uuid/logical-disks", h.LogicalDisks, h.IsAuthenticated)
 	e.POST("/tenant/:tenant/computers/:uuid/logical-disks", h.BrowseLogicalDisk, h.IsAuthenticated)
@@ -300,6 +417,7 @@ func (h *Handler) Register(e *echo.Echo, registerRateLimit float64) {
 	e.POST("/tenant/:tenant/computers/:uuid/power/:action", h.PowerManagement, h.IsAuthenticated)
 	e.GET("/tenant/:tenant/computers/:uuid/notes", h.Notes, h.IsAuthenticated)
 	e.POST("/tenant/:tenant/computers/:uuid/notes", h.Notes, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/computers/:uuid/lifecycle", h.ComputerLifecycle, h.IsAuthenticated)
 	e.GET("/tenant/:tenant/computers/:uuid/deploy", func(c echo.Context) error { return h.ComputerDeploy(c, "") }, h.IsAuthenticated)
 	e.POST("/tenant/:tenant/computers/:uuid/deploy", func(c echo.Context) error { return h.ComputerDeploy(c, "") }, h.IsAuthenticated)
 	e.GET("/tenant/:tenant/computers/:uuid/deploy/searchinstall", func(c echo.Context) error { return h.ComputerDeploy(c, "") }, h.IsAuthenticated)
@@ -334,12 +452,21 @@ func (h *Handler) Register(e *echo.Echo, registerRateLimit float64) {
 	e.GET("/tenant/:tenant/site/:site/computers", func(c echo.Context) error { return h.ComputersList(c, "", false) }, h.IsAuthenticated)
 	e.POST("/tenant/:tenant/site/:site/computers", func(c echo.Context) error { return h.ComputersList(c, "", false) }, h.IsAuthenticated)
 	e.DELETE("/tenant/:tenant/site/:site/computers", func(c echo.Context) error { return h.ComputersList(c, "", false) }, h.IsAuthenticated)
+	e.GET("/tenant/:tenant/site/:site/computers/bulk", h.ComputersBulkForm, h.IsAuthenticated)
+	for _, action := range bulkactions.Actions {
+		e.POST("/tenant/:tenant/site/:site/computers/bulk/"+action, func(c echo.Context) error { return h.ComputersBulkAction(c, action) }, h.IsAuthenticated)
+	}
+	e.POST("/tenant/:tenant/site/:site/computers/columns", h.SaveComputerColumns, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/site/:site/computers/views", func(c echo.Context) error { return h.SaveView(c, savedviews.ListComputers) }, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/site/:site/computers/views/:id/default", func(c echo.Context) error { return h.ToggleDefaultView(c, savedviews.ListComputers) }, h.IsAuthenticated)
+	e.DELETE("/tenant/:tenant/site/:site/computers/views/:id", func(c echo.Context) error { return h.DeleteView(c, savedviews.ListComputers) }, h.IsAuthenticated)
 	e.GET("/tenant/:tenant/site/:site/computers/:uuid", h.Overview, h.IsAuthenticated)
 	e.DELETE("/tenant/:tenant/site/:site/computers/:uuid", h.ComputerConfirmDelete, h.IsAuthenticated)
 	e.GET("/tenant/:tenant/site/:site/computers/:uuid/overview", h.Overview, h.IsAuthenticated)
 	e.POST("/tenant/:tenant/site/:site/computers/:uuid/overview", h.Overview, h.IsAuthenticated)
 	e.GET("/tenant/:tenant/site/:site/computers/:uuid/software", h.Apps, h.IsAuthenticated)
 	e.POST("/tenant/:tenant/site/:site/computers/:uuid/software", h.Apps, h.IsAuthenticated)
+	e.GET("/tenant/:tenant/site/:site/computers/:uuid/vulnerabilities", h.ComputerVulnerabilities, h.IsAuthenticated)
 	e.GET("/tenant/:tenant/site/:site/computers/:uuid/hardware", h.Computer, h.IsAuthenticated)
 	e.GET("/tenant/:tenant/site/:site/computers/:uuid/logical-disks", h.LogicalDisks, h.IsAuthenticated)
 	e.POST("/tenant/:tenant/site/:site/computers/:uuid/logical-disks", h.BrowseLogicalDisk, h.IsAuthenticated)
@@ -364,6 +491,7 @@ func (h *Handler) Register(e *echo.Echo, registerRateLimit float64) {
 	e.POST("/tenant/:tenant/site/:site/computers/:uuid/power/:action", h.PowerManagement, h.IsAuthenticated)
 	e.GET("/tenant/:tenant/site/:site/computers/:uuid/notes", h.Notes, h.IsAuthenticated)
 	e.POST("/tenant/:tenant/site/:site/computers/:uuid/notes", h.Notes, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/site/:site/computers/:uuid/lifecycle", h.ComputerLifecycle, h.IsAuthenticated)
 	e.GET("/tenant/:tenant/site/:site/computers/:uuid/deploy", func(c echo.Context) error { return h.ComputerDeploy(c, "") }, h.IsAuthenticated)
 	e.POST("/tenant/:tenant/site/:site/computers/:uuid/deploy", func(c echo.Context) error { return h.ComputerDeploy(c, "") }, h.IsAuthenticated)
 	e.GET("/tenant/:tenant/site/:site/computers/:uuid/deploy/searchinstall", func(c echo.Context) error { return h.ComputerDeploy(c, "") }, h.IsAuthenticated)
@@ -473,13 +601,115 @@ func (h *Handler) Register(e *echo.Echo, registerRateLimit float64) {
 		},
 	)))
 
+	e.GET("/inventory-changes", h.ListInventoryChanges, h.IsAuthenticated)
+	e.POST("/inventory-changes", h.ListInventoryChanges, h.IsAuthenticated)
+	e.GET("/compliance", h.ComplianceDashboard, h.IsAuthenticated)
+	e.POST("/compliance", h.ComplianceDashboard, h.IsAuthenticated)
+	e.GET("/vulnerabilities", h.VulnerabilitiesDashboard, h.IsAuthenticated)
+	e.POST("/vulnerabilities", h.VulnerabilitiesDashboard, h.IsAuthenticated)
+	e.GET("/licenses", h.LicensePositions, h.IsAuthenticated)
+	e.POST("/licenses", h.LicensePositions, h.IsAuthenticated)
+	e.GET("/hardware-lifecycle", h.HardwareLifecycle, h.IsAuthenticated)
+	e.POST("/hardware-lifecycle", h.HardwareLifecycle, h.IsAuthenticated)
+	e.POST("/hardware-lifecycle/import", h.ImportHardwareLifecycle, h.IsAuthenticated)
+	e.GET("/dashboards", h.ListDashboards, h.IsAuthenticated)
+	e.POST("/dashboards", h.AddDashboard, h.IsAuthenticated)
+	e.GET("/dashboards/:id", h.ShowDashboard, h.IsAuthenticated)
+	e.POST("/dashboards/:id", h.UpdateDashboard, h.IsAuthenticated)
+	e.GET("/dashboards/:id/delete", h.DashboardDelete, h.IsAuthenticated)
+	e.DELETE("/dashboards/:id", h.DashboardConfirmDelete, h.IsAuthenticated)
+	e.POST("/dashboards/:id/widgets", h.AddDashboardWidget, h.IsAuthenticated)
+	e.DELETE("/dashboards/:id/widgets/:widget", h.DeleteDashboardWidget, h.IsAuthenticated)
+	e.POST("/dashboards/:id/widgets/:widget/up", func(c echo.Context) error { return h.MoveDashboardWidget(c, true) }, h.IsAuthenticated)
+	e.POST("/dashboards/:id/widgets/:widget/down", func(c echo.Context) error { return h.MoveDashboardWidget(c, false) }, h.IsAuthenticated)
+	e.GET("/maintenance-queue", h.ListMaintenanceQueue, h.IsAuthenticated)
+	e.POST("/maintenance-queue", h.ListMaintenanceQueue, h.IsAuthenticated)
+	e.POST("/maintenance-queue/:id/cancel", h.CancelMaintenanceAction, h.IsAuthenticated)
+	e.GET("/scheduled-reports", h.ListReportSchedules, h.IsAuthenticated)
+	e.POST("/scheduled-reports", h.AddReportSchedule, h.IsAuthenticated)
+	e.POST("/scheduled-reports/new/:report", h.NewReportSchedule, h.IsAuthenticated)
+	e.POST("/scheduled-reports/:id/enable", h.EnableReportSchedule, h.IsAuthenticated)
+	e.POST("/scheduled-reports/:id/run", h.RunReportSchedule, h.IsAuthenticated)
+	e.GET("/scheduled-reports/:id/delete", h.ReportScheduleDelete, h.IsAuthenticated)
+	e.DELETE("/scheduled-reports/:id", h.ReportScheduleConfirmDelete, h.IsAuthenticated)
+
+	e.GET("/tenant/:tenant/inventory-changes", h.ListInventoryChanges, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/inventory-changes", h.ListInventoryChanges, h.IsAuthenticated)
+	e.GET("/tenant/:tenant/compliance", h.ComplianceDashboard, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/compliance", h.ComplianceDashboard, h.IsAuthenticated)
+	e.GET("/tenant/:tenant/vulnerabilities", h.VulnerabilitiesDashboard, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/vulnerabilities", h.VulnerabilitiesDashboard, h.IsAuthenticated)
+	e.GET("/tenant/:tenant/licenses", h.LicensePositions, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/licenses", h.LicensePositions, h.IsAuthenticated)
+	e.GET("/tenant/:tenant/hardware-lifecycle", h.HardwareLifecycle, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/hardware-lifecycle", h.HardwareLifecycle, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/hardware-lifecycle/import", h.ImportHardwareLifecycle, h.IsAuthenticated)
+	e.GET("/tenant/:tenant/dashboards", h.ListDashboards, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/dashboards", h.AddDashboard, h.IsAuthenticated)
+	e.GET("/tenant/:tenant/dashboards/:id", h.ShowDashboard, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/dashboards/:id", h.UpdateDashboard, h.IsAuthenticated)
+	e.GET("/tenant/:tenant/dashboards/:id/delete", h.DashboardDelete, h.IsAuthenticated)
+	e.DELETE("/tenant/:tenant/dashboards/:id", h.DashboardConfirmDelete, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/dashboards/:id/widgets", h.AddDashboardWidget, h.IsAuthenticated)
+	e.DELETE("/tenant/:tenant/dashboards/:id/widgets/:widget", h.DeleteDashboardWidget, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/dashboards/:id/widgets/:widget/up", func(c echo.Context) error { return h.MoveDashboardWidget(c, true) }, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/dashboards/:id/widgets/:widget/down", func(c echo.Context) error { return h.MoveDashboardWidget(c, false) }, h.IsAuthenticated)
+	e.GET("/tenant/:tenant/maintenance-queue", h.ListMaintenanceQueue, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/maintenance-queue", h.ListMaintenanceQueue, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/maintenance-queue/:id/cancel", h.CancelMaintenanceAction, h.IsAuthenticated)
+	e.GET("/tenant/:tenant/scheduled-reports", h.ListReportSchedules, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/scheduled-reports", h.AddReportSchedule, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/scheduled-reports/new/:report", h.NewReportSchedule, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/scheduled-reports/:id/enable", h.EnableReportSchedule, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/scheduled-reports/:id/run", h.RunReportSchedule, h.IsAuthenticated)
+	e.GET("/tenant/:tenant/scheduled-reports/:id/delete", h.ReportScheduleDelete, h.IsAuthenticated)
+	e.DELETE("/tenant/:tenant/scheduled-reports/:id", h.ReportScheduleConfirmDelete, h.IsAuthenticated)
+
+	e.GET("/tenant/:tenant/site/:site/inventory-changes", h.ListInventoryChanges, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/site/:site/inventory-changes", h.ListInventoryChanges, h.IsAuthenticated)
+	e.GET("/tenant/:tenant/site/:site/compliance", h.ComplianceDashboard, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/site/:site/compliance", h.ComplianceDashboard, h.IsAuthenticated)
+	e.GET("/tenant/:tenant/site/:site/vulnerabilities", h.VulnerabilitiesDashboard, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/site/:site/vulnerabilities", h.VulnerabilitiesDashboard, h.IsAuthenticated)
+	e.GET("/tenant/:tenant/site/:site/licenses", h.LicensePositions, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/site/:site/licenses", h.LicensePositions, h.IsAuthenticated)
+	e.GET("/tenant/:tenant/site/:site/hardware-lifecycle", h.HardwareLifecycle, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/site/:site/hardware-lifecycle", h.HardwareLifecycle, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/site/:site/hardware-lifecycle/import", h.ImportHardwareLifecycle, h.IsAuthenticated)
+	e.GET("/tenant/:tenant/site/:site/dashboards", h.ListDashboards, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/site/:site/dashboards", h.AddDashboard, h.IsAuthenticated)
+	e.GET("/tenant/:tenant/site/:site/dashboards/:id", h.ShowDashboard, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/site/:site/dashboards/:id", h.UpdateDashboard, h.IsAuthenticated)
+	e.GET("/tenant/:tenant/site/:site/dashboards/:id/delete", h.DashboardDelete, h.IsAuthenticated)
+	e.DELETE("/tenant/:tenant/site/:site/dashboards/:id", h.DashboardConfirmDelete, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/site/:site/dashboards/:id/widgets", h.AddDashboardWidget, h.IsAuthenticated)
+	e.DELETE("/tenant/:tenant/site/:site/dashboards/:id/widgets/:widget", h.DeleteDashboardWidget, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/site/:site/dashboards/:id/widgets/:widget/up", func(c echo.Context) error { return h.MoveDashboardWidget(c, true) }, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/site/:site/dashboards/:id/widgets/:widget/down", func(c echo.Context) error { return h.MoveDashboardWidget(c, false) }, h.IsAuthenticated)
+	e.GET("/tenant/:tenant/site/:site/maintenance-queue", h.ListMaintenanceQueue, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/site/:site/maintenance-queue", h.ListMaintenanceQueue, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/site/:site/maintenance-queue/:id/cancel", h.CancelMaintenanceAction, h.IsAuthenticated)
+	e.GET("/tenant/:tenant/site/:site/scheduled-reports", h.ListReportSchedules, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/site/:site/scheduled-reports", h.AddReportSchedule, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/site/:site/scheduled-reports/new/:report", h.NewReportSchedule, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/site/:site/scheduled-reports/:id/enable", h.EnableReportSchedule, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/site/:site/scheduled-reports/:id/run", h.RunReportSchedule, h.IsAuthenticated)
+	e.GET("/tenant/:tenant/site/:site/scheduled-reports/:id/delete", h.ReportScheduleDelete, h.IsAuthenticated)
+	e.DELETE("/tenant/:tenant/site/:site/scheduled-reports/:id", h.ReportScheduleConfirmDelete, h.IsAuthenticated)
+
 	e.POST("/reports/agents", h.GenerateAgentsReport, h.IsAuthenticated)
 	e.POST("/reports/computers", h.GenerateComputersReport, h.IsAuthenticated)
 	e.POST("/reports/antivirus", h.GenerateAntivirusReport, h.IsAuthenticated)
 	e.POST("/reports/updates", h.GenerateUpdatesReport, h.IsAuthenticated)
 	e.POST("/reports/software", h.GenerateSoftwareReport, h.IsAuthenticated)
+	e.POST("/reports/compliance", h.GenerateComplianceReport, h.IsAuthenticated)
+	e.POST("/reports/vulnerabilities", h.GenerateVulnerabilitiesReport, h.IsAuthenticated)
+	e.POST("/reports/licenses", h.GenerateLicensesReport, h.IsAuthenticated)
+	e.POST("/reports/hardware-lifecycle", h.GenerateHardwareLifecycleReport, h.IsAuthenticated)
+	e.POST("/reports/fleet-trends", h.GenerateFleetTrendsReport, h.IsAuthenticated)
 	e.POST("/reports/computer/:uuid", h.GenerateComputerReport, h.IsAuthenticated)
 	e.POST("/reports/:report/csv", h.GenerateCSVReports, h.IsAuthenticated)
+	e.POST("/reports/:report/xlsx", h.GenerateXLSXReports, h.IsAuthenticated)
 	e.POST("/reports/computer/:uuid/ods", h.GenerateComputerODSReport, h.IsAuthenticated)
 
 	e.POST("/tenant/:tenant/reports/agents", h.GenerateAgentsReport, h.IsAuthenticated)
@@ -487,8 +717,14 @@ func (h *Handler) Register(e *echo.Echo, registerRateLimit float64) {
 	e.POST("/tenant/:tenant/reports/antivirus", h.GenerateAntivirusReport, h.IsAuthenticated)
 	e.POST("/tenant/:tenant/reports/updates", h.GenerateUpdatesReport, h.IsAuthenticated)
 	e.POST("/tenant/:tenant/reports/software", h.GenerateSoftwareReport, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/reports/compliance", h.GenerateComplianceReport, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/reports/vulnerabilities", h.GenerateVulnerabilitiesReport, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/reports/licenses", h.GenerateLicensesReport, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/reports/hardware-lifecycle", h.GenerateHardwareLifecycleReport, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/reports/fleet-trends", h.GenerateFleetTrendsReport, h.IsAuthenticated)
 	e.POST("/tenant/:tenant/reports/computer/:uuid", h.GenerateComputerReport, h.IsAuthenticated)
 	e.POST("/tenant/:tenant/reports/:report/csv", h.GenerateCSVReports, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/reports/:report/xlsx", h.GenerateXLSXReports, h.IsAuthenticated)
 	e.POST("/tenant/:tenant/reports/computer/:uuid/ods", h.GenerateComputerODSReport, h.IsAuthenticated)
 
 	e.POST("/tenant/:tenant/site/:site/reports/agents", h.GenerateAgentsReport, h.IsAuthenticated)
@@ -496,8 +732,14 @@ func (h *Handler) Register(e *echo.Echo, registerRateLimit float64) {
 	e.POST("/tenant/:tenant/site/:site/reports/antivirus", h.GenerateAntivirusReport, h.IsAuthenticated)
 	e.POST("/tenant/:tenant/site/:site/reports/updates", h.GenerateUpdatesReport, h.IsAuthenticated)
 	e.POST("/tenant/:tenant/site/:site/reports/software", h.GenerateSoftwareReport, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/site/:site/reports/compliance", h.GenerateComplianceReport, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/site/:site/reports/vulnerabilities", h.GenerateVulnerabilitiesReport, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/site/:site/reports/licenses", h.GenerateLicensesReport, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/site/:site/reports/hardware-lifecycle", h.GenerateHardwareLifecycleReport, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/site/:site/reports/fleet-trends", h.GenerateFleetTrendsReport, h.IsAuthenticated)
 	e.POST("/tenant/:tenant/site/:site/reports/computer/:uuid", h.GenerateComputerReport, h.IsAuthenticated)
 	e.POST("/tenant/:tenant/site/:site/reports/:report/csv", h.GenerateCSVReports, h.IsAuthenticated)
+	e.POST("/tenant/:tenant/site/:site/reports/:report/xlsx", h.GenerateXLSXReports, h.IsAuthenticated)
 	e.POST("/tenant/:tenant/site/:site/reports/computer/:uuid/ods", h.GenerateComputerODSReport, h.IsAuthenticated)
 
 	e.GET("/security", h.ListAntivirusStatus, h.IsAuthenticated)
@@ -624,46 +866,103 @@ func (h *Handler) Register(e *echo.Echo, registerRateLimit float64) {
 
 	e.GET("/login/new", h.LoginNewUser)
 
+	// Rate-Limit for security keys, same window as TOTP validate
+	e.POST("/login/passkey/begin", h.LoginPasskeyBegin)
+	e.POST("/login/passkey/finish", h.LoginPasskeyFinish, middleware.RateLimiter(middleware.NewRateLimiterMemoryStoreWithConfig(
+		middleware.RateLimiterMemoryStoreConfig{
+			Rate:      rate.Every(30 * time.Second),
+			Burst:     5,
+			ExpiresIn: 1 * time.Minute,
+		},
+	)))
+	e.POST("/login/webauthn/begin", h.LoginWebAuthnBegin)
+	e.POST("/login/webauthn/finish", h.LoginWebAuthnFinish, middleware.RateLimiter(middleware.NewRateLimiterMemoryStoreWithConfig(
+		middleware.RateLimiterMemoryStoreConfig{
+			Rate:      rate.Every(30 * time.Second),
+			Burst:     5,
+			ExpiresIn: 1 * time.Minute,
+		},
+	)))
+
 	e.GET("/myaccount", h.MyAccount, h.IsAuthenticated)
 	e.POST("/myaccount/info", h.UpdatePersonalInfo, h.IsAuthenticated)
 	e.POST("/myaccount/password", h.MyAccountPassword, h.IsAuthenticated)
 	e.POST("/myaccount/enable2fa", h.Enable2FA, h.IsAuthenticated)
 	e.POST("/myaccount/disable2fa", h.Disable2FA, h.IsAuthenticated)
 	e.POST("/myaccount/register2fa", h.Enabled2FA, h.IsAuthenticated)
+	e.POST("/myaccount/tokens", h.MyAccountNewAPIToken, h.IsAuthenticated)
+	e.GET("/myaccount/tokens/:id/delete", h.MyAccountAPITokenDelete, h.IsAuthenticated)
+	e.DELETE("/myaccount/tokens/:id", h.MyAccountAPITokenConfirmDelete, h.IsAuthenticated)
+	e.POST("/myaccount/passkeys/begin", h.MyAccountPasskeyBegin, h.IsAuthenticated)
+	e.POST("/myaccount/passkeys/finish", h.MyAccountPasskeyFinish, h.IsAuthenticated)
+	e.POST("/myaccount/passkeys/:id", h.MyAccountPasskeyRename, h.IsAuthenticated)
+	e.GET("/myaccount/passkeys/:id/delete", h.MyAccountPasskeyDelete, h.IsAuthenticated)
+	e.DELETE("/myaccount/passkeys/:id", h.MyAccountPasskeyConfirmDelete, h.IsAuthenticated)
+	e.POST("/myaccount/sessions/logout", h.MyAccountSignOutEverywhere, h.IsAuthenticated)
+	e.GET("/myaccount/sessions/:hash/delete", h.MyAccountSessionDelete, h.IsAuthenticated)
+	e.DELETE("/myaccount/sessions/:hash", h.MyAccountSessionConfirmDelete, h.IsAuthenticated)
 }
 
 func (h *Handler) IsAuthenticated(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
+		// Personal API tokens are only accepted by the REST API
+		if bearer, ok := GetBearerToken(c); ok {
+			if !IsAPIRequest(c) {
+				return h.NotAuthenticated(c)
+			}
+			return h.AuthenticateAPIToken(c, next, bearer)
+		}
+
 		// Redirect to Login if user has no session
 		if !h.SessionManager.Manager.Exists(c.Request().Context(), "uid") {
-			return h.Login(c)
+			return h.NotAuthenticated(c)
 		}
 
 		// get uid from session
 		username := h.SessionManager.Manager.GetString(c.Request().Context(), "uid")
 		if username == "" {
-			return h.Login(c)
+			return h.NotAuthenticated(c)
 		}
 
 		// get user from database
 		user, err := h.Model.GetUserById(username)
 		if err != nil {
-			return h.Login(c)
+			return h.NotAuthenticated(c)
+		}
+
+		// users disabled by the directory or the identity provider are signed out
+		disabled, err := h.Model.IsUserDisabled(user.ID)
+		if err != nil {
+			log.Printf("[ERROR]: could not check if user %s has been disabled, reason: %v", user.ID, err)
+			return h.Forbidden(c)
+		}
+
+		if disabled {
+			if err := h.SessionManager.Manager.Destroy(c.Request().Context()); err != nil {
+				log.Printf("[ERROR]: could not destroy the session of disabled user %s, reason: %v", user.ID, err)
+			}
+			return h.NotAuthenticated(c)
 		}
 
 		// if sessions includes forgot
 		forgot := h.SessionManager.Manager.GetBool(c.Request().Context(), "forgot")
 		if forgot {
-			return h.Login(c)
+			return h.NotAuthenticated(c)
+		}
+
+		// if use 2fa, security keys are a second factor too
+		hasSecurityKeys, err := h.Model.HasWebAuthnCredentials(user.ID)
+		if err != nil {
+			log.Printf("[ERROR]: could not check the security keys of user %s, reason: %v", user.ID, err)
+			return h.Forbidden(c)
 		}
 
-		// if use 2fa
-		if user.Use2fa {
+		if user.Use2fa || hasSecurityKeys {
 			// check if user has been 2FA authenticated
 			twofa := h.SessionManager.Manager.GetBool(c.Request().Context(), "twofa")
 			if !twofa {
-				if user.Passwd {
-					return h.Login(c)
+				if user.Passwd || IsAPIRequest(c) {
+					return h.NotAuthenticated(c)
 				}
 
 				csrfToken, ok := c.Get("csrf").(string)
@@ -679,10 +978,79 @@ func (h *Handler) IsAuthenticated(next echo.HandlerFunc) echo.HandlerFunc {
 
 				isTurnstileEnabled := turnstileSiteKey != "" && turnstileSecretKey != ""
 
-				return RenderLogin(c, login_views.LoginIndex(login_views.Enter2FA(username, turnstileSiteKey, turnstileSecretKey), csrfToken, isTurnstileEnabled))
+				return RenderLogin(c, login_views.LoginIndex(login_views.Enter2FA(username, turnstileSiteKey, turnstileSecretKey, user.Use2fa, hasSecurityKeys), csrfToken, isTurnstileEnabled))
 			}
 		}
 
+		h.TrackSession(c, user.ID)
+
+		// admins may be required to sign in with a security key, until then they can
+		// only use their account page to register one
+		if !strings.HasPrefix(c.Path(), "/myaccount") && c.Path() != "/logout" && h.PhishingResistantAuthRequired(c) {
+			return h.RequirePhishingResistantAuth(c)
+		}
+
+		return h.IsAuthorized(c, next, username)
+	}
+}
+
+// NotAuthenticated shows the login page or, for API requests, a JSON 401 response
+func (h *Handler) NotAuthenticated(c echo.Context) error {
+	if IsAPIRequest(c) {
+		return RenderAPIError(c, http.StatusUnauthorized, i18n.T(c.Request().Context(), "api.unauthorized"))
+	}
+	return h.Login(c)
+}
+
+// IsAuthorized is called by IsAuthenticated once the user is known, it checks that the roles
+// assigned to the user in the tenant and site of the route grant the permission required
+func (h *Handler) IsAuthorized(c echo.Context, next echo.HandlerFunc, username string) error {
+	access, err := h.GetUserAccess(c)
+	if err != nil {
+		log.Printf("[ERROR]: could not get the roles for user %s, reason: %v", username, err)
+		return h.Forbidden(c)
+	}
+
+	required := rbac.RequiredPermission(c.Request().Method, c.Path())
+	if required == rbac.PermissionNone {
 		return next(c)
 	}
+
+	tenantID, siteID, err := h.GetRouteScope(c)
+	if err != nil {
+		return h.Forbidden(c)
+	}
+
+	level := access.Level(tenantID, siteID)
+	if level >= required {
+		return next(c)
+	}
+
+	// users whose roles are restricted to other tenants or sites are sent to a dashboard they can see
+	if level == rbac.PermissionNone && c.Request().Method == http.MethodGet && !IsAPIRequest(c) {
+		if homeTenant, homeSite, ok := access.Home(); ok && homeTenant != -1 {
+			url := fmt.Sprintf("/tenant/%d/dashboard", homeTenant)
+			if homeSite != -1 {
+				url = fmt.Sprintf("/tenant/%d/site/%d/dashboard", homeTenant, homeSite)
+			}
+			if c.Request().Header.Get("HX-Request") == "true" {
+				c.Response().Header().Set("HX-Redirect", url)
+				return c.NoContent(http.StatusOK)
+			}
+			return c.Redirect(http.StatusFound, url)
+		}
+	}
+
+	return h.Forbidden(c)
+}
+
+func (h *Handler) Forbidden(c echo.Context) error {
+	message := i18n.T(c.Request().Context(), "roles.forbidden")
+	if IsAPIRequest(c) {
+		return RenderAPIError(c, http.StatusForbidden, message)
+	}
+	if c.Request().Header.Get("HX-Request") == "true" {
+		return RenderError(c, partials.ErrorMessage(message, true))
+	}
+	return echo.NewHTTPError(http.StatusForbidden, message)
 }
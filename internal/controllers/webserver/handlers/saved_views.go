@@ -0,0 +1,279 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/invopop/ctxi18n/i18n"
+	"github.com/labstack/echo/v4"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/models"
+	"github.com/open-uem/openuem-console/internal/savedviews"
+	"github.com/open-uem/openuem-console/internal/views/computers_views"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+)
+
+// computerColumnsSessionKey holds the columns chosen for the computers list in the session
+const computerColumnsSessionKey = "computers-columns"
+
+// applySavedView replaces the query of a list request with the filters and sorting of a
+// saved view. The view is the one requested with the view param or, when the user opens
+// the list from the menu without filters, the default view of the user. Refreshing the
+// list or clearing its filters target #main and don't apply the default view. Pages
+// loaded without htmx must be redirected to the returned url so the browser shows the
+// filters of the view
+func (h *Handler) applySavedView(c echo.Context, list string, commonInfo *partials.CommonInfo) string {
+	tenantID, err := strconv.Atoi(commonInfo.TenantID)
+	if err != nil {
+		return ""
+	}
+	userID := h.GetUserID(c)
+
+	var v consoledb.SavedView
+	query := c.Request().URL.Query()
+	switch {
+	case query.Get("view") != "":
+		id, err := strconv.Atoi(query.Get("view"))
+		if err != nil {
+			return ""
+		}
+		if v, err = h.Model.GetSavedView(id, userID, tenantID, list); err != nil {
+			return ""
+		}
+	case c.Request().Method == http.MethodGet && len(query) == 0 && c.Request().Header.Get("HX-Target") != "main":
+		if v, err = h.Model.GetDefaultSavedView(userID, tenantID, list); err != nil {
+			return ""
+		}
+	default:
+		return ""
+	}
+
+	c.Request().URL.RawQuery = v.Query
+	c.Request().Form = nil
+	c.Request().PostForm = nil
+
+	if list == savedviews.ListComputers {
+		h.SessionManager.Manager.Put(c.Request().Context(), computerColumnsSessionKey, strings.Join(v.Columns, ","))
+	}
+
+	u := url.URL{Path: c.Request().URL.Path, RawQuery: v.Query}
+	if c.Request().Header.Get("HX-Request") == "true" {
+		c.Response().Header().Set("HX-Push-Url", u.String())
+		return ""
+	}
+
+	// a view without filters is shown at the list url, redirecting would apply the default view again
+	if v.Query == "" && query.Get("view") == "" {
+		return ""
+	}
+	return u.String()
+}
+
+// savedViewsMenu returns the views of the list the user can pick. The view whose filters
+// and sorting match the current ones is marked as active
+func (h *Handler) savedViewsMenu(c echo.Context, list string, current url.Values, commonInfo *partials.CommonInfo) partials.SavedViewsMenu {
+	menu := partials.SavedViewsMenu{
+		Url:    partials.GetNavigationUrl(commonInfo, "/"+list),
+		UserID: h.GetUserID(c),
+	}
+
+	tenantID, err := strconv.Atoi(commonInfo.TenantID)
+	if err != nil {
+		return menu
+	}
+
+	views, err := h.Model.GetSavedViews(menu.UserID, tenantID, list)
+	if err != nil {
+		return menu
+	}
+	menu.Views = views
+
+	if v, err := h.Model.GetDefaultSavedView(menu.UserID, tenantID, list); err == nil {
+		menu.DefaultID = v.ID
+	}
+
+	query := savedviews.Query(current)
+	for _, v := range views {
+		if v.Query == query {
+			menu.ActiveID = v.ID
+			break
+		}
+	}
+
+	return menu
+}
+
+// currentListValues returns the filters and sorting of the list being rendered, read
+// from the url shown in the browser when the list is rendered again after an action
+func currentListValues(c echo.Context, comesFromDialog bool) url.Values {
+	if comesFromDialog {
+		if u, err := url.Parse(c.Request().Header.Get("Hx-Current-Url")); err == nil {
+			return u.Query()
+		}
+		return url.Values{}
+	}
+
+	values, err := c.FormParams()
+	if err != nil {
+		return url.Values{}
+	}
+	return values
+}
+
+// computerColumns returns the columns chosen for the computers list and the values of the
+// metadata columns for the listed computers
+func (h *Handler) computerColumns(c echo.Context, computers []models.Computer, commonInfo *partials.CommonInfo) (computers_views.ComputerColumns, error) {
+	columns := computers_views.ComputerColumns{}
+
+	orgMetadata, err := h.Model.GetAllOrgMetadata(commonInfo)
+	if err != nil {
+		return columns, err
+	}
+	columns.Metadata = orgMetadata
+
+	ids := []int{}
+	for _, m := range orgMetadata {
+		ids = append(ids, m.ID)
+	}
+	columns.Selected = savedviews.ParseColumns(h.SessionManager.Manager.GetString(c.Request().Context(), computerColumnsSessionKey), ids)
+
+	selectedIDs := []int{}
+	for _, column := range columns.Selected {
+		if id, ok := savedviews.MetadataID(column); ok {
+			selectedIDs = append(selectedIDs, id)
+		}
+	}
+
+	agentIDs := []string{}
+	for _, computer := range computers {
+		agentIDs = append(agentIDs, computer.ID)
+	}
+
+	columns.Values, err = h.Model.GetMetadataValues(agentIDs, selectedIDs)
+	return columns, err
+}
+
+func (h *Handler) SaveView(c echo.Context, list string) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	tenantID, err := strconv.Atoi(commonInfo.TenantID)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "tenants.could_not_convert_to_int", err.Error()), true))
+	}
+
+	name := strings.TrimSpace(c.FormValue("savedViewName"))
+	if name == "" {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "saved_views.name_required"), true))
+	}
+	if len(name) > 100 {
+		name = name[:100]
+	}
+
+	v := consoledb.SavedView{
+		TenantID: tenantID,
+		UserID:   h.GetUserID(c),
+		List:     list,
+		Name:     name,
+		Query:    savedviews.Query(currentListValues(c, true)),
+		Shared:   c.FormValue("savedViewShared") == "on",
+	}
+
+	if list == savedviews.ListComputers {
+		if columns := h.SessionManager.Manager.GetString(c.Request().Context(), computerColumnsSessionKey); columns != "" {
+			v.Columns = strings.Split(columns, ",")
+		}
+	}
+
+	if err := h.Model.SaveView(v); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "saved_views.could_not_save", err.Error()), true))
+	}
+
+	return h.renderSavedViewList(c, list, i18n.T(c.Request().Context(), "saved_views.saved"))
+}
+
+func (h *Handler) DeleteView(c echo.Context, list string) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	tenantID, err := strconv.Atoi(commonInfo.TenantID)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "tenants.could_not_convert_to_int", err.Error()), true))
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "saved_views.not_found"), true))
+	}
+
+	if err := h.Model.DeleteSavedView(id, h.GetUserID(c), tenantID); err != nil {
+		if errors.Is(err, models.ErrSavedViewNotFound) {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "saved_views.not_found"), true))
+		}
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "saved_views.could_not_delete", err.Error()), true))
+	}
+
+	return h.renderSavedViewList(c, list, i18n.T(c.Request().Context(), "saved_views.deleted"))
+}
+
+// ToggleDefaultView makes the view the one shown when the user opens the list, or
+// stops showing it if it was already the default view
+func (h *Handler) ToggleDefaultView(c echo.Context, list string) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	tenantID, err := strconv.Atoi(commonInfo.TenantID)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "tenants.could_not_convert_to_int", err.Error()), true))
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "saved_views.not_found"), true))
+	}
+
+	userID := h.GetUserID(c)
+	successMessage := i18n.T(c.Request().Context(), "saved_views.default_set")
+	if current, err := h.Model.GetDefaultSavedView(userID, tenantID, list); err == nil && current.ID == id {
+		id = 0
+		successMessage = i18n.T(c.Request().Context(), "saved_views.default_unset")
+	}
+
+	if err := h.Model.SetDefaultSavedView(userID, tenantID, list, id); err != nil {
+		if errors.Is(err, models.ErrSavedViewNotFound) {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "saved_views.not_found"), true))
+		}
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "saved_views.could_not_set_default", err.Error()), true))
+	}
+
+	return h.renderSavedViewList(c, list, successMessage)
+}
+
+// SaveComputerColumns keeps the columns chosen for the computers list in the session,
+// they're saved with the next view the user saves
+func (h *Handler) SaveComputerColumns(c echo.Context) error {
+	values, err := c.FormParams()
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), true))
+	}
+
+	h.SessionManager.Manager.Put(c.Request().Context(), computerColumnsSessionKey, strings.Join(values["column"], ","))
+
+	return h.ComputersList(c, "", true)
+}
+
+func (h *Handler) renderSavedViewList(c echo.Context, list string, successMessage string) error {
+	if list == savedviews.ListAgents {
+		return h.ListAgents(c, successMessage, "", true)
+	}
+	return h.ComputersList(c, successMessage, true)
+}
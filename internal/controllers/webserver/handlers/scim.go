@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/invopop/ctxi18n/i18n"
+	"github.com/labstack/echo/v4"
+	"github.com/open-uem/openuem-console/internal/models"
+	"github.com/open-uem/openuem-console/internal/scim"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+)
+
+// SCIMPrefix is the path prefix of the SCIM 2.0 endpoint used by identity providers
+const SCIMPrefix = "/scim/v2"
+
+// SCIMActor is the user recorded in the audit log for the changes done by the identity provider
+const SCIMActor = "scim"
+
+// RegisterSCIM adds the SCIM 2.0 endpoint that lets an identity provider provision and
+// deprovision console users and grant roles with groups
+func (h *Handler) RegisterSCIM(e *echo.Echo) {
+	api := e.Group(SCIMPrefix, h.IsSCIMAuthenticated)
+
+	api.GET("/ServiceProviderConfig", h.SCIMServiceProviderConfig)
+
+	api.GET("/Users", h.SCIMListUsers)
+	api.POST("/Users", h.SCIMAddUser)
+	api.GET("/Users/:id", h.SCIMGetUser)
+	api.PUT("/Users/:id", h.SCIMReplaceUser)
+	api.PATCH("/Users/:id", h.SCIMPatchUser)
+	api.DELETE("/Users/:id", h.SCIMDeleteUser)
+
+	api.GET("/Groups", h.SCIMListGroups)
+	api.POST("/Groups", h.SCIMAddGroup)
+	api.GET("/Groups/:id", h.SCIMGetGroup)
+	api.PUT("/Groups/:id", h.SCIMReplaceGroup)
+	api.PATCH("/Groups/:id", h.SCIMPatchGroup)
+	api.DELETE("/Groups/:id", h.SCIMDeleteGroup)
+}
+
+// IsSCIMAuthenticated only lets requests with the SCIM token through while the endpoint is enabled
+func (h *Handler) IsSCIMAuthenticated(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		s, err := h.Model.GetSCIMSettings()
+		if err != nil {
+			log.Printf("[ERROR]: could not get the SCIM settings, reason: %v", err)
+			return RenderSCIMError(c, http.StatusInternalServerError, "", err.Error())
+		}
+
+		if !s.Enabled {
+			return RenderSCIMError(c, http.StatusForbidden, "", i18n.T(c.Request().Context(), "scim.not_enabled"))
+		}
+
+		bearer, ok := GetBearerToken(c)
+		if !ok {
+			return RenderSCIMError(c, http.StatusUnauthorized, "", i18n.T(c.Request().Context(), "api.unauthorized"))
+		}
+
+		if err := h.Model.ValidateSCIMToken(bearer); err != nil {
+			if !errors.Is(err, models.ErrInvalidSCIMToken) {
+				log.Printf("[ERROR]: could not validate SCIM token, reason: %v", err)
+			}
+			return RenderSCIMError(c, http.StatusUnauthorized, "", i18n.T(c.Request().Context(), "api.unauthorized"))
+		}
+
+		c.Set("uid", SCIMActor)
+
+		return next(c)
+	}
+}
+
+func (h *Handler) SCIMServiceProviderConfig(c echo.Context) error {
+	return RenderSCIM(c, http.StatusOK, scim.NewServiceProviderConfig())
+}
+
+// SaveSCIMSettings enables or disables the SCIM endpoint, the token is kept
+func (h *Handler) SaveSCIMSettings(c echo.Context) error {
+	before, err := h.Model.GetSCIMSettings()
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "scim.could_not_get_settings", err.Error()), true))
+	}
+
+	enabled, err := strconv.ParseBool(c.FormValue("scim-enabled"))
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "scim.could_not_parse_option", "scim-enabled"), true))
+	}
+
+	if err := h.Model.SaveSCIMEnabled(enabled); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "scim.settings_not_saved", err.Error()), true))
+	}
+
+	after := before
+	after.Enabled = enabled
+	h.AuditChanges(c, AuditAuthUpdate, "scim", before, after)
+
+	return h.RenderAuthenticationSettings(c, "", i18n.T(c.Request().Context(), "scim.settings_saved"))
+}
+
+// NewSCIMToken replaces the SCIM token, the previous one stops working right away
+func (h *Handler) NewSCIMToken(c echo.Context) error {
+	token, err := h.Model.NewSCIMToken()
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "scim.could_not_create_token", err.Error()), true))
+	}
+
+	h.Audit(c, AuditAuthUpdate, "scim", "", "token")
+
+	return h.RenderAuthenticationSettings(c, token, i18n.T(c.Request().Context(), "scim.token_created"))
+}
+
+// RenderSCIM sends a SCIM response, the protocol uses its own media type
+func RenderSCIM(c echo.Context, code int, v any) error {
+	c.Response().Header().Set(echo.HeaderContentType, scim.ContentType)
+	return c.JSON(code, v)
+}
+
+func RenderSCIMError(c echo.Context, code int, scimType, detail string) error {
+	return RenderSCIM(c, code, scim.NewError(code, scimType, detail))
+}
+
+// BindSCIM decodes the body of a request, identity providers send it as application/scim+json
+// which the echo binder doesn't understand
+func BindSCIM(c echo.Context, v any) error {
+	return json.NewDecoder(c.Request().Body).Decode(v)
+}
+
+// SCIMBaseURL returns the URL of the SCIM endpoint as seen by the identity provider
+func SCIMBaseURL(c echo.Context) string {
+	return c.Scheme() + "://" + c.Request().Host + SCIMPrefix
+}
+
+// SCIMLocation returns the URL of a resource
+func SCIMLocation(c echo.Context, resource, id string) string {
+	return SCIMBaseURL(c) + "/" + resource + "/" + url.PathEscape(id)
+}
+
+// SCIMPage reads the 1-based startIndex and count query parameters
+func SCIMPage(c echo.Context) (int, int) {
+	startIndex, err := strconv.Atoi(c.QueryParam("startIndex"))
+	if err != nil || startIndex < 1 {
+		startIndex = 1
+	}
+
+	count, err := strconv.Atoi(c.QueryParam("count"))
+	if err != nil || count < 0 {
+		count = scim.DefaultCount
+	}
+
+	return startIndex, count
+}
@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/invopop/ctxi18n/i18n"
+	"github.com/labstack/echo/v4"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/models"
+	"github.com/open-uem/openuem-console/internal/rbac"
+	"github.com/open-uem/openuem-console/internal/scim"
+)
+
+// The SCIM groups are the console roles, the members of a group have the role in every tenant
+// and site. Groups can't be created, a group sent by the identity provider is linked to the
+// role with the same name
+
+// errSCIMRolesNotAssigned is returned when the identity provider tries to grant the first role,
+// the console would stop being unrestricted and every other user, admins included, would be locked out
+var errSCIMRolesNotAssigned = errors.New("the first role must be assigned in the console")
+
+func (h *Handler) SCIMListGroups(c echo.Context) error {
+	attribute, value := "", ""
+	if filter := c.QueryParam("filter"); filter != "" {
+		var err error
+		attribute, value, err = scim.ParseFilter(filter)
+		if err != nil {
+			return RenderSCIMError(c, http.StatusBadRequest, scim.ErrorInvalidFilter, err.Error())
+		}
+	}
+
+	groups := []scim.Group{}
+	for _, role := range rbac.Roles {
+		group, err := h.SCIMGroup(c, role)
+		if err != nil {
+			return RenderSCIMError(c, http.StatusInternalServerError, "", err.Error())
+		}
+
+		if attribute != "" {
+			match, err := group.Matches(attribute, value)
+			if err != nil {
+				return RenderSCIMError(c, http.StatusBadRequest, scim.ErrorInvalidFilter, err.Error())
+			}
+			if !match {
+				continue
+			}
+		}
+
+		groups = append(groups, group)
+	}
+
+	startIndex, count := SCIMPage(c)
+	return RenderSCIM(c, http.StatusOK, scim.NewListResponse(groups, startIndex, count))
+}
+
+func (h *Handler) SCIMGetGroup(c echo.Context) error {
+	role := rbac.Role(c.Param("id"))
+	if !role.IsValid() {
+		return h.renderSCIMGroupNotFound(c)
+	}
+
+	return h.renderSCIMGroup(c, http.StatusOK, role)
+}
+
+// SCIMAddGroup links the group of the identity provider to the role with the same name
+func (h *Handler) SCIMAddGroup(c echo.Context) error {
+	group := scim.Group{}
+	if err := BindSCIM(c, &group); err != nil {
+		return RenderSCIMError(c, http.StatusBadRequest, scim.ErrorInvalidSyntax, err.Error())
+	}
+
+	index := slices.IndexFunc(rbac.Roles, func(r rbac.Role) bool { return strings.EqualFold(string(r), group.DisplayName) })
+	if index == -1 {
+		return RenderSCIMError(c, http.StatusBadRequest, scim.ErrorInvalidValue, i18n.T(c.Request().Context(), "scim.group_not_role", group.DisplayName))
+	}
+	role := rbac.Roles[index]
+
+	if err := h.setSCIMGroupMembers(c, role, memberIDs(group.Members)); err != nil {
+		return h.renderSCIMGroupMembersError(c, err)
+	}
+
+	c.Response().Header().Set(echo.HeaderLocation, SCIMLocation(c, "Groups", string(role)))
+	return h.renderSCIMGroup(c, http.StatusCreated, role)
+}
+
+func (h *Handler) SCIMReplaceGroup(c echo.Context) error {
+	role := rbac.Role(c.Param("id"))
+	if !role.IsValid() {
+		return h.renderSCIMGroupNotFound(c)
+	}
+
+	group := scim.Group{}
+	if err := BindSCIM(c, &group); err != nil {
+		return RenderSCIMError(c, http.StatusBadRequest, scim.ErrorInvalidSyntax, err.Error())
+	}
+
+	if err := h.setSCIMGroupMembers(c, role, memberIDs(group.Members)); err != nil {
+		return h.renderSCIMGroupMembersError(c, err)
+	}
+
+	return h.renderSCIMGroup(c, http.StatusOK, role)
+}
+
+func (h *Handler) SCIMPatchGroup(c echo.Context) error {
+	role := rbac.Role(c.Param("id"))
+	if !role.IsValid() {
+		return h.renderSCIMGroupNotFound(c)
+	}
+
+	patch := scim.PatchRequest{}
+	if err := BindSCIM(c, &patch); err != nil {
+		return RenderSCIMError(c, http.StatusBadRequest, scim.ErrorInvalidSyntax, err.Error())
+	}
+
+	group, err := h.SCIMGroup(c, role)
+	if err != nil {
+		return RenderSCIMError(c, http.StatusInternalServerError, "", err.Error())
+	}
+
+	members, err := scim.PatchMembers(memberIDs(group.Members), patch.Operations)
+	if err != nil {
+		if errors.Is(err, scim.ErrInvalidPath) {
+			return RenderSCIMError(c, http.StatusBadRequest, scim.ErrorInvalidPath, err.Error())
+		}
+		return RenderSCIMError(c, http.StatusBadRequest, scim.ErrorInvalidValue, err.Error())
+	}
+
+	if err := h.setSCIMGroupMembers(c, role, members); err != nil {
+		return h.renderSCIMGroupMembersError(c, err)
+	}
+
+	return h.renderSCIMGroup(c, http.StatusOK, role)
+}
+
+// SCIMDeleteGroup removes every member of the group, the role itself can't be deleted
+func (h *Handler) SCIMDeleteGroup(c echo.Context) error {
+	role := rbac.Role(c.Param("id"))
+	if !role.IsValid() {
+		return h.renderSCIMGroupNotFound(c)
+	}
+
+	if err := h.setSCIMGroupMembers(c, role, []string{}); err != nil {
+		return h.renderSCIMGroupMembersError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// SCIMGroup returns the role as a SCIM group, its members are the users provisioned by the
+// identity provider that have been granted the role with SCIM
+func (h *Handler) SCIMGroup(c echo.Context, role rbac.Role) (scim.Group, error) {
+	assignments, err := h.Model.GetSCIMRoleMembers(role)
+	if err != nil {
+		return scim.Group{}, err
+	}
+
+	members := []scim.Member{}
+	for _, a := range assignments {
+		members = append(members, scim.Member{Value: a.UserID, Display: a.UserID, Ref: SCIMLocation(c, "Users", a.UserID)})
+	}
+
+	return scim.Group{
+		Schemas:     []string{scim.SchemaGroup},
+		ID:          string(role),
+		DisplayName: string(role),
+		Members:     members,
+		Meta: &scim.Meta{
+			ResourceType: "Group",
+			Location:     SCIMLocation(c, "Groups", string(role)),
+		},
+	}, nil
+}
+
+// setSCIMGroupMembers grants the role in every tenant to the members and removes it from
+// the users that are no longer members. Only the users provisioned by the identity provider
+// can be members and only the roles granted with SCIM are removed, the roles assigned by
+// other sources are never touched. The changes are validated before anything is changed:
+// every member must be provisioned, the last global admin can't be removed and the first
+// role of the console must be assigned by an admin
+func (h *Handler) setSCIMGroupMembers(c echo.Context, role rbac.Role, members []string) error {
+	current, err := h.Model.GetSCIMRoleMembers(role)
+	if err != nil {
+		return err
+	}
+
+	// users that already have the role from another source keep it as it is
+	global, err := h.Model.GetGlobalRoleMembers(role)
+	if err != nil {
+		return err
+	}
+
+	added := []string{}
+	for _, m := range members {
+		if slices.Contains(added, m) || slices.ContainsFunc(global, func(a consoledb.RoleAssignment) bool { return a.UserID == m }) {
+			continue
+		}
+
+		if _, err := h.Model.GetSCIMUser(m); err != nil {
+			if errors.Is(err, models.ErrSCIMUserNotFound) {
+				return &scimMemberNotFoundError{userID: m}
+			}
+			return err
+		}
+		added = append(added, m)
+	}
+
+	removed := []consoledb.RoleAssignment{}
+	for _, a := range current {
+		if !slices.Contains(members, a.UserID) {
+			removed = append(removed, a)
+		}
+	}
+
+	if role == rbac.RoleGlobalAdmin && len(removed) > 0 && len(global)-len(removed)+len(added) == 0 {
+		return models.ErrLastGlobalAdmin
+	}
+
+	if len(added) > 0 {
+		count, err := h.Model.CountAllRoleAssignments()
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return errSCIMRolesNotAssigned
+		}
+	}
+
+	for _, m := range added {
+		if err := h.Model.AddSCIMRoleAssignment(m, role); err != nil {
+			return err
+		}
+		h.Audit(c, AuditRoleAdd, m, "", auditRole(string(role), -1, -1))
+	}
+
+	for _, a := range removed {
+		if err := h.Model.DeleteRoleAssignment(a.ID); err != nil {
+			return err
+		}
+		h.Audit(c, AuditRoleDelete, a.UserID, auditRole(a.Role, a.TenantID, a.SiteID), "")
+	}
+
+	return nil
+}
+
+type scimMemberNotFoundError struct {
+	userID string
+}
+
+func (e *scimMemberNotFoundError) Error() string {
+	return "user " + e.userID + " doesn't exist"
+}
+
+func (h *Handler) renderSCIMGroup(c echo.Context, code int, role rbac.Role) error {
+	group, err := h.SCIMGroup(c, role)
+	if err != nil {
+		return RenderSCIMError(c, http.StatusInternalServerError, "", err.Error())
+	}
+
+	return RenderSCIM(c, code, group)
+}
+
+func (h *Handler) renderSCIMGroupNotFound(c echo.Context) error {
+	return RenderSCIMError(c, http.StatusNotFound, "", i18n.T(c.Request().Context(), "scim.group_not_found", c.Param("id")))
+}
+
+func (h *Handler) renderSCIMGroupMembersError(c echo.Context, err error) error {
+	var notFound *scimMemberNotFoundError
+	switch {
+	case errors.As(err, &notFound):
+		return RenderSCIMError(c, http.StatusBadRequest, scim.ErrorInvalidValue, i18n.T(c.Request().Context(), "scim.member_not_found", notFound.userID))
+	case errors.Is(err, models.ErrLastGlobalAdmin):
+		return RenderSCIMError(c, http.StatusBadRequest, scim.ErrorMutability, i18n.T(c.Request().Context(), "roles.last_global_admin"))
+	case errors.Is(err, errSCIMRolesNotAssigned):
+		return RenderSCIMError(c, http.StatusBadRequest, scim.ErrorMutability, i18n.T(c.Request().Context(), "scim.roles_not_assigned"))
+	default:
+		return RenderSCIMError(c, http.StatusInternalServerError, "", err.Error())
+	}
+}
+
+func memberIDs(members []scim.Member) []string {
+	ids := []string{}
+	for _, m := range members {
+		ids = append(ids, m.Value)
+	}
+	return ids
+}
@@ -0,0 +1,320 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/invopop/ctxi18n/i18n"
+	"github.com/labstack/echo/v4"
+	"github.com/open-uem/ent"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/models"
+	"github.com/open-uem/openuem-console/internal/scim"
+)
+
+// SCIMUserChanges holds the attributes of a user the identity provider can change,
+// it's used to record the changes in the audit log
+type SCIMUserChanges struct {
+	Name   string
+	Email  string
+	Phone  string
+	Active bool
+}
+
+// SCIMListUsers lists the users provisioned by the identity provider, local and directory
+// users are not managed with SCIM
+func (h *Handler) SCIMListUsers(c echo.Context) error {
+	all, err := h.Model.GetAllUsers()
+	if err != nil {
+		return RenderSCIMError(c, http.StatusInternalServerError, "", err.Error())
+	}
+
+	provisioned, err := h.Model.GetSCIMUsers()
+	if err != nil {
+		return RenderSCIMError(c, http.StatusInternalServerError, "", err.Error())
+	}
+
+	attribute, value := "", ""
+	if filter := c.QueryParam("filter"); filter != "" {
+		attribute, value, err = scim.ParseFilter(filter)
+		if err != nil {
+			return RenderSCIMError(c, http.StatusBadRequest, scim.ErrorInvalidFilter, err.Error())
+		}
+	}
+
+	users := []scim.User{}
+	for _, u := range all {
+		if !slices.ContainsFunc(provisioned, func(p consoledb.SCIMUser) bool { return p.UserID == u.ID }) {
+			continue
+		}
+
+		user, err := h.SCIMUser(c, u)
+		if err != nil {
+			return RenderSCIMError(c, http.StatusInternalServerError, "", err.Error())
+		}
+
+		if attribute != "" {
+			match, err := user.Matches(attribute, value)
+			if err != nil {
+				return RenderSCIMError(c, http.StatusBadRequest, scim.ErrorInvalidFilter, err.Error())
+			}
+			if !match {
+				continue
+			}
+		}
+
+		users = append(users, user)
+	}
+
+	startIndex, count := SCIMPage(c)
+	return RenderSCIM(c, http.StatusOK, scim.NewListResponse(users, startIndex, count))
+}
+
+func (h *Handler) SCIMGetUser(c echo.Context) error {
+	u, err := h.getSCIMUser(c.Param("id"))
+	if err != nil {
+		return h.renderSCIMUserNotFound(c, err)
+	}
+
+	return h.renderSCIMUser(c, http.StatusOK, u)
+}
+
+// SCIMAddUser creates the console user as an OpenID Connect user that is already approved,
+// the identity provider is the one that decides who can use the console
+func (h *Handler) SCIMAddUser(c echo.Context) error {
+	user := scim.User{}
+	if err := BindSCIM(c, &user); err != nil {
+		return RenderSCIMError(c, http.StatusBadRequest, scim.ErrorInvalidSyntax, err.Error())
+	}
+
+	uid := strings.TrimSpace(user.UserName)
+	if uid == "" {
+		return RenderSCIMError(c, http.StatusBadRequest, scim.ErrorInvalidValue, i18n.T(c.Request().Context(), "scim.username_required"))
+	}
+
+	exists, err := h.Model.UserExists(uid)
+	if err != nil {
+		return RenderSCIMError(c, http.StatusInternalServerError, "", err.Error())
+	}
+
+	if exists {
+		return RenderSCIMError(c, http.StatusConflict, scim.ErrorUniqueness, i18n.T(c.Request().Context(), "scim.user_exists", uid))
+	}
+
+	name := user.FullName()
+	if name == "" {
+		name = uid
+	}
+
+	if err := h.Model.AddOIDCUser(uid, name, user.Email(), user.Phone(), true, true); err != nil {
+		return RenderSCIMError(c, http.StatusInternalServerError, "", err.Error())
+	}
+
+	if err := h.Model.SaveSCIMUser(uid, user.ExternalID, user.IsActive()); err != nil {
+		return RenderSCIMError(c, http.StatusInternalServerError, "", err.Error())
+	}
+
+	h.Audit(c, AuditUserProvision, uid, "", "")
+
+	u, err := h.Model.GetUserById(uid)
+	if err != nil {
+		return RenderSCIMError(c, http.StatusInternalServerError, "", err.Error())
+	}
+
+	c.Response().Header().Set(echo.HeaderLocation, SCIMLocation(c, "Users", uid))
+	return h.renderSCIMUser(c, http.StatusCreated, u)
+}
+
+func (h *Handler) SCIMReplaceUser(c echo.Context) error {
+	u, err := h.getSCIMUser(c.Param("id"))
+	if err != nil {
+		return h.renderSCIMUserNotFound(c, err)
+	}
+
+	user := scim.User{}
+	if err := BindSCIM(c, &user); err != nil {
+		return RenderSCIMError(c, http.StatusBadRequest, scim.ErrorInvalidSyntax, err.Error())
+	}
+
+	return h.saveSCIMUser(c, u, user)
+}
+
+func (h *Handler) SCIMPatchUser(c echo.Context) error {
+	u, err := h.getSCIMUser(c.Param("id"))
+	if err != nil {
+		return h.renderSCIMUserNotFound(c, err)
+	}
+
+	patch := scim.PatchRequest{}
+	if err := BindSCIM(c, &patch); err != nil {
+		return RenderSCIMError(c, http.StatusBadRequest, scim.ErrorInvalidSyntax, err.Error())
+	}
+
+	user, err := h.SCIMUser(c, u)
+	if err != nil {
+		return RenderSCIMError(c, http.StatusInternalServerError, "", err.Error())
+	}
+
+	if err := user.ApplyPatch(patch.Operations); err != nil {
+		if errors.Is(err, scim.ErrInvalidPath) {
+			return RenderSCIMError(c, http.StatusBadRequest, scim.ErrorInvalidPath, err.Error())
+		}
+		return RenderSCIMError(c, http.StatusBadRequest, scim.ErrorInvalidValue, err.Error())
+	}
+
+	return h.saveSCIMUser(c, u, user)
+}
+
+// SCIMDeleteUser deletes the user the same way it's deleted from the users page
+func (h *Handler) SCIMDeleteUser(c echo.Context) error {
+	uid := c.Param("id")
+
+	if uid == "admin" || uid == "openuem" {
+		return RenderSCIMError(c, http.StatusBadRequest, scim.ErrorMutability, i18n.T(c.Request().Context(), "users.admin_cannot_be_removed"))
+	}
+
+	if _, err := h.getSCIMUser(uid); err != nil {
+		return h.renderSCIMUserNotFound(c, err)
+	}
+
+	if err := h.removeUser(c, uid); err != nil {
+		if errors.Is(err, models.ErrLastGlobalAdmin) {
+			return RenderSCIMError(c, http.StatusBadRequest, scim.ErrorMutability, i18n.T(c.Request().Context(), "roles.last_global_admin"))
+		}
+		return RenderSCIMError(c, http.StatusInternalServerError, "", err.Error())
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// SCIMUser returns the console user as a SCIM resource, the roles granted in every
+// tenant with SCIM are the groups of the user
+func (h *Handler) SCIMUser(c echo.Context, u *ent.User) (scim.User, error) {
+	active := true
+	externalID := ""
+
+	s, err := h.Model.GetSCIMUser(u.ID)
+	if err == nil {
+		active = s.Active
+		externalID = s.ExternalID
+	} else if !errors.Is(err, models.ErrSCIMUserNotFound) {
+		return scim.User{}, err
+	}
+
+	assignments, err := h.Model.GetRoleAssignmentsForUser(u.ID)
+	if err != nil {
+		return scim.User{}, err
+	}
+
+	groups := []scim.Member{}
+	for _, a := range assignments {
+		if a.Source == consoledb.RoleSourceSCIM && a.TenantID == -1 && a.SiteID == -1 {
+			groups = append(groups, scim.Member{Value: a.Role, Display: a.Role, Ref: SCIMLocation(c, "Groups", a.Role)})
+		}
+	}
+
+	user := scim.User{
+		Schemas:     []string{scim.SchemaUser},
+		ID:          u.ID,
+		ExternalID:  externalID,
+		UserName:    u.ID,
+		Name:        &scim.Name{Formatted: u.Name},
+		DisplayName: u.Name,
+		Active:      &active,
+		Groups:      groups,
+		Meta: &scim.Meta{
+			ResourceType: "User",
+			Created:      &u.Created,
+			LastModified: &u.Modified,
+			Location:     SCIMLocation(c, "Users", u.ID),
+		},
+	}
+
+	if u.Email != "" {
+		user.Emails = []scim.MultiValue{{Value: u.Email, Type: "work", Primary: true}}
+	}
+
+	if u.Phone != "" {
+		user.PhoneNumbers = []scim.MultiValue{{Value: u.Phone, Type: "work", Primary: true}}
+	}
+
+	return user, nil
+}
+
+// saveSCIMUser updates the console user with the attributes sent by the identity provider.
+// Deactivated users are signed out and can't sign in until they're activated again
+func (h *Handler) saveSCIMUser(c echo.Context, u *ent.User, user scim.User) error {
+	if user.UserName != "" && !strings.EqualFold(user.UserName, u.ID) {
+		return RenderSCIMError(c, http.StatusBadRequest, scim.ErrorMutability, i18n.T(c.Request().Context(), "scim.username_immutable"))
+	}
+
+	inactive, err := h.Model.IsSCIMUserInactive(u.ID)
+	if err != nil {
+		return RenderSCIMError(c, http.StatusInternalServerError, "", err.Error())
+	}
+
+	before := SCIMUserChanges{Name: u.Name, Email: u.Email, Phone: u.Phone, Active: !inactive}
+	after := SCIMUserChanges{Name: user.FullName(), Email: user.Email(), Phone: user.Phone(), Active: user.IsActive()}
+	if after.Name == "" {
+		after.Name = u.Name
+	}
+
+	if !after.Active && (u.ID == "admin" || u.ID == "openuem") {
+		return RenderSCIMError(c, http.StatusBadRequest, scim.ErrorMutability, i18n.T(c.Request().Context(), "scim.admin_cannot_be_deactivated"))
+	}
+
+	if err := h.Model.UpdateUser(u.ID, after.Name, after.Email, after.Phone, u.Country); err != nil {
+		return RenderSCIMError(c, http.StatusBadRequest, scim.ErrorInvalidValue, err.Error())
+	}
+
+	if err := h.Model.SaveSCIMUser(u.ID, user.ExternalID, after.Active); err != nil {
+		return RenderSCIMError(c, http.StatusInternalServerError, "", err.Error())
+	}
+
+	if before.Active && !after.Active {
+		if err := h.Model.DeleteUserSessions(u.ID); err != nil {
+			log.Printf("[ERROR]: could not delete the sessions of user %s deactivated by the identity provider, reason: %v", u.ID, err)
+		}
+
+		if h.AuthLogger != nil {
+			h.AuthLogger.Printf("user %s has been deactivated by the identity provider", u.ID)
+		}
+	}
+
+	h.AuditChanges(c, AuditUserUpdate, u.ID, before, after)
+
+	updated, err := h.Model.GetUserById(u.ID)
+	if err != nil {
+		return RenderSCIMError(c, http.StatusInternalServerError, "", err.Error())
+	}
+
+	return h.renderSCIMUser(c, http.StatusOK, updated)
+}
+
+func (h *Handler) renderSCIMUser(c echo.Context, code int, u *ent.User) error {
+	user, err := h.SCIMUser(c, u)
+	if err != nil {
+		return RenderSCIMError(c, http.StatusInternalServerError, "", err.Error())
+	}
+
+	return RenderSCIM(c, code, user)
+}
+
+// getSCIMUser returns the console user if it has been provisioned by the identity provider
+func (h *Handler) getSCIMUser(uid string) (*ent.User, error) {
+	if _, err := h.Model.GetSCIMUser(uid); err != nil {
+		return nil, err
+	}
+
+	return h.Model.GetUserById(uid)
+}
+
+func (h *Handler) renderSCIMUserNotFound(c echo.Context, err error) error {
+	if ent.IsNotFound(err) || errors.Is(err, models.ErrSCIMUserNotFound) {
+		return RenderSCIMError(c, http.StatusNotFound, "", i18n.T(c.Request().Context(), "scim.user_not_found", c.Param("id")))
+	}
+	return RenderSCIMError(c, http.StatusInternalServerError, "", err.Error())
+}
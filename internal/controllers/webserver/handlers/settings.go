@@ -30,6 +30,15 @@ func (h *Handler) GeneralSettings(c echo.Context) error {
 	}
 
 	if c.Request().Method == "POST" {
+		// Settings are saved one by one with several return paths, so the changes
+		// are recorded comparing the settings before and after the request
+		if before, err := h.Model.GetGeneralSettings(commonInfo.TenantID); err == nil {
+			defer func() {
+				if after, err := h.Model.GetGeneralSettings(commonInfo.TenantID); err == nil {
+					h.AuditChanges(c, AuditSettingsUpdate, "general", before, after)
+				}
+			}()
+		}
 
 		settings, err := validateGeneralSettings(c)
 		if err != nil {
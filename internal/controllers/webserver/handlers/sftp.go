@@ -230,6 +230,8 @@ func (h *Handler) DeleteItem(c echo.Context) error {
 		return RenderError(c, partials.ErrorMessage(err.Error(), false))
 	}
 
+	h.Audit(c, AuditFileDelete, auditAgent(agent), path, "")
+
 	files, err := client.ReadDir(cwd)
 	if err != nil {
 		return RenderError(c, partials.ErrorMessage(err.Error(), false))
@@ -393,6 +395,7 @@ func (h *Handler) DeleteMany(c echo.Context) error {
 		if err := client.RemoveAll(path); err != nil {
 			return RenderError(c, partials.ErrorMessage(err.Error(), false))
 		}
+		h.Audit(c, AuditFileDelete, auditAgent(agent), path, "")
 	}
 
 	files, err := client.ReadDir(cwd)
@@ -346,6 +346,8 @@ func (h *Handler) DeleteSite(c echo.Context) error {
 		return h.ListSites(c, "", i18n.T(c.Request().Context(), "sites.delete_error", err.Error()), false)
 	}
 
+	h.Audit(c, AuditSiteDelete, s.Description, "", "")
+
 	successMessage := i18n.T(c.Request().Context(), "sites.deleted")
 	return h.ListSites(c, successMessage, "", false)
 }
@@ -47,10 +47,19 @@ func (h *Handler) SMTPSettings(c echo.Context) error {
 			}
 		}
 
+		before, err := h.Model.GetSMTPSettings(commonInfo.TenantID)
+		if err != nil {
+			return RenderError(c, partials.ErrorMessage(err.Error(), false))
+		}
+
 		if err := h.Model.UpdateSMTPSettings(settings); err != nil {
 			return RenderError(c, partials.ErrorMessage(err.Error(), false))
 		}
 
+		if after, err := h.Model.GetSMTPSettings(commonInfo.TenantID); err == nil {
+			h.AuditChanges(c, AuditSMTPUpdate, "smtp", before, after)
+		}
+
 		// Notification Worker must reload its smtp settings
 		if h.NATSConnection == nil || !h.NATSConnection.IsConnected() {
 			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "nats.not_connected"), false))
@@ -175,6 +184,68 @@ func validateSMTPSettings(c echo.Context) (*models.SMTPSettings, error) {
 }
 
 func (h *Handler) SendEmailTest(settings *models.SMTPSettings, to string) error {
+	c, err := h.newMailClient(settings)
+	if err != nil {
+		return err
+	}
+
+	m := mail.NewMsg()
+	if err := m.From(settings.MailFrom); err != nil {
+		return err
+	}
+	if err := m.To(to); err != nil {
+		return err
+	}
+	m.Subject("This is a test email from OpenUEM")
+
+	return c.DialAndSend(m)
+}
+
+// SendEmail sends a plain text e-mail, with the files as attachments, using the SMTP
+// settings of a tenant, -1 uses the global settings
+func (h *Handler) SendEmail(tenantID string, to []string, subject, body string, attachments ...string) error {
+	s, err := h.Model.GetSMTPSettings(tenantID)
+	if err != nil {
+		return err
+	}
+
+	if s.SMTPServer == "" || s.MessageFrom == "" {
+		return fmt.Errorf("SMTP settings are not configured")
+	}
+
+	settings := &models.SMTPSettings{
+		ID:             s.ID,
+		Server:         s.SMTPServer,
+		Port:           s.SMTPPort,
+		User:           s.SMTPUser,
+		Password:       s.SMTPPassword,
+		Auth:           s.SMTPAuth,
+		MailFrom:       s.MessageFrom,
+		EncryptionType: string(s.SMTPEncryptionType),
+	}
+
+	c, err := h.newMailClient(settings)
+	if err != nil {
+		return err
+	}
+
+	m := mail.NewMsg()
+	if err := m.From(settings.MailFrom); err != nil {
+		return err
+	}
+	if err := m.To(to...); err != nil {
+		return err
+	}
+	m.Subject(subject)
+	m.SetBodyString(mail.TypeTextPlain, body)
+	for _, path := range attachments {
+		m.AttachFile(path)
+	}
+
+	return c.DialAndSend(m)
+}
+
+func (h *Handler) newMailClient(settings *models.SMTPSettings) (*mail.Client, error) {
 	var err error
 	var c *mail.Client
 	if settings.Auth == "NOAUTH" || (settings.User == "" && settings.Password == "") {
@@ -189,13 +260,13 @@ func (h *Handler) SendEmailTest(settings *models.SMTPSettings, to string) error
 			if h.EncryptionMasterKey != "" {
 				isSMTPPasswordEncrypted, err := utils.IsSensitiveFieldEncrypted(settings.Password, h.EncryptionMasterKey)
 				if err != nil {
-					return err
+					return nil, err
 				}
 
 				if isSMTPPasswordEncrypted {
 					smtpPassword, err = utils.DecryptSensitiveField(settings.Password, h.EncryptionMasterKey)
 					if err != nil {
-						return err
+						return nil, err
 					}
 				}
 			}
@@ -204,7 +275,7 @@ func (h *Handler) SendEmailTest(settings *models.SMTPSettings, to string) error
 			mail.WithUsername(settings.User), mail.WithPassword(smtpPassword))
 	}
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if settings.EncryptionType == string(smtpsettings.SMTPEncryptionTypeSmtps) {
@@ -215,14 +286,5 @@ func (h *Handler) SendEmailTest(settings *models.SMTPSettings, to string) error
 		c.SetTLSPortPolicy(mail.TLSMandatory)
 	}
 
-	m := mail.NewMsg()
-	if err := m.From(settings.MailFrom); err != nil {
-		return err
-	}
-	if err := m.To(to); err != nil {
-		return err
-	}
-	m.Subject("This is a test email from OpenUEM")
-
-	return c.DialAndSend(m)
+	return c, nil
 }
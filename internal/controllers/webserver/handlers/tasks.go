@@ -10,6 +10,7 @@ import (
 
 	"github.com/invopop/ctxi18n/i18n"
 	"github.com/labstack/echo/v4"
+	"github.com/open-uem/ent"
 	"github.com/open-uem/ent/task"
 	"github.com/open-uem/openuem-console/internal/models"
 	"github.com/open-uem/openuem-console/internal/views/partials"
@@ -167,8 +168,7 @@ func (h *Handler) CloneTask(c echo.Context) error {
 		return RenderError(c, partials.ErrorMessage(fmt.Sprintf("%s : %v", i18n.T(c.Request().Context(), "tasks.edit.no_profile"), err), true))
 	}
 
-	// TODO-Steve we must filter which profiles are available for a role
-	allProfiles, err := h.Model.GetAllProfiles()
+	allProfiles, err := h.Model.GetAllProfiles(commonInfo)
 	if err != nil {
 		return RenderError(c, partials.ErrorMessage(fmt.Sprintf("%s : %v", i18n.T(c.Request().Context(), "tasks.clone.all_profiles_error"), err), true))
 	}
@@ -194,6 +194,10 @@ func (h *Handler) CloneTask(c echo.Context) error {
 			return RenderError(c, partials.ErrorMessage(fmt.Sprintf("%s : %v", i18n.T(c.Request().Context(), "tasks.edit.invalid_profile"), err), true))
 		}
 
+		if !slices.ContainsFunc(allProfiles, func(p *ent.Profile) bool { return p.ID == profileID }) {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "roles.forbidden"), true))
+		}
+
 		lastTask, err := h.Model.GetLasTaskOrderInProfile(profileID)
 		if err != nil {
 			return RenderError(c, partials.ErrorMessage(fmt.Sprintf("%s : %v", i18n.T(c.Request().Context(), "tasks.edit.invalid_profile"), err), true))
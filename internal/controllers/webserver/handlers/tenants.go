@@ -6,12 +6,14 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/invopop/ctxi18n/i18n"
 	"github.com/labstack/echo/v4"
+	"github.com/open-uem/ent"
 	"github.com/open-uem/openuem-console/internal/views/admin_views"
 	"github.com/open-uem/openuem-console/internal/views/filters"
 	"github.com/open-uem/openuem-console/internal/views/partials"
@@ -316,6 +318,8 @@ func (h *Handler) DeleteTenant(c echo.Context) error {
 		return h.ListTenants(c, "", i18n.T(c.Request().Context(), "tenants.delete_error", err.Error()), false)
 	}
 
+	h.Audit(c, AuditTenantDelete, t.Description, "", "")
+
 	successMessage := i18n.T(c.Request().Context(), "tenants.deleted")
 	return h.ListTenants(c, successMessage, "", false)
 }
@@ -400,10 +404,16 @@ func (h *Handler) GetTenantSites(c echo.Context) error {
 		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "tenants.could_not_convert_to_int", err.Error()), true))
 	}
 
+	access, err := h.GetUserAccess(c)
+	if err != nil || !access.CanAccessTenant(tenantID) {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "roles.forbidden"), true))
+	}
+
 	sites, err := h.Model.GetSites(tenantID)
 	if err != nil {
 		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "agents.could_not_get_sites"), true))
 	}
+	sites = slices.DeleteFunc(sites, func(s *ent.Site) bool { return !access.CanAccessSite(tenantID, s.ID) })
 
 	return RenderView(c, profiles_views.SitesSelect(sites))
 }
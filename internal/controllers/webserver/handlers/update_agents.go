@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"strconv"
@@ -14,6 +15,8 @@ import (
 	openuem_ent "github.com/open-uem/ent"
 	"github.com/open-uem/ent/release"
 	openuem_nats "github.com/open-uem/nats"
+	"github.com/open-uem/openuem-console/internal/maintenance"
+	"github.com/open-uem/openuem-console/internal/models"
 	"github.com/open-uem/openuem-console/internal/views/admin_views"
 	"github.com/open-uem/openuem-console/internal/views/filters"
 	"github.com/open-uem/openuem-console/internal/views/partials"
@@ -60,28 +63,7 @@ func (h *Handler) UpdateAgents(c echo.Context) error {
 				return RenderError(c, partials.ErrorMessage(err.Error(), false))
 			}
 
-			arch := ""
-			switch agentInfo.Edges.Computer.ProcessorArch {
-			case "x64", "x86_64":
-				arch = "amd64"
-			case "aarch64":
-				arch = "arm64"
-			}
-
-			switch agentInfo.Os {
-			case "debian", "ubuntu", "opensuse-leap", "linuxmint", "fedora", "manjaro", "arch", "almalinux", "rocky", "neon":
-				agentInfo.Os = "linux"
-			case "macOS":
-				agentInfo.Os = "darwin"
-				macArch := strings.TrimSpace(agentInfo.Edges.Computer.ProcessorArch)
-				if macArch == "x86_64" {
-					arch = "amd64"
-				} else {
-					arch = "arm64"
-				}
-			}
-
-			releaseToBeApplied, err := h.Model.GetAgentsReleaseByType(release.ReleaseTypeAgent, channel, agentInfo.Os, arch, sr)
+			releaseToBeApplied, err := h.agentUpdateRelease(agentInfo, channel, sr)
 			if err != nil {
 				log.Printf("[ERROR]: could not get release to be applied, reason: %v\n", err)
 				errorMessage = err.Error()
@@ -109,33 +91,32 @@ func (h *Handler) UpdateAgents(c echo.Context) error {
 				}
 			}
 
-			data, err := json.Marshal(updateRequest)
-			if err != nil {
-				errorMessage = err.Error()
-				if err := h.Model.SaveAgentUpdateInfo(a, "admin.update.agents.task_status_error", "admin.update.agents.task_status_error", releaseToBeApplied.Version, commonInfo); err != nil {
-					log.Println("[ERROR]: could not save update task info")
-				}
-				continue
+			at := time.Now()
+			if !updateRequest.UpdateNow {
+				at = updateRequest.UpdateAt
 			}
 
-			if h.NATSConnection == nil || !h.NATSConnection.IsConnected() {
-				errorMessage = i18n.T(c.Request().Context(), "nats.not_connected")
-				if err := h.Model.SaveAgentUpdateInfo(a, "admin.update.agents.task_status_error", "nats.not_connected", releaseToBeApplied.Version, commonInfo); err != nil {
-					log.Println("[ERROR]: could not save update task info")
-				}
+			// a queued update is applied as soon as the maintenance window opens
+			queuedRequest := updateRequest
+			queuedRequest.UpdateNow = true
+			queuedRequest.UpdateAt = time.Time{}
+
+			next, err := h.queueForMaintenance(c, agentInfo, maintenance.ActionAgentUpdate, queuedRequest, releaseToBeApplied.Version, at, commonInfo)
+			if err != nil {
+				log.Printf("[ERROR]: could not queue the update of agent %s, reason: %v\n", a, err)
+				errorMessage = err.Error()
 				continue
 			}
-
-			if _, err := h.JetStream.Publish(context.Background(), "agent.update."+a, data); err != nil {
-				errorMessage = i18n.T(c.Request().Context(), "admin.update.agents.cannot_send_request")
-				if err := h.Model.SaveAgentUpdateInfo(a, "admin.update.agents.task_status_error", "admin.update.agents.cannot_send_request", releaseToBeApplied.Version, commonInfo); err != nil {
+			if !next.IsZero() {
+				description := i18n.T(c.Request().Context(), "maintenance.update_queued", releaseToBeApplied.Version, commonInfo.Translator.FmtDateMedium(next.Local())+" "+commonInfo.Translator.FmtTimeShort(next.Local()))
+				if err := h.Model.SaveAgentUpdateInfo(a, models.AgentUpdateTaskPending, description, releaseToBeApplied.Version, commonInfo); err != nil {
 					log.Println("[ERROR]: could not save update task info")
 				}
 				continue
 			}
 
-			if err := h.Model.SaveAgentUpdateInfo(a, "admin.update.agents.task_status_pending", i18n.T(c.Request().Context(), "admin.update.agents.task_update", releaseToBeApplied.Version), releaseToBeApplied.Version, commonInfo); err != nil {
-				log.Println("[ERROR]: could not save update task info")
+			if err := h.publishAgentUpdate(c.Request().Context(), a, updateRequest, commonInfo); err != nil {
+				errorMessage = err.Error()
 				continue
 			}
 		}
@@ -295,3 +276,68 @@ func (h *Handler) ShowUpdateAgentList(c echo.Context, r *openuem_ent.Release, su
 
 	return RenderView(c, admin_views.UpdateAgentsIndex(" | Update Agents", admin_views.UpdateAgents(c, p, f, agents, settings, r, higherVersion, allReleases, availableReleases, availableTaskStatus, appliedTags, refreshTime, itemsPerPage, successMessage, errorMessage, agentsExists, serversExists, commonInfo, h.GetAdminTenantName(commonInfo)), commonInfo))
 }
+
+// agentUpdateRelease returns the release of the version that matches the OS and the
+// processor architecture of the agent
+func (h *Handler) agentUpdateRelease(agentInfo *openuem_ent.Agent, channel, version string) (*openuem_ent.Release, error) {
+	arch := ""
+	os := agentInfo.Os
+	processorArch := ""
+	if agentInfo.Edges.Computer != nil {
+		processorArch = agentInfo.Edges.Computer.ProcessorArch
+	}
+
+	switch processorArch {
+	case "x64", "x86_64":
+		arch = "amd64"
+	case "aarch64":
+		arch = "arm64"
+	}
+
+	switch agentInfo.Os {
+	case "debian", "ubuntu", "opensuse-leap", "linuxmint", "fedora", "manjaro", "arch", "almalinux", "rocky", "neon":
+		os = "linux"
+	case "macOS":
+		os = "darwin"
+		macArch := strings.TrimSpace(processorArch)
+		if macArch == "x86_64" {
+			arch = "amd64"
+		} else {
+			arch = "arm64"
+		}
+	}
+
+	return h.Model.GetAgentsReleaseByType(release.ReleaseTypeAgent, channel, os, arch, version)
+}
+
+// publishAgentUpdate sends the update request to the agent and saves the status of its
+// update task. The error returned is translated with the locale of ctx
+func (h *Handler) publishAgentUpdate(ctx context.Context, agentID string, updateRequest openuem_nats.OpenUEMUpdateRequest, commonInfo *partials.CommonInfo) error {
+	data, err := json.Marshal(updateRequest)
+	if err != nil {
+		if err := h.Model.SaveAgentUpdateInfo(agentID, models.AgentUpdateTaskError, models.AgentUpdateTaskError, updateRequest.Version, commonInfo); err != nil {
+			log.Println("[ERROR]: could not save update task info")
+		}
+		return err
+	}
+
+	if h.NATSConnection == nil || !h.NATSConnection.IsConnected() {
+		if err := h.Model.SaveAgentUpdateInfo(agentID, models.AgentUpdateTaskError, "nats.not_connected", updateRequest.Version, commonInfo); err != nil {
+			log.Println("[ERROR]: could not save update task info")
+		}
+		return errors.New(i18n.T(ctx, "nats.not_connected"))
+	}
+
+	if _, err := h.JetStream.Publish(context.Background(), "agent.update."+agentID, data); err != nil {
+		if err := h.Model.SaveAgentUpdateInfo(agentID, models.AgentUpdateTaskError, "admin.update.agents.cannot_send_request", updateRequest.Version, commonInfo); err != nil {
+			log.Println("[ERROR]: could not save update task info")
+		}
+		return errors.New(i18n.T(ctx, "admin.update.agents.cannot_send_request"))
+	}
+
+	if err := h.Model.SaveAgentUpdateInfo(agentID, models.AgentUpdateTaskPending, i18n.T(ctx, "admin.update.agents.task_update", updateRequest.Version), updateRequest.Version, commonInfo); err != nil {
+		log.Println("[ERROR]: could not save update task info")
+	}
+
+	return nil
+}
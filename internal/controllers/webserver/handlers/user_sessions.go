@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/invopop/ctxi18n/i18n"
+	"github.com/labstack/echo/v4"
+	"github.com/open-uem/openuem-console/internal/models"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+)
+
+// sessionSeenInterval is how often the device and last time a session was seen are saved
+const sessionSeenInterval = time.Minute
+
+// TrackSession records the device the current session is used from. The first time a session
+// is seen the oldest sessions of the user are signed out if the user has more sessions than allowed
+func (h *Handler) TrackSession(c echo.Context, userID string) {
+	token := h.SessionManager.Manager.Token(c.Request().Context())
+	if token == "" {
+		return
+	}
+
+	hash := models.SessionTokenHash(token)
+	now := time.Now()
+	if last, ok := h.sessionsSeen.Load(hash); ok && now.Sub(last.(time.Time)) < sessionSeenInterval {
+		return
+	}
+
+	h.sessionsSeen.Range(func(key, value any) bool {
+		if now.Sub(value.(time.Time)) >= sessionSeenInterval {
+			h.sessionsSeen.Delete(key)
+		}
+		return true
+	})
+	h.sessionsSeen.Store(hash, now)
+
+	created, err := h.Model.TouchSession(token, userID, c.Request().UserAgent(), c.RealIP())
+	if err != nil {
+		log.Printf("[ERROR]: could not record the session of user %s, reason: %v", userID, err)
+		return
+	}
+
+	if !created {
+		return
+	}
+
+	maxSessions, err := h.Model.GetMaxUserSessions()
+	if err != nil {
+		log.Printf("[ERROR]: could not get the maximum number of sessions, reason: %v", err)
+		return
+	}
+
+	evicted, err := h.Model.EvictUserSessions(userID, hash, maxSessions, h.EncryptionMasterKey)
+	if err != nil {
+		log.Printf("[ERROR]: could not sign out the oldest sessions of user %s, reason: %v", userID, err)
+	}
+
+	for _, s := range evicted {
+		h.sessionsSeen.Delete(s.TokenHash)
+	}
+
+	if len(evicted) > 0 && h.AuthLogger != nil {
+		h.AuthLogger.Printf("user %s has been signed out of %d sessions as only %d sessions are allowed", userID, len(evicted), maxSessions)
+	}
+}
+
+func (h *Handler) MyAccountSessionDelete(c echo.Context) error {
+	hash := c.Param("hash")
+	if hash == "" {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "user_sessions.not_found"), true))
+	}
+
+	return RenderConfirm(c, partials.ConfirmDelete(c, i18n.T(c.Request().Context(), "user_sessions.confirm_delete"), "/myaccount", fmt.Sprintf("/myaccount/sessions/%s", hash)))
+}
+
+// MyAccountSessionConfirmDelete signs the user out of another browser or device,
+// the current session is closed with the log out button
+func (h *Handler) MyAccountSessionConfirmDelete(c echo.Context) error {
+	username := h.SessionManager.Manager.GetString(c.Request().Context(), "uid")
+	if username == "" {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "login.username_empty"), true))
+	}
+
+	hash := c.Param("hash")
+	if hash == models.SessionTokenHash(h.SessionManager.Manager.Token(c.Request().Context())) {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "user_sessions.current_not_deleted"), true))
+	}
+
+	if err := h.Model.DeleteUserSession(username, hash, h.EncryptionMasterKey); err != nil {
+		if errors.Is(err, models.ErrUserSessionNotFound) {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "user_sessions.not_found"), true))
+		}
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "user_sessions.could_not_delete", err.Error()), true))
+	}
+	h.sessionsSeen.Delete(hash)
+
+	if h.AuthLogger != nil {
+		h.AuthLogger.Printf("user %s has signed out of a session from its account page", username)
+	}
+
+	return h.RenderMyAccount(c, username, "", i18n.T(c.Request().Context(), "user_sessions.deleted"))
+}
+
+// MyAccountSignOutEverywhere closes every session of the user, including the current one
+func (h *Handler) MyAccountSignOutEverywhere(c echo.Context) error {
+	username := h.SessionManager.Manager.GetString(c.Request().Context(), "uid")
+	if username == "" {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "login.username_empty"), true))
+	}
+
+	if err := h.Model.DeleteUserSessions(username); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "user_sessions.could_not_delete", err.Error()), true))
+	}
+
+	if h.AuthLogger != nil {
+		h.AuthLogger.Printf("user %s has signed out of every session", username)
+	}
+
+	return h.Logout(c)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -16,6 +17,8 @@ import (
 	"github.com/labstack/echo/v4"
 	openuem_ent "github.com/open-uem/ent"
 	openuem_nats "github.com/open-uem/nats"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/models"
 	"github.com/open-uem/openuem-console/internal/views/admin_views"
 	"github.com/open-uem/openuem-console/internal/views/filters"
 	"github.com/open-uem/openuem-console/internal/views/partials"
@@ -41,54 +44,7 @@ func (h *Handler) ListUsers(c echo.Context, successMessage, errMessage string) e
 		return err
 	}
 
-	f := filters.UserFilter{}
-
-	usernameFilter := c.FormValue("filterByUsername")
-	if usernameFilter != "" {
-		f.Username = usernameFilter
-	}
-
-	nameFilter := c.FormValue("filterByName")
-	if nameFilter != "" {
-		f.Name = nameFilter
-	}
-
-	emailFilter := c.FormValue("filterByEmail")
-	if emailFilter != "" {
-		f.Email = emailFilter
-	}
-
-	phoneFilter := c.FormValue("filterByPhone")
-	if phoneFilter != "" {
-		f.Phone = phoneFilter
-	}
-
-	createdFrom := c.FormValue("filterByCreatedDateFrom")
-	if createdFrom != "" {
-		f.CreatedFrom = createdFrom
-	}
-	createdTo := c.FormValue("filterByCreatedDateTo")
-	if createdTo != "" {
-		f.CreatedTo = createdTo
-	}
-
-	modifiedFrom := c.FormValue("filterByModifiedDateFrom")
-	if modifiedFrom != "" {
-		f.ModifiedFrom = modifiedFrom
-	}
-	modifiedTo := c.FormValue("filterByModifiedDateTo")
-	if modifiedTo != "" {
-		f.ModifiedTo = modifiedTo
-	}
-
-	filteredRegisterStatus := []string{}
-	for index := range openuem_nats.RegisterPossibleStatus() {
-		value := c.FormValue(fmt.Sprintf("filterByRegisterStatus%d", index))
-		if value != "" {
-			filteredRegisterStatus = append(filteredRegisterStatus, value)
-		}
-	}
-	f.RegisterOptions = filteredRegisterStatus
+	f := h.GetUserFilters(c)
 
 	itemsPerPage, err := h.Model.GetDefaultItemsPerPage()
 	if err != nil {
@@ -129,7 +85,25 @@ func (h *Handler) ListUsers(c echo.Context, successMessage, errMessage string) e
 
 	warnAboutSMTP := h.Model.IsPasswdAuthEnabled() && !h.Model.IsSMTPConfigured()
 
-	return RenderView(c, admin_views.UsersIndex(" | Users", admin_views.Users(c, p, f, users, successMessage, errMessage, refreshTime, itemsPerPage, agentsExists, serversExists, warnAboutSMTP, commonInfo), commonInfo))
+	directoryUsers := map[string]consoledb.DirectoryUser{}
+	managed, err := h.Model.GetDirectoryUsers()
+	if err != nil {
+		log.Printf("[ERROR]: could not get the users managed by the directory, reason: %v", err)
+	}
+	for _, u := range managed {
+		directoryUsers[u.UserID] = u
+	}
+
+	scimUsers := map[string]consoledb.SCIMUser{}
+	provisioned, err := h.Model.GetSCIMUsers()
+	if err != nil {
+		log.Printf("[ERROR]: could not get the users managed by the identity provider, reason: %v", err)
+	}
+	for _, u := range provisioned {
+		scimUsers[u.UserID] = u
+	}
+
+	return RenderView(c, admin_views.UsersIndex(" | Users", admin_views.Users(c, p, f, users, directoryUsers, scimUsers, successMessage, errMessage, refreshTime, itemsPerPage, agentsExists, serversExists, warnAboutSMTP, commonInfo), commonInfo))
 }
 
 func (h *Handler) NewUser(c echo.Context) error {
@@ -291,32 +265,63 @@ func (h *Handler) DeleteUser(c echo.Context) error {
 		return RenderError(c, partials.ErrorMessage(err.Error(), false))
 	}
 
-	// Delete user
-	if err := h.Model.DeleteUser(uid); err != nil {
+	if err := h.removeUser(c, uid); err != nil {
+		if errors.Is(err, models.ErrLastGlobalAdmin) {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "roles.last_global_admin"), false))
+		}
 		return RenderError(c, partials.ErrorMessage(err.Error(), false))
 	}
 
-	// Revoke certificate
+	successMessage := i18n.T(c.Request().Context(), "users.deleted")
+	return h.ListUsers(c, successMessage, "")
+}
+
+// removeUser deletes the user, its roles, API tokens, security keys and sessions and revokes its certificate
+func (h *Handler) removeUser(c echo.Context, uid string) error {
+	if err := h.Model.DeleteRoleAssignmentsForUser(uid); err != nil {
+		return err
+	}
+
+	if err := h.Model.DeleteAPITokensForUser(uid); err != nil {
+		return err
+	}
+
+	if err := h.Model.ResetWebAuthnCredentials(uid); err != nil {
+		return err
+	}
+
+	if err := h.Model.DeleteUserSessions(uid); err != nil {
+		return err
+	}
+
+	if err := h.Model.DeleteUser(uid); err != nil {
+		return err
+	}
+
+	if err := h.Model.DeleteDirectoryUser(uid); err != nil {
+		log.Printf("[ERROR]: could not delete the directory information of user %s, reason: %v", uid, err)
+	}
+
+	if err := h.Model.DeleteSCIMUser(uid); err != nil {
+		log.Printf("[ERROR]: could not delete the provisioning information of user %s, reason: %v", uid, err)
+	}
+
+	h.Audit(c, AuditUserDelete, uid, "", "")
+
 	cert, err := h.Model.GetCertificateByUID(uid)
 	if err != nil {
-		if !openuem_ent.IsNotFound(err) {
-			return RenderError(c, partials.ErrorMessage(err.Error(), false))
+		if openuem_ent.IsNotFound(err) {
+			return nil
 		}
-		successMessage := i18n.T(c.Request().Context(), "users.deleted")
-		return h.ListUsers(c, successMessage, "")
+		return err
 	}
 
 	if err := h.Model.RevokeCertificate(cert, "user has been deleted", ocsp.CessationOfOperation); err != nil {
-		return RenderError(c, partials.ErrorMessage(err.Error(), false))
+		return err
 	}
 
 	// Delete certificate information
-	if err := h.Model.DeleteCertificate(cert.ID); err != nil {
-		return RenderError(c, partials.ErrorMessage(err.Error(), false))
-	}
-
-	successMessage := i18n.T(c.Request().Context(), "users.deleted")
-	return h.ListUsers(c, successMessage, "")
+	return h.Model.DeleteCertificate(cert.ID)
 }
 
 func (h *Handler) RenewUserCertificate(c echo.Context) error {
@@ -613,3 +618,57 @@ func (h *Handler) ImportUsers(c echo.Context) error {
 
 	return h.ListUsers(c, i18n.T(c.Request().Context(), "users.import_success"), "")
 }
+
+// GetUserFilters reads the filters applied to the users list
+func (h *Handler) GetUserFilters(c echo.Context) filters.UserFilter {
+	f := filters.UserFilter{}
+
+	usernameFilter := c.FormValue("filterByUsername")
+	if usernameFilter != "" {
+		f.Username = usernameFilter
+	}
+
+	nameFilter := c.FormValue("filterByName")
+	if nameFilter != "" {
+		f.Name = nameFilter
+	}
+
+	emailFilter := c.FormValue("filterByEmail")
+	if emailFilter != "" {
+		f.Email = emailFilter
+	}
+
+	phoneFilter := c.FormValue("filterByPhone")
+	if phoneFilter != "" {
+		f.Phone = phoneFilter
+	}
+
+	createdFrom := c.FormValue("filterByCreatedDateFrom")
+	if createdFrom != "" {
+		f.CreatedFrom = createdFrom
+	}
+	createdTo := c.FormValue("filterByCreatedDateTo")
+	if createdTo != "" {
+		f.CreatedTo = createdTo
+	}
+
+	modifiedFrom := c.FormValue("filterByModifiedDateFrom")
+	if modifiedFrom != "" {
+		f.ModifiedFrom = modifiedFrom
+	}
+	modifiedTo := c.FormValue("filterByModifiedDateTo")
+	if modifiedTo != "" {
+		f.ModifiedTo = modifiedTo
+	}
+
+	filteredRegisterStatus := []string{}
+	for index := range openuem_nats.RegisterPossibleStatus() {
+		value := c.FormValue(fmt.Sprintf("filterByRegisterStatus%d", index))
+		if value != "" {
+			filteredRegisterStatus = append(filteredRegisterStatus, value)
+		}
+	}
+	f.RegisterOptions = filteredRegisterStatus
+
+	return f
+}
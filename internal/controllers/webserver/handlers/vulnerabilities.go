@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"log"
+	"strconv"
+
+	"github.com/invopop/ctxi18n/i18n"
+	"github.com/labstack/echo/v4"
+	"github.com/open-uem/openuem-console/internal/views/computers_views"
+	"github.com/open-uem/openuem-console/internal/views/filters"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+	"github.com/open-uem/openuem-console/internal/views/vulnerabilities_views"
+	"github.com/open-uem/openuem-console/internal/vulnerabilities"
+)
+
+func (h *Handler) VulnerabilitiesDashboard(c echo.Context) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	errMessage := ""
+
+	itemsPerPage, err := h.Model.GetDefaultItemsPerPage()
+	if err != nil {
+		log.Println("[ERROR]: could not get items per page from database")
+		itemsPerPage = 5
+	}
+
+	p := partials.NewPaginationAndSort(itemsPerPage)
+	p.GetPaginationAndSortParams(c.FormValue("page"), c.FormValue("pageSize"), c.FormValue("sortBy"), c.FormValue("sortOrder"), c.FormValue("currentSortBy"), itemsPerPage)
+
+	f := getVulnerabilityFindingFilter(c)
+
+	feed, err := h.Model.GetVulnerabilityFeed()
+	if err != nil {
+		errMessage = i18n.T(c.Request().Context(), "vulnerabilities.could_not_get_feed", err.Error())
+	}
+
+	summary, err := h.Model.GetVulnerabilitySummary(commonInfo)
+	if err != nil {
+		errMessage = i18n.T(c.Request().Context(), "vulnerabilities.could_not_get_results", err.Error())
+	}
+
+	exposed, err := h.Model.GetMostExposedSoftware(commonInfo, 10)
+	if err != nil {
+		errMessage = i18n.T(c.Request().Context(), "vulnerabilities.could_not_get_results", err.Error())
+	}
+
+	p.NItems, err = h.Model.CountVulnerabilityFindings(f, commonInfo)
+	if err != nil {
+		errMessage = i18n.T(c.Request().Context(), "vulnerabilities.could_not_get_results", err.Error())
+	}
+
+	findings, err := h.Model.GetVulnerabilityFindingsByPage(p, f, commonInfo)
+	if err != nil {
+		errMessage = i18n.T(c.Request().Context(), "vulnerabilities.could_not_get_results", err.Error())
+	}
+
+	return RenderView(c, vulnerabilities_views.VulnerabilitiesIndex(" | Vulnerabilities", vulnerabilities_views.VulnerabilitiesDashboard(c, p, f, h.VulnerabilityFeedDir != "", feed, summary, exposed, findings, errMessage, itemsPerPage, commonInfo), commonInfo))
+}
+
+func (h *Handler) ComputerVulnerabilities(c echo.Context) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	agentId := c.Param("uuid")
+
+	if agentId == "" {
+		return RenderView(c, computers_views.InventoryIndex(" | Inventory", partials.Error(c, "an error occurred getting uuid param", "Computer", partials.GetNavigationUrl(commonInfo, "/computers"), commonInfo), commonInfo))
+	}
+
+	agent, err := h.Model.GetAgentById(agentId, commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "agents.could_not_get_agent"), false))
+	}
+
+	findings, err := h.Model.GetAgentVulnerabilities(agentId)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "vulnerabilities.could_not_get_results", err.Error()), true))
+	}
+
+	confirmDelete := c.QueryParam("delete") != ""
+	p := partials.PaginationAndSort{}
+
+	tenantID, err := strconv.Atoi(commonInfo.TenantID)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "tenants.could_not_convert_to_int", err.Error()), true))
+	}
+	settings, err := h.Model.GetNetbirdSettings(tenantID)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "netbird.could_not_get_settings", err.Error()), true))
+	}
+	netbird := settings.AccessToken != ""
+
+	offline := h.IsAgentOffline(c)
+
+	return RenderView(c, computers_views.InventoryIndex(" | Inventory", computers_views.Vulnerabilities(c, p, agent, findings, confirmDelete, commonInfo, netbird, offline), commonInfo))
+}
+
+func getVulnerabilityFindingFilter(c echo.Context) filters.VulnerabilityFindingFilter {
+	return filters.VulnerabilityFindingFilter{
+		Hostname:      c.FormValue("filterByHostname"),
+		Vulnerability: c.FormValue("filterByVulnerability"),
+		Software:      c.FormValue("filterBySoftware"),
+		Severities:    filteredOptions(c, "Severity", "vulnerabilities.severity_", vulnerabilities.Severities),
+	}
+}
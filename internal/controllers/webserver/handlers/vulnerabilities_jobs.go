@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"log"
+	"time"
+
+	"github.com/go-co-op/gocron/v2"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/models"
+	"github.com/open-uem/openuem-console/internal/vulnerabilities"
+)
+
+const vulnerabilityAgentsBatch = 100
+
+// StartVulnerabilitiesJob schedules the job that loads the offline vulnerability feed
+// and scans the software of the agents. It runs right away so the feed is ready
+// without waiting for the first interval
+func (h *Handler) StartVulnerabilitiesJob() error {
+	if h.VulnerabilityFeedDir == "" {
+		log.Println("[INFO]: no vulnerability feed folder has been set, the installed software won't be scanned")
+		return nil
+	}
+
+	if _, err := h.TaskScheduler.NewJob(
+		gocron.DurationJob(vulnerabilities.RefreshInterval),
+		gocron.NewTask(h.ScanVulnerabilities),
+		gocron.WithSingletonMode(gocron.LimitModeReschedule),
+		gocron.WithStartAt(gocron.WithStartImmediately()),
+	); err != nil {
+		log.Printf("[ERROR]: could not schedule the job that scans vulnerabilities, reason: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// ScanVulnerabilities matches the software of the agents of every tenant against the feed
+func (h *Handler) ScanVulnerabilities() {
+	feed := h.loadVulnerabilityFeed()
+	if feed == nil {
+		return
+	}
+
+	tenants, err := h.Model.GetTenants()
+	if err != nil {
+		log.Printf("[ERROR]: could not get the tenants to scan vulnerabilities, reason: %v", err)
+		return
+	}
+
+	for _, t := range tenants {
+		h.scanTenantVulnerabilities(t.ID, feed)
+	}
+}
+
+// loadVulnerabilityFeed reads the feed folder when its files have changed. If the folder
+// can't be read the previous feed is used, and nil is returned if there isn't one
+func (h *Handler) loadVulnerabilityFeed() *vulnerabilities.Feed {
+	fingerprint, err := vulnerabilities.Fingerprint(h.VulnerabilityFeedDir)
+	if err == nil && h.vulnerabilityFeed != nil && fingerprint == h.vulnerabilityFeedID {
+		return h.vulnerabilityFeed
+	}
+
+	var feed *vulnerabilities.Feed
+	if err == nil {
+		feed, err = vulnerabilities.LoadFeed(h.VulnerabilityFeedDir)
+	}
+
+	state := consoledb.VulnerabilityFeed{Fingerprint: fingerprint, Imported: time.Now()}
+
+	if err != nil {
+		log.Printf("[ERROR]: could not load the vulnerability feed, reason: %v", err)
+		state.Error = err.Error()
+		if h.vulnerabilityFeed != nil {
+			state.Fingerprint = h.vulnerabilityFeedID
+			state.Files = h.vulnerabilityFeed.Files
+			state.Vulnerabilities = len(h.vulnerabilityFeed.Vulnerabilities)
+		}
+	} else {
+		h.vulnerabilityFeed = feed
+		h.vulnerabilityFeedID = fingerprint
+		state.Files = feed.Files
+		state.Vulnerabilities = len(feed.Vulnerabilities)
+		log.Printf("[INFO]: the vulnerability feed has been loaded, %d vulnerabilities from %d files", state.Vulnerabilities, state.Files)
+	}
+
+	if err := h.Model.SaveVulnerabilityFeed(state); err != nil {
+		log.Printf("[ERROR]: could not save the state of the vulnerability feed, reason: %v", err)
+	}
+
+	return h.vulnerabilityFeed
+}
+
+// scanTenantVulnerabilities saves the findings of every agent of the tenant and removes
+// the results of the agents that no longer belong to it
+func (h *Handler) scanTenantVulnerabilities(tenantID int, feed *vulnerabilities.Feed) {
+	start := time.Now()
+
+	for offset := 0; ; offset += vulnerabilityAgentsBatch {
+		agents, err := h.Model.GetVulnerabilityAgents(tenantID, offset, vulnerabilityAgentsBatch)
+		if err != nil {
+			log.Printf("[ERROR]: could not get the software of the agents of tenant %d, reason: %v", tenantID, err)
+			return
+		}
+
+		for _, a := range agents {
+			findings := feed.Match(models.AgentVulnerabilitySoftware(a))
+			if err := h.Model.SaveVulnerabilityScan(a, tenantID, findings); err != nil {
+				log.Printf("[ERROR]: could not save the vulnerabilities of agent %s, reason: %v", a.ID, err)
+			}
+		}
+
+		if len(agents) < vulnerabilityAgentsBatch {
+			break
+		}
+	}
+
+	if err := h.Model.DeleteVulnerabilityResults(tenantID, start); err != nil {
+		log.Printf("[ERROR]: could not delete the old vulnerability results of tenant %d, reason: %v", tenantID, err)
+	}
+}
@@ -0,0 +1,478 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/invopop/ctxi18n/i18n"
+	"github.com/labstack/echo/v4"
+	"github.com/open-uem/ent"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+)
+
+// Session keys used to keep the state of a WebAuthn ceremony between its begin and finish requests
+const (
+	webAuthnLoginSession        = "webauthn-login"
+	webAuthnRegistrationSession = "webauthn-registration"
+)
+
+// webAuthnUser adapts an OpenUEM user to the interface required by the WebAuthn library,
+// the user handle stored in discoverable credentials is the user's ID
+type webAuthnUser struct {
+	user        *ent.User
+	credentials []webauthn.Credential
+}
+
+func (u *webAuthnUser) WebAuthnID() []byte {
+	return []byte(u.user.ID)
+}
+
+func (u *webAuthnUser) WebAuthnName() string {
+	return u.user.ID
+}
+
+func (u *webAuthnUser) WebAuthnDisplayName() string {
+	if u.user.Name != "" {
+		return u.user.Name
+	}
+	return u.user.ID
+}
+
+func (u *webAuthnUser) WebAuthnCredentials() []webauthn.Credential {
+	return u.credentials
+}
+
+// WebAuthn returns the relying party used in the ceremonies. The RP ID is the console's
+// server name, or the reverse proxy name if it's used, as browsers only release credentials
+// to the host they were registered for
+func (h *Handler) WebAuthn(c echo.Context) (*webauthn.WebAuthn, error) {
+	rpID := h.ServerName
+	origins := []string{fmt.Sprintf("https://%s:%s", h.ServerName, h.ConsolePort)}
+
+	if h.ReverseProxyServer != "" {
+		rpID = h.ReverseProxyServer
+		origins = append(origins, "https://"+h.ReverseProxyServer)
+
+		// the reverse proxy may listen on any port
+		if origin, err := url.Parse(c.Request().Header.Get("Origin")); err == nil && origin.Hostname() == h.ReverseProxyServer {
+			origins = append(origins, origin.Scheme+"://"+origin.Host)
+		}
+	}
+
+	return webauthn.New(&webauthn.Config{
+		RPID:          rpID,
+		RPDisplayName: "OpenUEM",
+		RPOrigins:     origins,
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			ResidentKey:      protocol.ResidentKeyRequirementPreferred,
+			UserVerification: protocol.VerificationPreferred,
+		},
+		Timeouts: webauthn.TimeoutsConfig{
+			Login:        webauthn.TimeoutConfig{Enforce: true, Timeout: 2 * time.Minute, TimeoutUVD: 2 * time.Minute},
+			Registration: webauthn.TimeoutConfig{Enforce: true, Timeout: 5 * time.Minute, TimeoutUVD: 5 * time.Minute},
+		},
+	})
+}
+
+func (h *Handler) getWebAuthnUser(userID string) (*webAuthnUser, error) {
+	user, err := h.Model.GetUserById(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials, err := h.Model.GetUserWebAuthnCredentials(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &webAuthnUser{user: user, credentials: credentials}, nil
+}
+
+func (h *Handler) putWebAuthnSession(c echo.Context, key string, session *webauthn.SessionData) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	h.SessionManager.Manager.Put(c.Request().Context(), key, data)
+	return nil
+}
+
+// popWebAuthnSession returns the state of the ceremony, it's removed so a response can't be replayed
+func (h *Handler) popWebAuthnSession(c echo.Context, key string) (*webauthn.SessionData, error) {
+	data := h.SessionManager.Manager.PopBytes(c.Request().Context(), key)
+	if len(data) == 0 {
+		return nil, fmt.Errorf("the WebAuthn ceremony has not been started")
+	}
+
+	session := webauthn.SessionData{}
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// LoginPasskeyBegin starts a passwordless login, the browser asks the user to pick any
+// passkey registered for the console so the user doesn't have to enter a username
+func (h *Handler) LoginPasskeyBegin(c echo.Context) error {
+	w, err := h.WebAuthn(c)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "passkeys.could_not_start", err.Error()), true))
+	}
+
+	assertion, session, err := w.BeginDiscoverableLogin(webauthn.WithUserVerification(protocol.VerificationRequired))
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "passkeys.could_not_start", err.Error()), true))
+	}
+
+	if err := h.putWebAuthnSession(c, webAuthnLoginSession, session); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "passkeys.could_not_start", err.Error()), true))
+	}
+
+	return c.JSON(http.StatusOK, assertion)
+}
+
+func (h *Handler) LoginPasskeyFinish(c echo.Context) error {
+	if c.FormValue("error") != "" {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "passkeys.cancelled"), true))
+	}
+
+	w, err := h.WebAuthn(c)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "passkeys.could_not_verify"), true))
+	}
+
+	session, err := h.popWebAuthnSession(c, webAuthnLoginSession)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "passkeys.could_not_verify"), true))
+	}
+
+	response, err := protocol.ParseCredentialRequestResponseBytes([]byte(c.FormValue("credential")))
+	if err != nil {
+		log.Printf("[ERROR]: could not parse the passkey assertion, reason: %v", err)
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "passkeys.could_not_verify"), true))
+	}
+
+	var account *webAuthnUser
+	_, credential, err := w.ValidatePasskeyLogin(func(rawID, userHandle []byte) (webauthn.User, error) {
+		account, err = h.getWebAuthnUser(string(userHandle))
+		return account, err
+	}, *session, response)
+	if err != nil {
+		if h.AuthLogger != nil {
+			h.AuthLogger.Printf("a passkey could not be verified, reason: %v", err)
+		}
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "passkeys.could_not_verify"), true))
+	}
+
+	if err := h.Model.UpdateWebAuthnCredential(account.user.ID, credential); err != nil {
+		log.Printf("[ERROR]: could not update the passkey of user %s, reason: %v", account.user.ID, err)
+	}
+
+	if err := h.NewSession(c, account.user); err != nil {
+		log.Printf("[ERROR]: could not create a new session after passkey login, reason: %v", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not create session")
+	}
+
+	// a passkey is a phishing-resistant login on its own, no other factor is required
+	h.SessionManager.Manager.Put(c.Request().Context(), "webauthn", true)
+
+	return h.AccessGranted(c, account.user)
+}
+
+// LoginWebAuthnBegin starts the second factor verification with a security key, the user
+// has already been identified by its password or certificate
+func (h *Handler) LoginWebAuthnBegin(c echo.Context) error {
+	username := h.SessionManager.Manager.GetString(c.Request().Context(), "uid")
+	if username == "" || h.SessionManager.Manager.GetBool(c.Request().Context(), "forgot") {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "login.username_empty"), true))
+	}
+
+	account, err := h.getWebAuthnUser(username)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "passkeys.could_not_start", err.Error()), true))
+	}
+
+	w, err := h.WebAuthn(c)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "passkeys.could_not_start", err.Error()), true))
+	}
+
+	assertion, session, err := w.BeginLogin(account)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "passkeys.could_not_start", err.Error()), true))
+	}
+
+	if err := h.putWebAuthnSession(c, webAuthnLoginSession, session); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "passkeys.could_not_start", err.Error()), true))
+	}
+
+	return c.JSON(http.StatusOK, assertion)
+}
+
+func (h *Handler) LoginWebAuthnFinish(c echo.Context) error {
+	username := h.SessionManager.Manager.GetString(c.Request().Context(), "uid")
+	if username == "" || h.SessionManager.Manager.GetBool(c.Request().Context(), "forgot") {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "login.username_empty"), true))
+	}
+
+	if c.FormValue("error") != "" {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "passkeys.cancelled"), true))
+	}
+
+	account, err := h.getWebAuthnUser(username)
+	if err != nil {
+		log.Printf("[ERROR]: could not get user account for username %s, reason: %v", username, err)
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "passkeys.could_not_verify"), true))
+	}
+
+	w, err := h.WebAuthn(c)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "passkeys.could_not_verify"), true))
+	}
+
+	session, err := h.popWebAuthnSession(c, webAuthnLoginSession)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "passkeys.could_not_verify"), true))
+	}
+
+	response, err := protocol.ParseCredentialRequestResponseBytes([]byte(c.FormValue("credential")))
+	if err != nil {
+		log.Printf("[ERROR]: could not parse the security key assertion, reason: %v", err)
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "passkeys.could_not_verify"), true))
+	}
+
+	credential, err := w.ValidateLogin(account, *session, response)
+	if err != nil {
+		if h.AuthLogger != nil {
+			h.AuthLogger.Printf("the security key of user %s could not be verified, reason: %v", username, err)
+		}
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "passkeys.could_not_verify"), true))
+	}
+
+	if err := h.Model.UpdateWebAuthnCredential(username, credential); err != nil {
+		log.Printf("[ERROR]: could not update the security key of user %s, reason: %v", username, err)
+	}
+
+	h.SessionManager.Manager.Put(c.Request().Context(), "webauthn", true)
+
+	return h.AccessGranted(c, account.user)
+}
+
+// MyAccountPasskeyBegin starts the registration of a new security key or passkey
+func (h *Handler) MyAccountPasskeyBegin(c echo.Context) error {
+	username := h.SessionManager.Manager.GetString(c.Request().Context(), "uid")
+	if username == "" {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "login.username_empty"), true))
+	}
+
+	if strings.TrimSpace(c.FormValue("passkey-name")) == "" {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "passkeys.empty_name"), true))
+	}
+
+	account, err := h.getWebAuthnUser(username)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "passkeys.could_not_start", err.Error()), true))
+	}
+
+	w, err := h.WebAuthn(c)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "passkeys.could_not_start", err.Error()), true))
+	}
+
+	creation, session, err := w.BeginRegistration(account, webauthn.WithExclusions(webauthn.Credentials(account.credentials).CredentialDescriptors()))
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "passkeys.could_not_start", err.Error()), true))
+	}
+
+	if err := h.putWebAuthnSession(c, webAuthnRegistrationSession, session); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "passkeys.could_not_start", err.Error()), true))
+	}
+
+	return c.JSON(http.StatusOK, creation)
+}
+
+func (h *Handler) MyAccountPasskeyFinish(c echo.Context) error {
+	username := h.SessionManager.Manager.GetString(c.Request().Context(), "uid")
+	if username == "" {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "login.username_empty"), true))
+	}
+
+	if c.FormValue("error") != "" {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "passkeys.cancelled"), true))
+	}
+
+	name := strings.TrimSpace(c.FormValue("passkey-name"))
+	if name == "" {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "passkeys.empty_name"), true))
+	}
+
+	account, err := h.getWebAuthnUser(username)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "passkeys.could_not_add", err.Error()), true))
+	}
+
+	w, err := h.WebAuthn(c)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "passkeys.could_not_add", err.Error()), true))
+	}
+
+	session, err := h.popWebAuthnSession(c, webAuthnRegistrationSession)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "passkeys.could_not_add", err.Error()), true))
+	}
+
+	response, err := protocol.ParseCredentialCreationResponseBytes([]byte(c.FormValue("credential")))
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "passkeys.could_not_add", err.Error()), true))
+	}
+
+	credential, err := w.CreateCredential(account, *session, response)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "passkeys.could_not_add", err.Error()), true))
+	}
+
+	if err := h.Model.AddWebAuthnCredential(username, name, credential); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "passkeys.could_not_add", err.Error()), true))
+	}
+
+	// enrolling the first key satisfies the phishing-resistant policy for this session, once
+	// a user has keys it must sign in with one of them
+	if len(account.credentials) == 0 {
+		h.SessionManager.Manager.Put(c.Request().Context(), "webauthn", true)
+	}
+
+	if h.AuthLogger != nil {
+		h.AuthLogger.Printf("user %s has registered the security key %s", username, name)
+	}
+
+	return h.RenderMyAccount(c, username, "", i18n.T(c.Request().Context(), "passkeys.added"))
+}
+
+func (h *Handler) MyAccountPasskeyRename(c echo.Context) error {
+	username := h.SessionManager.Manager.GetString(c.Request().Context(), "uid")
+	if username == "" {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "login.username_empty"), true))
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "passkeys.invalid_id"), true))
+	}
+
+	name := strings.TrimSpace(c.FormValue("passkey-name"))
+	if name == "" {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "passkeys.empty_name"), true))
+	}
+
+	if err := h.Model.RenameWebAuthnCredential(id, username, name); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "passkeys.could_not_rename", err.Error()), true))
+	}
+
+	return h.RenderMyAccount(c, username, "", i18n.T(c.Request().Context(), "passkeys.renamed"))
+}
+
+func (h *Handler) MyAccountPasskeyDelete(c echo.Context) error {
+	id := c.Param("id")
+	if _, err := strconv.Atoi(id); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "passkeys.invalid_id"), true))
+	}
+
+	return RenderConfirm(c, partials.ConfirmDelete(c, i18n.T(c.Request().Context(), "passkeys.confirm_delete"), "/myaccount", fmt.Sprintf("/myaccount/passkeys/%s", id)))
+}
+
+func (h *Handler) MyAccountPasskeyConfirmDelete(c echo.Context) error {
+	username := h.SessionManager.Manager.GetString(c.Request().Context(), "uid")
+	if username == "" {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "login.username_empty"), true))
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "passkeys.invalid_id"), true))
+	}
+
+	if err := h.Model.DeleteWebAuthnCredential(id, username); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "passkeys.could_not_delete", err.Error()), true))
+	}
+
+	if h.AuthLogger != nil {
+		h.AuthLogger.Printf("user %s has removed a security key", username)
+	}
+
+	return h.RenderMyAccount(c, username, "", i18n.T(c.Request().Context(), "passkeys.deleted"))
+}
+
+// ResetUserPasskeys removes every security key of a user, for example when the user has lost
+// them, so the user can sign in again with its password and second factor
+func (h *Handler) ResetUserPasskeys(c echo.Context) error {
+	uid := c.Param("uid")
+	exists, err := h.Model.UserExists(uid)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), false))
+	}
+
+	if !exists {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "users.user_not_found"), true))
+	}
+
+	if err := h.Model.ResetWebAuthnCredentials(uid); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "passkeys.could_not_reset", err.Error()), false))
+	}
+
+	if h.AuthLogger != nil {
+		h.AuthLogger.Printf("the security keys of user %s have been reset by %s", uid, h.SessionManager.Manager.GetString(c.Request().Context(), "uid"))
+	}
+
+	return h.ListUsers(c, i18n.T(c.Request().Context(), "passkeys.reset_success"), "")
+}
+
+// PhishingResistantAuthRequired reports if the user is an admin that must sign in with a
+// security key or passkey, as set in the authentication settings, and hasn't done it. If the
+// policy or the roles of the user can't be read the key is required
+func (h *Handler) PhishingResistantAuthRequired(c echo.Context) bool {
+	if h.SessionManager.Manager.GetBool(c.Request().Context(), "webauthn") {
+		return false
+	}
+
+	required, err := h.Model.RequirePhishingResistantAdmins()
+	if err != nil {
+		log.Printf("[ERROR]: could not get the phishing-resistant authentication policy, reason: %v", err)
+		return true
+	}
+
+	if !required {
+		return false
+	}
+
+	access, err := h.GetUserAccess(c)
+	if err != nil {
+		log.Printf("[ERROR]: could not get the roles for user %s, reason: %v", h.GetUserID(c), err)
+		return true
+	}
+
+	_, isAdmin := access.AdminTenant()
+	return isAdmin
+}
+
+// RequirePhishingResistantAuth sends the admin to its account page, the only one available
+// until the admin signs in with a security key or registers its first one
+func (h *Handler) RequirePhishingResistantAuth(c echo.Context) error {
+	if IsAPIRequest(c) {
+		return RenderAPIError(c, http.StatusForbidden, i18n.T(c.Request().Context(), "passkeys.admin_required"))
+	}
+
+	if c.Request().Header.Get("HX-Request") == "true" {
+		c.Response().Header().Set("HX-Redirect", "/myaccount")
+		return c.NoContent(http.StatusOK)
+	}
+
+	return c.Redirect(http.StatusFound, "/myaccount")
+}
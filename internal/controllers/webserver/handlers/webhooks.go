@@ -0,0 +1,324 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/invopop/ctxi18n/i18n"
+	"github.com/labstack/echo/v4"
+	"github.com/open-uem/ent"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/models"
+	"github.com/open-uem/openuem-console/internal/views/admin_views"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+	"github.com/open-uem/openuem-console/internal/webhooks"
+	"github.com/open-uem/utils"
+)
+
+// NotifyWebhooks queues the event for the webhooks subscribed to it. Errors are only logged
+// as the action that triggered the event has already been done
+func (h *Handler) NotifyWebhooks(tenantID int, event string, data any) {
+	if err := h.Model.QueueWebhookEvent(tenantID, event, data); err != nil {
+		log.Printf("[ERROR]: could not queue the %s webhook event, reason: %v", event, err)
+	}
+}
+
+// NotifyAgentWebhooks queues an agent event for the tenant selected in the current route
+func (h *Handler) NotifyAgentWebhooks(commonInfo *partials.CommonInfo, event string, agent *ent.Agent) {
+	tenantID, err := strconv.Atoi(commonInfo.TenantID)
+	if err != nil {
+		log.Printf("[ERROR]: could not queue the %s webhook event, reason: %v", event, err)
+		return
+	}
+	h.NotifyWebhooks(tenantID, event, models.WebhookAgentData(agent))
+}
+
+func (h *Handler) ListWebhooks(c echo.Context) error {
+	return h.RenderWebhooks(c, "", "", "")
+}
+
+func (h *Handler) AddWebhook(c echo.Context) error {
+	tenantID, err := h.getWebhooksTenant(c)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "tenants.could_not_convert_to_int", err.Error()), true))
+	}
+
+	name := strings.TrimSpace(c.FormValue("webhook-name"))
+	if name == "" {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "webhooks.empty_name"), true))
+	}
+
+	url := strings.TrimSpace(c.FormValue("webhook-url"))
+	if !webhooks.ValidURL(url) {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "webhooks.invalid_url"), true))
+	}
+
+	if err := c.Request().ParseForm(); err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), true))
+	}
+
+	events := c.Request().Form["webhook-events"]
+	if len(events) == 0 {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "webhooks.empty_events"), true))
+	}
+	for _, e := range events {
+		if !slices.Contains(webhookEvents(tenantID), e) {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "webhooks.invalid_event", e), true))
+		}
+	}
+
+	if h.EncryptionMasterKey == "" {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "webhooks.empty_encryption_master_key"), true))
+	}
+
+	secret, err := webhooks.GenerateSecret()
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "webhooks.could_not_add", err.Error()), true))
+	}
+
+	encryptedSecret, err := utils.EncryptSensitiveField(secret, h.EncryptionMasterKey)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "webhooks.could_not_add", err.Error()), true))
+	}
+
+	w := consoledb.Webhook{
+		TenantID: tenantID,
+		Name:     name,
+		URL:      url,
+		Secret:   encryptedSecret,
+		Events:   events,
+		Enabled:  true,
+	}
+
+	if err := h.Model.AddWebhook(w); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "webhooks.could_not_add", err.Error()), true))
+	}
+
+	h.Audit(c, AuditWebhookAdd, name, "", url)
+
+	return h.RenderWebhooks(c, secret, i18n.T(c.Request().Context(), "webhooks.added"), "")
+}
+
+func (h *Handler) EnableWebhook(c echo.Context) error {
+	w, err := h.getWebhook(c)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), true))
+	}
+
+	enabled, err := strconv.ParseBool(c.FormValue("webhook-enabled"))
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "webhooks.invalid_enabled"), true))
+	}
+
+	if err := h.Model.SetWebhookEnabled(w.ID, w.TenantID, enabled); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "webhooks.could_not_update", err.Error()), true))
+	}
+
+	if enabled {
+		return h.RenderWebhooks(c, "", i18n.T(c.Request().Context(), "webhooks.has_been_enabled"), "")
+	}
+	return h.RenderWebhooks(c, "", i18n.T(c.Request().Context(), "webhooks.has_been_disabled"), "")
+}
+
+func (h *Handler) TestWebhook(c echo.Context) error {
+	w, err := h.getWebhook(c)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), true))
+	}
+
+	if err := h.Model.QueueWebhookTest(w); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "webhooks.could_not_test", err.Error()), true))
+	}
+
+	return h.RenderWebhooks(c, "", i18n.T(c.Request().Context(), "webhooks.test_queued"), "")
+}
+
+func (h *Handler) WebhookDelete(c echo.Context) error {
+	w, err := h.getWebhook(c)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), true))
+	}
+
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+	commonInfo.TenantID = strconv.Itoa(w.TenantID)
+
+	url := webhooksURL(commonInfo)
+	return RenderConfirm(c, partials.ConfirmDelete(c, i18n.T(c.Request().Context(), "webhooks.confirm_delete", w.Name), "", fmt.Sprintf("%s/%d", url, w.ID)))
+}
+
+func (h *Handler) WebhookConfirmDelete(c echo.Context) error {
+	w, err := h.getWebhook(c)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), true))
+	}
+
+	if err := h.Model.DeleteWebhook(w.ID, w.TenantID); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "webhooks.could_not_delete", err.Error()), true))
+	}
+
+	h.Audit(c, AuditWebhookDelete, w.Name, w.URL, "")
+
+	return h.RenderWebhooks(c, "", i18n.T(c.Request().Context(), "webhooks.deleted"), "")
+}
+
+// RenderWebhooks renders the webhooks page, newSecret is only shown once right after the webhook is created
+func (h *Handler) RenderWebhooks(c echo.Context, newSecret, successMessage, errMessage string) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	tenantID, err := h.getWebhooksTenant(c)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "tenants.could_not_convert_to_int", err.Error()), true))
+	}
+	commonInfo.TenantID = strconv.Itoa(tenantID)
+
+	hooks, err := h.Model.GetWebhooks(tenantID)
+	if err != nil {
+		successMessage = ""
+		errMessage = i18n.T(c.Request().Context(), "webhooks.could_not_get", err.Error())
+	}
+
+	agentsExists, err := h.Model.AgentsExists(commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), false))
+	}
+
+	serversExists, err := h.Model.ServersExists()
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), false))
+	}
+
+	return RenderView(c, admin_views.WebhooksIndex(" | Webhooks", admin_views.Webhooks(c, hooks, webhookEvents(tenantID), newSecret, successMessage, errMessage, agentsExists, serversExists, commonInfo, h.GetAdminTenantName(commonInfo)), commonInfo))
+}
+
+func (h *Handler) ListWebhookDeliveries(c echo.Context) error {
+	return h.RenderWebhookDeliveries(c, "")
+}
+
+func (h *Handler) RetryWebhookDelivery(c echo.Context) error {
+	tenantID, err := h.getWebhooksTenant(c)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "tenants.could_not_convert_to_int", err.Error()), true))
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "webhooks.invalid_delivery"), true))
+	}
+
+	if err := h.Model.RetryWebhookDelivery(id, tenantID); err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "webhooks.could_not_retry", err.Error()), true))
+	}
+
+	return h.RenderWebhookDeliveries(c, i18n.T(c.Request().Context(), "webhooks.retry_queued"))
+}
+
+func (h *Handler) RenderWebhookDeliveries(c echo.Context, successMessage string) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	tenantID, err := h.getWebhooksTenant(c)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "tenants.could_not_convert_to_int", err.Error()), true))
+	}
+	commonInfo.TenantID = strconv.Itoa(tenantID)
+
+	errMessage := ""
+
+	itemsPerPage, err := h.Model.GetDefaultItemsPerPage()
+	if err != nil {
+		log.Println("[ERROR]: could not get items per page from database")
+		itemsPerPage = 5
+	}
+
+	p := partials.NewPaginationAndSort(itemsPerPage)
+	p.GetPaginationAndSortParams(c.FormValue("page"), c.FormValue("pageSize"), c.FormValue("sortBy"), c.FormValue("sortOrder"), c.FormValue("currentSortBy"), itemsPerPage)
+
+	p.NItems, err = h.Model.CountWebhookDeliveries(tenantID)
+	if err != nil {
+		errMessage = i18n.T(c.Request().Context(), "webhooks.could_not_get_deliveries", err.Error())
+	}
+
+	deliveries, err := h.Model.GetWebhookDeliveriesByPage(p, tenantID)
+	if err != nil {
+		successMessage = ""
+		errMessage = i18n.T(c.Request().Context(), "webhooks.could_not_get_deliveries", err.Error())
+	}
+
+	hookNames := map[int]string{}
+	hooks, err := h.Model.GetWebhooks(tenantID)
+	if err != nil {
+		log.Printf("[ERROR]: could not get webhooks names, reason: %v", err)
+	}
+	for _, w := range hooks {
+		hookNames[w.ID] = w.Name
+	}
+
+	agentsExists, err := h.Model.AgentsExists(commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), false))
+	}
+
+	serversExists, err := h.Model.ServersExists()
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(err.Error(), false))
+	}
+
+	return RenderView(c, admin_views.WebhooksIndex(" | Webhooks", admin_views.WebhookDeliveries(c, p, deliveries, hookNames, successMessage, errMessage, agentsExists, serversExists, itemsPerPage, commonInfo, h.GetAdminTenantName(commonInfo)), commonInfo))
+}
+
+// getWebhooksTenant returns the tenant whose webhooks are managed, -1 for the global webhooks
+func (h *Handler) getWebhooksTenant(c echo.Context) (int, error) {
+	if c.Param("tenant") == "" {
+		return -1, nil
+	}
+	return strconv.Atoi(c.Param("tenant"))
+}
+
+func (h *Handler) getWebhook(c echo.Context) (consoledb.Webhook, error) {
+	tenantID, err := h.getWebhooksTenant(c)
+	if err != nil {
+		return consoledb.Webhook{}, errors.New(i18n.T(c.Request().Context(), "tenants.could_not_convert_to_int", err.Error()))
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return consoledb.Webhook{}, errors.New(i18n.T(c.Request().Context(), "webhooks.invalid_id"))
+	}
+
+	w, err := h.Model.GetWebhook(id, tenantID)
+	if err != nil {
+		if errors.Is(err, models.ErrWebhookNotFound) {
+			return consoledb.Webhook{}, errors.New(i18n.T(c.Request().Context(), "webhooks.not_found"))
+		}
+		return consoledb.Webhook{}, errors.New(i18n.T(c.Request().Context(), "webhooks.could_not_get", err.Error()))
+	}
+
+	return w, nil
+}
+
+// webhookEvents returns the events available in the scope, certificates are only managed globally
+func webhookEvents(tenantID int) []string {
+	if tenantID == -1 {
+		return webhooks.Events
+	}
+	return slices.DeleteFunc(slices.Clone(webhooks.Events), func(e string) bool { return e == webhooks.EventCertificateExpiring })
+}
+
+func webhooksURL(commonInfo *partials.CommonInfo) string {
+	if commonInfo.TenantID != "-1" {
+		return fmt.Sprintf("/tenant/%s/admin/webhooks", commonInfo.TenantID)
+	}
+	return "/admin/webhooks"
+}
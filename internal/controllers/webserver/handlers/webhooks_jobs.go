@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-co-op/gocron/v2"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/webhooks"
+	"github.com/open-uem/utils"
+)
+
+const (
+	webhookDispatchInterval = 30 * time.Second
+	webhookDetectInterval   = 5 * time.Minute
+	webhookRequestTimeout   = 10 * time.Second
+	webhookDeliveriesKept   = 30 * 24 * time.Hour
+	webhookDispatchBatch    = 50
+)
+
+// webhookStateEvents are the events sent when an agent, deployment or certificate enters a state,
+// they're detected by comparing the current state with the one saved in the previous run
+var webhookStateEvents = []string{
+	webhooks.EventAgentWaitingAdmission,
+	webhooks.EventDeploymentFailed,
+	webhooks.EventAntivirusDisabled,
+	webhooks.EventUpdatesPending,
+	webhooks.EventProfileIssue,
+}
+
+// StartWebhookJobs schedules the jobs that detect webhook events and deliver them.
+// Several console instances can run them as deliveries are claimed before being sent
+func (h *Handler) StartWebhookJobs() error {
+	client := &http.Client{Timeout: webhookRequestTimeout}
+
+	if _, err := h.TaskScheduler.NewJob(
+		gocron.DurationJob(webhookDispatchInterval),
+		gocron.NewTask(func() { h.DispatchWebhookDeliveries(client) }),
+		gocron.WithSingletonMode(gocron.LimitModeReschedule),
+	); err != nil {
+		log.Printf("[ERROR]: could not schedule the job that sends webhooks, reason: %v", err)
+		return err
+	}
+
+	if _, err := h.TaskScheduler.NewJob(
+		gocron.DurationJob(webhookDetectInterval),
+		gocron.NewTask(h.DetectWebhookEvents),
+		gocron.WithSingletonMode(gocron.LimitModeReschedule),
+	); err != nil {
+		log.Printf("[ERROR]: could not schedule the job that detects webhook events, reason: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// DispatchWebhookDeliveries sends the pending deliveries, failed requests are retried
+// with an exponential backoff until webhooks.MaxAttempts is reached
+func (h *Handler) DispatchWebhookDeliveries(client *http.Client) {
+	deliveries, err := h.Model.GetDueWebhookDeliveries(webhookDispatchBatch)
+	if err != nil {
+		log.Printf("[ERROR]: could not get the pending webhook deliveries, reason: %v", err)
+		return
+	}
+
+	for _, d := range deliveries {
+		claimed, err := h.Model.ClaimWebhookDelivery(d, 2*webhookRequestTimeout)
+		if err != nil {
+			log.Printf("[ERROR]: could not claim webhook delivery %d, reason: %v", d.ID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		h.sendWebhookDelivery(client, d, d.Attempts+1)
+	}
+
+	if err := h.Model.DeleteOldWebhookDeliveries(time.Now().Add(-webhookDeliveriesKept)); err != nil {
+		log.Printf("[ERROR]: could not delete old webhook deliveries, reason: %v", err)
+	}
+}
+
+func (h *Handler) sendWebhookDelivery(client *http.Client, d consoledb.WebhookDelivery, attempts int) {
+	statusCode, err := h.postWebhookDelivery(client, d)
+	if err == nil {
+		if err := h.Model.SaveWebhookDeliveryResult(d.ID, consoledb.WebhookDeliveryDelivered, time.Now(), statusCode, ""); err != nil {
+			log.Printf("[ERROR]: could not save webhook delivery %d result, reason: %v", d.ID, err)
+		}
+		return
+	}
+
+	status := consoledb.WebhookDeliveryPending
+	if attempts >= webhooks.MaxAttempts {
+		status = consoledb.WebhookDeliveryFailed
+	}
+
+	if err := h.Model.SaveWebhookDeliveryResult(d.ID, status, time.Now().Add(webhooks.Backoff(attempts)), statusCode, err.Error()); err != nil {
+		log.Printf("[ERROR]: could not save webhook delivery %d result, reason: %v", d.ID, err)
+	}
+}
+
+func (h *Handler) postWebhookDelivery(client *http.Client, d consoledb.WebhookDelivery) (int, error) {
+	w, err := h.Model.GetWebhook(d.WebhookID, d.TenantID)
+	if err != nil {
+		// global webhooks receive the events of every tenant
+		w, err = h.Model.GetWebhook(d.WebhookID, -1)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	secret, err := utils.DecryptSensitiveField(w.Secret, h.EncryptionMasterKey)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), webhookRequestTimeout)
+	defer cancel()
+
+	return webhooks.Send(ctx, client, w.URL, secret, []byte(d.Payload))
+}
+
+// DetectWebhookEvents queues the events for agents, deployments and certificates that
+// have entered a state since the previous run
+func (h *Handler) DetectWebhookEvents() {
+	tenants, err := h.Model.GetTenants()
+	if err != nil {
+		log.Printf("[ERROR]: could not get tenants to detect webhook events, reason: %v", err)
+		return
+	}
+
+	for _, t := range tenants {
+		for _, event := range webhookStateEvents {
+			h.detectWebhookEvent(t.ID, event)
+		}
+	}
+
+	h.detectWebhookEvent(-1, webhooks.EventCertificateExpiring)
+}
+
+func (h *Handler) detectWebhookEvent(tenantID int, event string) {
+	subjects, err := h.Model.GetWebhookSubjects(tenantID, event)
+	if err != nil {
+		log.Printf("[ERROR]: could not check the %s webhook event, reason: %v", event, err)
+		return
+	}
+
+	keys := []string{}
+	for _, s := range subjects {
+		keys = append(keys, s.Key)
+	}
+
+	added, err := h.Model.SyncWebhookState(tenantID, event, keys)
+	if err != nil {
+		log.Printf("[ERROR]: could not save the %s webhook event state, reason: %v", event, err)
+		return
+	}
+
+	for _, s := range subjects {
+		for _, key := range added {
+			if s.Key == key {
+				h.NotifyWebhooks(tenantID, event, s.Data)
+			}
+		}
+	}
+}
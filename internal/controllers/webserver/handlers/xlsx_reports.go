@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/invopop/ctxi18n/i18n"
+	"github.com/labstack/echo/v4"
+	"github.com/open-uem/ent"
+	"github.com/open-uem/openuem-console/internal/models"
+	"github.com/open-uem/openuem-console/internal/spreadsheet"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+)
+
+// GenerateXLSXReports exports a list with the filters and sorting applied by the user.
+// Rows are split in one sheet per site when the list contains every site of the tenant
+func (h *Handler) GenerateXLSXReports(c echo.Context) error {
+	commonInfo, err := h.GetCommonInfo(c)
+	if err != nil {
+		return err
+	}
+
+	siteName, err := h.siteSheetNames(commonInfo)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_apply_filters"), false))
+	}
+
+	p := h.allRowsPagination(c)
+
+	var sheets []spreadsheet.Sheet
+	switch c.Param("report") {
+	case "agents":
+		f, err := h.GetAgentFilters(c)
+		if err != nil {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_apply_filters"), false))
+		}
+		agents, err := h.Model.GetAgentsByPage(p, *f, true, commonInfo)
+		if err != nil {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_get_all_agents"), false))
+		}
+		sheets = agentsSheets(agents, siteName)
+	case "computers":
+		f, err := h.GetComputerFilters(c)
+		if err != nil {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_apply_filters"), false))
+		}
+		computers, err := h.Model.GetComputersByPage(p, *f, commonInfo)
+		if err != nil {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_get_all_computers"), false))
+		}
+		sheets = computersSheets(computers, siteName)
+	case "software":
+		f, err := h.GetSoftwareFilters(c)
+		if err != nil {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_apply_filters"), false))
+		}
+		apps, err := h.Model.GetAppsByPage(p, *f, commonInfo)
+		if err != nil {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_get_all_software"), false))
+		}
+		sheets = softwareSheets(apps)
+	case "antivirus":
+		f, err := h.GetEDRFilters(c)
+		if err != nil {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_apply_filters"), false))
+		}
+		antiviri, err := h.Model.GetAntiviriByPage(p, *f, commonInfo)
+		if err != nil {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_get_all_antiviri"), false))
+		}
+		sheets = antiviriSheets(antiviri, siteName)
+	case "updates":
+		f, _, _, err := h.GetSystemUpdatesFilters(c)
+		if err != nil {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_apply_filters"), false))
+		}
+		updates, err := h.Model.GetSystemUpdatesByPage(p, *f, commonInfo)
+		if err != nil {
+			return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_get_system_updates"), false))
+		}
+		sheets = systemUpdatesSheets(c, updates, siteName)
+	case "deployments":
+		// The deployments of a computer are exported from its deploy page
+		var deployments []*ent.Deployment
+		if agentID := c.QueryParam("agent"); agentID != "" {
+			agent, err := h.Model.GetAgentById(agentID, commonInfo)
+			if err != nil {
+				return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_get_all_deployments"), false))
+			}
+			deployments, err = h.Model.GetDeploymentsForAgent(agentID, p, commonInfo)
+			if err != nil {
+				return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_get_all_deployments"), false))
+			}
+			for _, d := range deployments {
+				d.Edges.Owner = agent
+			}
+		} else {
+			deployments, err = h.Model.GetDeployments(p, commonInfo)
+			if err != nil {
+				return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_get_all_deployments"), false))
+			}
+		}
+		sheets = deploymentsSheets(deployments, siteName)
+	default:
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.invalid_report_selected"), false))
+	}
+
+	return h.sendXLSXReport(c, sheets)
+}
+
+func (h *Handler) GenerateUsersXLSXReport(c echo.Context) error {
+	users, err := h.Model.GetUsersByPage(h.allRowsPagination(c), h.GetUserFilters(c))
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_get_all_users"), false))
+	}
+
+	columns := []string{"uid", "name", "email", "phone", "country", "register", "email_verified", "use_2fa", "created", "modified"}
+	return h.sendXLSXReport(c, spreadsheet.Group(users, "users", func(*ent.User) string { return "users" }, columns, func(u *ent.User) []any {
+		return []any{u.ID, u.Name, u.Email, u.Phone, u.Country, u.Register, u.EmailVerified, u.Use2fa, u.Created, u.Modified}
+	}))
+}
+
+func (h *Handler) GenerateCertificatesXLSXReport(c echo.Context) error {
+	f, err := h.GetCertificateFilters(c)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_apply_filters"), false))
+	}
+
+	certificates, err := h.Model.GetCertificatesByPage(h.allRowsPagination(c), f)
+	if err != nil {
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_get_all_certificates"), false))
+	}
+
+	columns := []string{"serial", "type", "description", "username", "expiry"}
+	return h.sendXLSXReport(c, spreadsheet.Group(certificates, "certificates", func(*ent.Certificate) string { return "certificates" }, columns, func(cert *ent.Certificate) []any {
+		return []any{cert.ID, string(cert.Type), cert.Description, cert.UID, cert.Expiry}
+	}))
+}
+
+func (h *Handler) sendXLSXReport(c echo.Context, sheets []spreadsheet.Sheet) error {
+	fileName := uuid.NewString() + ".xlsx"
+	if err := spreadsheet.Write(filepath.Join(h.DownloadDir, fileName), sheets); err != nil {
+		log.Printf("[ERROR]: could not write XLSX report, reason: %v", err)
+		return RenderError(c, partials.ErrorMessage(i18n.T(c.Request().Context(), "reports.could_not_generate_report"), false))
+	}
+
+	// Redirect to file
+	url := "/download/" + fileName
+	c.Response().Header().Set("HX-Redirect", url)
+
+	return c.String(http.StatusOK, "")
+}
+
+// allRowsPagination keeps the sorting chosen by the user but not the page, so every row is exported
+func (h *Handler) allRowsPagination(c echo.Context) partials.PaginationAndSort {
+	itemsPerPage, err := h.Model.GetDefaultItemsPerPage()
+	if err != nil {
+		log.Println("[ERROR]: could not get items per page from database")
+		itemsPerPage = 5
+	}
+
+	p := partials.PaginationAndSort{}
+	p.GetPaginationAndSortParams("0", "0", c.FormValue("sortBy"), c.FormValue("sortOrder"), "", itemsPerPage)
+	return p
+}
+
+// siteSheetNames returns the name of the sheet where the rows of each site of the tenant are written
+func (h *Handler) siteSheetNames(commonInfo *partials.CommonInfo) (func(siteID int) string, error) {
+	tenantID, err := strconv.Atoi(commonInfo.TenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	sites, err := h.Model.GetSites(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[int]string{}
+	for _, s := range sites {
+		names[s.ID] = s.Description
+	}
+
+	return func(siteID int) string { return names[siteID] }, nil
+}
+
+func agentSiteID(a *ent.Agent) int {
+	if a == nil || len(a.Edges.Site) != 1 {
+		return -1
+	}
+	return a.Edges.Site[0].ID
+}
+
+func agentsSheets(agents []*ent.Agent, siteName func(int) string) []spreadsheet.Sheet {
+	columns := []string{"name", "status", "os", "version", "ip", "last_contact"}
+	return spreadsheet.Group(agents, "agents", func(a *ent.Agent) string { return siteName(agentSiteID(a)) }, columns, func(a *ent.Agent) []any {
+		version := ""
+		if a.Edges.Release != nil {
+			version = a.Edges.Release.Version
+		}
+		return []any{a.Nickname, string(a.AgentStatus), a.Os, version, a.IP, a.LastContact}
+	})
+}
+
+func computersSheets(computers []models.Computer, siteName func(int) string) []spreadsheet.Sheet {
+	columns := []string{"name", "os", "version", "username", "manufacturer", "model", "serial_number", "ip", "mac", "remote", "last_contact"}
+	return spreadsheet.Group(computers, "computers", func(c models.Computer) string { return siteName(c.SiteID) }, columns, func(c models.Computer) []any {
+		return []any{c.Nickname, c.OS, c.Version, c.Username, c.Manufacturer, c.Model, c.Serial, c.IP, c.MAC, c.IsRemote, c.LastContact}
+	})
+}
+
+// softwareSheets writes a single sheet as the applications are counted across every site
+func softwareSheets(apps []models.App) []spreadsheet.Sheet {
+	columns := []string{"name", "publisher", "installations"}
+	return spreadsheet.Group(apps, "software", func(models.App) string { return "software" }, columns, func(a models.App) []any {
+		return []any{a.Name, a.Publisher, a.Count}
+	})
+}
+
+func antiviriSheets(antiviri []models.Antivirus, siteName func(int) string) []spreadsheet.Sheet {
+	columns := []string{"name", "os", "antivirus", "antivirus_enabled", "antivirus_updated"}
+	return spreadsheet.Group(antiviri, "antivirus", func(a models.Antivirus) string { return siteName(a.SiteID) }, columns, func(a models.Antivirus) []any {
+		return []any{a.Nickname, a.OS, a.Name, a.IsActive, a.IsUpdated}
+	})
+}
+
+func systemUpdatesSheets(c echo.Context, updates []models.SystemUpdate, siteName func(int) string) []spreadsheet.Sheet {
+	columns := []string{"name", "os", "status", "last_search", "last_install", "pending_updates"}
+	return spreadsheet.Group(updates, "updates", func(u models.SystemUpdate) string { return siteName(u.SiteID) }, columns, func(u models.SystemUpdate) []any {
+		return []any{u.Nickname, u.OS, i18n.T(c.Request().Context(), u.SystemUpdateStatus), u.LastSearch, u.LastInstall, u.PendingUpdates}
+	})
+}
+
+func deploymentsSheets(deployments []*ent.Deployment, siteName func(int) string) []spreadsheet.Sheet {
+	columns := []string{"computer", "name", "version", "installed", "updated", "failed"}
+	return spreadsheet.Group(deployments, "deployments", func(d *ent.Deployment) string { return siteName(agentSiteID(d.Edges.Owner)) }, columns, func(d *ent.Deployment) []any {
+		computer := ""
+		if d.Edges.Owner != nil {
+			computer = d.Edges.Owner.Nickname
+		}
+		return []any{computer, d.Name, d.Version, d.Installed, d.Updated, d.Failed}
+	})
+}
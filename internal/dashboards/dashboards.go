@@ -0,0 +1,232 @@
+// Package dashboards defines the widgets users can place in their own dashboards, the
+// dimensions the computers can be broken down by and how each value of a dimension is
+// linked to the computers list filtered by it.
+package dashboards
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/open-uem/openuem-console/internal/lifecycle"
+	"github.com/open-uem/openuem-console/internal/views/filters"
+)
+
+// Kinds of widget
+const (
+	// KindCounter shows how many computers match the filters of the widget
+	KindCounter = "counter"
+	// KindPie shows the share of computers for each value of a dimension
+	KindPie = "pie"
+	// KindBar shows the number of computers for each value of a dimension
+	KindBar = "bar"
+	// KindTop lists the values of a dimension with most computers
+	KindTop = "top"
+)
+
+// Kinds contains the widgets of the catalog in the order they're offered
+var Kinds = []string{KindCounter, KindPie, KindBar, KindTop}
+
+// Dimensions the computers can be broken down by
+const (
+	DimensionOS           = "os"
+	DimensionOSVersion    = "os_version"
+	DimensionManufacturer = "manufacturer"
+	DimensionModel        = "model"
+	DimensionRemote       = "remote"
+	DimensionTag          = "tag"
+	DimensionApp          = "app"
+	DimensionHardwareAge  = "hardware_age"
+	DimensionWarranty     = "warranty"
+)
+
+// Dimensions contains the dimensions of the catalog, besides one for each metadata field of the tenant
+var Dimensions = []string{DimensionOS, DimensionOSVersion, DimensionManufacturer, DimensionModel, DimensionRemote, DimensionTag, DimensionApp, DimensionHardwareAge, DimensionWarranty}
+
+// DefaultLimit is the number of values shown by charts and lists if the widget doesn't set it
+const DefaultLimit = 10
+
+// MaxLimit is the highest number of values a widget can show
+const MaxLimit = 50
+
+var (
+	ErrInvalidKind      = errors.New("the kind of widget is not valid")
+	ErrInvalidDimension = errors.New("the dimension of the widget is not valid")
+	ErrInvalidLimit     = errors.New("the number of values must be between 1 and 50")
+)
+
+const metadataPrefix = "metadata_"
+
+// MetadataDimension returns the dimension that breaks computers down by a metadata field
+func MetadataDimension(orgMetadataID int) string {
+	return fmt.Sprintf("%s%d", metadataPrefix, orgMetadataID)
+}
+
+// MetadataID returns the metadata field of a dimension, false if it isn't a metadata dimension
+func MetadataID(dimension string) (int, bool) {
+	value, found := strings.CutPrefix(dimension, metadataPrefix)
+	if !found {
+		return 0, false
+	}
+
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, false
+	}
+	return id, true
+}
+
+// Validate checks a widget of the catalog. Counters don't use a dimension nor a limit,
+// metadata dimensions must belong to one of the metadata fields of the tenant
+func Validate(kind, dimension string, limit int, orgMetadataIDs []int) error {
+	if !slices.Contains(Kinds, kind) {
+		return ErrInvalidKind
+	}
+
+	if kind == KindCounter {
+		return nil
+	}
+
+	if id, ok := MetadataID(dimension); ok {
+		if !slices.Contains(orgMetadataIDs, id) {
+			return ErrInvalidDimension
+		}
+	} else if !slices.Contains(Dimensions, dimension) {
+		return ErrInvalidDimension
+	}
+
+	if limit < 1 || limit > MaxLimit {
+		return ErrInvalidLimit
+	}
+
+	return nil
+}
+
+// indexedFilter matches the checkboxes of the option filters of the computers list, e.g.
+// filterByAgentOS2, whose number is the position of the option in the list
+var indexedFilter = regexp.MustCompile(`^filterBy([A-Za-z]+?)(\d+)$`)
+
+// Options returns the values selected in an option filter of the computers list, e.g. the
+// values of filterByAgentOS0, filterByAgentOS1... for the AgentOS filter
+func Options(values url.Values, filter string) []string {
+	options := []string{}
+	for key := range values {
+		if m := indexedFilter.FindStringSubmatch(key); m != nil && m[1] == filter {
+			if value := strings.TrimSpace(values.Get(key)); value != "" && !slices.Contains(options, value) {
+				options = append(options, value)
+			}
+		}
+	}
+	slices.Sort(options)
+	return options
+}
+
+// Numbered returns the numbers of a filter of the computers list that is numbered by id,
+// like the tags or the metadata fields, mapped to their values
+func Numbered(values url.Values, filter string) map[int]string {
+	numbered := map[int]string{}
+	for key := range values {
+		if m := indexedFilter.FindStringSubmatch(key); m != nil && m[1] == filter {
+			id, err := strconv.Atoi(m[2])
+			if err != nil {
+				continue
+			}
+			if value := strings.TrimSpace(values.Get(key)); value != "" {
+				numbered[id] = value
+			}
+		}
+	}
+	return numbered
+}
+
+// Filter reads the filters of the computers list saved in the query of a widget
+func Filter(query string) filters.AgentFilter {
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return filters.AgentFilter{}
+	}
+
+	f := filters.AgentFilter{
+		Nickname:              values.Get("filterByNickname"),
+		Username:              values.Get("filterByUsername"),
+		Search:                values.Get("filterBySearch"),
+		WithApplication:       values.Get("filterByApplication"),
+		AgentOSVersions:       Options(values, "AgentOS"),
+		OSVersions:            Options(values, "OSVersion"),
+		ComputerManufacturers: Options(values, "ComputerManufacturer"),
+		ComputerModels:        Options(values, "ComputerModel"),
+		IsRemote:              Options(values, "IsRemote"),
+		Metadata:              Numbered(values, "Metadata"),
+	}
+
+	// the options of the lifecycle filters are translation keys
+	for _, value := range Options(values, "HardwareAge") {
+		if age := strings.TrimPrefix(value, "lifecycle.age_"); slices.Contains(lifecycle.Ages, age) {
+			f.HardwareAges = append(f.HardwareAges, age)
+		}
+	}
+	for _, value := range Options(values, "Warranty") {
+		if warranty := strings.TrimPrefix(value, "lifecycle.warranty_"); slices.Contains(lifecycle.Warranties, warranty) {
+			f.Warranties = append(f.Warranties, warranty)
+		}
+	}
+
+	for id := range Numbered(values, "Tag") {
+		f.Tags = append(f.Tags, id)
+	}
+	slices.Sort(f.Tags)
+
+	return f
+}
+
+// DrillDown returns the query of the computers list that shows the computers of a widget
+// with the value of its dimension. The other filters of the widget are kept. The options
+// already selected in the filter of the dimension are replaced by the value, as the values
+// of the breakdown are always one of them
+func DrillDown(query, dimension, value string) string {
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		values = url.Values{}
+	}
+	values.Del("page")
+
+	indexed := func(filter, value string) {
+		for key := range values {
+			if m := indexedFilter.FindStringSubmatch(key); m != nil && m[1] == filter {
+				values.Del(key)
+			}
+		}
+		values.Set(fmt.Sprintf("filterBy%s0", filter), value)
+	}
+
+	switch dimension {
+	case DimensionOS:
+		indexed("AgentOS", value)
+	case DimensionOSVersion:
+		indexed("OSVersion", value)
+	case DimensionManufacturer:
+		indexed("ComputerManufacturer", value)
+	case DimensionModel:
+		indexed("ComputerModel", value)
+	case DimensionRemote:
+		indexed("IsRemote", value)
+	case DimensionHardwareAge:
+		indexed("HardwareAge", "lifecycle.age_"+value)
+	case DimensionWarranty:
+		indexed("Warranty", "lifecycle.warranty_"+value)
+	case DimensionTag:
+		values.Set("filterByTag"+value, value)
+	case DimensionApp:
+		values.Set("filterByApplication", value)
+	default:
+		if id, ok := MetadataID(dimension); ok {
+			values.Set(fmt.Sprintf("filterByMetadata%d", id), value)
+		}
+	}
+
+	return values.Encode()
+}
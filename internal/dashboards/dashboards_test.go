@@ -0,0 +1,85 @@
+package dashboards
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate(KindCounter, "", 0, nil), "counters don't need a dimension")
+	assert.NoError(t, Validate(KindPie, DimensionOS, DefaultLimit, nil))
+	assert.NoError(t, Validate(KindTop, MetadataDimension(3), 5, []int{3}))
+
+	assert.ErrorIs(t, Validate("gauge", DimensionOS, DefaultLimit, nil), ErrInvalidKind)
+	assert.ErrorIs(t, Validate(KindBar, "serial", DefaultLimit, nil), ErrInvalidDimension)
+	assert.ErrorIs(t, Validate(KindBar, MetadataDimension(4), DefaultLimit, []int{3}), ErrInvalidDimension, "metadata fields must belong to the tenant")
+	assert.ErrorIs(t, Validate(KindBar, DimensionOS, 0, nil), ErrInvalidLimit)
+	assert.ErrorIs(t, Validate(KindBar, DimensionOS, MaxLimit+1, nil), ErrInvalidLimit)
+}
+
+func TestMetadataID(t *testing.T) {
+	id, ok := MetadataID(MetadataDimension(12))
+	assert.True(t, ok)
+	assert.Equal(t, 12, id)
+
+	_, ok = MetadataID(DimensionOS)
+	assert.False(t, ok)
+
+	_, ok = MetadataID("metadata_x")
+	assert.False(t, ok)
+}
+
+func TestOptions(t *testing.T) {
+	values := url.Values{
+		"filterByAgentOS0":  {"windows"},
+		"filterByAgentOS3":  {"linux"},
+		"filterByAgentOS4":  {"windows"},
+		"filterByOSVersion": {"10"},
+		"filterByTag12":     {"12"},
+		"filterByMetadata2": {"B1"},
+	}
+
+	assert.Equal(t, []string{"linux", "windows"}, Options(values, "AgentOS"))
+	assert.Empty(t, Options(values, "OSVersion"), "filters without a number are not options")
+	assert.Equal(t, map[int]string{12: "12"}, Numbered(values, "Tag"))
+	assert.Equal(t, map[int]string{2: "B1"}, Numbered(values, "Metadata"))
+}
+
+func TestFilter(t *testing.T) {
+	f := Filter("filterByAgentOS1=windows&filterByWarranty2=lifecycle.warranty_expired&filterByWarranty3=lifecycle.warranty_x&filterByTag7=7&filterByTag3=3&filterByMetadata2=B1&filterByNickname=pc")
+
+	assert.Equal(t, []string{"windows"}, f.AgentOSVersions)
+	assert.Equal(t, []string{"expired"}, f.Warranties, "unknown lifecycle options are ignored")
+	assert.Equal(t, []int{3, 7}, f.Tags)
+	assert.Equal(t, map[int]string{2: "B1"}, f.Metadata)
+	assert.Equal(t, "pc", f.Nickname)
+
+	assert.Empty(t, Filter("").AgentOSVersions)
+}
+
+func TestDrillDown(t *testing.T) {
+	query := "filterByAgentOS0=windows&filterByAgentOS1=linux&filterByNickname=pc&page=3"
+
+	values, err := url.ParseQuery(DrillDown(query, DimensionOS, "linux"))
+	assert.NoError(t, err)
+	assert.Equal(t, url.Values{"filterByAgentOS0": {"linux"}, "filterByNickname": {"pc"}}, values, "the options of the dimension are replaced and the page is reset")
+
+	values, err = url.ParseQuery(DrillDown(query, DimensionWarranty, "expired"))
+	assert.NoError(t, err)
+	assert.Equal(t, "lifecycle.warranty_expired", values.Get("filterByWarranty0"))
+	assert.Equal(t, "windows", values.Get("filterByAgentOS0"), "other filters are kept")
+
+	values, err = url.ParseQuery(DrillDown("", DimensionTag, "7"))
+	assert.NoError(t, err)
+	assert.Equal(t, url.Values{"filterByTag7": {"7"}}, values)
+
+	values, err = url.ParseQuery(DrillDown("", MetadataDimension(2), "B1"))
+	assert.NoError(t, err)
+	assert.Equal(t, url.Values{"filterByMetadata2": {"B1"}}, values)
+
+	values, err = url.ParseQuery(DrillDown("", DimensionApp, "Firefox"))
+	assert.NoError(t, err)
+	assert.Equal(t, url.Values{"filterByApplication": {"Firefox"}}, values)
+}
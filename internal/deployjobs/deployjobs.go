@@ -0,0 +1,102 @@
+// Package deployjobs tracks the package installs and uninstalls requested for several
+// agents at once. A job groups the agents of one request and the result of each agent
+// is read from the deployment the agent reports back.
+package deployjobs
+
+import (
+	"time"
+)
+
+// Actions of a deployment job
+const (
+	ActionInstall   = "install"
+	ActionUninstall = "uninstall"
+)
+
+// Status of an agent in a deployment job
+const (
+	TargetPending   = "pending"
+	TargetSent      = "sent"
+	TargetSucceeded = "succeeded"
+	TargetFailed    = "failed"
+	TargetTimedOut  = "timed_out"
+	TargetCancelled = "cancelled"
+)
+
+// TargetStatuses contains the status an agent goes through in a deployment job
+var TargetStatuses = []string{TargetPending, TargetSent, TargetSucceeded, TargetFailed, TargetTimedOut, TargetCancelled}
+
+// CheckInterval is how often the agents of running jobs are checked for a result
+const CheckInterval = time.Minute
+
+// Timeout is how long to wait for an agent to report the result once the request was sent
+const Timeout = 2 * time.Hour
+
+// Progress counts the agents of a job in each status
+type Progress struct {
+	Pending   int
+	Sent      int
+	Succeeded int
+	Failed    int
+	TimedOut  int
+	Cancelled int
+}
+
+// Total returns the number of agents in the job
+func (p Progress) Total() int {
+	return p.Pending + p.Sent + p.Succeeded + p.Failed + p.TimedOut + p.Cancelled
+}
+
+// Finished reports if every agent of the job has a result
+func (p Progress) Finished() bool {
+	return p.Pending+p.Sent == 0
+}
+
+// Add counts count agents with the given status
+func (p *Progress) Add(status string, count int) {
+	switch status {
+	case TargetPending:
+		p.Pending += count
+	case TargetSent:
+		p.Sent += count
+	case TargetSucceeded:
+		p.Succeeded += count
+	case TargetFailed:
+		p.Failed += count
+	case TargetTimedOut:
+		p.TimedOut += count
+	case TargetCancelled:
+		p.Cancelled += count
+	}
+}
+
+// Retryable reports if the request can be sent again to an agent with the given status
+func Retryable(status string) bool {
+	return status == TargetFailed || status == TargetTimedOut
+}
+
+// Deployment is what the agent reported about the package. Found is false when the
+// agent has no deployment for the package, which is how a successful uninstall ends
+type Deployment struct {
+	Found     bool
+	Installed time.Time
+	Failed    bool
+	Info      string
+}
+
+// Resolve returns the status of an agent the request was sent to at the given time and
+// the reason when it failed. It returns TargetSent while the agent has no result yet
+func Resolve(action string, sent time.Time, d Deployment, now time.Time) (string, string) {
+	switch {
+	case d.Found && d.Failed:
+		return TargetFailed, d.Info
+	case action == ActionInstall && d.Found && !d.Installed.IsZero():
+		return TargetSucceeded, ""
+	case action == ActionUninstall && !d.Found:
+		return TargetSucceeded, ""
+	case now.Sub(sent) > Timeout:
+		return TargetTimedOut, ""
+	default:
+		return TargetSent, ""
+	}
+}
@@ -0,0 +1,40 @@
+package deployjobs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolve(t *testing.T) {
+	now := time.Now()
+	sent := now.Add(-time.Minute)
+
+	status, _ := Resolve(ActionInstall, sent, Deployment{Found: true}, now)
+	assert.Equal(t, TargetSent, status)
+
+	status, _ = Resolve(ActionInstall, sent, Deployment{Found: true, Installed: now}, now)
+	assert.Equal(t, TargetSucceeded, status)
+
+	status, reason := Resolve(ActionInstall, sent, Deployment{Found: true, Failed: true, Info: "exit code 1"}, now)
+	assert.Equal(t, TargetFailed, status)
+	assert.Equal(t, "exit code 1", reason)
+
+	status, _ = Resolve(ActionUninstall, sent, Deployment{}, now)
+	assert.Equal(t, TargetSucceeded, status)
+
+	status, _ = Resolve(ActionUninstall, now.Add(-Timeout-time.Minute), Deployment{Found: true}, now)
+	assert.Equal(t, TargetTimedOut, status)
+}
+
+func TestProgress(t *testing.T) {
+	p := Progress{}
+	p.Add(TargetSent, 2)
+	p.Add(TargetFailed, 1)
+	assert.Equal(t, 3, p.Total())
+	assert.False(t, p.Finished())
+
+	p = Progress{Succeeded: 2, TimedOut: 1}
+	assert.True(t, p.Finished())
+}
@@ -0,0 +1,260 @@
+// Package directory authenticates console users against an LDAP or Active Directory
+// server and reads the members of the group that grants access to the console, so the
+// console users can be kept in sync with the directory.
+package directory
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// Default values of the settings, they match the attributes of Active Directory
+const (
+	DefaultUserFilter        = "(&(objectCategory=person)(objectClass=user))"
+	DefaultUsernameAttribute = "sAMAccountName"
+	DefaultNameAttribute     = "displayName"
+	DefaultEmailAttribute    = "mail"
+	DefaultPhoneAttribute    = "telephoneNumber"
+)
+
+// SyncInterval is how often the console users are synced with the directory
+const SyncInterval = time.Hour
+
+// Timeout limits how long the console waits for the directory server
+const Timeout = 10 * time.Second
+
+// pageSize is the number of entries requested at once when the members are read
+const pageSize = 500
+
+var (
+	ErrInvalidCredentials = errors.New("the username or password is not valid")
+	ErrUserNotFound       = errors.New("the user was not found in the directory or is not allowed to use the console")
+	ErrAmbiguousUser      = errors.New("more than one entry of the directory matches the username")
+	ErrNoMembers          = errors.New("the directory returned no users allowed to use the console, no user has been disabled")
+)
+
+// Config holds the connection and the search settings of the directory. GroupFilter is
+// the filter that users must match to use the console, usually a memberOf clause
+type Config struct {
+	URL                string
+	StartTLS           bool
+	InsecureSkipVerify bool
+	BindDN             string
+	BindPassword       string
+	SearchBase         string
+	UserFilter         string
+	GroupFilter        string
+	UsernameAttribute  string
+	NameAttribute      string
+	EmailAttribute     string
+	PhoneAttribute     string
+}
+
+// Entry is a user read from the directory, Username is lowercased as directories
+// compare usernames without case
+type Entry struct {
+	DN       string
+	Username string
+	Name     string
+	Email    string
+	Phone    string
+}
+
+// ValidURL reports if the address is an ldap:// or ldaps:// URL with a host
+func ValidURL(address string) bool {
+	u, err := url.Parse(address)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "ldap" || u.Scheme == "ldaps") && u.Host != ""
+}
+
+// ValidFilter reports if the filter can be sent to the directory, an empty
+// filter is valid as it's not added to the searches
+func ValidFilter(filter string) bool {
+	if filter == "" {
+		return true
+	}
+	_, err := ldap.CompileFilter(filter)
+	return err == nil
+}
+
+// LoginFilter returns the filter that finds the user allowed to use the console
+// with the given username
+func (c Config) LoginFilter(username string) string {
+	return c.filter(fmt.Sprintf("(%s=%s)", c.UsernameAttribute, ldap.EscapeFilter(username)))
+}
+
+// MembersFilter returns the filter that finds every user allowed to use the console
+func (c Config) MembersFilter() string {
+	return c.filter("")
+}
+
+func (c Config) filter(clause string) string {
+	filters := []string{}
+	for _, f := range []string{c.UserFilter, c.GroupFilter, clause} {
+		if f != "" {
+			filters = append(filters, f)
+		}
+	}
+
+	switch len(filters) {
+	case 0:
+		return "(objectClass=*)"
+	case 1:
+		return filters[0]
+	default:
+		return "(&" + strings.Join(filters, "") + ")"
+	}
+}
+
+func (c Config) attributes() []string {
+	attributes := []string{}
+	for _, a := range []string{c.UsernameAttribute, c.NameAttribute, c.EmailAttribute, c.PhoneAttribute} {
+		if a != "" {
+			attributes = append(attributes, a)
+		}
+	}
+	return attributes
+}
+
+// NewEntry reads the mapped attributes of a directory entry
+func (c Config) NewEntry(e *ldap.Entry) Entry {
+	entry := Entry{
+		DN:       e.DN,
+		Username: strings.ToLower(e.GetEqualFoldAttributeValue(c.UsernameAttribute)),
+	}
+
+	if c.NameAttribute != "" {
+		entry.Name = e.GetEqualFoldAttributeValue(c.NameAttribute)
+	}
+	if c.EmailAttribute != "" {
+		entry.Email = e.GetEqualFoldAttributeValue(c.EmailAttribute)
+	}
+	if c.PhoneAttribute != "" {
+		entry.Phone = e.GetEqualFoldAttributeValue(c.PhoneAttribute)
+	}
+
+	return entry
+}
+
+// Connect opens a connection to the directory and binds with the service account
+func Connect(c Config) (*ldap.Conn, error) {
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName:         u.Hostname(),
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+
+	conn, err := ldap.DialURL(c.URL,
+		ldap.DialWithDialer(&net.Dialer{Timeout: Timeout}),
+		ldap.DialWithTLSConfig(tlsConfig),
+	)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(Timeout)
+
+	if c.StartTLS && u.Scheme == "ldap" {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	if c.BindDN == "" {
+		err = conn.UnauthenticatedBind("")
+	} else {
+		err = conn.Bind(c.BindDN, c.BindPassword)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// Authenticate finds the user allowed to use the console with the given username and
+// checks its password binding as the user
+func Authenticate(c Config, username, password string) (*Entry, error) {
+	// an empty password would be an unauthenticated bind, that most servers accept
+	if username == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	conn, err := Connect(c)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	result, err := conn.Search(ldap.NewSearchRequest(
+		c.SearchBase, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, int(Timeout.Seconds()), false,
+		c.LoginFilter(username), c.attributes(), nil,
+	))
+	if err != nil && !ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+		return nil, err
+	}
+
+	switch {
+	case result == nil || len(result.Entries) == 0:
+		return nil, ErrUserNotFound
+	case len(result.Entries) > 1:
+		return nil, ErrAmbiguousUser
+	}
+
+	entry := c.NewEntry(result.Entries[0])
+	if entry.Username == "" {
+		return nil, ErrUserNotFound
+	}
+
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+// Members returns the users allowed to use the console. Entries without a username
+// are skipped
+func Members(c Config) ([]Entry, error) {
+	conn, err := Connect(c)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	result, err := conn.SearchWithPaging(ldap.NewSearchRequest(
+		c.SearchBase, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
+		c.MembersFilter(), c.attributes(), nil,
+	), pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	members := []Entry{}
+	for _, e := range result.Entries {
+		entry := c.NewEntry(e)
+		if entry.Username == "" {
+			continue
+		}
+		members = append(members, entry)
+	}
+
+	return members, nil
+}
@@ -0,0 +1,70 @@
+package directory
+
+import (
+	"testing"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidURL(t *testing.T) {
+	assert.True(t, ValidURL("ldap://dc1.example.com"))
+	assert.True(t, ValidURL("ldaps://dc1.example.com:636"))
+	assert.False(t, ValidURL("https://dc1.example.com"))
+	assert.False(t, ValidURL("dc1.example.com"))
+	assert.False(t, ValidURL(""))
+}
+
+func TestValidFilter(t *testing.T) {
+	assert.True(t, ValidFilter(""))
+	assert.True(t, ValidFilter(DefaultUserFilter))
+	assert.True(t, ValidFilter("(memberOf:1.2.840.113556.1.4.1941:=CN=OpenUEM,OU=Groups,DC=example,DC=com)"))
+	assert.False(t, ValidFilter("(memberOf=CN=OpenUEM"))
+	assert.False(t, ValidFilter("memberOf"))
+}
+
+func TestFilters(t *testing.T) {
+	c := Config{
+		UserFilter:        "(objectClass=user)",
+		GroupFilter:       "(memberOf=CN=OpenUEM,DC=example,DC=com)",
+		UsernameAttribute: "sAMAccountName",
+	}
+
+	assert.Equal(t, "(&(objectClass=user)(memberOf=CN=OpenUEM,DC=example,DC=com)(sAMAccountName=jdoe))", c.LoginFilter("jdoe"))
+	assert.Equal(t, "(&(objectClass=user)(memberOf=CN=OpenUEM,DC=example,DC=com))", c.MembersFilter())
+	assert.Equal(t, "(&(objectClass=user)(memberOf=CN=OpenUEM,DC=example,DC=com)(sAMAccountName=\\2a\\29\\28uid=\\2a))", c.LoginFilter("*)(uid=*"), "username should be escaped")
+
+	c.GroupFilter = ""
+	assert.Equal(t, "(objectClass=user)", c.MembersFilter())
+
+	c.UserFilter = ""
+	assert.Equal(t, "(objectClass=*)", c.MembersFilter())
+	assert.Equal(t, "(sAMAccountName=jdoe)", c.LoginFilter("jdoe"))
+}
+
+func TestNewEntry(t *testing.T) {
+	c := Config{
+		UsernameAttribute: DefaultUsernameAttribute,
+		NameAttribute:     DefaultNameAttribute,
+		EmailAttribute:    DefaultEmailAttribute,
+	}
+
+	e := ldap.NewEntry("CN=John Doe,DC=example,DC=com", map[string][]string{
+		"samaccountname":  {"JDoe"},
+		"displayName":     {"John Doe"},
+		"mail":            {"jdoe@example.com"},
+		"telephoneNumber": {"555-0100"},
+	})
+
+	entry := c.NewEntry(e)
+	assert.Equal(t, "CN=John Doe,DC=example,DC=com", entry.DN)
+	assert.Equal(t, "jdoe", entry.Username, "username should be lowercased and read without case")
+	assert.Equal(t, "John Doe", entry.Name)
+	assert.Equal(t, "jdoe@example.com", entry.Email)
+	assert.Equal(t, "", entry.Phone, "attributes that aren't mapped should not be read")
+}
+
+func TestAuthenticateRequiresPassword(t *testing.T) {
+	_, err := Authenticate(Config{URL: "ldap://127.0.0.1:1"}, "jdoe", "")
+	assert.ErrorIs(t, err, ErrInvalidCredentials, "an empty password should never reach the directory")
+}
@@ -0,0 +1,138 @@
+// Package inventory compares the inventory reported by an agent with the previous
+// one to record when hardware, the operating system or applications changed.
+package inventory
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// TypeHardware is used for the computer, its processor and memory modules
+	TypeHardware = "hardware"
+	// TypeOS is used for the operating system version, edition and domain
+	TypeOS = "os"
+	// TypeDisk is used for the physical disks and logical volumes
+	TypeDisk = "disk"
+	// TypeMonitor is used for the monitors connected to the computer
+	TypeMonitor = "monitor"
+	// TypePrinter is used for the installed printers
+	TypePrinter = "printer"
+	// TypeApp is used for the installed applications
+	TypeApp = "app"
+)
+
+// Types contains the change types in the order they're shown
+var Types = []string{TypeHardware, TypeOS, TypeDisk, TypeMonitor, TypePrinter, TypeApp}
+
+const (
+	ActionAdded   = "added"
+	ActionRemoved = "removed"
+	ActionChanged = "changed"
+)
+
+// Actions contains what can happen to an inventory item
+var Actions = []string{ActionAdded, ActionRemoved, ActionChanged}
+
+// DetectionInterval is how often the inventory of the agents is compared with the previous one
+const DetectionInterval = 10 * time.Minute
+
+// Snapshot holds the value of every inventory item of an agent by type and item name.
+// Values that change on every report, like the free space of a disk, aren't included
+type Snapshot map[string]map[string]string
+
+// Change is an item that was added, removed or whose value changed between two snapshots
+type Change struct {
+	Type   string
+	Action string
+	Item   string
+	Before string
+	After  string
+}
+
+// Add sets the value of an item. Items reported several times with the same name, like two
+// versions of an application, keep all their values sorted so the snapshot is stable
+func (s Snapshot) Add(changeType, item, value string) {
+	item = strings.TrimSpace(item)
+	if item == "" {
+		return
+	}
+
+	items, ok := s[changeType]
+	if !ok {
+		items = map[string]string{}
+		s[changeType] = items
+	}
+
+	previous, ok := items[item]
+	if !ok {
+		items[item] = strings.TrimSpace(value)
+		return
+	}
+
+	values := strings.Split(previous, ", ")
+	if value = strings.TrimSpace(value); !slices.Contains(values, value) {
+		values = append(values, value)
+		slices.Sort(values)
+	}
+	items[item] = strings.Join(values, ", ")
+}
+
+// Hash identifies the content of the snapshot, two snapshots with the same items have the same hash
+func (s Snapshot) Hash() string {
+	// maps are encoded with their keys sorted
+	data, _ := json.Marshal(s)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Diff returns the changes from before to after sorted by type and item
+func Diff(before, after Snapshot) []Change {
+	changes := []Change{}
+
+	for _, t := range types(before, after) {
+		old, current := before[t], after[t]
+
+		for item, value := range current {
+			previous, ok := old[item]
+			switch {
+			case !ok:
+				changes = append(changes, Change{Type: t, Action: ActionAdded, Item: item, After: value})
+			case previous != value:
+				changes = append(changes, Change{Type: t, Action: ActionChanged, Item: item, Before: previous, After: value})
+			}
+		}
+
+		for item, value := range old {
+			if _, ok := current[item]; !ok {
+				changes = append(changes, Change{Type: t, Action: ActionRemoved, Item: item, Before: value})
+			}
+		}
+	}
+
+	slices.SortStableFunc(changes, func(a, b Change) int {
+		if a.Type != b.Type {
+			return slices.Index(Types, a.Type) - slices.Index(Types, b.Type)
+		}
+		return strings.Compare(a.Item, b.Item)
+	})
+
+	return changes
+}
+
+// types returns the known types followed by any other type found in the snapshots
+func types(snapshots ...Snapshot) []string {
+	all := slices.Clone(Types)
+	for _, s := range snapshots {
+		for t := range s {
+			if !slices.Contains(all, t) {
+				all = append(all, t)
+			}
+		}
+	}
+	return all
+}
@@ -0,0 +1,57 @@
+package inventory
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotAdd(t *testing.T) {
+	s := Snapshot{}
+	s.Add(TypeApp, "Firefox", "128.0")
+	s.Add(TypeApp, "Python", "3.12")
+	s.Add(TypeApp, "Python", "3.11")
+	s.Add(TypeApp, "Python", "3.12")
+	s.Add(TypeApp, " ", "1.0")
+
+	assert.Equal(t, Snapshot{TypeApp: {"Firefox": "128.0", "Python": "3.11, 3.12"}}, s)
+}
+
+func TestSnapshotHash(t *testing.T) {
+	a := Snapshot{}
+	a.Add(TypeHardware, "Memory", "16384 MB")
+	a.Add(TypeApp, "Firefox", "128.0")
+
+	b := Snapshot{}
+	b.Add(TypeApp, "Firefox", "128.0")
+	b.Add(TypeHardware, "Memory", "16384 MB")
+	assert.Equal(t, a.Hash(), b.Hash(), "the order items are added doesn't matter")
+
+	b.Add(TypeApp, "Chrome", "127.0")
+	assert.NotEqual(t, a.Hash(), b.Hash())
+}
+
+func TestDiff(t *testing.T) {
+	before := Snapshot{
+		TypeHardware: {"Memory": "16384 MB", "Memory slot DIMM 1": "8 GB DDR4", "Memory slot DIMM 2": "8 GB DDR4"},
+		TypeMonitor:  {"Dell P2419H (ABC123)": ""},
+		TypeApp:      {"Firefox": "127.0", "7-Zip": "23.01"},
+	}
+	after := Snapshot{
+		TypeHardware: {"Memory": "8192 MB", "Memory slot DIMM 1": "8 GB DDR4"},
+		TypeMonitor:  {"Dell P2422H (XYZ789)": ""},
+		TypeApp:      {"Firefox": "128.0", "7-Zip": "23.01", "VLC": "3.0.21"},
+	}
+
+	assert.Equal(t, []Change{
+		{Type: TypeHardware, Action: ActionChanged, Item: "Memory", Before: "16384 MB", After: "8192 MB"},
+		{Type: TypeHardware, Action: ActionRemoved, Item: "Memory slot DIMM 2", Before: "8 GB DDR4"},
+		{Type: TypeMonitor, Action: ActionRemoved, Item: "Dell P2419H (ABC123)"},
+		{Type: TypeMonitor, Action: ActionAdded, Item: "Dell P2422H (XYZ789)"},
+		{Type: TypeApp, Action: ActionChanged, Item: "Firefox", Before: "127.0", After: "128.0"},
+		{Type: TypeApp, Action: ActionAdded, Item: "VLC", After: "3.0.21"},
+	}, Diff(before, after))
+
+	assert.Empty(t, Diff(after, after))
+	assert.Equal(t, 3, len(Diff(Snapshot{}, Snapshot{TypeApp: {"Firefox": "128.0", "7-Zip": "23.01", "VLC": "3.0.21"}})))
+}
@@ -0,0 +1,69 @@
+// Package licenses compares the seats purchased for a software product with the
+// agents of the tenant that have it installed.
+package licenses
+
+import (
+	"strings"
+	"time"
+
+	"github.com/open-uem/openuem-console/internal/compliance"
+)
+
+const (
+	// StatusCompliant licenses have a seat for every installation
+	StatusCompliant = "compliant"
+	// StatusOverDeployed licenses are installed in more agents than purchased seats
+	StatusOverDeployed = "over_deployed"
+	// StatusExpired licenses are past their expiry date
+	StatusExpired = "expired"
+)
+
+// Statuses contains the states of a license position, the ones that need attention last
+var Statuses = []string{StatusCompliant, StatusOverDeployed, StatusExpired}
+
+// ExpiryWarning is how long before the expiry date a license is shown as about to expire
+const ExpiryWarning = 30 * 24 * time.Hour
+
+// Rule is the product a license applies to. Patterns are matched like the ones of the
+// compliance policies: against the whole name or publisher ignoring case, * matches any
+// text and an empty pattern matches everything
+type Rule struct {
+	AppPattern       string
+	PublisherPattern string
+}
+
+// Matches reports if an application with the name and publisher is covered by the license
+func (r Rule) Matches(name, publisher string) bool {
+	return compliance.MatchPattern(r.AppPattern, name) && compliance.MatchPattern(r.PublisherPattern, publisher)
+}
+
+// Fragment returns the longest text between the wildcards of the pattern, which every
+// matching value contains. It lets the database filter the applications before they're
+// matched
+func Fragment(pattern string) string {
+	fragment := ""
+	for _, part := range strings.Split(strings.TrimSpace(pattern), "*") {
+		if len(part) > len(fragment) {
+			fragment = part
+		}
+	}
+	return fragment
+}
+
+// Status returns the state of a license with the seats, the number of agents where it's
+// installed and its expiry date, which is zero if it doesn't expire
+func Status(seats, installed int, expiry time.Time, now time.Time) string {
+	switch {
+	case !expiry.IsZero() && !now.Before(expiry):
+		return StatusExpired
+	case installed > seats:
+		return StatusOverDeployed
+	default:
+		return StatusCompliant
+	}
+}
+
+// ExpiresSoon reports if a license that hasn't expired yet will expire within ExpiryWarning
+func ExpiresSoon(expiry time.Time, now time.Time) bool {
+	return !expiry.IsZero() && now.Before(expiry) && expiry.Sub(now) <= ExpiryWarning
+}
@@ -0,0 +1,44 @@
+package licenses
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuleMatches(t *testing.T) {
+	office := Rule{AppPattern: "Microsoft Office*", PublisherPattern: "Microsoft*"}
+	assert.True(t, office.Matches("Microsoft Office Professional Plus 2021", "Microsoft Corporation"))
+	assert.False(t, office.Matches("Microsoft Office Professional Plus 2021", "Someone else"))
+	assert.False(t, office.Matches("Microsoft Teams", "Microsoft Corporation"))
+
+	jetbrains := Rule{PublisherPattern: "JetBrains*"}
+	assert.True(t, jetbrains.Matches("IntelliJ IDEA 2024.1", "JetBrains s.r.o."))
+}
+
+func TestFragment(t *testing.T) {
+	assert.Equal(t, "Microsoft Office", Fragment("Microsoft Office*"))
+	assert.Equal(t, " Acrobat ", Fragment("*Adobe* Acrobat *"))
+	assert.Equal(t, "", Fragment("*"))
+	assert.Equal(t, "", Fragment(""))
+}
+
+func TestStatus(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, StatusCompliant, Status(10, 10, time.Time{}, now))
+	assert.Equal(t, StatusOverDeployed, Status(10, 11, time.Time{}, now))
+	assert.Equal(t, StatusCompliant, Status(10, 5, now.Add(time.Hour), now))
+	assert.Equal(t, StatusExpired, Status(10, 1, now, now), "a license expires at its expiry date")
+	assert.Equal(t, StatusExpired, Status(10, 11, now.Add(-time.Hour), now), "expiration is reported before over deployment")
+}
+
+func TestExpiresSoon(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.True(t, ExpiresSoon(now.Add(24*time.Hour), now))
+	assert.False(t, ExpiresSoon(now.Add(60*24*time.Hour), now))
+	assert.False(t, ExpiresSoon(now.Add(-time.Hour), now), "expired licenses don't expire soon")
+	assert.False(t, ExpiresSoon(time.Time{}, now))
+}
@@ -0,0 +1,189 @@
+// Package lifecycle classifies computers by the age of their hardware and the state of
+// their warranty, and reads the purchase and warranty dates imported from CSV files.
+package lifecycle
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// AgeUnder1Year computers were purchased less than a year ago
+	AgeUnder1Year = "under_1"
+	// Age1To3Years computers were purchased between one and three years ago
+	Age1To3Years = "1_3"
+	// Age3To5Years computers were purchased between three and five years ago
+	Age3To5Years = "3_5"
+	// AgeOver5Years computers were purchased more than five years ago
+	AgeOver5Years = "over_5"
+	// AgeUnknown computers don't have a purchase date
+	AgeUnknown = "unknown"
+)
+
+// Ages contains the age brackets of the hardware from the newest to the oldest
+var Ages = []string{AgeUnder1Year, Age1To3Years, Age3To5Years, AgeOver5Years, AgeUnknown}
+
+const (
+	// WarrantyActive computers are covered by the warranty for longer than WarrantyWarning
+	WarrantyActive = "active"
+	// WarrantyExpiring computers are covered by the warranty for WarrantyWarning at most
+	WarrantyExpiring = "expiring"
+	// WarrantyExpired computers aren't covered by the warranty anymore
+	WarrantyExpired = "expired"
+	// WarrantyUnknown computers don't have a warranty expiry date
+	WarrantyUnknown = "unknown"
+)
+
+// Warranties contains the states of the warranty, the ones that need attention last
+var Warranties = []string{WarrantyActive, WarrantyExpiring, WarrantyExpired, WarrantyUnknown}
+
+// WarrantyWarning is how long before the warranty expires a computer is shown as expiring
+const WarrantyWarning = 90 * 24 * time.Hour
+
+// DateFormat is the format of the dates in the forms and in the imported files
+const DateFormat = "2006-01-02"
+
+// ageLimits are the years since the purchase that separate the age brackets
+var ageLimits = map[string][2]int{
+	AgeUnder1Year: {0, 1},
+	Age1To3Years:  {1, 3},
+	Age3To5Years:  {3, 5},
+	AgeOver5Years: {5, 0},
+}
+
+// Age returns the age bracket of a computer purchased on the given date
+func Age(purchase time.Time, now time.Time) string {
+	if purchase.IsZero() {
+		return AgeUnknown
+	}
+	for _, age := range Ages[:len(Ages)-1] {
+		after, until := AgeRange(age, now)
+		if (after.IsZero() || purchase.After(after)) && (until.IsZero() || !purchase.After(until)) {
+			return age
+		}
+	}
+	return AgeUnknown
+}
+
+// AgeRange returns the purchase dates of the computers in an age bracket, which were
+// purchased after the first date and up to the second one. A zero date means that the
+// range has no limit on that side. Both dates are zero for AgeUnknown
+func AgeRange(age string, now time.Time) (after time.Time, until time.Time) {
+	limits, ok := ageLimits[age]
+	if !ok {
+		return time.Time{}, time.Time{}
+	}
+	if limits[1] > 0 {
+		after = now.AddDate(-limits[1], 0, 0)
+	}
+	if limits[0] > 0 {
+		until = now.AddDate(-limits[0], 0, 0)
+	}
+	return after, until
+}
+
+// Warranty returns the state of a warranty that expires on the given date
+func Warranty(expiry time.Time, now time.Time) string {
+	switch {
+	case expiry.IsZero():
+		return WarrantyUnknown
+	case !now.Before(expiry):
+		return WarrantyExpired
+	case expiry.Sub(now) <= WarrantyWarning:
+		return WarrantyExpiring
+	default:
+		return WarrantyActive
+	}
+}
+
+// WarrantyRange returns the expiry dates of the warranties in a state, which expire after
+// the first date and up to the second one. A zero date means that the range has no limit
+// on that side. Both dates are zero for WarrantyUnknown
+func WarrantyRange(warranty string, now time.Time) (after time.Time, until time.Time) {
+	switch warranty {
+	case WarrantyActive:
+		return now.Add(WarrantyWarning), time.Time{}
+	case WarrantyExpiring:
+		return now, now.Add(WarrantyWarning)
+	case WarrantyExpired:
+		return time.Time{}, now
+	default:
+		return time.Time{}, time.Time{}
+	}
+}
+
+var (
+	// ErrWrongFormat is returned if a line of the file doesn't have the expected columns
+	ErrWrongFormat = errors.New("the line doesn't have the serial number, purchase date, warranty expiry and planned replacement columns")
+	// ErrEmptySerial is returned if a line of the file doesn't have a serial number
+	ErrEmptySerial = errors.New("the serial number is empty")
+	// ErrInvalidDate is wrapped by the errors of the dates that don't use the YYYY-MM-DD format
+	ErrInvalidDate = errors.New("the date is not valid")
+)
+
+// DateError is returned if a date doesn't use the YYYY-MM-DD format
+type DateError struct {
+	Value string
+}
+
+func (e *DateError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrInvalidDate, e.Value)
+}
+
+func (e *DateError) Unwrap() error {
+	return ErrInvalidDate
+}
+
+// Record is a line of a file with lifecycle dates, the computer is found by its serial
+// number. Dates that are empty in the file are zero
+type Record struct {
+	Serial             string
+	PurchaseDate       time.Time
+	WarrantyExpiry     time.Time
+	PlannedReplacement time.Time
+}
+
+// IsHeader reports if a line of a file is the header with the names of the columns
+func IsHeader(line []string) bool {
+	return len(line) > 0 && strings.EqualFold(strings.TrimSpace(line[0]), "serial")
+}
+
+// ParseRecord reads a line of a file with the serial number, the purchase date, the
+// warranty expiry and the planned replacement of a computer
+func ParseRecord(line []string) (Record, error) {
+	if len(line) != 4 {
+		return Record{}, ErrWrongFormat
+	}
+
+	r := Record{Serial: strings.TrimSpace(line[0])}
+	if r.Serial == "" {
+		return Record{}, ErrEmptySerial
+	}
+
+	dates := []*time.Time{&r.PurchaseDate, &r.WarrantyExpiry, &r.PlannedReplacement}
+	for i, date := range dates {
+		value, err := ParseDate(line[i+1])
+		if err != nil {
+			return Record{}, err
+		}
+		*date = value
+	}
+
+	return r, nil
+}
+
+// ParseDate reads a date with the YYYY-MM-DD format, an empty value is the zero date
+func ParseDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	date, err := time.Parse(DateFormat, value)
+	if err != nil {
+		return time.Time{}, &DateError{Value: value}
+	}
+	return date, nil
+}
@@ -0,0 +1,75 @@
+package lifecycle
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAge(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, AgeUnknown, Age(time.Time{}, now))
+	assert.Equal(t, AgeUnder1Year, Age(now.AddDate(0, -6, 0), now))
+	assert.Equal(t, Age1To3Years, Age(now.AddDate(-1, 0, 0), now), "a computer is one year old on the anniversary of its purchase")
+	assert.Equal(t, Age3To5Years, Age(now.AddDate(-4, 0, 0), now))
+	assert.Equal(t, AgeOver5Years, Age(now.AddDate(-5, 0, 0), now))
+	assert.Equal(t, AgeOver5Years, Age(now.AddDate(-12, 0, 0), now))
+}
+
+func TestAgeRange(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	after, until := AgeRange(Age1To3Years, now)
+	assert.Equal(t, now.AddDate(-3, 0, 0), after)
+	assert.Equal(t, now.AddDate(-1, 0, 0), until)
+
+	after, until = AgeRange(AgeOver5Years, now)
+	assert.True(t, after.IsZero())
+	assert.Equal(t, now.AddDate(-5, 0, 0), until)
+
+	after, until = AgeRange(AgeUnknown, now)
+	assert.True(t, after.IsZero())
+	assert.True(t, until.IsZero())
+}
+
+func TestWarranty(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, WarrantyUnknown, Warranty(time.Time{}, now))
+	assert.Equal(t, WarrantyExpired, Warranty(now, now), "a warranty expires at its expiry date")
+	assert.Equal(t, WarrantyExpiring, Warranty(now.Add(WarrantyWarning), now))
+	assert.Equal(t, WarrantyActive, Warranty(now.Add(WarrantyWarning+time.Hour), now))
+
+	// every state matches the dates of its range
+	for _, expiry := range []time.Time{now.AddDate(-1, 0, 0), now, now.AddDate(0, 1, 0), now.Add(WarrantyWarning), now.AddDate(2, 0, 0)} {
+		after, until := WarrantyRange(Warranty(expiry, now), now)
+		assert.True(t, after.IsZero() || expiry.After(after))
+		assert.True(t, until.IsZero() || !expiry.After(until))
+	}
+}
+
+func TestParseRecord(t *testing.T) {
+	r, err := ParseRecord([]string{" 5CG1234XYZ ", "2021-03-15", "2024-03-15", ""})
+	assert.NoError(t, err)
+	assert.Equal(t, "5CG1234XYZ", r.Serial)
+	assert.Equal(t, time.Date(2021, 3, 15, 0, 0, 0, 0, time.UTC), r.PurchaseDate)
+	assert.Equal(t, time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC), r.WarrantyExpiry)
+	assert.True(t, r.PlannedReplacement.IsZero())
+
+	_, err = ParseRecord([]string{"5CG1234XYZ", "2021-03-15"})
+	assert.ErrorIs(t, err, ErrWrongFormat)
+
+	_, err = ParseRecord([]string{"", "2021-03-15", "", ""})
+	assert.ErrorIs(t, err, ErrEmptySerial)
+
+	_, err = ParseRecord([]string{"5CG1234XYZ", "15/03/2021", "", ""})
+	assert.ErrorIs(t, err, ErrInvalidDate)
+	var dateErr *DateError
+	assert.ErrorAs(t, err, &dateErr)
+	assert.Equal(t, "15/03/2021", dateErr.Value)
+
+	assert.True(t, IsHeader([]string{"Serial", "purchase_date", "warranty_expiry", "planned_replacement"}))
+	assert.False(t, IsHeader([]string{"5CG1234XYZ", "2021-03-15", "", ""}))
+}
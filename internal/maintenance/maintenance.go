@@ -0,0 +1,191 @@
+// Package maintenance defines the weekly maintenance windows of sites and tags. Power
+// actions, package deployments, agent updates and profile runs requested for an agent
+// whose site or tags have maintenance windows are queued until the next window opens.
+package maintenance
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Targets a maintenance window can be attached to
+const (
+	TargetSite = "site"
+	TargetTag  = "tag"
+)
+
+// TargetTypes contains the targets a maintenance window can be attached to
+var TargetTypes = []string{TargetSite, TargetTag}
+
+// Actions that wait for a maintenance window
+const (
+	ActionPowerOff    = "power_off"
+	ActionReboot      = "reboot"
+	ActionInstall     = "install"
+	ActionUninstall   = "uninstall"
+	ActionAgentUpdate = "agent_update"
+	ActionRunProfile  = "run_profile"
+)
+
+// Actions contains the actions that wait for a maintenance window
+var Actions = []string{ActionPowerOff, ActionReboot, ActionInstall, ActionUninstall, ActionAgentUpdate, ActionRunProfile}
+
+// Status of a queued action
+const (
+	StatusQueued    = "queued"
+	StatusSent      = "sent"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+// Statuses contains the status a queued action goes through
+var Statuses = []string{StatusQueued, StatusSent, StatusFailed, StatusCancelled}
+
+// CheckInterval is how often the queue is checked to send the actions whose window has opened
+const CheckInterval = time.Minute
+
+// MaxDurationMinutes is the maximum length of a window, a window can end the day after it starts
+const MaxDurationMinutes = 24 * 60
+
+var (
+	ErrInvalidDays     = errors.New("a maintenance window needs at least one day of the week")
+	ErrInvalidStart    = errors.New("the start of a maintenance window must be a HH:MM time")
+	ErrInvalidDuration = errors.New("the duration of a maintenance window must be between 1 minute and 24 hours")
+)
+
+// Window is a weekly recurring period, in a time zone, in which queued actions can be sent
+type Window struct {
+	Days     []time.Weekday
+	Start    int
+	Duration int
+	Location *time.Location
+}
+
+// ParseDays parses the days of the week, 0 is Sunday as in time.Weekday
+func ParseDays(values []string) ([]time.Weekday, error) {
+	days := []time.Weekday{}
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+
+		day, err := strconv.Atoi(value)
+		if err != nil || day < int(time.Sunday) || day > int(time.Saturday) {
+			return nil, ErrInvalidDays
+		}
+		if !slices.Contains(days, time.Weekday(day)) {
+			days = append(days, time.Weekday(day))
+		}
+	}
+
+	if len(days) == 0 {
+		return nil, ErrInvalidDays
+	}
+
+	sort.Slice(days, func(i, j int) bool { return days[i] < days[j] })
+	return days, nil
+}
+
+// FormatDays returns the days as stored
+func FormatDays(days []time.Weekday) string {
+	items := []string{}
+	for _, d := range days {
+		items = append(items, strconv.Itoa(int(d)))
+	}
+	return strings.Join(items, ",")
+}
+
+// ParseStart returns the minutes after midnight of a HH:MM time
+func ParseStart(value string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, ErrInvalidStart
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// FormatStart returns the minutes after midnight as a HH:MM time
+func FormatStart(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
+
+// ValidDuration reports if the length in minutes of a window is allowed
+func ValidDuration(minutes int) bool {
+	return minutes > 0 && minutes <= MaxDurationMinutes
+}
+
+// Open reports if the window is open at the given time
+func (w Window) Open(t time.Time) bool {
+	local := t.In(w.location())
+
+	// a window that started the day before may still be open
+	for _, offset := range []int{-1, 0} {
+		start := w.startOn(local, offset)
+		if !slices.Contains(w.Days, start.Weekday()) {
+			continue
+		}
+		if !t.Before(start) && t.Before(start.Add(time.Duration(w.Duration)*time.Minute)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Next returns the given time if the window is open or the next time the window opens
+func (w Window) Next(t time.Time) time.Time {
+	if w.Open(t) {
+		return t
+	}
+
+	local := t.In(w.location())
+	for offset := 0; offset <= 7; offset++ {
+		start := w.startOn(local, offset)
+		if slices.Contains(w.Days, start.Weekday()) && start.After(t) {
+			return start
+		}
+	}
+
+	// a window without days never opens
+	return time.Time{}
+}
+
+// NextOpen returns the earliest time, from the given one, at which one of the windows is
+// open. If there are no windows actions aren't restricted and the given time is returned
+func NextOpen(windows []Window, t time.Time) time.Time {
+	if len(windows) == 0 {
+		return t
+	}
+
+	next := time.Time{}
+	for _, w := range windows {
+		n := w.Next(t)
+		if n.IsZero() {
+			continue
+		}
+		if next.IsZero() || n.Before(next) {
+			next = n
+		}
+	}
+
+	if next.IsZero() {
+		return t
+	}
+	return next
+}
+
+func (w Window) startOn(local time.Time, offset int) time.Time {
+	return time.Date(local.Year(), local.Month(), local.Day()+offset, w.Start/60, w.Start%60, 0, 0, w.location())
+}
+
+func (w Window) location() *time.Location {
+	if w.Location == nil {
+		return time.UTC
+	}
+	return w.Location
+}
@@ -0,0 +1,69 @@
+package maintenance
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDays(t *testing.T) {
+	days, err := ParseDays([]string{"5", "1", "", "1"})
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Weekday{time.Monday, time.Friday}, days)
+	assert.Equal(t, "1,5", FormatDays(days))
+
+	_, err = ParseDays([]string{})
+	assert.ErrorIs(t, err, ErrInvalidDays)
+
+	_, err = ParseDays([]string{"7"})
+	assert.ErrorIs(t, err, ErrInvalidDays)
+}
+
+func TestParseStart(t *testing.T) {
+	start, err := ParseStart("22:30")
+	assert.NoError(t, err)
+	assert.Equal(t, 22*60+30, start)
+	assert.Equal(t, "22:30", FormatStart(start))
+
+	_, err = ParseStart("25:00")
+	assert.ErrorIs(t, err, ErrInvalidStart)
+}
+
+func TestWindow(t *testing.T) {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	assert.NoError(t, err)
+
+	// Saturdays from 22:00 to 04:00 in Madrid
+	w := Window{Days: []time.Weekday{time.Saturday}, Start: 22 * 60, Duration: 6 * 60, Location: madrid}
+
+	saturday := time.Date(2025, 3, 15, 21, 0, 0, 0, madrid)
+	assert.False(t, w.Open(saturday))
+	assert.Equal(t, time.Date(2025, 3, 15, 22, 0, 0, 0, madrid), w.Next(saturday))
+
+	sundayMorning := time.Date(2025, 3, 16, 3, 0, 0, 0, madrid)
+	assert.True(t, w.Open(sundayMorning), "the window crosses midnight")
+	assert.Equal(t, sundayMorning, w.Next(sundayMorning))
+
+	sunday := time.Date(2025, 3, 16, 4, 0, 0, 0, madrid)
+	assert.False(t, w.Open(sunday))
+	assert.Equal(t, time.Date(2025, 3, 22, 22, 0, 0, 0, madrid), w.Next(sunday))
+
+	// the same instant in another time zone
+	assert.True(t, w.Open(sundayMorning.UTC()))
+}
+
+func TestNextOpen(t *testing.T) {
+	now := time.Date(2025, 3, 17, 10, 0, 0, 0, time.UTC) // Monday
+
+	assert.Equal(t, now, NextOpen(nil, now), "agents without windows aren't restricted")
+
+	windows := []Window{
+		{Days: []time.Weekday{time.Friday}, Start: 18 * 60, Duration: 60},
+		{Days: []time.Weekday{time.Wednesday}, Start: 2 * 60, Duration: 60},
+	}
+	assert.Equal(t, time.Date(2025, 3, 19, 2, 0, 0, 0, time.UTC), NextOpen(windows, now))
+
+	open := time.Date(2025, 3, 21, 18, 30, 0, 0, time.UTC)
+	assert.Equal(t, open, NextOpen(windows, open))
+}
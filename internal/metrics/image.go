@@ -0,0 +1,115 @@
+package metrics
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strconv"
+
+	"github.com/open-uem/openuem-console/internal/consoledb"
+)
+
+// imageMargin is the space in pixels around the plot of the trend image
+const imageMargin = 10
+
+// TrendImage draws the counters of the snapshots as lines, like the trend chart of the
+// dashboard, so it can be added to the PDF reports. The image has no text, the labels
+// are written by the report
+func TrendImage(items []consoledb.FleetMetrics, width int, height int) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+
+	plot := image.Rect(imageMargin, imageMargin, width-imageMargin, height-imageMargin)
+
+	grid := color.RGBA{R: 220, G: 220, B: 220, A: 255}
+	for i := 0; i <= 4; i++ {
+		y := plot.Max.Y - i*plot.Dy()/4
+		drawLine(img, plot.Min.X, y, plot.Max.X, y, grid)
+	}
+
+	highest := 1
+	for _, kpi := range KPIs {
+		for _, value := range Series(items, kpi) {
+			highest = max(highest, value)
+		}
+	}
+
+	point := func(i int, value int) (int, int) {
+		x := plot.Min.X + plot.Dx()/2
+		if len(items) > 1 {
+			x = plot.Min.X + i*plot.Dx()/(len(items)-1)
+		}
+		return x, plot.Max.Y - value*plot.Dy()/highest
+	}
+
+	for _, kpi := range KPIs {
+		c := Color(kpi)
+		values := Series(items, kpi)
+		for i, value := range values {
+			x, y := point(i, value)
+			if i == 0 {
+				// a single snapshot is drawn as a dot
+				drawLine(img, x-1, y, x+1, y, c)
+				continue
+			}
+			prevX, prevY := point(i-1, values[i-1])
+			drawLine(img, prevX, prevY, x, y, c)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// drawLine draws a line two pixels thick between two points
+func drawLine(img *image.RGBA, x0, y0, x1, y1 int, c color.Color) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+
+	e := dx + dy
+	for {
+		img.Set(x0, y0, c)
+		img.Set(x0, y0+1, c)
+		img.Set(x0+1, y0, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+// Color returns the color of the line of a counter
+func Color(kpi string) color.RGBA {
+	hex := KPIColors[kpi]
+	value, err := strconv.ParseUint(hex[min(1, len(hex)):], 16, 32)
+	if err != nil || len(hex) != 7 {
+		return color.RGBA{A: 255}
+	}
+	return color.RGBA{R: uint8(value >> 16), G: uint8(value >> 8), B: uint8(value), A: 255}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,98 @@
+// Package metrics defines the periods of the fleet trends and how the daily snapshots of
+// the fleet counters are taken and kept.
+package metrics
+
+import (
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/open-uem/openuem-console/internal/consoledb"
+)
+
+const (
+	// KPIAgents counts the admitted agents
+	KPIAgents = "agents"
+	// KPIReported24h counts the agents that reported in the last 24 hours
+	KPIReported24h = "reported_24h"
+	// KPIPendingUpdates counts the agents with pending system updates
+	KPIPendingUpdates = "pending_updates"
+	// KPIDisabledAntivirus counts the Windows agents whose antivirus is disabled
+	KPIDisabledAntivirus = "disabled_antivirus"
+	// KPIOutdatedAgents counts the agents running an older release than the newest one installed
+	KPIOutdatedAgents = "outdated_agents"
+)
+
+// KPIs contains the counters saved in each snapshot, in the order they're shown
+var KPIs = []string{KPIAgents, KPIReported24h, KPIPendingUpdates, KPIDisabledAntivirus, KPIOutdatedAgents}
+
+// KPIColors are the colors of the lines of each counter in the trend charts
+var KPIColors = map[string]string{
+	KPIAgents:            "#3288bd",
+	KPIReported24h:       "#66c2a5",
+	KPIPendingUpdates:    "#fdae61",
+	KPIDisabledAntivirus: "#9e0142",
+	KPIOutdatedAgents:    "#5e4fa2",
+}
+
+// Periods contains the days of history that the trend charts can show
+var Periods = []int{30, 90, 365}
+
+// DefaultPeriod is the period shown if none is selected
+const DefaultPeriod = 30
+
+// SnapshotInterval is how often the counters are saved, the snapshot of the current day
+// is replaced each time so the last value of the day is the one kept
+const SnapshotInterval = time.Hour
+
+// Retention is how long the snapshots are kept, a bit longer than the longest period
+const Retention = 366 * 24 * time.Hour
+
+// ParsePeriod returns the period selected in a query param, or the default one if it isn't
+// one of the supported periods
+func ParsePeriod(value string) int {
+	period, err := strconv.Atoi(value)
+	if err != nil || !slices.Contains(Periods, period) {
+		return DefaultPeriod
+	}
+	return period
+}
+
+// Day returns the day a snapshot taken at the given time belongs to. Days are saved in UTC
+// so the snapshots don't depend on the time zone of the database
+func Day(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Since returns the first day of a period that ends on the given time
+func Since(period int, now time.Time) time.Time {
+	return Day(now).AddDate(0, 0, 1-period)
+}
+
+// Value returns the counter of a snapshot
+func Value(snapshot consoledb.FleetMetrics, kpi string) int {
+	switch kpi {
+	case KPIAgents:
+		return snapshot.Agents
+	case KPIReported24h:
+		return snapshot.Reported24h
+	case KPIPendingUpdates:
+		return snapshot.PendingUpdates
+	case KPIDisabledAntivirus:
+		return snapshot.DisabledAntivirus
+	case KPIOutdatedAgents:
+		return snapshot.OutdatedAgents
+	default:
+		return 0
+	}
+}
+
+// Series returns the values of a counter in the snapshots
+func Series(items []consoledb.FleetMetrics, kpi string) []int {
+	values := []int{}
+	for _, item := range items {
+		values = append(values, Value(item, kpi))
+	}
+	return values
+}
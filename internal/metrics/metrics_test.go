@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePeriod(t *testing.T) {
+	assert.Equal(t, 90, ParsePeriod("90"))
+	assert.Equal(t, 365, ParsePeriod("365"))
+	assert.Equal(t, DefaultPeriod, ParsePeriod(""))
+	assert.Equal(t, DefaultPeriod, ParsePeriod("45"), "only the supported periods can be selected")
+	assert.Equal(t, DefaultPeriod, ParsePeriod("x"))
+}
+
+func TestDay(t *testing.T) {
+	madrid := time.FixedZone("CEST", 2*60*60)
+
+	assert.Equal(t, time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC), Day(time.Date(2026, 10, 18, 1, 30, 0, 0, madrid)), "days are in UTC")
+	assert.Equal(t, time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC), Day(time.Date(2026, 10, 18, 23, 59, 0, 0, time.UTC)))
+}
+
+func TestSince(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, time.Date(2026, 9, 19, 0, 0, 0, 0, time.UTC), Since(30, now), "the period includes the current day")
+	assert.Equal(t, Day(now), Since(1, now))
+}
+
+func TestSeries(t *testing.T) {
+	items := []consoledb.FleetMetrics{
+		{Agents: 10, PendingUpdates: 4, OutdatedAgents: 2},
+		{Agents: 12, PendingUpdates: 1, OutdatedAgents: 0},
+	}
+
+	assert.Equal(t, []int{10, 12}, Series(items, KPIAgents))
+	assert.Equal(t, []int{4, 1}, Series(items, KPIPendingUpdates))
+	assert.Equal(t, []int{0, 0}, Series(items, "unknown"))
+}
+
+func TestTrendImage(t *testing.T) {
+	for _, items := range [][]consoledb.FleetMetrics{
+		nil,
+		{{Agents: 3}},
+		{{Agents: 3, Reported24h: 1}, {Agents: 5, Reported24h: 5}, {Agents: 4}},
+	} {
+		data, err := TrendImage(items, 300, 120)
+		assert.NoError(t, err)
+
+		img, err := png.Decode(bytes.NewReader(data))
+		assert.NoError(t, err, "should be a PNG image")
+		assert.Equal(t, image.Rect(0, 0, 300, 120), img.Bounds())
+	}
+}
+
+func TestColor(t *testing.T) {
+	assert.Equal(t, color.RGBA{R: 0x32, G: 0x88, B: 0xbd, A: 255}, Color(KPIAgents))
+	assert.Equal(t, color.RGBA{A: 255}, Color("unknown"))
+}
@@ -0,0 +1,365 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	entsql "entgo.io/ent/dialect/sql"
+	"github.com/open-uem/ent/agent"
+	"github.com/open-uem/ent/antivirus"
+	"github.com/open-uem/ent/logicaldisk"
+	"github.com/open-uem/ent/predicate"
+	"github.com/open-uem/ent/site"
+	"github.com/open-uem/ent/systemupdate"
+	"github.com/open-uem/ent/tenant"
+	"github.com/open-uem/openuem-console/internal/alerts"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/views/filters"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+)
+
+var ErrAlertRuleNotFound = errors.New("the alert rule doesn't exist")
+
+var alertRuleColumns = []string{"id", "tenant_id", "site_id", "name", "type", "threshold", "recipients", "throttle_minutes", "enabled", "last_run", "created"}
+var alertHistoryColumns = []string{"id", "rule_id", "tenant_id", "site_id", "rule_name", "type", "subject", "hostname", "message", "recipients", "status", "error", "created"}
+
+func (m *Model) AddAlertRule(r consoledb.AlertRule) error {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Insert(consoledb.AlertRulesTable.Name).
+		Columns("tenant_id", "site_id", "name", "type", "threshold", "recipients", "throttle_minutes", "enabled", "created").
+		Values(r.TenantID, r.SiteID, r.Name, r.Type, r.Threshold, strings.Join(r.Recipients, ","), r.ThrottleMinutes, r.Enabled, time.Now()).
+		Query()
+
+	_, err := m.Driver.DB().ExecContext(context.Background(), query, args...)
+	return err
+}
+
+func (m *Model) GetAlertRules(tenantID int) ([]consoledb.AlertRule, error) {
+	return m.queryAlertRules(func(s *entsql.Selector) {
+		s.Where(entsql.EQ("tenant_id", tenantID)).OrderBy(entsql.Asc("name"))
+	})
+}
+
+// GetEnabledAlertRules returns the enabled rules of every tenant
+func (m *Model) GetEnabledAlertRules() ([]consoledb.AlertRule, error) {
+	return m.queryAlertRules(func(s *entsql.Selector) {
+		s.Where(entsql.EQ("enabled", true)).OrderBy(entsql.Asc("id"))
+	})
+}
+
+func (m *Model) GetAlertRule(id int, tenantID int) (consoledb.AlertRule, error) {
+	rules, err := m.queryAlertRules(func(s *entsql.Selector) {
+		s.Where(entsql.And(entsql.EQ("id", id), entsql.EQ("tenant_id", tenantID)))
+	})
+	if err != nil {
+		return consoledb.AlertRule{}, err
+	}
+
+	if len(rules) != 1 {
+		return consoledb.AlertRule{}, ErrAlertRuleNotFound
+	}
+
+	return rules[0], nil
+}
+
+func (m *Model) SetAlertRuleEnabled(id int, tenantID int, enabled bool) error {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Update(consoledb.AlertRulesTable.Name).
+		Set("enabled", enabled).
+		Where(entsql.And(entsql.EQ("id", id), entsql.EQ("tenant_id", tenantID))).
+		Query()
+
+	return m.execAffectingOne(query, args, ErrAlertRuleNotFound)
+}
+
+// DeleteAlertRule removes the rule, its history is kept as it stores the rule name
+func (m *Model) DeleteAlertRule(id int, tenantID int) error {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Delete(consoledb.AlertRulesTable.Name).
+		Where(entsql.And(entsql.EQ("id", id), entsql.EQ("tenant_id", tenantID))).
+		Query()
+
+	return m.execAffectingOne(query, args, ErrAlertRuleNotFound)
+}
+
+// ClaimAlertRule marks the rule as evaluated now if it hasn't been evaluated in the last
+// interval. It returns false if the rule isn't due or another console instance claimed it
+func (m *Model) ClaimAlertRule(id int, interval time.Duration) (bool, error) {
+	now := time.Now()
+
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Update(consoledb.AlertRulesTable.Name).
+		Set("last_run", now).
+		Where(entsql.And(
+			entsql.EQ("id", id),
+			entsql.Or(entsql.IsNull("last_run"), entsql.LTE("last_run", now.Add(-interval))),
+		)).
+		Query()
+
+	result, err := m.Driver.DB().ExecContext(context.Background(), query, args...)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n == 1, nil
+}
+
+// GetAlertMatches returns the agents, or their disks, that match the rule right now
+func (m *Model) GetAlertMatches(r consoledb.AlertRule) ([]alerts.Match, error) {
+	ctx := context.Background()
+	matches := []alerts.Match{}
+
+	inScope := agent.HasSiteWith(site.HasTenantWith(tenant.ID(r.TenantID)))
+	if r.SiteID != -1 {
+		inScope = agent.HasSiteWith(site.ID(r.SiteID), site.HasTenantWith(tenant.ID(r.TenantID)))
+	}
+	admitted := []predicate.Agent{inScope, agent.AgentStatusEQ(agent.AgentStatusEnabled)}
+
+	switch r.Type {
+	case alerts.TypeAgentNotReported:
+		agents, err := m.Client.Agent.Query().Where(append(admitted, agent.LastContactLT(time.Now().Add(-time.Duration(r.Threshold)*time.Hour)))...).All(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range agents {
+			matches = append(matches, alerts.Match{Subject: a.ID, Hostname: a.Hostname, Detail: a.LastContact.Format("2006-01-02 15:04")})
+		}
+	case alerts.TypeDiskUsage:
+		disks, err := m.Client.LogicalDisk.Query().Where(logicaldisk.UsageGTE(int8(r.Threshold)), logicaldisk.HasOwnerWith(admitted...)).WithOwner().All(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range disks {
+			if d.Edges.Owner == nil {
+				continue
+			}
+			matches = append(matches, alerts.Match{Subject: d.Edges.Owner.ID + ":" + d.Label, Hostname: d.Edges.Owner.Hostname, Detail: fmt.Sprintf("%s (%d%%)", d.Label, d.Usage)})
+		}
+	case alerts.TypeAntivirusOutdated:
+		agents, err := m.Client.Agent.Query().Where(append(admitted, agent.HasAntivirusWith(antivirus.IsUpdated(false)))...).WithAntivirus().All(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range agents {
+			name := ""
+			if a.Edges.Antivirus != nil {
+				name = a.Edges.Antivirus.Name
+			}
+			matches = append(matches, alerts.Match{Subject: a.ID, Hostname: a.Hostname, Detail: name})
+		}
+	case alerts.TypeUpdatesPending:
+		agents, err := m.Client.Agent.Query().Where(append(admitted, agent.HasSystemupdateWith(systemupdate.PendingUpdatesEQ(true), systemupdate.LastInstallLT(time.Now().AddDate(0, 0, -r.Threshold))))...).WithSystemupdate().All(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range agents {
+			lastInstall := ""
+			if a.Edges.Systemupdate != nil && !a.Edges.Systemupdate.LastInstall.IsZero() {
+				lastInstall = a.Edges.Systemupdate.LastInstall.Format("2006-01-02")
+			}
+			matches = append(matches, alerts.Match{Subject: a.ID, Hostname: a.Hostname, Detail: lastInstall})
+		}
+	}
+
+	return matches, nil
+}
+
+// GetAlertedSubjects returns the subjects the rule has sent an alert for since the given time
+func (m *Model) GetAlertedSubjects(ruleID int, since time.Time) (map[string]bool, error) {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Select("subject").
+		From(entsql.Table(consoledb.AlertHistoryTable.Name)).
+		Where(entsql.And(
+			entsql.EQ("rule_id", ruleID),
+			entsql.EQ("status", consoledb.AlertSent),
+			entsql.GTE("created", since),
+		)).
+		Query()
+
+	rows, err := m.Driver.DB().QueryContext(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subjects := map[string]bool{}
+	for rows.Next() {
+		var subject string
+		if err := rows.Scan(&subject); err != nil {
+			return nil, err
+		}
+		subjects[subject] = true
+	}
+
+	return subjects, rows.Err()
+}
+
+func (m *Model) AddAlertHistory(entries []consoledb.AlertHistoryEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	insert := entsql.Dialect(m.Driver.Dialect()).
+		Insert(consoledb.AlertHistoryTable.Name).
+		Columns(alertHistoryColumns[1:]...)
+
+	for _, e := range entries {
+		if e.Created.IsZero() {
+			e.Created = time.Now()
+		}
+		insert.Values(e.RuleID, e.TenantID, e.SiteID, e.RuleName, e.Type, e.Subject, e.Hostname, truncate(e.Message, 2000), truncate(e.Recipients, 2000), e.Status, truncate(e.Error, 2000), e.Created)
+	}
+
+	query, args := insert.Query()
+	_, err := m.Driver.DB().ExecContext(context.Background(), query, args...)
+	return err
+}
+
+func (m *Model) CountAlertHistory(tenantID int, f filters.AlertHistoryFilter) (int, error) {
+	var count int
+
+	selector := entsql.Dialect(m.Driver.Dialect()).
+		Select(entsql.Count("*")).
+		From(entsql.Table(consoledb.AlertHistoryTable.Name)).
+		Where(entsql.EQ("tenant_id", tenantID))
+	applyAlertHistoryFilter(selector, f)
+
+	query, args := selector.Query()
+	if err := m.Driver.DB().QueryRowContext(context.Background(), query, args...).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (m *Model) GetAlertHistoryByPage(p partials.PaginationAndSort, tenantID int, f filters.AlertHistoryFilter) ([]consoledb.AlertHistoryEntry, error) {
+	return m.queryAlertHistory(func(s *entsql.Selector) {
+		s.Where(entsql.EQ("tenant_id", tenantID))
+		applyAlertHistoryFilter(s, f)
+
+		column := "created"
+		switch p.SortBy {
+		case "rule":
+			column = "rule_name"
+		case "hostname":
+			column = "hostname"
+		case "status":
+			column = "status"
+		}
+
+		if p.SortOrder == "asc" {
+			s.OrderBy(entsql.Asc(column), entsql.Asc("id"))
+		} else {
+			s.OrderBy(entsql.Desc(column), entsql.Desc("id"))
+		}
+
+		s.Limit(p.PageSize).Offset((p.CurrentPage - 1) * p.PageSize)
+	})
+}
+
+// DeleteOldAlertHistory removes the history entries created before the given time
+func (m *Model) DeleteOldAlertHistory(before time.Time) error {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Delete(consoledb.AlertHistoryTable.Name).
+		Where(entsql.LT("created", before)).
+		Query()
+
+	_, err := m.Driver.DB().ExecContext(context.Background(), query, args...)
+	return err
+}
+
+func applyAlertHistoryFilter(s *entsql.Selector, f filters.AlertHistoryFilter) {
+	if len(f.Rule) > 0 {
+		s.Where(entsql.ContainsFold("rule_name", f.Rule))
+	}
+
+	if len(f.Hostname) > 0 {
+		s.Where(entsql.ContainsFold("hostname", f.Hostname))
+	}
+
+	if len(f.Status) > 0 {
+		s.Where(entsql.EQ("status", f.Status))
+	}
+
+	if len(f.CreatedFrom) > 0 {
+		dateFrom, err := time.Parse("2006-01-02", f.CreatedFrom)
+		if err == nil {
+			s.Where(entsql.GTE("created", dateFrom))
+		}
+	}
+
+	if len(f.CreatedTo) > 0 {
+		dateTo, err := time.Parse("2006-01-02", f.CreatedTo)
+		if err == nil {
+			// include the whole day
+			s.Where(entsql.LT("created", dateTo.AddDate(0, 0, 1)))
+		}
+	}
+}
+
+func (m *Model) queryAlertRules(modifier func(s *entsql.Selector)) ([]consoledb.AlertRule, error) {
+	selector := entsql.Dialect(m.Driver.Dialect()).
+		Select(alertRuleColumns...).
+		From(entsql.Table(consoledb.AlertRulesTable.Name))
+	modifier(selector)
+
+	query, args := selector.Query()
+	rows, err := m.Driver.DB().QueryContext(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []consoledb.AlertRule{}
+	for rows.Next() {
+		var r consoledb.AlertRule
+		var recipients string
+		var lastRun sql.NullTime
+		if err := rows.Scan(&r.ID, &r.TenantID, &r.SiteID, &r.Name, &r.Type, &r.Threshold, &recipients, &r.ThrottleMinutes, &r.Enabled, &lastRun, &r.Created); err != nil {
+			return nil, err
+		}
+		if recipients != "" {
+			r.Recipients = strings.Split(recipients, ",")
+		}
+		if lastRun.Valid {
+			r.LastRun = lastRun.Time
+		}
+		rules = append(rules, r)
+	}
+
+	return rules, rows.Err()
+}
+
+func (m *Model) queryAlertHistory(modifier func(s *entsql.Selector)) ([]consoledb.AlertHistoryEntry, error) {
+	selector := entsql.Dialect(m.Driver.Dialect()).
+		Select(alertHistoryColumns...).
+		From(entsql.Table(consoledb.AlertHistoryTable.Name))
+	modifier(selector)
+
+	query, args := selector.Query()
+	rows, err := m.Driver.DB().QueryContext(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []consoledb.AlertHistoryEntry{}
+	for rows.Next() {
+		var e consoledb.AlertHistoryEntry
+		if err := rows.Scan(&e.ID, &e.RuleID, &e.TenantID, &e.SiteID, &e.RuleName, &e.Type, &e.Subject, &e.Hostname, &e.Message, &e.Recipients, &e.Status, &e.Error, &e.Created); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
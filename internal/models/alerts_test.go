@@ -0,0 +1,190 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/open-uem/ent/agent"
+	"github.com/open-uem/openuem-console/internal/alerts"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/views/filters"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type AlertsTestSuite struct {
+	suite.Suite
+	model    Model
+	p        partials.PaginationAndSort
+	tenantID int
+	siteID   int
+}
+
+func (suite *AlertsTestSuite) SetupTest() {
+	suite.model = newTestModel(suite.T())
+	suite.p = partials.PaginationAndSort{CurrentPage: 1, PageSize: 5}
+	client := suite.model.Client
+
+	t, err := suite.model.CreateDefaultTenant()
+	assert.NoError(suite.T(), err, "should create default tenant")
+	suite.tenantID = t.ID
+
+	s, err := suite.model.CreateDefaultSite(t)
+	assert.NoError(suite.T(), err, "should create default site")
+	suite.siteID = s.ID
+
+	other, err := client.Site.Create().SetDescription("Other").SetTenantID(t.ID).Save(context.Background())
+	assert.NoError(suite.T(), err, "should create site")
+
+	for i := 0; i <= 3; i++ {
+		query := client.Agent.Create().
+			SetID(fmt.Sprintf("agent%d", i)).
+			SetHostname(fmt.Sprintf("agent%d", i)).
+			SetOs("windows").
+			SetNickname(fmt.Sprintf("agent%d", i)).
+			SetAgentStatus(agent.AgentStatusEnabled).
+			SetLastContact(time.Now().Add(-time.Duration(i*12) * time.Hour))
+		if i == 3 {
+			query.AddSiteIDs(other.ID)
+		} else {
+			query.AddSiteIDs(s.ID)
+		}
+		err := query.Exec(context.Background())
+		assert.NoError(suite.T(), err, "should create agent")
+
+		err = client.LogicalDisk.Create().SetLabel("C:").SetUsage(int8(50 + i*15)).SetOwnerID(fmt.Sprintf("agent%d", i)).Exec(context.Background())
+		assert.NoError(suite.T(), err, "should create logical disk")
+
+		err = client.Antivirus.Create().SetName("Defender").SetIsActive(true).SetIsUpdated(i%2 == 0).SetOwnerID(fmt.Sprintf("agent%d", i)).Exec(context.Background())
+		assert.NoError(suite.T(), err, "should create antivirus")
+
+		err = client.SystemUpdate.Create().SetSystemUpdateStatus("").SetPendingUpdates(i > 0).SetLastInstall(time.Now().AddDate(0, 0, -i*5)).SetLastSearch(time.Now()).SetOwnerID(fmt.Sprintf("agent%d", i)).Exec(context.Background())
+		assert.NoError(suite.T(), err, "should create system update")
+	}
+
+	rules := []consoledb.AlertRule{
+		{TenantID: suite.tenantID, SiteID: -1, Name: "not reported", Type: alerts.TypeAgentNotReported, Threshold: 20, Recipients: []string{"admin@example.com", "ops@example.com"}, ThrottleMinutes: 60, Enabled: true},
+		{TenantID: suite.tenantID, SiteID: suite.siteID, Name: "disks", Type: alerts.TypeDiskUsage, Threshold: 70, Recipients: []string{"admin@example.com"}, ThrottleMinutes: 60, Enabled: true},
+		{TenantID: suite.tenantID, SiteID: -1, Name: "antivirus", Type: alerts.TypeAntivirusOutdated, Recipients: []string{"admin@example.com"}, ThrottleMinutes: 60, Enabled: false},
+		{TenantID: suite.tenantID + 1, SiteID: -1, Name: "updates", Type: alerts.TypeUpdatesPending, Threshold: 7, Recipients: []string{"admin@example.com"}, ThrottleMinutes: 60, Enabled: true},
+	}
+	for _, r := range rules {
+		err := suite.model.AddAlertRule(r)
+		assert.NoError(suite.T(), err, "should add alert rule")
+	}
+}
+
+func (suite *AlertsTestSuite) TestGetAlertRules() {
+	rules, err := suite.model.GetAlertRules(suite.tenantID)
+	assert.NoError(suite.T(), err, "should get alert rules")
+	assert.Equal(suite.T(), 3, len(rules))
+	assert.Equal(suite.T(), "antivirus", rules[0].Name)
+	assert.Equal(suite.T(), []string{"admin@example.com", "ops@example.com"}, rules[2].Recipients)
+	assert.True(suite.T(), rules[2].LastRun.IsZero())
+
+	rules, err = suite.model.GetEnabledAlertRules()
+	assert.NoError(suite.T(), err, "should get enabled alert rules")
+	assert.Equal(suite.T(), 3, len(rules))
+
+	_, err = suite.model.GetAlertRule(rules[2].ID, suite.tenantID)
+	assert.ErrorIs(suite.T(), err, ErrAlertRuleNotFound, "should not get rules from other tenants")
+
+	err = suite.model.SetAlertRuleEnabled(rules[0].ID, suite.tenantID, false)
+	assert.NoError(suite.T(), err, "should disable alert rule")
+
+	err = suite.model.DeleteAlertRule(rules[1].ID, suite.tenantID+1)
+	assert.ErrorIs(suite.T(), err, ErrAlertRuleNotFound, "should not delete rules from other tenants")
+
+	err = suite.model.DeleteAlertRule(rules[1].ID, suite.tenantID)
+	assert.NoError(suite.T(), err, "should delete alert rule")
+
+	rules, err = suite.model.GetEnabledAlertRules()
+	assert.NoError(suite.T(), err, "should get enabled alert rules")
+	assert.Equal(suite.T(), 1, len(rules))
+}
+
+func (suite *AlertsTestSuite) TestClaimAlertRule() {
+	rules, err := suite.model.GetAlertRules(suite.tenantID)
+	assert.NoError(suite.T(), err, "should get alert rules")
+
+	claimed, err := suite.model.ClaimAlertRule(rules[0].ID, time.Hour)
+	assert.NoError(suite.T(), err, "should claim alert rule")
+	assert.True(suite.T(), claimed)
+
+	claimed, err = suite.model.ClaimAlertRule(rules[0].ID, time.Hour)
+	assert.NoError(suite.T(), err, "should try to claim alert rule")
+	assert.False(suite.T(), claimed, "a rule can't be evaluated again before the interval")
+
+	claimed, err = suite.model.ClaimAlertRule(rules[0].ID, 0)
+	assert.NoError(suite.T(), err, "should claim alert rule")
+	assert.True(suite.T(), claimed)
+}
+
+func (suite *AlertsTestSuite) TestGetAlertMatches() {
+	tests := []struct {
+		rule     consoledb.AlertRule
+		subjects []string
+	}{
+		{consoledb.AlertRule{TenantID: suite.tenantID, SiteID: -1, Type: alerts.TypeAgentNotReported, Threshold: 20}, []string{"agent2", "agent3"}},
+		{consoledb.AlertRule{TenantID: suite.tenantID, SiteID: suite.siteID, Type: alerts.TypeAgentNotReported, Threshold: 20}, []string{"agent2"}},
+		{consoledb.AlertRule{TenantID: suite.tenantID, SiteID: -1, Type: alerts.TypeDiskUsage, Threshold: 70}, []string{"agent2:C:", "agent3:C:"}},
+		{consoledb.AlertRule{TenantID: suite.tenantID, SiteID: -1, Type: alerts.TypeAntivirusOutdated}, []string{"agent1", "agent3"}},
+		{consoledb.AlertRule{TenantID: suite.tenantID, SiteID: -1, Type: alerts.TypeUpdatesPending, Threshold: 7}, []string{"agent2", "agent3"}},
+		{consoledb.AlertRule{TenantID: suite.tenantID + 1, SiteID: -1, Type: alerts.TypeUpdatesPending, Threshold: 7}, []string{}},
+	}
+
+	for _, tt := range tests {
+		matches, err := suite.model.GetAlertMatches(tt.rule)
+		assert.NoError(suite.T(), err, "should get alert matches")
+		subjects := []string{}
+		for _, m := range matches {
+			subjects = append(subjects, m.Subject)
+		}
+		assert.ElementsMatch(suite.T(), tt.subjects, subjects, "unexpected matches for %s in site %d", tt.rule.Type, tt.rule.SiteID)
+	}
+}
+
+func (suite *AlertsTestSuite) TestAlertHistory() {
+	entries := []consoledb.AlertHistoryEntry{
+		{RuleID: 1, TenantID: suite.tenantID, SiteID: -1, RuleName: "disks", Type: alerts.TypeDiskUsage, Subject: "agent1:C:", Hostname: "agent1", Status: consoledb.AlertSent, Created: time.Now().Add(-2 * time.Hour)},
+		{RuleID: 1, TenantID: suite.tenantID, SiteID: -1, RuleName: "disks", Type: alerts.TypeDiskUsage, Subject: "agent2:C:", Hostname: "agent2", Status: consoledb.AlertSent},
+		{RuleID: 1, TenantID: suite.tenantID, SiteID: -1, RuleName: "disks", Type: alerts.TypeDiskUsage, Subject: "agent3:C:", Hostname: "agent3", Status: consoledb.AlertFailed, Error: "SMTP is not configured"},
+		{RuleID: 2, TenantID: suite.tenantID + 1, SiteID: -1, RuleName: "updates", Type: alerts.TypeUpdatesPending, Subject: "agent9", Hostname: "agent9", Status: consoledb.AlertSent},
+	}
+	err := suite.model.AddAlertHistory(entries)
+	assert.NoError(suite.T(), err, "should add alert history")
+
+	alerted, err := suite.model.GetAlertedSubjects(1, time.Now().Add(-time.Hour))
+	assert.NoError(suite.T(), err, "should get alerted subjects")
+	assert.Equal(suite.T(), map[string]bool{"agent2:C:": true}, alerted, "only alerts sent in the throttle window should be returned")
+
+	count, err := suite.model.CountAlertHistory(suite.tenantID, filters.AlertHistoryFilter{})
+	assert.NoError(suite.T(), err, "should count alert history")
+	assert.Equal(suite.T(), 3, count)
+
+	count, err = suite.model.CountAlertHistory(suite.tenantID, filters.AlertHistoryFilter{Status: consoledb.AlertFailed})
+	assert.NoError(suite.T(), err, "should count alert history")
+	assert.Equal(suite.T(), 1, count)
+
+	suite.p.SortBy = "hostname"
+	suite.p.SortOrder = "asc"
+	history, err := suite.model.GetAlertHistoryByPage(suite.p, suite.tenantID, filters.AlertHistoryFilter{Rule: "DISK"})
+	assert.NoError(suite.T(), err, "should get alert history by page")
+	assert.Equal(suite.T(), 3, len(history))
+	assert.Equal(suite.T(), "agent1", history[0].Hostname)
+	assert.Equal(suite.T(), "SMTP is not configured", history[2].Error)
+
+	err = suite.model.DeleteOldAlertHistory(time.Now().Add(-time.Hour))
+	assert.NoError(suite.T(), err, "should delete old alert history")
+
+	count, err = suite.model.CountAlertHistory(suite.tenantID, filters.AlertHistoryFilter{})
+	assert.NoError(suite.T(), err, "should count alert history")
+	assert.Equal(suite.T(), 2, count)
+}
+
+func TestAlertsTestSuite(t *testing.T) {
+	suite.Run(t, new(AlertsTestSuite))
+}
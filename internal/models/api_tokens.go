@@ -0,0 +1,216 @@
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	entsql "entgo.io/ent/dialect/sql"
+	"github.com/alexedwards/argon2id"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+)
+
+var ErrInvalidAPIToken = errors.New("the API token is not valid")
+var ErrExpiredAPIToken = errors.New("the API token has expired")
+
+var apiTokenColumns = []string{"id", "user_id", "name", "prefix", "hash", "tenant_id", "created", "expiry", "last_used"}
+
+// AddAPIToken creates a new token for the user and returns it in clear, it's the only time that it's available
+func (m *Model) AddAPIToken(userID string, name string, tenantID int, expiry time.Time) (string, error) {
+	prefix, err := randomHex(6)
+	if err != nil {
+		return "", err
+	}
+
+	secret, err := randomHex(24)
+	if err != nil {
+		return "", err
+	}
+
+	hash, err := argon2id.CreateHash(secret, argon2id.DefaultParams)
+	if err != nil {
+		return "", err
+	}
+
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Insert(consoledb.APITokensTable.Name).
+		Columns("user_id", "name", "prefix", "hash", "tenant_id", "created", "expiry").
+		Values(userID, name, prefix, hash, tenantID, time.Now(), expiry).
+		Query()
+
+	if _, err := m.Driver.DB().ExecContext(context.Background(), query, args...); err != nil {
+		return "", err
+	}
+
+	return consoledb.APITokenPrefix + prefix + "_" + secret, nil
+}
+
+// ValidateAPIToken checks the token against the stored hash and returns the token information if it's valid and not expired
+func (m *Model) ValidateAPIToken(token string) (*consoledb.APIToken, error) {
+	prefix, secret, found := strings.Cut(strings.TrimPrefix(token, consoledb.APITokenPrefix), "_")
+	if !strings.HasPrefix(token, consoledb.APITokenPrefix) || !found || prefix == "" || secret == "" {
+		return nil, ErrInvalidAPIToken
+	}
+
+	tokens, err := m.queryAPITokens(func(s *entsql.Selector) {
+		s.Where(entsql.EQ("prefix", prefix))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tokens) != 1 {
+		return nil, ErrInvalidAPIToken
+	}
+	t := tokens[0]
+
+	match, err := argon2id.ComparePasswordAndHash(secret, t.Hash)
+	if err != nil {
+		return nil, err
+	}
+
+	if !match {
+		return nil, ErrInvalidAPIToken
+	}
+
+	if time.Now().After(t.Expiry) {
+		return nil, ErrExpiredAPIToken
+	}
+
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Update(consoledb.APITokensTable.Name).
+		Set("last_used", time.Now()).
+		Where(entsql.EQ("id", t.ID)).
+		Query()
+
+	if _, err := m.Driver.DB().ExecContext(context.Background(), query, args...); err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+func (m *Model) GetAPITokensForUser(userID string) ([]consoledb.APIToken, error) {
+	return m.queryAPITokens(func(s *entsql.Selector) {
+		s.Where(entsql.EQ("user_id", userID)).OrderBy(entsql.Desc("created"))
+	})
+}
+
+func (m *Model) CountAllAPITokens() (int, error) {
+	var count int
+
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Select(entsql.Count("*")).
+		From(entsql.Table(consoledb.APITokensTable.Name)).
+		Query()
+
+	if err := m.Driver.DB().QueryRowContext(context.Background(), query, args...).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (m *Model) GetAPITokensByPage(p partials.PaginationAndSort) ([]consoledb.APIToken, error) {
+	column := "user_id"
+	switch p.SortBy {
+	case "uid":
+		column = "user_id"
+	case "name":
+		column = "name"
+	case "created":
+		column = "created"
+	case "expiry":
+		column = "expiry"
+	}
+
+	return m.queryAPITokens(func(s *entsql.Selector) {
+		if p.SortOrder == "asc" {
+			s.OrderBy(entsql.Asc(column))
+		} else {
+			s.OrderBy(entsql.Desc(column))
+		}
+		s.Limit(p.PageSize).Offset((p.CurrentPage - 1) * p.PageSize)
+	})
+}
+
+// DeleteAPIToken revokes a token, if userID is not empty the token must belong to that user
+func (m *Model) DeleteAPIToken(tokenID int, userID string) error {
+	predicates := []*entsql.Predicate{entsql.EQ("id", tokenID)}
+	if userID != "" {
+		predicates = append(predicates, entsql.EQ("user_id", userID))
+	}
+
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Delete(consoledb.APITokensTable.Name).
+		Where(entsql.And(predicates...)).
+		Query()
+
+	res, err := m.Driver.DB().ExecContext(context.Background(), query, args...)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return ErrInvalidAPIToken
+	}
+
+	return nil
+}
+
+// DeleteAPITokensForUser revokes every token of the user, the tokens of a deleted user
+// must not be valid again if a user with the same username is created
+func (m *Model) DeleteAPITokensForUser(userID string) error {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Delete(consoledb.APITokensTable.Name).
+		Where(entsql.EQ("user_id", userID)).
+		Query()
+
+	_, err := m.Driver.DB().ExecContext(context.Background(), query, args...)
+	return err
+}
+
+func (m *Model) queryAPITokens(modifier func(s *entsql.Selector)) ([]consoledb.APIToken, error) {
+	selector := entsql.Dialect(m.Driver.Dialect()).
+		Select(apiTokenColumns...).
+		From(entsql.Table(consoledb.APITokensTable.Name))
+	modifier(selector)
+
+	query, args := selector.Query()
+	rows, err := m.Driver.DB().QueryContext(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []consoledb.APIToken{}
+	for rows.Next() {
+		var t consoledb.APIToken
+		var lastUsed sql.NullTime
+		if err := rows.Scan(&t.ID, &t.UserID, &t.Name, &t.Prefix, &t.Hash, &t.TenantID, &t.Created, &t.Expiry, &lastUsed); err != nil {
+			return nil, err
+		}
+		t.LastUsed = lastUsed.Time
+		tokens = append(tokens, t)
+	}
+
+	return tokens, rows.Err()
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type APITokensTestSuite struct {
+	suite.Suite
+	model Model
+	p     partials.PaginationAndSort
+}
+
+func (suite *APITokensTestSuite) SetupTest() {
+	suite.model = newTestModel(suite.T())
+	suite.p = partials.PaginationAndSort{CurrentPage: 1, PageSize: 5}
+}
+
+func (suite *APITokensTestSuite) TestAddAndValidateAPIToken() {
+	token, err := suite.model.AddAPIToken("user1", "ci", 2, time.Now().Add(24*time.Hour))
+	assert.NoError(suite.T(), err, "should add API token")
+	assert.True(suite.T(), strings.HasPrefix(token, consoledb.APITokenPrefix), "token should have the OpenUEM prefix")
+
+	t, err := suite.model.ValidateAPIToken(token)
+	assert.NoError(suite.T(), err, "should validate API token")
+	assert.Equal(suite.T(), "user1", t.UserID)
+	assert.Equal(suite.T(), "ci", t.Name)
+	assert.Equal(suite.T(), 2, t.TenantID)
+
+	_, err = suite.model.ValidateAPIToken(token + "x")
+	assert.ErrorIs(suite.T(), err, ErrInvalidAPIToken, "modified token should not be valid")
+
+	_, err = suite.model.ValidateAPIToken("not a token")
+	assert.ErrorIs(suite.T(), err, ErrInvalidAPIToken, "malformed token should not be valid")
+
+	tokens, err := suite.model.GetAPITokensForUser("user1")
+	assert.NoError(suite.T(), err, "should get user tokens")
+	assert.Equal(suite.T(), 1, len(tokens))
+	assert.False(suite.T(), tokens[0].LastUsed.IsZero(), "last used should be set after validation")
+}
+
+func (suite *APITokensTestSuite) TestExpiredAPIToken() {
+	token, err := suite.model.AddAPIToken("user1", "expired", -1, time.Now().Add(-1*time.Hour))
+	assert.NoError(suite.T(), err, "should add API token")
+
+	_, err = suite.model.ValidateAPIToken(token)
+	assert.ErrorIs(suite.T(), err, ErrExpiredAPIToken, "expired token should not be valid")
+}
+
+func (suite *APITokensTestSuite) TestGetAPITokensByPage() {
+	for _, user := range []string{"user1", "user2", "user3", "user4", "user5", "user6", "user7"} {
+		_, err := suite.model.AddAPIToken(user, "token", -1, time.Now().Add(time.Hour))
+		assert.NoError(suite.T(), err, "should add API token")
+	}
+
+	count, err := suite.model.CountAllAPITokens()
+	assert.NoError(suite.T(), err, "should count API tokens")
+	assert.Equal(suite.T(), 7, count)
+
+	suite.p.SortBy = "uid"
+	suite.p.SortOrder = "asc"
+	tokens, err := suite.model.GetAPITokensByPage(suite.p)
+	assert.NoError(suite.T(), err, "should get API tokens by page")
+	assert.Equal(suite.T(), 5, len(tokens))
+	assert.Equal(suite.T(), "user1", tokens[0].UserID)
+
+	suite.p.SortOrder = "desc"
+	suite.p.CurrentPage = 2
+	tokens, err = suite.model.GetAPITokensByPage(suite.p)
+	assert.NoError(suite.T(), err, "should get API tokens by page")
+	assert.Equal(suite.T(), 2, len(tokens))
+	assert.Equal(suite.T(), "user1", tokens[1].UserID)
+}
+
+func (suite *APITokensTestSuite) TestDeleteAPIToken() {
+	_, err := suite.model.AddAPIToken("user1", "token", -1, time.Now().Add(time.Hour))
+	assert.NoError(suite.T(), err, "should add API token")
+
+	tokens, err := suite.model.GetAPITokensForUser("user1")
+	assert.NoError(suite.T(), err, "should get user tokens")
+
+	err = suite.model.DeleteAPIToken(tokens[0].ID, "user2")
+	assert.ErrorIs(suite.T(), err, ErrInvalidAPIToken, "should not delete tokens from other users")
+
+	err = suite.model.DeleteAPIToken(tokens[0].ID, "user1")
+	assert.NoError(suite.T(), err, "should delete user token")
+
+	tokens, err = suite.model.GetAPITokensForUser("user1")
+	assert.NoError(suite.T(), err, "should get user tokens")
+	assert.Equal(suite.T(), 0, len(tokens))
+}
+
+func (suite *APITokensTestSuite) TestDeleteAPITokensForUser() {
+	token, err := suite.model.AddAPIToken("user1", "ci", -1, time.Now().Add(time.Hour))
+	assert.NoError(suite.T(), err, "should add API token")
+
+	other, err := suite.model.AddAPIToken("user2", "ci", -1, time.Now().Add(time.Hour))
+	assert.NoError(suite.T(), err, "should add API token")
+
+	err = suite.model.DeleteAPITokensForUser("user1")
+	assert.NoError(suite.T(), err, "should delete user tokens")
+
+	_, err = suite.model.ValidateAPIToken(token)
+	assert.ErrorIs(suite.T(), err, ErrInvalidAPIToken, "token of a deleted user should not be valid")
+
+	_, err = suite.model.ValidateAPIToken(other)
+	assert.NoError(suite.T(), err, "tokens of other users should still be valid")
+}
+
+func TestAPITokensTestSuite(t *testing.T) {
+	suite.Run(t, new(APITokensTestSuite))
+}
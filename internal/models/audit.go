@@ -0,0 +1,139 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	entsql "entgo.io/ent/dialect/sql"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/views/filters"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+)
+
+var auditEntryColumns = []string{"id", "created", "user_id", "ip", "tenant_id", "site_id", "action", "target", "before_value", "after_value"}
+
+func (m *Model) AddAuditEntry(entry consoledb.AuditEntry) error {
+	if entry.Created.IsZero() {
+		entry.Created = time.Now()
+	}
+
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Insert(consoledb.AuditLogTable.Name).
+		Columns(auditEntryColumns[1:]...).
+		Values(entry.Created, entry.UserID, entry.IP, entry.TenantID, entry.SiteID, entry.Action, entry.Target, entry.Before, entry.After).
+		Query()
+
+	_, err := m.Driver.DB().ExecContext(context.Background(), query, args...)
+	return err
+}
+
+func (m *Model) CountAuditEntries(f filters.AuditFilter) (int, error) {
+	var count int
+
+	selector := entsql.Dialect(m.Driver.Dialect()).
+		Select(entsql.Count("*")).
+		From(entsql.Table(consoledb.AuditLogTable.Name))
+	applyAuditFilter(selector, f)
+
+	query, args := selector.Query()
+	if err := m.Driver.DB().QueryRowContext(context.Background(), query, args...).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (m *Model) GetAuditEntriesByPage(p partials.PaginationAndSort, f filters.AuditFilter) ([]consoledb.AuditEntry, error) {
+	return m.queryAuditEntries(func(s *entsql.Selector) {
+		applyAuditFilter(s, f)
+		applyAuditOrder(s, p)
+		s.Limit(p.PageSize).Offset((p.CurrentPage - 1) * p.PageSize)
+	})
+}
+
+// GetAuditEntries returns every entry matching the filter, it's used to export the audit log
+func (m *Model) GetAuditEntries(p partials.PaginationAndSort, f filters.AuditFilter) ([]consoledb.AuditEntry, error) {
+	return m.queryAuditEntries(func(s *entsql.Selector) {
+		applyAuditFilter(s, f)
+		applyAuditOrder(s, p)
+	})
+}
+
+func applyAuditOrder(s *entsql.Selector, p partials.PaginationAndSort) {
+	column := "created"
+	switch p.SortBy {
+	case "uid":
+		column = "user_id"
+	case "action":
+		column = "action"
+	case "target":
+		column = "target"
+	case "ip":
+		column = "ip"
+	}
+
+	// The newest entries are shown first unless the user chooses otherwise
+	if p.SortOrder == "asc" {
+		s.OrderBy(entsql.Asc(column), entsql.Asc("id"))
+	} else {
+		s.OrderBy(entsql.Desc(column), entsql.Desc("id"))
+	}
+}
+
+func applyAuditFilter(s *entsql.Selector, f filters.AuditFilter) {
+	if len(f.Username) > 0 {
+		s.Where(entsql.ContainsFold("user_id", f.Username))
+	}
+
+	if len(f.Action) > 0 {
+		s.Where(entsql.ContainsFold("action", f.Action))
+	}
+
+	if len(f.Target) > 0 {
+		s.Where(entsql.ContainsFold("target", f.Target))
+	}
+
+	if len(f.IP) > 0 {
+		s.Where(entsql.ContainsFold("ip", f.IP))
+	}
+
+	if len(f.CreatedFrom) > 0 {
+		dateFrom, err := time.Parse("2006-01-02", f.CreatedFrom)
+		if err == nil {
+			s.Where(entsql.GTE("created", dateFrom))
+		}
+	}
+
+	if len(f.CreatedTo) > 0 {
+		dateTo, err := time.Parse("2006-01-02", f.CreatedTo)
+		if err == nil {
+			// include the whole day
+			s.Where(entsql.LT("created", dateTo.AddDate(0, 0, 1)))
+		}
+	}
+}
+
+func (m *Model) queryAuditEntries(modifier func(s *entsql.Selector)) ([]consoledb.AuditEntry, error) {
+	selector := entsql.Dialect(m.Driver.Dialect()).
+		Select(auditEntryColumns...).
+		From(entsql.Table(consoledb.AuditLogTable.Name))
+	modifier(selector)
+
+	query, args := selector.Query()
+	rows, err := m.Driver.DB().QueryContext(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []consoledb.AuditEntry{}
+	for rows.Next() {
+		var e consoledb.AuditEntry
+		if err := rows.Scan(&e.ID, &e.Created, &e.UserID, &e.IP, &e.TenantID, &e.SiteID, &e.Action, &e.Target, &e.Before, &e.After); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/views/filters"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type AuditTestSuite struct {
+	suite.Suite
+	model Model
+	p     partials.PaginationAndSort
+}
+
+func (suite *AuditTestSuite) SetupTest() {
+	suite.model = newTestModel(suite.T())
+	suite.p = partials.PaginationAndSort{CurrentPage: 1, PageSize: 5}
+
+	entries := []consoledb.AuditEntry{
+		{UserID: "admin", IP: "10.0.0.1", TenantID: 1, SiteID: 1, Action: "computer.power", Target: "server1", After: "reboot", Created: time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)},
+		{UserID: "admin", IP: "10.0.0.1", TenantID: -1, SiteID: -1, Action: "tenant.delete", Target: "Tenant 2", Created: time.Date(2025, 3, 2, 10, 0, 0, 0, time.UTC)},
+		{UserID: "helpdesk", IP: "10.0.0.2", TenantID: 1, SiteID: 1, Action: "computer.power", Target: "server2", After: "poweroff", Created: time.Date(2025, 3, 3, 10, 0, 0, 0, time.UTC)},
+		{UserID: "operator", IP: "10.0.0.3", TenantID: 1, SiteID: -1, Action: "agent.delete", Target: "server1", Created: time.Date(2025, 3, 4, 10, 0, 0, 0, time.UTC)},
+		{UserID: "operator", IP: "10.0.0.3", TenantID: 1, SiteID: -1, Action: "file.delete", Target: "server3", Before: "C:\\Temp\\file.txt", Created: time.Date(2025, 3, 5, 10, 0, 0, 0, time.UTC)},
+		{UserID: "admin", IP: "10.0.0.1", TenantID: 1, SiteID: -1, Action: "settings.update", Target: "general", Before: "{}", After: "{}", Created: time.Date(2025, 3, 6, 10, 0, 0, 0, time.UTC)},
+		{UserID: "admin", IP: "10.0.0.1", TenantID: 1, SiteID: -1, Action: "certificate.revoke", Target: "1234", Created: time.Date(2025, 3, 7, 10, 0, 0, 0, time.UTC)},
+	}
+
+	for _, e := range entries {
+		err := suite.model.AddAuditEntry(e)
+		assert.NoError(suite.T(), err, "should add audit entry")
+	}
+}
+
+func (suite *AuditTestSuite) TestGetAuditEntriesByPage() {
+	count, err := suite.model.CountAuditEntries(filters.AuditFilter{})
+	assert.NoError(suite.T(), err, "should count audit entries")
+	assert.Equal(suite.T(), 7, count)
+
+	entries, err := suite.model.GetAuditEntriesByPage(suite.p, filters.AuditFilter{})
+	assert.NoError(suite.T(), err, "should get audit entries by page")
+	assert.Equal(suite.T(), 5, len(entries))
+	assert.Equal(suite.T(), "certificate.revoke", entries[0].Action, "newest entries should be first")
+
+	suite.p.CurrentPage = 2
+	entries, err = suite.model.GetAuditEntriesByPage(suite.p, filters.AuditFilter{})
+	assert.NoError(suite.T(), err, "should get audit entries by page")
+	assert.Equal(suite.T(), 2, len(entries))
+
+	suite.p.CurrentPage = 1
+	suite.p.SortBy = "uid"
+	suite.p.SortOrder = "asc"
+	entries, err = suite.model.GetAuditEntriesByPage(suite.p, filters.AuditFilter{})
+	assert.NoError(suite.T(), err, "should get audit entries by page")
+	assert.Equal(suite.T(), "admin", entries[0].UserID)
+}
+
+func (suite *AuditTestSuite) TestAuditFilter() {
+	f := filters.AuditFilter{Target: "server1"}
+	entries, err := suite.model.GetAuditEntries(suite.p, f)
+	assert.NoError(suite.T(), err, "should get audit entries")
+	assert.Equal(suite.T(), 2, len(entries))
+
+	f = filters.AuditFilter{Action: "power", Username: "HELP"}
+	entries, err = suite.model.GetAuditEntries(suite.p, f)
+	assert.NoError(suite.T(), err, "should get audit entries")
+	assert.Equal(suite.T(), 1, len(entries))
+	assert.Equal(suite.T(), "server2", entries[0].Target)
+	assert.Equal(suite.T(), "10.0.0.2", entries[0].IP)
+	assert.Equal(suite.T(), "poweroff", entries[0].After)
+
+	f = filters.AuditFilter{CreatedFrom: "2025-03-02", CreatedTo: "2025-03-04"}
+	count, err := suite.model.CountAuditEntries(f)
+	assert.NoError(suite.T(), err, "should count audit entries")
+	assert.Equal(suite.T(), 3, count, "date filter should include the whole last day")
+
+	f = filters.AuditFilter{IP: "10.0.0.3"}
+	count, err = suite.model.CountAuditEntries(f)
+	assert.NoError(suite.T(), err, "should count audit entries")
+	assert.Equal(suite.T(), 2, count)
+}
+
+func TestAuditTestSuite(t *testing.T) {
+	suite.Run(t, new(AuditTestSuite))
+}
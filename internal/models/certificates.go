@@ -51,7 +51,10 @@ func (m *Model) CountCertificatesAboutToexpire() (int, error) {
 }
 
 func (m *Model) GetCertificatesByPage(p partials.PaginationAndSort, f filters.CertificateFilter) ([]*openuem_ent.Certificate, error) {
-	query := m.Client.Certificate.Query().Limit(p.PageSize).Offset((p.CurrentPage - 1) * p.PageSize)
+	query := m.Client.Certificate.Query()
+	if p.PageSize != 0 {
+		query = query.Limit(p.PageSize).Offset((p.CurrentPage - 1) * p.PageSize)
+	}
 
 	// Apply filters
 	applyCertificateFilters(query, f)
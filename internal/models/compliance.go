@@ -0,0 +1,429 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+
+	entsql "entgo.io/ent/dialect/sql"
+	"github.com/open-uem/ent"
+	"github.com/open-uem/ent/agent"
+	"github.com/open-uem/ent/site"
+	"github.com/open-uem/ent/tenant"
+	"github.com/open-uem/openuem-console/internal/compliance"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/views/filters"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+)
+
+var ErrCompliancePolicyNotFound = errors.New("the compliance policy doesn't exist")
+
+var compliancePolicyColumns = []string{"id", "tenant_id", "name", "type", "app_pattern", "publisher_pattern", "min_version", "enabled", "created"}
+var complianceViolationColumns = []string{"id", "agent_id", "tenant_id", "site_id", "hostname", "policy_id", "policy_name", "policy_type", "reason", "app", "version", "evaluated"}
+
+// ComplianceSummary counts the evaluated agents of a tenant or site
+type ComplianceSummary struct {
+	Evaluated     int
+	Compliant     int
+	NonCompliant  int
+	LastEvaluated time.Time
+}
+
+// PolicyViolations counts the agents that don't comply with a policy
+type PolicyViolations struct {
+	PolicyID   int
+	PolicyName string
+	PolicyType string
+	Agents     int
+}
+
+func (m *Model) AddCompliancePolicy(p consoledb.CompliancePolicy) error {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Insert(consoledb.CompliancePoliciesTable.Name).
+		Columns(compliancePolicyColumns[1:]...).
+		Values(p.TenantID, p.Name, p.Type, p.AppPattern, p.PublisherPattern, p.MinVersion, p.Enabled, time.Now()).
+		Query()
+
+	_, err := m.Driver.DB().ExecContext(context.Background(), query, args...)
+	return err
+}
+
+func (m *Model) GetCompliancePolicies(tenantID int) ([]consoledb.CompliancePolicy, error) {
+	return m.queryCompliancePolicies(func(s *entsql.Selector) {
+		s.Where(entsql.EQ("tenant_id", tenantID)).OrderBy(entsql.Asc("type"), entsql.Asc("name"))
+	})
+}
+
+// GetEnabledCompliancePolicies returns the enabled policies of every tenant by tenant id
+func (m *Model) GetEnabledCompliancePolicies() (map[int][]compliance.Policy, error) {
+	items, err := m.queryCompliancePolicies(func(s *entsql.Selector) {
+		s.Where(entsql.EQ("enabled", true)).OrderBy(entsql.Asc("id"))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	policies := map[int][]compliance.Policy{}
+	for _, p := range items {
+		policies[p.TenantID] = append(policies[p.TenantID], compliance.Policy{
+			ID:               p.ID,
+			Name:             p.Name,
+			Type:             p.Type,
+			AppPattern:       p.AppPattern,
+			PublisherPattern: p.PublisherPattern,
+			MinVersion:       p.MinVersion,
+		})
+	}
+
+	return policies, nil
+}
+
+func (m *Model) GetCompliancePolicy(id int, tenantID int) (consoledb.CompliancePolicy, error) {
+	policies, err := m.queryCompliancePolicies(func(s *entsql.Selector) {
+		s.Where(entsql.And(entsql.EQ("id", id), entsql.EQ("tenant_id", tenantID)))
+	})
+	if err != nil {
+		return consoledb.CompliancePolicy{}, err
+	}
+
+	if len(policies) != 1 {
+		return consoledb.CompliancePolicy{}, ErrCompliancePolicyNotFound
+	}
+
+	return policies[0], nil
+}
+
+func (m *Model) SetCompliancePolicyEnabled(id int, tenantID int, enabled bool) error {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Update(consoledb.CompliancePoliciesTable.Name).
+		Set("enabled", enabled).
+		Where(entsql.And(entsql.EQ("id", id), entsql.EQ("tenant_id", tenantID))).
+		Query()
+
+	return m.execAffectingOne(query, args, ErrCompliancePolicyNotFound)
+}
+
+// DeleteCompliancePolicy removes the policy, the violations recorded for it are
+// removed the next time the agents are evaluated
+func (m *Model) DeleteCompliancePolicy(id int, tenantID int) error {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Delete(consoledb.CompliancePoliciesTable.Name).
+		Where(entsql.And(entsql.EQ("id", id), entsql.EQ("tenant_id", tenantID))).
+		Query()
+
+	return m.execAffectingOne(query, args, ErrCompliancePolicyNotFound)
+}
+
+func (m *Model) queryCompliancePolicies(modifier func(s *entsql.Selector)) ([]consoledb.CompliancePolicy, error) {
+	selector := entsql.Dialect(m.Driver.Dialect()).
+		Select(compliancePolicyColumns...).
+		From(entsql.Table(consoledb.CompliancePoliciesTable.Name))
+	modifier(selector)
+
+	query, args := selector.Query()
+	rows, err := m.Driver.DB().QueryContext(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	policies := []consoledb.CompliancePolicy{}
+	for rows.Next() {
+		var p consoledb.CompliancePolicy
+		if err := rows.Scan(&p.ID, &p.TenantID, &p.Name, &p.Type, &p.AppPattern, &p.PublisherPattern, &p.MinVersion, &p.Enabled, &p.Created); err != nil {
+			return nil, err
+		}
+		policies = append(policies, p)
+	}
+
+	return policies, rows.Err()
+}
+
+// GetComplianceAgents returns a batch of admitted agents of the tenant with their applications
+func (m *Model) GetComplianceAgents(tenantID, offset, limit int) ([]*ent.Agent, error) {
+	return m.Client.Agent.Query().
+		Where(agent.AgentStatusNEQ(agent.AgentStatusWaitingForAdmission), agent.HasSiteWith(site.HasTenantWith(tenant.ID(tenantID)))).
+		WithSite().
+		WithApps().
+		Order(agent.ByID()).
+		Offset(offset).
+		Limit(limit).
+		All(context.Background())
+}
+
+// AgentComplianceApps returns the applications of an agent loaded with GetComplianceAgents
+func AgentComplianceApps(a *ent.Agent) []compliance.App {
+	apps := []compliance.App{}
+	for _, app := range a.Edges.Apps {
+		apps = append(apps, compliance.App{Name: app.Name, Version: app.Version, Publisher: app.Publisher})
+	}
+	return apps
+}
+
+// SaveComplianceEvaluation replaces the result of the previous evaluation of the agent
+func (m *Model) SaveComplianceEvaluation(a *ent.Agent, tenantID int, violations []compliance.Violation) error {
+	ctx := context.Background()
+
+	siteID := -1
+	if len(a.Edges.Site) == 1 {
+		siteID = a.Edges.Site[0].ID
+	}
+
+	tx, err := m.Driver.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, table := range []string{consoledb.ComplianceViolationsTable.Name, consoledb.ComplianceStatusTable.Name} {
+		query, args := entsql.Dialect(m.Driver.Dialect()).
+			Delete(table).
+			Where(entsql.EQ("agent_id", a.ID)).
+			Query()
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Insert(consoledb.ComplianceStatusTable.Name).
+		Columns("agent_id", "tenant_id", "site_id", "hostname", "violations", "evaluated").
+		Values(a.ID, tenantID, siteID, a.Hostname, len(violations), now).
+		Query()
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return err
+	}
+
+	if len(violations) > 0 {
+		insert := entsql.Dialect(m.Driver.Dialect()).
+			Insert(consoledb.ComplianceViolationsTable.Name).
+			Columns(complianceViolationColumns[1:]...)
+		for _, v := range violations {
+			insert.Values(a.ID, tenantID, siteID, a.Hostname, v.PolicyID, v.PolicyName, v.PolicyType, v.Reason, truncate(v.App, 1000), truncate(v.Version, 250), now)
+		}
+
+		query, args := insert.Query()
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// DeleteComplianceResults removes the results of the tenant saved before the given time,
+// which belong to agents that were removed or moved to another tenant. A zero time
+// removes every result, which is used when the tenant has no enabled policies
+func (m *Model) DeleteComplianceResults(tenantID int, before time.Time) error {
+	for _, table := range []string{consoledb.ComplianceViolationsTable.Name, consoledb.ComplianceStatusTable.Name} {
+		where := entsql.EQ("tenant_id", tenantID)
+		if !before.IsZero() {
+			where = entsql.And(where, entsql.LT("evaluated", before))
+		}
+
+		query, args := entsql.Dialect(m.Driver.Dialect()).
+			Delete(table).
+			Where(where).
+			Query()
+		if _, err := m.Driver.DB().ExecContext(context.Background(), query, args...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Model) GetComplianceSummary(c *partials.CommonInfo) (ComplianceSummary, error) {
+	var summary ComplianceSummary
+	var nonCompliant sql.NullInt64
+
+	selector := entsql.Dialect(m.Driver.Dialect()).
+		Select(entsql.Count("*"), "SUM(CASE WHEN violations > 0 THEN 1 ELSE 0 END)").
+		From(entsql.Table(consoledb.ComplianceStatusTable.Name))
+	if err := applyComplianceScope(selector, c); err != nil {
+		return summary, err
+	}
+
+	query, args := selector.Query()
+	if err := m.Driver.DB().QueryRowContext(context.Background(), query, args...).Scan(&summary.Evaluated, &nonCompliant); err != nil {
+		return summary, err
+	}
+	summary.NonCompliant = int(nonCompliant.Int64)
+	summary.Compliant = summary.Evaluated - summary.NonCompliant
+
+	if summary.Evaluated == 0 {
+		return summary, nil
+	}
+
+	selector = entsql.Dialect(m.Driver.Dialect()).
+		Select("evaluated").
+		From(entsql.Table(consoledb.ComplianceStatusTable.Name))
+	if err := applyComplianceScope(selector, c); err != nil {
+		return summary, err
+	}
+	selector.OrderBy(entsql.Desc("evaluated")).Limit(1)
+
+	query, args = selector.Query()
+	if err := m.Driver.DB().QueryRowContext(context.Background(), query, args...).Scan(&summary.LastEvaluated); err != nil {
+		return summary, err
+	}
+
+	return summary, nil
+}
+
+// GetPolicyViolations returns the number of agents that don't comply with each policy,
+// the policies with more agents first
+func (m *Model) GetPolicyViolations(c *partials.CommonInfo) ([]PolicyViolations, error) {
+	selector := entsql.Dialect(m.Driver.Dialect()).
+		Select("policy_id", "policy_name", "policy_type", "COUNT(DISTINCT agent_id) AS agents").
+		From(entsql.Table(consoledb.ComplianceViolationsTable.Name))
+	if err := applyComplianceScope(selector, c); err != nil {
+		return nil, err
+	}
+	selector.GroupBy("policy_id", "policy_name", "policy_type").OrderBy(entsql.Desc("agents"), entsql.Asc("policy_name"))
+
+	query, args := selector.Query()
+	rows, err := m.Driver.DB().QueryContext(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []PolicyViolations{}
+	for rows.Next() {
+		var p PolicyViolations
+		if err := rows.Scan(&p.PolicyID, &p.PolicyName, &p.PolicyType, &p.Agents); err != nil {
+			return nil, err
+		}
+		items = append(items, p)
+	}
+
+	return items, rows.Err()
+}
+
+// GetAgentComplianceViolations returns the violations found in the last evaluation of the agent
+func (m *Model) GetAgentComplianceViolations(agentID string) ([]consoledb.ComplianceViolation, error) {
+	return m.queryComplianceViolations(func(s *entsql.Selector) {
+		s.Where(entsql.EQ("agent_id", agentID)).OrderBy(entsql.Asc("policy_name"), entsql.Asc("app"))
+	})
+}
+
+func (m *Model) CountComplianceViolations(f filters.ComplianceViolationFilter, c *partials.CommonInfo) (int, error) {
+	var count int
+
+	selector := entsql.Dialect(m.Driver.Dialect()).
+		Select(entsql.Count("*")).
+		From(entsql.Table(consoledb.ComplianceViolationsTable.Name))
+	if err := applyComplianceScope(selector, c); err != nil {
+		return 0, err
+	}
+	applyComplianceViolationFilter(selector, f)
+
+	query, args := selector.Query()
+	if err := m.Driver.DB().QueryRowContext(context.Background(), query, args...).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// GetComplianceViolationsByPage returns a page of violations, every violation when the page size is 0
+func (m *Model) GetComplianceViolationsByPage(p partials.PaginationAndSort, f filters.ComplianceViolationFilter, c *partials.CommonInfo) ([]consoledb.ComplianceViolation, error) {
+	var scopeErr error
+
+	violations, err := m.queryComplianceViolations(func(s *entsql.Selector) {
+		scopeErr = applyComplianceScope(s, c)
+		applyComplianceViolationFilter(s, f)
+
+		column := "hostname"
+		switch p.SortBy {
+		case "policy":
+			column = "policy_name"
+		case "reason":
+			column = "reason"
+		case "app":
+			column = "app"
+		}
+
+		if p.SortOrder == "desc" {
+			s.OrderBy(entsql.Desc(column), entsql.Desc("id"))
+		} else {
+			s.OrderBy(entsql.Asc(column), entsql.Asc("id"))
+		}
+
+		if p.PageSize != 0 {
+			s.Limit(p.PageSize).Offset((p.CurrentPage - 1) * p.PageSize)
+		}
+	})
+	if scopeErr != nil {
+		return nil, scopeErr
+	}
+
+	return violations, err
+}
+
+// applyComplianceScope limits the results to the agents of the tenant, and of the site when a site is selected
+func applyComplianceScope(s *entsql.Selector, c *partials.CommonInfo) error {
+	tenantID, err := strconv.Atoi(c.TenantID)
+	if err != nil {
+		return err
+	}
+	siteID, err := strconv.Atoi(c.SiteID)
+	if err != nil {
+		return err
+	}
+
+	s.Where(entsql.EQ("tenant_id", tenantID))
+	if siteID != -1 {
+		s.Where(entsql.EQ("site_id", siteID))
+	}
+
+	return nil
+}
+
+func applyComplianceViolationFilter(s *entsql.Selector, f filters.ComplianceViolationFilter) {
+	if len(f.Hostname) > 0 {
+		s.Where(entsql.ContainsFold("hostname", f.Hostname))
+	}
+
+	if len(f.Policy) > 0 {
+		s.Where(entsql.ContainsFold("policy_name", f.Policy))
+	}
+
+	if len(f.App) > 0 {
+		s.Where(entsql.ContainsFold("app", f.App))
+	}
+
+	if len(f.Reasons) > 0 {
+		s.Where(entsql.In("reason", toAny(f.Reasons)...))
+	}
+}
+
+func (m *Model) queryComplianceViolations(modifier func(s *entsql.Selector)) ([]consoledb.ComplianceViolation, error) {
+	selector := entsql.Dialect(m.Driver.Dialect()).
+		Select(complianceViolationColumns...).
+		From(entsql.Table(consoledb.ComplianceViolationsTable.Name))
+	modifier(selector)
+
+	query, args := selector.Query()
+	rows, err := m.Driver.DB().QueryContext(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	violations := []consoledb.ComplianceViolation{}
+	for rows.Next() {
+		var v consoledb.ComplianceViolation
+		if err := rows.Scan(&v.ID, &v.AgentID, &v.TenantID, &v.SiteID, &v.Hostname, &v.PolicyID, &v.PolicyName, &v.PolicyType, &v.Reason, &v.App, &v.Version, &v.Evaluated); err != nil {
+			return nil, err
+		}
+		violations = append(violations, v)
+	}
+
+	return violations, rows.Err()
+}
@@ -0,0 +1,191 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/open-uem/ent/agent"
+	"github.com/open-uem/openuem-console/internal/compliance"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/views/filters"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type ComplianceTestSuite struct {
+	suite.Suite
+	model       Model
+	p           partials.PaginationAndSort
+	commonInfo  *partials.CommonInfo
+	tenantID    int
+	siteID      int
+	otherSiteID int
+}
+
+func (suite *ComplianceTestSuite) SetupTest() {
+	suite.model = newTestModel(suite.T())
+	suite.p = partials.PaginationAndSort{CurrentPage: 1, PageSize: 5}
+	client := suite.model.Client
+
+	t, err := suite.model.CreateDefaultTenant()
+	assert.NoError(suite.T(), err, "should create default tenant")
+	suite.tenantID = t.ID
+
+	s, err := suite.model.CreateDefaultSite(t)
+	assert.NoError(suite.T(), err, "should create default site")
+	suite.siteID = s.ID
+
+	other, err := client.Site.Create().SetDescription("Other").SetTenantID(t.ID).Save(context.Background())
+	assert.NoError(suite.T(), err, "should create site")
+	suite.otherSiteID = other.ID
+
+	suite.commonInfo = &partials.CommonInfo{TenantID: fmt.Sprintf("%d", t.ID), SiteID: "-1"}
+
+	for i := 0; i <= 3; i++ {
+		id := fmt.Sprintf("agent%d", i)
+		query := client.Agent.Create().
+			SetID(id).
+			SetHostname(id).
+			SetOs("windows").
+			SetNickname(id).
+			SetAgentStatus(agent.AgentStatusEnabled)
+		if i == 3 {
+			query.AddSiteIDs(other.ID)
+		} else {
+			query.AddSiteIDs(s.ID)
+		}
+		err := query.Exec(context.Background())
+		assert.NoError(suite.T(), err, "should create agent")
+
+		err = client.App.Create().SetName("Mozilla Firefox").SetVersion(fmt.Sprintf("12%d.0", 5+i)).SetPublisher("Mozilla").SetOwnerID(id).Exec(context.Background())
+		assert.NoError(suite.T(), err, "should create app")
+
+		if i%2 == 1 {
+			err = client.App.Create().SetName("uTorrent").SetVersion("3.6").SetPublisher("BitTorrent").SetOwnerID(id).Exec(context.Background())
+			assert.NoError(suite.T(), err, "should create app")
+		}
+	}
+
+	err = client.Agent.Create().SetID("waiting").SetHostname("waiting").SetOs("windows").SetNickname("waiting").SetAgentStatus(agent.AgentStatusWaitingForAdmission).AddSiteIDs(s.ID).Exec(context.Background())
+	assert.NoError(suite.T(), err, "should create agent")
+
+	policies := []consoledb.CompliancePolicy{
+		{TenantID: suite.tenantID, Name: "Browser", Type: compliance.TypeRequired, AppPattern: "*Firefox*", MinVersion: "127.0", Enabled: true},
+		{TenantID: suite.tenantID, Name: "P2P", Type: compliance.TypeForbidden, AppPattern: "*torrent*", Enabled: true},
+		{TenantID: suite.tenantID, Name: "Archiver", Type: compliance.TypeRequired, AppPattern: "7-Zip*", Enabled: false},
+		{TenantID: suite.tenantID + 1, Name: "Office", Type: compliance.TypeRequired, AppPattern: "Microsoft Office*", Enabled: true},
+	}
+	for _, p := range policies {
+		err := suite.model.AddCompliancePolicy(p)
+		assert.NoError(suite.T(), err, "should add compliance policy")
+	}
+}
+
+func (suite *ComplianceTestSuite) evaluate() {
+	policies, err := suite.model.GetEnabledCompliancePolicies()
+	assert.NoError(suite.T(), err, "should get enabled policies")
+
+	agents, err := suite.model.GetComplianceAgents(suite.tenantID, 0, 100)
+	assert.NoError(suite.T(), err, "should get compliance agents")
+	assert.Equal(suite.T(), 4, len(agents), "agents waiting for admission shouldn't be evaluated")
+
+	for _, a := range agents {
+		violations := compliance.Evaluate(policies[suite.tenantID], AgentComplianceApps(a))
+		err := suite.model.SaveComplianceEvaluation(a, suite.tenantID, violations)
+		assert.NoError(suite.T(), err, "should save compliance evaluation")
+	}
+}
+
+func (suite *ComplianceTestSuite) TestCompliancePolicies() {
+	policies, err := suite.model.GetCompliancePolicies(suite.tenantID)
+	assert.NoError(suite.T(), err, "should get compliance policies")
+	assert.Equal(suite.T(), 3, len(policies))
+	assert.Equal(suite.T(), "P2P", policies[0].Name, "forbidden policies should be sorted first")
+	assert.Equal(suite.T(), "127.0", policies[2].MinVersion)
+
+	enabled, err := suite.model.GetEnabledCompliancePolicies()
+	assert.NoError(suite.T(), err, "should get enabled policies")
+	assert.Equal(suite.T(), 2, len(enabled[suite.tenantID]))
+	assert.Equal(suite.T(), 1, len(enabled[suite.tenantID+1]))
+
+	_, err = suite.model.GetCompliancePolicy(policies[0].ID, suite.tenantID+1)
+	assert.ErrorIs(suite.T(), err, ErrCompliancePolicyNotFound, "should not get policies from other tenants")
+
+	err = suite.model.SetCompliancePolicyEnabled(policies[1].ID, suite.tenantID, true)
+	assert.NoError(suite.T(), err, "should enable compliance policy")
+
+	err = suite.model.DeleteCompliancePolicy(policies[0].ID, suite.tenantID+1)
+	assert.ErrorIs(suite.T(), err, ErrCompliancePolicyNotFound, "should not delete policies from other tenants")
+
+	err = suite.model.DeleteCompliancePolicy(policies[0].ID, suite.tenantID)
+	assert.NoError(suite.T(), err, "should delete compliance policy")
+
+	enabled, err = suite.model.GetEnabledCompliancePolicies()
+	assert.NoError(suite.T(), err, "should get enabled policies")
+	assert.Equal(suite.T(), 2, len(enabled[suite.tenantID]))
+	assert.Equal(suite.T(), "Archiver", enabled[suite.tenantID][1].Name)
+}
+
+func (suite *ComplianceTestSuite) TestComplianceResults() {
+	suite.evaluate()
+	// evaluating again replaces the previous results
+	suite.evaluate()
+
+	summary, err := suite.model.GetComplianceSummary(suite.commonInfo)
+	assert.NoError(suite.T(), err, "should get compliance summary")
+	assert.Equal(suite.T(), 4, summary.Evaluated)
+	assert.Equal(suite.T(), 3, summary.NonCompliant, "agents 0 and 1 have an old browser and agents 1 and 3 have P2P")
+	assert.Equal(suite.T(), 1, summary.Compliant)
+	assert.False(suite.T(), summary.LastEvaluated.IsZero())
+
+	byPolicy, err := suite.model.GetPolicyViolations(suite.commonInfo)
+	assert.NoError(suite.T(), err, "should get violations by policy")
+	assert.Equal(suite.T(), []PolicyViolations{
+		{PolicyID: byPolicy[0].PolicyID, PolicyName: "Browser", PolicyType: compliance.TypeRequired, Agents: 2},
+		{PolicyID: byPolicy[1].PolicyID, PolicyName: "P2P", PolicyType: compliance.TypeForbidden, Agents: 2},
+	}, byPolicy)
+
+	count, err := suite.model.CountComplianceViolations(filters.ComplianceViolationFilter{Reasons: []string{compliance.ReasonForbidden}}, suite.commonInfo)
+	assert.NoError(suite.T(), err, "should count violations")
+	assert.Equal(suite.T(), 2, count)
+
+	site := &partials.CommonInfo{TenantID: suite.commonInfo.TenantID, SiteID: fmt.Sprintf("%d", suite.otherSiteID)}
+	count, err = suite.model.CountComplianceViolations(filters.ComplianceViolationFilter{}, site)
+	assert.NoError(suite.T(), err, "should count violations")
+	assert.Equal(suite.T(), 1, count, "only agent3 is in the other site")
+
+	suite.p.SortBy = "hostname"
+	suite.p.SortOrder = "desc"
+	violations, err := suite.model.GetComplianceViolationsByPage(suite.p, filters.ComplianceViolationFilter{App: "TORRENT"}, suite.commonInfo)
+	assert.NoError(suite.T(), err, "should get violations by page")
+	assert.Equal(suite.T(), 2, len(violations))
+	assert.Equal(suite.T(), "agent3", violations[0].Hostname)
+	assert.Equal(suite.T(), "3.6", violations[0].Version)
+
+	violations, err = suite.model.GetAgentComplianceViolations("agent0")
+	assert.NoError(suite.T(), err, "should get agent violations")
+	assert.Equal(suite.T(), 1, len(violations))
+	assert.Equal(suite.T(), compliance.ReasonOutdated, violations[0].Reason)
+	assert.Equal(suite.T(), "125.0", violations[0].Version)
+
+	err = suite.model.DeleteComplianceResults(suite.tenantID, time.Now().Add(-time.Hour))
+	assert.NoError(suite.T(), err, "should delete old results")
+
+	summary, err = suite.model.GetComplianceSummary(suite.commonInfo)
+	assert.NoError(suite.T(), err, "should get compliance summary")
+	assert.Equal(suite.T(), 4, summary.Evaluated, "recent results should be kept")
+
+	err = suite.model.DeleteComplianceResults(suite.tenantID, time.Time{})
+	assert.NoError(suite.T(), err, "should delete every result")
+
+	summary, err = suite.model.GetComplianceSummary(suite.commonInfo)
+	assert.NoError(suite.T(), err, "should get compliance summary")
+	assert.Equal(suite.T(), ComplianceSummary{}, summary)
+}
+
+func TestComplianceTestSuite(t *testing.T) {
+	suite.Run(t, new(ComplianceTestSuite))
+}
@@ -10,8 +10,10 @@ import (
 	"github.com/open-uem/ent/agent"
 	"github.com/open-uem/ent/app"
 	"github.com/open-uem/ent/computer"
+	"github.com/open-uem/ent/metadata"
 	"github.com/open-uem/ent/networkadapter"
 	"github.com/open-uem/ent/operatingsystem"
+	"github.com/open-uem/ent/orgmetadata"
 	"github.com/open-uem/ent/predicate"
 	"github.com/open-uem/ent/printer"
 	"github.com/open-uem/ent/profile"
@@ -26,21 +28,23 @@ import (
 )
 
 type Computer struct {
-	ID           string
-	Hostname     string `sql:"hostname"`
-	Nickname     string `sql:"nickname"`
-	OS           string
-	Version      string
-	IP           string
-	MAC          string
-	Username     string
-	Manufacturer string
-	Model        string
-	Serial       string
-	IsRemote     bool      `sql:"is_remote"`
-	LastContact  time.Time `sql:"last_contact"`
-	Tags         []*ent.Tag
-	SiteID       int
+	ID             string
+	Hostname       string `sql:"hostname"`
+	Nickname       string `sql:"nickname"`
+	OS             string
+	Version        string
+	IP             string
+	MAC            string
+	Username       string
+	Manufacturer   string
+	Model          string
+	Serial         string
+	IsRemote       bool      `sql:"is_remote"`
+	LastContact    time.Time `sql:"last_contact"`
+	Tags           []*ent.Tag
+	SiteID         int
+	PurchaseDate   time.Time
+	WarrantyExpiry time.Time
 }
 
 func (m *Model) CountAllComputers(f filters.AgentFilter, c *partials.CommonInfo) (int, error) {
@@ -76,6 +80,35 @@ func (m *Model) CountAllComputers(f filters.AgentFilter, c *partials.CommonInfo)
 	return count, err
 }
 
+// GetComputerIDs returns the IDs of every computer that matches the filters, it's used
+// to select all the computers of the list and not only those in the current page
+func (m *Model) GetComputerIDs(f filters.AgentFilter, c *partials.CommonInfo) ([]string, error) {
+	var query *ent.AgentQuery
+
+	siteID, err := strconv.Atoi(c.SiteID)
+	if err != nil {
+		return nil, err
+	}
+	tenantID, err := strconv.Atoi(c.TenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if siteID == -1 {
+		query = m.Client.Agent.Query().
+			Where(agent.AgentStatusNEQ(agent.AgentStatusWaitingForAdmission)).
+			Where(agent.HasSiteWith(site.HasTenantWith(tenant.ID(tenantID))))
+	} else {
+		query = m.Client.Agent.Query().
+			Where(agent.AgentStatusNEQ(agent.AgentStatusWaitingForAdmission)).
+			Where(agent.HasSiteWith(site.ID(siteID), site.HasTenantWith(tenant.ID(tenantID))))
+	}
+
+	applyComputerFilters(query, f)
+
+	return query.IDs(context.Background())
+}
+
 func mainQuery(s *sql.Selector, p partials.PaginationAndSort) {
 	s.Select(sql.As(agent.FieldID, "ID"), agent.FieldHostname, agent.FieldNickname, agent.FieldOs, "`t2`.`version`", agent.FieldIP, agent.FieldMAC, operatingsystem.FieldUsername, computer.FieldManufacturer, computer.FieldModel, computer.FieldSerial, agent.FieldIsRemote, agent.FieldLastContact).
 		LeftJoin(sql.Table(computer.Table)).
@@ -250,6 +283,42 @@ func (m *Model) GetComputersByPage(p partials.PaginationAndSort, f filters.Agent
 				s.OrderBy(sql.Desc(agent.FieldIsRemote))
 			}).Scan(context.Background(), &computers)
 		}
+	case "serial":
+		if p.SortOrder == "asc" {
+			err = query.Modify(func(s *sql.Selector) {
+				mainQuery(s, p)
+				s.OrderBy(sql.Asc(computer.FieldSerial))
+			}).Scan(context.Background(), &computers)
+		} else {
+			err = query.Modify(func(s *sql.Selector) {
+				mainQuery(s, p)
+				s.OrderBy(sql.Desc(computer.FieldSerial))
+			}).Scan(context.Background(), &computers)
+		}
+	case "ip":
+		if p.SortOrder == "asc" {
+			err = query.Modify(func(s *sql.Selector) {
+				mainQuery(s, p)
+				s.OrderBy(sql.Asc(agent.FieldIP))
+			}).Scan(context.Background(), &computers)
+		} else {
+			err = query.Modify(func(s *sql.Selector) {
+				mainQuery(s, p)
+				s.OrderBy(sql.Desc(agent.FieldIP))
+			}).Scan(context.Background(), &computers)
+		}
+	case "last_contact":
+		if p.SortOrder == "asc" {
+			err = query.Modify(func(s *sql.Selector) {
+				mainQuery(s, p)
+				s.OrderBy(sql.Asc(agent.FieldLastContact))
+			}).Scan(context.Background(), &computers)
+		} else {
+			err = query.Modify(func(s *sql.Selector) {
+				mainQuery(s, p)
+				s.OrderBy(sql.Desc(agent.FieldLastContact))
+			}).Scan(context.Background(), &computers)
+		}
 	default:
 		err = query.Modify(func(s *sql.Selector) {
 			mainQuery(s, p)
@@ -285,67 +354,91 @@ func (m *Model) GetComputersByPage(p partials.PaginationAndSort, f filters.Agent
 		}
 	}
 
+	// Add the purchase date and warranty expiry kept by the console
+	lifecycles, err := m.GetHardwareLifecycles(sortedAgentIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i, computer := range computers {
+		computers[i].PurchaseDate = lifecycles[computer.ID].PurchaseDate
+		computers[i].WarrantyExpiry = lifecycles[computer.ID].WarrantyExpiry
+	}
+
 	return computers, nil
 }
 
 func applyComputerFilters(query *ent.AgentQuery, f filters.AgentFilter) {
+	query.Where(computerPredicates(f)...)
+}
+
+// computerPredicates returns the conditions of the filters of the computers list, so
+// they can also be applied to the entities owned by the agents
+func computerPredicates(f filters.AgentFilter) []predicate.Agent {
+	predicates := []predicate.Agent{}
+
 	if len(f.Nickname) > 0 {
-		query.Where(agent.NicknameContainsFold(f.Nickname))
+		predicates = append(predicates, agent.NicknameContainsFold(f.Nickname))
 	}
 
 	if len(f.Username) > 0 {
-		query.Where(agent.HasOperatingsystemWith(operatingsystem.UsernameContainsFold(f.Username)))
+		predicates = append(predicates, agent.HasOperatingsystemWith(operatingsystem.UsernameContainsFold(f.Username)))
 	}
 
 	if len(f.AgentOSVersions) > 0 {
-		query.Where(agent.OsIn(f.AgentOSVersions...))
+		predicates = append(predicates, agent.OsIn(f.AgentOSVersions...))
 	}
 
 	if len(f.OSVersions) > 0 {
-		query.Where(agent.HasOperatingsystemWith(operatingsystem.VersionIn(f.OSVersions...)))
+		predicates = append(predicates, agent.HasOperatingsystemWith(operatingsystem.VersionIn(f.OSVersions...)))
 	}
 
 	if len(f.ComputerManufacturers) > 0 {
-		query.Where(agent.HasComputerWith(computer.ManufacturerIn(f.ComputerManufacturers...)))
+		predicates = append(predicates, agent.HasComputerWith(computer.ManufacturerIn(f.ComputerManufacturers...)))
 	}
 
 	if len(f.ComputerModels) > 0 {
-		query.Where(agent.HasComputerWith(computer.ModelIn(f.ComputerModels...)))
+		predicates = append(predicates, agent.HasComputerWith(computer.ModelIn(f.ComputerModels...)))
 	}
 
 	if len(f.WithApplication) > 0 && len(f.WithApplicationPublisher) > 0 {
-		query.Where(agent.HasComputerWith(computer.HasOwnerWith(agent.HasAppsWith(app.And(app.Name(f.WithApplication), app.Publisher(f.WithApplicationPublisher))))))
+		predicates = append(predicates, agent.HasComputerWith(computer.HasOwnerWith(agent.HasAppsWith(app.And(app.Name(f.WithApplication), app.Publisher(f.WithApplicationPublisher))))))
 	} else {
 		if len(f.WithApplication) > 0 {
-			query.Where(agent.HasComputerWith(computer.HasOwnerWith(agent.HasAppsWith(app.Name(f.WithApplication)))))
+			predicates = append(predicates, agent.HasComputerWith(computer.HasOwnerWith(agent.HasAppsWith(app.Name(f.WithApplication)))))
 		}
 		if len(f.WithApplicationPublisher) > 0 {
-			query.Where(agent.HasComputerWith(computer.HasOwnerWith(agent.HasAppsWith(app.Name(f.WithApplicationPublisher)))))
+			predicates = append(predicates, agent.HasComputerWith(computer.HasOwnerWith(agent.HasAppsWith(app.Name(f.WithApplicationPublisher)))))
 		}
 	}
 
 	if len(f.IsRemote) > 0 {
 		if len(f.IsRemote) == 1 && f.IsRemote[0] == "Remote" {
-			query.Where(agent.IsRemote(true))
+			predicates = append(predicates, agent.IsRemote(true))
 		}
 
 		if len(f.IsRemote) == 1 && f.IsRemote[0] == "Local" {
-			query.Where(agent.IsRemote(false))
+			predicates = append(predicates, agent.IsRemote(false))
 		}
 	}
 
-	if len(f.Tags) > 0 {
-		predicates := []predicate.Agent{}
-		for _, id := range f.Tags {
-			predicates = append(predicates, agent.HasTagsWith(tag.ID(id)))
-		}
-		if len(predicates) > 0 {
-			query.Where(agent.And(predicates...))
-		}
+	for _, id := range f.Tags {
+		predicates = append(predicates, agent.HasTagsWith(tag.ID(id)))
+	}
+
+	for id, value := range f.Metadata {
+		predicates = append(predicates, agent.HasMetadataWith(metadata.HasOrgWith(orgmetadata.ID(id)), metadata.Value(value)))
+	}
+
+	if len(f.HardwareAges) > 0 {
+		predicates = append(predicates, hardwareAgePredicate(f.HardwareAges, time.Now().UTC()))
+	}
+
+	if len(f.Warranties) > 0 {
+		predicates = append(predicates, hardwareWarrantyPredicate(f.Warranties, time.Now().UTC()))
 	}
 
 	if len(f.Search) > 0 {
-		query.Where(agent.Or(
+		predicates = append(predicates, agent.Or(
 			agent.NicknameContainsFold(f.Search),
 			agent.OsIn(f.Search),
 			agent.HasOperatingsystemWith(operatingsystem.UsernameContainsFold(f.Search)),
@@ -353,6 +446,8 @@ func applyComputerFilters(query *ent.AgentQuery, f filters.AgentFilter) {
 			agent.HasComputerWith(computer.ModelContainsFold(f.Search)),
 		))
 	}
+
+	return predicates
 }
 
 func (m *Model) GetAgentComputerInfo(agentId string, c *partials.CommonInfo) (*ent.Agent, error) {
@@ -9,7 +9,6 @@ import (
 
 	openuem_ent "github.com/open-uem/ent"
 	"github.com/open-uem/ent/agent"
-	"github.com/open-uem/ent/enttest"
 	"github.com/open-uem/openuem-console/internal/views/filters"
 	"github.com/open-uem/openuem-console/internal/views/partials"
 	"github.com/stretchr/testify/assert"
@@ -18,7 +17,6 @@ import (
 
 type ComputersTestSuite struct {
 	suite.Suite
-	t          enttest.TestingT
 	model      Model
 	p          partials.PaginationAndSort
 	tags       []int
@@ -26,8 +24,8 @@ type ComputersTestSuite struct {
 }
 
 func (suite *ComputersTestSuite) SetupTest() {
-	client := enttest.Open(suite.t, "sqlite3", "file:ent?mode=memory&_fk=1")
-	suite.model = Model{Client: client}
+	suite.model = newTestModel(suite.T())
+	client := suite.model.Client
 
 	t, err := suite.model.CreateDefaultTenant()
 	assert.NoError(suite.T(), err, "should create default tenant")
@@ -79,6 +77,7 @@ func (suite *ComputersTestSuite) SetupTest() {
 			SetManufacturer(fmt.Sprintf("manufacturer%d", i)).
 			SetMemory(10240000000).
 			SetModel(fmt.Sprintf("model%d", i)).
+			SetSerial(fmt.Sprintf("serial%d", i)).
 			SetProcessor("intel").
 			SetProcessorArch("amd64").
 			SetProcessorCores(4).
@@ -255,6 +254,22 @@ func (suite *ComputersTestSuite) TestGetComputersByPage() {
 		assert.Equal(suite.T(), fmt.Sprintf("agent%d", 6-i), item.ID, fmt.Sprintf("agent ID should be %d", 6-i))
 	}
 
+	suite.p.SortBy = "serial"
+	suite.p.SortOrder = "asc"
+	items, err = suite.model.GetComputersByPage(suite.p, filters.AgentFilter{}, suite.commonInfo)
+	assert.NoError(suite.T(), err, "should get computers by page")
+	for i, item := range items {
+		assert.Equal(suite.T(), fmt.Sprintf("serial%d", i), item.Serial, fmt.Sprintf("serial should be %d", i))
+	}
+
+	suite.p.SortBy = "serial"
+	suite.p.SortOrder = "desc"
+	items, err = suite.model.GetComputersByPage(suite.p, filters.AgentFilter{}, suite.commonInfo)
+	assert.NoError(suite.T(), err, "should get computers by page")
+	for i, item := range items {
+		assert.Equal(suite.T(), fmt.Sprintf("serial%d", 6-i), item.Serial, fmt.Sprintf("serial should be %d", 6-i))
+	}
+
 	suite.p.SortBy = "nickname"
 	suite.p.SortOrder = "asc"
 	items, err = suite.model.GetComputersByPage(suite.p, filters.AgentFilter{Tags: []int{suite.tags[1]}}, suite.commonInfo)
@@ -311,6 +326,17 @@ func (suite *ComputersTestSuite) TestCountAllComputers() {
 	assert.Equal(suite.T(), 3, count, "should count 3 computers")
 }
 
+func (suite *ComputersTestSuite) TestGetComputerIDs() {
+	ids, err := suite.model.GetComputerIDs(filters.AgentFilter{}, suite.commonInfo)
+	assert.NoError(suite.T(), err, "should get computer ids")
+	assert.Equal(suite.T(), 7, len(ids), "should get 7 computer ids")
+
+	f := filters.AgentFilter{ComputerManufacturers: []string{"manufacturer0", "manufacturer1", "manufacturer3"}}
+	ids, err = suite.model.GetComputerIDs(f, suite.commonInfo)
+	assert.NoError(suite.T(), err, "should get computer ids")
+	assert.Equal(suite.T(), 3, len(ids), "should get 3 computer ids")
+}
+
 func (suite *ComputersTestSuite) TestGetAgentComputerInfo() {
 	var err error
 
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"context"
+
+	"entgo.io/ent/dialect/sql/schema"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+)
+
+// CreateConsoleTables creates or updates the tables owned by the console. Only the tables
+// listed in consoledb.Tables are inspected so the tables in the shared schema are not modified
+func (m *Model) CreateConsoleTables() error {
+	migrate, err := schema.NewMigrate(m.Driver)
+	if err != nil {
+		return err
+	}
+	return migrate.Create(context.Background(), consoledb.Tables...)
+}
@@ -0,0 +1,361 @@
+package models
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	entsql "entgo.io/ent/dialect/sql"
+	"github.com/open-uem/ent/agent"
+	"github.com/open-uem/ent/app"
+	"github.com/open-uem/ent/computer"
+	"github.com/open-uem/ent/metadata"
+	"github.com/open-uem/ent/operatingsystem"
+	"github.com/open-uem/ent/orgmetadata"
+	"github.com/open-uem/ent/predicate"
+	"github.com/open-uem/ent/tag"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/dashboards"
+	"github.com/open-uem/openuem-console/internal/lifecycle"
+	"github.com/open-uem/openuem-console/internal/views/filters"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+)
+
+var (
+	ErrDashboardNotFound       = errors.New("the dashboard doesn't exist")
+	ErrDashboardWidgetNotFound = errors.New("the widget doesn't exist")
+)
+
+var dashboardColumns = []string{"id", "tenant_id", "user_id", "name", "shared", "created"}
+
+var dashboardWidgetColumns = []string{"id", "dashboard_id", "position", "title", "kind", "dimension", "query", "max_values"}
+
+// Breakdown is the number of computers with a value of a dimension. Label is the value
+// shown to the user, which differs from the value for tags, whose value is their id
+type Breakdown struct {
+	Value string
+	Label string
+	Count int
+}
+
+// AddDashboard saves a new dashboard without widgets and returns its id
+func (m *Model) AddDashboard(d consoledb.Dashboard) (int, error) {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Insert(consoledb.DashboardsTable.Name).
+		Columns(dashboardColumns[1:]...).
+		Values(d.TenantID, d.UserID, d.Name, d.Shared, time.Now()).
+		Returning("id").
+		Query()
+
+	var id int
+	if err := m.Driver.DB().QueryRowContext(context.Background(), query, args...).Scan(&id); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// UpdateDashboard renames a dashboard of the user and shares or stops sharing it
+func (m *Model) UpdateDashboard(d consoledb.Dashboard) error {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Update(consoledb.DashboardsTable.Name).
+		Set("name", d.Name).
+		Set("shared", d.Shared).
+		Where(entsql.And(entsql.EQ("id", d.ID), entsql.EQ("tenant_id", d.TenantID), entsql.EQ("user_id", d.UserID))).
+		Query()
+
+	return m.execAffectingOne(query, args, ErrDashboardNotFound)
+}
+
+// GetDashboards returns the dashboards the user can open, their own dashboards and the
+// dashboards shared in the tenant, sorted by name
+func (m *Model) GetDashboards(userID string, tenantID int) ([]consoledb.Dashboard, error) {
+	return m.queryDashboards(func(s *entsql.Selector) {
+		s.Where(entsql.And(
+			entsql.EQ("tenant_id", tenantID),
+			entsql.Or(entsql.EQ("user_id", userID), entsql.EQ("shared", true)),
+		)).OrderBy(entsql.Asc("name"), entsql.Asc("id"))
+	})
+}
+
+// GetDashboard returns a dashboard if the user created it or it's shared in the tenant
+func (m *Model) GetDashboard(id int, userID string, tenantID int) (consoledb.Dashboard, error) {
+	items, err := m.queryDashboards(func(s *entsql.Selector) {
+		s.Where(entsql.And(
+			entsql.EQ("id", id),
+			entsql.EQ("tenant_id", tenantID),
+			entsql.Or(entsql.EQ("user_id", userID), entsql.EQ("shared", true)),
+		))
+	})
+	if err != nil {
+		return consoledb.Dashboard{}, err
+	}
+
+	if len(items) != 1 {
+		return consoledb.Dashboard{}, ErrDashboardNotFound
+	}
+
+	return items[0], nil
+}
+
+// DeleteDashboard removes a dashboard created by the user and its widgets
+func (m *Model) DeleteDashboard(id int, userID string, tenantID int) error {
+	ctx := context.Background()
+
+	tx, err := m.Driver.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Delete(consoledb.DashboardsTable.Name).
+		Where(entsql.And(entsql.EQ("id", id), entsql.EQ("tenant_id", tenantID), entsql.EQ("user_id", userID))).
+		Query()
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrDashboardNotFound
+	}
+
+	query, args = entsql.Dialect(m.Driver.Dialect()).
+		Delete(consoledb.DashboardWidgetsTable.Name).
+		Where(entsql.EQ("dashboard_id", id)).
+		Query()
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetDashboardWidgets returns the widgets of a dashboard in the order they're shown
+func (m *Model) GetDashboardWidgets(dashboardID int) ([]consoledb.DashboardWidget, error) {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Select(dashboardWidgetColumns...).
+		From(entsql.Table(consoledb.DashboardWidgetsTable.Name)).
+		Where(entsql.EQ("dashboard_id", dashboardID)).
+		OrderBy(entsql.Asc("position"), entsql.Asc("id")).
+		Query()
+
+	rows, err := m.Driver.DB().QueryContext(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	widgets := []consoledb.DashboardWidget{}
+	for rows.Next() {
+		var w consoledb.DashboardWidget
+		if err := rows.Scan(&w.ID, &w.DashboardID, &w.Position, &w.Title, &w.Kind, &w.Dimension, &w.Query, &w.Limit); err != nil {
+			return nil, err
+		}
+		widgets = append(widgets, w)
+	}
+
+	return widgets, rows.Err()
+}
+
+// AddDashboardWidget places the widget after the other widgets of its dashboard
+func (m *Model) AddDashboardWidget(w consoledb.DashboardWidget) error {
+	widgets, err := m.GetDashboardWidgets(w.DashboardID)
+	if err != nil {
+		return err
+	}
+
+	position := 0
+	for _, other := range widgets {
+		position = max(position, other.Position+1)
+	}
+
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Insert(consoledb.DashboardWidgetsTable.Name).
+		Columns(dashboardWidgetColumns[1:]...).
+		Values(w.DashboardID, position, w.Title, w.Kind, w.Dimension, w.Query, w.Limit).
+		Query()
+
+	_, err = m.Driver.DB().ExecContext(context.Background(), query, args...)
+	return err
+}
+
+// DeleteDashboardWidget removes a widget of a dashboard
+func (m *Model) DeleteDashboardWidget(id, dashboardID int) error {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Delete(consoledb.DashboardWidgetsTable.Name).
+		Where(entsql.And(entsql.EQ("id", id), entsql.EQ("dashboard_id", dashboardID))).
+		Query()
+
+	return m.execAffectingOne(query, args, ErrDashboardWidgetNotFound)
+}
+
+// MoveDashboardWidget swaps a widget with the previous one, or the next one if up is
+// false. Nothing changes if the widget is already the first or the last one
+func (m *Model) MoveDashboardWidget(id, dashboardID int, up bool) error {
+	widgets, err := m.GetDashboardWidgets(dashboardID)
+	if err != nil {
+		return err
+	}
+
+	index := slices.IndexFunc(widgets, func(w consoledb.DashboardWidget) bool { return w.ID == id })
+	if index == -1 {
+		return ErrDashboardWidgetNotFound
+	}
+
+	other := index + 1
+	if up {
+		other = index - 1
+	}
+	if other < 0 || other >= len(widgets) {
+		return nil
+	}
+
+	// the positions are numbered again so widgets saved with the same position can be swapped
+	ctx := context.Background()
+	tx, err := m.Driver.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	widgets[index], widgets[other] = widgets[other], widgets[index]
+	for position, w := range widgets {
+		query, args := entsql.Dialect(m.Driver.Dialect()).
+			Update(consoledb.DashboardWidgetsTable.Name).
+			Set("position", position).
+			Where(entsql.EQ("id", w.ID)).
+			Query()
+
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// CountComputersBy breaks the admitted computers of the tenant or site that match the
+// filter down by a dimension of the dashboards catalog. Values without computers are left
+// out and the rest are sorted by the number of computers
+func (m *Model) CountComputersBy(dimension string, f filters.AgentFilter, c *partials.CommonInfo) ([]Breakdown, error) {
+	predicates, err := admittedAgentsPredicates(c)
+	if err != nil {
+		return nil, err
+	}
+	predicates = append(predicates, computerPredicates(f)...)
+
+	ctx := context.Background()
+	rows := []struct {
+		Value string `sql:"value"`
+		Count int    `sql:"count"`
+	}{}
+	groupBy := func(column string) func(s *entsql.Selector) {
+		return func(s *entsql.Selector) {
+			s.Select(entsql.As(s.C(column), "value"), entsql.As(entsql.Count("*"), "count")).GroupBy(s.C(column))
+		}
+	}
+	countBy := func(values []string, predicate func(value string) predicate.Agent) ([]Breakdown, error) {
+		items := []Breakdown{}
+		for _, value := range values {
+			count, err := m.Client.Agent.Query().Where(predicates...).Where(predicate(value)).Count(ctx)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, Breakdown{Value: value, Label: value, Count: count})
+		}
+		return items, nil
+	}
+
+	items := []Breakdown{}
+	switch dimension {
+	case dashboards.DimensionOS:
+		err = m.Client.Agent.Query().Where(predicates...).Modify(groupBy(agent.FieldOs)).Scan(ctx, &rows)
+	case dashboards.DimensionOSVersion:
+		err = m.Client.OperatingSystem.Query().Where(operatingsystem.HasOwnerWith(predicates...)).Modify(groupBy(operatingsystem.FieldVersion)).Scan(ctx, &rows)
+	case dashboards.DimensionManufacturer:
+		err = m.Client.Computer.Query().Where(computer.HasOwnerWith(predicates...)).Modify(groupBy(computer.FieldManufacturer)).Scan(ctx, &rows)
+	case dashboards.DimensionModel:
+		err = m.Client.Computer.Query().Where(computer.HasOwnerWith(predicates...)).Modify(groupBy(computer.FieldModel)).Scan(ctx, &rows)
+	case dashboards.DimensionApp:
+		// an application installed twice in a computer is counted once
+		err = m.Client.App.Query().Where(app.HasOwnerWith(predicates...)).Modify(func(s *entsql.Selector) {
+			s.Select(entsql.As(s.C(app.FieldName), "value"), entsql.As(entsql.Count(entsql.Distinct(s.C(app.OwnerColumn))), "count")).GroupBy(s.C(app.FieldName))
+		}).Scan(ctx, &rows)
+	case dashboards.DimensionRemote:
+		items, err = countBy([]string{"Remote", "Local"}, func(value string) predicate.Agent { return agent.IsRemote(value == "Remote") })
+	case dashboards.DimensionHardwareAge:
+		now := time.Now().UTC()
+		items, err = countBy(lifecycle.Ages, func(value string) predicate.Agent { return hardwareAgePredicate([]string{value}, now) })
+	case dashboards.DimensionWarranty:
+		now := time.Now().UTC()
+		items, err = countBy(lifecycle.Warranties, func(value string) predicate.Agent { return hardwareWarrantyPredicate([]string{value}, now) })
+	case dashboards.DimensionTag:
+		tags, err := m.Client.Tag.Query().Where(tag.HasOwnerWith(predicates...)).All(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range tags {
+			count, err := m.Client.Agent.Query().Where(predicates...).Where(agent.HasTagsWith(tag.ID(t.ID))).Count(ctx)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, Breakdown{Value: strconv.Itoa(t.ID), Label: t.Tag, Count: count})
+		}
+	default:
+		id, ok := dashboards.MetadataID(dimension)
+		if !ok {
+			return nil, dashboards.ErrInvalidDimension
+		}
+		err = m.Client.Metadata.Query().Where(metadata.HasOrgWith(orgmetadata.ID(id)), metadata.HasOwnerWith(predicates...)).Modify(groupBy(metadata.FieldValue)).Scan(ctx, &rows)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range rows {
+		items = append(items, Breakdown{Value: r.Value, Label: r.Value, Count: r.Count})
+	}
+
+	items = slices.DeleteFunc(items, func(b Breakdown) bool { return b.Count == 0 })
+	slices.SortStableFunc(items, func(a, b Breakdown) int {
+		if result := cmp.Compare(b.Count, a.Count); result != 0 {
+			return result
+		}
+		return cmp.Compare(strings.ToLower(a.Label), strings.ToLower(b.Label))
+	})
+
+	return items, nil
+}
+
+func (m *Model) queryDashboards(modifier func(s *entsql.Selector)) ([]consoledb.Dashboard, error) {
+	selector := entsql.Dialect(m.Driver.Dialect()).
+		Select(dashboardColumns...).
+		From(entsql.Table(consoledb.DashboardsTable.Name))
+	modifier(selector)
+
+	query, args := selector.Query()
+	rows, err := m.Driver.DB().QueryContext(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []consoledb.Dashboard{}
+	for rows.Next() {
+		var d consoledb.Dashboard
+		if err := rows.Scan(&d.ID, &d.TenantID, &d.UserID, &d.Name, &d.Shared, &d.Created); err != nil {
+			return nil, err
+		}
+		items = append(items, d)
+	}
+
+	return items, rows.Err()
+}
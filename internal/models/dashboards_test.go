@@ -0,0 +1,237 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/open-uem/ent/agent"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/dashboards"
+	"github.com/open-uem/openuem-console/internal/views/filters"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type DashboardsTestSuite struct {
+	suite.Suite
+	model      Model
+	commonInfo *partials.CommonInfo
+	tenantID   int
+	tagID      int
+	metadataID int
+}
+
+func (suite *DashboardsTestSuite) SetupTest() {
+	suite.model = newTestModel(suite.T())
+	client := suite.model.Client
+
+	t, err := suite.model.CreateDefaultTenant()
+	assert.NoError(suite.T(), err, "should create default tenant")
+	suite.tenantID = t.ID
+
+	s, err := suite.model.CreateDefaultSite(t)
+	assert.NoError(suite.T(), err, "should create default site")
+
+	suite.commonInfo = &partials.CommonInfo{TenantID: fmt.Sprintf("%d", t.ID), SiteID: "-1"}
+
+	tag, err := client.Tag.Create().SetTag("finance").SetColor("#ff0000").SetTenantID(t.ID).Save(context.Background())
+	assert.NoError(suite.T(), err, "should create tag")
+	suite.tagID = tag.ID
+
+	org, err := client.OrgMetadata.Create().SetName("building").SetTenantID(t.ID).Save(context.Background())
+	assert.NoError(suite.T(), err, "should create org metadata")
+	suite.metadataID = org.ID
+
+	for i := range 5 {
+		id := fmt.Sprintf("agent%d", i)
+		os := "windows"
+		if i == 2 {
+			os = "linux"
+		}
+		status := agent.AgentStatusEnabled
+		if i == 4 {
+			status = agent.AgentStatusWaitingForAdmission
+		}
+		query := client.Agent.Create().
+			SetID(id).
+			SetHostname(id).
+			SetOs(os).
+			SetNickname(id).
+			SetAgentStatus(status).
+			SetIsRemote(i == 0).
+			AddSiteIDs(s.ID)
+		if i < 2 {
+			query.AddTagIDs(tag.ID)
+		}
+		err := query.Exec(context.Background())
+		assert.NoError(suite.T(), err, "should create agent")
+
+		manufacturer := "HP"
+		if i == 1 {
+			manufacturer = "Dell"
+		}
+		err = client.Computer.Create().
+			SetManufacturer(manufacturer).
+			SetMemory(16384).
+			SetModel("model").
+			SetSerial(fmt.Sprintf("serial%d", i)).
+			SetProcessor("intel").
+			SetProcessorArch("amd64").
+			SetProcessorCores(4).
+			SetOwnerID(id).
+			Exec(context.Background())
+		assert.NoError(suite.T(), err, "should create computer")
+
+		err = client.OperatingSystem.Create().
+			SetVersion("10").
+			SetDescription("description").
+			SetUsername("user").
+			SetOwnerID(id).
+			Exec(context.Background())
+		assert.NoError(suite.T(), err, "should create operating system")
+
+		// the first computer has the application installed twice
+		for j := range 2 - min(i, 1) {
+			err = client.App.Create().
+				SetName("Firefox").
+				SetVersion(fmt.Sprintf("%d", j)).
+				SetOwnerID(id).
+				Exec(context.Background())
+			assert.NoError(suite.T(), err, "should create app")
+		}
+
+		err = client.Metadata.Create().
+			SetValue(fmt.Sprintf("B%d", i%2)).
+			SetOwnerID(id).
+			SetOrgID(org.ID).
+			Exec(context.Background())
+		assert.NoError(suite.T(), err, "should create metadata")
+	}
+}
+
+func (suite *DashboardsTestSuite) TestDashboards() {
+	id, err := suite.model.AddDashboard(consoledb.Dashboard{TenantID: suite.tenantID, UserID: "admin", Name: "Inventory"})
+	assert.NoError(suite.T(), err, "should add dashboard")
+
+	_, err = suite.model.AddDashboard(consoledb.Dashboard{TenantID: suite.tenantID, UserID: "other", Name: "Assets"})
+	assert.NoError(suite.T(), err, "should add dashboard")
+
+	items, err := suite.model.GetDashboards("admin", suite.tenantID)
+	assert.NoError(suite.T(), err, "should get dashboards")
+	assert.Equal(suite.T(), 1, len(items), "private dashboards of other users must be hidden")
+
+	err = suite.model.UpdateDashboard(consoledb.Dashboard{ID: id, TenantID: suite.tenantID, UserID: "other", Name: "Stolen"})
+	assert.ErrorIs(suite.T(), err, ErrDashboardNotFound, "only the owner can update the dashboard")
+
+	err = suite.model.UpdateDashboard(consoledb.Dashboard{ID: id, TenantID: suite.tenantID, UserID: "admin", Name: "Fleet", Shared: true})
+	assert.NoError(suite.T(), err, "should update dashboard")
+
+	d, err := suite.model.GetDashboard(id, "other", suite.tenantID)
+	assert.NoError(suite.T(), err, "shared dashboards are visible to other users")
+	assert.Equal(suite.T(), "Fleet", d.Name)
+
+	items, err = suite.model.GetDashboards("other", suite.tenantID)
+	assert.NoError(suite.T(), err, "should get dashboards")
+	assert.Equal(suite.T(), []string{"Assets", "Fleet"}, []string{items[0].Name, items[1].Name})
+
+	_, err = suite.model.GetDashboard(id, "admin", suite.tenantID+1)
+	assert.ErrorIs(suite.T(), err, ErrDashboardNotFound, "dashboards belong to a tenant")
+
+	err = suite.model.DeleteDashboard(id, "other", suite.tenantID)
+	assert.ErrorIs(suite.T(), err, ErrDashboardNotFound, "only the owner can delete the dashboard")
+
+	err = suite.model.AddDashboardWidget(consoledb.DashboardWidget{DashboardID: id, Title: "Computers", Kind: dashboards.KindCounter})
+	assert.NoError(suite.T(), err, "should add widget")
+
+	err = suite.model.DeleteDashboard(id, "admin", suite.tenantID)
+	assert.NoError(suite.T(), err, "should delete dashboard")
+
+	widgets, err := suite.model.GetDashboardWidgets(id)
+	assert.NoError(suite.T(), err, "should get widgets")
+	assert.Empty(suite.T(), widgets, "widgets must be deleted with their dashboard")
+}
+
+func (suite *DashboardsTestSuite) TestDashboardWidgets() {
+	id, err := suite.model.AddDashboard(consoledb.Dashboard{TenantID: suite.tenantID, UserID: "admin", Name: "Inventory"})
+	assert.NoError(suite.T(), err, "should add dashboard")
+
+	for _, title := range []string{"first", "second", "third"} {
+		err := suite.model.AddDashboardWidget(consoledb.DashboardWidget{DashboardID: id, Title: title, Kind: dashboards.KindPie, Dimension: dashboards.DimensionOS, Limit: 10})
+		assert.NoError(suite.T(), err, "should add widget")
+	}
+
+	widgets, err := suite.model.GetDashboardWidgets(id)
+	assert.NoError(suite.T(), err, "should get widgets")
+	assert.Equal(suite.T(), []int{0, 1, 2}, []int{widgets[0].Position, widgets[1].Position, widgets[2].Position})
+
+	err = suite.model.MoveDashboardWidget(widgets[2].ID, id, true)
+	assert.NoError(suite.T(), err, "should move widget")
+
+	err = suite.model.MoveDashboardWidget(widgets[0].ID, id, true)
+	assert.NoError(suite.T(), err, "moving the first widget up does nothing")
+
+	moved, err := suite.model.GetDashboardWidgets(id)
+	assert.NoError(suite.T(), err, "should get widgets")
+	assert.Equal(suite.T(), []string{"first", "third", "second"}, []string{moved[0].Title, moved[1].Title, moved[2].Title})
+
+	err = suite.model.DeleteDashboardWidget(widgets[0].ID, id+1)
+	assert.ErrorIs(suite.T(), err, ErrDashboardWidgetNotFound, "widgets belong to a dashboard")
+
+	err = suite.model.DeleteDashboardWidget(widgets[0].ID, id)
+	assert.NoError(suite.T(), err, "should delete widget")
+
+	moved, err = suite.model.GetDashboardWidgets(id)
+	assert.NoError(suite.T(), err, "should get widgets")
+	assert.Equal(suite.T(), 2, len(moved))
+}
+
+func (suite *DashboardsTestSuite) TestCountComputersBy() {
+	f := filters.AgentFilter{}
+
+	items, err := suite.model.CountComputersBy(dashboards.DimensionOS, f, suite.commonInfo)
+	assert.NoError(suite.T(), err, "should count computers by os")
+	assert.Equal(suite.T(), []Breakdown{{Value: "windows", Label: "windows", Count: 3}, {Value: "linux", Label: "linux", Count: 1}}, items)
+
+	items, err = suite.model.CountComputersBy(dashboards.DimensionOSVersion, f, suite.commonInfo)
+	assert.NoError(suite.T(), err, "should count computers by os version")
+	assert.Equal(suite.T(), []Breakdown{{Value: "10", Label: "10", Count: 4}}, items)
+
+	items, err = suite.model.CountComputersBy(dashboards.DimensionManufacturer, f, suite.commonInfo)
+	assert.NoError(suite.T(), err, "should count computers by manufacturer")
+	assert.Equal(suite.T(), []Breakdown{{Value: "HP", Label: "HP", Count: 3}, {Value: "Dell", Label: "Dell", Count: 1}}, items)
+
+	items, err = suite.model.CountComputersBy(dashboards.DimensionApp, f, suite.commonInfo)
+	assert.NoError(suite.T(), err, "should count computers by app")
+	assert.Equal(suite.T(), []Breakdown{{Value: "Firefox", Label: "Firefox", Count: 4}}, items, "apps installed twice are counted once")
+
+	items, err = suite.model.CountComputersBy(dashboards.DimensionRemote, f, suite.commonInfo)
+	assert.NoError(suite.T(), err, "should count computers by remote")
+	assert.Equal(suite.T(), []Breakdown{{Value: "Local", Label: "Local", Count: 3}, {Value: "Remote", Label: "Remote", Count: 1}}, items)
+
+	items, err = suite.model.CountComputersBy(dashboards.DimensionTag, f, suite.commonInfo)
+	assert.NoError(suite.T(), err, "should count computers by tag")
+	assert.Equal(suite.T(), []Breakdown{{Value: strconv.Itoa(suite.tagID), Label: "finance", Count: 2}}, items)
+
+	items, err = suite.model.CountComputersBy(dashboards.MetadataDimension(suite.metadataID), f, suite.commonInfo)
+	assert.NoError(suite.T(), err, "should count computers by metadata")
+	assert.Equal(suite.T(), []Breakdown{{Value: "B0", Label: "B0", Count: 2}, {Value: "B1", Label: "B1", Count: 2}}, items)
+
+	items, err = suite.model.CountComputersBy(dashboards.DimensionWarranty, f, suite.commonInfo)
+	assert.NoError(suite.T(), err, "should count computers by warranty")
+	assert.Equal(suite.T(), []Breakdown{{Value: "unknown", Label: "unknown", Count: 4}}, items)
+
+	f = filters.AgentFilter{AgentOSVersions: []string{"windows"}, Metadata: map[int]string{suite.metadataID: "B0"}}
+	items, err = suite.model.CountComputersBy(dashboards.DimensionManufacturer, f, suite.commonInfo)
+	assert.NoError(suite.T(), err, "should count filtered computers")
+	assert.Equal(suite.T(), []Breakdown{{Value: "HP", Label: "HP", Count: 1}}, items)
+
+	_, err = suite.model.CountComputersBy("metadata_x", f, suite.commonInfo)
+	assert.ErrorIs(suite.T(), err, dashboards.ErrInvalidDimension)
+}
+
+func TestDashboardsTestSuite(t *testing.T) {
+	suite.Run(t, new(DashboardsTestSuite))
+}
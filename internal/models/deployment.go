@@ -32,6 +32,48 @@ func (m *Model) GetDeploymentsForAgent(agentId string, p partials.PaginationAndS
 		query = m.Client.Deployment.Query().Where(deployment.HasOwnerWith(agent.ID(agentId), agent.HasSiteWith(site.ID(siteID), site.HasTenantWith(tenant.ID(tenantID)))))
 	}
 
+	query = orderDeployments(query, p)
+
+	if p.PageSize != 0 {
+		query = query.Limit(p.PageSize).Offset((p.CurrentPage - 1) * p.PageSize)
+	}
+
+	deployments, err := query.All(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return deployments, nil
+}
+
+// GetDeployments returns the deployments of every agent in the tenant or site with
+// the agent and its site
+func (m *Model) GetDeployments(p partials.PaginationAndSort, c *partials.CommonInfo) ([]*ent.Deployment, error) {
+	siteID, err := strconv.Atoi(c.SiteID)
+	if err != nil {
+		return nil, err
+	}
+	tenantID, err := strconv.Atoi(c.TenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := m.Client.Deployment.Query().WithOwner(func(q *ent.AgentQuery) { q.WithSite() })
+	if siteID == -1 {
+		query = query.Where(deployment.HasOwnerWith(agent.HasSiteWith(site.HasTenantWith(tenant.ID(tenantID)))))
+	} else {
+		query = query.Where(deployment.HasOwnerWith(agent.HasSiteWith(site.ID(siteID), site.HasTenantWith(tenant.ID(tenantID)))))
+	}
+
+	query = orderDeployments(query, p)
+
+	if p.PageSize != 0 {
+		query = query.Limit(p.PageSize).Offset((p.CurrentPage - 1) * p.PageSize)
+	}
+
+	return query.All(context.Background())
+}
+
+func orderDeployments(query *ent.DeploymentQuery, p partials.PaginationAndSort) *ent.DeploymentQuery {
 	switch p.SortBy {
 	case "name":
 		if p.SortOrder == "asc" {
@@ -55,11 +97,7 @@ func (m *Model) GetDeploymentsForAgent(agentId string, p partials.PaginationAndS
 		query = query.Order(ent.Desc(deployment.FieldInstalled))
 	}
 
-	deployments, err := query.Limit(p.PageSize).Offset((p.CurrentPage - 1) * p.PageSize).All(context.Background())
-	if err != nil {
-		return nil, err
-	}
-	return deployments, nil
+	return query
 }
 
 func (m *Model) CountDeploymentsForAgent(agentId string, c *partials.CommonInfo) (int, error) {
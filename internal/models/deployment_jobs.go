@@ -0,0 +1,478 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"strconv"
+	"time"
+
+	entsql "entgo.io/ent/dialect/sql"
+	"github.com/open-uem/ent"
+	"github.com/open-uem/ent/agent"
+	"github.com/open-uem/ent/deployment"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/deployjobs"
+	"github.com/open-uem/openuem-console/internal/maintenance"
+	"github.com/open-uem/openuem-console/internal/views/filters"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+)
+
+var ErrDeploymentJobNotFound = errors.New("the deployment job doesn't exist")
+
+var deploymentJobColumns = []string{"id", "tenant_id", "site_id", "action", "package_id", "package_name", "package_branch", "package_brew_type", "package_verified", "created_by", "created"}
+var deploymentJobTargetColumns = []string{"id", "job_id", "agent_id", "hostname", "status", "error", "maintenance_action_id", "sent", "finished"}
+
+// deploymentJobTargetsBatch is the number of targets inserted with each statement
+const deploymentJobTargetsBatch = 500
+
+// AddDeploymentJob saves the job and its agents, all of them pending, and returns the id of the new job
+func (m *Model) AddDeploymentJob(j consoledb.DeploymentJob, hostnames map[string]string) (int, error) {
+	ctx := context.Background()
+
+	tx, err := m.Driver.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Insert(consoledb.DeploymentJobsTable.Name).
+		Columns("tenant_id", "site_id", "action", "package_id", "package_name", "package_branch", "package_brew_type", "package_verified", "created_by", "created").
+		Values(j.TenantID, j.SiteID, j.Action, j.PackageID, j.PackageName, j.PackageBranch, j.PackageBrewType, j.PackageVerified, j.CreatedBy, time.Now()).
+		Returning("id").
+		Query()
+
+	var id int
+	if err := tx.QueryRowContext(ctx, query, args...).Scan(&id); err != nil {
+		return 0, err
+	}
+
+	agentIDs := make([]string, 0, len(hostnames))
+	for agentID := range hostnames {
+		agentIDs = append(agentIDs, agentID)
+	}
+	slices.Sort(agentIDs)
+
+	for batch := range slices.Chunk(agentIDs, deploymentJobTargetsBatch) {
+		insert := entsql.Dialect(m.Driver.Dialect()).
+			Insert(consoledb.DeploymentJobTargetsTable.Name).
+			Columns("job_id", "agent_id", "hostname", "status")
+		for _, agentID := range batch {
+			insert.Values(id, agentID, hostnames[agentID], deployjobs.TargetPending)
+		}
+
+		query, args := insert.Query()
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return 0, err
+		}
+	}
+
+	return id, tx.Commit()
+}
+
+func (m *Model) GetDeploymentJob(id int, c *partials.CommonInfo) (consoledb.DeploymentJob, error) {
+	var scopeErr error
+
+	items, err := m.queryDeploymentJobs(func(s *entsql.Selector) {
+		scopeErr = applyDeploymentJobScope(s, c)
+		s.Where(entsql.EQ("id", id))
+	})
+	if scopeErr != nil {
+		return consoledb.DeploymentJob{}, scopeErr
+	}
+	if err != nil {
+		return consoledb.DeploymentJob{}, err
+	}
+
+	if len(items) != 1 {
+		return consoledb.DeploymentJob{}, ErrDeploymentJobNotFound
+	}
+
+	return items[0], nil
+}
+
+func (m *Model) CountDeploymentJobs(c *partials.CommonInfo) (int, error) {
+	var count int
+
+	selector := entsql.Dialect(m.Driver.Dialect()).
+		Select(entsql.Count("*")).
+		From(entsql.Table(consoledb.DeploymentJobsTable.Name))
+	if err := applyDeploymentJobScope(selector, c); err != nil {
+		return 0, err
+	}
+
+	query, args := selector.Query()
+	if err := m.Driver.DB().QueryRowContext(context.Background(), query, args...).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (m *Model) GetDeploymentJobsByPage(p partials.PaginationAndSort, c *partials.CommonInfo) ([]consoledb.DeploymentJob, error) {
+	var scopeErr error
+
+	items, err := m.queryDeploymentJobs(func(s *entsql.Selector) {
+		scopeErr = applyDeploymentJobScope(s, c)
+
+		column := "created"
+		switch p.SortBy {
+		case "package":
+			column = "package_name"
+		case "action":
+			column = "action"
+		}
+
+		if p.SortOrder == "asc" {
+			s.OrderBy(entsql.Asc(column), entsql.Asc("id"))
+		} else {
+			s.OrderBy(entsql.Desc(column), entsql.Desc("id"))
+		}
+
+		s.Limit(p.PageSize).Offset((p.CurrentPage - 1) * p.PageSize)
+	})
+	if scopeErr != nil {
+		return nil, scopeErr
+	}
+
+	return items, err
+}
+
+// GetRunningDeploymentJobs returns the jobs of every tenant with agents that have no result yet
+func (m *Model) GetRunningDeploymentJobs() ([]consoledb.DeploymentJob, error) {
+	running := entsql.Dialect(m.Driver.Dialect()).
+		Select("job_id").
+		From(entsql.Table(consoledb.DeploymentJobTargetsTable.Name)).
+		Where(entsql.In("status", deployjobs.TargetPending, deployjobs.TargetSent))
+
+	return m.queryDeploymentJobs(func(s *entsql.Selector) {
+		s.Where(entsql.In("id", running)).OrderBy(entsql.Asc("id"))
+	})
+}
+
+// GetDeploymentJobsProgress returns how many agents of each job are in each status
+func (m *Model) GetDeploymentJobsProgress(ids []int) (map[int]deployjobs.Progress, error) {
+	progress := map[int]deployjobs.Progress{}
+	if len(ids) == 0 {
+		return progress, nil
+	}
+
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Select("job_id", "status", entsql.Count("*")).
+		From(entsql.Table(consoledb.DeploymentJobTargetsTable.Name)).
+		Where(entsql.In("job_id", toAny(ids)...)).
+		GroupBy("job_id", "status").
+		Query()
+
+	rows, err := m.Driver.DB().QueryContext(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var jobID, count int
+		var status string
+		if err := rows.Scan(&jobID, &status, &count); err != nil {
+			return nil, err
+		}
+
+		p := progress[jobID]
+		p.Add(status, count)
+		progress[jobID] = p
+	}
+
+	return progress, rows.Err()
+}
+
+// GetDeploymentJobTargets returns the agents of the job in any of the given status
+func (m *Model) GetDeploymentJobTargets(jobID int, statuses []string) ([]consoledb.DeploymentJobTarget, error) {
+	return m.queryDeploymentJobTargets(func(s *entsql.Selector) {
+		s.Where(entsql.And(entsql.EQ("job_id", jobID), entsql.In("status", toAny(statuses)...))).OrderBy(entsql.Asc("id"))
+	})
+}
+
+func (m *Model) CountDeploymentJobTargets(jobID int, f filters.DeploymentJobTargetFilter) (int, error) {
+	var count int
+
+	selector := entsql.Dialect(m.Driver.Dialect()).
+		Select(entsql.Count("*")).
+		From(entsql.Table(consoledb.DeploymentJobTargetsTable.Name)).
+		Where(entsql.EQ("job_id", jobID))
+	applyDeploymentJobTargetFilter(selector, f)
+
+	query, args := selector.Query()
+	if err := m.Driver.DB().QueryRowContext(context.Background(), query, args...).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (m *Model) GetDeploymentJobTargetsByPage(p partials.PaginationAndSort, jobID int, f filters.DeploymentJobTargetFilter) ([]consoledb.DeploymentJobTarget, error) {
+	return m.queryDeploymentJobTargets(func(s *entsql.Selector) {
+		s.Where(entsql.EQ("job_id", jobID))
+		applyDeploymentJobTargetFilter(s, f)
+
+		column := "hostname"
+		switch p.SortBy {
+		case "status":
+			column = "status"
+		case "sent":
+			column = "sent"
+		}
+
+		if p.SortOrder == "desc" {
+			s.OrderBy(entsql.Desc(column), entsql.Desc("hostname"))
+		} else {
+			s.OrderBy(entsql.Asc(column), entsql.Asc("hostname"))
+		}
+
+		s.Limit(p.PageSize).Offset((p.CurrentPage - 1) * p.PageSize)
+	})
+}
+
+// QueueDeploymentJobTarget links a pending agent to the action that waits for its maintenance window
+func (m *Model) QueueDeploymentJobTarget(jobID int, agentID string, maintenanceActionID int) error {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Update(consoledb.DeploymentJobTargetsTable.Name).
+		Set("maintenance_action_id", maintenanceActionID).
+		Where(entsql.And(entsql.EQ("job_id", jobID), entsql.EQ("agent_id", agentID), entsql.EQ("status", deployjobs.TargetPending))).
+		Query()
+
+	_, err := m.Driver.DB().ExecContext(context.Background(), query, args...)
+	return err
+}
+
+// SendDeploymentJobTarget marks a pending agent as sent at the given time. It returns false if
+// the agent is no longer pending, e.g. the agent was cancelled
+func (m *Model) SendDeploymentJobTarget(jobID int, agentID string, sent time.Time) (bool, error) {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Update(consoledb.DeploymentJobTargetsTable.Name).
+		Set("status", deployjobs.TargetSent).
+		Set("sent", sent).
+		Where(entsql.And(entsql.EQ("job_id", jobID), entsql.EQ("agent_id", agentID), entsql.EQ("status", deployjobs.TargetPending))).
+		Query()
+
+	return m.execClaim(query, args)
+}
+
+// FinishDeploymentJobTarget records the result of an agent if it's still in one of the given
+// status. It returns false if the agent had already changed, e.g. another console instance
+// recorded its result first
+func (m *Model) FinishDeploymentJobTarget(jobID int, agentID string, from []string, status string, errMessage string) (bool, error) {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Update(consoledb.DeploymentJobTargetsTable.Name).
+		Set("status", status).
+		Set("error", truncate(errMessage, 2048)).
+		Set("finished", time.Now()).
+		Where(entsql.And(entsql.EQ("job_id", jobID), entsql.EQ("agent_id", agentID), entsql.In("status", toAny(from)...))).
+		Query()
+
+	return m.execClaim(query, args)
+}
+
+// RetryDeploymentJobTarget makes an agent that failed or timed out pending again so the
+// request can be sent once more. It returns false if the agent can't be retried
+func (m *Model) RetryDeploymentJobTarget(jobID int, agentID string) (bool, error) {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Update(consoledb.DeploymentJobTargetsTable.Name).
+		Set("status", deployjobs.TargetPending).
+		Set("error", "").
+		Set("maintenance_action_id", 0).
+		SetNull("sent").
+		SetNull("finished").
+		Where(entsql.And(entsql.EQ("job_id", jobID), entsql.EQ("agent_id", agentID), entsql.In("status", deployjobs.TargetFailed, deployjobs.TargetTimedOut))).
+		Query()
+
+	return m.execClaim(query, args)
+}
+
+// SyncDeploymentJobTargets records what happened to the agents of the job that have no result
+// yet. Agents waiting for their maintenance window follow the queued action, and agents the
+// request was sent to follow the deployment the agent reports back for the package
+func (m *Model) SyncDeploymentJobTargets(j consoledb.DeploymentJob, now time.Time) error {
+	targets, err := m.GetDeploymentJobTargets(j.ID, []string{deployjobs.TargetPending, deployjobs.TargetSent})
+	if err != nil || len(targets) == 0 {
+		return err
+	}
+
+	queued := map[int]string{}
+	sent := map[string]time.Time{}
+	for _, t := range targets {
+		switch {
+		case t.Status == deployjobs.TargetPending && t.MaintenanceActionID != 0:
+			queued[t.MaintenanceActionID] = t.AgentID
+		case t.Status == deployjobs.TargetSent:
+			sent[t.AgentID] = t.Sent
+		}
+	}
+
+	if err := m.syncQueuedDeploymentJobTargets(j, queued); err != nil {
+		return err
+	}
+
+	return m.syncSentDeploymentJobTargets(j, sent, now)
+}
+
+func (m *Model) syncQueuedDeploymentJobTargets(j consoledb.DeploymentJob, queued map[int]string) error {
+	ids := []int{}
+	for id := range queued {
+		ids = append(ids, id)
+	}
+
+	actions, err := m.GetMaintenanceActionsByID(ids)
+	if err != nil {
+		return err
+	}
+
+	pending := []string{deployjobs.TargetPending}
+	for _, a := range actions {
+		agentID := queued[a.ID]
+		switch a.Status {
+		case maintenance.StatusSent:
+			_, err = m.SendDeploymentJobTarget(j.ID, agentID, a.Sent)
+		case maintenance.StatusFailed:
+			_, err = m.FinishDeploymentJobTarget(j.ID, agentID, pending, deployjobs.TargetFailed, a.Error)
+		case maintenance.StatusCancelled:
+			_, err = m.FinishDeploymentJobTarget(j.ID, agentID, pending, deployjobs.TargetCancelled, "")
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Model) syncSentDeploymentJobTargets(j consoledb.DeploymentJob, sent map[string]time.Time, now time.Time) error {
+	if len(sent) == 0 {
+		return nil
+	}
+
+	agentIDs := []string{}
+	for agentID := range sent {
+		agentIDs = append(agentIDs, agentID)
+	}
+
+	deployments, err := m.Client.Deployment.Query().
+		WithOwner().
+		Where(deployment.PackageID(j.PackageID), deployment.HasOwnerWith(agent.IDIn(agentIDs...))).
+		All(context.Background())
+	if err != nil {
+		return err
+	}
+
+	reported := map[string]*ent.Deployment{}
+	for _, d := range deployments {
+		if d.Edges.Owner != nil {
+			reported[d.Edges.Owner.ID] = d
+		}
+	}
+
+	for agentID, sentAt := range sent {
+		state := deployjobs.Deployment{}
+		if d, ok := reported[agentID]; ok {
+			state = deployjobs.Deployment{Found: true, Installed: d.Installed, Failed: d.Failed, Info: d.MoreInfo}
+		}
+
+		status, reason := deployjobs.Resolve(j.Action, sentAt, state, now)
+		if status == deployjobs.TargetSent {
+			continue
+		}
+
+		if _, err := m.FinishDeploymentJobTarget(j.ID, agentID, []string{deployjobs.TargetSent}, status, reason); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// applyDeploymentJobScope limits the jobs to those of the tenant, and of the site when a site is selected
+func applyDeploymentJobScope(s *entsql.Selector, c *partials.CommonInfo) error {
+	tenantID, err := strconv.Atoi(c.TenantID)
+	if err != nil {
+		return err
+	}
+	siteID, err := strconv.Atoi(c.SiteID)
+	if err != nil {
+		return err
+	}
+
+	s.Where(entsql.EQ("tenant_id", tenantID))
+	if siteID != -1 {
+		s.Where(entsql.EQ("site_id", siteID))
+	}
+
+	return nil
+}
+
+func applyDeploymentJobTargetFilter(s *entsql.Selector, f filters.DeploymentJobTargetFilter) {
+	if len(f.Hostname) > 0 {
+		s.Where(entsql.ContainsFold("hostname", f.Hostname))
+	}
+
+	if len(f.Statuses) > 0 {
+		s.Where(entsql.In("status", toAny(f.Statuses)...))
+	}
+}
+
+func (m *Model) queryDeploymentJobs(modifier func(s *entsql.Selector)) ([]consoledb.DeploymentJob, error) {
+	selector := entsql.Dialect(m.Driver.Dialect()).
+		Select(deploymentJobColumns...).
+		From(entsql.Table(consoledb.DeploymentJobsTable.Name))
+	modifier(selector)
+
+	query, args := selector.Query()
+	rows, err := m.Driver.DB().QueryContext(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []consoledb.DeploymentJob{}
+	for rows.Next() {
+		var j consoledb.DeploymentJob
+		if err := rows.Scan(&j.ID, &j.TenantID, &j.SiteID, &j.Action, &j.PackageID, &j.PackageName, &j.PackageBranch, &j.PackageBrewType, &j.PackageVerified, &j.CreatedBy, &j.Created); err != nil {
+			return nil, err
+		}
+		items = append(items, j)
+	}
+
+	return items, rows.Err()
+}
+
+func (m *Model) queryDeploymentJobTargets(modifier func(s *entsql.Selector)) ([]consoledb.DeploymentJobTarget, error) {
+	selector := entsql.Dialect(m.Driver.Dialect()).
+		Select(deploymentJobTargetColumns...).
+		From(entsql.Table(consoledb.DeploymentJobTargetsTable.Name))
+	modifier(selector)
+
+	query, args := selector.Query()
+	rows, err := m.Driver.DB().QueryContext(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []consoledb.DeploymentJobTarget{}
+	for rows.Next() {
+		var t consoledb.DeploymentJobTarget
+		var sent, finished sql.NullTime
+		if err := rows.Scan(&t.ID, &t.JobID, &t.AgentID, &t.Hostname, &t.Status, &t.Error, &t.MaintenanceActionID, &sent, &finished); err != nil {
+			return nil, err
+		}
+		if sent.Valid {
+			t.Sent = sent.Time
+		}
+		if finished.Valid {
+			t.Finished = finished.Time
+		}
+		items = append(items, t)
+	}
+
+	return items, rows.Err()
+}
@@ -0,0 +1,217 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/open-uem/ent/agent"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/deployjobs"
+	"github.com/open-uem/openuem-console/internal/maintenance"
+	"github.com/open-uem/openuem-console/internal/views/filters"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type DeploymentJobsTestSuite struct {
+	suite.Suite
+	model      Model
+	tenantID   int
+	siteID     int
+	commonInfo *partials.CommonInfo
+}
+
+func (suite *DeploymentJobsTestSuite) SetupTest() {
+	suite.model = newTestModel(suite.T())
+	client := suite.model.Client
+
+	t, err := suite.model.CreateDefaultTenant()
+	assert.NoError(suite.T(), err, "should create default tenant")
+	suite.tenantID = t.ID
+
+	s, err := suite.model.CreateDefaultSite(t)
+	assert.NoError(suite.T(), err, "should create default site")
+	suite.siteID = s.ID
+
+	suite.commonInfo = &partials.CommonInfo{TenantID: strconv.Itoa(t.ID), SiteID: "-1"}
+
+	for i := 0; i <= 3; i++ {
+		err := client.Agent.Create().
+			SetID(fmt.Sprintf("agent%d", i)).
+			SetHostname(fmt.Sprintf("host%d", i)).
+			SetOs("windows").
+			SetNickname(fmt.Sprintf("agent%d", i)).
+			SetAgentStatus(agent.AgentStatusEnabled).
+			AddSiteIDs(s.ID).
+			Exec(context.Background())
+		assert.NoError(suite.T(), err, "should create agent")
+	}
+}
+
+func (suite *DeploymentJobsTestSuite) addJob(action string) consoledb.DeploymentJob {
+	hostnames := map[string]string{}
+	for i := 0; i <= 3; i++ {
+		hostnames[fmt.Sprintf("agent%d", i)] = fmt.Sprintf("host%d", i)
+	}
+
+	id, err := suite.model.AddDeploymentJob(consoledb.DeploymentJob{
+		TenantID:    suite.tenantID,
+		SiteID:      -1,
+		Action:      action,
+		PackageID:   "Mozilla.Firefox",
+		PackageName: "Firefox",
+		CreatedBy:   "admin",
+	}, hostnames)
+	assert.NoError(suite.T(), err, "should add deployment job")
+
+	j, err := suite.model.GetDeploymentJob(id, suite.commonInfo)
+	assert.NoError(suite.T(), err, "should get deployment job")
+	return j
+}
+
+func (suite *DeploymentJobsTestSuite) TestAddDeploymentJob() {
+	j := suite.addJob(deployjobs.ActionInstall)
+	assert.Equal(suite.T(), "Firefox", j.PackageName)
+
+	count, err := suite.model.CountDeploymentJobs(suite.commonInfo)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, count)
+
+	progress, err := suite.model.GetDeploymentJobsProgress([]int{j.ID})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), deployjobs.Progress{Pending: 4}, progress[j.ID])
+
+	running, err := suite.model.GetRunningDeploymentJobs()
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, len(running))
+
+	_, err = suite.model.GetDeploymentJob(j.ID, &partials.CommonInfo{TenantID: strconv.Itoa(suite.tenantID + 1), SiteID: "-1"})
+	assert.ErrorIs(suite.T(), err, ErrDeploymentJobNotFound, "jobs of other tenants can't be read")
+}
+
+func (suite *DeploymentJobsTestSuite) TestSyncDeploymentJobTargets() {
+	j := suite.addJob(deployjobs.ActionInstall)
+	now := time.Now()
+
+	for _, id := range []string{"agent0", "agent1", "agent2"} {
+		sent, err := suite.model.SendDeploymentJobTarget(j.ID, id, now.Add(-time.Minute))
+		assert.NoError(suite.T(), err)
+		assert.True(suite.T(), sent)
+	}
+	sent, err := suite.model.SendDeploymentJobTarget(j.ID, "agent2", now)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), sent, "an agent is only sent the request once")
+
+	client := suite.model.Client
+	err = client.Deployment.Create().SetName("Firefox").SetOwnerID("agent0").SetPackageID("Mozilla.Firefox").SetInstalled(now).SetUpdated(now).Exec(context.Background())
+	assert.NoError(suite.T(), err)
+	err = client.Deployment.Create().SetName("Firefox").SetOwnerID("agent1").SetPackageID("Mozilla.Firefox").SetFailed(true).SetMoreInfo("exit code 1").Exec(context.Background())
+	assert.NoError(suite.T(), err)
+
+	err = suite.model.SyncDeploymentJobTargets(j, now)
+	assert.NoError(suite.T(), err, "should sync deployment job targets")
+
+	err = suite.model.SyncDeploymentJobTargets(j, now.Add(deployjobs.Timeout+time.Minute))
+	assert.NoError(suite.T(), err, "should sync deployment job targets")
+
+	progress, err := suite.model.GetDeploymentJobsProgress([]int{j.ID})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), deployjobs.Progress{Pending: 1, Succeeded: 1, Failed: 1, TimedOut: 1}, progress[j.ID])
+
+	p := partials.PaginationAndSort{CurrentPage: 1, PageSize: 5}
+	f := filters.DeploymentJobTargetFilter{Statuses: []string{deployjobs.TargetFailed}}
+	count, err := suite.model.CountDeploymentJobTargets(j.ID, f)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, count)
+
+	failed, err := suite.model.GetDeploymentJobTargetsByPage(p, j.ID, f)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "host1", failed[0].Hostname)
+	assert.Equal(suite.T(), "exit code 1", failed[0].Error)
+}
+
+func (suite *DeploymentJobsTestSuite) TestSyncQueuedDeploymentJobTargets() {
+	j := suite.addJob(deployjobs.ActionUninstall)
+	now := time.Now()
+
+	queued := []int{}
+	for _, id := range []string{"agent0", "agent1"} {
+		actionID, err := suite.model.QueueMaintenanceAction(consoledb.MaintenanceAction{
+			TenantID:  suite.tenantID,
+			SiteID:    suite.siteID,
+			AgentID:   id,
+			Hostname:  "host" + id[len(id)-1:],
+			Action:    maintenance.ActionUninstall,
+			Payload:   "{}",
+			NotBefore: now,
+			Language:  "en",
+			CreatedBy: "admin",
+		})
+		assert.NoError(suite.T(), err, "should queue action")
+		queued = append(queued, actionID)
+
+		err = suite.model.QueueDeploymentJobTarget(j.ID, id, actionID)
+		assert.NoError(suite.T(), err)
+	}
+
+	claimed, err := suite.model.ClaimMaintenanceAction(queued[0])
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), claimed)
+
+	err = suite.model.CancelMaintenanceAction(queued[1], suite.commonInfo)
+	assert.NoError(suite.T(), err)
+
+	err = suite.model.SyncDeploymentJobTargets(j, now)
+	assert.NoError(suite.T(), err, "should sync deployment job targets")
+
+	progress, err := suite.model.GetDeploymentJobsProgress([]int{j.ID})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), deployjobs.Progress{Pending: 2, Sent: 1, Cancelled: 1}, progress[j.ID])
+
+	// the agent has no deployment for the package once it's uninstalled
+	err = suite.model.SyncDeploymentJobTargets(j, now)
+	assert.NoError(suite.T(), err, "should sync deployment job targets")
+
+	progress, err = suite.model.GetDeploymentJobsProgress([]int{j.ID})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), deployjobs.Progress{Pending: 2, Succeeded: 1, Cancelled: 1}, progress[j.ID])
+}
+
+func (suite *DeploymentJobsTestSuite) TestRetryAndCancelDeploymentJobTargets() {
+	j := suite.addJob(deployjobs.ActionInstall)
+
+	finished, err := suite.model.FinishDeploymentJobTarget(j.ID, "agent0", []string{deployjobs.TargetPending}, deployjobs.TargetFailed, "nats not connected")
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), finished)
+
+	retried, err := suite.model.RetryDeploymentJobTarget(j.ID, "agent1")
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), retried, "a pending agent can't be retried")
+
+	retried, err = suite.model.RetryDeploymentJobTarget(j.ID, "agent0")
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), retried)
+
+	for _, id := range []string{"agent0", "agent1", "agent2", "agent3"} {
+		cancelled, err := suite.model.FinishDeploymentJobTarget(j.ID, id, []string{deployjobs.TargetPending}, deployjobs.TargetCancelled, "")
+		assert.NoError(suite.T(), err)
+		assert.True(suite.T(), cancelled)
+	}
+
+	targets, err := suite.model.GetDeploymentJobTargets(j.ID, []string{deployjobs.TargetCancelled})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 4, len(targets))
+	assert.Equal(suite.T(), "", targets[0].Error)
+
+	running, err := suite.model.GetRunningDeploymentJobs()
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 0, len(running))
+}
+
+func TestDeploymentJobsTestSuite(t *testing.T) {
+	suite.Run(t, new(DeploymentJobsTestSuite))
+}
@@ -111,6 +111,21 @@ func (suite *DeploymentTestSuite) TestGetDeploymentsForAgent() {
 	}
 }
 
+func (suite *DeploymentTestSuite) TestGetDeployments() {
+	suite.p.SortBy = "name"
+	suite.p.SortOrder = "asc"
+	items, err := suite.model.GetDeployments(suite.p, suite.commonInfo)
+	assert.NoError(suite.T(), err, "should get deployments")
+	assert.Equal(suite.T(), 5, len(items), "should get a page of deployments")
+	assert.Equal(suite.T(), "package0", items[0].PackageID)
+	assert.Equal(suite.T(), "agent1", items[0].Edges.Owner.ID, "should get the agent")
+	assert.Equal(suite.T(), 1, len(items[0].Edges.Owner.Edges.Site), "should get the site of the agent")
+
+	items, err = suite.model.GetDeployments(partials.PaginationAndSort{}, &partials.CommonInfo{TenantID: suite.commonInfo.TenantID, SiteID: "-1"})
+	assert.NoError(suite.T(), err, "should get deployments")
+	assert.Equal(suite.T(), 7, len(items), "should get every deployment of the tenant if the page size is zero")
+}
+
 func (suite *DeploymentTestSuite) TestDeploymentAlreadyInstalled() {
 	installed, err := suite.model.DeploymentAlreadyInstalled("agent1", "package6", suite.commonInfo)
 	assert.NoError(suite.T(), err, "should check if deployment already installed")
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	entsql "entgo.io/ent/dialect/sql"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/metrics"
+	"github.com/open-uem/openuem-console/internal/views/filters"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+)
+
+var fleetMetricsColumns = []string{"id", "tenant_id", "site_id", "day", "agents", "reported_24h", "pending_updates", "disabled_antivirus", "outdated_agents"}
+
+// TakeFleetMetrics counts the agents of the tenant, or of the site if one is selected, like
+// the counters of the dashboard do right now
+func (m *Model) TakeFleetMetrics(c *partials.CommonInfo) (consoledb.FleetMetrics, error) {
+	var err error
+	snapshot := consoledb.FleetMetrics{Day: metrics.Day(time.Now())}
+
+	if snapshot.Agents, err = m.CountAllAgents(filters.AgentFilter{}, true, c); err != nil {
+		return snapshot, err
+	}
+	if snapshot.Reported24h, err = m.CountAgentsReportedLast24h(c); err != nil {
+		return snapshot, err
+	}
+	if snapshot.PendingUpdates, err = m.CountPendingUpdateAgents(c); err != nil {
+		return snapshot, err
+	}
+	if snapshot.DisabledAntivirus, err = m.CountDisabledAntivirusAgents(c); err != nil {
+		return snapshot, err
+	}
+	if snapshot.OutdatedAgents, err = m.CountOutdatedAgentsInScope(c); err != nil {
+		return snapshot, err
+	}
+
+	return snapshot, nil
+}
+
+// SaveFleetMetrics saves the snapshot of a day, replacing the one taken earlier that day
+func (m *Model) SaveFleetMetrics(snapshot consoledb.FleetMetrics) error {
+	ctx := context.Background()
+	day := metrics.Day(snapshot.Day)
+
+	tx, err := m.Driver.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Delete(consoledb.FleetMetricsTable.Name).
+		Where(entsql.And(entsql.EQ("tenant_id", snapshot.TenantID), entsql.EQ("site_id", snapshot.SiteID), entsql.EQ("day", day))).
+		Query()
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return err
+	}
+
+	query, args = entsql.Dialect(m.Driver.Dialect()).
+		Insert(consoledb.FleetMetricsTable.Name).
+		Columns(fleetMetricsColumns[1:]...).
+		Values(snapshot.TenantID, snapshot.SiteID, day, snapshot.Agents, snapshot.Reported24h, snapshot.PendingUpdates, snapshot.DisabledAntivirus, snapshot.OutdatedAgents).
+		Query()
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetFleetMetrics returns the snapshots of the tenant, or of one of its sites, taken since
+// the given day sorted from the oldest. A siteID of -1 returns the ones of the whole tenant
+func (m *Model) GetFleetMetrics(tenantID int, siteID int, since time.Time) ([]consoledb.FleetMetrics, error) {
+	selector := entsql.Dialect(m.Driver.Dialect()).
+		Select(fleetMetricsColumns...).
+		From(entsql.Table(consoledb.FleetMetricsTable.Name)).
+		Where(entsql.And(entsql.EQ("tenant_id", tenantID), entsql.EQ("site_id", siteID), entsql.GTE("day", metrics.Day(since)))).
+		OrderBy(entsql.Asc("day"))
+
+	query, args := selector.Query()
+	rows, err := m.Driver.DB().QueryContext(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []consoledb.FleetMetrics{}
+	for rows.Next() {
+		var s consoledb.FleetMetrics
+		if err := rows.Scan(&s.ID, &s.TenantID, &s.SiteID, &s.Day, &s.Agents, &s.Reported24h, &s.PendingUpdates, &s.DisabledAntivirus, &s.OutdatedAgents); err != nil {
+			return nil, err
+		}
+		items = append(items, s)
+	}
+
+	return items, rows.Err()
+}
+
+// DeleteOldFleetMetrics removes the snapshots of the days before the given time
+func (m *Model) DeleteOldFleetMetrics(before time.Time) error {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Delete(consoledb.FleetMetricsTable.Name).
+		Where(entsql.LT("day", metrics.Day(before))).
+		Query()
+
+	_, err := m.Driver.DB().ExecContext(context.Background(), query, args...)
+	return err
+}
@@ -0,0 +1,143 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/open-uem/ent/agent"
+	"github.com/open-uem/ent/release"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/metrics"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type FleetMetricsTestSuite struct {
+	suite.Suite
+	model    Model
+	tenantID int
+	siteID   int
+	otherID  int
+}
+
+func (suite *FleetMetricsTestSuite) SetupTest() {
+	suite.model = newTestModel(suite.T())
+	client := suite.model.Client
+
+	t, err := suite.model.CreateDefaultTenant()
+	assert.NoError(suite.T(), err, "should create default tenant")
+	suite.tenantID = t.ID
+
+	s, err := suite.model.CreateDefaultSite(t)
+	assert.NoError(suite.T(), err, "should create default site")
+	suite.siteID = s.ID
+
+	other, err := client.Site.Create().SetDescription("Other").SetTenantID(t.ID).Save(context.Background())
+	assert.NoError(suite.T(), err, "should create site")
+	suite.otherID = other.ID
+
+	old, err := client.Release.Create().SetReleaseType(release.ReleaseTypeAgent).SetArch("amd64").SetChannel("stable").SetOs("windows").SetVersion("0.1.0").Save(context.Background())
+	assert.NoError(suite.T(), err, "should create release")
+	current, err := client.Release.Create().SetReleaseType(release.ReleaseTypeAgent).SetArch("amd64").SetChannel("stable").SetOs("windows").SetVersion("0.2.0").Save(context.Background())
+	assert.NoError(suite.T(), err, "should create release")
+
+	for i := 0; i <= 4; i++ {
+		id := fmt.Sprintf("agent%d", i)
+		status := agent.AgentStatusEnabled
+		if i == 4 {
+			status = agent.AgentStatusWaitingForAdmission
+		}
+		query := client.Agent.Create().
+			SetID(id).
+			SetHostname(id).
+			SetOs("windows").
+			SetNickname(id).
+			SetAgentStatus(status).
+			SetLastContact(time.Now().AddDate(0, 0, -i))
+		if i == 3 {
+			query.AddSiteIDs(other.ID)
+		} else {
+			query.AddSiteIDs(s.ID)
+		}
+		if i%2 == 0 {
+			query.SetReleaseID(old.ID)
+		} else {
+			query.SetReleaseID(current.ID)
+		}
+		err := query.Exec(context.Background())
+		assert.NoError(suite.T(), err, "should create agent")
+
+		err = client.Antivirus.Create().SetName("Defender").SetIsActive(i != 1).SetIsUpdated(true).SetOwnerID(id).Exec(context.Background())
+		assert.NoError(suite.T(), err, "should create antivirus")
+
+		err = client.SystemUpdate.Create().SetSystemUpdateStatus("").SetPendingUpdates(i < 2).SetLastInstall(time.Now()).SetLastSearch(time.Now()).SetOwnerID(id).Exec(context.Background())
+		assert.NoError(suite.T(), err, "should create system update")
+	}
+}
+
+func (suite *FleetMetricsTestSuite) TestTakeFleetMetrics() {
+	snapshot, err := suite.model.TakeFleetMetrics(&partials.CommonInfo{TenantID: fmt.Sprintf("%d", suite.tenantID), SiteID: "-1"})
+	assert.NoError(suite.T(), err, "should take fleet metrics")
+	assert.Equal(suite.T(), metrics.Day(time.Now()), snapshot.Day)
+	assert.Equal(suite.T(), 4, snapshot.Agents, "agents waiting for admission are not counted")
+	assert.Equal(suite.T(), 1, snapshot.Reported24h)
+	assert.Equal(suite.T(), 2, snapshot.PendingUpdates)
+	assert.Equal(suite.T(), 1, snapshot.DisabledAntivirus)
+	assert.Equal(suite.T(), 2, snapshot.OutdatedAgents)
+
+	snapshot, err = suite.model.TakeFleetMetrics(&partials.CommonInfo{TenantID: fmt.Sprintf("%d", suite.tenantID), SiteID: fmt.Sprintf("%d", suite.otherID)})
+	assert.NoError(suite.T(), err, "should take fleet metrics of a site")
+	assert.Equal(suite.T(), 1, snapshot.Agents)
+	assert.Equal(suite.T(), 0, snapshot.OutdatedAgents)
+}
+
+func (suite *FleetMetricsTestSuite) TestSaveAndGetFleetMetrics() {
+	now := time.Now()
+
+	for days, agents := range []int{10, 9, 8} {
+		err := suite.model.SaveFleetMetrics(consoledb.FleetMetrics{TenantID: suite.tenantID, SiteID: -1, Day: now.AddDate(0, 0, -days*40), Agents: agents})
+		assert.NoError(suite.T(), err, "should save fleet metrics")
+	}
+	err := suite.model.SaveFleetMetrics(consoledb.FleetMetrics{TenantID: suite.tenantID, SiteID: suite.siteID, Day: now, Agents: 3})
+	assert.NoError(suite.T(), err, "should save fleet metrics of a site")
+
+	// a later snapshot of the same day replaces the previous one
+	err = suite.model.SaveFleetMetrics(consoledb.FleetMetrics{TenantID: suite.tenantID, SiteID: -1, Day: now, Agents: 11, PendingUpdates: 2})
+	assert.NoError(suite.T(), err, "should replace fleet metrics")
+
+	items, err := suite.model.GetFleetMetrics(suite.tenantID, -1, metrics.Since(30, now))
+	assert.NoError(suite.T(), err, "should get fleet metrics")
+	assert.Equal(suite.T(), 1, len(items))
+	assert.Equal(suite.T(), 11, items[0].Agents)
+	assert.Equal(suite.T(), 2, items[0].PendingUpdates)
+
+	items, err = suite.model.GetFleetMetrics(suite.tenantID, -1, metrics.Since(90, now))
+	assert.NoError(suite.T(), err, "should get fleet metrics")
+	assert.Equal(suite.T(), []int{8, 9, 11}, fleetAgents(items), "snapshots are sorted from the oldest")
+
+	items, err = suite.model.GetFleetMetrics(suite.tenantID, suite.siteID, metrics.Since(365, now))
+	assert.NoError(suite.T(), err, "should get fleet metrics of a site")
+	assert.Equal(suite.T(), []int{3}, fleetAgents(items))
+
+	err = suite.model.DeleteOldFleetMetrics(now.AddDate(0, 0, -50))
+	assert.NoError(suite.T(), err, "should delete old fleet metrics")
+
+	items, err = suite.model.GetFleetMetrics(suite.tenantID, -1, metrics.Since(365, now))
+	assert.NoError(suite.T(), err, "should get fleet metrics")
+	assert.Equal(suite.T(), []int{9, 11}, fleetAgents(items))
+}
+
+func fleetAgents(items []consoledb.FleetMetrics) []int {
+	agents := []int{}
+	for _, item := range items {
+		agents = append(agents, item.Agents)
+	}
+	return agents
+}
+
+func TestFleetMetricsTestSuite(t *testing.T) {
+	suite.Run(t, new(FleetMetricsTestSuite))
+}
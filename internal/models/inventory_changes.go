@@ -0,0 +1,359 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	entsql "entgo.io/ent/dialect/sql"
+	"github.com/open-uem/ent"
+	"github.com/open-uem/ent/agent"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/inventory"
+	"github.com/open-uem/openuem-console/internal/views/filters"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+)
+
+var inventoryChangeColumns = []string{"id", "agent_id", "tenant_id", "site_id", "hostname", "type", "action", "item", "before_value", "after_value", "created"}
+
+// GetInventoryAgents returns a batch of admitted agents with the inventory compared between reports
+func (m *Model) GetInventoryAgents(offset, limit int) ([]*ent.Agent, error) {
+	return m.Client.Agent.Query().
+		Where(agent.AgentStatusNEQ(agent.AgentStatusWaitingForAdmission)).
+		WithSite(func(q *ent.SiteQuery) { q.WithTenant() }).
+		WithComputer().
+		WithOperatingsystem().
+		WithMemoryslots().
+		WithPhysicaldisks().
+		WithLogicaldisks().
+		WithMonitors().
+		WithPrinters().
+		WithApps().
+		Order(agent.ByID()).
+		Offset(offset).
+		Limit(limit).
+		All(context.Background())
+}
+
+// AgentInventorySnapshot returns the inventory items of an agent loaded with GetInventoryAgents
+func AgentInventorySnapshot(a *ent.Agent) inventory.Snapshot {
+	s := inventory.Snapshot{}
+
+	if c := a.Edges.Computer; c != nil {
+		s.Add(inventory.TypeHardware, "Manufacturer", c.Manufacturer)
+		s.Add(inventory.TypeHardware, "Model", c.Model)
+		s.Add(inventory.TypeHardware, "Serial number", c.Serial)
+		s.Add(inventory.TypeHardware, "Processor", joinNonEmpty(c.Processor, fmt.Sprintf("%d cores", c.ProcessorCores), c.ProcessorArch))
+		s.Add(inventory.TypeHardware, "Memory", fmt.Sprintf("%d MB", c.Memory))
+	}
+
+	for _, slot := range a.Edges.Memoryslots {
+		// empty slots are reported without size
+		if slot.Size == "" {
+			continue
+		}
+		s.Add(inventory.TypeHardware, "Memory slot "+slot.Slot, joinNonEmpty(slot.Size, slot.Type, slot.Speed, slot.Manufacturer, slot.PartNumber, slot.SerialNumber))
+	}
+
+	if os := a.Edges.Operatingsystem; os != nil {
+		s.Add(inventory.TypeOS, "Operating system", os.Description)
+		s.Add(inventory.TypeOS, "Version", os.Version)
+		s.Add(inventory.TypeOS, "Edition", os.Edition)
+		s.Add(inventory.TypeOS, "Architecture", os.Arch)
+		s.Add(inventory.TypeOS, "Domain", os.Domain)
+	}
+
+	for _, d := range a.Edges.Physicaldisks {
+		s.Add(inventory.TypeDisk, "Physical disk "+d.DeviceID, joinNonEmpty(d.Model, d.SerialNumber, d.SizeInUnits))
+	}
+
+	for _, d := range a.Edges.Logicaldisks {
+		s.Add(inventory.TypeDisk, "Volume "+d.Label, joinNonEmpty(d.VolumeName, d.Filesystem, d.SizeInUnits, d.BitlockerStatus))
+	}
+
+	for _, monitor := range a.Edges.Monitors {
+		item := joinNonEmpty(monitor.Manufacturer, monitor.Model)
+		if monitor.Serial != "" {
+			item += " (" + monitor.Serial + ")"
+		}
+		s.Add(inventory.TypeMonitor, item, "")
+	}
+
+	for _, p := range a.Edges.Printers {
+		s.Add(inventory.TypePrinter, p.Name, p.Port)
+	}
+
+	for _, app := range a.Edges.Apps {
+		s.Add(inventory.TypeApp, app.Name, app.Version)
+	}
+
+	return s
+}
+
+// DetectInventoryChanges compares the inventory of the agent with the one saved the previous time
+// and records the differences. The first inventory of an agent is saved without recording changes.
+// If several console instances compare the same inventory only the one that replaces the saved
+// snapshot records the changes
+func (m *Model) DetectInventoryChanges(a *ent.Agent) ([]inventory.Change, error) {
+	current := AgentInventorySnapshot(a)
+	hash := current.Hash()
+
+	data, err := json.Marshal(current)
+	if err != nil {
+		return nil, err
+	}
+
+	previousHash, previousData, err := m.getInventorySnapshot(a.ID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			return nil, err
+		}
+
+		query, args := entsql.Dialect(m.Driver.Dialect()).
+			Insert(consoledb.InventorySnapshotsTable.Name).
+			Columns("agent_id", "hash", "data", "updated").
+			Values(a.ID, hash, string(data), time.Now()).
+			Query()
+
+		// the unique index makes the insert fail if another instance saved it first
+		_, _ = m.Driver.DB().ExecContext(context.Background(), query, args...)
+		return nil, nil
+	}
+
+	if previousHash == hash {
+		return nil, nil
+	}
+
+	previous := inventory.Snapshot{}
+	if err := json.Unmarshal([]byte(previousData), &previous); err != nil {
+		return nil, err
+	}
+
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Update(consoledb.InventorySnapshotsTable.Name).
+		Set("hash", hash).
+		Set("data", string(data)).
+		Set("updated", time.Now()).
+		Where(entsql.And(entsql.EQ("agent_id", a.ID), entsql.EQ("hash", previousHash))).
+		Query()
+
+	result, err := m.Driver.DB().ExecContext(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return nil, err
+	}
+
+	changes := inventory.Diff(previous, current)
+	if err := m.addInventoryChanges(a, changes); err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+func (m *Model) getInventorySnapshot(agentID string) (string, string, error) {
+	var hash, data string
+
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Select("hash", "data").
+		From(entsql.Table(consoledb.InventorySnapshotsTable.Name)).
+		Where(entsql.EQ("agent_id", agentID)).
+		Query()
+
+	if err := m.Driver.DB().QueryRowContext(context.Background(), query, args...).Scan(&hash, &data); err != nil {
+		return "", "", err
+	}
+
+	return hash, data, nil
+}
+
+func (m *Model) addInventoryChanges(a *ent.Agent, changes []inventory.Change) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	tenantID, siteID := -1, -1
+	if len(a.Edges.Site) == 1 {
+		siteID = a.Edges.Site[0].ID
+		if a.Edges.Site[0].Edges.Tenant != nil {
+			tenantID = a.Edges.Site[0].Edges.Tenant.ID
+		}
+	}
+
+	insert := entsql.Dialect(m.Driver.Dialect()).
+		Insert(consoledb.InventoryChangesTable.Name).
+		Columns(inventoryChangeColumns[1:]...)
+
+	now := time.Now()
+	for _, c := range changes {
+		insert.Values(a.ID, tenantID, siteID, a.Hostname, c.Type, c.Action, truncate(c.Item, 1000), truncate(c.Before, 2000), truncate(c.After, 2000), now)
+	}
+
+	query, args := insert.Query()
+	_, err := m.Driver.DB().ExecContext(context.Background(), query, args...)
+	return err
+}
+
+// GetAgentInventoryChanges returns the latest changes of an agent, newest first
+func (m *Model) GetAgentInventoryChanges(agentID string, limit int) ([]consoledb.InventoryChange, error) {
+	return m.queryInventoryChanges(func(s *entsql.Selector) {
+		s.Where(entsql.EQ("agent_id", agentID)).
+			OrderBy(entsql.Desc("created"), entsql.Asc("id")).
+			Limit(limit)
+	})
+}
+
+func (m *Model) CountInventoryChanges(f filters.InventoryChangeFilter, c *partials.CommonInfo) (int, error) {
+	var count int
+
+	selector := entsql.Dialect(m.Driver.Dialect()).
+		Select(entsql.Count("*")).
+		From(entsql.Table(consoledb.InventoryChangesTable.Name))
+	if err := applyInventoryChangeScope(selector, c); err != nil {
+		return 0, err
+	}
+	applyInventoryChangeFilter(selector, f)
+
+	query, args := selector.Query()
+	if err := m.Driver.DB().QueryRowContext(context.Background(), query, args...).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (m *Model) GetInventoryChangesByPage(p partials.PaginationAndSort, f filters.InventoryChangeFilter, c *partials.CommonInfo) ([]consoledb.InventoryChange, error) {
+	var scopeErr error
+
+	changes, err := m.queryInventoryChanges(func(s *entsql.Selector) {
+		scopeErr = applyInventoryChangeScope(s, c)
+		applyInventoryChangeFilter(s, f)
+
+		column := "created"
+		switch p.SortBy {
+		case "hostname":
+			column = "hostname"
+		case "type":
+			column = "type"
+		case "action":
+			column = "action"
+		case "item":
+			column = "item"
+		}
+
+		if p.SortOrder == "asc" {
+			s.OrderBy(entsql.Asc(column), entsql.Asc("id"))
+		} else {
+			s.OrderBy(entsql.Desc(column), entsql.Desc("id"))
+		}
+
+		if p.PageSize != 0 {
+			s.Limit(p.PageSize).Offset((p.CurrentPage - 1) * p.PageSize)
+		}
+	})
+	if scopeErr != nil {
+		return nil, scopeErr
+	}
+
+	return changes, err
+}
+
+// applyInventoryChangeScope keeps the changes recorded while the agent belonged to the tenant and site
+func applyInventoryChangeScope(s *entsql.Selector, c *partials.CommonInfo) error {
+	tenantID, err := strconv.Atoi(c.TenantID)
+	if err != nil {
+		return err
+	}
+	siteID, err := strconv.Atoi(c.SiteID)
+	if err != nil {
+		return err
+	}
+
+	s.Where(entsql.EQ("tenant_id", tenantID))
+	if siteID != -1 {
+		s.Where(entsql.EQ("site_id", siteID))
+	}
+	return nil
+}
+
+func applyInventoryChangeFilter(s *entsql.Selector, f filters.InventoryChangeFilter) {
+	if len(f.Hostname) > 0 {
+		s.Where(entsql.ContainsFold("hostname", f.Hostname))
+	}
+
+	if len(f.Item) > 0 {
+		s.Where(entsql.ContainsFold("item", f.Item))
+	}
+
+	if len(f.Types) > 0 {
+		s.Where(entsql.In("type", toAny(f.Types)...))
+	}
+
+	if len(f.Actions) > 0 {
+		s.Where(entsql.In("action", toAny(f.Actions)...))
+	}
+
+	if len(f.CreatedFrom) > 0 {
+		dateFrom, err := time.Parse("2006-01-02", f.CreatedFrom)
+		if err == nil {
+			s.Where(entsql.GTE("created", dateFrom))
+		}
+	}
+
+	if len(f.CreatedTo) > 0 {
+		dateTo, err := time.Parse("2006-01-02", f.CreatedTo)
+		if err == nil {
+			// include the whole day
+			s.Where(entsql.LT("created", dateTo.AddDate(0, 0, 1)))
+		}
+	}
+}
+
+func (m *Model) queryInventoryChanges(modifier func(s *entsql.Selector)) ([]consoledb.InventoryChange, error) {
+	selector := entsql.Dialect(m.Driver.Dialect()).
+		Select(inventoryChangeColumns...).
+		From(entsql.Table(consoledb.InventoryChangesTable.Name))
+	modifier(selector)
+
+	query, args := selector.Query()
+	rows, err := m.Driver.DB().QueryContext(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := []consoledb.InventoryChange{}
+	for rows.Next() {
+		var c consoledb.InventoryChange
+		if err := rows.Scan(&c.ID, &c.AgentID, &c.TenantID, &c.SiteID, &c.Hostname, &c.Type, &c.Action, &c.Item, &c.Before, &c.After, &c.Created); err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+
+	return changes, rows.Err()
+}
+
+func joinNonEmpty(values ...string) string {
+	nonEmpty := []string{}
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			nonEmpty = append(nonEmpty, v)
+		}
+	}
+	return strings.Join(nonEmpty, ", ")
+}
+
+func toAny[T any](values []T) []any {
+	items := make([]any, len(values))
+	for i, v := range values {
+		items[i] = v
+	}
+	return items
+}
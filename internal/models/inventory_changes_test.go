@@ -0,0 +1,178 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/open-uem/ent/agent"
+	"github.com/open-uem/ent/app"
+	"github.com/open-uem/openuem-console/internal/inventory"
+	"github.com/open-uem/openuem-console/internal/views/filters"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type InventoryChangesTestSuite struct {
+	suite.Suite
+	model      Model
+	p          partials.PaginationAndSort
+	commonInfo *partials.CommonInfo
+	tenantID   int
+	siteID     int
+}
+
+func (suite *InventoryChangesTestSuite) SetupTest() {
+	suite.model = newTestModel(suite.T())
+	suite.p = partials.PaginationAndSort{CurrentPage: 1, PageSize: 5}
+	client := suite.model.Client
+
+	t, err := suite.model.CreateDefaultTenant()
+	assert.NoError(suite.T(), err, "should create default tenant")
+	suite.tenantID = t.ID
+
+	s, err := suite.model.CreateDefaultSite(t)
+	assert.NoError(suite.T(), err, "should create default site")
+	suite.siteID = s.ID
+	suite.commonInfo = &partials.CommonInfo{TenantID: strconv.Itoa(t.ID), SiteID: "-1"}
+
+	for i := 0; i <= 2; i++ {
+		id := fmt.Sprintf("agent%d", i)
+		err := client.Agent.Create().
+			SetID(id).
+			SetHostname(id).
+			SetOs("windows").
+			SetNickname(id).
+			SetAgentStatus(agent.AgentStatusEnabled).
+			AddSiteIDs(s.ID).
+			Exec(context.Background())
+		assert.NoError(suite.T(), err, "should create agent")
+
+		err = client.Computer.Create().SetManufacturer("Dell").SetModel("Latitude").SetSerial(id).SetMemory(16384).SetOwnerID(id).Exec(context.Background())
+		assert.NoError(suite.T(), err, "should create computer")
+
+		err = client.App.Create().SetName("Firefox").SetVersion("127.0").SetOwnerID(id).Exec(context.Background())
+		assert.NoError(suite.T(), err, "should create app")
+	}
+
+	err = client.Agent.Create().
+		SetID("waiting").
+		SetHostname("waiting").
+		SetOs("windows").
+		SetNickname("waiting").
+		SetAgentStatus(agent.AgentStatusWaitingForAdmission).
+		AddSiteIDs(s.ID).
+		Exec(context.Background())
+	assert.NoError(suite.T(), err, "should create agent")
+}
+
+func (suite *InventoryChangesTestSuite) detect() {
+	agents, err := suite.model.GetInventoryAgents(0, 10)
+	assert.NoError(suite.T(), err, "should get inventory agents")
+	for _, a := range agents {
+		_, err := suite.model.DetectInventoryChanges(a)
+		assert.NoError(suite.T(), err, "should detect inventory changes")
+	}
+}
+
+func (suite *InventoryChangesTestSuite) TestGetInventoryAgents() {
+	agents, err := suite.model.GetInventoryAgents(0, 2)
+	assert.NoError(suite.T(), err, "should get inventory agents")
+	assert.Equal(suite.T(), 2, len(agents))
+	assert.Equal(suite.T(), "agent0", agents[0].ID)
+	assert.Equal(suite.T(), suite.tenantID, agents[0].Edges.Site[0].Edges.Tenant.ID)
+
+	agents, err = suite.model.GetInventoryAgents(2, 2)
+	assert.NoError(suite.T(), err, "should get inventory agents")
+	assert.Equal(suite.T(), 1, len(agents), "agents waiting for admission are not compared")
+
+	s := AgentInventorySnapshot(agents[0])
+	assert.Equal(suite.T(), "16384 MB", s[inventory.TypeHardware]["Memory"])
+	assert.Equal(suite.T(), "127.0", s[inventory.TypeApp]["Firefox"])
+}
+
+func (suite *InventoryChangesTestSuite) TestDetectInventoryChanges() {
+	suite.detect()
+
+	count, err := suite.model.CountInventoryChanges(filters.InventoryChangeFilter{}, suite.commonInfo)
+	assert.NoError(suite.T(), err, "should count inventory changes")
+	assert.Equal(suite.T(), 0, count, "the first inventory is saved without changes")
+
+	client := suite.model.Client
+	_, err = client.App.Update().Where(app.HasOwnerWith(agent.ID("agent1"))).SetVersion("128.0").Save(context.Background())
+	assert.NoError(suite.T(), err, "should update app")
+	err = client.App.Create().SetName("VLC").SetVersion("3.0.21").SetOwnerID("agent1").Exec(context.Background())
+	assert.NoError(suite.T(), err, "should create app")
+	_, err = client.Computer.Update().SetMemory(8192).Save(context.Background())
+	assert.NoError(suite.T(), err, "should update computers")
+
+	agents, err := suite.model.GetInventoryAgents(1, 1)
+	assert.NoError(suite.T(), err, "should get inventory agents")
+	changes, err := suite.model.DetectInventoryChanges(agents[0])
+	assert.NoError(suite.T(), err, "should detect inventory changes")
+	assert.Equal(suite.T(), []inventory.Change{
+		{Type: inventory.TypeHardware, Action: inventory.ActionChanged, Item: "Memory", Before: "16384 MB", After: "8192 MB"},
+		{Type: inventory.TypeApp, Action: inventory.ActionChanged, Item: "Firefox", Before: "127.0", After: "128.0"},
+		{Type: inventory.TypeApp, Action: inventory.ActionAdded, Item: "VLC", After: "3.0.21"},
+	}, changes)
+
+	changes, err = suite.model.DetectInventoryChanges(agents[0])
+	assert.NoError(suite.T(), err, "should detect inventory changes")
+	assert.Empty(suite.T(), changes, "changes are recorded once")
+
+	suite.detect()
+
+	count, err = suite.model.CountInventoryChanges(filters.InventoryChangeFilter{}, suite.commonInfo)
+	assert.NoError(suite.T(), err, "should count inventory changes")
+	assert.Equal(suite.T(), 5, count)
+
+	history, err := suite.model.GetAgentInventoryChanges("agent1", 10)
+	assert.NoError(suite.T(), err, "should get agent inventory changes")
+	assert.Equal(suite.T(), 3, len(history))
+	assert.Equal(suite.T(), "agent1", history[0].Hostname)
+	assert.Equal(suite.T(), suite.siteID, history[0].SiteID)
+}
+
+func (suite *InventoryChangesTestSuite) TestGetInventoryChangesByPage() {
+	suite.detect()
+
+	client := suite.model.Client
+	_, err := client.App.Delete().Where(app.Name("Firefox")).Exec(context.Background())
+	assert.NoError(suite.T(), err, "should delete apps")
+	_, err = client.Computer.Update().SetMemory(8192).Save(context.Background())
+	assert.NoError(suite.T(), err, "should update computers")
+
+	suite.detect()
+
+	changes, err := suite.model.GetInventoryChangesByPage(suite.p, filters.InventoryChangeFilter{}, suite.commonInfo)
+	assert.NoError(suite.T(), err, "should get inventory changes")
+	assert.Equal(suite.T(), 5, len(changes))
+
+	f := filters.InventoryChangeFilter{Types: []string{inventory.TypeApp}, Actions: []string{inventory.ActionRemoved}}
+	changes, err = suite.model.GetInventoryChangesByPage(suite.p, f, suite.commonInfo)
+	assert.NoError(suite.T(), err, "should get inventory changes")
+	assert.Equal(suite.T(), 3, len(changes))
+	assert.Equal(suite.T(), "Firefox", changes[0].Item)
+	assert.Equal(suite.T(), "127.0", changes[0].Before)
+
+	f = filters.InventoryChangeFilter{Hostname: "agent2", Item: "memory"}
+	changes, err = suite.model.GetInventoryChangesByPage(suite.p, f, suite.commonInfo)
+	assert.NoError(suite.T(), err, "should get inventory changes")
+	assert.Equal(suite.T(), 1, len(changes))
+
+	p := partials.PaginationAndSort{CurrentPage: 1, PageSize: 5, SortBy: "hostname", SortOrder: "asc"}
+	changes, err = suite.model.GetInventoryChangesByPage(p, filters.InventoryChangeFilter{}, suite.commonInfo)
+	assert.NoError(suite.T(), err, "should get inventory changes")
+	assert.Equal(suite.T(), "agent0", changes[0].Hostname)
+
+	otherSite := &partials.CommonInfo{TenantID: suite.commonInfo.TenantID, SiteID: strconv.Itoa(suite.siteID + 1)}
+	count, err := suite.model.CountInventoryChanges(filters.InventoryChangeFilter{}, otherSite)
+	assert.NoError(suite.T(), err, "should count inventory changes")
+	assert.Equal(suite.T(), 0, count)
+}
+
+func TestInventoryChangesTestSuite(t *testing.T) {
+	suite.Run(t, new(InventoryChangesTestSuite))
+}
@@ -0,0 +1,302 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	entsql "entgo.io/ent/dialect/sql"
+	ent "github.com/open-uem/ent"
+	openuem_nats "github.com/open-uem/nats"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/directory"
+)
+
+var (
+	ErrDirectoryUserNotFound = errors.New("the user is not managed by the directory")
+	ErrDirectoryUserConflict = errors.New("a console user with the same username exists and is not managed by the directory")
+)
+
+// Changes done to a console user when it's saved from the directory
+const (
+	DirectoryUserUnchanged = iota
+	DirectoryUserCreated
+	DirectoryUserUpdated
+)
+
+var ldapSettingsColumns = []string{
+	"enabled", "url", "start_tls", "insecure_skip_verify", "bind_dn", "bind_password", "search_base",
+	"user_filter", "group_filter", "username_attribute", "name_attribute", "email_attribute",
+	"phone_attribute", "sync",
+}
+
+var directoryUserColumns = []string{"id", "user_id", "dn", "disabled", "synced"}
+
+// GetLDAPSettings returns the directory settings, the defaults for Active Directory are
+// returned if the directory has never been configured
+func (m *Model) GetLDAPSettings() (consoledb.LDAPSettings, error) {
+	s := consoledb.LDAPSettings{
+		UserFilter:        directory.DefaultUserFilter,
+		UsernameAttribute: directory.DefaultUsernameAttribute,
+		NameAttribute:     directory.DefaultNameAttribute,
+		EmailAttribute:    directory.DefaultEmailAttribute,
+		PhoneAttribute:    directory.DefaultPhoneAttribute,
+	}
+
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Select(append(ldapSettingsColumns, "last_sync", "last_sync_result")...).
+		From(entsql.Table(consoledb.LDAPSettingsTable.Name)).
+		Limit(1).
+		Query()
+
+	var lastSync sql.NullTime
+	var lastSyncResult string
+	if err := m.Driver.DB().QueryRowContext(context.Background(), query, args...).Scan(
+		&s.Enabled, &s.URL, &s.StartTLS, &s.InsecureSkipVerify, &s.BindDN, &s.BindPassword, &s.SearchBase,
+		&s.UserFilter, &s.GroupFilter, &s.UsernameAttribute, &s.NameAttribute, &s.EmailAttribute,
+		&s.PhoneAttribute, &s.Sync, &lastSync, &lastSyncResult,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return s, nil
+		}
+		return s, err
+	}
+
+	s.LastSync = lastSync.Time
+	if lastSyncResult != "" {
+		if err := json.Unmarshal([]byte(lastSyncResult), &s.LastSyncResult); err != nil {
+			return s, err
+		}
+	}
+
+	return s, nil
+}
+
+// SaveLDAPSettings saves the directory settings, the result of the last sync is kept
+func (m *Model) SaveLDAPSettings(s consoledb.LDAPSettings) error {
+	ctx := context.Background()
+
+	tx, err := m.Driver.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	values := []any{
+		s.Enabled, s.URL, s.StartTLS, s.InsecureSkipVerify, s.BindDN, s.BindPassword, s.SearchBase,
+		s.UserFilter, s.GroupFilter, s.UsernameAttribute, s.NameAttribute, s.EmailAttribute,
+		s.PhoneAttribute, s.Sync,
+	}
+
+	update := entsql.Dialect(m.Driver.Dialect()).Update(consoledb.LDAPSettingsTable.Name)
+	for i, column := range ldapSettingsColumns {
+		update.Set(column, values[i])
+	}
+
+	query, args := update.Query()
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		query, args = entsql.Dialect(m.Driver.Dialect()).
+			Insert(consoledb.LDAPSettingsTable.Name).
+			Columns(ldapSettingsColumns...).
+			Values(values...).
+			Query()
+
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// SaveLDAPSyncResult sets when the console users were last synced with the directory
+// and the changes done
+func (m *Model) SaveLDAPSyncResult(result consoledb.LDAPSyncResult) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Update(consoledb.LDAPSettingsTable.Name).
+		Set("last_sync", time.Now()).
+		Set("last_sync_result", string(data)).
+		Query()
+
+	_, err = m.Driver.DB().ExecContext(context.Background(), query, args...)
+	return err
+}
+
+// SaveDirectoryUser creates or updates the console user of a directory entry and marks it
+// as managed by the directory. Console users that weren't created from the directory are
+// never taken over
+func (m *Model) SaveDirectoryUser(e directory.Entry) (int, error) {
+	ctx := context.Background()
+	change := DirectoryUserUnchanged
+
+	current, err := m.GetDirectoryUser(e.Username)
+	if err != nil && !errors.Is(err, ErrDirectoryUserNotFound) {
+		return change, err
+	}
+	managed := err == nil
+
+	u, err := m.Client.User.Get(ctx, e.Username)
+	switch {
+	case ent.IsNotFound(err):
+		if err := m.Client.User.Create().
+			SetID(e.Username).
+			SetName(e.Name).
+			SetEmail(e.Email).
+			SetPhone(e.Phone).
+			SetEmailVerified(true).
+			SetRegister(openuem_nats.REGISTER_APPROVED).
+			SetCreated(time.Now()).
+			Exec(ctx); err != nil {
+			return change, err
+		}
+		change = DirectoryUserCreated
+	case err != nil:
+		return change, err
+	case !managed:
+		return change, ErrDirectoryUserConflict
+	default:
+		if u.Name != e.Name || u.Email != e.Email || u.Phone != e.Phone {
+			if err := m.Client.User.UpdateOneID(u.ID).
+				SetName(e.Name).
+				SetEmail(e.Email).
+				SetPhone(e.Phone).
+				SetModified(time.Now()).
+				Exec(ctx); err != nil {
+				return change, err
+			}
+			change = DirectoryUserUpdated
+		}
+
+		if current.Disabled || current.DN != e.DN {
+			change = DirectoryUserUpdated
+		}
+	}
+
+	var query string
+	var args []any
+	if managed {
+		query, args = entsql.Dialect(m.Driver.Dialect()).
+			Update(consoledb.DirectoryUsersTable.Name).
+			Set("dn", e.DN).
+			Set("disabled", false).
+			Set("synced", time.Now()).
+			Where(entsql.EQ("user_id", e.Username)).
+			Query()
+	} else {
+		query, args = entsql.Dialect(m.Driver.Dialect()).
+			Insert(consoledb.DirectoryUsersTable.Name).
+			Columns("user_id", "dn", "disabled", "synced").
+			Values(e.Username, e.DN, false, time.Now()).
+			Query()
+	}
+
+	if _, err := m.Driver.DB().ExecContext(ctx, query, args...); err != nil {
+		return change, err
+	}
+
+	return change, nil
+}
+
+func (m *Model) GetDirectoryUser(userID string) (consoledb.DirectoryUser, error) {
+	users, err := m.queryDirectoryUsers(func(s *entsql.Selector) {
+		s.Where(entsql.EQ("user_id", userID))
+	})
+	if err != nil {
+		return consoledb.DirectoryUser{}, err
+	}
+
+	if len(users) == 0 {
+		return consoledb.DirectoryUser{}, ErrDirectoryUserNotFound
+	}
+
+	return users[0], nil
+}
+
+func (m *Model) GetDirectoryUsers() ([]consoledb.DirectoryUser, error) {
+	return m.queryDirectoryUsers(func(s *entsql.Selector) {
+		s.OrderBy(entsql.Asc("user_id"))
+	})
+}
+
+func (m *Model) IsDirectoryUser(userID string) bool {
+	_, err := m.GetDirectoryUser(userID)
+	return err == nil
+}
+
+// IsDirectoryUserDisabled reports if the directory no longer allows the user to use
+// the console, users not managed by the directory are never disabled by it
+func (m *Model) IsDirectoryUserDisabled(userID string) (bool, error) {
+	u, err := m.GetDirectoryUser(userID)
+	if err != nil {
+		if errors.Is(err, ErrDirectoryUserNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return u.Disabled, nil
+}
+
+// DisableDirectoryUser prevents the user from signing in until the directory allows
+// the user to use the console again
+func (m *Model) DisableDirectoryUser(userID string) error {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Update(consoledb.DirectoryUsersTable.Name).
+		Set("disabled", true).
+		Set("synced", time.Now()).
+		Where(entsql.EQ("user_id", userID)).
+		Query()
+
+	return m.execAffectingOne(query, args, ErrDirectoryUserNotFound)
+}
+
+func (m *Model) DeleteDirectoryUser(userID string) error {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Delete(consoledb.DirectoryUsersTable.Name).
+		Where(entsql.EQ("user_id", userID)).
+		Query()
+
+	_, err := m.Driver.DB().ExecContext(context.Background(), query, args...)
+	return err
+}
+
+func (m *Model) queryDirectoryUsers(modifier func(s *entsql.Selector)) ([]consoledb.DirectoryUser, error) {
+	selector := entsql.Dialect(m.Driver.Dialect()).
+		Select(directoryUserColumns...).
+		From(entsql.Table(consoledb.DirectoryUsersTable.Name))
+	modifier(selector)
+
+	query, args := selector.Query()
+	rows, err := m.Driver.DB().QueryContext(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []consoledb.DirectoryUser{}
+	for rows.Next() {
+		var u consoledb.DirectoryUser
+		if err := rows.Scan(&u.ID, &u.UserID, &u.DN, &u.Disabled, &u.Synced); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+
+	return users, rows.Err()
+}
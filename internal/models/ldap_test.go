@@ -0,0 +1,123 @@
+package models
+
+import (
+	"context"
+	"testing"
+
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/directory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type LDAPTestSuite struct {
+	suite.Suite
+	model Model
+}
+
+func (suite *LDAPTestSuite) SetupTest() {
+	suite.model = newTestModel(suite.T())
+
+	err := suite.model.Client.User.Create().SetID("admin").SetName("Admin").Exec(context.Background())
+	assert.NoError(suite.T(), err, "should create local user")
+}
+
+func (suite *LDAPTestSuite) TestLDAPSettings() {
+	s, err := suite.model.GetLDAPSettings()
+	assert.NoError(suite.T(), err, "should get default settings")
+	assert.False(suite.T(), s.Enabled, "directory should be disabled by default")
+	assert.Equal(suite.T(), directory.DefaultUsernameAttribute, s.UsernameAttribute)
+
+	s.Enabled = true
+	s.URL = "ldaps://dc1.example.com"
+	s.BindDN = "CN=openuem,DC=example,DC=com"
+	s.GroupFilter = "(memberOf=CN=OpenUEM,DC=example,DC=com)"
+	s.Sync = true
+	err = suite.model.SaveLDAPSettings(s)
+	assert.NoError(suite.T(), err, "should insert settings")
+
+	err = suite.model.SaveLDAPSyncResult(consoledb.LDAPSyncResult{Created: 2, Disabled: 1})
+	assert.NoError(suite.T(), err, "should save sync result")
+
+	s.URL = "ldaps://dc2.example.com"
+	err = suite.model.SaveLDAPSettings(s)
+	assert.NoError(suite.T(), err, "should update settings")
+
+	s, err = suite.model.GetLDAPSettings()
+	assert.NoError(suite.T(), err, "should get settings")
+	assert.True(suite.T(), s.Enabled)
+	assert.True(suite.T(), s.Sync)
+	assert.Equal(suite.T(), "ldaps://dc2.example.com", s.URL)
+	assert.Equal(suite.T(), "(memberOf=CN=OpenUEM,DC=example,DC=com)", s.GroupFilter)
+	assert.False(suite.T(), s.LastSync.IsZero(), "last sync should be kept when settings are saved")
+	assert.Equal(suite.T(), 2, s.LastSyncResult.Created)
+	assert.Equal(suite.T(), 1, s.LastSyncResult.Disabled)
+}
+
+func (suite *LDAPTestSuite) TestSaveDirectoryUser() {
+	entry := directory.Entry{DN: "CN=John Doe,DC=example,DC=com", Username: "jdoe", Name: "John Doe", Email: "jdoe@example.com"}
+
+	change, err := suite.model.SaveDirectoryUser(entry)
+	assert.NoError(suite.T(), err, "should create user")
+	assert.Equal(suite.T(), DirectoryUserCreated, change)
+
+	u, err := suite.model.GetUserById("jdoe")
+	assert.NoError(suite.T(), err, "should get created user")
+	assert.Equal(suite.T(), "John Doe", u.Name)
+	assert.False(suite.T(), u.Passwd, "directory users have no local password")
+	assert.True(suite.T(), suite.model.IsDirectoryUser("jdoe"))
+
+	change, err = suite.model.SaveDirectoryUser(entry)
+	assert.NoError(suite.T(), err, "should save user again")
+	assert.Equal(suite.T(), DirectoryUserUnchanged, change)
+
+	entry.Email = "john.doe@example.com"
+	change, err = suite.model.SaveDirectoryUser(entry)
+	assert.NoError(suite.T(), err, "should update user")
+	assert.Equal(suite.T(), DirectoryUserUpdated, change)
+
+	u, err = suite.model.GetUserById("jdoe")
+	assert.NoError(suite.T(), err, "should get updated user")
+	assert.Equal(suite.T(), "john.doe@example.com", u.Email)
+
+	_, err = suite.model.SaveDirectoryUser(directory.Entry{DN: "CN=Admin,DC=example,DC=com", Username: "admin"})
+	assert.ErrorIs(suite.T(), err, ErrDirectoryUserConflict, "local users should not be taken over")
+	assert.False(suite.T(), suite.model.IsDirectoryUser("admin"))
+}
+
+func (suite *LDAPTestSuite) TestDisableDirectoryUser() {
+	entry := directory.Entry{DN: "CN=John Doe,DC=example,DC=com", Username: "jdoe"}
+	_, err := suite.model.SaveDirectoryUser(entry)
+	assert.NoError(suite.T(), err, "should create user")
+
+	err = suite.model.DisableDirectoryUser("jdoe")
+	assert.NoError(suite.T(), err, "should disable user")
+	disabled, err := suite.model.IsDirectoryUserDisabled("jdoe")
+	assert.NoError(suite.T(), err, "should check if the user is disabled")
+	assert.True(suite.T(), disabled)
+
+	err = suite.model.DisableDirectoryUser("admin")
+	assert.ErrorIs(suite.T(), err, ErrDirectoryUserNotFound, "local users can't be disabled by the directory")
+	disabled, err = suite.model.IsDirectoryUserDisabled("admin")
+	assert.NoError(suite.T(), err, "should check if the user is disabled")
+	assert.False(suite.T(), disabled)
+
+	change, err := suite.model.SaveDirectoryUser(entry)
+	assert.NoError(suite.T(), err, "should enable user again")
+	assert.Equal(suite.T(), DirectoryUserUpdated, change)
+	disabled, err = suite.model.IsDirectoryUserDisabled("jdoe")
+	assert.NoError(suite.T(), err, "should check if the user is disabled")
+	assert.False(suite.T(), disabled)
+
+	users, err := suite.model.GetDirectoryUsers()
+	assert.NoError(suite.T(), err, "should get directory users")
+	assert.Equal(suite.T(), 1, len(users))
+
+	err = suite.model.DeleteDirectoryUser("jdoe")
+	assert.NoError(suite.T(), err, "should delete directory user")
+	assert.False(suite.T(), suite.model.IsDirectoryUser("jdoe"))
+}
+
+func TestLDAPTestSuite(t *testing.T) {
+	suite.Run(t, new(LDAPTestSuite))
+}
@@ -0,0 +1,277 @@
+package models
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	entsql "entgo.io/ent/dialect/sql"
+	"github.com/open-uem/ent/agent"
+	"github.com/open-uem/ent/app"
+	"github.com/open-uem/ent/site"
+	"github.com/open-uem/ent/tenant"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/licenses"
+	"github.com/open-uem/openuem-console/internal/views/filters"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+)
+
+var ErrLicenseNotFound = errors.New("the license doesn't exist")
+
+var licenseColumns = []string{"id", "tenant_id", "name", "app_pattern", "publisher_pattern", "seats", "expiry", "cost_center", "created"}
+
+// LicensePosition compares the seats of a license with the agents that have the product installed
+type LicensePosition struct {
+	consoledb.License
+	Installed   int
+	Available   int
+	Status      string
+	ExpiresSoon bool
+}
+
+// LicenseWarnings counts the licenses of a tenant that need attention
+type LicenseWarnings struct {
+	OverDeployed int
+	Expired      int
+	ExpiringSoon int
+}
+
+func (m *Model) AddLicense(l consoledb.License) error {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Insert(consoledb.LicensesTable.Name).
+		Columns(licenseColumns[1:]...).
+		Values(l.TenantID, l.Name, l.AppPattern, l.PublisherPattern, l.Seats, licenseExpiry(l), l.CostCenter, time.Now()).
+		Query()
+
+	_, err := m.Driver.DB().ExecContext(context.Background(), query, args...)
+	return err
+}
+
+func (m *Model) UpdateLicense(l consoledb.License) error {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Update(consoledb.LicensesTable.Name).
+		Set("name", l.Name).
+		Set("app_pattern", l.AppPattern).
+		Set("publisher_pattern", l.PublisherPattern).
+		Set("seats", l.Seats).
+		Set("expiry", licenseExpiry(l)).
+		Set("cost_center", l.CostCenter).
+		Where(entsql.And(entsql.EQ("id", l.ID), entsql.EQ("tenant_id", l.TenantID))).
+		Query()
+
+	return m.execAffectingOne(query, args, ErrLicenseNotFound)
+}
+
+func (m *Model) GetLicenses(tenantID int) ([]consoledb.License, error) {
+	return m.queryLicenses(func(s *entsql.Selector) {
+		s.Where(entsql.EQ("tenant_id", tenantID)).OrderBy(entsql.Asc("name"), entsql.Asc("id"))
+	})
+}
+
+func (m *Model) GetLicense(id int, tenantID int) (consoledb.License, error) {
+	items, err := m.queryLicenses(func(s *entsql.Selector) {
+		s.Where(entsql.And(entsql.EQ("id", id), entsql.EQ("tenant_id", tenantID)))
+	})
+	if err != nil {
+		return consoledb.License{}, err
+	}
+
+	if len(items) != 1 {
+		return consoledb.License{}, ErrLicenseNotFound
+	}
+
+	return items[0], nil
+}
+
+func (m *Model) DeleteLicense(id int, tenantID int) error {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Delete(consoledb.LicensesTable.Name).
+		Where(entsql.And(entsql.EQ("id", id), entsql.EQ("tenant_id", tenantID))).
+		Query()
+
+	return m.execAffectingOne(query, args, ErrLicenseNotFound)
+}
+
+// GetLicensePositions returns the licenses of the tenant that match the filter with the
+// agents that have them installed, sorted like the table. Seats are purchased for the
+// whole tenant so the installations of every site are counted even if a site is selected
+func (m *Model) GetLicensePositions(p partials.PaginationAndSort, f filters.LicenseFilter, c *partials.CommonInfo) ([]LicensePosition, error) {
+	tenantID, err := strconv.Atoi(c.TenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := m.GetLicenses(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	positions := []LicensePosition{}
+	for _, l := range items {
+		if f.Name != "" && !strings.Contains(strings.ToLower(l.Name), strings.ToLower(f.Name)) {
+			continue
+		}
+		if f.CostCenter != "" && !strings.Contains(strings.ToLower(l.CostCenter), strings.ToLower(f.CostCenter)) {
+			continue
+		}
+
+		installed, err := m.CountLicenseInstallations(tenantID, licenses.Rule{AppPattern: l.AppPattern, PublisherPattern: l.PublisherPattern})
+		if err != nil {
+			return nil, err
+		}
+
+		position := LicensePosition{
+			License:     l,
+			Installed:   installed,
+			Available:   l.Seats - installed,
+			Status:      licenses.Status(l.Seats, installed, l.Expiry, now),
+			ExpiresSoon: licenses.ExpiresSoon(l.Expiry, now),
+		}
+		if len(f.Statuses) > 0 && !slices.Contains(f.Statuses, position.Status) {
+			continue
+		}
+		positions = append(positions, position)
+	}
+
+	sortLicensePositions(positions, p)
+
+	return positions, nil
+}
+
+// GetLicenseWarnings counts the over deployed, expired and about to expire licenses of the tenant
+func (m *Model) GetLicenseWarnings(c *partials.CommonInfo) (LicenseWarnings, error) {
+	warnings := LicenseWarnings{}
+
+	positions, err := m.GetLicensePositions(partials.PaginationAndSort{}, filters.LicenseFilter{}, c)
+	if err != nil {
+		return warnings, err
+	}
+
+	for _, p := range positions {
+		switch p.Status {
+		case licenses.StatusOverDeployed:
+			warnings.OverDeployed++
+		case licenses.StatusExpired:
+			warnings.Expired++
+		}
+		if p.ExpiresSoon {
+			warnings.ExpiringSoon++
+		}
+	}
+
+	return warnings, nil
+}
+
+// CountLicenseInstallations counts the admitted agents of the tenant with an application
+// covered by the rule. The database only filters by the literal text of the patterns, the
+// applications are matched with the whole patterns afterwards
+func (m *Model) CountLicenseInstallations(tenantID int, rule licenses.Rule) (int, error) {
+	query := m.Client.App.Query().Where(app.HasOwnerWith(agent.AgentStatusNEQ(agent.AgentStatusWaitingForAdmission), agent.HasSiteWith(site.HasTenantWith(tenant.ID(tenantID)))))
+	if fragment := licenses.Fragment(rule.AppPattern); fragment != "" {
+		query.Where(app.NameContainsFold(fragment))
+	}
+	if fragment := licenses.Fragment(rule.PublisherPattern); fragment != "" {
+		query.Where(app.PublisherContainsFold(fragment))
+	}
+
+	var installations []struct {
+		Name      string `sql:"name"`
+		Publisher string `sql:"publisher"`
+		AgentID   string `sql:"agent_apps"`
+	}
+	if err := query.Modify(func(s *entsql.Selector) {
+		s.Select(s.C(app.FieldName), s.C(app.FieldPublisher), s.C(app.OwnerColumn)).Distinct()
+	}).Scan(context.Background(), &installations); err != nil {
+		return 0, err
+	}
+
+	agents := map[string]bool{}
+	for _, i := range installations {
+		if rule.Matches(i.Name, i.Publisher) {
+			agents[i.AgentID] = true
+		}
+	}
+
+	return len(agents), nil
+}
+
+// LicensePositionsPage returns the positions of the current page, or all of them if the page size is zero
+func LicensePositionsPage(positions []LicensePosition, p partials.PaginationAndSort) []LicensePosition {
+	if p.PageSize == 0 {
+		return positions
+	}
+
+	start := min((p.CurrentPage-1)*p.PageSize, len(positions))
+	end := min(start+p.PageSize, len(positions))
+	return positions[start:end]
+}
+
+// sortLicensePositions sorts by name unless other column is chosen. Statuses are sorted
+// in the order of licenses.Statuses so the ones that need attention are last
+func sortLicensePositions(positions []LicensePosition, p partials.PaginationAndSort) {
+	slices.SortStableFunc(positions, func(a, b LicensePosition) int {
+		var result int
+		switch p.SortBy {
+		case "seats":
+			result = cmp.Compare(a.Seats, b.Seats)
+		case "installed":
+			result = cmp.Compare(a.Installed, b.Installed)
+		case "available":
+			result = cmp.Compare(a.Available, b.Available)
+		case "expiry":
+			result = a.Expiry.Compare(b.Expiry)
+		case "cost_center":
+			result = cmp.Compare(strings.ToLower(a.CostCenter), strings.ToLower(b.CostCenter))
+		case "status":
+			result = cmp.Compare(slices.Index(licenses.Statuses, a.Status), slices.Index(licenses.Statuses, b.Status))
+		default:
+			result = cmp.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+		}
+
+		if p.SortBy != "" && p.SortOrder == "desc" {
+			return -result
+		}
+		return result
+	})
+}
+
+func (m *Model) queryLicenses(modifier func(s *entsql.Selector)) ([]consoledb.License, error) {
+	selector := entsql.Dialect(m.Driver.Dialect()).
+		Select(licenseColumns...).
+		From(entsql.Table(consoledb.LicensesTable.Name))
+	modifier(selector)
+
+	query, args := selector.Query()
+	rows, err := m.Driver.DB().QueryContext(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []consoledb.License{}
+	for rows.Next() {
+		var l consoledb.License
+		var expiry sql.NullTime
+		if err := rows.Scan(&l.ID, &l.TenantID, &l.Name, &l.AppPattern, &l.PublisherPattern, &l.Seats, &expiry, &l.CostCenter, &l.Created); err != nil {
+			return nil, err
+		}
+		l.Expiry = expiry.Time
+		items = append(items, l)
+	}
+
+	return items, rows.Err()
+}
+
+// licenseExpiry is the value saved in the expiry column, which is NULL if the license doesn't expire
+func licenseExpiry(l consoledb.License) any {
+	if l.Expiry.IsZero() {
+		return nil
+	}
+	return l.Expiry
+}
@@ -0,0 +1,157 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/open-uem/ent/agent"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/licenses"
+	"github.com/open-uem/openuem-console/internal/views/filters"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type LicensesTestSuite struct {
+	suite.Suite
+	model      Model
+	p          partials.PaginationAndSort
+	commonInfo *partials.CommonInfo
+	tenantID   int
+}
+
+func (suite *LicensesTestSuite) SetupTest() {
+	suite.model = newTestModel(suite.T())
+	suite.p = partials.PaginationAndSort{CurrentPage: 1, PageSize: 5}
+	client := suite.model.Client
+
+	t, err := suite.model.CreateDefaultTenant()
+	assert.NoError(suite.T(), err, "should create default tenant")
+	suite.tenantID = t.ID
+
+	s, err := suite.model.CreateDefaultSite(t)
+	assert.NoError(suite.T(), err, "should create default site")
+
+	other, err := client.Site.Create().SetDescription("Other").SetTenantID(t.ID).Save(context.Background())
+	assert.NoError(suite.T(), err, "should create site")
+
+	suite.commonInfo = &partials.CommonInfo{TenantID: fmt.Sprintf("%d", t.ID), SiteID: fmt.Sprintf("%d", s.ID)}
+
+	for i := 0; i <= 3; i++ {
+		id := fmt.Sprintf("agent%d", i)
+		status := agent.AgentStatusEnabled
+		if i == 2 {
+			status = agent.AgentStatusWaitingForAdmission
+		}
+		query := client.Agent.Create().
+			SetID(id).
+			SetHostname(id).
+			SetOs("windows").
+			SetNickname(id).
+			SetAgentStatus(status)
+		if i == 3 {
+			query.AddSiteIDs(other.ID)
+		} else {
+			query.AddSiteIDs(s.ID)
+		}
+		err := query.Exec(context.Background())
+		assert.NoError(suite.T(), err, "should create agent")
+
+		err = client.App.Create().SetName("Microsoft Office Professional Plus 2021").SetVersion("16.0").SetPublisher("Microsoft Corporation").SetOwnerID(id).Exec(context.Background())
+		assert.NoError(suite.T(), err, "should create app")
+
+		// the same product installed twice only uses a seat
+		err = client.App.Create().SetName("Microsoft Office Professional Plus 2021 - es-es").SetVersion("16.0").SetPublisher("Microsoft Corporation").SetOwnerID(id).Exec(context.Background())
+		assert.NoError(suite.T(), err, "should create app")
+
+		if i == 0 {
+			err = client.App.Create().SetName("Adobe Acrobat DC").SetVersion("24.0").SetPublisher("Adobe").SetOwnerID(id).Exec(context.Background())
+			assert.NoError(suite.T(), err, "should create app")
+		}
+	}
+
+	for _, l := range []consoledb.License{
+		{TenantID: t.ID, Name: "Office 2021", AppPattern: "Microsoft Office*", PublisherPattern: "Microsoft*", Seats: 2, CostCenter: "IT"},
+		{TenantID: t.ID, Name: "Acrobat", AppPattern: "*Acrobat*", Seats: 5, Expiry: time.Now().Add(24 * time.Hour), CostCenter: "Marketing"},
+		{TenantID: t.ID, Name: "Visio", AppPattern: "*Visio*", Seats: 1, Expiry: time.Now().Add(-24 * time.Hour), CostCenter: "IT"},
+	} {
+		err := suite.model.AddLicense(l)
+		assert.NoError(suite.T(), err, "should add license")
+	}
+}
+
+func (suite *LicensesTestSuite) TestLicenses() {
+	items, err := suite.model.GetLicenses(suite.tenantID)
+	assert.NoError(suite.T(), err, "should get licenses")
+	assert.Equal(suite.T(), []string{"Acrobat", "Office 2021", "Visio"}, []string{items[0].Name, items[1].Name, items[2].Name})
+	assert.False(suite.T(), items[0].Expiry.IsZero())
+	assert.True(suite.T(), items[1].Expiry.IsZero())
+
+	l, err := suite.model.GetLicense(items[1].ID, suite.tenantID)
+	assert.NoError(suite.T(), err, "should get license")
+	l.Seats = 10
+	l.Expiry = time.Now().Add(time.Hour)
+	err = suite.model.UpdateLicense(l)
+	assert.NoError(suite.T(), err, "should update license")
+
+	l, err = suite.model.GetLicense(items[1].ID, suite.tenantID)
+	assert.NoError(suite.T(), err, "should get license")
+	assert.Equal(suite.T(), 10, l.Seats)
+	assert.False(suite.T(), l.Expiry.IsZero())
+
+	_, err = suite.model.GetLicense(items[1].ID, suite.tenantID+1)
+	assert.ErrorIs(suite.T(), err, ErrLicenseNotFound, "licenses of other tenants shouldn't be found")
+
+	err = suite.model.DeleteLicense(items[2].ID, suite.tenantID)
+	assert.NoError(suite.T(), err, "should delete license")
+
+	err = suite.model.DeleteLicense(items[2].ID, suite.tenantID)
+	assert.ErrorIs(suite.T(), err, ErrLicenseNotFound)
+}
+
+func (suite *LicensesTestSuite) TestLicensePositions() {
+	positions, err := suite.model.GetLicensePositions(suite.p, filters.LicenseFilter{}, suite.commonInfo)
+	assert.NoError(suite.T(), err, "should get license positions")
+	assert.Equal(suite.T(), 3, len(positions))
+
+	acrobat, office, visio := positions[0], positions[1], positions[2]
+	assert.Equal(suite.T(), 1, acrobat.Installed)
+	assert.Equal(suite.T(), 4, acrobat.Available)
+	assert.Equal(suite.T(), licenses.StatusCompliant, acrobat.Status)
+	assert.True(suite.T(), acrobat.ExpiresSoon)
+
+	assert.Equal(suite.T(), 3, office.Installed, "agents waiting for admission aren't counted but agents of other sites are")
+	assert.Equal(suite.T(), -1, office.Available)
+	assert.Equal(suite.T(), licenses.StatusOverDeployed, office.Status)
+
+	assert.Equal(suite.T(), 0, visio.Installed)
+	assert.Equal(suite.T(), licenses.StatusExpired, visio.Status)
+
+	suite.p.SortBy = "installed"
+	suite.p.SortOrder = "desc"
+	positions, err = suite.model.GetLicensePositions(suite.p, filters.LicenseFilter{CostCenter: "it"}, suite.commonInfo)
+	assert.NoError(suite.T(), err, "should get license positions")
+	assert.Equal(suite.T(), []string{"Office 2021", "Visio"}, []string{positions[0].Name, positions[1].Name})
+
+	positions, err = suite.model.GetLicensePositions(suite.p, filters.LicenseFilter{Statuses: []string{licenses.StatusOverDeployed}}, suite.commonInfo)
+	assert.NoError(suite.T(), err, "should get license positions")
+	assert.Equal(suite.T(), 1, len(positions))
+	assert.Equal(suite.T(), "Office 2021", positions[0].Name)
+
+	suite.p.PageSize = 2
+	suite.p.CurrentPage = 2
+	positions, err = suite.model.GetLicensePositions(suite.p, filters.LicenseFilter{}, suite.commonInfo)
+	assert.NoError(suite.T(), err, "should get license positions")
+	assert.Equal(suite.T(), 1, len(LicensePositionsPage(positions, suite.p)))
+
+	warnings, err := suite.model.GetLicenseWarnings(suite.commonInfo)
+	assert.NoError(suite.T(), err, "should get license warnings")
+	assert.Equal(suite.T(), LicenseWarnings{OverDeployed: 1, Expired: 1, ExpiringSoon: 1}, warnings)
+}
+
+func TestLicensesTestSuite(t *testing.T) {
+	suite.Run(t, new(LicensesTestSuite))
+}
@@ -0,0 +1,387 @@
+package models
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	entsql "entgo.io/ent/dialect/sql"
+	ent "github.com/open-uem/ent"
+	"github.com/open-uem/ent/agent"
+	"github.com/open-uem/ent/computer"
+	"github.com/open-uem/ent/predicate"
+	"github.com/open-uem/ent/site"
+	"github.com/open-uem/ent/tenant"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/lifecycle"
+	"github.com/open-uem/openuem-console/internal/views/filters"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+)
+
+var hardwareLifecycleColumns = []string{"id", "agent_id", "tenant_id", "purchase_date", "warranty_expiry", "planned_replacement", "updated"}
+
+// HardwareRefresh is a computer with its lifecycle dates and the age and warranty state derived from them
+type HardwareRefresh struct {
+	consoledb.HardwareLifecycle
+	Nickname     string
+	Manufacturer string
+	Model        string
+	Serial       string
+	SiteID       int
+	Age          string
+	Warranty     string
+}
+
+// WarrantyWarnings counts the computers of a tenant or site whose warranty needs attention
+type WarrantyWarnings struct {
+	Expiring int
+	Expired  int
+}
+
+// SaveHardwareLifecycle replaces the lifecycle dates of the agent, the row is removed if every date is unknown
+func (m *Model) SaveHardwareLifecycle(l consoledb.HardwareLifecycle) error {
+	ctx := context.Background()
+
+	tx, err := m.Driver.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := m.saveHardwareLifecycle(ctx, tx, l); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m *Model) saveHardwareLifecycle(ctx context.Context, tx *sql.Tx, l consoledb.HardwareLifecycle) error {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Delete(consoledb.HardwareLifecycleTable.Name).
+		Where(entsql.EQ("agent_id", l.AgentID)).
+		Query()
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return err
+	}
+
+	if !l.PurchaseDate.IsZero() || !l.WarrantyExpiry.IsZero() || !l.PlannedReplacement.IsZero() {
+		query, args := entsql.Dialect(m.Driver.Dialect()).
+			Insert(consoledb.HardwareLifecycleTable.Name).
+			Columns(hardwareLifecycleColumns[1:]...).
+			Values(l.AgentID, l.TenantID, nullableDate(l.PurchaseDate), nullableDate(l.WarrantyExpiry), nullableDate(l.PlannedReplacement), time.Now()).
+			Query()
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetHardwareLifecycle returns the lifecycle dates of the agent, which are all zero if they haven't been set
+func (m *Model) GetHardwareLifecycle(agentID string) (consoledb.HardwareLifecycle, error) {
+	items, err := m.GetHardwareLifecycles([]string{agentID})
+	if err != nil {
+		return consoledb.HardwareLifecycle{}, err
+	}
+
+	l, ok := items[agentID]
+	if !ok {
+		return consoledb.HardwareLifecycle{AgentID: agentID}, nil
+	}
+	return l, nil
+}
+
+// GetHardwareLifecycles returns the lifecycle dates of the agents that have them, by agent ID
+func (m *Model) GetHardwareLifecycles(agentIDs []string) (map[string]consoledb.HardwareLifecycle, error) {
+	items := map[string]consoledb.HardwareLifecycle{}
+	if len(agentIDs) == 0 {
+		return items, nil
+	}
+
+	selector := entsql.Dialect(m.Driver.Dialect()).
+		Select(hardwareLifecycleColumns...).
+		From(entsql.Table(consoledb.HardwareLifecycleTable.Name)).
+		Where(entsql.In("agent_id", toAny(agentIDs)...))
+
+	query, args := selector.Query()
+	rows, err := m.Driver.DB().QueryContext(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var l consoledb.HardwareLifecycle
+		var purchase, warranty, replacement sql.NullTime
+		if err := rows.Scan(&l.ID, &l.AgentID, &l.TenantID, &purchase, &warranty, &replacement, &l.Updated); err != nil {
+			return nil, err
+		}
+		l.PurchaseDate = purchase.Time
+		l.WarrantyExpiry = warranty.Time
+		l.PlannedReplacement = replacement.Time
+		items[l.AgentID] = l
+	}
+
+	return items, rows.Err()
+}
+
+// ImportHardwareLifecycle saves the dates of the lines of an imported file to the admitted computers
+// of the tenant or site with their serial numbers. The file is saved in a single transaction, nothing
+// is saved if a line fails. It returns how many computers were updated and the unknown serial numbers
+func (m *Model) ImportHardwareLifecycle(records []lifecycle.Record, c *partials.CommonInfo) (int, []string, error) {
+	ctx := context.Background()
+
+	tenantID, err := strconv.Atoi(c.TenantID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	predicates, err := admittedAgentsPredicates(c)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	notFound := []string{}
+	items := []consoledb.HardwareLifecycle{}
+	for _, r := range records {
+		agentIDs, err := m.Client.Agent.Query().
+			Where(append(predicates, agent.HasComputerWith(computer.SerialEqualFold(r.Serial)))...).
+			IDs(ctx)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		if len(agentIDs) == 0 {
+			notFound = append(notFound, r.Serial)
+			continue
+		}
+
+		for _, id := range agentIDs {
+			items = append(items, consoledb.HardwareLifecycle{
+				AgentID:            id,
+				TenantID:           tenantID,
+				PurchaseDate:       r.PurchaseDate,
+				WarrantyExpiry:     r.WarrantyExpiry,
+				PlannedReplacement: r.PlannedReplacement,
+			})
+		}
+	}
+
+	tx, err := m.Driver.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, l := range items {
+		if err := m.saveHardwareLifecycle(ctx, tx, l); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, nil, err
+	}
+
+	return len(items), notFound, nil
+}
+
+// GetHardwareRefresh returns the admitted computers of the tenant or site that match the
+// filter with their lifecycle dates, sorted like the table
+func (m *Model) GetHardwareRefresh(p partials.PaginationAndSort, f filters.HardwareRefreshFilter, c *partials.CommonInfo) ([]HardwareRefresh, error) {
+	query, err := m.admittedAgentsQuery(c)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.Nickname != "" {
+		query.Where(agent.NicknameContainsFold(f.Nickname))
+	}
+	if len(f.Ages) > 0 {
+		query.Where(hardwareAgePredicate(f.Ages, time.Now().UTC()))
+	}
+	if len(f.Warranties) > 0 {
+		query.Where(hardwareWarrantyPredicate(f.Warranties, time.Now().UTC()))
+	}
+
+	agents, err := query.WithComputer().WithSite().All(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []string{}
+	for _, a := range agents {
+		ids = append(ids, a.ID)
+	}
+	dates, err := m.GetHardwareLifecycles(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	items := []HardwareRefresh{}
+	for _, a := range agents {
+		item := HardwareRefresh{HardwareLifecycle: dates[a.ID], Nickname: a.Nickname, SiteID: -1}
+		item.AgentID = a.ID
+		if a.Edges.Computer != nil {
+			item.Manufacturer = a.Edges.Computer.Manufacturer
+			item.Model = a.Edges.Computer.Model
+			item.Serial = a.Edges.Computer.Serial
+		}
+		if len(a.Edges.Site) == 1 {
+			item.SiteID = a.Edges.Site[0].ID
+		}
+		item.Age = lifecycle.Age(item.PurchaseDate, now)
+		item.Warranty = lifecycle.Warranty(item.WarrantyExpiry, now)
+		items = append(items, item)
+	}
+
+	sortHardwareRefresh(items, p)
+
+	return items, nil
+}
+
+// GetWarrantyWarnings counts the admitted computers of the tenant or site whose warranty is expiring or expired
+func (m *Model) GetWarrantyWarnings(c *partials.CommonInfo) (WarrantyWarnings, error) {
+	warnings := WarrantyWarnings{}
+
+	for warranty, count := range map[string]*int{lifecycle.WarrantyExpiring: &warnings.Expiring, lifecycle.WarrantyExpired: &warnings.Expired} {
+		query, err := m.admittedAgentsQuery(c)
+		if err != nil {
+			return warnings, err
+		}
+
+		*count, err = query.Where(hardwareWarrantyPredicate([]string{warranty}, time.Now().UTC())).Count(context.Background())
+		if err != nil {
+			return warnings, err
+		}
+	}
+
+	return warnings, nil
+}
+
+// HardwareRefreshPage returns the computers of the current page, or all of them if the page size is zero
+func HardwareRefreshPage(items []HardwareRefresh, p partials.PaginationAndSort) []HardwareRefresh {
+	if p.PageSize == 0 {
+		return items
+	}
+
+	start := min((p.CurrentPage-1)*p.PageSize, len(items))
+	end := min(start+p.PageSize, len(items))
+	return items[start:end]
+}
+
+// admittedAgentsQuery returns the agents of the tenant or site that have been admitted
+func (m *Model) admittedAgentsQuery(c *partials.CommonInfo) (*ent.AgentQuery, error) {
+	predicates, err := admittedAgentsPredicates(c)
+	if err != nil {
+		return nil, err
+	}
+	return m.Client.Agent.Query().Where(predicates...), nil
+}
+
+// admittedAgentsPredicates matches the agents of the tenant or site that have been admitted
+func admittedAgentsPredicates(c *partials.CommonInfo) ([]predicate.Agent, error) {
+	siteID, err := strconv.Atoi(c.SiteID)
+	if err != nil {
+		return nil, err
+	}
+	tenantID, err := strconv.Atoi(c.TenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	predicates := []predicate.Agent{agent.AgentStatusNEQ(agent.AgentStatusWaitingForAdmission)}
+	if siteID == -1 {
+		predicates = append(predicates, agent.HasSiteWith(site.HasTenantWith(tenant.ID(tenantID))))
+	} else {
+		predicates = append(predicates, agent.HasSiteWith(site.ID(siteID), site.HasTenantWith(tenant.ID(tenantID))))
+	}
+	return predicates, nil
+}
+
+// hardwareAgePredicate matches the agents purchased in any of the age brackets
+func hardwareAgePredicate(ages []string, now time.Time) predicate.Agent {
+	return lifecyclePredicate("purchase_date", ages, lifecycle.AgeUnknown, func(age string) (time.Time, time.Time) {
+		return lifecycle.AgeRange(age, now)
+	})
+}
+
+// hardwareWarrantyPredicate matches the agents whose warranty is in any of the states
+func hardwareWarrantyPredicate(warranties []string, now time.Time) predicate.Agent {
+	return lifecyclePredicate("warranty_expiry", warranties, lifecycle.WarrantyUnknown, func(warranty string) (time.Time, time.Time) {
+		return lifecycle.WarrantyRange(warranty, now)
+	})
+}
+
+// lifecyclePredicate matches the agents with a date of the lifecycle table in the range of
+// any of the options. The unknown option matches the agents without that date
+func lifecyclePredicate(column string, options []string, unknown string, dateRange func(option string) (time.Time, time.Time)) predicate.Agent {
+	return predicate.Agent(func(s *entsql.Selector) {
+		t := entsql.Table(consoledb.HardwareLifecycleTable.Name)
+
+		predicates := []*entsql.Predicate{}
+		for _, option := range options {
+			where := entsql.NotNull(t.C(column))
+			if option == unknown {
+				predicates = append(predicates, entsql.NotIn(s.C(agent.FieldID), entsql.Select(t.C("agent_id")).From(t).Where(where)))
+				continue
+			}
+
+			after, until := dateRange(option)
+			if !after.IsZero() {
+				where = entsql.And(where, entsql.GT(t.C(column), after))
+			}
+			if !until.IsZero() {
+				where = entsql.And(where, entsql.LTE(t.C(column), until))
+			}
+			predicates = append(predicates, entsql.In(s.C(agent.FieldID), entsql.Select(t.C("agent_id")).From(t).Where(where)))
+		}
+
+		if len(predicates) > 0 {
+			s.Where(entsql.Or(predicates...))
+		}
+	})
+}
+
+// sortHardwareRefresh sorts by nickname unless other column is chosen. Unknown dates are
+// sorted before the known ones
+func sortHardwareRefresh(items []HardwareRefresh, p partials.PaginationAndSort) {
+	slices.SortStableFunc(items, func(a, b HardwareRefresh) int {
+		var result int
+		switch p.SortBy {
+		case "manufacturer":
+			result = cmp.Compare(strings.ToLower(a.Manufacturer), strings.ToLower(b.Manufacturer))
+		case "model":
+			result = cmp.Compare(strings.ToLower(a.Model), strings.ToLower(b.Model))
+		case "serial":
+			result = cmp.Compare(a.Serial, b.Serial)
+		case "purchase_date":
+			result = a.PurchaseDate.Compare(b.PurchaseDate)
+		case "warranty_expiry":
+			result = a.WarrantyExpiry.Compare(b.WarrantyExpiry)
+		case "planned_replacement":
+			result = a.PlannedReplacement.Compare(b.PlannedReplacement)
+		default:
+			result = cmp.Compare(strings.ToLower(a.Nickname), strings.ToLower(b.Nickname))
+		}
+
+		if p.SortBy != "" && p.SortOrder == "desc" {
+			return -result
+		}
+		return result
+	})
+}
+
+// nullableDate is the value saved in a date column, which is NULL if the date is unknown
+func nullableDate(date time.Time) any {
+	if date.IsZero() {
+		return nil
+	}
+	return date
+}
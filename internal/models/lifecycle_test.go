@@ -0,0 +1,200 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/open-uem/ent/agent"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/lifecycle"
+	"github.com/open-uem/openuem-console/internal/views/filters"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type LifecycleTestSuite struct {
+	suite.Suite
+	model      Model
+	p          partials.PaginationAndSort
+	commonInfo *partials.CommonInfo
+	tenantID   int
+	siteID     int
+}
+
+func (suite *LifecycleTestSuite) SetupTest() {
+	suite.model = newTestModel(suite.T())
+	suite.p = partials.PaginationAndSort{CurrentPage: 1, PageSize: 5}
+	client := suite.model.Client
+
+	t, err := suite.model.CreateDefaultTenant()
+	assert.NoError(suite.T(), err, "should create default tenant")
+	suite.tenantID = t.ID
+
+	s, err := suite.model.CreateDefaultSite(t)
+	assert.NoError(suite.T(), err, "should create default site")
+	suite.siteID = s.ID
+
+	suite.commonInfo = &partials.CommonInfo{TenantID: fmt.Sprintf("%d", t.ID), SiteID: "-1"}
+
+	for i := range 4 {
+		id := fmt.Sprintf("agent%d", i)
+		status := agent.AgentStatusEnabled
+		if i == 3 {
+			status = agent.AgentStatusWaitingForAdmission
+		}
+		err := client.Agent.Create().
+			SetID(id).
+			SetHostname(id).
+			SetOs("windows").
+			SetNickname(id).
+			SetAgentStatus(status).
+			AddSiteIDs(s.ID).
+			Exec(context.Background())
+		assert.NoError(suite.T(), err, "should create agent")
+
+		err = client.Computer.Create().
+			SetManufacturer("HP").
+			SetMemory(16384).
+			SetModel("EliteBook 840").
+			SetSerial(fmt.Sprintf("serial%d", i)).
+			SetProcessor("intel").
+			SetProcessorArch("amd64").
+			SetProcessorCores(4).
+			SetOwnerID(id).
+			Exec(context.Background())
+		assert.NoError(suite.T(), err, "should create computer")
+	}
+
+	now := time.Now().UTC()
+	for _, l := range []consoledb.HardwareLifecycle{
+		{AgentID: "agent0", PurchaseDate: now.AddDate(-6, 0, 0), WarrantyExpiry: now.AddDate(-1, 0, 0)},
+		{AgentID: "agent1", PurchaseDate: now.AddDate(-2, 0, 0), WarrantyExpiry: now.AddDate(0, 1, 0), PlannedReplacement: now.AddDate(1, 0, 0)},
+		{AgentID: "agent3", WarrantyExpiry: now.AddDate(0, 0, -1)},
+	} {
+		l.TenantID = t.ID
+		err := suite.model.SaveHardwareLifecycle(l)
+		assert.NoError(suite.T(), err, "should save lifecycle")
+	}
+}
+
+func (suite *LifecycleTestSuite) TestSaveHardwareLifecycle() {
+	l, err := suite.model.GetHardwareLifecycle("agent1")
+	assert.NoError(suite.T(), err, "should get lifecycle")
+	assert.False(suite.T(), l.PurchaseDate.IsZero())
+	assert.False(suite.T(), l.PlannedReplacement.IsZero())
+
+	l, err = suite.model.GetHardwareLifecycle("agent2")
+	assert.NoError(suite.T(), err, "should get lifecycle of an agent without dates")
+	assert.Equal(suite.T(), "agent2", l.AgentID)
+	assert.True(suite.T(), l.WarrantyExpiry.IsZero())
+
+	err = suite.model.SaveHardwareLifecycle(consoledb.HardwareLifecycle{AgentID: "agent1", TenantID: suite.tenantID})
+	assert.NoError(suite.T(), err, "should remove lifecycle")
+	items, err := suite.model.GetHardwareLifecycles([]string{"agent0", "agent1"})
+	assert.NoError(suite.T(), err, "should get lifecycles")
+	assert.Len(suite.T(), items, 1)
+}
+
+func (suite *LifecycleTestSuite) TestImportHardwareLifecycle() {
+	expiry := time.Date(2030, 1, 31, 0, 0, 0, 0, time.UTC)
+	n, notFound, err := suite.model.ImportHardwareLifecycle([]lifecycle.Record{
+		{Serial: "SERIAL2", WarrantyExpiry: expiry},
+		{Serial: "unknown", WarrantyExpiry: expiry},
+		{Serial: "serial3", WarrantyExpiry: expiry},
+	}, suite.commonInfo)
+	assert.NoError(suite.T(), err, "should import lifecycle")
+	assert.Equal(suite.T(), 1, n)
+	assert.Equal(suite.T(), []string{"unknown", "serial3"}, notFound, "agents waiting for admission should not be updated")
+
+	l, err := suite.model.GetHardwareLifecycle("agent2")
+	assert.NoError(suite.T(), err, "should get lifecycle")
+	assert.True(suite.T(), expiry.Equal(l.WarrantyExpiry))
+}
+
+func (suite *LifecycleTestSuite) TestImportHardwareLifecycleSite() {
+	client := suite.model.Client
+	other, err := client.Site.Create().SetDescription("Other").SetTenantID(suite.tenantID).Save(context.Background())
+	assert.NoError(suite.T(), err, "should create site")
+
+	err = client.Agent.Create().SetID("agent4").SetHostname("agent4").SetOs("windows").SetNickname("agent4").AddSiteIDs(other.ID).Exec(context.Background())
+	assert.NoError(suite.T(), err, "should create agent")
+	err = client.Computer.Create().
+		SetManufacturer("HP").
+		SetMemory(16384).
+		SetModel("EliteBook 840").
+		SetSerial("serial4").
+		SetProcessor("intel").
+		SetProcessorArch("amd64").
+		SetProcessorCores(4).
+		SetOwnerID("agent4").
+		Exec(context.Background())
+	assert.NoError(suite.T(), err, "should create computer")
+
+	expiry := time.Date(2030, 1, 31, 0, 0, 0, 0, time.UTC)
+	commonInfo := &partials.CommonInfo{TenantID: suite.commonInfo.TenantID, SiteID: fmt.Sprintf("%d", suite.siteID)}
+	n, notFound, err := suite.model.ImportHardwareLifecycle([]lifecycle.Record{
+		{Serial: "serial1", WarrantyExpiry: expiry},
+		{Serial: "serial4", WarrantyExpiry: expiry},
+	}, commonInfo)
+	assert.NoError(suite.T(), err, "should import lifecycle")
+	assert.Equal(suite.T(), 1, n)
+	assert.Equal(suite.T(), []string{"serial4"}, notFound, "computers of other sites should not be updated")
+
+	l, err := suite.model.GetHardwareLifecycle("agent4")
+	assert.NoError(suite.T(), err, "should get lifecycle")
+	assert.True(suite.T(), l.WarrantyExpiry.IsZero())
+}
+
+func (suite *LifecycleTestSuite) TestGetHardwareRefresh() {
+	items, err := suite.model.GetHardwareRefresh(suite.p, filters.HardwareRefreshFilter{}, suite.commonInfo)
+	assert.NoError(suite.T(), err, "should get hardware refresh")
+	assert.Len(suite.T(), items, 3, "agents waiting for admission should not be listed")
+	assert.Equal(suite.T(), lifecycle.AgeOver5Years, items[0].Age)
+	assert.Equal(suite.T(), lifecycle.WarrantyExpired, items[0].Warranty)
+	assert.Equal(suite.T(), lifecycle.WarrantyExpiring, items[1].Warranty)
+	assert.Equal(suite.T(), lifecycle.AgeUnknown, items[2].Age)
+	assert.Equal(suite.T(), "serial2", items[2].Serial)
+
+	items, err = suite.model.GetHardwareRefresh(suite.p, filters.HardwareRefreshFilter{Ages: []string{lifecycle.Age1To3Years, lifecycle.AgeUnknown}}, suite.commonInfo)
+	assert.NoError(suite.T(), err, "should get hardware refresh")
+	assert.Len(suite.T(), items, 2)
+	assert.Equal(suite.T(), "agent1", items[0].AgentID)
+	assert.Equal(suite.T(), "agent2", items[1].AgentID)
+
+	p := suite.p
+	p.SortBy = "warranty_expiry"
+	p.SortOrder = "desc"
+	items, err = suite.model.GetHardwareRefresh(p, filters.HardwareRefreshFilter{Warranties: []string{lifecycle.WarrantyExpired, lifecycle.WarrantyExpiring}}, suite.commonInfo)
+	assert.NoError(suite.T(), err, "should get hardware refresh")
+	assert.Len(suite.T(), items, 2)
+	assert.Equal(suite.T(), "agent1", items[0].AgentID)
+}
+
+func (suite *LifecycleTestSuite) TestComputerFilters() {
+	count, err := suite.model.CountAllComputers(filters.AgentFilter{Warranties: []string{lifecycle.WarrantyUnknown}}, suite.commonInfo)
+	assert.NoError(suite.T(), err, "should count computers")
+	assert.Equal(suite.T(), 1, count)
+
+	count, err = suite.model.CountAllComputers(filters.AgentFilter{HardwareAges: []string{lifecycle.AgeOver5Years}, Warranties: []string{lifecycle.WarrantyExpired}}, suite.commonInfo)
+	assert.NoError(suite.T(), err, "should count computers")
+	assert.Equal(suite.T(), 1, count)
+
+	computers, err := suite.model.GetComputersByPage(partials.PaginationAndSort{CurrentPage: 1, PageSize: 5}, filters.AgentFilter{HardwareAges: []string{lifecycle.AgeOver5Years}}, suite.commonInfo)
+	assert.NoError(suite.T(), err, "should get computers")
+	assert.Equal(suite.T(), 1, len(computers))
+	assert.False(suite.T(), computers[0].PurchaseDate.IsZero(), "the purchase date should be added to the computer")
+	assert.False(suite.T(), computers[0].WarrantyExpiry.IsZero(), "the warranty expiry should be added to the computer")
+}
+
+func (suite *LifecycleTestSuite) TestGetWarrantyWarnings() {
+	warnings, err := suite.model.GetWarrantyWarnings(suite.commonInfo)
+	assert.NoError(suite.T(), err, "should get warranty warnings")
+	assert.Equal(suite.T(), WarrantyWarnings{Expiring: 1, Expired: 1}, warnings)
+}
+
+func TestLifecycleTestSuite(t *testing.T) {
+	suite.Run(t, new(LifecycleTestSuite))
+}
@@ -0,0 +1,382 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	entsql "entgo.io/ent/dialect/sql"
+	"github.com/open-uem/ent"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/maintenance"
+	"github.com/open-uem/openuem-console/internal/views/filters"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+)
+
+var ErrMaintenanceWindowNotFound = errors.New("the maintenance window doesn't exist")
+var ErrMaintenanceActionNotFound = errors.New("the queued action doesn't exist or has already been sent")
+
+var maintenanceWindowColumns = []string{"id", "tenant_id", "name", "target_type", "target_id", "days", "start_minute", "duration_minutes", "timezone", "enabled", "created"}
+var maintenanceActionColumns = []string{"id", "tenant_id", "site_id", "agent_id", "hostname", "action", "description", "payload", "not_before", "status", "error", "language", "created_by", "created", "sent"}
+
+func (m *Model) AddMaintenanceWindow(w consoledb.MaintenanceWindow) error {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Insert(consoledb.MaintenanceWindowsTable.Name).
+		Columns(maintenanceWindowColumns[1:]...).
+		Values(w.TenantID, w.Name, w.TargetType, w.TargetID, maintenance.FormatDays(w.Days), w.Start, w.Duration, w.TimeZone, w.Enabled, time.Now()).
+		Query()
+
+	_, err := m.Driver.DB().ExecContext(context.Background(), query, args...)
+	return err
+}
+
+func (m *Model) GetMaintenanceWindows(tenantID int) ([]consoledb.MaintenanceWindow, error) {
+	return m.queryMaintenanceWindows(func(s *entsql.Selector) {
+		s.Where(entsql.EQ("tenant_id", tenantID)).OrderBy(entsql.Asc("name"), entsql.Asc("id"))
+	})
+}
+
+func (m *Model) GetMaintenanceWindow(id int, tenantID int) (consoledb.MaintenanceWindow, error) {
+	items, err := m.queryMaintenanceWindows(func(s *entsql.Selector) {
+		s.Where(entsql.And(entsql.EQ("id", id), entsql.EQ("tenant_id", tenantID)))
+	})
+	if err != nil {
+		return consoledb.MaintenanceWindow{}, err
+	}
+
+	if len(items) != 1 {
+		return consoledb.MaintenanceWindow{}, ErrMaintenanceWindowNotFound
+	}
+
+	return items[0], nil
+}
+
+func (m *Model) SetMaintenanceWindowEnabled(id int, tenantID int, enabled bool) error {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Update(consoledb.MaintenanceWindowsTable.Name).
+		Set("enabled", enabled).
+		Where(entsql.And(entsql.EQ("id", id), entsql.EQ("tenant_id", tenantID))).
+		Query()
+
+	return m.execAffectingOne(query, args, ErrMaintenanceWindowNotFound)
+}
+
+// DeleteMaintenanceWindow removes the window, the actions waiting for it are sent at the
+// next window of the agent or right away if the agent has no windows left
+func (m *Model) DeleteMaintenanceWindow(id int, tenantID int) error {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Delete(consoledb.MaintenanceWindowsTable.Name).
+		Where(entsql.And(entsql.EQ("id", id), entsql.EQ("tenant_id", tenantID))).
+		Query()
+
+	return m.execAffectingOne(query, args, ErrMaintenanceWindowNotFound)
+}
+
+// GetAgentMaintenanceWindows returns the enabled windows of the sites and tags of the agent,
+// which must have been loaded with its site and tags edges
+func (m *Model) GetAgentMaintenanceWindows(a *ent.Agent) ([]maintenance.Window, error) {
+	targets := []*entsql.Predicate{}
+
+	sites := []any{}
+	for _, s := range a.Edges.Site {
+		sites = append(sites, s.ID)
+	}
+	if len(sites) > 0 {
+		targets = append(targets, entsql.And(entsql.EQ("target_type", maintenance.TargetSite), entsql.In("target_id", sites...)))
+	}
+
+	tags := []any{}
+	for _, t := range a.Edges.Tags {
+		tags = append(tags, t.ID)
+	}
+	if len(tags) > 0 {
+		targets = append(targets, entsql.And(entsql.EQ("target_type", maintenance.TargetTag), entsql.In("target_id", tags...)))
+	}
+
+	if len(targets) == 0 {
+		return nil, nil
+	}
+
+	items, err := m.queryMaintenanceWindows(func(s *entsql.Selector) {
+		s.Where(entsql.And(entsql.EQ("enabled", true), entsql.Or(targets...)))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	windows := []maintenance.Window{}
+	for _, w := range items {
+		location, err := time.LoadLocation(w.TimeZone)
+		if err != nil {
+			return nil, err
+		}
+		windows = append(windows, maintenance.Window{Days: w.Days, Start: w.Start, Duration: w.Duration, Location: location})
+	}
+
+	return windows, nil
+}
+
+// QueueMaintenanceAction saves the action until its window opens and returns its id
+func (m *Model) QueueMaintenanceAction(a consoledb.MaintenanceAction) (int, error) {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Insert(consoledb.MaintenanceQueueTable.Name).
+		Columns("tenant_id", "site_id", "agent_id", "hostname", "action", "description", "payload", "not_before", "status", "language", "created_by", "created").
+		Values(a.TenantID, a.SiteID, a.AgentID, a.Hostname, a.Action, truncate(a.Description, 1024), a.Payload, a.NotBefore, maintenance.StatusQueued, a.Language, a.CreatedBy, time.Now()).
+		Returning("id").
+		Query()
+
+	var id int
+	if err := m.Driver.DB().QueryRowContext(context.Background(), query, args...).Scan(&id); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// GetMaintenanceActionsByID returns the actions with the given ids of every tenant
+func (m *Model) GetMaintenanceActionsByID(ids []int) ([]consoledb.MaintenanceAction, error) {
+	if len(ids) == 0 {
+		return []consoledb.MaintenanceAction{}, nil
+	}
+
+	return m.queryMaintenanceActions(func(s *entsql.Selector) {
+		s.Where(entsql.In("id", toAny(ids)...))
+	})
+}
+
+// GetDueMaintenanceActions returns the queued actions of every tenant whose window has opened
+func (m *Model) GetDueMaintenanceActions(now time.Time, limit int) ([]consoledb.MaintenanceAction, error) {
+	return m.queryMaintenanceActions(func(s *entsql.Selector) {
+		s.Where(entsql.And(entsql.EQ("status", maintenance.StatusQueued), entsql.LTE("not_before", now))).
+			OrderBy(entsql.Asc("not_before"), entsql.Asc("id")).
+			Limit(limit)
+	})
+}
+
+func (m *Model) GetMaintenanceAction(id int, c *partials.CommonInfo) (consoledb.MaintenanceAction, error) {
+	var scopeErr error
+
+	items, err := m.queryMaintenanceActions(func(s *entsql.Selector) {
+		scopeErr = applyMaintenanceActionScope(s, c)
+		s.Where(entsql.EQ("id", id))
+	})
+	if scopeErr != nil {
+		return consoledb.MaintenanceAction{}, scopeErr
+	}
+	if err != nil {
+		return consoledb.MaintenanceAction{}, err
+	}
+
+	if len(items) != 1 {
+		return consoledb.MaintenanceAction{}, ErrMaintenanceActionNotFound
+	}
+
+	return items[0], nil
+}
+
+// RescheduleMaintenanceAction delays a queued action, e.g. when its windows changed
+func (m *Model) RescheduleMaintenanceAction(id int, notBefore time.Time) error {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Update(consoledb.MaintenanceQueueTable.Name).
+		Set("not_before", notBefore).
+		Where(entsql.And(entsql.EQ("id", id), entsql.EQ("status", maintenance.StatusQueued))).
+		Query()
+
+	return m.execAffectingOne(query, args, ErrMaintenanceActionNotFound)
+}
+
+// ClaimMaintenanceAction marks a queued action as sent. It returns false if the action is
+// no longer queued, e.g. it was cancelled or another console instance claimed it first
+func (m *Model) ClaimMaintenanceAction(id int) (bool, error) {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Update(consoledb.MaintenanceQueueTable.Name).
+		Set("status", maintenance.StatusSent).
+		Set("sent", time.Now()).
+		Where(entsql.And(entsql.EQ("id", id), entsql.EQ("status", maintenance.StatusQueued))).
+		Query()
+
+	return m.execClaim(query, args)
+}
+
+// FailMaintenanceAction records why a claimed action couldn't be sent
+func (m *Model) FailMaintenanceAction(id int, errMessage string) error {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Update(consoledb.MaintenanceQueueTable.Name).
+		Set("status", maintenance.StatusFailed).
+		Set("error", truncate(errMessage, 2048)).
+		Where(entsql.EQ("id", id)).
+		Query()
+
+	return m.execAffectingOne(query, args, ErrMaintenanceActionNotFound)
+}
+
+// CancelMaintenanceAction removes an action from the queue if it hasn't been sent yet
+func (m *Model) CancelMaintenanceAction(id int, c *partials.CommonInfo) error {
+	tenantID, err := strconv.Atoi(c.TenantID)
+	if err != nil {
+		return err
+	}
+	siteID, err := strconv.Atoi(c.SiteID)
+	if err != nil {
+		return err
+	}
+
+	where := entsql.And(entsql.EQ("id", id), entsql.EQ("tenant_id", tenantID), entsql.EQ("status", maintenance.StatusQueued))
+	if siteID != -1 {
+		where = entsql.And(where, entsql.EQ("site_id", siteID))
+	}
+
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Update(consoledb.MaintenanceQueueTable.Name).
+		Set("status", maintenance.StatusCancelled).
+		Where(where).
+		Query()
+
+	return m.execAffectingOne(query, args, ErrMaintenanceActionNotFound)
+}
+
+func (m *Model) CountMaintenanceActions(f filters.MaintenanceQueueFilter, c *partials.CommonInfo) (int, error) {
+	var count int
+
+	selector := entsql.Dialect(m.Driver.Dialect()).
+		Select(entsql.Count("*")).
+		From(entsql.Table(consoledb.MaintenanceQueueTable.Name))
+	if err := applyMaintenanceActionScope(selector, c); err != nil {
+		return 0, err
+	}
+	applyMaintenanceActionFilter(selector, f)
+
+	query, args := selector.Query()
+	if err := m.Driver.DB().QueryRowContext(context.Background(), query, args...).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (m *Model) GetMaintenanceActionsByPage(p partials.PaginationAndSort, f filters.MaintenanceQueueFilter, c *partials.CommonInfo) ([]consoledb.MaintenanceAction, error) {
+	var scopeErr error
+
+	items, err := m.queryMaintenanceActions(func(s *entsql.Selector) {
+		scopeErr = applyMaintenanceActionScope(s, c)
+		applyMaintenanceActionFilter(s, f)
+
+		column := "not_before"
+		switch p.SortBy {
+		case "hostname":
+			column = "hostname"
+		case "action":
+			column = "action"
+		case "status":
+			column = "status"
+		case "created":
+			column = "created"
+		}
+
+		if p.SortOrder == "asc" {
+			s.OrderBy(entsql.Asc(column), entsql.Asc("id"))
+		} else {
+			s.OrderBy(entsql.Desc(column), entsql.Desc("id"))
+		}
+
+		if p.PageSize != 0 {
+			s.Limit(p.PageSize).Offset((p.CurrentPage - 1) * p.PageSize)
+		}
+	})
+	if scopeErr != nil {
+		return nil, scopeErr
+	}
+
+	return items, err
+}
+
+// applyMaintenanceActionScope keeps the actions requested for agents of the tenant and site
+func applyMaintenanceActionScope(s *entsql.Selector, c *partials.CommonInfo) error {
+	tenantID, err := strconv.Atoi(c.TenantID)
+	if err != nil {
+		return err
+	}
+	siteID, err := strconv.Atoi(c.SiteID)
+	if err != nil {
+		return err
+	}
+
+	s.Where(entsql.EQ("tenant_id", tenantID))
+	if siteID != -1 {
+		s.Where(entsql.EQ("site_id", siteID))
+	}
+	return nil
+}
+
+func applyMaintenanceActionFilter(s *entsql.Selector, f filters.MaintenanceQueueFilter) {
+	if len(f.Hostname) > 0 {
+		s.Where(entsql.ContainsFold("hostname", f.Hostname))
+	}
+
+	if len(f.Actions) > 0 {
+		s.Where(entsql.In("action", toAny(f.Actions)...))
+	}
+
+	if len(f.Statuses) > 0 {
+		s.Where(entsql.In("status", toAny(f.Statuses)...))
+	}
+}
+
+func (m *Model) queryMaintenanceWindows(modifier func(s *entsql.Selector)) ([]consoledb.MaintenanceWindow, error) {
+	selector := entsql.Dialect(m.Driver.Dialect()).
+		Select(maintenanceWindowColumns...).
+		From(entsql.Table(consoledb.MaintenanceWindowsTable.Name))
+	modifier(selector)
+
+	query, args := selector.Query()
+	rows, err := m.Driver.DB().QueryContext(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []consoledb.MaintenanceWindow{}
+	for rows.Next() {
+		var w consoledb.MaintenanceWindow
+		var days string
+		if err := rows.Scan(&w.ID, &w.TenantID, &w.Name, &w.TargetType, &w.TargetID, &days, &w.Start, &w.Duration, &w.TimeZone, &w.Enabled, &w.Created); err != nil {
+			return nil, err
+		}
+		w.Days, _ = maintenance.ParseDays(strings.Split(days, ","))
+		items = append(items, w)
+	}
+
+	return items, rows.Err()
+}
+
+func (m *Model) queryMaintenanceActions(modifier func(s *entsql.Selector)) ([]consoledb.MaintenanceAction, error) {
+	selector := entsql.Dialect(m.Driver.Dialect()).
+		Select(maintenanceActionColumns...).
+		From(entsql.Table(consoledb.MaintenanceQueueTable.Name))
+	modifier(selector)
+
+	query, args := selector.Query()
+	rows, err := m.Driver.DB().QueryContext(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []consoledb.MaintenanceAction{}
+	for rows.Next() {
+		var a consoledb.MaintenanceAction
+		var sent sql.NullTime
+		if err := rows.Scan(&a.ID, &a.TenantID, &a.SiteID, &a.AgentID, &a.Hostname, &a.Action, &a.Description, &a.Payload, &a.NotBefore, &a.Status, &a.Error, &a.Language, &a.CreatedBy, &a.Created, &sent); err != nil {
+			return nil, err
+		}
+		if sent.Valid {
+			a.Sent = sent.Time
+		}
+		items = append(items, a)
+	}
+
+	return items, rows.Err()
+}
@@ -0,0 +1,242 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/open-uem/ent/agent"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/maintenance"
+	"github.com/open-uem/openuem-console/internal/views/filters"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type MaintenanceTestSuite struct {
+	suite.Suite
+	model      Model
+	tenantID   int
+	siteID     int
+	tagID      int
+	commonInfo *partials.CommonInfo
+}
+
+func (suite *MaintenanceTestSuite) SetupTest() {
+	suite.model = newTestModel(suite.T())
+	client := suite.model.Client
+
+	t, err := suite.model.CreateDefaultTenant()
+	assert.NoError(suite.T(), err, "should create default tenant")
+	suite.tenantID = t.ID
+
+	s, err := suite.model.CreateDefaultSite(t)
+	assert.NoError(suite.T(), err, "should create default site")
+	suite.siteID = s.ID
+
+	suite.commonInfo = &partials.CommonInfo{TenantID: strconv.Itoa(t.ID), SiteID: "-1"}
+
+	tag, err := client.Tag.Create().SetTag("Servers").SetDescription("Servers").SetColor("#f0f0f0").SetTenantID(t.ID).Save(context.Background())
+	assert.NoError(suite.T(), err, "should create tag")
+	suite.tagID = tag.ID
+
+	for i := 0; i <= 1; i++ {
+		query := client.Agent.Create().
+			SetID(fmt.Sprintf("agent%d", i)).
+			SetHostname(fmt.Sprintf("host%d", i)).
+			SetOs("windows").
+			SetNickname(fmt.Sprintf("agent%d", i)).
+			SetAgentStatus(agent.AgentStatusEnabled).
+			AddSiteIDs(s.ID)
+		if i == 0 {
+			query.AddTagIDs(tag.ID)
+		}
+		err := query.Exec(context.Background())
+		assert.NoError(suite.T(), err, "should create agent")
+	}
+}
+
+func (suite *MaintenanceTestSuite) addWindow(name, targetType string, targetID int) consoledb.MaintenanceWindow {
+	err := suite.model.AddMaintenanceWindow(consoledb.MaintenanceWindow{
+		TenantID:   suite.tenantID,
+		Name:       name,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Days:       []time.Weekday{time.Saturday, time.Sunday},
+		Start:      22 * 60,
+		Duration:   6 * 60,
+		TimeZone:   "Europe/Madrid",
+		Enabled:    true,
+	})
+	assert.NoError(suite.T(), err, "should add maintenance window")
+
+	windows, err := suite.model.GetMaintenanceWindows(suite.tenantID)
+	assert.NoError(suite.T(), err, "should get maintenance windows")
+	for _, w := range windows {
+		if w.Name == name {
+			return w
+		}
+	}
+	suite.T().Fatalf("maintenance window %s not found", name)
+	return consoledb.MaintenanceWindow{}
+}
+
+func (suite *MaintenanceTestSuite) queueAction(agentID, action string, notBefore time.Time) {
+	_, err := suite.model.QueueMaintenanceAction(consoledb.MaintenanceAction{
+		TenantID:  suite.tenantID,
+		SiteID:    suite.siteID,
+		AgentID:   agentID,
+		Hostname:  "host" + agentID[len(agentID)-1:],
+		Action:    action,
+		Payload:   "{}",
+		NotBefore: notBefore,
+		Language:  "en",
+		CreatedBy: "admin",
+	})
+	assert.NoError(suite.T(), err, "should queue action")
+}
+
+func (suite *MaintenanceTestSuite) TestMaintenanceWindows() {
+	w := suite.addWindow("Weekend nights", maintenance.TargetTag, suite.tagID)
+	assert.Equal(suite.T(), []time.Weekday{time.Sunday, time.Saturday}, w.Days)
+	assert.Equal(suite.T(), 22*60, w.Start)
+	assert.Equal(suite.T(), 6*60, w.Duration)
+	assert.True(suite.T(), w.Enabled)
+
+	err := suite.model.SetMaintenanceWindowEnabled(w.ID, suite.tenantID, false)
+	assert.NoError(suite.T(), err, "should disable maintenance window")
+
+	w, err = suite.model.GetMaintenanceWindow(w.ID, suite.tenantID)
+	assert.NoError(suite.T(), err, "should get maintenance window")
+	assert.False(suite.T(), w.Enabled)
+
+	_, err = suite.model.GetMaintenanceWindow(w.ID, suite.tenantID+1)
+	assert.ErrorIs(suite.T(), err, ErrMaintenanceWindowNotFound, "windows of other tenants must not be found")
+
+	err = suite.model.DeleteMaintenanceWindow(w.ID, suite.tenantID+1)
+	assert.ErrorIs(suite.T(), err, ErrMaintenanceWindowNotFound)
+
+	err = suite.model.DeleteMaintenanceWindow(w.ID, suite.tenantID)
+	assert.NoError(suite.T(), err, "should delete maintenance window")
+
+	windows, err := suite.model.GetMaintenanceWindows(suite.tenantID)
+	assert.NoError(suite.T(), err, "should get maintenance windows")
+	assert.Equal(suite.T(), 0, len(windows))
+}
+
+func (suite *MaintenanceTestSuite) TestGetAgentMaintenanceWindows() {
+	w := suite.addWindow("Servers", maintenance.TargetTag, suite.tagID)
+
+	tagged, err := suite.model.GetAgentById("agent0", suite.commonInfo)
+	assert.NoError(suite.T(), err, "should get agent")
+	windows, err := suite.model.GetAgentMaintenanceWindows(tagged)
+	assert.NoError(suite.T(), err, "should get agent maintenance windows")
+	assert.Equal(suite.T(), 1, len(windows))
+	assert.Equal(suite.T(), "Europe/Madrid", windows[0].Location.String())
+
+	untagged, err := suite.model.GetAgentById("agent1", suite.commonInfo)
+	assert.NoError(suite.T(), err, "should get agent")
+	windows, err = suite.model.GetAgentMaintenanceWindows(untagged)
+	assert.NoError(suite.T(), err, "should get agent maintenance windows")
+	assert.Equal(suite.T(), 0, len(windows), "agents without windows aren't restricted")
+
+	suite.addWindow("Site", maintenance.TargetSite, suite.siteID)
+	windows, err = suite.model.GetAgentMaintenanceWindows(tagged)
+	assert.NoError(suite.T(), err, "should get agent maintenance windows")
+	assert.Equal(suite.T(), 2, len(windows), "windows of the site and the tags are combined")
+
+	err = suite.model.SetMaintenanceWindowEnabled(w.ID, suite.tenantID, false)
+	assert.NoError(suite.T(), err, "should disable maintenance window")
+	windows, err = suite.model.GetAgentMaintenanceWindows(tagged)
+	assert.NoError(suite.T(), err, "should get agent maintenance windows")
+	assert.Equal(suite.T(), 1, len(windows), "disabled windows are ignored")
+}
+
+func (suite *MaintenanceTestSuite) TestMaintenanceQueue() {
+	now := time.Now()
+	suite.queueAction("agent0", maintenance.ActionReboot, now.Add(-time.Minute))
+	suite.queueAction("agent1", maintenance.ActionInstall, now.Add(time.Hour))
+
+	due, err := suite.model.GetDueMaintenanceActions(now, 10)
+	assert.NoError(suite.T(), err, "should get due actions")
+	assert.Equal(suite.T(), 1, len(due))
+	assert.Equal(suite.T(), "agent0", due[0].AgentID)
+	assert.Equal(suite.T(), maintenance.StatusQueued, due[0].Status)
+
+	err = suite.model.RescheduleMaintenanceAction(due[0].ID, now.Add(2*time.Hour))
+	assert.NoError(suite.T(), err, "should reschedule action")
+
+	due, err = suite.model.GetDueMaintenanceActions(now.Add(3*time.Hour), 10)
+	assert.NoError(suite.T(), err, "should get due actions")
+	assert.Equal(suite.T(), 2, len(due))
+	assert.Equal(suite.T(), "agent1", due[0].AgentID, "the action that waits the longest goes first")
+
+	claimed, err := suite.model.ClaimMaintenanceAction(due[0].ID)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), claimed)
+
+	claimed, err = suite.model.ClaimMaintenanceAction(due[0].ID)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), claimed, "an action must be sent only once")
+
+	err = suite.model.FailMaintenanceAction(due[0].ID, "timeout")
+	assert.NoError(suite.T(), err, "should save the error")
+
+	failed, err := suite.model.GetMaintenanceAction(due[0].ID, suite.commonInfo)
+	assert.NoError(suite.T(), err, "should get action")
+	assert.Equal(suite.T(), maintenance.StatusFailed, failed.Status)
+	assert.Equal(suite.T(), "timeout", failed.Error)
+
+	err = suite.model.CancelMaintenanceAction(due[0].ID, suite.commonInfo)
+	assert.ErrorIs(suite.T(), err, ErrMaintenanceActionNotFound, "only queued actions can be cancelled")
+
+	otherSite := &partials.CommonInfo{TenantID: suite.commonInfo.TenantID, SiteID: strconv.Itoa(suite.siteID + 1)}
+	err = suite.model.CancelMaintenanceAction(due[1].ID, otherSite)
+	assert.ErrorIs(suite.T(), err, ErrMaintenanceActionNotFound, "actions of other sites can't be cancelled")
+
+	err = suite.model.CancelMaintenanceAction(due[1].ID, suite.commonInfo)
+	assert.NoError(suite.T(), err, "should cancel action")
+
+	due, err = suite.model.GetDueMaintenanceActions(now.Add(3*time.Hour), 10)
+	assert.NoError(suite.T(), err, "should get due actions")
+	assert.Equal(suite.T(), 0, len(due))
+}
+
+func (suite *MaintenanceTestSuite) TestGetMaintenanceActionsByPage() {
+	now := time.Now()
+	suite.queueAction("agent0", maintenance.ActionReboot, now.Add(time.Hour))
+	suite.queueAction("agent0", maintenance.ActionAgentUpdate, now.Add(2*time.Hour))
+	suite.queueAction("agent1", maintenance.ActionReboot, now.Add(3*time.Hour))
+
+	count, err := suite.model.CountMaintenanceActions(filters.MaintenanceQueueFilter{}, suite.commonInfo)
+	assert.NoError(suite.T(), err, "should count actions")
+	assert.Equal(suite.T(), 3, count)
+
+	p := partials.PaginationAndSort{CurrentPage: 1, PageSize: 2, SortBy: "not_before", SortOrder: "desc"}
+	items, err := suite.model.GetMaintenanceActionsByPage(p, filters.MaintenanceQueueFilter{}, suite.commonInfo)
+	assert.NoError(suite.T(), err, "should get actions by page")
+	assert.Equal(suite.T(), 2, len(items))
+	assert.Equal(suite.T(), "agent1", items[0].AgentID)
+
+	f := filters.MaintenanceQueueFilter{Hostname: "host0", Actions: []string{maintenance.ActionReboot}}
+	count, err = suite.model.CountMaintenanceActions(f, suite.commonInfo)
+	assert.NoError(suite.T(), err, "should count actions")
+	assert.Equal(suite.T(), 1, count)
+
+	f = filters.MaintenanceQueueFilter{Statuses: []string{maintenance.StatusSent}}
+	count, err = suite.model.CountMaintenanceActions(f, suite.commonInfo)
+	assert.NoError(suite.T(), err, "should count actions")
+	assert.Equal(suite.T(), 0, count)
+
+	otherTenant := &partials.CommonInfo{TenantID: strconv.Itoa(suite.tenantID + 1), SiteID: "-1"}
+	count, err = suite.model.CountMaintenanceActions(filters.MaintenanceQueueFilter{}, otherTenant)
+	assert.NoError(suite.T(), err, "should count actions")
+	assert.Equal(suite.T(), 0, count)
+}
+
+func TestMaintenanceTestSuite(t *testing.T) {
+	suite.Run(t, new(MaintenanceTestSuite))
+}
@@ -8,6 +8,7 @@ import (
 	ent "github.com/open-uem/ent"
 	"github.com/open-uem/ent/agent"
 	"github.com/open-uem/ent/metadata"
+	"github.com/open-uem/ent/orgmetadata"
 	"github.com/open-uem/ent/site"
 	"github.com/open-uem/ent/tenant"
 	"github.com/open-uem/openuem-console/internal/views/partials"
@@ -58,3 +59,29 @@ func (m *Model) CountMetadataForAgent(agentId string, c *partials.CommonInfo) (i
 func (m *Model) SaveMetadata(agentId string, metadataId int, value string) error {
 	return m.Client.Metadata.Create().SetOwnerID(agentId).SetOrgID(metadataId).SetValue(value).OnConflict(sql.ConflictColumns(metadata.OwnerColumn, metadata.OrgColumn)).UpdateNewValues().Exec(context.Background())
 }
+
+// GetMetadataValues returns the values of the metadata fields for the agents, by agent and
+// metadata field id. Agents without a value for a field have no entry for it
+func (m *Model) GetMetadataValues(agentIDs []string, orgMetadataIDs []int) (map[string]map[int]string, error) {
+	values := map[string]map[int]string{}
+	if len(agentIDs) == 0 || len(orgMetadataIDs) == 0 {
+		return values, nil
+	}
+
+	data, err := m.Client.Metadata.Query().WithOrg().WithOwner().Where(metadata.HasOwnerWith(agent.IDIn(agentIDs...)), metadata.HasOrgWith(orgmetadata.IDIn(orgMetadataIDs...))).All(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, d := range data {
+		if d.Edges.Owner == nil || d.Edges.Org == nil {
+			continue
+		}
+		if _, ok := values[d.Edges.Owner.ID]; !ok {
+			values[d.Edges.Owner.ID] = map[int]string{}
+		}
+		values[d.Edges.Owner.ID][d.Edges.Org.ID] = d.Value
+	}
+
+	return values, nil
+}
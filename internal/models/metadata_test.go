@@ -90,6 +90,16 @@ func (suite *MetadataTestSuite) TestSaveMetadata() {
 	assert.Equal(suite.T(), 8, count, "should count 8 metadata")
 }
 
+func (suite *MetadataTestSuite) TestGetMetadataValues() {
+	values, err := suite.model.GetMetadataValues([]string{"agent1", "agent2"}, []int{suite.orgs[0], suite.orgs[7]})
+	assert.NoError(suite.T(), err, "should get metadata values")
+	assert.Equal(suite.T(), map[string]map[int]string{"agent1": {suite.orgs[0]: "value0"}}, values)
+
+	values, err = suite.model.GetMetadataValues([]string{"agent1"}, nil)
+	assert.NoError(suite.T(), err, "should get metadata values")
+	assert.Empty(suite.T(), values)
+}
+
 func TestMetadataTestSuite(t *testing.T) {
 	suite.Run(t, new(MetadataTestSuite))
 }
@@ -22,6 +22,8 @@ import (
 
 type Model struct {
 	Client *ent.Client
+	// Driver is used to query the tables owned by the console, see console_schema.go
+	Driver *entsql.Driver
 }
 
 func New(dbUrl string, driverName, domain string) (*Model, error) {
@@ -36,7 +38,8 @@ func New(dbUrl string, driverName, domain string) (*Model, error) {
 		if err != nil {
 			return nil, fmt.Errorf("could not connect with Postgres database: %v", err)
 		}
-		model.Client = ent.NewClient(ent.Driver(entsql.OpenDB(dialect.Postgres, db)))
+		model.Driver = entsql.OpenDB(dialect.Postgres, db)
+		model.Client = ent.NewClient(ent.Driver(model.Driver))
 	default:
 		return nil, fmt.Errorf("unsupported DB driver")
 	}
@@ -51,6 +54,10 @@ func New(dbUrl string, driverName, domain string) (*Model, error) {
 		}
 	}
 
+	if err := model.CreateConsoleTables(); err != nil {
+		return nil, fmt.Errorf("could not create console tables: %v", err)
+	}
+
 	return &model, nil
 }
 
@@ -1,8 +1,14 @@
 package models
 
 import (
-	_ "github.com/mattn/go-sqlite3"
+	"context"
+	"testing"
 
+	"entgo.io/ent/dialect"
+	entsql "entgo.io/ent/dialect/sql"
+	_ "github.com/mattn/go-sqlite3"
+	ent "github.com/open-uem/ent"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -10,6 +16,25 @@ type ModelTestSuite struct {
 	suite.Suite
 }
 
+// newTestModel opens an in-memory database with the shared schema and the tables owned by the console
+func newTestModel(t *testing.T) Model {
+	drv, err := entsql.Open(dialect.SQLite, "file:ent?mode=memory&_fk=1")
+	assert.NoError(t, err, "should open in-memory database")
+
+	// every connection to an in-memory database gets its own database
+	drv.DB().SetMaxOpenConns(1)
+
+	client := ent.NewClient(ent.Driver(drv))
+	err = client.Schema.Create(context.Background())
+	assert.NoError(t, err, "should create schema")
+
+	m := Model{Client: client, Driver: drv}
+	err = m.CreateConsoleTables()
+	assert.NoError(t, err, "should create console tables")
+
+	return m
+}
+
 // func (suite *ModelTestSuite) TestNewModel() {
 // 	 "sqlite3", "file:ent?mode=memory&_fk=1"
 
@@ -0,0 +1,230 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	entsql "entgo.io/ent/dialect/sql"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/rbac"
+)
+
+var ErrOIDCRoleMappingNotFound = errors.New("the claim mapping doesn't exist")
+
+var oidcRoleMappingColumns = []string{"id", "value", "role", "tenant_id", "site_id", "created"}
+
+// GetOIDCClaimSettings returns the claim mapping settings, the mapping is disabled and
+// reads the groups claim until it's configured
+func (m *Model) GetOIDCClaimSettings() (consoledb.OIDCClaimSettings, error) {
+	s := consoledb.OIDCClaimSettings{Claim: "groups"}
+
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Select("enabled", "claim", "deny_unmapped").
+		From(entsql.Table(consoledb.OIDCClaimSettingsTable.Name)).
+		Limit(1).
+		Query()
+
+	if err := m.Driver.DB().QueryRowContext(context.Background(), query, args...).Scan(&s.Enabled, &s.Claim, &s.DenyUnmapped); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return s, nil
+		}
+		return s, err
+	}
+
+	return s, nil
+}
+
+// SaveOIDCClaimSettings updates the settings row, creating it the first time
+func (m *Model) SaveOIDCClaimSettings(s consoledb.OIDCClaimSettings) error {
+	ctx := context.Background()
+
+	tx, err := m.Driver.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Update(consoledb.OIDCClaimSettingsTable.Name).
+		Set("enabled", s.Enabled).
+		Set("claim", strings.TrimSpace(s.Claim)).
+		Set("deny_unmapped", s.DenyUnmapped).
+		Query()
+
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		query, args = entsql.Dialect(m.Driver.Dialect()).
+			Insert(consoledb.OIDCClaimSettingsTable.Name).
+			Columns("enabled", "claim", "deny_unmapped").
+			Values(s.Enabled, strings.TrimSpace(s.Claim), s.DenyUnmapped).
+			Query()
+
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// AddOIDCRoleMapping grants a role to the users that have the value in the claim, tenantID and siteID
+// can be -1 to grant the role in every tenant or site. As with the role assignments, the global admin
+// role can only be granted in every tenant
+func (m *Model) AddOIDCRoleMapping(value string, role rbac.Role, tenantID int, siteID int) error {
+	if !role.IsValid() {
+		return ErrInvalidRole
+	}
+
+	if role == rbac.RoleGlobalAdmin {
+		tenantID = -1
+	}
+
+	if tenantID == -1 {
+		siteID = -1
+	}
+
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Insert(consoledb.OIDCRoleMappingsTable.Name).
+		Columns("value", "role", "tenant_id", "site_id", "created").
+		Values(strings.TrimSpace(value), string(role), tenantID, siteID, time.Now()).
+		Query()
+
+	_, err := m.Driver.DB().ExecContext(context.Background(), query, args...)
+	return err
+}
+
+func (m *Model) GetOIDCRoleMappings() ([]consoledb.OIDCRoleMapping, error) {
+	return m.queryOIDCRoleMappings(func(s *entsql.Selector) {
+		s.OrderBy(entsql.Asc("value"), entsql.Asc("role"), entsql.Asc("tenant_id"), entsql.Asc("site_id"))
+	})
+}
+
+func (m *Model) GetOIDCRoleMapping(id int) (consoledb.OIDCRoleMapping, error) {
+	mappings, err := m.queryOIDCRoleMappings(func(s *entsql.Selector) {
+		s.Where(entsql.EQ("id", id))
+	})
+	if err != nil {
+		return consoledb.OIDCRoleMapping{}, err
+	}
+
+	if len(mappings) != 1 {
+		return consoledb.OIDCRoleMapping{}, ErrOIDCRoleMappingNotFound
+	}
+
+	return mappings[0], nil
+}
+
+func (m *Model) DeleteOIDCRoleMapping(id int) error {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Delete(consoledb.OIDCRoleMappingsTable.Name).
+		Where(entsql.EQ("id", id)).
+		Query()
+
+	return m.execAffectingOne(query, args, ErrOIDCRoleMappingNotFound)
+}
+
+// GetOIDCRoleMappingsForValues returns the mappings of the values found in the claim of a user
+func (m *Model) GetOIDCRoleMappingsForValues(values []string) ([]consoledb.OIDCRoleMapping, error) {
+	if len(values) == 0 {
+		return []consoledb.OIDCRoleMapping{}, nil
+	}
+
+	args := []any{}
+	for _, v := range values {
+		args = append(args, v)
+	}
+
+	return m.queryOIDCRoleMappings(func(s *entsql.Selector) {
+		s.Where(entsql.In("value", args...)).OrderBy(entsql.Asc("id"))
+	})
+}
+
+// SyncOIDCRoleAssignments makes the roles granted by the claim mapping match the mappings of the
+// user. Missing roles are added, the roles previously granted by the mapping that the user no longer
+// has are removed and the roles assigned by an admin are never touched. The last global admin is kept
+// so the identity provider can't lock everyone out of the console
+func (m *Model) SyncOIDCRoleAssignments(userID string, mappings []consoledb.OIDCRoleMapping) (added []consoledb.RoleAssignment, removed []consoledb.RoleAssignment, err error) {
+	current, err := m.GetRoleAssignmentsForUser(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	wanted := []consoledb.RoleAssignment{}
+	for _, mapping := range mappings {
+		a := consoledb.RoleAssignment{UserID: userID, Role: mapping.Role, TenantID: mapping.TenantID, SiteID: mapping.SiteID, Source: consoledb.RoleSourceOIDC}
+		if !slices.ContainsFunc(wanted, func(w consoledb.RoleAssignment) bool { return sameRoleAssignment(w, a) }) {
+			wanted = append(wanted, a)
+		}
+	}
+
+	for _, w := range wanted {
+		if slices.ContainsFunc(current, func(a consoledb.RoleAssignment) bool { return sameRoleAssignment(a, w) }) {
+			continue
+		}
+
+		if err := m.addRoleAssignment(userID, rbac.Role(w.Role), w.TenantID, w.SiteID, consoledb.RoleSourceOIDC); err != nil {
+			return added, removed, err
+		}
+		added = append(added, w)
+	}
+
+	for _, a := range current {
+		if a.Source != consoledb.RoleSourceOIDC || slices.ContainsFunc(wanted, func(w consoledb.RoleAssignment) bool { return sameRoleAssignment(a, w) }) {
+			continue
+		}
+
+		if err := m.DeleteRoleAssignment(a.ID); err != nil {
+			if errors.Is(err, ErrLastGlobalAdmin) {
+				log.Printf("[WARN]: the global admin role of %s is no longer mapped but it's kept as there are no other global admins", userID)
+				continue
+			}
+			return added, removed, err
+		}
+		removed = append(removed, a)
+	}
+
+	return added, removed, nil
+}
+
+func sameRoleAssignment(a, b consoledb.RoleAssignment) bool {
+	return a.Role == b.Role && a.TenantID == b.TenantID && a.SiteID == b.SiteID
+}
+
+func (m *Model) queryOIDCRoleMappings(modifier func(s *entsql.Selector)) ([]consoledb.OIDCRoleMapping, error) {
+	selector := entsql.Dialect(m.Driver.Dialect()).
+		Select(oidcRoleMappingColumns...).
+		From(entsql.Table(consoledb.OIDCRoleMappingsTable.Name))
+	modifier(selector)
+
+	query, args := selector.Query()
+	rows, err := m.Driver.DB().QueryContext(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	mappings := []consoledb.OIDCRoleMapping{}
+	for rows.Next() {
+		var r consoledb.OIDCRoleMapping
+		if err := rows.Scan(&r.ID, &r.Value, &r.Role, &r.TenantID, &r.SiteID, &r.Created); err != nil {
+			return nil, err
+		}
+		mappings = append(mappings, r)
+	}
+
+	return mappings, rows.Err()
+}
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/rbac"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type OIDCMappingsTestSuite struct {
+	suite.Suite
+	model Model
+}
+
+func (suite *OIDCMappingsTestSuite) SetupTest() {
+	suite.model = newTestModel(suite.T())
+}
+
+func (suite *OIDCMappingsTestSuite) TestOIDCClaimSettings() {
+	s, err := suite.model.GetOIDCClaimSettings()
+	assert.NoError(suite.T(), err, "should get default settings")
+	assert.False(suite.T(), s.Enabled, "claim mapping should be disabled by default")
+	assert.Equal(suite.T(), "groups", s.Claim, "groups claim should be used by default")
+
+	err = suite.model.SaveOIDCClaimSettings(consoledb.OIDCClaimSettings{Enabled: true, Claim: " realm_access.roles ", DenyUnmapped: true})
+	assert.NoError(suite.T(), err, "should save settings")
+
+	err = suite.model.SaveOIDCClaimSettings(consoledb.OIDCClaimSettings{Enabled: true, Claim: "realm_access.roles", DenyUnmapped: false})
+	assert.NoError(suite.T(), err, "should update settings")
+
+	s, err = suite.model.GetOIDCClaimSettings()
+	assert.NoError(suite.T(), err, "should get settings")
+	assert.True(suite.T(), s.Enabled)
+	assert.Equal(suite.T(), "realm_access.roles", s.Claim)
+	assert.False(suite.T(), s.DenyUnmapped)
+}
+
+func (suite *OIDCMappingsTestSuite) TestOIDCRoleMappings() {
+	err := suite.model.AddOIDCRoleMapping("admins", rbac.Role("superuser"), -1, -1)
+	assert.ErrorIs(suite.T(), err, ErrInvalidRole, "should not map unknown roles")
+
+	err = suite.model.AddOIDCRoleMapping("admins", rbac.RoleGlobalAdmin, 2, 3)
+	assert.NoError(suite.T(), err, "should add mapping")
+
+	err = suite.model.AddOIDCRoleMapping("support", rbac.RoleHelpdesk, 2, 3)
+	assert.NoError(suite.T(), err, "should add mapping")
+
+	err = suite.model.AddOIDCRoleMapping("support", rbac.RoleHelpdesk, 2, 3)
+	assert.Error(suite.T(), err, "should not add the same mapping twice")
+
+	mappings, err := suite.model.GetOIDCRoleMappings()
+	assert.NoError(suite.T(), err, "should get mappings")
+	assert.Equal(suite.T(), 2, len(mappings))
+	assert.Equal(suite.T(), -1, mappings[0].TenantID, "global admin should be mapped in every tenant")
+	assert.Equal(suite.T(), -1, mappings[0].SiteID, "global admin should be mapped in every site")
+
+	mappings, err = suite.model.GetOIDCRoleMappingsForValues([]string{"support", "it"})
+	assert.NoError(suite.T(), err, "should get mappings for values")
+	assert.Equal(suite.T(), 1, len(mappings))
+	assert.Equal(suite.T(), string(rbac.RoleHelpdesk), mappings[0].Role)
+
+	mappings, err = suite.model.GetOIDCRoleMappingsForValues([]string{})
+	assert.NoError(suite.T(), err, "should get mappings for no values")
+	assert.Empty(suite.T(), mappings)
+
+	mapping, err := suite.model.GetOIDCRoleMapping(1)
+	assert.NoError(suite.T(), err, "should get mapping")
+	assert.Equal(suite.T(), "admins", mapping.Value)
+
+	err = suite.model.DeleteOIDCRoleMapping(1)
+	assert.NoError(suite.T(), err, "should delete mapping")
+
+	err = suite.model.DeleteOIDCRoleMapping(1)
+	assert.ErrorIs(suite.T(), err, ErrOIDCRoleMappingNotFound, "should not delete a missing mapping")
+}
+
+func (suite *OIDCMappingsTestSuite) TestSyncOIDCRoleAssignments() {
+	err := suite.model.AddRoleAssignment("jdoe", rbac.RoleAuditor, -1, -1)
+	assert.NoError(suite.T(), err, "should add role assignment")
+
+	helpdesk := consoledb.OIDCRoleMapping{Role: string(rbac.RoleHelpdesk), TenantID: 2, SiteID: -1}
+	auditor := consoledb.OIDCRoleMapping{Role: string(rbac.RoleAuditor), TenantID: -1, SiteID: -1}
+	admin := consoledb.OIDCRoleMapping{Role: string(rbac.RoleGlobalAdmin), TenantID: -1, SiteID: -1}
+
+	added, removed, err := suite.model.SyncOIDCRoleAssignments("jdoe", []consoledb.OIDCRoleMapping{helpdesk, helpdesk, auditor, admin})
+	assert.NoError(suite.T(), err, "should sync roles")
+	assert.Equal(suite.T(), 2, len(added), "should add the missing roles once")
+	assert.Empty(suite.T(), removed)
+
+	assignments, err := suite.model.GetRoleAssignmentsForUser("jdoe")
+	assert.NoError(suite.T(), err, "should get role assignments")
+	assert.Equal(suite.T(), 3, len(assignments))
+
+	// the helpdesk role is no longer mapped, the role assigned by an admin is kept even
+	// if it's not mapped and the global admin is kept as there's no other global admin
+	added, removed, err = suite.model.SyncOIDCRoleAssignments("jdoe", []consoledb.OIDCRoleMapping{})
+	assert.NoError(suite.T(), err, "should sync roles")
+	assert.Empty(suite.T(), added)
+	assert.Equal(suite.T(), 1, len(removed))
+	assert.Equal(suite.T(), string(rbac.RoleHelpdesk), removed[0].Role)
+
+	assignments, err = suite.model.GetRoleAssignmentsForUser("jdoe")
+	assert.NoError(suite.T(), err, "should get role assignments")
+	assert.Equal(suite.T(), 2, len(assignments))
+	for _, a := range assignments {
+		if a.Role == string(rbac.RoleAuditor) {
+			assert.Equal(suite.T(), "", a.Source, "role assigned by an admin should keep its source")
+		} else {
+			assert.Equal(suite.T(), consoledb.RoleSourceOIDC, a.Source)
+		}
+	}
+}
+
+func TestOIDCMappingsTestSuite(t *testing.T) {
+	suite.Run(t, new(OIDCMappingsTestSuite))
+}
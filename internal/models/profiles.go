@@ -11,6 +11,7 @@ import (
 	"github.com/open-uem/ent/site"
 	"github.com/open-uem/ent/task"
 	"github.com/open-uem/ent/tenant"
+	"github.com/open-uem/openuem-console/internal/rbac"
 	"github.com/open-uem/openuem-console/internal/views/partials"
 )
 
@@ -118,6 +119,33 @@ func (m *Model) GetProfileById(profileId int, c *partials.CommonInfo) (*ent.Prof
 	return m.Client.Profile.Query().WithTags().WithTasks().WithIssues().Where(profile.ID(profileId)).First(context.Background())
 }
 
+// GetProfileByIdAndScope returns the profile only if it belongs to the tenant and site in the common info
+func (m *Model) GetProfileByIdAndScope(profileId int, c *partials.CommonInfo) (*ent.Profile, error) {
+	query := m.Client.Profile.Query().WithTags().WithTasks().Where(profile.ID(profileId))
+
+	siteID, err := strconv.Atoi(c.SiteID)
+	if err != nil {
+		return nil, err
+	}
+
+	tenantID, err := strconv.Atoi(c.TenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if tenantID == -1 {
+		query = query.Where(profile.And(profile.Not(profile.HasSite()), profile.Not(profile.HasTenant())))
+	} else {
+		if siteID == -1 {
+			query = query.Where(profile.HasTenantWith(tenant.ID(tenantID)), profile.Not(profile.HasSite()))
+		} else {
+			query = query.Where(profile.HasSiteWith(site.ID(siteID), site.HasTenantWith(tenant.ID(tenantID))))
+		}
+	}
+
+	return query.Only(context.Background())
+}
+
 func (m *Model) DeleteProfile(profileID int, c *partials.CommonInfo) error {
 	_, err := m.Client.Task.Delete().Where(task.HasProfileWith(profile.ID(profileID))).Exec(context.Background())
 	if err != nil {
@@ -186,9 +214,29 @@ func (m *Model) SetProfileAsTenantProfile(profiledID int, tenantID int) error {
 	return m.Client.Profile.Update().AddTenantIDs(tenantID).ClearSite().Where(profile.ID(profiledID)).Exec(context.Background())
 }
 
-// TODO-Steve we should check which profiles can be listed based on user's role
-func (m *Model) GetAllProfiles() ([]*ent.Profile, error) {
-	return m.Client.Profile.Query().All(context.Background())
+// GetAllProfiles returns the profiles the user's role allows to manage
+func (m *Model) GetAllProfiles(c *partials.CommonInfo) ([]*ent.Profile, error) {
+	profiles, err := m.Client.Profile.Query().WithTenant().WithSite().All(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := []*ent.Profile{}
+	for _, p := range profiles {
+		tenantID := -1
+		siteID := -1
+		if len(p.Edges.Tenant) > 0 {
+			tenantID = p.Edges.Tenant[0].ID
+		}
+		if len(p.Edges.Site) > 0 {
+			siteID = p.Edges.Site[0].ID
+		}
+		if c.Access.Level(tenantID, siteID) >= rbac.PermissionManage {
+			allowed = append(allowed, p)
+		}
+	}
+
+	return allowed, nil
 }
 
 func (m *Model) CloneProfile(profileID int, description string, tenantID int, siteID int) error {
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/open-uem/ent/enttest"
+	"github.com/open-uem/ent/task"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type ProfilesTestSuite struct {
+	suite.Suite
+	t          enttest.TestingT
+	model      Model
+	tenantID   int
+	siteID     int
+	commonInfo *partials.CommonInfo
+}
+
+func (suite *ProfilesTestSuite) SetupTest() {
+	client := enttest.Open(suite.t, "sqlite3", "file:ent?mode=memory&_fk=1")
+	suite.model = Model{Client: client}
+
+	t, err := suite.model.CreateDefaultTenant()
+	assert.NoError(suite.T(), err, "should create default tenant")
+	suite.tenantID = t.ID
+
+	s, err := suite.model.CreateDefaultSite(t)
+	assert.NoError(suite.T(), err, "should create default site")
+	suite.siteID = s.ID
+
+	suite.commonInfo = &partials.CommonInfo{TenantID: strconv.Itoa(t.ID), SiteID: strconv.Itoa(s.ID)}
+}
+
+func (suite *ProfilesTestSuite) TestGetProfileByIdAndScope() {
+	siteProfile, err := suite.model.AddProfile(suite.siteID, suite.tenantID, "site profile")
+	assert.NoError(suite.T(), err, "should add site profile")
+
+	tenantProfile, err := suite.model.AddProfile(-1, suite.tenantID, "tenant profile")
+	assert.NoError(suite.T(), err, "should add tenant profile")
+
+	globalProfile, err := suite.model.AddProfile(-1, -1, "global profile")
+	assert.NoError(suite.T(), err, "should add global profile")
+
+	err = suite.model.Client.Task.Create().SetName("task").SetType(task.TypeWingetInstall).SetProfileID(siteProfile.ID).Exec(context.Background())
+	assert.NoError(suite.T(), err, "should add task to site profile")
+
+	p, err := suite.model.GetProfileByIdAndScope(siteProfile.ID, suite.commonInfo)
+	assert.NoError(suite.T(), err, "should get site profile")
+	assert.Equal(suite.T(), "site profile", p.Name)
+	assert.Equal(suite.T(), 1, len(p.Edges.Tasks), "should load profile tasks")
+
+	_, err = suite.model.GetProfileByIdAndScope(tenantProfile.ID, suite.commonInfo)
+	assert.Error(suite.T(), err, "tenant profile should not be visible from site")
+
+	tenantInfo := &partials.CommonInfo{TenantID: strconv.Itoa(suite.tenantID), SiteID: "-1"}
+	p, err = suite.model.GetProfileByIdAndScope(tenantProfile.ID, tenantInfo)
+	assert.NoError(suite.T(), err, "should get tenant profile")
+	assert.Equal(suite.T(), "tenant profile", p.Name)
+
+	_, err = suite.model.GetProfileByIdAndScope(siteProfile.ID, tenantInfo)
+	assert.Error(suite.T(), err, "site profile should not be visible from tenant")
+
+	globalInfo := &partials.CommonInfo{TenantID: "-1", SiteID: "-1"}
+	p, err = suite.model.GetProfileByIdAndScope(globalProfile.ID, globalInfo)
+	assert.NoError(suite.T(), err, "should get global profile")
+	assert.Equal(suite.T(), "global profile", p.Name)
+
+	_, err = suite.model.GetProfileByIdAndScope(tenantProfile.ID, globalInfo)
+	assert.Error(suite.T(), err, "tenant profile should not be visible globally")
+}
+
+func TestProfilesTestSuite(t *testing.T) {
+	suite.Run(t, new(ProfilesTestSuite))
+}
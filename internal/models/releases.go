@@ -8,6 +8,7 @@ import (
 	"github.com/open-uem/ent/agent"
 	"github.com/open-uem/ent/release"
 	openuem_nats "github.com/open-uem/nats"
+	"github.com/open-uem/openuem-console/internal/views/partials"
 	"golang.org/x/mod/semver"
 )
 
@@ -122,6 +123,34 @@ func (m *Model) CountUpgradableAgents(version string) (int, error) {
 	return count, nil
 }
 
+// CountOutdatedAgentsInScope counts the admitted agents of the tenant, or of the site if one
+// is selected, running an older release than the newest agent release installed
+func (m *Model) CountOutdatedAgentsInScope(c *partials.CommonInfo) (int, error) {
+	release, err := m.GetHigherAgentReleaseInstalled()
+	if err != nil || release == nil {
+		return 0, err
+	}
+
+	predicates, err := admittedAgentsPredicates(c)
+	if err != nil {
+		return 0, err
+	}
+
+	data, err := m.Client.Agent.Query().WithRelease().Where(predicates...).All(context.Background())
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, item := range data {
+		if item.Edges.Release != nil && semver.Compare("v"+item.Edges.Release.Version, "v"+release.Version) < 0 {
+			count += 1
+		}
+	}
+
+	return count, nil
+}
+
 func (m *Model) SaveNewReleaseAvailable(releaseType release.ReleaseType, newRelease openuem_nats.OpenUEMRelease) error {
 	for _, file := range newRelease.Files {
 		exists, err := m.Client.Release.Query().Where(release.ReleaseTypeEQ(releaseType), release.Os(file.Os), release.Arch(file.Arch), release.Version(newRelease.Version)).Exist(context.Background())
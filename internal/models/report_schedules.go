@@ -0,0 +1,146 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	entsql "entgo.io/ent/dialect/sql"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+)
+
+var ErrReportScheduleNotFound = errors.New("the scheduled report doesn't exist")
+
+var reportScheduleColumns = []string{"id", "tenant_id", "site_id", "name", "report", "format", "filters", "sort_by", "sort_order", "cron", "delivery", "recipients", "folder", "language", "enabled", "last_run", "last_status", "last_error", "created_by", "created"}
+
+func (m *Model) AddReportSchedule(r consoledb.ReportSchedule) error {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Insert(consoledb.ReportSchedulesTable.Name).
+		Columns("tenant_id", "site_id", "name", "report", "format", "filters", "sort_by", "sort_order", "cron", "delivery", "recipients", "folder", "language", "enabled", "created_by", "created").
+		Values(r.TenantID, r.SiteID, r.Name, r.Report, r.Format, r.Filters, r.SortBy, r.SortOrder, r.Cron, r.Delivery, strings.Join(r.Recipients, ","), r.Folder, r.Language, r.Enabled, r.CreatedBy, time.Now()).
+		Query()
+
+	_, err := m.Driver.DB().ExecContext(context.Background(), query, args...)
+	return err
+}
+
+// GetReportSchedules returns the scheduled reports of a tenant, including those restricted to a site
+func (m *Model) GetReportSchedules(tenantID int) ([]consoledb.ReportSchedule, error) {
+	return m.queryReportSchedules(func(s *entsql.Selector) {
+		s.Where(entsql.EQ("tenant_id", tenantID)).OrderBy(entsql.Asc("name"))
+	})
+}
+
+// GetEnabledReportSchedules returns the enabled scheduled reports of every tenant
+func (m *Model) GetEnabledReportSchedules() ([]consoledb.ReportSchedule, error) {
+	return m.queryReportSchedules(func(s *entsql.Selector) {
+		s.Where(entsql.EQ("enabled", true)).OrderBy(entsql.Asc("id"))
+	})
+}
+
+func (m *Model) GetReportSchedule(id int, tenantID int) (consoledb.ReportSchedule, error) {
+	schedules, err := m.queryReportSchedules(func(s *entsql.Selector) {
+		s.Where(entsql.And(entsql.EQ("id", id), entsql.EQ("tenant_id", tenantID)))
+	})
+	if err != nil {
+		return consoledb.ReportSchedule{}, err
+	}
+
+	if len(schedules) != 1 {
+		return consoledb.ReportSchedule{}, ErrReportScheduleNotFound
+	}
+
+	return schedules[0], nil
+}
+
+func (m *Model) SetReportScheduleEnabled(id int, tenantID int, enabled bool) error {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Update(consoledb.ReportSchedulesTable.Name).
+		Set("enabled", enabled).
+		Where(entsql.And(entsql.EQ("id", id), entsql.EQ("tenant_id", tenantID))).
+		Query()
+
+	return m.execAffectingOne(query, args, ErrReportScheduleNotFound)
+}
+
+func (m *Model) DeleteReportSchedule(id int, tenantID int) error {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Delete(consoledb.ReportSchedulesTable.Name).
+		Where(entsql.And(entsql.EQ("id", id), entsql.EQ("tenant_id", tenantID))).
+		Query()
+
+	return m.execAffectingOne(query, args, ErrReportScheduleNotFound)
+}
+
+// ClaimReportSchedule marks the scheduled report as run now if it hasn't run in the last
+// interval. It returns false if another console instance has already generated it
+func (m *Model) ClaimReportSchedule(id int, interval time.Duration) (bool, error) {
+	now := time.Now()
+
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Update(consoledb.ReportSchedulesTable.Name).
+		Set("last_run", now).
+		Where(entsql.And(
+			entsql.EQ("id", id),
+			entsql.Or(entsql.IsNull("last_run"), entsql.LTE("last_run", now.Add(-interval))),
+		)).
+		Query()
+
+	result, err := m.Driver.DB().ExecContext(context.Background(), query, args...)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n == 1, nil
+}
+
+func (m *Model) SaveReportScheduleResult(id int, status string, errMessage string) error {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Update(consoledb.ReportSchedulesTable.Name).
+		Set("last_status", status).
+		Set("last_error", truncate(errMessage, 2000)).
+		Where(entsql.EQ("id", id)).
+		Query()
+
+	return m.execAffectingOne(query, args, ErrReportScheduleNotFound)
+}
+
+func (m *Model) queryReportSchedules(modifier func(s *entsql.Selector)) ([]consoledb.ReportSchedule, error) {
+	selector := entsql.Dialect(m.Driver.Dialect()).
+		Select(reportScheduleColumns...).
+		From(entsql.Table(consoledb.ReportSchedulesTable.Name))
+	modifier(selector)
+
+	query, args := selector.Query()
+	rows, err := m.Driver.DB().QueryContext(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := []consoledb.ReportSchedule{}
+	for rows.Next() {
+		var r consoledb.ReportSchedule
+		var recipients string
+		var lastRun sql.NullTime
+		if err := rows.Scan(&r.ID, &r.TenantID, &r.SiteID, &r.Name, &r.Report, &r.Format, &r.Filters, &r.SortBy, &r.SortOrder, &r.Cron, &r.Delivery, &recipients, &r.Folder, &r.Language, &r.Enabled, &lastRun, &r.LastStatus, &r.LastError, &r.CreatedBy, &r.Created); err != nil {
+			return nil, err
+		}
+		if recipients != "" {
+			r.Recipients = strings.Split(recipients, ",")
+		}
+		if lastRun.Valid {
+			r.LastRun = lastRun.Time
+		}
+		schedules = append(schedules, r)
+	}
+
+	return schedules, rows.Err()
+}
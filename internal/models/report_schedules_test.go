@@ -0,0 +1,93 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/reports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type ReportSchedulesTestSuite struct {
+	suite.Suite
+	model Model
+}
+
+func (suite *ReportSchedulesTestSuite) SetupTest() {
+	suite.model = newTestModel(suite.T())
+
+	schedules := []consoledb.ReportSchedule{
+		{TenantID: 1, SiteID: -1, Name: "weekly compliance", Report: reports.ReportAntivirus, Format: reports.FormatPDF, Filters: `{"AntivirusUpdatedOptions":["false"]}`, Cron: "0 7 * * 1", Delivery: reports.DeliveryEmail, Recipients: []string{"boss@example.com", "it@example.com"}, Language: "es", Enabled: true, CreatedBy: "admin"},
+		{TenantID: 1, SiteID: 2, Name: "daily agents", Report: reports.ReportAgents, Format: reports.FormatCSV, Filters: "{}", Cron: "@daily", Delivery: reports.DeliveryFolder, Folder: "agents", Language: "en", Enabled: false, CreatedBy: "admin"},
+		{TenantID: 2, SiteID: -1, Name: "monthly software", Report: reports.ReportSoftware, Format: reports.FormatPDF, Filters: "{}", Cron: "0 0 1 * *", Delivery: reports.DeliveryEmail, Recipients: []string{"it@example.com"}, Language: "en", Enabled: true, CreatedBy: "admin"},
+	}
+	for _, s := range schedules {
+		err := suite.model.AddReportSchedule(s)
+		assert.NoError(suite.T(), err, "should add scheduled report")
+	}
+}
+
+func (suite *ReportSchedulesTestSuite) TestGetReportSchedules() {
+	schedules, err := suite.model.GetReportSchedules(1)
+	assert.NoError(suite.T(), err, "should get scheduled reports")
+	assert.Equal(suite.T(), 2, len(schedules), "should get the scheduled reports of the tenant")
+	assert.Equal(suite.T(), "daily agents", schedules[0].Name, "should sort by name")
+	assert.Equal(suite.T(), "agents", schedules[0].Folder)
+	assert.Equal(suite.T(), []string{"boss@example.com", "it@example.com"}, schedules[1].Recipients)
+	assert.Equal(suite.T(), `{"AntivirusUpdatedOptions":["false"]}`, schedules[1].Filters)
+	assert.Equal(suite.T(), "es", schedules[1].Language)
+	assert.True(suite.T(), schedules[1].LastRun.IsZero(), "should not have run yet")
+
+	enabled, err := suite.model.GetEnabledReportSchedules()
+	assert.NoError(suite.T(), err, "should get enabled scheduled reports")
+	assert.Equal(suite.T(), 2, len(enabled), "should get the enabled scheduled reports of every tenant")
+
+	_, err = suite.model.GetReportSchedule(schedules[0].ID, 2)
+	assert.ErrorIs(suite.T(), err, ErrReportScheduleNotFound, "should not get a scheduled report of another tenant")
+
+	err = suite.model.SetReportScheduleEnabled(schedules[0].ID, 1, true)
+	assert.NoError(suite.T(), err, "should enable scheduled report")
+
+	s, err := suite.model.GetReportSchedule(schedules[0].ID, 1)
+	assert.NoError(suite.T(), err, "should get scheduled report")
+	assert.True(suite.T(), s.Enabled, "should be enabled")
+
+	err = suite.model.DeleteReportSchedule(schedules[0].ID, 2)
+	assert.ErrorIs(suite.T(), err, ErrReportScheduleNotFound, "should not delete a scheduled report of another tenant")
+
+	err = suite.model.DeleteReportSchedule(schedules[0].ID, 1)
+	assert.NoError(suite.T(), err, "should delete scheduled report")
+
+	schedules, err = suite.model.GetReportSchedules(1)
+	assert.NoError(suite.T(), err, "should get scheduled reports")
+	assert.Equal(suite.T(), 1, len(schedules))
+}
+
+func (suite *ReportSchedulesTestSuite) TestClaimReportSchedule() {
+	schedules, err := suite.model.GetReportSchedules(2)
+	assert.NoError(suite.T(), err, "should get scheduled reports")
+	id := schedules[0].ID
+
+	claimed, err := suite.model.ClaimReportSchedule(id, time.Minute)
+	assert.NoError(suite.T(), err, "should claim scheduled report")
+	assert.True(suite.T(), claimed, "should claim a scheduled report that never ran")
+
+	claimed, err = suite.model.ClaimReportSchedule(id, time.Minute)
+	assert.NoError(suite.T(), err, "should claim scheduled report")
+	assert.False(suite.T(), claimed, "should not claim a scheduled report twice in the interval")
+
+	err = suite.model.SaveReportScheduleResult(id, consoledb.ReportScheduleFailed, "SMTP settings are not configured")
+	assert.NoError(suite.T(), err, "should save result")
+
+	s, err := suite.model.GetReportSchedule(id, 2)
+	assert.NoError(suite.T(), err, "should get scheduled report")
+	assert.False(suite.T(), s.LastRun.IsZero(), "should save when it ran")
+	assert.Equal(suite.T(), consoledb.ReportScheduleFailed, s.LastStatus)
+	assert.Equal(suite.T(), "SMTP settings are not configured", s.LastError)
+}
+
+func TestReportSchedulesTestSuite(t *testing.T) {
+	suite.Run(t, new(ReportSchedulesTestSuite))
+}
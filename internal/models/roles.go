@@ -0,0 +1,213 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	entsql "entgo.io/ent/dialect/sql"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/rbac"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+)
+
+var ErrInvalidRole = errors.New("the role is not valid")
+var ErrRoleAssignmentNotFound = errors.New("the role assignment doesn't exist")
+var ErrLastGlobalAdmin = errors.New("at least one user must keep the global admin role")
+
+var roleAssignmentColumns = []string{"id", "user_id", "role", "tenant_id", "site_id", "created", "source"}
+
+// AddRoleAssignment grants a role to a user, tenantID and siteID can be -1 to grant the role in every tenant or site.
+// The global admin role can only be granted in every tenant
+func (m *Model) AddRoleAssignment(userID string, role rbac.Role, tenantID int, siteID int) error {
+	return m.addRoleAssignment(userID, role, tenantID, siteID, "")
+}
+
+func (m *Model) addRoleAssignment(userID string, role rbac.Role, tenantID int, siteID int, source string) error {
+	if !role.IsValid() {
+		return ErrInvalidRole
+	}
+
+	if role == rbac.RoleGlobalAdmin {
+		tenantID = -1
+	}
+
+	if tenantID == -1 {
+		siteID = -1
+	}
+
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Insert(consoledb.RoleAssignmentsTable.Name).
+		Columns("user_id", "role", "tenant_id", "site_id", "created", "source").
+		Values(userID, string(role), tenantID, siteID, time.Now(), source).
+		Query()
+
+	_, err := m.Driver.DB().ExecContext(context.Background(), query, args...)
+	return err
+}
+
+// GetUserAccess returns the roles assigned to the user. If no role has been assigned to anyone yet, the
+// access is unrestricted so the console keeps working as it did before roles were introduced
+func (m *Model) GetUserAccess(userID string) (rbac.Access, error) {
+	count, err := m.CountAllRoleAssignments()
+	if err != nil {
+		return rbac.Access{}, err
+	}
+
+	if count == 0 {
+		return rbac.Access{Unrestricted: true}, nil
+	}
+
+	assignments, err := m.GetRoleAssignmentsForUser(userID)
+	if err != nil {
+		return rbac.Access{}, err
+	}
+
+	return rbac.Access{Assignments: assignments}, nil
+}
+
+func (m *Model) GetRoleAssignmentsForUser(userID string) ([]consoledb.RoleAssignment, error) {
+	return m.queryRoleAssignments(func(s *entsql.Selector) {
+		s.Where(entsql.EQ("user_id", userID)).OrderBy(entsql.Asc("tenant_id"), entsql.Asc("site_id"))
+	})
+}
+
+// GetGlobalRoleMembers returns the users that have the role in every tenant and site
+func (m *Model) GetGlobalRoleMembers(role rbac.Role) ([]consoledb.RoleAssignment, error) {
+	return m.queryRoleAssignments(func(s *entsql.Selector) {
+		s.Where(entsql.And(
+			entsql.EQ("role", string(role)),
+			entsql.EQ("tenant_id", -1),
+			entsql.EQ("site_id", -1),
+		)).OrderBy(entsql.Asc("user_id"))
+	})
+}
+
+func (m *Model) CountAllRoleAssignments() (int, error) {
+	var count int
+
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Select(entsql.Count("*")).
+		From(entsql.Table(consoledb.RoleAssignmentsTable.Name)).
+		Query()
+
+	if err := m.Driver.DB().QueryRowContext(context.Background(), query, args...).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (m *Model) GetRoleAssignmentsByPage(p partials.PaginationAndSort) ([]consoledb.RoleAssignment, error) {
+	column := "user_id"
+	switch p.SortBy {
+	case "uid":
+		column = "user_id"
+	case "role":
+		column = "role"
+	case "created":
+		column = "created"
+	}
+
+	return m.queryRoleAssignments(func(s *entsql.Selector) {
+		if p.SortOrder == "desc" {
+			s.OrderBy(entsql.Desc(column))
+		} else {
+			s.OrderBy(entsql.Asc(column))
+		}
+		s.Limit(p.PageSize).Offset((p.CurrentPage - 1) * p.PageSize)
+	})
+}
+
+func (m *Model) GetRoleAssignment(id int) (consoledb.RoleAssignment, error) {
+	assignments, err := m.queryRoleAssignments(func(s *entsql.Selector) {
+		s.Where(entsql.EQ("id", id))
+	})
+	if err != nil {
+		return consoledb.RoleAssignment{}, err
+	}
+
+	if len(assignments) != 1 {
+		return consoledb.RoleAssignment{}, ErrRoleAssignmentNotFound
+	}
+
+	return assignments[0], nil
+}
+
+// DeleteRoleAssignment removes a role assignment, the last global admin assignment can't be removed
+// as nobody would be able to manage the roles afterwards
+func (m *Model) DeleteRoleAssignment(id int) error {
+	assignment, err := m.GetRoleAssignment(id)
+	if err != nil {
+		return err
+	}
+
+	if assignment.Role == string(rbac.RoleGlobalAdmin) {
+		admins, err := m.queryRoleAssignments(func(s *entsql.Selector) {
+			s.Where(entsql.EQ("role", string(rbac.RoleGlobalAdmin)))
+		})
+		if err != nil {
+			return err
+		}
+		if len(admins) == 1 {
+			return ErrLastGlobalAdmin
+		}
+	}
+
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Delete(consoledb.RoleAssignmentsTable.Name).
+		Where(entsql.EQ("id", id)).
+		Query()
+
+	_, err = m.Driver.DB().ExecContext(context.Background(), query, args...)
+	return err
+}
+
+// DeleteRoleAssignmentsForUser removes the roles of a user that is going to be deleted,
+// it fails if the user is the only global admin
+func (m *Model) DeleteRoleAssignmentsForUser(userID string) error {
+	admins, err := m.queryRoleAssignments(func(s *entsql.Selector) {
+		s.Where(entsql.EQ("role", string(rbac.RoleGlobalAdmin)))
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(admins) > 0 && !slices.ContainsFunc(admins, func(r consoledb.RoleAssignment) bool { return r.UserID != userID }) {
+		return ErrLastGlobalAdmin
+	}
+
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Delete(consoledb.RoleAssignmentsTable.Name).
+		Where(entsql.EQ("user_id", userID)).
+		Query()
+
+	_, err = m.Driver.DB().ExecContext(context.Background(), query, args...)
+	return err
+}
+
+func (m *Model) queryRoleAssignments(modifier func(s *entsql.Selector)) ([]consoledb.RoleAssignment, error) {
+	selector := entsql.Dialect(m.Driver.Dialect()).
+		Select(roleAssignmentColumns...).
+		From(entsql.Table(consoledb.RoleAssignmentsTable.Name))
+	modifier(selector)
+
+	query, args := selector.Query()
+	rows, err := m.Driver.DB().QueryContext(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	assignments := []consoledb.RoleAssignment{}
+	for rows.Next() {
+		var r consoledb.RoleAssignment
+		if err := rows.Scan(&r.ID, &r.UserID, &r.Role, &r.TenantID, &r.SiteID, &r.Created, &r.Source); err != nil {
+			return nil, err
+		}
+		assignments = append(assignments, r)
+	}
+
+	return assignments, rows.Err()
+}
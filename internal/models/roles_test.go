@@ -0,0 +1,134 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/open-uem/openuem-console/internal/rbac"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type RolesTestSuite struct {
+	suite.Suite
+	model Model
+	p     partials.PaginationAndSort
+}
+
+func (suite *RolesTestSuite) SetupTest() {
+	suite.model = newTestModel(suite.T())
+	suite.p = partials.PaginationAndSort{CurrentPage: 1, PageSize: 5}
+}
+
+func (suite *RolesTestSuite) TestUnrestrictedWithoutRoles() {
+	access, err := suite.model.GetUserAccess("user1")
+	assert.NoError(suite.T(), err, "should get user access")
+	assert.True(suite.T(), access.Unrestricted, "access should be unrestricted if no roles are assigned")
+
+	err = suite.model.AddRoleAssignment("admin", rbac.RoleGlobalAdmin, -1, -1)
+	assert.NoError(suite.T(), err, "should add role assignment")
+
+	access, err = suite.model.GetUserAccess("user1")
+	assert.NoError(suite.T(), err, "should get user access")
+	assert.False(suite.T(), access.Unrestricted, "access should be restricted once a role is assigned")
+	assert.Equal(suite.T(), rbac.PermissionNone, access.Level(1, -1), "user without roles should have no permissions")
+}
+
+func (suite *RolesTestSuite) TestAddRoleAssignment() {
+	err := suite.model.AddRoleAssignment("user1", rbac.Role("superuser"), -1, -1)
+	assert.ErrorIs(suite.T(), err, ErrInvalidRole, "should not add unknown roles")
+
+	err = suite.model.AddRoleAssignment("user1", rbac.RoleGlobalAdmin, 2, 3)
+	assert.NoError(suite.T(), err, "should add role assignment")
+
+	err = suite.model.AddRoleAssignment("user1", rbac.RoleHelpdesk, 2, 3)
+	assert.NoError(suite.T(), err, "should add role assignment")
+
+	err = suite.model.AddRoleAssignment("user1", rbac.RoleHelpdesk, 2, 3)
+	assert.Error(suite.T(), err, "should not add the same role assignment twice")
+
+	assignments, err := suite.model.GetRoleAssignmentsForUser("user1")
+	assert.NoError(suite.T(), err, "should get role assignments")
+	assert.Equal(suite.T(), 2, len(assignments))
+	assert.Equal(suite.T(), string(rbac.RoleGlobalAdmin), assignments[0].Role)
+	assert.Equal(suite.T(), -1, assignments[0].TenantID, "global admin should be assigned in every tenant")
+	assert.Equal(suite.T(), -1, assignments[0].SiteID, "global admin should be assigned in every site")
+	assert.Equal(suite.T(), 2, assignments[1].TenantID)
+	assert.Equal(suite.T(), 3, assignments[1].SiteID)
+}
+
+func (suite *RolesTestSuite) TestGetRoleAssignmentsByPage() {
+	for _, user := range []string{"user1", "user2", "user3", "user4", "user5", "user6", "user7"} {
+		err := suite.model.AddRoleAssignment(user, rbac.RoleAuditor, 1, -1)
+		assert.NoError(suite.T(), err, "should add role assignment")
+	}
+
+	count, err := suite.model.CountAllRoleAssignments()
+	assert.NoError(suite.T(), err, "should count role assignments")
+	assert.Equal(suite.T(), 7, count)
+
+	suite.p.SortBy = "uid"
+	suite.p.SortOrder = "desc"
+	assignments, err := suite.model.GetRoleAssignmentsByPage(suite.p)
+	assert.NoError(suite.T(), err, "should get role assignments by page")
+	assert.Equal(suite.T(), 5, len(assignments))
+	assert.Equal(suite.T(), "user7", assignments[0].UserID)
+
+	suite.p.CurrentPage = 2
+	assignments, err = suite.model.GetRoleAssignmentsByPage(suite.p)
+	assert.NoError(suite.T(), err, "should get role assignments by page")
+	assert.Equal(suite.T(), 2, len(assignments))
+}
+
+func (suite *RolesTestSuite) TestDeleteRoleAssignment() {
+	err := suite.model.AddRoleAssignment("admin", rbac.RoleGlobalAdmin, -1, -1)
+	assert.NoError(suite.T(), err, "should add role assignment")
+
+	err = suite.model.AddRoleAssignment("user1", rbac.RoleOperator, 1, -1)
+	assert.NoError(suite.T(), err, "should add role assignment")
+
+	admin, err := suite.model.GetRoleAssignmentsForUser("admin")
+	assert.NoError(suite.T(), err, "should get role assignments")
+
+	err = suite.model.DeleteRoleAssignment(admin[0].ID)
+	assert.ErrorIs(suite.T(), err, ErrLastGlobalAdmin, "should not delete the last global admin")
+
+	err = suite.model.DeleteRoleAssignmentsForUser("admin")
+	assert.ErrorIs(suite.T(), err, ErrLastGlobalAdmin, "should not delete the roles of the last global admin")
+
+	err = suite.model.DeleteRoleAssignment(admin[0].ID + 100)
+	assert.ErrorIs(suite.T(), err, ErrRoleAssignmentNotFound)
+
+	err = suite.model.DeleteRoleAssignmentsForUser("user1")
+	assert.NoError(suite.T(), err, "should delete the user roles")
+
+	assignments, err := suite.model.GetRoleAssignmentsForUser("user1")
+	assert.NoError(suite.T(), err, "should get role assignments")
+	assert.Equal(suite.T(), 0, len(assignments))
+
+	err = suite.model.AddRoleAssignment("user2", rbac.RoleGlobalAdmin, -1, -1)
+	assert.NoError(suite.T(), err, "should add role assignment")
+
+	err = suite.model.DeleteRoleAssignment(admin[0].ID)
+	assert.NoError(suite.T(), err, "should delete global admin if there's another one")
+}
+
+func (suite *RolesTestSuite) TestGetGlobalRoleMembers() {
+	err := suite.model.AddRoleAssignment("user1", rbac.RoleOperator, -1, -1)
+	assert.NoError(suite.T(), err, "should add role assignment")
+
+	err = suite.model.AddRoleAssignment("user2", rbac.RoleOperator, 1, -1)
+	assert.NoError(suite.T(), err, "should add role assignment")
+
+	err = suite.model.AddRoleAssignment("user3", rbac.RoleAuditor, -1, -1)
+	assert.NoError(suite.T(), err, "should add role assignment")
+
+	members, err := suite.model.GetGlobalRoleMembers(rbac.RoleOperator)
+	assert.NoError(suite.T(), err, "should get role members")
+	assert.Equal(suite.T(), 1, len(members), "roles granted in a tenant should be excluded")
+	assert.Equal(suite.T(), "user1", members[0].UserID)
+}
+
+func TestRolesTestSuite(t *testing.T) {
+	suite.Run(t, new(RolesTestSuite))
+}
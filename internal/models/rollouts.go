@@ -0,0 +1,454 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	entsql "entgo.io/ent/dialect/sql"
+	"github.com/open-uem/ent"
+	"github.com/open-uem/ent/agent"
+	"github.com/open-uem/ent/predicate"
+	"github.com/open-uem/ent/release"
+	"github.com/open-uem/ent/site"
+	"github.com/open-uem/ent/tag"
+	"github.com/open-uem/ent/tenant"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/rollouts"
+	"github.com/open-uem/openuem-console/internal/views/filters"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+)
+
+var ErrRolloutNotFound = errors.New("the rollout doesn't exist or can't change to the requested status")
+
+// Update task status saved with SaveAgentUpdateInfo, the agent workers set the success
+// or error status when the agent reports the result of the update
+const (
+	AgentUpdateTaskPending = "admin.update.agents.task_status_pending"
+	AgentUpdateTaskSuccess = "admin.update.agents.task_status_success"
+	AgentUpdateTaskError   = "admin.update.agents.task_status_error"
+)
+
+var rolloutColumns = []string{"id", "tenant_id", "name", "version", "channel", "pilot_type", "pilot_value", "waves", "soak_minutes", "failure_threshold", "status", "current_wave", "wave_started", "halt_reason", "language", "created_by", "created"}
+var rolloutAgentColumns = []string{"id", "rollout_id", "agent_id", "hostname", "wave", "status", "error", "sent", "finished"}
+
+// rolloutAgentsBatch is the number of agents inserted with each statement
+const rolloutAgentsBatch = 500
+
+// GetRolloutTargets returns the enabled agents of the tenant that don't run the version yet,
+// and the ids of those agents that belong to the pilot ring
+func (m *Model) GetRolloutTargets(tenantID int, version, pilotType string, pilotValue int) ([]*ent.Agent, []string, error) {
+	ctx := context.Background()
+
+	inScope := []predicate.Agent{
+		agent.HasSiteWith(site.HasTenantWith(tenant.ID(tenantID))),
+		agent.AgentStatusEQ(agent.AgentStatusEnabled),
+		agent.Not(agent.HasReleaseWith(release.VersionEQ(version))),
+	}
+
+	agents, err := m.Client.Agent.Query().Where(inScope...).Order(ent.Asc(agent.FieldHostname)).All(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pilotQuery := m.Client.Agent.Query().Where(inScope...)
+	switch pilotType {
+	case rollouts.PilotTag:
+		pilotQuery.Where(agent.HasTagsWith(tag.ID(pilotValue), tag.HasTenantWith(tenant.ID(tenantID))))
+	case rollouts.PilotSite:
+		pilotQuery.Where(agent.HasSiteWith(site.ID(pilotValue), site.HasTenantWith(tenant.ID(tenantID))))
+	default:
+		return nil, nil, rollouts.ErrEmptyPilot
+	}
+
+	pilot, err := pilotQuery.IDs(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return agents, pilot, nil
+}
+
+// AddRollout saves the rollout and the wave of each of its agents, it returns the id of
+// the new rollout. The rollout starts with the pilot wave
+func (m *Model) AddRollout(r consoledb.Rollout, plan map[string]int, hostnames map[string]string) (int, error) {
+	ctx := context.Background()
+	now := time.Now()
+
+	tx, err := m.Driver.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Insert(consoledb.RolloutsTable.Name).
+		Columns("tenant_id", "name", "version", "channel", "pilot_type", "pilot_value", "waves", "soak_minutes", "failure_threshold", "status", "current_wave", "wave_started", "language", "created_by", "created").
+		Values(r.TenantID, r.Name, r.Version, r.Channel, r.PilotType, r.PilotValue, rollouts.FormatWaves(r.Waves), r.SoakMinutes, r.FailureThreshold, rollouts.StatusRunning, rollouts.PilotWave, now, r.Language, r.CreatedBy, now).
+		Returning("id").
+		Query()
+
+	var id int
+	if err := tx.QueryRowContext(ctx, query, args...).Scan(&id); err != nil {
+		return 0, err
+	}
+
+	agentIDs := make([]string, 0, len(plan))
+	for agentID := range plan {
+		agentIDs = append(agentIDs, agentID)
+	}
+	slices.Sort(agentIDs)
+
+	for batch := range slices.Chunk(agentIDs, rolloutAgentsBatch) {
+		insert := entsql.Dialect(m.Driver.Dialect()).
+			Insert(consoledb.RolloutAgentsTable.Name).
+			Columns("rollout_id", "agent_id", "hostname", "wave", "status")
+		for _, agentID := range batch {
+			insert.Values(id, agentID, hostnames[agentID], plan[agentID], rollouts.AgentQueued)
+		}
+
+		query, args := insert.Query()
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return 0, err
+		}
+	}
+
+	return id, tx.Commit()
+}
+
+func (m *Model) GetRollouts(tenantID int) ([]consoledb.Rollout, error) {
+	return m.queryRollouts(func(s *entsql.Selector) {
+		s.Where(entsql.EQ("tenant_id", tenantID)).OrderBy(entsql.Desc("created"), entsql.Desc("id"))
+	})
+}
+
+func (m *Model) GetRollout(id int, tenantID int) (consoledb.Rollout, error) {
+	items, err := m.queryRollouts(func(s *entsql.Selector) {
+		s.Where(entsql.And(entsql.EQ("id", id), entsql.EQ("tenant_id", tenantID)))
+	})
+	if err != nil {
+		return consoledb.Rollout{}, err
+	}
+
+	if len(items) != 1 {
+		return consoledb.Rollout{}, ErrRolloutNotFound
+	}
+
+	return items[0], nil
+}
+
+// GetRunningRollouts returns the running rollouts of every tenant
+func (m *Model) GetRunningRollouts() ([]consoledb.Rollout, error) {
+	return m.queryRollouts(func(s *entsql.Selector) {
+		s.Where(entsql.EQ("status", rollouts.StatusRunning)).OrderBy(entsql.Asc("id"))
+	})
+}
+
+// SetRolloutStatus changes the status of a rollout if its current status is one of from.
+// Resuming a rollout starts the soak time of the current wave again
+func (m *Model) SetRolloutStatus(id int, tenantID int, from []string, status string) error {
+	update := entsql.Dialect(m.Driver.Dialect()).
+		Update(consoledb.RolloutsTable.Name).
+		Set("status", status)
+	if status == rollouts.StatusRunning {
+		update.Set("wave_started", time.Now()).Set("halt_reason", "")
+	}
+
+	query, args := update.
+		Where(entsql.And(entsql.EQ("id", id), entsql.EQ("tenant_id", tenantID), entsql.In("status", toAny(from)...))).
+		Query()
+
+	return m.execAffectingOne(query, args, ErrRolloutNotFound)
+}
+
+// HaltRollout stops a running rollout. It returns false if the rollout was no longer
+// running, e.g. another console instance halted it first
+func (m *Model) HaltRollout(id int, reason string) (bool, error) {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Update(consoledb.RolloutsTable.Name).
+		Set("status", rollouts.StatusHalted).
+		Set("halt_reason", truncate(reason, 2048)).
+		Where(entsql.And(entsql.EQ("id", id), entsql.EQ("status", rollouts.StatusRunning))).
+		Query()
+
+	return m.execClaim(query, args)
+}
+
+// CompleteRollout marks a running rollout as completed once its last wave has soaked
+func (m *Model) CompleteRollout(id int) (bool, error) {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Update(consoledb.RolloutsTable.Name).
+		Set("status", rollouts.StatusCompleted).
+		Where(entsql.And(entsql.EQ("id", id), entsql.EQ("status", rollouts.StatusRunning))).
+		Query()
+
+	return m.execClaim(query, args)
+}
+
+// ClaimRolloutWave moves a running rollout from the wave to the next one. It returns
+// false if another console instance moved it first so the wave is only started once
+func (m *Model) ClaimRolloutWave(id int, wave int) (bool, error) {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Update(consoledb.RolloutsTable.Name).
+		Set("current_wave", wave+1).
+		Set("wave_started", time.Now()).
+		Where(entsql.And(entsql.EQ("id", id), entsql.EQ("status", rollouts.StatusRunning), entsql.EQ("current_wave", wave))).
+		Query()
+
+	return m.execClaim(query, args)
+}
+
+// GetQueuedRolloutAgents returns the agents of the waves up to the given one that haven't
+// been sent the update yet
+func (m *Model) GetQueuedRolloutAgents(rolloutID int, wave int) ([]consoledb.RolloutAgent, error) {
+	return m.queryRolloutAgents(func(s *entsql.Selector) {
+		s.Where(entsql.And(
+			entsql.EQ("rollout_id", rolloutID),
+			entsql.LTE("wave", wave),
+			entsql.EQ("status", rollouts.AgentQueued),
+		)).OrderBy(entsql.Asc("wave"), entsql.Asc("id"))
+	})
+}
+
+// ClaimRolloutAgent marks a queued agent as sent. It returns false if another console
+// instance claimed it first so the update request is only sent once
+func (m *Model) ClaimRolloutAgent(rolloutID int, agentID string) (bool, error) {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Update(consoledb.RolloutAgentsTable.Name).
+		Set("status", rollouts.AgentSent).
+		Set("sent", time.Now()).
+		Where(entsql.And(entsql.EQ("rollout_id", rolloutID), entsql.EQ("agent_id", agentID), entsql.EQ("status", rollouts.AgentQueued))).
+		Query()
+
+	return m.execClaim(query, args)
+}
+
+// FinishRolloutAgent records the result of the update of an agent
+func (m *Model) FinishRolloutAgent(rolloutID int, agentID string, status string, errMessage string) error {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Update(consoledb.RolloutAgentsTable.Name).
+		Set("status", status).
+		Set("error", truncate(errMessage, 2048)).
+		Set("finished", time.Now()).
+		Where(entsql.And(entsql.EQ("rollout_id", rolloutID), entsql.EQ("agent_id", agentID))).
+		Query()
+
+	_, err := m.Driver.DB().ExecContext(context.Background(), query, args...)
+	return err
+}
+
+// SyncRolloutAgents records the result of the agents the update was sent to. An agent
+// succeeded when it reports the version of the rollout, and failed when the update task
+// saved by SaveAgentUpdateInfo for that version ended with an error
+func (m *Model) SyncRolloutAgents(r consoledb.Rollout) error {
+	sent, err := m.queryRolloutAgents(func(s *entsql.Selector) {
+		s.Where(entsql.And(entsql.EQ("rollout_id", r.ID), entsql.EQ("status", rollouts.AgentSent)))
+	})
+	if err != nil || len(sent) == 0 {
+		return err
+	}
+
+	ids := []string{}
+	for _, a := range sent {
+		ids = append(ids, a.AgentID)
+	}
+
+	agents, err := m.Client.Agent.Query().WithRelease().Where(agent.IDIn(ids...)).All(context.Background())
+	if err != nil {
+		return err
+	}
+
+	for _, a := range agents {
+		switch {
+		case a.Edges.Release != nil && a.Edges.Release.Version == r.Version,
+			a.UpdateTaskStatus == AgentUpdateTaskSuccess && a.UpdateTaskVersion == r.Version:
+			err = m.FinishRolloutAgent(r.ID, a.ID, rollouts.AgentSucceeded, "")
+		case a.UpdateTaskStatus == AgentUpdateTaskError && a.UpdateTaskVersion == r.Version:
+			reason := a.UpdateTaskResult
+			if reason == "" {
+				reason = a.UpdateTaskDescription
+			}
+			err = m.FinishRolloutAgent(r.ID, a.ID, rollouts.AgentFailed, reason)
+		default:
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetRolloutProgress returns how many agents of each wave are in each status, sorted by wave
+func (m *Model) GetRolloutProgress(rolloutID int) ([]rollouts.WaveProgress, error) {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Select("wave", "status", entsql.Count("*")).
+		From(entsql.Table(consoledb.RolloutAgentsTable.Name)).
+		Where(entsql.EQ("rollout_id", rolloutID)).
+		GroupBy("wave", "status").
+		OrderBy("wave").
+		Query()
+
+	rows, err := m.Driver.DB().QueryContext(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	progress := []rollouts.WaveProgress{}
+	for rows.Next() {
+		var wave, count int
+		var status string
+		if err := rows.Scan(&wave, &status, &count); err != nil {
+			return nil, err
+		}
+
+		if len(progress) == 0 || progress[len(progress)-1].Wave != wave {
+			progress = append(progress, rollouts.WaveProgress{Wave: wave})
+		}
+		p := &progress[len(progress)-1]
+
+		switch status {
+		case rollouts.AgentQueued:
+			p.Queued = count
+		case rollouts.AgentSent:
+			p.Sent = count
+		case rollouts.AgentSucceeded:
+			p.Succeeded = count
+		case rollouts.AgentFailed:
+			p.Failed = count
+		}
+	}
+
+	return progress, rows.Err()
+}
+
+func (m *Model) CountRolloutAgents(rolloutID int, f filters.RolloutAgentFilter) (int, error) {
+	var count int
+
+	selector := entsql.Dialect(m.Driver.Dialect()).
+		Select(entsql.Count("*")).
+		From(entsql.Table(consoledb.RolloutAgentsTable.Name)).
+		Where(entsql.EQ("rollout_id", rolloutID))
+	applyRolloutAgentFilter(selector, f)
+
+	query, args := selector.Query()
+	if err := m.Driver.DB().QueryRowContext(context.Background(), query, args...).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (m *Model) GetRolloutAgentsByPage(p partials.PaginationAndSort, rolloutID int, f filters.RolloutAgentFilter) ([]consoledb.RolloutAgent, error) {
+	return m.queryRolloutAgents(func(s *entsql.Selector) {
+		s.Where(entsql.EQ("rollout_id", rolloutID))
+		applyRolloutAgentFilter(s, f)
+
+		column := "wave"
+		switch p.SortBy {
+		case "hostname":
+			column = "hostname"
+		case "status":
+			column = "status"
+		case "sent":
+			column = "sent"
+		}
+
+		if p.SortOrder == "desc" {
+			s.OrderBy(entsql.Desc(column), entsql.Desc("hostname"))
+		} else {
+			s.OrderBy(entsql.Asc(column), entsql.Asc("hostname"))
+		}
+
+		s.Limit(p.PageSize).Offset((p.CurrentPage - 1) * p.PageSize)
+	})
+}
+
+func applyRolloutAgentFilter(s *entsql.Selector, f filters.RolloutAgentFilter) {
+	if len(f.Hostname) > 0 {
+		s.Where(entsql.ContainsFold("hostname", f.Hostname))
+	}
+
+	if len(f.Statuses) > 0 {
+		s.Where(entsql.In("status", toAny(f.Statuses)...))
+	}
+}
+
+// execClaim runs a conditional update and reports if it changed a row
+func (m *Model) execClaim(query string, args []any) (bool, error) {
+	result, err := m.Driver.DB().ExecContext(context.Background(), query, args...)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n == 1, nil
+}
+
+func (m *Model) queryRollouts(modifier func(s *entsql.Selector)) ([]consoledb.Rollout, error) {
+	selector := entsql.Dialect(m.Driver.Dialect()).
+		Select(rolloutColumns...).
+		From(entsql.Table(consoledb.RolloutsTable.Name))
+	modifier(selector)
+
+	query, args := selector.Query()
+	rows, err := m.Driver.DB().QueryContext(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []consoledb.Rollout{}
+	for rows.Next() {
+		var r consoledb.Rollout
+		var waves string
+		if err := rows.Scan(&r.ID, &r.TenantID, &r.Name, &r.Version, &r.Channel, &r.PilotType, &r.PilotValue, &waves, &r.SoakMinutes, &r.FailureThreshold, &r.Status, &r.CurrentWave, &r.WaveStarted, &r.HaltReason, &r.Language, &r.CreatedBy, &r.Created); err != nil {
+			return nil, err
+		}
+		r.Waves, _ = rollouts.ParseWaves(strings.TrimSpace(waves))
+		items = append(items, r)
+	}
+
+	return items, rows.Err()
+}
+
+func (m *Model) queryRolloutAgents(modifier func(s *entsql.Selector)) ([]consoledb.RolloutAgent, error) {
+	selector := entsql.Dialect(m.Driver.Dialect()).
+		Select(rolloutAgentColumns...).
+		From(entsql.Table(consoledb.RolloutAgentsTable.Name))
+	modifier(selector)
+
+	query, args := selector.Query()
+	rows, err := m.Driver.DB().QueryContext(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []consoledb.RolloutAgent{}
+	for rows.Next() {
+		var a consoledb.RolloutAgent
+		var sent, finished sql.NullTime
+		if err := rows.Scan(&a.ID, &a.RolloutID, &a.AgentID, &a.Hostname, &a.Wave, &a.Status, &a.Error, &sent, &finished); err != nil {
+			return nil, err
+		}
+		if sent.Valid {
+			a.Sent = sent.Time
+		}
+		if finished.Valid {
+			a.Finished = finished.Time
+		}
+		items = append(items, a)
+	}
+
+	return items, rows.Err()
+}
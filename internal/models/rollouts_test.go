@@ -0,0 +1,215 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/open-uem/ent/agent"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/rollouts"
+	"github.com/open-uem/openuem-console/internal/views/filters"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type RolloutsTestSuite struct {
+	suite.Suite
+	model    Model
+	tenantID int
+	siteID   int
+	tagID    int
+}
+
+func (suite *RolloutsTestSuite) SetupTest() {
+	suite.model = newTestModel(suite.T())
+	client := suite.model.Client
+
+	t, err := suite.model.CreateDefaultTenant()
+	assert.NoError(suite.T(), err, "should create default tenant")
+	suite.tenantID = t.ID
+
+	s, err := suite.model.CreateDefaultSite(t)
+	assert.NoError(suite.T(), err, "should create default site")
+	suite.siteID = s.ID
+
+	tag, err := client.Tag.Create().SetTag("Pilot").SetDescription("Pilot ring").SetColor("#f0f0f0").SetTenantID(t.ID).Save(context.Background())
+	assert.NoError(suite.T(), err, "should create tag")
+	suite.tagID = tag.ID
+
+	current, err := client.Release.Create().SetArch("amd64").SetChannel("stable").SetOs("windows").SetVersion("0.2.0").Save(context.Background())
+	assert.NoError(suite.T(), err, "should create release")
+
+	for i := 0; i <= 5; i++ {
+		query := client.Agent.Create().
+			SetID(fmt.Sprintf("agent%d", i)).
+			SetHostname(fmt.Sprintf("host%d", i)).
+			SetOs("windows").
+			SetNickname(fmt.Sprintf("agent%d", i)).
+			SetAgentStatus(agent.AgentStatusEnabled).
+			AddSiteIDs(s.ID)
+		if i < 2 {
+			query.AddTagIDs(tag.ID)
+		}
+		if i == 5 {
+			query.SetReleaseID(current.ID)
+		}
+		err := query.Exec(context.Background())
+		assert.NoError(suite.T(), err, "should create agent")
+	}
+}
+
+func (suite *RolloutsTestSuite) addRollout() consoledb.Rollout {
+	agents, pilot, err := suite.model.GetRolloutTargets(suite.tenantID, "0.2.0", rollouts.PilotTag, suite.tagID)
+	assert.NoError(suite.T(), err, "should get rollout targets")
+
+	ids := []string{}
+	hostnames := map[string]string{}
+	for _, a := range agents {
+		ids = append(ids, a.ID)
+		hostnames[a.ID] = a.Hostname
+	}
+
+	plan, err := rollouts.Plan(ids, pilot, []int{50, 100})
+	assert.NoError(suite.T(), err, "should plan the rollout")
+
+	id, err := suite.model.AddRollout(consoledb.Rollout{
+		TenantID:         suite.tenantID,
+		Name:             "0.2.0 rollout",
+		Version:          "0.2.0",
+		Channel:          "stable",
+		PilotType:        rollouts.PilotTag,
+		PilotValue:       suite.tagID,
+		Waves:            []int{50, 100},
+		SoakMinutes:      60,
+		FailureThreshold: 10,
+		Language:         "en",
+	}, plan, hostnames)
+	assert.NoError(suite.T(), err, "should add rollout")
+
+	r, err := suite.model.GetRollout(id, suite.tenantID)
+	assert.NoError(suite.T(), err, "should get rollout")
+	return r
+}
+
+func (suite *RolloutsTestSuite) TestGetRolloutTargets() {
+	agents, pilot, err := suite.model.GetRolloutTargets(suite.tenantID, "0.2.0", rollouts.PilotTag, suite.tagID)
+	assert.NoError(suite.T(), err, "should get rollout targets")
+	assert.Equal(suite.T(), 5, len(agents), "agents that run the version are excluded")
+	assert.ElementsMatch(suite.T(), []string{"agent0", "agent1"}, pilot)
+
+	_, pilot, err = suite.model.GetRolloutTargets(suite.tenantID, "0.2.0", rollouts.PilotSite, suite.siteID)
+	assert.NoError(suite.T(), err, "should get rollout targets")
+	assert.Equal(suite.T(), 5, len(pilot))
+}
+
+func (suite *RolloutsTestSuite) TestAddRollout() {
+	r := suite.addRollout()
+	assert.Equal(suite.T(), rollouts.StatusRunning, r.Status)
+	assert.Equal(suite.T(), rollouts.PilotWave, r.CurrentWave)
+	assert.Equal(suite.T(), []int{50, 100}, r.Waves)
+
+	progress, err := suite.model.GetRolloutProgress(r.ID)
+	assert.NoError(suite.T(), err, "should get rollout progress")
+	assert.Equal(suite.T(), []rollouts.WaveProgress{{Wave: 0, Queued: 2}, {Wave: 1, Queued: 2}, {Wave: 2, Queued: 1}}, progress)
+
+	items, err := suite.model.GetRollouts(suite.tenantID)
+	assert.NoError(suite.T(), err, "should get rollouts")
+	assert.Equal(suite.T(), 1, len(items))
+
+	_, err = suite.model.GetRollout(r.ID, suite.tenantID+1)
+	assert.ErrorIs(suite.T(), err, ErrRolloutNotFound)
+}
+
+func (suite *RolloutsTestSuite) TestClaimRolloutWave() {
+	r := suite.addRollout()
+
+	queued, err := suite.model.GetQueuedRolloutAgents(r.ID, r.CurrentWave)
+	assert.NoError(suite.T(), err, "should get queued agents")
+	assert.Equal(suite.T(), 2, len(queued), "only the pilot agents are queued in the pilot wave")
+
+	claimed, err := suite.model.ClaimRolloutAgent(r.ID, queued[0].AgentID)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), claimed)
+
+	claimed, err = suite.model.ClaimRolloutAgent(r.ID, queued[0].AgentID)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), claimed, "an agent must be sent only once")
+
+	claimed, err = suite.model.ClaimRolloutWave(r.ID, r.CurrentWave)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), claimed)
+
+	claimed, err = suite.model.ClaimRolloutWave(r.ID, r.CurrentWave)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), claimed, "a wave must be started only once")
+
+	queued, err = suite.model.GetQueuedRolloutAgents(r.ID, 1)
+	assert.NoError(suite.T(), err, "should get queued agents")
+	assert.Equal(suite.T(), 3, len(queued))
+}
+
+func (suite *RolloutsTestSuite) TestSyncRolloutAgents() {
+	r := suite.addRollout()
+
+	for _, id := range []string{"agent0", "agent1"} {
+		claimed, err := suite.model.ClaimRolloutAgent(r.ID, id)
+		assert.NoError(suite.T(), err)
+		assert.True(suite.T(), claimed)
+	}
+
+	err := suite.model.Client.Agent.UpdateOneID("agent0").SetUpdateTaskStatus(AgentUpdateTaskSuccess).SetUpdateTaskVersion("0.2.0").Exec(context.Background())
+	assert.NoError(suite.T(), err)
+	err = suite.model.Client.Agent.UpdateOneID("agent1").SetUpdateTaskStatus(AgentUpdateTaskError).SetUpdateTaskVersion("0.2.0").SetUpdateTaskResult("checksum mismatch").Exec(context.Background())
+	assert.NoError(suite.T(), err)
+
+	err = suite.model.SyncRolloutAgents(r)
+	assert.NoError(suite.T(), err, "should sync rollout agents")
+
+	progress, err := suite.model.GetRolloutProgress(r.ID)
+	assert.NoError(suite.T(), err, "should get rollout progress")
+	assert.Equal(suite.T(), rollouts.WaveProgress{Wave: 0, Succeeded: 1, Failed: 1}, progress[0])
+
+	p := partials.PaginationAndSort{CurrentPage: 1, PageSize: 5}
+	f := filters.RolloutAgentFilter{Statuses: []string{rollouts.AgentFailed}}
+	count, err := suite.model.CountRolloutAgents(r.ID, f)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, count)
+
+	failed, err := suite.model.GetRolloutAgentsByPage(p, r.ID, f)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "host1", failed[0].Hostname)
+	assert.Equal(suite.T(), "checksum mismatch", failed[0].Error)
+}
+
+func (suite *RolloutsTestSuite) TestRolloutStatus() {
+	r := suite.addRollout()
+
+	halted, err := suite.model.HaltRollout(r.ID, "too many failures")
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), halted)
+
+	running, err := suite.model.GetRunningRollouts()
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 0, len(running))
+
+	err = suite.model.SetRolloutStatus(r.ID, suite.tenantID, []string{rollouts.StatusPaused}, rollouts.StatusRunning)
+	assert.ErrorIs(suite.T(), err, ErrRolloutNotFound, "a halted rollout isn't paused")
+
+	err = suite.model.SetRolloutStatus(r.ID, suite.tenantID, []string{rollouts.StatusPaused, rollouts.StatusHalted}, rollouts.StatusRunning)
+	assert.NoError(suite.T(), err, "should resume rollout")
+
+	r, err = suite.model.GetRollout(r.ID, suite.tenantID)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), rollouts.StatusRunning, r.Status)
+	assert.Equal(suite.T(), "", r.HaltReason)
+
+	completed, err := suite.model.CompleteRollout(r.ID)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), completed)
+}
+
+func TestRolloutsTestSuite(t *testing.T) {
+	suite.Run(t, new(RolloutsTestSuite))
+}
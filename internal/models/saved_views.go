@@ -0,0 +1,188 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	entsql "entgo.io/ent/dialect/sql"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+)
+
+var ErrSavedViewNotFound = errors.New("the saved view doesn't exist")
+
+var savedViewColumns = []string{"id", "tenant_id", "user_id", "list", "name", "query", "columns", "shared", "created"}
+
+// SaveView stores the view of a user. A view of the same list with the same name
+// is replaced so users can update their views by saving them again
+func (m *Model) SaveView(v consoledb.SavedView) error {
+	existing, err := m.querySavedViews(func(s *entsql.Selector) {
+		s.Where(entsql.And(
+			entsql.EQ("tenant_id", v.TenantID),
+			entsql.EQ("user_id", v.UserID),
+			entsql.EQ("list", v.List),
+			entsql.EQ("name", v.Name),
+		))
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(existing) > 0 {
+		query, args := entsql.Dialect(m.Driver.Dialect()).
+			Update(consoledb.SavedViewsTable.Name).
+			Set("query", v.Query).
+			Set("columns", strings.Join(v.Columns, ",")).
+			Set("shared", v.Shared).
+			Where(entsql.EQ("id", existing[0].ID)).
+			Query()
+
+		return m.execAffectingOne(query, args, ErrSavedViewNotFound)
+	}
+
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Insert(consoledb.SavedViewsTable.Name).
+		Columns("tenant_id", "user_id", "list", "name", "query", "columns", "shared", "created").
+		Values(v.TenantID, v.UserID, v.List, v.Name, v.Query, strings.Join(v.Columns, ","), v.Shared, time.Now()).
+		Query()
+
+	_, err = m.Driver.DB().ExecContext(context.Background(), query, args...)
+	return err
+}
+
+// GetSavedViews returns the views of a list the user can pick, their own views and
+// the views shared in the tenant, sorted by name
+func (m *Model) GetSavedViews(userID string, tenantID int, list string) ([]consoledb.SavedView, error) {
+	return m.querySavedViews(func(s *entsql.Selector) {
+		s.Where(entsql.And(
+			entsql.EQ("tenant_id", tenantID),
+			entsql.EQ("list", list),
+			entsql.Or(entsql.EQ("user_id", userID), entsql.EQ("shared", true)),
+		)).OrderBy(entsql.Asc("name"), entsql.Asc("id"))
+	})
+}
+
+// GetSavedView returns a view of a list if the user saved it or it's shared in the tenant
+func (m *Model) GetSavedView(id int, userID string, tenantID int, list string) (consoledb.SavedView, error) {
+	views, err := m.querySavedViews(func(s *entsql.Selector) {
+		s.Where(entsql.And(
+			entsql.EQ("id", id),
+			entsql.EQ("tenant_id", tenantID),
+			entsql.EQ("list", list),
+			entsql.Or(entsql.EQ("user_id", userID), entsql.EQ("shared", true)),
+		))
+	})
+	if err != nil {
+		return consoledb.SavedView{}, err
+	}
+
+	if len(views) != 1 {
+		return consoledb.SavedView{}, ErrSavedViewNotFound
+	}
+
+	return views[0], nil
+}
+
+// DeleteSavedView removes a view saved by the user, users that picked it as their
+// default view land on the list without filters again
+func (m *Model) DeleteSavedView(id int, userID string, tenantID int) error {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Delete(consoledb.SavedViewsTable.Name).
+		Where(entsql.And(entsql.EQ("id", id), entsql.EQ("tenant_id", tenantID), entsql.EQ("user_id", userID))).
+		Query()
+
+	if err := m.execAffectingOne(query, args, ErrSavedViewNotFound); err != nil {
+		return err
+	}
+
+	query, args = entsql.Dialect(m.Driver.Dialect()).
+		Delete(consoledb.SavedViewDefaultsTable.Name).
+		Where(entsql.EQ("view_id", id)).
+		Query()
+
+	_, err := m.Driver.DB().ExecContext(context.Background(), query, args...)
+	return err
+}
+
+// SetDefaultSavedView sets the view shown when the user opens the list. A viewID
+// of 0 removes the default view
+func (m *Model) SetDefaultSavedView(userID string, tenantID int, list string, viewID int) error {
+	if viewID != 0 {
+		if _, err := m.GetSavedView(viewID, userID, tenantID, list); err != nil {
+			return err
+		}
+	}
+
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Delete(consoledb.SavedViewDefaultsTable.Name).
+		Where(entsql.And(entsql.EQ("tenant_id", tenantID), entsql.EQ("user_id", userID), entsql.EQ("list", list))).
+		Query()
+
+	if _, err := m.Driver.DB().ExecContext(context.Background(), query, args...); err != nil {
+		return err
+	}
+
+	if viewID == 0 {
+		return nil
+	}
+
+	query, args = entsql.Dialect(m.Driver.Dialect()).
+		Insert(consoledb.SavedViewDefaultsTable.Name).
+		Columns("tenant_id", "user_id", "list", "view_id").
+		Values(tenantID, userID, list, viewID).
+		Query()
+
+	_, err := m.Driver.DB().ExecContext(context.Background(), query, args...)
+	return err
+}
+
+// GetDefaultSavedView returns the view the user picked as default for the list. It
+// returns ErrSavedViewNotFound if there's none or the view is no longer shared
+func (m *Model) GetDefaultSavedView(userID string, tenantID int, list string) (consoledb.SavedView, error) {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Select("view_id").
+		From(entsql.Table(consoledb.SavedViewDefaultsTable.Name)).
+		Where(entsql.And(entsql.EQ("tenant_id", tenantID), entsql.EQ("user_id", userID), entsql.EQ("list", list))).
+		Query()
+
+	var viewID int
+	if err := m.Driver.DB().QueryRowContext(context.Background(), query, args...).Scan(&viewID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return consoledb.SavedView{}, ErrSavedViewNotFound
+		}
+		return consoledb.SavedView{}, err
+	}
+
+	return m.GetSavedView(viewID, userID, tenantID, list)
+}
+
+func (m *Model) querySavedViews(modifier func(s *entsql.Selector)) ([]consoledb.SavedView, error) {
+	selector := entsql.Dialect(m.Driver.Dialect()).
+		Select(savedViewColumns...).
+		From(entsql.Table(consoledb.SavedViewsTable.Name))
+	modifier(selector)
+
+	query, args := selector.Query()
+	rows, err := m.Driver.DB().QueryContext(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	views := []consoledb.SavedView{}
+	for rows.Next() {
+		var v consoledb.SavedView
+		var columns string
+		if err := rows.Scan(&v.ID, &v.TenantID, &v.UserID, &v.List, &v.Name, &v.Query, &columns, &v.Shared, &v.Created); err != nil {
+			return nil, err
+		}
+		if columns != "" {
+			v.Columns = strings.Split(columns, ",")
+		}
+		views = append(views, v)
+	}
+
+	return views, rows.Err()
+}
@@ -0,0 +1,111 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/savedviews"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type SavedViewsTestSuite struct {
+	suite.Suite
+	model Model
+}
+
+func (suite *SavedViewsTestSuite) SetupTest() {
+	suite.model = newTestModel(suite.T())
+
+	views := []consoledb.SavedView{
+		{TenantID: 1, UserID: "alice", List: savedviews.ListComputers, Name: "windows laptops", Query: "filterByAgentOS0=windows", Columns: []string{savedviews.ColumnOS, savedviews.ColumnSerial, "metadata_1"}},
+		{TenantID: 1, UserID: "bob", List: savedviews.ListComputers, Name: "all by model", Query: "sortBy=model&sortOrder=asc", Shared: true},
+		{TenantID: 1, UserID: "bob", List: savedviews.ListComputers, Name: "bob only", Query: "filterByUsername=bob"},
+		{TenantID: 1, UserID: "alice", List: savedviews.ListAgents, Name: "disabled", Query: "filterByStatusAgent0=Disabled"},
+		{TenantID: 2, UserID: "alice", List: savedviews.ListComputers, Name: "other tenant", Shared: true},
+	}
+	for _, v := range views {
+		err := suite.model.SaveView(v)
+		assert.NoError(suite.T(), err, "should save view")
+	}
+}
+
+func (suite *SavedViewsTestSuite) TestGetSavedViews() {
+	views, err := suite.model.GetSavedViews("alice", 1, savedviews.ListComputers)
+	assert.NoError(suite.T(), err, "should get saved views")
+	assert.Equal(suite.T(), 2, len(views), "should get own and shared views of the tenant")
+	assert.Equal(suite.T(), "all by model", views[0].Name, "should sort by name")
+	assert.Equal(suite.T(), []string{savedviews.ColumnOS, savedviews.ColumnSerial, "metadata_1"}, views[1].Columns)
+	assert.Empty(suite.T(), views[0].Columns)
+
+	_, err = suite.model.GetSavedView(views[0].ID, "alice", 2, savedviews.ListComputers)
+	assert.ErrorIs(suite.T(), err, ErrSavedViewNotFound, "should not get a view of another tenant")
+
+	_, err = suite.model.GetSavedView(views[0].ID, "alice", 1, savedviews.ListAgents)
+	assert.ErrorIs(suite.T(), err, ErrSavedViewNotFound, "should not get a view of another list")
+
+	views, err = suite.model.GetSavedViews("bob", 1, savedviews.ListComputers)
+	assert.NoError(suite.T(), err, "should get saved views")
+	assert.Equal(suite.T(), 2, len(views), "should not get private views of other users")
+}
+
+func (suite *SavedViewsTestSuite) TestSaveViewReplacesByName() {
+	err := suite.model.SaveView(consoledb.SavedView{TenantID: 1, UserID: "alice", List: savedviews.ListComputers, Name: "windows laptops", Query: "filterByAgentOS0=linux", Shared: true})
+	assert.NoError(suite.T(), err, "should save view")
+
+	views, err := suite.model.GetSavedViews("bob", 1, savedviews.ListComputers)
+	assert.NoError(suite.T(), err, "should get saved views")
+	assert.Equal(suite.T(), 3, len(views))
+	assert.Equal(suite.T(), "windows laptops", views[2].Name)
+	assert.Equal(suite.T(), "filterByAgentOS0=linux", views[2].Query)
+	assert.Empty(suite.T(), views[2].Columns)
+}
+
+func (suite *SavedViewsTestSuite) TestDefaultSavedView() {
+	_, err := suite.model.GetDefaultSavedView("alice", 1, savedviews.ListComputers)
+	assert.ErrorIs(suite.T(), err, ErrSavedViewNotFound, "should not have a default view")
+
+	views, err := suite.model.GetSavedViews("alice", 1, savedviews.ListComputers)
+	assert.NoError(suite.T(), err, "should get saved views")
+
+	hidden, err := suite.model.GetSavedViews("bob", 1, savedviews.ListComputers)
+	assert.NoError(suite.T(), err, "should get saved views")
+	err = suite.model.SetDefaultSavedView("alice", 1, savedviews.ListComputers, hidden[1].ID)
+	assert.ErrorIs(suite.T(), err, ErrSavedViewNotFound, "should not use a private view of another user")
+
+	err = suite.model.SetDefaultSavedView("alice", 1, savedviews.ListComputers, views[1].ID)
+	assert.NoError(suite.T(), err, "should set default view")
+	err = suite.model.SetDefaultSavedView("alice", 1, savedviews.ListComputers, views[0].ID)
+	assert.NoError(suite.T(), err, "should replace default view")
+	err = suite.model.SetDefaultSavedView("bob", 1, savedviews.ListComputers, views[0].ID)
+	assert.NoError(suite.T(), err, "should set default view")
+
+	v, err := suite.model.GetDefaultSavedView("alice", 1, savedviews.ListComputers)
+	assert.NoError(suite.T(), err, "should get default view")
+	assert.Equal(suite.T(), "all by model", v.Name)
+
+	_, err = suite.model.GetDefaultSavedView("alice", 1, savedviews.ListAgents)
+	assert.ErrorIs(suite.T(), err, ErrSavedViewNotFound, "defaults are set by list")
+
+	err = suite.model.DeleteSavedView(views[0].ID, "alice", 1)
+	assert.ErrorIs(suite.T(), err, ErrSavedViewNotFound, "should not delete a view of another user")
+
+	err = suite.model.DeleteSavedView(views[0].ID, "bob", 1)
+	assert.NoError(suite.T(), err, "should delete view")
+
+	_, err = suite.model.GetDefaultSavedView("alice", 1, savedviews.ListComputers)
+	assert.ErrorIs(suite.T(), err, ErrSavedViewNotFound, "should remove the default view when it's deleted")
+	_, err = suite.model.GetDefaultSavedView("bob", 1, savedviews.ListComputers)
+	assert.ErrorIs(suite.T(), err, ErrSavedViewNotFound, "should remove the default view when it's deleted")
+
+	err = suite.model.SetDefaultSavedView("alice", 1, savedviews.ListComputers, views[1].ID)
+	assert.NoError(suite.T(), err, "should set default view")
+	err = suite.model.SetDefaultSavedView("alice", 1, savedviews.ListComputers, 0)
+	assert.NoError(suite.T(), err, "should remove default view")
+	_, err = suite.model.GetDefaultSavedView("alice", 1, savedviews.ListComputers)
+	assert.ErrorIs(suite.T(), err, ErrSavedViewNotFound, "should not have a default view")
+}
+
+func TestSavedViewsTestSuite(t *testing.T) {
+	suite.Run(t, new(SavedViewsTestSuite))
+}
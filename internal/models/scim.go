@@ -0,0 +1,273 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	entsql "entgo.io/ent/dialect/sql"
+	"github.com/alexedwards/argon2id"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/rbac"
+)
+
+var (
+	ErrInvalidSCIMToken = errors.New("the SCIM token is not valid")
+	ErrSCIMUserNotFound = errors.New("the user is not managed by the identity provider")
+)
+
+var scimUserColumns = []string{"id", "user_id", "external_id", "active", "created", "modified"}
+
+func (m *Model) GetSCIMSettings() (consoledb.SCIMSettings, error) {
+	s := consoledb.SCIMSettings{}
+
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Select("enabled", "token_hash", "token_created", "last_used").
+		From(entsql.Table(consoledb.SCIMSettingsTable.Name)).
+		Limit(1).
+		Query()
+
+	var tokenCreated, lastUsed sql.NullTime
+	if err := m.Driver.DB().QueryRowContext(context.Background(), query, args...).Scan(&s.Enabled, &s.TokenHash, &tokenCreated, &lastUsed); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return s, nil
+		}
+		return s, err
+	}
+
+	s.TokenCreated = tokenCreated.Time
+	s.LastUsed = lastUsed.Time
+	return s, nil
+}
+
+func (m *Model) SaveSCIMEnabled(enabled bool) error {
+	return m.saveSCIMSettings([]string{"enabled"}, []any{enabled})
+}
+
+// NewSCIMToken replaces the token used by the identity provider and returns it in clear,
+// it's the only time that it's available
+func (m *Model) NewSCIMToken() (string, error) {
+	secret, err := randomHex(32)
+	if err != nil {
+		return "", err
+	}
+
+	hash, err := argon2id.CreateHash(secret, argon2id.DefaultParams)
+	if err != nil {
+		return "", err
+	}
+
+	if err := m.saveSCIMSettings([]string{"token_hash", "token_created", "last_used"}, []any{hash, time.Now(), nil}); err != nil {
+		return "", err
+	}
+
+	return consoledb.SCIMTokenPrefix + secret, nil
+}
+
+// ValidateSCIMToken checks the token against the stored hash and records when it was used
+func (m *Model) ValidateSCIMToken(token string) error {
+	secret, found := strings.CutPrefix(token, consoledb.SCIMTokenPrefix)
+	if !found || secret == "" {
+		return ErrInvalidSCIMToken
+	}
+
+	s, err := m.GetSCIMSettings()
+	if err != nil {
+		return err
+	}
+
+	if s.TokenHash == "" {
+		return ErrInvalidSCIMToken
+	}
+
+	match, err := argon2id.ComparePasswordAndHash(secret, s.TokenHash)
+	if err != nil {
+		return err
+	}
+
+	if !match {
+		return ErrInvalidSCIMToken
+	}
+
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Update(consoledb.SCIMSettingsTable.Name).
+		Set("last_used", time.Now()).
+		Query()
+
+	_, err = m.Driver.DB().ExecContext(context.Background(), query, args...)
+	return err
+}
+
+// saveSCIMSettings updates the columns of the settings row, creating it the first time
+func (m *Model) saveSCIMSettings(columns []string, values []any) error {
+	ctx := context.Background()
+
+	tx, err := m.Driver.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	update := entsql.Dialect(m.Driver.Dialect()).Update(consoledb.SCIMSettingsTable.Name)
+	for i, column := range columns {
+		update.Set(column, values[i])
+	}
+
+	query, args := update.Query()
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		query, args = entsql.Dialect(m.Driver.Dialect()).
+			Insert(consoledb.SCIMSettingsTable.Name).
+			Columns(columns...).
+			Values(values...).
+			Query()
+
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// SaveSCIMUser marks the user as managed by the identity provider and sets if the
+// identity provider allows the user to use the console
+func (m *Model) SaveSCIMUser(userID, externalID string, active bool) error {
+	var query string
+	var args []any
+
+	if _, err := m.GetSCIMUser(userID); err == nil {
+		query, args = entsql.Dialect(m.Driver.Dialect()).
+			Update(consoledb.SCIMUsersTable.Name).
+			Set("external_id", externalID).
+			Set("active", active).
+			Set("modified", time.Now()).
+			Where(entsql.EQ("user_id", userID)).
+			Query()
+	} else if errors.Is(err, ErrSCIMUserNotFound) {
+		query, args = entsql.Dialect(m.Driver.Dialect()).
+			Insert(consoledb.SCIMUsersTable.Name).
+			Columns("user_id", "external_id", "active", "created", "modified").
+			Values(userID, externalID, active, time.Now(), time.Now()).
+			Query()
+	} else {
+		return err
+	}
+
+	_, err := m.Driver.DB().ExecContext(context.Background(), query, args...)
+	return err
+}
+
+func (m *Model) GetSCIMUser(userID string) (consoledb.SCIMUser, error) {
+	users, err := m.querySCIMUsers(func(s *entsql.Selector) {
+		s.Where(entsql.EQ("user_id", userID))
+	})
+	if err != nil {
+		return consoledb.SCIMUser{}, err
+	}
+
+	if len(users) == 0 {
+		return consoledb.SCIMUser{}, ErrSCIMUserNotFound
+	}
+
+	return users[0], nil
+}
+
+func (m *Model) GetSCIMUsers() ([]consoledb.SCIMUser, error) {
+	return m.querySCIMUsers(func(s *entsql.Selector) {
+		s.OrderBy(entsql.Asc("user_id"))
+	})
+}
+
+// IsSCIMUserInactive reports if the identity provider has deactivated the user, users
+// not provisioned by the identity provider are always active
+func (m *Model) IsSCIMUserInactive(userID string) (bool, error) {
+	u, err := m.GetSCIMUser(userID)
+	if err != nil {
+		if errors.Is(err, ErrSCIMUserNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return !u.Active, nil
+}
+
+// IsUserDisabled reports if the directory or the identity provider no longer allow
+// the user to use the console
+func (m *Model) IsUserDisabled(userID string) (bool, error) {
+	disabled, err := m.IsDirectoryUserDisabled(userID)
+	if err != nil || disabled {
+		return disabled, err
+	}
+
+	return m.IsSCIMUserInactive(userID)
+}
+
+func (m *Model) DeleteSCIMUser(userID string) error {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Delete(consoledb.SCIMUsersTable.Name).
+		Where(entsql.EQ("user_id", userID)).
+		Query()
+
+	_, err := m.Driver.DB().ExecContext(context.Background(), query, args...)
+	return err
+}
+
+// AddSCIMRoleAssignment grants the role in every tenant to a member of the SCIM group of the role
+func (m *Model) AddSCIMRoleAssignment(userID string, role rbac.Role) error {
+	return m.addRoleAssignment(userID, role, -1, -1, consoledb.RoleSourceSCIM)
+}
+
+// GetSCIMRoleMembers returns the members of the SCIM group of the role, the roles granted in
+// every tenant by the identity provider to the users it has provisioned
+func (m *Model) GetSCIMRoleMembers(role rbac.Role) ([]consoledb.RoleAssignment, error) {
+	provisioned := entsql.Dialect(m.Driver.Dialect()).
+		Select("user_id").
+		From(entsql.Table(consoledb.SCIMUsersTable.Name))
+
+	return m.queryRoleAssignments(func(s *entsql.Selector) {
+		s.Where(entsql.And(
+			entsql.EQ("role", string(role)),
+			entsql.EQ("tenant_id", -1),
+			entsql.EQ("site_id", -1),
+			entsql.EQ("source", consoledb.RoleSourceSCIM),
+			entsql.In("user_id", provisioned),
+		)).OrderBy(entsql.Asc("user_id"))
+	})
+}
+
+func (m *Model) querySCIMUsers(modifier func(s *entsql.Selector)) ([]consoledb.SCIMUser, error) {
+	selector := entsql.Dialect(m.Driver.Dialect()).
+		Select(scimUserColumns...).
+		From(entsql.Table(consoledb.SCIMUsersTable.Name))
+	modifier(selector)
+
+	query, args := selector.Query()
+	rows, err := m.Driver.DB().QueryContext(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []consoledb.SCIMUser{}
+	for rows.Next() {
+		var u consoledb.SCIMUser
+		if err := rows.Scan(&u.ID, &u.UserID, &u.ExternalID, &u.Active, &u.Created, &u.Modified); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+
+	return users, rows.Err()
+}
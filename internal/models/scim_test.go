@@ -0,0 +1,117 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/rbac"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type SCIMTestSuite struct {
+	suite.Suite
+	model Model
+}
+
+func (suite *SCIMTestSuite) SetupTest() {
+	suite.model = newTestModel(suite.T())
+}
+
+func (suite *SCIMTestSuite) TestSCIMToken() {
+	s, err := suite.model.GetSCIMSettings()
+	assert.NoError(suite.T(), err, "should get default settings")
+	assert.False(suite.T(), s.Enabled, "SCIM should be disabled by default")
+
+	err = suite.model.ValidateSCIMToken("ouemscim_0123")
+	assert.ErrorIs(suite.T(), err, ErrInvalidSCIMToken, "tokens should be rejected until one is generated")
+
+	err = suite.model.SaveSCIMEnabled(true)
+	assert.NoError(suite.T(), err, "should enable SCIM")
+
+	token, err := suite.model.NewSCIMToken()
+	assert.NoError(suite.T(), err, "should generate token")
+
+	err = suite.model.ValidateSCIMToken(token)
+	assert.NoError(suite.T(), err, "should validate token")
+
+	err = suite.model.ValidateSCIMToken(token + "0")
+	assert.ErrorIs(suite.T(), err, ErrInvalidSCIMToken, "should reject wrong token")
+
+	s, err = suite.model.GetSCIMSettings()
+	assert.NoError(suite.T(), err, "should get settings")
+	assert.True(suite.T(), s.Enabled, "generating a token should keep SCIM enabled")
+	assert.False(suite.T(), s.LastUsed.IsZero(), "should record when the token was used")
+
+	newToken, err := suite.model.NewSCIMToken()
+	assert.NoError(suite.T(), err, "should generate a new token")
+
+	err = suite.model.ValidateSCIMToken(token)
+	assert.ErrorIs(suite.T(), err, ErrInvalidSCIMToken, "previous token should be revoked")
+
+	err = suite.model.ValidateSCIMToken(newToken)
+	assert.NoError(suite.T(), err, "should validate new token")
+}
+
+func (suite *SCIMTestSuite) TestSCIMUsers() {
+	disabled, err := suite.model.IsUserDisabled("jdoe")
+	assert.NoError(suite.T(), err, "should check if the user is disabled")
+	assert.False(suite.T(), disabled, "unmanaged users should be enabled")
+
+	err = suite.model.SaveSCIMUser("jdoe", "00u1", true)
+	assert.NoError(suite.T(), err, "should save SCIM user")
+	disabled, err = suite.model.IsUserDisabled("jdoe")
+	assert.NoError(suite.T(), err, "should check if the user is disabled")
+	assert.False(suite.T(), disabled)
+
+	err = suite.model.SaveSCIMUser("jdoe", "00u1", false)
+	assert.NoError(suite.T(), err, "should deactivate SCIM user")
+	disabled, err = suite.model.IsSCIMUserInactive("jdoe")
+	assert.NoError(suite.T(), err, "should check if the user is disabled")
+	assert.True(suite.T(), disabled)
+	disabled, err = suite.model.IsUserDisabled("jdoe")
+	assert.NoError(suite.T(), err, "should check if the user is disabled")
+	assert.True(suite.T(), disabled)
+
+	u, err := suite.model.GetSCIMUser("jdoe")
+	assert.NoError(suite.T(), err, "should get SCIM user")
+	assert.Equal(suite.T(), "00u1", u.ExternalID)
+
+	users, err := suite.model.GetSCIMUsers()
+	assert.NoError(suite.T(), err, "should get SCIM users")
+	assert.Equal(suite.T(), 1, len(users))
+
+	err = suite.model.DeleteSCIMUser("jdoe")
+	assert.NoError(suite.T(), err, "should delete SCIM user")
+
+	_, err = suite.model.GetSCIMUser("jdoe")
+	assert.ErrorIs(suite.T(), err, ErrSCIMUserNotFound)
+}
+
+func (suite *SCIMTestSuite) TestSCIMRoleMembers() {
+	err := suite.model.SaveSCIMUser("jdoe", "00u1", true)
+	assert.NoError(suite.T(), err, "should save SCIM user")
+
+	err = suite.model.AddRoleAssignment("admin", rbac.RoleGlobalAdmin, -1, -1)
+	assert.NoError(suite.T(), err, "should add role assignment")
+
+	err = suite.model.AddSCIMRoleAssignment("jdoe", rbac.RoleGlobalAdmin)
+	assert.NoError(suite.T(), err, "should add SCIM role assignment")
+
+	err = suite.model.AddSCIMRoleAssignment("local", rbac.RoleGlobalAdmin)
+	assert.NoError(suite.T(), err, "should add SCIM role assignment")
+
+	members, err := suite.model.GetSCIMRoleMembers(rbac.RoleGlobalAdmin)
+	assert.NoError(suite.T(), err, "should get SCIM role members")
+	assert.Equal(suite.T(), 1, len(members), "only roles granted by SCIM to provisioned users should be members")
+	assert.Equal(suite.T(), "jdoe", members[0].UserID)
+	assert.Equal(suite.T(), consoledb.RoleSourceSCIM, members[0].Source)
+
+	members, err = suite.model.GetSCIMRoleMembers(rbac.RoleAuditor)
+	assert.NoError(suite.T(), err, "should get SCIM role members")
+	assert.Empty(suite.T(), members)
+}
+
+func TestSCIMTestSuite(t *testing.T) {
+	suite.Run(t, new(SCIMTestSuite))
+}
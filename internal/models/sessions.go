@@ -2,14 +2,26 @@ package models
 
 import (
 	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
 	"errors"
+	"slices"
+	"time"
 
+	entsql "entgo.io/ent/dialect/sql"
 	ent "github.com/open-uem/ent"
 	"github.com/open-uem/ent/sessions"
+	"github.com/open-uem/ent/user"
+	"github.com/open-uem/openuem-console/internal/consoledb"
 	"github.com/open-uem/openuem-console/internal/views/partials"
 	"github.com/open-uem/utils"
 )
 
+var ErrUserSessionNotFound = errors.New("the session doesn't exist")
+
+var sessionInfoColumns = []string{"id", "token_hash", "user_id", "user_agent", "ip_address", "created", "last_seen"}
+
 func (m *Model) CountAllSessions() (int, error) {
 	count, err := m.Client.Sessions.Query().Count(context.Background())
 	if err != nil {
@@ -62,6 +74,21 @@ func (m *Model) DeleteSession(token string) error {
 	return nil
 }
 
+// DeleteUserSessions signs the user out of every browser
+func (m *Model) DeleteUserSessions(userID string) error {
+	if _, err := m.Client.Sessions.Delete().Where(sessions.HasOwnerWith(user.ID(userID))).Exec(context.Background()); err != nil {
+		return err
+	}
+
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Delete(consoledb.SessionInfoTable.Name).
+		Where(entsql.EQ("user_id", userID)).
+		Query()
+
+	_, err := m.Driver.DB().ExecContext(context.Background(), query, args...)
+	return err
+}
+
 func (m *Model) GetSessionsTokens() ([]*ent.Sessions, error) {
 	return m.Client.Sessions.Query().Select(sessions.FieldID).All(context.Background())
 }
@@ -125,3 +152,227 @@ func (m *Model) AddUserToSession(token string, userID string, encryptionMasterKe
 
 	return nil
 }
+
+// SessionTokenHash identifies a session in the console tables without storing its token
+func SessionTokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// TouchSession records the device a session is used from and when it was last seen,
+// it reports whether the console sees the session for the first time. The first requests
+// of a session may arrive at the same time, only one of them creates the session info
+func (m *Model) TouchSession(token, userID, userAgent, ipAddress string) (bool, error) {
+	ctx := context.Background()
+	hash := SessionTokenHash(token)
+	now := time.Now()
+
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Update(consoledb.SessionInfoTable.Name).
+		Set("user_agent", userAgent).
+		Set("ip_address", ipAddress).
+		Set("last_seen", now).
+		Where(entsql.EQ("token_hash", hash)).
+		Query()
+
+	res, err := m.Driver.DB().ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	if n > 0 {
+		return false, nil
+	}
+
+	query, args = entsql.Dialect(m.Driver.Dialect()).
+		Insert(consoledb.SessionInfoTable.Name).
+		Columns("token_hash", "user_id", "user_agent", "ip_address", "created", "last_seen").
+		Values(hash, userID, userAgent, ipAddress, now, now).
+		OnConflict(entsql.ConflictColumns("token_hash"), entsql.DoNothing()).
+		Query()
+
+	res, err = m.Driver.DB().ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+
+	n, err = res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n == 1, nil
+}
+
+// GetUserSessions returns the live sessions of the user, the most recent first. The device
+// info of the sessions that no longer exist is removed
+func (m *Model) GetUserSessions(userID string, encryptionMasterKey string) ([]consoledb.UserSession, error) {
+	live, err := m.Client.Sessions.Query().
+		Where(sessions.HasOwnerWith(user.ID(userID)), sessions.ExpiryGT(time.Now())).
+		All(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	infos, err := m.querySessionInfo(func(s *entsql.Selector) {
+		s.Where(entsql.EQ("user_id", userID))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	userSessions := []consoledb.UserSession{}
+	for _, s := range live {
+		token := s.ID
+		if encryptionMasterKey != "" {
+			isTokenEncrypted, err := utils.IsSensitiveFieldEncrypted(s.ID, encryptionMasterKey)
+			if err != nil {
+				return nil, err
+			}
+
+			if isTokenEncrypted {
+				token, err = utils.DecryptSensitiveField(s.ID, encryptionMasterKey)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		us := consoledb.UserSession{Token: s.ID, TokenHash: SessionTokenHash(token), Expiry: s.Expiry}
+		if index := slices.IndexFunc(infos, func(i consoledb.SessionInfo) bool { return i.TokenHash == us.TokenHash }); index != -1 {
+			us.Info = infos[index]
+		}
+		userSessions = append(userSessions, us)
+	}
+
+	for _, i := range infos {
+		if slices.ContainsFunc(userSessions, func(us consoledb.UserSession) bool { return us.TokenHash == i.TokenHash }) {
+			continue
+		}
+		if err := m.deleteSessionInfo(i.TokenHash); err != nil {
+			return nil, err
+		}
+	}
+
+	slices.SortStableFunc(userSessions, func(a, b consoledb.UserSession) int {
+		return b.Info.Created.Compare(a.Info.Created)
+	})
+
+	return userSessions, nil
+}
+
+// DeleteUserSession signs the user out of the session with the token hash
+func (m *Model) DeleteUserSession(userID string, tokenHash string, encryptionMasterKey string) error {
+	userSessions, err := m.GetUserSessions(userID, encryptionMasterKey)
+	if err != nil {
+		return err
+	}
+
+	index := slices.IndexFunc(userSessions, func(us consoledb.UserSession) bool { return us.TokenHash == tokenHash })
+	if index == -1 {
+		return ErrUserSessionNotFound
+	}
+
+	if err := m.DeleteSession(userSessions[index].Token); err != nil {
+		return err
+	}
+
+	return m.deleteSessionInfo(tokenHash)
+}
+
+// EvictUserSessions signs the user out of its oldest sessions until only maxSessions are left,
+// the current session is never evicted. It returns the evicted sessions
+func (m *Model) EvictUserSessions(userID string, currentTokenHash string, maxSessions int, encryptionMasterKey string) ([]consoledb.UserSession, error) {
+	evicted := []consoledb.UserSession{}
+	if maxSessions <= 0 {
+		return evicted, nil
+	}
+
+	userSessions, err := m.GetUserSessions(userID, encryptionMasterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// the sessions are sorted from the most recent so the oldest are at the end
+	for i := len(userSessions) - 1; i >= 0 && len(userSessions)-len(evicted) > maxSessions; i-- {
+		if userSessions[i].TokenHash == currentTokenHash {
+			continue
+		}
+
+		if err := m.DeleteSession(userSessions[i].Token); err != nil && !ent.IsNotFound(err) {
+			return evicted, err
+		}
+
+		if err := m.deleteSessionInfo(userSessions[i].TokenHash); err != nil {
+			return evicted, err
+		}
+
+		evicted = append(evicted, userSessions[i])
+	}
+
+	return evicted, nil
+}
+
+// GetMaxUserSessions returns how many sessions a user can have at the same time, 0 is unlimited
+func (m *Model) GetMaxUserSessions() (int, error) {
+	var maxSessions int
+
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Select("max_sessions").
+		From(entsql.Table(consoledb.AuthPolicyTable.Name)).
+		Limit(1).
+		Query()
+
+	if err := m.Driver.DB().QueryRowContext(context.Background(), query, args...).Scan(&maxSessions); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	return maxSessions, nil
+}
+
+func (m *Model) SaveMaxUserSessions(maxSessions int) error {
+	return m.saveAuthPolicy("max_sessions", maxSessions)
+}
+
+func (m *Model) deleteSessionInfo(tokenHash string) error {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Delete(consoledb.SessionInfoTable.Name).
+		Where(entsql.EQ("token_hash", tokenHash)).
+		Query()
+
+	_, err := m.Driver.DB().ExecContext(context.Background(), query, args...)
+	return err
+}
+
+func (m *Model) querySessionInfo(modifier func(s *entsql.Selector)) ([]consoledb.SessionInfo, error) {
+	selector := entsql.Dialect(m.Driver.Dialect()).
+		Select(sessionInfoColumns...).
+		From(entsql.Table(consoledb.SessionInfoTable.Name))
+	modifier(selector)
+
+	query, args := selector.Query()
+	rows, err := m.Driver.DB().QueryContext(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	infos := []consoledb.SessionInfo{}
+	for rows.Next() {
+		var i consoledb.SessionInfo
+		if err := rows.Scan(&i.ID, &i.TokenHash, &i.UserID, &i.UserAgent, &i.IPAddress, &i.Created, &i.LastSeen); err != nil {
+			return nil, err
+		}
+		infos = append(infos, i)
+	}
+
+	return infos, rows.Err()
+}
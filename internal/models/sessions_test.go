@@ -3,11 +3,11 @@ package models
 import (
 	"context"
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
 	openuem_ent "github.com/open-uem/ent"
-	"github.com/open-uem/ent/enttest"
 	"github.com/open-uem/openuem-console/internal/views/partials"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
@@ -15,14 +15,13 @@ import (
 
 type SessionsTestSuite struct {
 	suite.Suite
-	t     enttest.TestingT
 	model Model
 	p     partials.PaginationAndSort
 }
 
 func (suite *SessionsTestSuite) SetupTest() {
-	client := enttest.Open(suite.t, "sqlite3", "file:ent?mode=memory&_fk=1")
-	suite.model = Model{Client: client}
+	suite.model = newTestModel(suite.T())
+	client := suite.model.Client
 
 	for i := 0; i <= 6; i++ {
 		err := client.User.Create().SetID(fmt.Sprintf("user%d", i)).SetName(fmt.Sprintf("User%d", i)).Exec(context.Background())
@@ -117,6 +116,101 @@ func (suite *SessionsTestSuite) TestDeleteSession() {
 	assert.Equal(suite.T(), 4, len(sessions), "number of sessions should be 4")
 }
 
+func (suite *SessionsTestSuite) TestDeleteUserSessions() {
+	err := suite.model.DeleteUserSessions("user1")
+	assert.NoError(suite.T(), err, "sessions of user1 should be deleted")
+
+	nSessions, err := suite.model.CountAllSessions()
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 6, nSessions, "only the session of user1 should be deleted")
+}
+
+func (suite *SessionsTestSuite) TestUserSessions() {
+	for i, token := range []string{"old", "current"} {
+		err := suite.model.Client.Sessions.Create().SetData([]byte(token)).SetExpiry(time.Now().Add(time.Hour)).SetID(token).SetOwnerID("user1").Exec(context.Background())
+		assert.NoError(suite.T(), err)
+
+		created, err := suite.model.TouchSession(token, "user1", "Mozilla/5.0", fmt.Sprintf("10.0.0.%d", i))
+		assert.NoError(suite.T(), err, "should record session")
+		assert.True(suite.T(), created, "session should be new")
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	created, err := suite.model.TouchSession("current", "user1", "Mozilla/5.0", "10.0.0.9")
+	assert.NoError(suite.T(), err, "should record session")
+	assert.False(suite.T(), created, "session should have been recorded before")
+
+	userSessions, err := suite.model.GetUserSessions("user1", "")
+	assert.NoError(suite.T(), err, "should get user sessions")
+	assert.Equal(suite.T(), 2, len(userSessions), "expired sessions should not be returned")
+	assert.Equal(suite.T(), "current", userSessions[0].Token, "most recent session should be first")
+	assert.Equal(suite.T(), "10.0.0.9", userSessions[0].Info.IPAddress)
+	assert.Equal(suite.T(), SessionTokenHash("current"), userSessions[0].TokenHash)
+
+	evicted, err := suite.model.EvictUserSessions("user1", SessionTokenHash("current"), 0, "")
+	assert.NoError(suite.T(), err, "should not evict sessions without limit")
+	assert.Empty(suite.T(), evicted)
+
+	evicted, err = suite.model.EvictUserSessions("user1", SessionTokenHash("current"), 1, "")
+	assert.NoError(suite.T(), err, "should evict sessions")
+	assert.Equal(suite.T(), 1, len(evicted))
+	assert.Equal(suite.T(), "old", evicted[0].Token, "oldest session should be evicted")
+
+	err = suite.model.DeleteUserSession("user2", SessionTokenHash("current"), "")
+	assert.ErrorIs(suite.T(), err, ErrUserSessionNotFound, "users should not delete sessions of other users")
+
+	err = suite.model.DeleteUserSession("user1", SessionTokenHash("current"), "")
+	assert.NoError(suite.T(), err, "should delete session")
+
+	userSessions, err = suite.model.GetUserSessions("user1", "")
+	assert.NoError(suite.T(), err, "should get user sessions")
+	assert.Empty(suite.T(), userSessions)
+}
+
+func (suite *SessionsTestSuite) TestTouchSessionConcurrently() {
+	var wg sync.WaitGroup
+	results := make(chan bool, 5)
+	for i := range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			created, err := suite.model.TouchSession("new", "user1", "Mozilla/5.0", fmt.Sprintf("10.0.0.%d", i))
+			assert.NoError(suite.T(), err, "parallel requests of a new session should not fail")
+			results <- created
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	nCreated := 0
+	for created := range results {
+		if created {
+			nCreated++
+		}
+	}
+	assert.Equal(suite.T(), 1, nCreated, "the session should be created only once")
+}
+
+func (suite *SessionsTestSuite) TestMaxUserSessions() {
+	maxSessions, err := suite.model.GetMaxUserSessions()
+	assert.NoError(suite.T(), err, "should get default limit")
+	assert.Equal(suite.T(), 0, maxSessions, "sessions should be unlimited by default")
+
+	err = suite.model.SaveRequirePhishingResistantAdmins(true)
+	assert.NoError(suite.T(), err, "should save authentication policy")
+
+	err = suite.model.SaveMaxUserSessions(3)
+	assert.NoError(suite.T(), err, "should save limit")
+
+	maxSessions, err = suite.model.GetMaxUserSessions()
+	assert.NoError(suite.T(), err, "should get limit")
+	assert.Equal(suite.T(), 3, maxSessions)
+
+	required, err := suite.model.RequirePhishingResistantAdmins()
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), required, "saving the limit should keep the rest of the policy")
+}
+
 func TestSessionsTestSuite(t *testing.T) {
 	suite.Run(t, new(SessionsTestSuite))
 }
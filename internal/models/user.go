@@ -90,7 +90,15 @@ func (m *Model) GetUsersByPage(p partials.PaginationAndSort, f filters.UserFilte
 		query.Order(ent.Desc(user.FieldID))
 	}
 
-	return query.Limit(p.PageSize).Offset((p.CurrentPage - 1) * p.PageSize).All(context.Background())
+	if p.PageSize != 0 {
+		query.Limit(p.PageSize).Offset((p.CurrentPage - 1) * p.PageSize)
+	}
+
+	return query.All(context.Background())
+}
+
+func (m *Model) GetAllUsers() ([]*ent.User, error) {
+	return m.Client.User.Query().Order(ent.Asc(user.FieldID)).All(context.Background())
 }
 
 func (m *Model) UserExists(uid string) (bool, error) {
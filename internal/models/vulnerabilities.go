@@ -0,0 +1,425 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+
+	entsql "entgo.io/ent/dialect/sql"
+	"github.com/open-uem/ent"
+	"github.com/open-uem/ent/agent"
+	"github.com/open-uem/ent/site"
+	"github.com/open-uem/ent/tenant"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/views/filters"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+	"github.com/open-uem/openuem-console/internal/vulnerabilities"
+)
+
+var vulnerabilityFindingColumns = []string{"id", "agent_id", "tenant_id", "site_id", "hostname", "vulnerability_id", "summary", "score", "severity", "kind", "software", "version", "fixed_version", "published", "scanned"}
+
+// VulnerabilitySummary counts the scanned agents of a tenant or site and their
+// findings by severity
+type VulnerabilitySummary struct {
+	Scanned     int
+	Exposed     int
+	Severities  map[string]int
+	LastScanned time.Time
+}
+
+// ExposedSoftware is a software with vulnerabilities and the agents that have it
+type ExposedSoftware struct {
+	Software        string
+	Kind            string
+	Vulnerabilities int
+	MaxScore        float64
+	Agents          int
+}
+
+// GetVulnerabilityFeed returns the state of the feed, which is empty if it has never been loaded
+func (m *Model) GetVulnerabilityFeed() (consoledb.VulnerabilityFeed, error) {
+	var feed consoledb.VulnerabilityFeed
+	var imported sql.NullTime
+
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Select("fingerprint", "files", "vulnerabilities", "imported", "error").
+		From(entsql.Table(consoledb.VulnerabilityFeedTable.Name)).
+		OrderBy(entsql.Desc("id")).
+		Limit(1).
+		Query()
+
+	err := m.Driver.DB().QueryRowContext(context.Background(), query, args...).Scan(&feed.Fingerprint, &feed.Files, &feed.Vulnerabilities, &imported, &feed.Error)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return feed, nil
+		}
+		return feed, err
+	}
+	feed.Imported = imported.Time
+
+	return feed, nil
+}
+
+func (m *Model) SaveVulnerabilityFeed(feed consoledb.VulnerabilityFeed) error {
+	ctx := context.Background()
+
+	tx, err := m.Driver.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Delete(consoledb.VulnerabilityFeedTable.Name).
+		Query()
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return err
+	}
+
+	var imported any
+	if !feed.Imported.IsZero() {
+		imported = feed.Imported
+	}
+
+	query, args = entsql.Dialect(m.Driver.Dialect()).
+		Insert(consoledb.VulnerabilityFeedTable.Name).
+		Columns("fingerprint", "files", "vulnerabilities", "imported", "error").
+		Values(feed.Fingerprint, feed.Files, feed.Vulnerabilities, imported, truncate(feed.Error, 1000)).
+		Query()
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetVulnerabilityAgents returns a batch of admitted agents of the tenant with their
+// applications and operating system
+func (m *Model) GetVulnerabilityAgents(tenantID, offset, limit int) ([]*ent.Agent, error) {
+	return m.Client.Agent.Query().
+		Where(agent.AgentStatusNEQ(agent.AgentStatusWaitingForAdmission), agent.HasSiteWith(site.HasTenantWith(tenant.ID(tenantID)))).
+		WithSite().
+		WithApps().
+		WithOperatingsystem().
+		Order(agent.ByID()).
+		Offset(offset).
+		Limit(limit).
+		All(context.Background())
+}
+
+// AgentVulnerabilitySoftware returns the applications and the operating system of an
+// agent loaded with GetVulnerabilityAgents
+func AgentVulnerabilitySoftware(a *ent.Agent) []vulnerabilities.Software {
+	software := []vulnerabilities.Software{}
+	for _, app := range a.Edges.Apps {
+		software = append(software, vulnerabilities.Software{Kind: vulnerabilities.KindApp, Name: app.Name, Version: app.Version, Publisher: app.Publisher})
+	}
+
+	if os := a.Edges.Operatingsystem; os != nil && os.Description != "" {
+		software = append(software, vulnerabilities.Software{Kind: vulnerabilities.KindOS, Name: os.Description, Version: os.Version})
+	}
+
+	return software
+}
+
+// SaveVulnerabilityScan replaces the findings of the previous scan of the agent
+func (m *Model) SaveVulnerabilityScan(a *ent.Agent, tenantID int, findings []vulnerabilities.Finding) error {
+	ctx := context.Background()
+
+	siteID := -1
+	if len(a.Edges.Site) == 1 {
+		siteID = a.Edges.Site[0].ID
+	}
+
+	tx, err := m.Driver.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, table := range []string{consoledb.VulnerabilityFindingsTable.Name, consoledb.VulnerabilityStatusTable.Name} {
+		query, args := entsql.Dialect(m.Driver.Dialect()).
+			Delete(table).
+			Where(entsql.EQ("agent_id", a.ID)).
+			Query()
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Insert(consoledb.VulnerabilityStatusTable.Name).
+		Columns("agent_id", "tenant_id", "site_id", "hostname", "findings", "scanned").
+		Values(a.ID, tenantID, siteID, a.Hostname, len(findings), now).
+		Query()
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return err
+	}
+
+	if len(findings) > 0 {
+		insert := entsql.Dialect(m.Driver.Dialect()).
+			Insert(consoledb.VulnerabilityFindingsTable.Name).
+			Columns(vulnerabilityFindingColumns[1:]...)
+		for _, f := range findings {
+			var published any
+			if !f.Published.IsZero() {
+				published = f.Published
+			}
+			insert.Values(a.ID, tenantID, siteID, a.Hostname, f.VulnerabilityID, truncate(f.Summary, 1000), f.Score, f.Severity, f.Kind, truncate(f.Software, 1000), truncate(f.Version, 250), truncate(f.FixedVersion, 250), published, now)
+		}
+
+		query, args := insert.Query()
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// DeleteVulnerabilityResults removes the results of the tenant saved before the given
+// time, which belong to agents that were removed or moved to another tenant. A zero time
+// removes every result, which is used when there's no feed
+func (m *Model) DeleteVulnerabilityResults(tenantID int, before time.Time) error {
+	for _, table := range []string{consoledb.VulnerabilityFindingsTable.Name, consoledb.VulnerabilityStatusTable.Name} {
+		where := entsql.EQ("tenant_id", tenantID)
+		if !before.IsZero() {
+			where = entsql.And(where, entsql.LT("scanned", before))
+		}
+
+		query, args := entsql.Dialect(m.Driver.Dialect()).
+			Delete(table).
+			Where(where).
+			Query()
+		if _, err := m.Driver.DB().ExecContext(context.Background(), query, args...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Model) GetVulnerabilitySummary(c *partials.CommonInfo) (VulnerabilitySummary, error) {
+	summary := VulnerabilitySummary{Severities: map[string]int{}}
+	var exposed sql.NullInt64
+
+	selector := entsql.Dialect(m.Driver.Dialect()).
+		Select(entsql.Count("*"), "SUM(CASE WHEN findings > 0 THEN 1 ELSE 0 END)").
+		From(entsql.Table(consoledb.VulnerabilityStatusTable.Name))
+	if err := applyVulnerabilityScope(selector, c); err != nil {
+		return summary, err
+	}
+
+	query, args := selector.Query()
+	if err := m.Driver.DB().QueryRowContext(context.Background(), query, args...).Scan(&summary.Scanned, &exposed); err != nil {
+		return summary, err
+	}
+	summary.Exposed = int(exposed.Int64)
+
+	if summary.Scanned == 0 {
+		return summary, nil
+	}
+
+	selector = entsql.Dialect(m.Driver.Dialect()).
+		Select("scanned").
+		From(entsql.Table(consoledb.VulnerabilityStatusTable.Name))
+	if err := applyVulnerabilityScope(selector, c); err != nil {
+		return summary, err
+	}
+	selector.OrderBy(entsql.Desc("scanned")).Limit(1)
+
+	query, args = selector.Query()
+	if err := m.Driver.DB().QueryRowContext(context.Background(), query, args...).Scan(&summary.LastScanned); err != nil {
+		return summary, err
+	}
+
+	selector = entsql.Dialect(m.Driver.Dialect()).
+		Select("severity", entsql.Count("*")).
+		From(entsql.Table(consoledb.VulnerabilityFindingsTable.Name))
+	if err := applyVulnerabilityScope(selector, c); err != nil {
+		return summary, err
+	}
+	selector.GroupBy("severity")
+
+	query, args = selector.Query()
+	rows, err := m.Driver.DB().QueryContext(context.Background(), query, args...)
+	if err != nil {
+		return summary, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var severity string
+		var count int
+		if err := rows.Scan(&severity, &count); err != nil {
+			return summary, err
+		}
+		summary.Severities[severity] = count
+	}
+
+	return summary, rows.Err()
+}
+
+// GetMostExposedSoftware returns the software found vulnerable in more agents, and
+// with the highest score when they're found in the same number of agents
+func (m *Model) GetMostExposedSoftware(c *partials.CommonInfo, limit int) ([]ExposedSoftware, error) {
+	selector := entsql.Dialect(m.Driver.Dialect()).
+		Select("software", "kind", "COUNT(DISTINCT vulnerability_id) AS vulnerabilities", "MAX(score) AS max_score", "COUNT(DISTINCT agent_id) AS agents").
+		From(entsql.Table(consoledb.VulnerabilityFindingsTable.Name))
+	if err := applyVulnerabilityScope(selector, c); err != nil {
+		return nil, err
+	}
+	selector.GroupBy("software", "kind").
+		OrderBy(entsql.Desc("agents"), entsql.Desc("max_score"), entsql.Asc("software")).
+		Limit(limit)
+
+	query, args := selector.Query()
+	rows, err := m.Driver.DB().QueryContext(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []ExposedSoftware{}
+	for rows.Next() {
+		var s ExposedSoftware
+		if err := rows.Scan(&s.Software, &s.Kind, &s.Vulnerabilities, &s.MaxScore, &s.Agents); err != nil {
+			return nil, err
+		}
+		items = append(items, s)
+	}
+
+	return items, rows.Err()
+}
+
+// GetAgentVulnerabilities returns the findings of the last scan of the agent, the highest score first
+func (m *Model) GetAgentVulnerabilities(agentID string) ([]consoledb.VulnerabilityFinding, error) {
+	return m.queryVulnerabilityFindings(func(s *entsql.Selector) {
+		s.Where(entsql.EQ("agent_id", agentID)).OrderBy(entsql.Desc("score"), entsql.Asc("vulnerability_id"), entsql.Asc("software"))
+	})
+}
+
+func (m *Model) CountVulnerabilityFindings(f filters.VulnerabilityFindingFilter, c *partials.CommonInfo) (int, error) {
+	var count int
+
+	selector := entsql.Dialect(m.Driver.Dialect()).
+		Select(entsql.Count("*")).
+		From(entsql.Table(consoledb.VulnerabilityFindingsTable.Name))
+	if err := applyVulnerabilityScope(selector, c); err != nil {
+		return 0, err
+	}
+	applyVulnerabilityFindingFilter(selector, f)
+
+	query, args := selector.Query()
+	if err := m.Driver.DB().QueryRowContext(context.Background(), query, args...).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// GetVulnerabilityFindingsByPage returns a page of findings, every finding when the page size is 0
+func (m *Model) GetVulnerabilityFindingsByPage(p partials.PaginationAndSort, f filters.VulnerabilityFindingFilter, c *partials.CommonInfo) ([]consoledb.VulnerabilityFinding, error) {
+	var scopeErr error
+
+	findings, err := m.queryVulnerabilityFindings(func(s *entsql.Selector) {
+		scopeErr = applyVulnerabilityScope(s, c)
+		applyVulnerabilityFindingFilter(s, f)
+
+		column := "score"
+		switch p.SortBy {
+		case "hostname":
+			column = "hostname"
+		case "vulnerability":
+			column = "vulnerability_id"
+		case "software":
+			column = "software"
+		case "published":
+			column = "published"
+		}
+
+		// the highest scores are shown first unless other order is chosen
+		if p.SortOrder == "asc" && p.SortBy != "" {
+			s.OrderBy(entsql.Asc(column), entsql.Asc("id"))
+		} else {
+			s.OrderBy(entsql.Desc(column), entsql.Desc("id"))
+		}
+
+		if p.PageSize != 0 {
+			s.Limit(p.PageSize).Offset((p.CurrentPage - 1) * p.PageSize)
+		}
+	})
+	if scopeErr != nil {
+		return nil, scopeErr
+	}
+
+	return findings, err
+}
+
+// applyVulnerabilityScope limits the results to the agents of the tenant, and of the site when a site is selected
+func applyVulnerabilityScope(s *entsql.Selector, c *partials.CommonInfo) error {
+	tenantID, err := strconv.Atoi(c.TenantID)
+	if err != nil {
+		return err
+	}
+	siteID, err := strconv.Atoi(c.SiteID)
+	if err != nil {
+		return err
+	}
+
+	s.Where(entsql.EQ("tenant_id", tenantID))
+	if siteID != -1 {
+		s.Where(entsql.EQ("site_id", siteID))
+	}
+
+	return nil
+}
+
+func applyVulnerabilityFindingFilter(s *entsql.Selector, f filters.VulnerabilityFindingFilter) {
+	if len(f.Hostname) > 0 {
+		s.Where(entsql.ContainsFold("hostname", f.Hostname))
+	}
+
+	if len(f.Vulnerability) > 0 {
+		s.Where(entsql.ContainsFold("vulnerability_id", f.Vulnerability))
+	}
+
+	if len(f.Software) > 0 {
+		s.Where(entsql.ContainsFold("software", f.Software))
+	}
+
+	if len(f.Severities) > 0 {
+		s.Where(entsql.In("severity", toAny(f.Severities)...))
+	}
+}
+
+func (m *Model) queryVulnerabilityFindings(modifier func(s *entsql.Selector)) ([]consoledb.VulnerabilityFinding, error) {
+	selector := entsql.Dialect(m.Driver.Dialect()).
+		Select(vulnerabilityFindingColumns...).
+		From(entsql.Table(consoledb.VulnerabilityFindingsTable.Name))
+	modifier(selector)
+
+	query, args := selector.Query()
+	rows, err := m.Driver.DB().QueryContext(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	findings := []consoledb.VulnerabilityFinding{}
+	for rows.Next() {
+		var f consoledb.VulnerabilityFinding
+		var published sql.NullTime
+		if err := rows.Scan(&f.ID, &f.AgentID, &f.TenantID, &f.SiteID, &f.Hostname, &f.VulnerabilityID, &f.Summary, &f.Score, &f.Severity, &f.Kind, &f.Software, &f.Version, &f.FixedVersion, &published, &f.Scanned); err != nil {
+			return nil, err
+		}
+		f.Published = published.Time
+		findings = append(findings, f)
+	}
+
+	return findings, rows.Err()
+}
@@ -0,0 +1,188 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/open-uem/ent/agent"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/views/filters"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+	"github.com/open-uem/openuem-console/internal/vulnerabilities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type VulnerabilitiesTestSuite struct {
+	suite.Suite
+	model       Model
+	p           partials.PaginationAndSort
+	commonInfo  *partials.CommonInfo
+	tenantID    int
+	otherSiteID int
+	feed        *vulnerabilities.Feed
+}
+
+func (suite *VulnerabilitiesTestSuite) SetupTest() {
+	suite.model = newTestModel(suite.T())
+	suite.p = partials.PaginationAndSort{CurrentPage: 1, PageSize: 5}
+	client := suite.model.Client
+
+	t, err := suite.model.CreateDefaultTenant()
+	assert.NoError(suite.T(), err, "should create default tenant")
+	suite.tenantID = t.ID
+
+	s, err := suite.model.CreateDefaultSite(t)
+	assert.NoError(suite.T(), err, "should create default site")
+
+	other, err := client.Site.Create().SetDescription("Other").SetTenantID(t.ID).Save(context.Background())
+	assert.NoError(suite.T(), err, "should create site")
+	suite.otherSiteID = other.ID
+
+	suite.commonInfo = &partials.CommonInfo{TenantID: fmt.Sprintf("%d", t.ID), SiteID: "-1"}
+
+	for i := 0; i <= 3; i++ {
+		id := fmt.Sprintf("agent%d", i)
+		query := client.Agent.Create().
+			SetID(id).
+			SetHostname(id).
+			SetOs("windows").
+			SetNickname(id).
+			SetAgentStatus(agent.AgentStatusEnabled)
+		if i == 3 {
+			query.AddSiteIDs(other.ID)
+		} else {
+			query.AddSiteIDs(s.ID)
+		}
+		err := query.Exec(context.Background())
+		assert.NoError(suite.T(), err, "should create agent")
+
+		err = client.App.Create().SetName("Mozilla Firefox").SetVersion(fmt.Sprintf("12%d.0", 5+i)).SetPublisher("Mozilla").SetOwnerID(id).Exec(context.Background())
+		assert.NoError(suite.T(), err, "should create app")
+
+		err = client.OperatingSystem.Create().
+			SetType("windows").
+			SetUsername("user").
+			SetDescription("Windows 10 Pro").
+			SetVersion(fmt.Sprintf("10.0.19045.%d", 3000+i*1000)).
+			SetOwnerID(id).
+			Exec(context.Background())
+		assert.NoError(suite.T(), err, "should create operating system")
+	}
+
+	err = client.Agent.Create().SetID("waiting").SetHostname("waiting").SetOs("windows").SetNickname("waiting").SetAgentStatus(agent.AgentStatusWaitingForAdmission).AddSiteIDs(s.ID).Exec(context.Background())
+	assert.NoError(suite.T(), err, "should create agent")
+
+	suite.feed = vulnerabilities.NewFeed([]vulnerabilities.Vulnerability{
+		{ID: "CVE-2024-0001", Summary: "Firefox", Score: 9.8, Severity: vulnerabilities.SeverityCritical, Published: time.Now(), Products: []vulnerabilities.Product{
+			{Kind: vulnerabilities.KindApp, Vendor: "mozilla", Name: "firefox", Range: vulnerabilities.Range{End: "127.0"}},
+		}},
+		{ID: "CVE-2024-0002", Summary: "Firefox", Score: 6.5, Severity: vulnerabilities.SeverityMedium, Products: []vulnerabilities.Product{
+			{Kind: vulnerabilities.KindApp, Vendor: "mozilla", Name: "firefox", Range: vulnerabilities.Range{End: "128.0"}},
+		}},
+		{ID: "CVE-2024-0003", Summary: "Windows", Score: 7.8, Severity: vulnerabilities.SeverityHigh, Products: []vulnerabilities.Product{
+			{Kind: vulnerabilities.KindOS, Vendor: "microsoft", Name: "windows_10", Range: vulnerabilities.Range{End: "10.0.19045.5000"}},
+		}},
+	}, 1)
+}
+
+func (suite *VulnerabilitiesTestSuite) scan() {
+	agents, err := suite.model.GetVulnerabilityAgents(suite.tenantID, 0, 100)
+	assert.NoError(suite.T(), err, "should get vulnerability agents")
+	assert.Equal(suite.T(), 4, len(agents), "agents waiting for admission shouldn't be scanned")
+
+	for _, a := range agents {
+		findings := suite.feed.Match(AgentVulnerabilitySoftware(a))
+		err := suite.model.SaveVulnerabilityScan(a, suite.tenantID, findings)
+		assert.NoError(suite.T(), err, "should save vulnerability scan")
+	}
+}
+
+func (suite *VulnerabilitiesTestSuite) TestVulnerabilityFeed() {
+	feed, err := suite.model.GetVulnerabilityFeed()
+	assert.NoError(suite.T(), err, "should get empty feed")
+	assert.Equal(suite.T(), consoledb.VulnerabilityFeed{}, feed)
+
+	err = suite.model.SaveVulnerabilityFeed(consoledb.VulnerabilityFeed{Fingerprint: "first", Files: 1, Vulnerabilities: 3, Imported: time.Now()})
+	assert.NoError(suite.T(), err, "should save feed")
+
+	err = suite.model.SaveVulnerabilityFeed(consoledb.VulnerabilityFeed{Fingerprint: "second", Files: 2, Vulnerabilities: 5, Imported: time.Now(), Error: "broken.json: unexpected end of JSON input"})
+	assert.NoError(suite.T(), err, "should replace feed")
+
+	feed, err = suite.model.GetVulnerabilityFeed()
+	assert.NoError(suite.T(), err, "should get feed")
+	assert.Equal(suite.T(), "second", feed.Fingerprint)
+	assert.Equal(suite.T(), 5, feed.Vulnerabilities)
+	assert.NotEmpty(suite.T(), feed.Error)
+	assert.False(suite.T(), feed.Imported.IsZero())
+}
+
+func (suite *VulnerabilitiesTestSuite) TestVulnerabilityResults() {
+	suite.scan()
+	// scanning again replaces the previous results
+	suite.scan()
+
+	summary, err := suite.model.GetVulnerabilitySummary(suite.commonInfo)
+	assert.NoError(suite.T(), err, "should get vulnerability summary")
+	assert.Equal(suite.T(), 4, summary.Scanned)
+	assert.Equal(suite.T(), 3, summary.Exposed, "agent3 has an up to date browser and operating system")
+	assert.Equal(suite.T(), map[string]int{vulnerabilities.SeverityCritical: 2, vulnerabilities.SeverityHigh: 2, vulnerabilities.SeverityMedium: 3}, summary.Severities)
+	assert.False(suite.T(), summary.LastScanned.IsZero())
+
+	exposed, err := suite.model.GetMostExposedSoftware(suite.commonInfo, 10)
+	assert.NoError(suite.T(), err, "should get most exposed software")
+	assert.Equal(suite.T(), []ExposedSoftware{
+		{Software: "Mozilla Firefox", Kind: vulnerabilities.KindApp, Vulnerabilities: 2, MaxScore: 9.8, Agents: 3},
+		{Software: "Windows 10 Pro", Kind: vulnerabilities.KindOS, Vulnerabilities: 1, MaxScore: 7.8, Agents: 2},
+	}, exposed)
+
+	count, err := suite.model.CountVulnerabilityFindings(filters.VulnerabilityFindingFilter{Severities: []string{vulnerabilities.SeverityCritical, vulnerabilities.SeverityHigh}}, suite.commonInfo)
+	assert.NoError(suite.T(), err, "should count findings")
+	assert.Equal(suite.T(), 4, count)
+
+	site := &partials.CommonInfo{TenantID: suite.commonInfo.TenantID, SiteID: fmt.Sprintf("%d", suite.otherSiteID)}
+	count, err = suite.model.CountVulnerabilityFindings(filters.VulnerabilityFindingFilter{}, site)
+	assert.NoError(suite.T(), err, "should count findings")
+	assert.Equal(suite.T(), 0, count, "agent3, the only one in the other site, has no findings")
+
+	findings, err := suite.model.GetVulnerabilityFindingsByPage(suite.p, filters.VulnerabilityFindingFilter{Software: "firefox"}, suite.commonInfo)
+	assert.NoError(suite.T(), err, "should get findings by page")
+	assert.Equal(suite.T(), 5, len(findings))
+	assert.Equal(suite.T(), "CVE-2024-0001", findings[0].VulnerabilityID, "the highest score should be first")
+	assert.Equal(suite.T(), "127.0", findings[0].FixedVersion)
+	assert.False(suite.T(), findings[0].Published.IsZero())
+
+	suite.p.SortBy = "hostname"
+	suite.p.SortOrder = "asc"
+	findings, err = suite.model.GetVulnerabilityFindingsByPage(suite.p, filters.VulnerabilityFindingFilter{Vulnerability: "0003"}, suite.commonInfo)
+	assert.NoError(suite.T(), err, "should get findings by page")
+	assert.Equal(suite.T(), 2, len(findings))
+	assert.Equal(suite.T(), "agent0", findings[0].Hostname)
+	assert.True(suite.T(), findings[0].Published.IsZero())
+
+	findings, err = suite.model.GetAgentVulnerabilities("agent0")
+	assert.NoError(suite.T(), err, "should get agent findings")
+	assert.Equal(suite.T(), 3, len(findings))
+	assert.Equal(suite.T(), []string{"CVE-2024-0001", "CVE-2024-0003", "CVE-2024-0002"}, []string{findings[0].VulnerabilityID, findings[1].VulnerabilityID, findings[2].VulnerabilityID})
+
+	err = suite.model.DeleteVulnerabilityResults(suite.tenantID, time.Now().Add(-time.Hour))
+	assert.NoError(suite.T(), err, "should delete old results")
+
+	summary, err = suite.model.GetVulnerabilitySummary(suite.commonInfo)
+	assert.NoError(suite.T(), err, "should get vulnerability summary")
+	assert.Equal(suite.T(), 4, summary.Scanned, "recent results should be kept")
+
+	err = suite.model.DeleteVulnerabilityResults(suite.tenantID, time.Time{})
+	assert.NoError(suite.T(), err, "should delete every result")
+
+	summary, err = suite.model.GetVulnerabilitySummary(suite.commonInfo)
+	assert.NoError(suite.T(), err, "should get vulnerability summary")
+	assert.Equal(suite.T(), 0, summary.Scanned)
+	assert.Empty(suite.T(), summary.Severities)
+}
+
+func TestVulnerabilitiesTestSuite(t *testing.T) {
+	suite.Run(t, new(VulnerabilitiesTestSuite))
+}
@@ -0,0 +1,230 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	entsql "entgo.io/ent/dialect/sql"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+)
+
+var ErrWebAuthnCredentialNotFound = errors.New("the security key was not found")
+
+var webAuthnCredentialColumns = []string{"id", "user_id", "name", "credential_id", "data", "created", "last_used"}
+
+// AddWebAuthnCredential stores a credential registered by the user, the whole credential is
+// kept as JSON as the public key, flags and sign counter are needed to verify the assertions
+func (m *Model) AddWebAuthnCredential(userID string, name string, credential *webauthn.Credential) error {
+	data, err := json.Marshal(credential)
+	if err != nil {
+		return err
+	}
+
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Insert(consoledb.WebAuthnCredentialsTable.Name).
+		Columns("user_id", "name", "credential_id", "data", "created").
+		Values(userID, name, base64.RawURLEncoding.EncodeToString(credential.ID), string(data), time.Now()).
+		Query()
+
+	_, err = m.Driver.DB().ExecContext(context.Background(), query, args...)
+	return err
+}
+
+// UpdateWebAuthnCredential saves the credential after a successful login so its sign counter
+// and backup flags are up to date, and sets when it was last used
+func (m *Model) UpdateWebAuthnCredential(userID string, credential *webauthn.Credential) error {
+	data, err := json.Marshal(credential)
+	if err != nil {
+		return err
+	}
+
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Update(consoledb.WebAuthnCredentialsTable.Name).
+		Set("data", string(data)).
+		Set("last_used", time.Now()).
+		Where(entsql.And(
+			entsql.EQ("user_id", userID),
+			entsql.EQ("credential_id", base64.RawURLEncoding.EncodeToString(credential.ID)),
+		)).
+		Query()
+
+	return m.execAffectingOne(query, args, ErrWebAuthnCredentialNotFound)
+}
+
+func (m *Model) GetWebAuthnCredentialsForUser(userID string) ([]consoledb.WebAuthnCredential, error) {
+	return m.queryWebAuthnCredentials(func(s *entsql.Selector) {
+		s.Where(entsql.EQ("user_id", userID)).OrderBy(entsql.Asc("created"))
+	})
+}
+
+// GetUserWebAuthnCredentials returns the decoded credentials of the user, ready to be
+// used in a WebAuthn ceremony
+func (m *Model) GetUserWebAuthnCredentials(userID string) ([]webauthn.Credential, error) {
+	items, err := m.GetWebAuthnCredentialsForUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials := []webauthn.Credential{}
+	for _, item := range items {
+		var c webauthn.Credential
+		if err := json.Unmarshal([]byte(item.Data), &c); err != nil {
+			return nil, err
+		}
+		credentials = append(credentials, c)
+	}
+
+	return credentials, nil
+}
+
+func (m *Model) CountWebAuthnCredentials(userID string) (int, error) {
+	var count int
+
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Select(entsql.Count("*")).
+		From(entsql.Table(consoledb.WebAuthnCredentialsTable.Name)).
+		Where(entsql.EQ("user_id", userID)).
+		Query()
+
+	if err := m.Driver.DB().QueryRowContext(context.Background(), query, args...).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (m *Model) HasWebAuthnCredentials(userID string) (bool, error) {
+	count, err := m.CountWebAuthnCredentials(userID)
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (m *Model) RenameWebAuthnCredential(id int, userID string, name string) error {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Update(consoledb.WebAuthnCredentialsTable.Name).
+		Set("name", name).
+		Where(entsql.And(entsql.EQ("id", id), entsql.EQ("user_id", userID))).
+		Query()
+
+	return m.execAffectingOne(query, args, ErrWebAuthnCredentialNotFound)
+}
+
+func (m *Model) DeleteWebAuthnCredential(id int, userID string) error {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Delete(consoledb.WebAuthnCredentialsTable.Name).
+		Where(entsql.And(entsql.EQ("id", id), entsql.EQ("user_id", userID))).
+		Query()
+
+	return m.execAffectingOne(query, args, ErrWebAuthnCredentialNotFound)
+}
+
+// ResetWebAuthnCredentials removes every security key of the user, it's used by admins when
+// a user has lost its keys
+func (m *Model) ResetWebAuthnCredentials(userID string) error {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Delete(consoledb.WebAuthnCredentialsTable.Name).
+		Where(entsql.EQ("user_id", userID)).
+		Query()
+
+	_, err := m.Driver.DB().ExecContext(context.Background(), query, args...)
+	return err
+}
+
+// RequirePhishingResistantAdmins reports if global and tenant admins must sign in with a
+// security key or passkey
+func (m *Model) RequirePhishingResistantAdmins() (bool, error) {
+	var required bool
+
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Select("require_phishing_resistant_admins").
+		From(entsql.Table(consoledb.AuthPolicyTable.Name)).
+		Limit(1).
+		Query()
+
+	if err := m.Driver.DB().QueryRowContext(context.Background(), query, args...).Scan(&required); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return required, nil
+}
+
+func (m *Model) SaveRequirePhishingResistantAdmins(required bool) error {
+	return m.saveAuthPolicy("require_phishing_resistant_admins", required)
+}
+
+// saveAuthPolicy updates a column of the authentication policy row, creating it the first time
+func (m *Model) saveAuthPolicy(column string, value any) error {
+	ctx := context.Background()
+
+	tx, err := m.Driver.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Update(consoledb.AuthPolicyTable.Name).
+		Set(column, value).
+		Query()
+
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		query, args = entsql.Dialect(m.Driver.Dialect()).
+			Insert(consoledb.AuthPolicyTable.Name).
+			Columns(column).
+			Values(value).
+			Query()
+
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (m *Model) queryWebAuthnCredentials(modifier func(s *entsql.Selector)) ([]consoledb.WebAuthnCredential, error) {
+	selector := entsql.Dialect(m.Driver.Dialect()).
+		Select(webAuthnCredentialColumns...).
+		From(entsql.Table(consoledb.WebAuthnCredentialsTable.Name))
+	modifier(selector)
+
+	query, args := selector.Query()
+	rows, err := m.Driver.DB().QueryContext(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	credentials := []consoledb.WebAuthnCredential{}
+	for rows.Next() {
+		var c consoledb.WebAuthnCredential
+		var lastUsed sql.NullTime
+		if err := rows.Scan(&c.ID, &c.UserID, &c.Name, &c.CredentialID, &c.Data, &c.Created, &lastUsed); err != nil {
+			return nil, err
+		}
+		c.LastUsed = lastUsed.Time
+		credentials = append(credentials, c)
+	}
+
+	return credentials, rows.Err()
+}
@@ -0,0 +1,119 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type WebAuthnTestSuite struct {
+	suite.Suite
+	model Model
+}
+
+func (suite *WebAuthnTestSuite) SetupTest() {
+	suite.model = newTestModel(suite.T())
+
+	for i, user := range []string{"user1", "user1", "user2"} {
+		credential := &webauthn.Credential{ID: []byte{byte(i), 1, 2, 3}, PublicKey: []byte{4, 5, 6}}
+		err := suite.model.AddWebAuthnCredential(user, "key", credential)
+		assert.NoError(suite.T(), err, "should add credential")
+	}
+}
+
+func (suite *WebAuthnTestSuite) TestGetUserWebAuthnCredentials() {
+	items, err := suite.model.GetWebAuthnCredentialsForUser("user1")
+	assert.NoError(suite.T(), err, "should get credentials")
+	assert.Equal(suite.T(), 2, len(items))
+	assert.Equal(suite.T(), "AAECAw", items[0].CredentialID, "credential id should be base64url encoded")
+
+	credentials, err := suite.model.GetUserWebAuthnCredentials("user1")
+	assert.NoError(suite.T(), err, "should decode credentials")
+	assert.Equal(suite.T(), 2, len(credentials))
+	assert.Equal(suite.T(), []byte{4, 5, 6}, credentials[0].PublicKey)
+
+	has, err := suite.model.HasWebAuthnCredentials("user2")
+	assert.NoError(suite.T(), err, "should check credentials")
+	assert.True(suite.T(), has)
+	has, err = suite.model.HasWebAuthnCredentials("user3")
+	assert.NoError(suite.T(), err, "should check credentials")
+	assert.False(suite.T(), has)
+}
+
+func (suite *WebAuthnTestSuite) TestUpdateWebAuthnCredential() {
+	credential := &webauthn.Credential{ID: []byte{0, 1, 2, 3}, PublicKey: []byte{4, 5, 6}}
+	credential.Authenticator.SignCount = 7
+	err := suite.model.UpdateWebAuthnCredential("user1", credential)
+	assert.NoError(suite.T(), err, "should update credential")
+
+	credentials, err := suite.model.GetUserWebAuthnCredentials("user1")
+	assert.NoError(suite.T(), err, "should decode credentials")
+	assert.Equal(suite.T(), uint32(7), credentials[0].Authenticator.SignCount)
+
+	items, err := suite.model.GetWebAuthnCredentialsForUser("user1")
+	assert.NoError(suite.T(), err, "should get credentials")
+	assert.False(suite.T(), items[0].LastUsed.IsZero(), "last used should be set after update")
+
+	err = suite.model.UpdateWebAuthnCredential("user2", credential)
+	assert.ErrorIs(suite.T(), err, ErrWebAuthnCredentialNotFound, "credential of another user should not be updated")
+}
+
+func (suite *WebAuthnTestSuite) TestRenameAndDeleteWebAuthnCredential() {
+	items, err := suite.model.GetWebAuthnCredentialsForUser("user1")
+	assert.NoError(suite.T(), err, "should get credentials")
+
+	err = suite.model.RenameWebAuthnCredential(items[0].ID, "user2", "stolen")
+	assert.ErrorIs(suite.T(), err, ErrWebAuthnCredentialNotFound, "should not rename another user's credential")
+
+	err = suite.model.RenameWebAuthnCredential(items[0].ID, "user1", "laptop")
+	assert.NoError(suite.T(), err, "should rename credential")
+
+	items, err = suite.model.GetWebAuthnCredentialsForUser("user1")
+	assert.NoError(suite.T(), err, "should get credentials")
+	assert.Equal(suite.T(), "laptop", items[0].Name)
+
+	err = suite.model.DeleteWebAuthnCredential(items[0].ID, "user2")
+	assert.ErrorIs(suite.T(), err, ErrWebAuthnCredentialNotFound, "should not delete another user's credential")
+
+	err = suite.model.DeleteWebAuthnCredential(items[0].ID, "user1")
+	assert.NoError(suite.T(), err, "should delete credential")
+
+	count, err := suite.model.CountWebAuthnCredentials("user1")
+	assert.NoError(suite.T(), err, "should count credentials")
+	assert.Equal(suite.T(), 1, count)
+}
+
+func (suite *WebAuthnTestSuite) TestResetWebAuthnCredentials() {
+	err := suite.model.ResetWebAuthnCredentials("user1")
+	assert.NoError(suite.T(), err, "should reset credentials")
+	has, err := suite.model.HasWebAuthnCredentials("user1")
+	assert.NoError(suite.T(), err, "should check credentials")
+	assert.False(suite.T(), has)
+	has, err = suite.model.HasWebAuthnCredentials("user2")
+	assert.NoError(suite.T(), err, "should check credentials")
+	assert.True(suite.T(), has, "other users should keep their credentials")
+}
+
+func (suite *WebAuthnTestSuite) TestRequirePhishingResistantAdmins() {
+	required, err := suite.model.RequirePhishingResistantAdmins()
+	assert.NoError(suite.T(), err, "should get policy")
+	assert.False(suite.T(), required, "policy should be disabled by default")
+
+	err = suite.model.SaveRequirePhishingResistantAdmins(true)
+	assert.NoError(suite.T(), err, "should save policy")
+	required, err = suite.model.RequirePhishingResistantAdmins()
+	assert.NoError(suite.T(), err, "should get policy")
+	assert.True(suite.T(), required)
+
+	err = suite.model.SaveRequirePhishingResistantAdmins(false)
+	assert.NoError(suite.T(), err, "should save policy")
+	required, err = suite.model.RequirePhishingResistantAdmins()
+	assert.NoError(suite.T(), err, "should get policy")
+	assert.False(suite.T(), required)
+}
+
+func TestWebAuthnTestSuite(t *testing.T) {
+	suite.Run(t, new(WebAuthnTestSuite))
+}
@@ -0,0 +1,525 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	entsql "entgo.io/ent/dialect/sql"
+	"github.com/google/uuid"
+	"github.com/open-uem/ent"
+	"github.com/open-uem/ent/agent"
+	"github.com/open-uem/ent/antivirus"
+	"github.com/open-uem/ent/certificate"
+	"github.com/open-uem/ent/deployment"
+	"github.com/open-uem/ent/profile"
+	"github.com/open-uem/ent/profileissue"
+	"github.com/open-uem/ent/site"
+	"github.com/open-uem/ent/systemupdate"
+	"github.com/open-uem/ent/tenant"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+	"github.com/open-uem/openuem-console/internal/webhooks"
+)
+
+var ErrWebhookNotFound = errors.New("the webhook doesn't exist")
+var ErrWebhookDeliveryNotFound = errors.New("the webhook delivery doesn't exist")
+
+var webhookColumns = []string{"id", "tenant_id", "name", "url", "secret", "events", "enabled", "created"}
+var webhookDeliveryColumns = []string{"id", "webhook_id", "tenant_id", "event", "payload", "status", "attempts", "next_attempt", "status_code", "error", "created", "delivered"}
+
+func (m *Model) AddWebhook(w consoledb.Webhook) error {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Insert(consoledb.WebhooksTable.Name).
+		Columns("tenant_id", "name", "url", "secret", "events", "enabled", "created").
+		Values(w.TenantID, w.Name, w.URL, w.Secret, strings.Join(w.Events, ","), w.Enabled, time.Now()).
+		Query()
+
+	_, err := m.Driver.DB().ExecContext(context.Background(), query, args...)
+	return err
+}
+
+// GetWebhooks returns the webhooks configured in the tenant, -1 returns the global webhooks
+func (m *Model) GetWebhooks(tenantID int) ([]consoledb.Webhook, error) {
+	return m.queryWebhooks(func(s *entsql.Selector) {
+		s.Where(entsql.EQ("tenant_id", tenantID)).OrderBy(entsql.Asc("name"))
+	})
+}
+
+func (m *Model) GetWebhook(id int, tenantID int) (consoledb.Webhook, error) {
+	hooks, err := m.queryWebhooks(func(s *entsql.Selector) {
+		s.Where(entsql.And(entsql.EQ("id", id), entsql.EQ("tenant_id", tenantID)))
+	})
+	if err != nil {
+		return consoledb.Webhook{}, err
+	}
+
+	if len(hooks) != 1 {
+		return consoledb.Webhook{}, ErrWebhookNotFound
+	}
+
+	return hooks[0], nil
+}
+
+func (m *Model) SetWebhookEnabled(id int, tenantID int, enabled bool) error {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Update(consoledb.WebhooksTable.Name).
+		Set("enabled", enabled).
+		Where(entsql.And(entsql.EQ("id", id), entsql.EQ("tenant_id", tenantID))).
+		Query()
+
+	return m.execAffectingOne(query, args, ErrWebhookNotFound)
+}
+
+// DeleteWebhook removes the webhook and its delivery log
+func (m *Model) DeleteWebhook(id int, tenantID int) error {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Delete(consoledb.WebhooksTable.Name).
+		Where(entsql.And(entsql.EQ("id", id), entsql.EQ("tenant_id", tenantID))).
+		Query()
+
+	if err := m.execAffectingOne(query, args, ErrWebhookNotFound); err != nil {
+		return err
+	}
+
+	query, args = entsql.Dialect(m.Driver.Dialect()).
+		Delete(consoledb.WebhookDeliveriesTable.Name).
+		Where(entsql.EQ("webhook_id", id)).
+		Query()
+
+	_, err := m.Driver.DB().ExecContext(context.Background(), query, args...)
+	return err
+}
+
+// QueueWebhookEvent queues a delivery for every enabled webhook subscribed to the event in
+// the tenant and for the global ones. Global events use -1 as tenantID
+func (m *Model) QueueWebhookEvent(tenantID int, event string, data any) error {
+	hooks, err := m.queryWebhooks(func(s *entsql.Selector) {
+		s.Where(entsql.And(entsql.EQ("enabled", true), entsql.In("tenant_id", -1, tenantID)))
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, w := range hooks {
+		if !slices.Contains(w.Events, event) {
+			continue
+		}
+		if err := m.queueWebhookDelivery(w, tenantID, event, data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// QueueWebhookTest queues a test event for the webhook even if it's disabled
+func (m *Model) QueueWebhookTest(w consoledb.Webhook) error {
+	return m.queueWebhookDelivery(w, w.TenantID, webhooks.EventTest, map[string]any{"webhook": w.Name})
+}
+
+func (m *Model) queueWebhookDelivery(w consoledb.Webhook, tenantID int, event string, data any) error {
+	now := time.Now()
+
+	payload, err := json.Marshal(webhooks.Payload{
+		ID:        uuid.NewString(),
+		Event:     event,
+		Timestamp: now,
+		TenantID:  tenantID,
+		Data:      data,
+	})
+	if err != nil {
+		return err
+	}
+
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Insert(consoledb.WebhookDeliveriesTable.Name).
+		Columns("webhook_id", "tenant_id", "event", "payload", "status", "attempts", "next_attempt", "created").
+		Values(w.ID, w.TenantID, event, string(payload), consoledb.WebhookDeliveryPending, 0, now, now).
+		Query()
+
+	_, err = m.Driver.DB().ExecContext(context.Background(), query, args...)
+	return err
+}
+
+// GetDueWebhookDeliveries returns the deliveries that must be tried now. Deliveries stuck in
+// the sending status, e.g. the console was stopped while sending, are tried again too
+func (m *Model) GetDueWebhookDeliveries(limit int) ([]consoledb.WebhookDelivery, error) {
+	return m.queryWebhookDeliveries(func(s *entsql.Selector) {
+		s.Where(entsql.And(
+			entsql.In("status", consoledb.WebhookDeliveryPending, consoledb.WebhookDeliverySending),
+			entsql.LTE("next_attempt", time.Now()),
+		)).OrderBy(entsql.Asc("next_attempt")).Limit(limit)
+	})
+}
+
+// ClaimWebhookDelivery marks the delivery as being sent so other console instances don't send
+// it too. It returns false if the delivery has been claimed by another instance
+func (m *Model) ClaimWebhookDelivery(d consoledb.WebhookDelivery, lease time.Duration) (bool, error) {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Update(consoledb.WebhookDeliveriesTable.Name).
+		Set("status", consoledb.WebhookDeliverySending).
+		Set("attempts", d.Attempts+1).
+		Set("next_attempt", time.Now().Add(lease)).
+		Where(entsql.And(
+			entsql.EQ("id", d.ID),
+			entsql.EQ("attempts", d.Attempts),
+			entsql.In("status", consoledb.WebhookDeliveryPending, consoledb.WebhookDeliverySending),
+		)).
+		Query()
+
+	result, err := m.Driver.DB().ExecContext(context.Background(), query, args...)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n == 1, nil
+}
+
+// SaveWebhookDeliveryResult stores the result of an attempt, status is pending if it must be retried at nextAttempt
+func (m *Model) SaveWebhookDeliveryResult(id int, status string, nextAttempt time.Time, statusCode int, errMessage string) error {
+	update := entsql.Dialect(m.Driver.Dialect()).
+		Update(consoledb.WebhookDeliveriesTable.Name).
+		Set("status", status).
+		Set("next_attempt", nextAttempt).
+		Set("status_code", statusCode).
+		Set("error", truncate(errMessage, 2000)).
+		Where(entsql.EQ("id", id))
+
+	if status == consoledb.WebhookDeliveryDelivered {
+		update.Set("delivered", time.Now())
+	}
+
+	query, args := update.Query()
+	_, err := m.Driver.DB().ExecContext(context.Background(), query, args...)
+	return err
+}
+
+// RetryWebhookDelivery queues a delivery again, resetting its attempts. A tenantID of -1 allows any delivery
+func (m *Model) RetryWebhookDelivery(id int, tenantID int) error {
+	where := entsql.EQ("id", id)
+	if tenantID != -1 {
+		where = entsql.And(where, entsql.EQ("tenant_id", tenantID))
+	}
+
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Update(consoledb.WebhookDeliveriesTable.Name).
+		Set("status", consoledb.WebhookDeliveryPending).
+		Set("attempts", 0).
+		Set("next_attempt", time.Now()).
+		Set("error", "").
+		Where(where).
+		Query()
+
+	return m.execAffectingOne(query, args, ErrWebhookDeliveryNotFound)
+}
+
+// CountWebhookDeliveries counts the deliveries of the tenant webhooks, -1 counts every delivery
+func (m *Model) CountWebhookDeliveries(tenantID int) (int, error) {
+	var count int
+
+	selector := entsql.Dialect(m.Driver.Dialect()).
+		Select(entsql.Count("*")).
+		From(entsql.Table(consoledb.WebhookDeliveriesTable.Name))
+	if tenantID != -1 {
+		selector.Where(entsql.EQ("tenant_id", tenantID))
+	}
+
+	query, args := selector.Query()
+	if err := m.Driver.DB().QueryRowContext(context.Background(), query, args...).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// GetWebhookDeliveriesByPage returns the deliveries of the tenant webhooks, -1 returns every delivery
+func (m *Model) GetWebhookDeliveriesByPage(p partials.PaginationAndSort, tenantID int) ([]consoledb.WebhookDelivery, error) {
+	column := "created"
+	switch p.SortBy {
+	case "event":
+		column = "event"
+	case "status":
+		column = "status"
+	case "attempts":
+		column = "attempts"
+	}
+
+	return m.queryWebhookDeliveries(func(s *entsql.Selector) {
+		if tenantID != -1 {
+			s.Where(entsql.EQ("tenant_id", tenantID))
+		}
+		if p.SortOrder == "asc" {
+			s.OrderBy(entsql.Asc(column), entsql.Asc("id"))
+		} else {
+			s.OrderBy(entsql.Desc(column), entsql.Desc("id"))
+		}
+		s.Limit(p.PageSize).Offset((p.CurrentPage - 1) * p.PageSize)
+	})
+}
+
+// DeleteOldWebhookDeliveries removes the deliveries that are no longer pending and were created before the date
+func (m *Model) DeleteOldWebhookDeliveries(before time.Time) error {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Delete(consoledb.WebhookDeliveriesTable.Name).
+		Where(entsql.And(
+			entsql.In("status", consoledb.WebhookDeliveryDelivered, consoledb.WebhookDeliveryFailed),
+			entsql.LT("created", before),
+		)).
+		Query()
+
+	_, err := m.Driver.DB().ExecContext(context.Background(), query, args...)
+	return err
+}
+
+// SyncWebhookState stores the objects that are currently in the state that triggers the event and
+// returns the ones that weren't in that state the last time, so the event is sent once per transition.
+// An object that leaves the state is forgotten so the event is sent again if it enters it again
+func (m *Model) SyncWebhookState(tenantID int, event string, keys []string) ([]string, error) {
+	query, args := entsql.Dialect(m.Driver.Dialect()).
+		Select("subject").
+		From(entsql.Table(consoledb.WebhookStatesTable.Name)).
+		Where(entsql.And(entsql.EQ("tenant_id", tenantID), entsql.EQ("event", event))).
+		Query()
+
+	rows, err := m.Driver.DB().QueryContext(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	known := map[string]bool{}
+	for rows.Next() {
+		var subject string
+		if err := rows.Scan(&subject); err != nil {
+			return nil, err
+		}
+		known[subject] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	added := []string{}
+	current := map[string]bool{}
+	for _, key := range keys {
+		current[key] = true
+		if known[key] {
+			continue
+		}
+
+		query, args := entsql.Dialect(m.Driver.Dialect()).
+			Insert(consoledb.WebhookStatesTable.Name).
+			Columns("tenant_id", "event", "subject", "created").
+			Values(tenantID, event, key, time.Now()).
+			Query()
+
+		// The unique index makes the insert fail if another console instance has already stored
+		// the subject, in that case that instance is responsible for the event
+		if _, err := m.Driver.DB().ExecContext(context.Background(), query, args...); err == nil {
+			added = append(added, key)
+		}
+	}
+
+	for subject := range known {
+		if current[subject] {
+			continue
+		}
+
+		query, args := entsql.Dialect(m.Driver.Dialect()).
+			Delete(consoledb.WebhookStatesTable.Name).
+			Where(entsql.And(entsql.EQ("tenant_id", tenantID), entsql.EQ("event", event), entsql.EQ("subject", subject))).
+			Query()
+
+		if _, err := m.Driver.DB().ExecContext(context.Background(), query, args...); err != nil {
+			return nil, err
+		}
+	}
+
+	return added, nil
+}
+
+// GetWebhookSubjects returns the objects of the tenant that are in the state that triggers
+// the event. Certificates don't belong to a tenant and are returned for the tenant -1
+func (m *Model) GetWebhookSubjects(tenantID int, event string) ([]webhooks.Subject, error) {
+	ctx := context.Background()
+	subjects := []webhooks.Subject{}
+	inTenant := agent.HasSiteWith(site.HasTenantWith(tenant.ID(tenantID)))
+
+	switch event {
+	case webhooks.EventAgentWaitingAdmission:
+		agents, err := m.Client.Agent.Query().Where(agent.AgentStatusEQ(agent.AgentStatusWaitingForAdmission), inTenant).All(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range agents {
+			subjects = append(subjects, webhooks.Subject{Key: a.ID, Data: WebhookAgentData(a)})
+		}
+	case webhooks.EventAntivirusDisabled:
+		agents, err := m.Client.Agent.Query().Where(agent.HasAntivirusWith(antivirus.IsActive(false)), agent.AgentStatusNEQ(agent.AgentStatusWaitingForAdmission), agent.Os("windows"), inTenant).WithAntivirus().All(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range agents {
+			data := WebhookAgentData(a)
+			if a.Edges.Antivirus != nil {
+				data["antivirus"] = a.Edges.Antivirus.Name
+			}
+			subjects = append(subjects, webhooks.Subject{Key: a.ID, Data: data})
+		}
+	case webhooks.EventUpdatesPending:
+		agents, err := m.Client.Agent.Query().Where(agent.HasSystemupdateWith(systemupdate.PendingUpdatesEQ(true)), agent.AgentStatusNEQ(agent.AgentStatusWaitingForAdmission), inTenant).All(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range agents {
+			subjects = append(subjects, webhooks.Subject{Key: a.ID, Data: WebhookAgentData(a)})
+		}
+	case webhooks.EventDeploymentFailed:
+		deployments, err := m.Client.Deployment.Query().Where(deployment.FailedEQ(true), deployment.HasOwnerWith(inTenant)).WithOwner().All(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range deployments {
+			data := map[string]any{"package_id": d.PackageID, "package_name": d.Name, "error": d.MoreInfo}
+			if d.Edges.Owner != nil {
+				data["agent"] = WebhookAgentData(d.Edges.Owner)
+			}
+			subjects = append(subjects, webhooks.Subject{Key: fmt.Sprintf("%d", d.ID), Data: data})
+		}
+	case webhooks.EventProfileIssue:
+		issues, err := m.Client.ProfileIssue.Query().Where(profileissue.ErrorNEQ(""), profileissue.HasProfileWith(profile.HasTenantWith(tenant.ID(tenantID)))).WithProfile().WithAgents().All(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, i := range issues {
+			data := map[string]any{"error": i.Error, "when": i.When}
+			if i.Edges.Profile != nil {
+				data["profile_id"] = i.Edges.Profile.ID
+				data["profile_name"] = i.Edges.Profile.Name
+			}
+			if i.Edges.Agents != nil {
+				data["agent"] = WebhookAgentData(i.Edges.Agents)
+			}
+			subjects = append(subjects, webhooks.Subject{Key: fmt.Sprintf("%d", i.ID), Data: data})
+		}
+	case webhooks.EventCertificateExpiring:
+		if tenantID != -1 {
+			return subjects, nil
+		}
+		certificates, err := m.Client.Certificate.Query().Where(certificate.ExpiryNotNil(), certificate.ExpiryLTE(time.Now().Add(webhooks.CertificateExpiryWarning))).All(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range certificates {
+			if c.Expiry.IsZero() {
+				continue
+			}
+			data := map[string]any{"serial": fmt.Sprintf("%d", c.ID), "type": c.Type.String(), "description": c.Description, "expiry": c.Expiry}
+			if c.UID != "" {
+				data["uid"] = c.UID
+			}
+			subjects = append(subjects, webhooks.Subject{Key: fmt.Sprintf("%d", c.ID), Data: data})
+		}
+	}
+
+	return subjects, nil
+}
+
+// WebhookAgentData returns the agent fields sent in webhook payloads
+func WebhookAgentData(a *ent.Agent) map[string]any {
+	return map[string]any{"id": a.ID, "hostname": a.Hostname, "nickname": a.Nickname, "os": a.Os, "ip": a.IP}
+}
+
+func (m *Model) execAffectingOne(query string, args []any, notFound error) error {
+	result, err := m.Driver.DB().ExecContext(context.Background(), query, args...)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return notFound
+	}
+
+	return nil
+}
+
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max]
+}
+
+func (m *Model) queryWebhooks(modifier func(s *entsql.Selector)) ([]consoledb.Webhook, error) {
+	selector := entsql.Dialect(m.Driver.Dialect()).
+		Select(webhookColumns...).
+		From(entsql.Table(consoledb.WebhooksTable.Name))
+	modifier(selector)
+
+	query, args := selector.Query()
+	rows, err := m.Driver.DB().QueryContext(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hooks := []consoledb.Webhook{}
+	for rows.Next() {
+		var w consoledb.Webhook
+		var events string
+		if err := rows.Scan(&w.ID, &w.TenantID, &w.Name, &w.URL, &w.Secret, &events, &w.Enabled, &w.Created); err != nil {
+			return nil, err
+		}
+		if events != "" {
+			w.Events = strings.Split(events, ",")
+		}
+		hooks = append(hooks, w)
+	}
+
+	return hooks, rows.Err()
+}
+
+func (m *Model) queryWebhookDeliveries(modifier func(s *entsql.Selector)) ([]consoledb.WebhookDelivery, error) {
+	selector := entsql.Dialect(m.Driver.Dialect()).
+		Select(webhookDeliveryColumns...).
+		From(entsql.Table(consoledb.WebhookDeliveriesTable.Name))
+	modifier(selector)
+
+	query, args := selector.Query()
+	rows, err := m.Driver.DB().QueryContext(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []consoledb.WebhookDelivery{}
+	for rows.Next() {
+		var d consoledb.WebhookDelivery
+		var delivered sql.NullTime
+		if err := rows.Scan(&d.ID, &d.WebhookID, &d.TenantID, &d.Event, &d.Payload, &d.Status, &d.Attempts, &d.NextAttempt, &d.StatusCode, &d.Error, &d.Created, &delivered); err != nil {
+			return nil, err
+		}
+		if delivered.Valid {
+			d.Delivered = delivered.Time
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, rows.Err()
+}
@@ -0,0 +1,216 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/open-uem/ent/agent"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/views/partials"
+	"github.com/open-uem/openuem-console/internal/webhooks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type WebhooksTestSuite struct {
+	suite.Suite
+	model    Model
+	p        partials.PaginationAndSort
+	tenantID int
+}
+
+func (suite *WebhooksTestSuite) SetupTest() {
+	suite.model = newTestModel(suite.T())
+	suite.p = partials.PaginationAndSort{CurrentPage: 1, PageSize: 5}
+
+	t, err := suite.model.CreateDefaultTenant()
+	assert.NoError(suite.T(), err, "should create default tenant")
+	suite.tenantID = t.ID
+
+	s, err := suite.model.CreateDefaultSite(t)
+	assert.NoError(suite.T(), err, "should create default site")
+
+	for i := 0; i <= 2; i++ {
+		status := agent.AgentStatusEnabled
+		if i == 0 {
+			status = agent.AgentStatusWaitingForAdmission
+		}
+		err := suite.model.Client.Agent.Create().
+			SetID(fmt.Sprintf("agent%d", i)).
+			SetHostname(fmt.Sprintf("agent%d", i)).
+			SetOs("windows").
+			SetNickname(fmt.Sprintf("agent%d", i)).
+			SetAgentStatus(status).
+			AddSiteIDs(s.ID).
+			Exec(context.Background())
+		assert.NoError(suite.T(), err, "should create agent")
+	}
+
+	hooks := []consoledb.Webhook{
+		{TenantID: suite.tenantID, Name: "tickets", URL: "https://tickets.example.com", Secret: "secret1", Events: []string{webhooks.EventAgentAdmitted, webhooks.EventAntivirusDisabled}, Enabled: true},
+		{TenantID: -1, Name: "chat", URL: "https://chat.example.com", Secret: "secret2", Events: []string{webhooks.EventAgentAdmitted}, Enabled: true},
+		{TenantID: suite.tenantID, Name: "disabled", URL: "https://disabled.example.com", Secret: "secret3", Events: []string{webhooks.EventAgentAdmitted}, Enabled: false},
+		{TenantID: suite.tenantID + 1, Name: "other", URL: "https://other.example.com", Secret: "secret4", Events: []string{webhooks.EventAgentAdmitted}, Enabled: true},
+	}
+	for _, w := range hooks {
+		err := suite.model.AddWebhook(w)
+		assert.NoError(suite.T(), err, "should add webhook")
+	}
+}
+
+func (suite *WebhooksTestSuite) TestGetWebhooks() {
+	hooks, err := suite.model.GetWebhooks(suite.tenantID)
+	assert.NoError(suite.T(), err, "should get webhooks")
+	assert.Equal(suite.T(), 2, len(hooks))
+	assert.Equal(suite.T(), "disabled", hooks[0].Name)
+	assert.Equal(suite.T(), []string{webhooks.EventAgentAdmitted, webhooks.EventAntivirusDisabled}, hooks[1].Events)
+
+	w, err := suite.model.GetWebhook(hooks[1].ID, suite.tenantID)
+	assert.NoError(suite.T(), err, "should get webhook")
+	assert.Equal(suite.T(), "secret1", w.Secret)
+
+	_, err = suite.model.GetWebhook(hooks[1].ID, -1)
+	assert.ErrorIs(suite.T(), err, ErrWebhookNotFound, "should not get webhooks from another tenant")
+
+	err = suite.model.SetWebhookEnabled(hooks[0].ID, suite.tenantID, true)
+	assert.NoError(suite.T(), err, "should enable webhook")
+
+	err = suite.model.DeleteWebhook(hooks[0].ID, -1)
+	assert.ErrorIs(suite.T(), err, ErrWebhookNotFound, "should not delete webhooks from another tenant")
+
+	err = suite.model.DeleteWebhook(hooks[0].ID, suite.tenantID)
+	assert.NoError(suite.T(), err, "should delete webhook")
+
+	hooks, err = suite.model.GetWebhooks(suite.tenantID)
+	assert.NoError(suite.T(), err, "should get webhooks")
+	assert.Equal(suite.T(), 1, len(hooks))
+	assert.True(suite.T(), hooks[0].Enabled)
+}
+
+func (suite *WebhooksTestSuite) TestQueueWebhookEvent() {
+	err := suite.model.QueueWebhookEvent(suite.tenantID, webhooks.EventAgentAdmitted, map[string]any{"id": "agent1"})
+	assert.NoError(suite.T(), err, "should queue event")
+
+	err = suite.model.QueueWebhookEvent(suite.tenantID, webhooks.EventAgentDeleted, nil)
+	assert.NoError(suite.T(), err, "should queue event")
+
+	count, err := suite.model.CountWebhookDeliveries(-1)
+	assert.NoError(suite.T(), err, "should count deliveries")
+	assert.Equal(suite.T(), 2, count, "only enabled webhooks subscribed to the event in the tenant or global should get the event")
+
+	count, err = suite.model.CountWebhookDeliveries(suite.tenantID)
+	assert.NoError(suite.T(), err, "should count deliveries")
+	assert.Equal(suite.T(), 1, count)
+
+	deliveries, err := suite.model.GetDueWebhookDeliveries(10)
+	assert.NoError(suite.T(), err, "should get due deliveries")
+	assert.Equal(suite.T(), 2, len(deliveries))
+
+	var payload webhooks.Payload
+	err = json.Unmarshal([]byte(deliveries[0].Payload), &payload)
+	assert.NoError(suite.T(), err, "payload should be JSON")
+	assert.Equal(suite.T(), webhooks.EventAgentAdmitted, payload.Event)
+	assert.Equal(suite.T(), suite.tenantID, payload.TenantID)
+	assert.NotEmpty(suite.T(), payload.ID)
+}
+
+func (suite *WebhooksTestSuite) TestDeliveryLifecycle() {
+	hooks, err := suite.model.GetWebhooks(suite.tenantID)
+	assert.NoError(suite.T(), err, "should get webhooks")
+
+	err = suite.model.QueueWebhookTest(hooks[0])
+	assert.NoError(suite.T(), err, "should queue test even for disabled webhooks")
+
+	deliveries, err := suite.model.GetDueWebhookDeliveries(10)
+	assert.NoError(suite.T(), err, "should get due deliveries")
+	assert.Equal(suite.T(), 1, len(deliveries))
+	d := deliveries[0]
+
+	claimed, err := suite.model.ClaimWebhookDelivery(d, time.Minute)
+	assert.NoError(suite.T(), err, "should claim delivery")
+	assert.True(suite.T(), claimed)
+
+	claimed, err = suite.model.ClaimWebhookDelivery(d, time.Minute)
+	assert.NoError(suite.T(), err, "should try to claim delivery")
+	assert.False(suite.T(), claimed, "a delivery can't be claimed twice")
+
+	deliveries, err = suite.model.GetDueWebhookDeliveries(10)
+	assert.NoError(suite.T(), err, "should get due deliveries")
+	assert.Equal(suite.T(), 0, len(deliveries), "claimed deliveries should not be due")
+
+	err = suite.model.SaveWebhookDeliveryResult(d.ID, consoledb.WebhookDeliveryFailed, time.Now(), 500, "the endpoint returned 500")
+	assert.NoError(suite.T(), err, "should save result")
+
+	deliveries, err = suite.model.GetWebhookDeliveriesByPage(suite.p, suite.tenantID)
+	assert.NoError(suite.T(), err, "should get deliveries by page")
+	assert.Equal(suite.T(), 1, len(deliveries))
+	assert.Equal(suite.T(), consoledb.WebhookDeliveryFailed, deliveries[0].Status)
+	assert.Equal(suite.T(), 1, deliveries[0].Attempts)
+	assert.Equal(suite.T(), 500, deliveries[0].StatusCode)
+
+	err = suite.model.RetryWebhookDelivery(d.ID, suite.tenantID+1)
+	assert.ErrorIs(suite.T(), err, ErrWebhookDeliveryNotFound, "should not retry deliveries from another tenant")
+
+	err = suite.model.RetryWebhookDelivery(d.ID, suite.tenantID)
+	assert.NoError(suite.T(), err, "should retry delivery")
+
+	deliveries, err = suite.model.GetDueWebhookDeliveries(10)
+	assert.NoError(suite.T(), err, "should get due deliveries")
+	assert.Equal(suite.T(), 1, len(deliveries))
+	assert.Equal(suite.T(), 0, deliveries[0].Attempts)
+
+	err = suite.model.SaveWebhookDeliveryResult(d.ID, consoledb.WebhookDeliveryDelivered, time.Now(), 200, "")
+	assert.NoError(suite.T(), err, "should save result")
+
+	err = suite.model.DeleteOldWebhookDeliveries(time.Now().Add(time.Minute))
+	assert.NoError(suite.T(), err, "should delete old deliveries")
+
+	count, err := suite.model.CountWebhookDeliveries(-1)
+	assert.NoError(suite.T(), err, "should count deliveries")
+	assert.Equal(suite.T(), 0, count)
+}
+
+func (suite *WebhooksTestSuite) TestSyncWebhookState() {
+	added, err := suite.model.SyncWebhookState(suite.tenantID, webhooks.EventAntivirusDisabled, []string{"agent1", "agent2"})
+	assert.NoError(suite.T(), err, "should sync state")
+	assert.ElementsMatch(suite.T(), []string{"agent1", "agent2"}, added)
+
+	added, err = suite.model.SyncWebhookState(suite.tenantID, webhooks.EventAntivirusDisabled, []string{"agent1", "agent2"})
+	assert.NoError(suite.T(), err, "should sync state")
+	assert.Empty(suite.T(), added, "objects still in the state should not trigger the event again")
+
+	added, err = suite.model.SyncWebhookState(suite.tenantID, webhooks.EventAntivirusDisabled, []string{"agent2"})
+	assert.NoError(suite.T(), err, "should sync state")
+	assert.Empty(suite.T(), added)
+
+	added, err = suite.model.SyncWebhookState(suite.tenantID, webhooks.EventAntivirusDisabled, []string{"agent1", "agent2"})
+	assert.NoError(suite.T(), err, "should sync state")
+	assert.Equal(suite.T(), []string{"agent1"}, added, "objects entering the state again should trigger the event")
+}
+
+func (suite *WebhooksTestSuite) TestGetWebhookSubjects() {
+	subjects, err := suite.model.GetWebhookSubjects(suite.tenantID, webhooks.EventAgentWaitingAdmission)
+	assert.NoError(suite.T(), err, "should get subjects")
+	assert.Equal(suite.T(), 1, len(subjects))
+	assert.Equal(suite.T(), "agent0", subjects[0].Key)
+	assert.Equal(suite.T(), "agent0", subjects[0].Data["hostname"])
+
+	err = suite.model.Client.Antivirus.Create().SetName("Defender").SetIsActive(false).SetIsUpdated(true).SetOwnerID("agent1").Exec(context.Background())
+	assert.NoError(suite.T(), err, "should create antivirus")
+
+	subjects, err = suite.model.GetWebhookSubjects(suite.tenantID, webhooks.EventAntivirusDisabled)
+	assert.NoError(suite.T(), err, "should get subjects")
+	assert.Equal(suite.T(), 1, len(subjects))
+	assert.Equal(suite.T(), "Defender", subjects[0].Data["antivirus"])
+
+	subjects, err = suite.model.GetWebhookSubjects(suite.tenantID+1, webhooks.EventAntivirusDisabled)
+	assert.NoError(suite.T(), err, "should get subjects")
+	assert.Equal(suite.T(), 0, len(subjects), "should not get subjects from other tenants")
+}
+
+func TestWebhooksTestSuite(t *testing.T) {
+	suite.Run(t, new(WebhooksTestSuite))
+}
@@ -0,0 +1,292 @@
+// Package rbac defines the roles that can be assigned to console users, the
+// permission granted by each role and the permission required by each route.
+package rbac
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/open-uem/openuem-console/internal/consoledb"
+)
+
+type Role string
+
+const (
+	RoleGlobalAdmin Role = "global_admin"
+	RoleTenantAdmin Role = "tenant_admin"
+	RoleOperator    Role = "operator"
+	RoleHelpdesk    Role = "helpdesk"
+	RoleAuditor     Role = "auditor"
+)
+
+// Roles contains the available roles, from the most to the least privileged
+var Roles = []Role{RoleGlobalAdmin, RoleTenantAdmin, RoleOperator, RoleHelpdesk, RoleAuditor}
+
+// Permission levels are cumulative, a role granting a level also grants the lower ones
+type Permission int
+
+const (
+	// PermissionNone is required by pages any authenticated user can visit, e.g. the account page
+	PermissionNone Permission = iota
+	// PermissionView allows reading inventory, reports and settings
+	PermissionView
+	// PermissionRemote allows remote assistance, power actions and browsing the endpoint files
+	PermissionRemote
+	// PermissionManage allows deploying software, editing profiles and managing agents
+	PermissionManage
+	// PermissionTenantAdmin allows changing the configuration of a tenant
+	PermissionTenantAdmin
+	// PermissionGlobalAdmin allows changing the global configuration, users and tenants
+	PermissionGlobalAdmin
+)
+
+func (r Role) Permission() Permission {
+	switch r {
+	case RoleGlobalAdmin:
+		return PermissionGlobalAdmin
+	case RoleTenantAdmin:
+		return PermissionTenantAdmin
+	case RoleOperator:
+		return PermissionManage
+	case RoleHelpdesk:
+		return PermissionRemote
+	case RoleAuditor:
+		return PermissionView
+	default:
+		return PermissionNone
+	}
+}
+
+func (r Role) IsValid() bool {
+	return r.Permission() != PermissionNone
+}
+
+// Access holds the role assignments of a user
+type Access struct {
+	Assignments []consoledb.RoleAssignment
+	// Unrestricted is set while no role has been assigned in the console, so
+	// existing installations behave as before until the first role is assigned
+	Unrestricted bool
+}
+
+// Level returns the highest permission the user has in the tenant and site. A tenantID
+// of -1 is the global scope and a siteID of -1 means every site in the tenant
+func (a Access) Level(tenantID, siteID int) Permission {
+	if a.Unrestricted {
+		return PermissionGlobalAdmin
+	}
+
+	level := PermissionNone
+	for _, r := range a.Assignments {
+		if !covers(r, tenantID, siteID) {
+			continue
+		}
+		if p := Role(r.Role).Permission(); p > level {
+			level = p
+		}
+	}
+	return level
+}
+
+// CanAccessTenant reports if the user has any role in the tenant, even if only for some sites
+func (a Access) CanAccessTenant(tenantID int) bool {
+	if a.Unrestricted {
+		return true
+	}
+
+	for _, r := range a.Assignments {
+		if r.TenantID == -1 || r.TenantID == tenantID {
+			return true
+		}
+	}
+	return false
+}
+
+// CanAccessSite reports if the user has any role in the site
+func (a Access) CanAccessSite(tenantID, siteID int) bool {
+	return a.Level(tenantID, siteID) > PermissionNone
+}
+
+func (a Access) IsGlobalAdmin() bool {
+	return a.Level(-1, -1) >= PermissionGlobalAdmin
+}
+
+// AdminTenant returns the tenant the user can administer, -1 if the user can
+// change the global configuration. It returns false if the user is not an admin
+func (a Access) AdminTenant() (int, bool) {
+	if a.IsGlobalAdmin() {
+		return -1, true
+	}
+
+	for _, r := range a.Assignments {
+		if r.TenantID != -1 && r.SiteID == -1 && Role(r.Role).Permission() >= PermissionTenantAdmin {
+			return r.TenantID, true
+		}
+	}
+	return 0, false
+}
+
+// Home returns the first tenant and site where the user has a role, it's used
+// to send users with roles restricted to a tenant or site to a page they can see
+func (a Access) Home() (tenantID int, siteID int, ok bool) {
+	for _, r := range a.Assignments {
+		if Role(r.Role).IsValid() {
+			return r.TenantID, r.SiteID, true
+		}
+	}
+	return -1, -1, false
+}
+
+func covers(r consoledb.RoleAssignment, tenantID, siteID int) bool {
+	if r.TenantID == -1 {
+		return true
+	}
+	if r.TenantID != tenantID {
+		return false
+	}
+	return r.SiteID == -1 || r.SiteID == siteID
+}
+
+// Scope splits a route path as registered in echo into the tenant and site prefixes
+// and the remaining path, e.g. /tenant/:tenant/site/:site/computers returns
+// true, true and /computers. The /api/v1 prefix is removed too
+func Scope(routePath string) (hasTenant bool, hasSite bool, path string) {
+	path = routePath
+	if rest, found := strings.CutPrefix(path, "/api/"); found {
+		if _, p, ok := strings.Cut(rest, "/"); ok {
+			path = "/" + p
+		} else {
+			path = "/"
+		}
+	}
+
+	path, hasTenant = cutSegment(path, "/tenant/:tenant")
+	if hasTenant {
+		path, hasSite = cutSegment(path, "/site/:site")
+	}
+
+	if path == "" {
+		path = "/"
+	}
+	return hasTenant, hasSite, path
+}
+
+func cutSegment(path string, segment string) (string, bool) {
+	if path == segment {
+		return "", true
+	}
+	if rest, found := strings.CutPrefix(path, segment+"/"); found {
+		return "/" + rest, true
+	}
+	return path, false
+}
+
+// viewRoutes are the routes that use POST or DELETE to filter, search or
+// export information without changing it, or to save the views and dashboards of a user
+var viewRoutes = []string{
+	"/agents",
+	"/agents/views*",
+	"/computers",
+	"/computers/columns",
+	"/computers/views*",
+	"/computers/:uuid/software",
+	"/computers/:uuid/sites",
+	"/software",
+	"/security*",
+	"/reports/*",
+	"/inventory-changes",
+	"/compliance",
+	"/vulnerabilities",
+	"/licenses",
+	"/hardware-lifecycle",
+	"/dashboards*",
+	"/maintenance-queue",
+	"/packages",
+	"/flatpak",
+	"/brew-casks",
+	"/brew-formulae",
+	"/tenant/sites",
+	"/profiles/:uuid/issues",
+}
+
+// remoteRoutes are the routes used to assist the user of an endpoint
+var remoteRoutes = []string{
+	"/computers/:uuid/power/:action",
+	"/computers/:uuid/startvnc",
+	"/computers/:uuid/stopvnc",
+	"/computers/:uuid/rustdesk",
+	"/computers/:uuid/startrustdesk",
+	"/computers/:uuid/stoprustdesk",
+	"/computers/:uuid/generaterdp",
+	"/agents/:uuid/forcereport",
+	"/agents/:uuid/forcerestart",
+	"/computers/bulk/reboot",
+	"/computers/bulk/power-off",
+	"/computers/bulk/wol",
+	"/computers/bulk/force-report",
+}
+
+// manageRoutes are the routes that change something even when they're requested with GET
+var manageRoutes = []string{
+	"/agents/admit",
+	"/agents/enable",
+	"/agents/disable",
+	"/agents/:uuid/admit",
+	"/agents/:uuid/disable",
+	"/agents/:uuid/delete",
+	"/agents/:uuid/regeneratecerts",
+	"/deploy*",
+	"/profiles/new",
+	"/profiles/:uuid/clone",
+	"/profiles/:uuid/confirm-delete",
+	"/tasks/:id/clone",
+	"/tasks/:profile/new",
+	"/tasks/:profile/confirm-delete/:task",
+}
+
+// RequiredPermission returns the permission needed to request the route path, as
+// registered in echo, with the HTTP method. Routes that aren't listed require
+// PermissionView to be read and PermissionManage for any other method
+func RequiredPermission(method string, routePath string) Permission {
+	hasTenant, _, path := Scope(routePath)
+
+	switch {
+	case matches(path, "/myaccount*") || path == "/render-markdown" || matches(path, "/download/*"):
+		return PermissionNone
+	case matches(path, "/admin*"):
+		if hasTenant {
+			return PermissionTenantAdmin
+		}
+		return PermissionGlobalAdmin
+	case matchesAny(path, remoteRoutes):
+		return PermissionRemote
+	case matches(path, "/computers/:uuid/logical-disks*") || path == "/computers/:uuid/notes":
+		if method == http.MethodGet {
+			return PermissionView
+		}
+		return PermissionRemote
+	case matchesAny(path, manageRoutes):
+		return PermissionManage
+	case method == http.MethodGet || method == http.MethodHead || matchesAny(path, viewRoutes):
+		return PermissionView
+	default:
+		return PermissionManage
+	}
+}
+
+// matches compares a path with a pattern, a trailing * matches any suffix
+func matches(path string, pattern string) bool {
+	if prefix, found := strings.CutSuffix(pattern, "*"); found {
+		return strings.HasPrefix(path, prefix)
+	}
+	return path == pattern
+}
+
+func matchesAny(path string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matches(path, pattern) {
+			return true
+		}
+	}
+	return false
+}
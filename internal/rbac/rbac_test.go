@@ -0,0 +1,118 @@
+package rbac
+
+import (
+	"testing"
+
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccessLevel(t *testing.T) {
+	access := Access{Assignments: []consoledb.RoleAssignment{
+		{Role: string(RoleAuditor), TenantID: -1, SiteID: -1},
+		{Role: string(RoleOperator), TenantID: 1, SiteID: -1},
+		{Role: string(RoleHelpdesk), TenantID: 2, SiteID: 5},
+	}}
+
+	assert.Equal(t, PermissionView, access.Level(-1, -1), "auditor in every tenant should view the global scope")
+	assert.Equal(t, PermissionManage, access.Level(1, -1), "operator should manage its tenant")
+	assert.Equal(t, PermissionManage, access.Level(1, 3), "operator should manage every site in its tenant")
+	assert.Equal(t, PermissionRemote, access.Level(2, 5), "helpdesk should assist in its site")
+	assert.Equal(t, PermissionView, access.Level(2, -1), "helpdesk restricted to a site should not assist in the whole tenant")
+	assert.Equal(t, PermissionView, access.Level(2, 6), "helpdesk should not assist in other sites")
+	assert.False(t, access.IsGlobalAdmin())
+
+	_, ok := access.AdminTenant()
+	assert.False(t, ok, "user without admin roles should not be an admin")
+
+	restricted := Access{Assignments: []consoledb.RoleAssignment{
+		{Role: string(RoleTenantAdmin), TenantID: 3, SiteID: -1},
+	}}
+	assert.True(t, restricted.CanAccessTenant(3))
+	assert.False(t, restricted.CanAccessTenant(4))
+	assert.Equal(t, PermissionNone, restricted.Level(-1, -1))
+
+	tenantID, ok := restricted.AdminTenant()
+	assert.True(t, ok)
+	assert.Equal(t, 3, tenantID)
+
+	assert.Equal(t, PermissionGlobalAdmin, Access{Unrestricted: true}.Level(-1, -1), "unrestricted access should grant every permission")
+}
+
+func TestScope(t *testing.T) {
+	tests := []struct {
+		route     string
+		hasTenant bool
+		hasSite   bool
+		path      string
+	}{
+		{"/", false, false, "/"},
+		{"/computers", false, false, "/computers"},
+		{"/tenant/:tenant", true, false, "/"},
+		{"/tenant/:tenant/site/:site/computers/:uuid", true, true, "/computers/:uuid"},
+		{"/tenant/sites", false, false, "/tenant/sites"},
+		{"/admin/tenants/:tenant", false, false, "/admin/tenants/:tenant"},
+		{"/api/v1/tenant/:tenant/agents", true, false, "/agents"},
+		{"/api/v1/profiles", false, false, "/profiles"},
+	}
+
+	for _, test := range tests {
+		hasTenant, hasSite, path := Scope(test.route)
+		assert.Equal(t, test.hasTenant, hasTenant, test.route)
+		assert.Equal(t, test.hasSite, hasSite, test.route)
+		assert.Equal(t, test.path, path, test.route)
+	}
+}
+
+func TestRequiredPermission(t *testing.T) {
+	tests := []struct {
+		method     string
+		route      string
+		permission Permission
+	}{
+		{"GET", "/myaccount", PermissionNone},
+		{"POST", "/myaccount/passkeys/begin", PermissionNone},
+		{"POST", "/admin/users/:uid/resetpasskeys", PermissionGlobalAdmin},
+		{"GET", "/", PermissionView},
+		{"GET", "/tenant/:tenant/site/:site/computers", PermissionView},
+		{"POST", "/computers", PermissionView},
+		{"POST", "/reports/agents", PermissionView},
+		{"POST", "/tenant/:tenant/inventory-changes", PermissionView},
+		{"POST", "/tenant/:tenant/site/:site/compliance", PermissionView},
+		{"POST", "/tenant/:tenant/vulnerabilities", PermissionView},
+		{"POST", "/tenant/:tenant/admin/compliance/:id/enable", PermissionTenantAdmin},
+		{"POST", "/tenant/:tenant/site/:site/licenses", PermissionView},
+		{"DELETE", "/tenant/:tenant/admin/licenses/:id", PermissionTenantAdmin},
+		{"POST", "/tenant/:tenant/site/:site/hardware-lifecycle", PermissionView},
+		{"POST", "/tenant/:tenant/hardware-lifecycle/import", PermissionManage},
+		{"POST", "/computers/:uuid/lifecycle", PermissionManage},
+		{"POST", "/tenant/:tenant/dashboards", PermissionView},
+		{"POST", "/tenant/:tenant/site/:site/dashboards/:id/widgets", PermissionView},
+		{"DELETE", "/dashboards/:id", PermissionView},
+		{"POST", "/tenant/:tenant/site/:site/computers/views", PermissionView},
+		{"DELETE", "/agents/views/:id", PermissionView},
+		{"POST", "/computers/columns", PermissionView},
+		{"POST", "/computers/:uuid/power/:action", PermissionRemote},
+		{"GET", "/computers/:uuid/logical-disks", PermissionView},
+		{"POST", "/computers/:uuid/logical-disks", PermissionRemote},
+		{"GET", "/tenant/:tenant/deploy", PermissionManage},
+		{"GET", "/tenant/:tenant/site/:site/deploy/jobs/:id", PermissionManage},
+		{"POST", "/deploy/jobs/:id/retry", PermissionManage},
+		{"GET", "/agents/:uuid/admit", PermissionManage},
+		{"DELETE", "/tenant/:tenant/profiles/:uuid", PermissionManage},
+		{"DELETE", "/api/v1/profiles/:profile", PermissionManage},
+		{"GET", "/tenant/:tenant/admin/tags", PermissionTenantAdmin},
+		{"POST", "/tenant/:tenant/admin/rollouts/:id/pause", PermissionTenantAdmin},
+		{"POST", "/tenant/:tenant/maintenance-queue", PermissionView},
+		{"POST", "/tenant/:tenant/maintenance-queue/:id/cancel", PermissionManage},
+		{"GET", "/computers/bulk", PermissionView},
+		{"POST", "/tenant/:tenant/computers/bulk/reboot", PermissionRemote},
+		{"POST", "/tenant/:tenant/site/:site/computers/bulk/wol", PermissionRemote},
+		{"POST", "/computers/bulk/move-site", PermissionManage},
+		{"GET", "/admin/users", PermissionGlobalAdmin},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.permission, RequiredPermission(test.method, test.route), test.method+" "+test.route)
+	}
+}
@@ -0,0 +1,94 @@
+// Package reports defines the reports that can be scheduled, how their cron
+// schedules are validated and how the generated files are named.
+package reports
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	ReportAgents    = "agents"
+	ReportComputers = "computers"
+	ReportAntivirus = "antivirus"
+	ReportUpdates   = "updates"
+	ReportSoftware  = "software"
+)
+
+// Types contains the reports that can be scheduled
+var Types = []string{ReportAgents, ReportComputers, ReportAntivirus, ReportUpdates, ReportSoftware}
+
+const (
+	FormatPDF = "pdf"
+	FormatCSV = "csv"
+)
+
+// Formats contains the file formats a scheduled report can be generated in
+var Formats = []string{FormatPDF, FormatCSV}
+
+const (
+	// DeliveryEmail sends the report as an attachment using the tenant SMTP settings
+	DeliveryEmail = "email"
+	// DeliveryFolder saves the report in a folder inside the reports directory
+	DeliveryFolder = "folder"
+)
+
+// Deliveries contains how a scheduled report can be delivered
+var Deliveries = []string{DeliveryEmail, DeliveryFolder}
+
+// ParseSchedule parses a standard cron expression with five fields, e.g. 0 7 * * 1
+// for every Monday at 7:00. Descriptors like @weekly are accepted too
+func ParseSchedule(expr string) (cron.Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, errors.New("the cron expression is empty")
+	}
+	return cron.ParseStandard(expr)
+}
+
+// NextRun returns when a schedule will run next after t, the zero time is
+// returned if the expression is not valid
+func NextRun(expr string, t time.Time) time.Time {
+	s, err := ParseSchedule(expr)
+	if err != nil {
+		return time.Time{}
+	}
+	return s.Next(t)
+}
+
+// ValidFolder reports if folder is a relative path that stays inside the reports directory
+func ValidFolder(folder string) bool {
+	if folder == "" {
+		return true
+	}
+	return filepath.IsLocal(folder)
+}
+
+// FileName returns the name of the file generated for a scheduled report, e.g.
+// weekly-compliance-2025-01-06-0700.pdf
+func FileName(name, format string, t time.Time) string {
+	slug := strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			return unicode.ToLower(r)
+		case r == '-' || r == '_':
+			return r
+		case unicode.IsSpace(r):
+			return '-'
+		default:
+			return -1
+		}
+	}, strings.TrimSpace(name))
+
+	if slug == "" {
+		slug = "report"
+	}
+
+	return fmt.Sprintf("%s-%s.%s", slug, t.Format("2006-01-02-1504"), format)
+}
@@ -0,0 +1,43 @@
+package reports
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSchedule(t *testing.T) {
+	for _, expr := range []string{"0 7 * * 1", "*/15 * * * *", "@weekly", "30 6 1 * *"} {
+		_, err := ParseSchedule(expr)
+		assert.NoError(t, err, expr)
+	}
+
+	for _, expr := range []string{"", "0 7 * *", "61 * * * *", "0 0 7 * * 1", "every monday"} {
+		_, err := ParseSchedule(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestNextRun(t *testing.T) {
+	// Sunday
+	now := time.Date(2025, 1, 5, 10, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2025, 1, 6, 7, 0, 0, 0, time.UTC), NextRun("0 7 * * 1", now))
+	assert.True(t, NextRun("not valid", now).IsZero())
+}
+
+func TestValidFolder(t *testing.T) {
+	assert.True(t, ValidFolder(""))
+	assert.True(t, ValidFolder("compliance"))
+	assert.True(t, ValidFolder("management/weekly"))
+	assert.False(t, ValidFolder("../etc"))
+	assert.False(t, ValidFolder("/etc"))
+	assert.False(t, ValidFolder("weekly/../../etc"))
+}
+
+func TestFileName(t *testing.T) {
+	now := time.Date(2025, 1, 6, 7, 0, 0, 0, time.UTC)
+	assert.Equal(t, "weekly-compliance-2025-01-06-0700.pdf", FileName("Weekly compliance", FormatPDF, now))
+	assert.Equal(t, "antivirus_status-2025-01-06-0700.csv", FileName("Antivirus_status!", FormatCSV, now))
+	assert.Equal(t, "report-2025-01-06-0700.pdf", FileName("../", FormatPDF, now))
+}
@@ -0,0 +1,200 @@
+// Package rollouts plans staged agent updates. A rollout sends the update to a pilot
+// ring first and then to the rest of the agents in waves that cover a growing
+// percentage of them, waiting a soak time between waves and halting when too many
+// agents fail to update.
+package rollouts
+
+import (
+	"errors"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Pilot ring types, the pilot ring holds the agents with a tag or the agents of a site
+const (
+	PilotTag  = "tag"
+	PilotSite = "site"
+)
+
+// PilotTypes contains the ways the pilot ring can be chosen
+var PilotTypes = []string{PilotTag, PilotSite}
+
+// Rollout status
+const (
+	StatusRunning   = "running"
+	StatusPaused    = "paused"
+	StatusHalted    = "halted"
+	StatusCompleted = "completed"
+	StatusCancelled = "cancelled"
+)
+
+// Status of an agent in a rollout
+const (
+	AgentQueued    = "queued"
+	AgentSent      = "sent"
+	AgentSucceeded = "succeeded"
+	AgentFailed    = "failed"
+)
+
+// AgentStatuses contains the status an agent goes through in a rollout
+var AgentStatuses = []string{AgentQueued, AgentSent, AgentSucceeded, AgentFailed}
+
+// PilotWave is the wave of the agents in the pilot ring, the percentage waves follow it
+const PilotWave = 0
+
+// CheckInterval is how often running rollouts are checked to send the next wave
+const CheckInterval = time.Minute
+
+const (
+	// MaxWaves is the maximum number of percentage waves after the pilot ring
+	MaxWaves = 10
+	// MaxSoakMinutes is the maximum time to wait between waves, a week
+	MaxSoakMinutes = 7 * 24 * 60
+)
+
+var (
+	ErrInvalidWaves = errors.New("waves must be increasing percentages between 1 and 100 ending at 100")
+	ErrEmptyPilot   = errors.New("the pilot ring has no agents to update")
+)
+
+// WaveProgress counts the agents of a wave in each status
+type WaveProgress struct {
+	Wave      int
+	Queued    int
+	Sent      int
+	Succeeded int
+	Failed    int
+}
+
+// Total returns the number of agents in the wave
+func (p WaveProgress) Total() int {
+	return p.Queued + p.Sent + p.Succeeded + p.Failed
+}
+
+// ParseWaves parses the comma separated percentages of agents, out of the agents that
+// aren't in the pilot ring, that must have received the update when each wave is sent.
+// Percentages must increase and the last one must be 100
+func ParseWaves(value string) ([]int, error) {
+	waves := []int{}
+	for item := range strings.SplitSeq(value, ",") {
+		item = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(item), "%"))
+		if item == "" {
+			continue
+		}
+
+		percentage, err := strconv.Atoi(item)
+		if err != nil || percentage < 1 || percentage > 100 {
+			return nil, ErrInvalidWaves
+		}
+		if len(waves) > 0 && percentage <= waves[len(waves)-1] {
+			return nil, ErrInvalidWaves
+		}
+		waves = append(waves, percentage)
+	}
+
+	if len(waves) == 0 || len(waves) > MaxWaves || waves[len(waves)-1] != 100 {
+		return nil, ErrInvalidWaves
+	}
+
+	return waves, nil
+}
+
+// FormatWaves returns the percentages as stored and shown to the user
+func FormatWaves(waves []int) string {
+	items := []string{}
+	for _, w := range waves {
+		items = append(items, strconv.Itoa(w))
+	}
+	return strings.Join(items, ",")
+}
+
+// ValidSoak reports if the minutes to wait between waves are allowed
+func ValidSoak(minutes int) bool {
+	return minutes >= 0 && minutes <= MaxSoakMinutes
+}
+
+// ValidThreshold reports if the failure threshold is a percentage
+func ValidThreshold(threshold int) bool {
+	return threshold >= 0 && threshold <= 100
+}
+
+// Plan assigns each agent to the wave it will be updated in. The pilot agents that are
+// in the list of agents go in the PilotWave, the rest are ordered by a hash of their id,
+// so waves mix agents of every site instead of following the order they were enrolled
+// in, and are split so each wave reaches its cumulative percentage
+func Plan(agents []string, pilot []string, waves []int) (map[string]int, error) {
+	plan := map[string]int{}
+
+	inPilot := map[string]bool{}
+	for _, id := range pilot {
+		inPilot[id] = true
+	}
+
+	rest := []string{}
+	for _, id := range agents {
+		if _, ok := plan[id]; ok {
+			continue
+		}
+		if inPilot[id] {
+			plan[id] = PilotWave
+			continue
+		}
+		plan[id] = -1
+		rest = append(rest, id)
+	}
+
+	if len(plan) == len(rest) {
+		return nil, ErrEmptyPilot
+	}
+
+	sort.Slice(rest, func(i, j int) bool {
+		hi, hj := hash(rest[i]), hash(rest[j])
+		if hi == hj {
+			return rest[i] < rest[j]
+		}
+		return hi < hj
+	})
+
+	next := 0
+	for i, percentage := range waves {
+		last := (len(rest)*percentage + 99) / 100
+		for ; next < last; next++ {
+			plan[rest[next]] = i + 1
+		}
+	}
+
+	return plan, nil
+}
+
+// FailureRate returns the percentage of agents that failed to update out of the
+// agents the update was sent to
+func FailureRate(failed, sent int) int {
+	if sent == 0 {
+		return 0
+	}
+	return failed * 100 / sent
+}
+
+// ShouldHalt reports if the failures exceed the threshold percentage
+func ShouldHalt(failed, sent, threshold int) bool {
+	return sent > 0 && failed*100 > threshold*sent
+}
+
+// WaveDue reports if the soak time of the current wave has passed
+func WaveDue(waveStarted time.Time, soakMinutes int, now time.Time) bool {
+	return !now.Before(waveStarted.Add(time.Duration(soakMinutes) * time.Minute))
+}
+
+// Active reports if the rollout can still send updates, or be resumed to send them
+func Active(status string) bool {
+	return status == StatusRunning || status == StatusPaused || status == StatusHalted
+}
+
+func hash(id string) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(id))
+	return h.Sum32()
+}
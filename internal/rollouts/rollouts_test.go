@@ -0,0 +1,68 @@
+package rollouts
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseWaves(t *testing.T) {
+	waves, err := ParseWaves("10, 50%,100")
+	assert.NoError(t, err)
+	assert.Equal(t, []int{10, 50, 100}, waves)
+	assert.Equal(t, "10,50,100", FormatWaves(waves))
+
+	waves, err = ParseWaves("100")
+	assert.NoError(t, err)
+	assert.Equal(t, []int{100}, waves)
+
+	for _, value := range []string{"", "10,50", "50,10,100", "10,10,100", "0,100", "10,abc,100", "10,150"} {
+		_, err := ParseWaves(value)
+		assert.ErrorIs(t, err, ErrInvalidWaves, value)
+	}
+}
+
+func TestPlan(t *testing.T) {
+	agents := []string{}
+	for i := range 20 {
+		agents = append(agents, fmt.Sprintf("agent%02d", i))
+	}
+
+	plan, err := Plan(agents, []string{"agent03", "agent07", "other"}, []int{10, 50, 100})
+	assert.NoError(t, err)
+	assert.Len(t, plan, 20, "agents not in the list of agents are ignored")
+
+	count := map[int]int{}
+	for _, wave := range plan {
+		count[wave]++
+	}
+	assert.Equal(t, map[int]int{PilotWave: 2, 1: 2, 2: 7, 3: 9}, count)
+	assert.Equal(t, PilotWave, plan["agent03"])
+	assert.Equal(t, PilotWave, plan["agent07"])
+
+	again, err := Plan(agents, []string{"agent07", "agent03"}, []int{10, 50, 100})
+	assert.NoError(t, err)
+	assert.Equal(t, plan, again, "the plan must not depend on the order of the agents")
+
+	_, err = Plan(agents, []string{"other"}, []int{100})
+	assert.ErrorIs(t, err, ErrEmptyPilot)
+}
+
+func TestShouldHalt(t *testing.T) {
+	assert.False(t, ShouldHalt(0, 0, 0))
+	assert.False(t, ShouldHalt(1, 10, 10))
+	assert.True(t, ShouldHalt(2, 10, 10))
+	assert.True(t, ShouldHalt(1, 10, 0))
+	assert.False(t, ShouldHalt(10, 10, 100))
+	assert.Equal(t, 20, FailureRate(2, 10))
+	assert.Equal(t, 0, FailureRate(0, 0))
+}
+
+func TestWaveDue(t *testing.T) {
+	started := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	assert.False(t, WaveDue(started, 60, started.Add(59*time.Minute)))
+	assert.True(t, WaveDue(started, 60, started.Add(60*time.Minute)))
+	assert.True(t, WaveDue(started, 0, started))
+}
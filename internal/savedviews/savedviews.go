@@ -0,0 +1,140 @@
+// Package savedviews defines the lists whose filters, sorting and columns can be saved
+// with a name, and the columns that can be shown in the computers list.
+package savedviews
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	ListComputers = "computers"
+	ListAgents    = "agents"
+)
+
+// Lists contains the lists that support saved views
+var Lists = []string{ListComputers, ListAgents}
+
+// Columns of the computers list, the nickname is always shown as the first column
+const (
+	ColumnOS           = "os"
+	ColumnVersion      = "version"
+	ColumnUsername     = "username"
+	ColumnManufacturer = "manufacturer"
+	ColumnModel        = "model"
+	ColumnSerial       = "serial"
+	ColumnIP           = "ip"
+	ColumnMAC          = "mac"
+	ColumnRemote       = "remote"
+	ColumnLastContact  = "last_contact"
+	ColumnTags         = "tags"
+	ColumnPurchaseDate = "purchase_date"
+	ColumnWarranty     = "warranty"
+)
+
+// ComputerColumns contains the columns that can be chosen for the computers list,
+// besides one column for each metadata field of the tenant
+var ComputerColumns = []string{ColumnOS, ColumnVersion, ColumnUsername, ColumnManufacturer, ColumnModel, ColumnSerial, ColumnIP, ColumnMAC, ColumnRemote, ColumnLastContact, ColumnTags, ColumnPurchaseDate, ColumnWarranty}
+
+// DefaultComputerColumns are shown until the user chooses other columns
+var DefaultComputerColumns = []string{ColumnOS, ColumnVersion, ColumnUsername, ColumnManufacturer, ColumnModel, ColumnTags}
+
+const metadataPrefix = "metadata_"
+
+// MetadataColumn returns the column that shows the value of a metadata field
+func MetadataColumn(orgMetadataID int) string {
+	return fmt.Sprintf("%s%d", metadataPrefix, orgMetadataID)
+}
+
+// MetadataID returns the metadata field shown by a column, false if it isn't a metadata column
+func MetadataID(column string) (int, bool) {
+	value, found := strings.CutPrefix(column, metadataPrefix)
+	if !found {
+		return 0, false
+	}
+
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, false
+	}
+	return id, true
+}
+
+// ParseColumns returns the known columns in a comma separated list keeping their order.
+// Metadata columns must belong to one of the metadata fields of the tenant. The default
+// columns are returned if none of the columns is valid
+func ParseColumns(value string, orgMetadataIDs []int) []string {
+	columns := []string{}
+	for _, column := range strings.Split(value, ",") {
+		column = strings.TrimSpace(column)
+		if slices.Contains(columns, column) {
+			continue
+		}
+
+		if id, ok := MetadataID(column); ok {
+			if slices.Contains(orgMetadataIDs, id) {
+				columns = append(columns, column)
+			}
+			continue
+		}
+
+		if slices.Contains(ComputerColumns, column) {
+			columns = append(columns, column)
+		}
+	}
+
+	if len(columns) == 0 {
+		return slices.Clone(DefaultComputerColumns)
+	}
+	return columns
+}
+
+// indexedFilter matches the checkboxes of the option filters, e.g. filterByAgentOS2,
+// whose number is the position of the option in the list shown to the user
+var indexedFilter = regexp.MustCompile(`^(filterBy[A-Za-z]+?)(\d+)$`)
+
+// Query keeps the filters, the sorting and the page size from the values of a list request
+// and encodes them. The options selected in option filters are numbered again from zero, as
+// the position of an option changes when the available options change, so the saved filter
+// still applies while there are at least as many options as selected ones. Tags and
+// metadata fields are kept as they are numbered by their id
+func Query(values url.Values) string {
+	q := url.Values{}
+	counters := map[string]int{}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := strings.TrimSpace(values.Get(key))
+		if value == "" {
+			continue
+		}
+
+		switch {
+		case key == "sortBy" || key == "sortOrder" || key == "pageSize":
+			q.Set(key, value)
+		case key == "filterBySelectedItems":
+			continue
+		case strings.HasPrefix(key, "filterByTag") || strings.HasPrefix(key, "filterByMetadata"):
+			q.Set(key, value)
+		case strings.HasPrefix(key, "filterBy"):
+			if m := indexedFilter.FindStringSubmatch(key); m != nil {
+				q.Set(fmt.Sprintf("%s%d", m[1], counters[m[1]]), value)
+				counters[m[1]]++
+			} else {
+				q.Set(key, value)
+			}
+		}
+	}
+
+	return q.Encode()
+}
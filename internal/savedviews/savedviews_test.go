@@ -0,0 +1,58 @@
+package savedviews
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadataColumn(t *testing.T) {
+	id, ok := MetadataID(MetadataColumn(7))
+	assert.True(t, ok)
+	assert.Equal(t, 7, id)
+
+	_, ok = MetadataID(ColumnSerial)
+	assert.False(t, ok)
+
+	_, ok = MetadataID("metadata_abc")
+	assert.False(t, ok)
+}
+
+func TestParseColumns(t *testing.T) {
+	assert.Equal(t, []string{ColumnSerial, ColumnOS, "metadata_2"}, ParseColumns("serial, os,metadata_2,os,metadata_3,unknown", []int{1, 2}))
+	assert.Equal(t, DefaultComputerColumns, ParseColumns("", []int{1, 2}))
+	assert.Equal(t, DefaultComputerColumns, ParseColumns("metadata_3", []int{1, 2}), "metadata of other tenants is ignored")
+}
+
+func TestQuery(t *testing.T) {
+	values := url.Values{
+		"page":                  {"3"},
+		"pageSize":              {"10"},
+		"sortBy":                {"nickname"},
+		"sortOrder":             {"desc"},
+		"filterByNickname":      {"lab"},
+		"filterByUsername":      {""},
+		"filterByAgentOS3":      {"windows"},
+		"filterByAgentOS5":      {"linux"},
+		"filterByTag12":         {"12"},
+		"filterByMetadata4":     {"B1"},
+		"filterBySelectedItems": {"0"},
+		"tagId":                 {"1"},
+	}
+
+	q, err := url.ParseQuery(Query(values))
+	assert.NoError(t, err)
+	assert.Equal(t, url.Values{
+		"pageSize":          {"10"},
+		"sortBy":            {"nickname"},
+		"sortOrder":         {"desc"},
+		"filterByNickname":  {"lab"},
+		"filterByAgentOS0":  {"windows"},
+		"filterByAgentOS1":  {"linux"},
+		"filterByTag12":     {"12"},
+		"filterByMetadata4": {"B1"},
+	}, q)
+
+	assert.Equal(t, "", Query(url.Values{"page": {"1"}}))
+}
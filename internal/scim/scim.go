@@ -0,0 +1,519 @@
+// Package scim implements the parts of the SCIM 2.0 protocol (RFC 7643 and RFC 7644)
+// identity providers use to provision and deprovision the console users.
+package scim
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	SchemaUser                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SchemaGroup                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	SchemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	SchemaListResponse          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SchemaPatchOp               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SchemaError                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+)
+
+// ContentType is the media type of the SCIM requests and responses
+const ContentType = "application/scim+json"
+
+// Error types returned in the scimType field
+const (
+	ErrorInvalidFilter = "invalidFilter"
+	ErrorInvalidSyntax = "invalidSyntax"
+	ErrorInvalidPath   = "invalidPath"
+	ErrorInvalidValue  = "invalidValue"
+	ErrorUniqueness    = "uniqueness"
+	ErrorMutability    = "mutability"
+)
+
+// DefaultCount is the page size used when the identity provider doesn't send one
+const DefaultCount = 100
+
+var (
+	ErrInvalidFilter = errors.New("only filters with the form 'attribute eq \"value\"' are supported")
+	ErrInvalidPath   = errors.New("the path of the operation is not supported")
+	ErrInvalidValue  = errors.New("the value of the operation is not valid")
+	ErrInvalidOp     = errors.New("the operation must be add, replace or remove")
+)
+
+type User struct {
+	Schemas      []string     `json:"schemas"`
+	ID           string       `json:"id,omitempty"`
+	ExternalID   string       `json:"externalId,omitempty"`
+	UserName     string       `json:"userName"`
+	Name         *Name        `json:"name,omitempty"`
+	DisplayName  string       `json:"displayName,omitempty"`
+	Emails       []MultiValue `json:"emails,omitempty"`
+	PhoneNumbers []MultiValue `json:"phoneNumbers,omitempty"`
+	Active       *bool        `json:"active,omitempty"`
+	Groups       []Member     `json:"groups,omitempty"`
+	Meta         *Meta        `json:"meta,omitempty"`
+}
+
+type Name struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+type MultiValue struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+type Group struct {
+	Schemas     []string `json:"schemas"`
+	ID          string   `json:"id,omitempty"`
+	DisplayName string   `json:"displayName"`
+	Members     []Member `json:"members,omitempty"`
+	Meta        *Meta    `json:"meta,omitempty"`
+}
+
+type Member struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Ref     string `json:"$ref,omitempty"`
+}
+
+type Meta struct {
+	ResourceType string     `json:"resourceType"`
+	Created      *time.Time `json:"created,omitempty"`
+	LastModified *time.Time `json:"lastModified,omitempty"`
+	Location     string     `json:"location,omitempty"`
+}
+
+type ListResponse struct {
+	Schemas      []string `json:"schemas"`
+	TotalResults int      `json:"totalResults"`
+	StartIndex   int      `json:"startIndex"`
+	ItemsPerPage int      `json:"itemsPerPage"`
+	Resources    []any    `json:"Resources"`
+}
+
+type Error struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
+
+type PatchRequest struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []PatchOperation `json:"Operations"`
+}
+
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+type ServiceProviderConfig struct {
+	Schemas               []string            `json:"schemas"`
+	Patch                 Supported           `json:"patch"`
+	Bulk                  BulkSupported       `json:"bulk"`
+	Filter                FilterSupported     `json:"filter"`
+	ChangePassword        Supported           `json:"changePassword"`
+	Sort                  Supported           `json:"sort"`
+	ETag                  Supported           `json:"etag"`
+	AuthenticationSchemes []map[string]string `json:"authenticationSchemes"`
+}
+
+type Supported struct {
+	Supported bool `json:"supported"`
+}
+
+type BulkSupported struct {
+	Supported      bool `json:"supported"`
+	MaxOperations  int  `json:"maxOperations"`
+	MaxPayloadSize int  `json:"maxPayloadSize"`
+}
+
+type FilterSupported struct {
+	Supported  bool `json:"supported"`
+	MaxResults int  `json:"maxResults"`
+}
+
+// NewServiceProviderConfig describes the features of the protocol the console supports
+func NewServiceProviderConfig() ServiceProviderConfig {
+	return ServiceProviderConfig{
+		Schemas: []string{SchemaServiceProviderConfig},
+		Patch:   Supported{Supported: true},
+		Filter:  FilterSupported{Supported: true, MaxResults: DefaultCount},
+		AuthenticationSchemes: []map[string]string{{
+			"type":        "oauthbearertoken",
+			"name":        "OAuth Bearer Token",
+			"description": "Authentication with the token generated in the authentication settings of the console",
+		}},
+	}
+}
+
+func NewError(status int, scimType, detail string) Error {
+	return Error{
+		Schemas:  []string{SchemaError},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   detail,
+	}
+}
+
+// NewListResponse returns the page of resources that starts at startIndex, which
+// begins at 1 as required by the protocol
+func NewListResponse[T any](resources []T, startIndex, count int) ListResponse {
+	if startIndex < 1 {
+		startIndex = 1
+	}
+	if count < 0 {
+		count = 0
+	}
+
+	page := []any{}
+	for i := startIndex - 1; i < len(resources) && len(page) < count; i++ {
+		page = append(page, resources[i])
+	}
+
+	return ListResponse{
+		Schemas:      []string{SchemaListResponse},
+		TotalResults: len(resources),
+		StartIndex:   startIndex,
+		ItemsPerPage: len(page),
+		Resources:    page,
+	}
+}
+
+// ParseFilter returns the attribute and the value of an equality filter, the only
+// kind of filter identity providers use to find a user or group before creating it
+func ParseFilter(filter string) (string, string, error) {
+	fields := strings.SplitN(strings.TrimSpace(filter), " ", 3)
+	if len(fields) != 3 || !strings.EqualFold(fields[1], "eq") {
+		return "", "", ErrInvalidFilter
+	}
+
+	value, err := strconv.Unquote(strings.TrimSpace(fields[2]))
+	if err != nil {
+		return "", "", ErrInvalidFilter
+	}
+
+	return fields[0], value, nil
+}
+
+// FullName returns the name shown in the console for the user
+func (u User) FullName() string {
+	switch {
+	case u.DisplayName != "":
+		return u.DisplayName
+	case u.Name != nil && u.Name.Formatted != "":
+		return u.Name.Formatted
+	case u.Name != nil && (u.Name.GivenName != "" || u.Name.FamilyName != ""):
+		return strings.TrimSpace(u.Name.GivenName + " " + u.Name.FamilyName)
+	default:
+		return ""
+	}
+}
+
+func (u User) Email() string {
+	return primaryValue(u.Emails)
+}
+
+func (u User) Phone() string {
+	return primaryValue(u.PhoneNumbers)
+}
+
+// IsActive reports if the user can use the console, users are active unless the
+// identity provider says otherwise
+func (u User) IsActive() bool {
+	return u.Active == nil || *u.Active
+}
+
+// Matches reports if the user matches an equality filter, usernames and emails are
+// compared without case
+func (u User) Matches(attribute, value string) (bool, error) {
+	switch strings.ToLower(attribute) {
+	case "id":
+		return u.ID == value, nil
+	case "username":
+		return strings.EqualFold(u.UserName, value), nil
+	case "externalid":
+		return u.ExternalID == value, nil
+	case "displayname":
+		return u.DisplayName == value, nil
+	case "emails", "emails.value":
+		return slices.ContainsFunc(u.Emails, func(e MultiValue) bool { return strings.EqualFold(e.Value, value) }), nil
+	default:
+		return false, ErrInvalidFilter
+	}
+}
+
+// Matches reports if the group matches an equality filter
+func (g Group) Matches(attribute, value string) (bool, error) {
+	switch strings.ToLower(attribute) {
+	case "id":
+		return g.ID == value, nil
+	case "displayname":
+		return strings.EqualFold(g.DisplayName, value), nil
+	default:
+		return false, ErrInvalidFilter
+	}
+}
+
+// ApplyPatch changes the user with the operations of a PATCH request. Attributes the
+// console doesn't store are ignored
+func (u *User) ApplyPatch(operations []PatchOperation) error {
+	for _, o := range operations {
+		op := strings.ToLower(o.Op)
+		if op != "add" && op != "replace" && op != "remove" {
+			return ErrInvalidOp
+		}
+
+		if o.Path == "" {
+			if op == "remove" {
+				return ErrInvalidPath
+			}
+
+			values := map[string]json.RawMessage{}
+			if err := json.Unmarshal(o.Value, &values); err != nil {
+				return ErrInvalidValue
+			}
+
+			for path, value := range values {
+				if err := u.setAttribute(path, value); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		value := o.Value
+		if op == "remove" {
+			value = nil
+		}
+
+		if err := u.setAttribute(o.Path, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// setAttribute sets the attribute in path, a nil value clears it
+func (u *User) setAttribute(path string, value json.RawMessage) error {
+	attribute, _, _ := strings.Cut(strings.ToLower(path), "[")
+	switch {
+	case attribute == "username":
+		return unmarshalString(value, &u.UserName)
+	case attribute == "externalid":
+		return unmarshalString(value, &u.ExternalID)
+	case attribute == "displayname":
+		return unmarshalString(value, &u.DisplayName)
+	case strings.HasPrefix(attribute, "name."):
+		if u.Name == nil {
+			u.Name = &Name{}
+		}
+		switch attribute {
+		case "name.formatted":
+			return unmarshalString(value, &u.Name.Formatted)
+		case "name.givenname":
+			return unmarshalString(value, &u.Name.GivenName)
+		case "name.familyname":
+			return unmarshalString(value, &u.Name.FamilyName)
+		}
+	case attribute == "name":
+		u.Name = &Name{}
+		if value == nil {
+			return nil
+		}
+		if err := json.Unmarshal(value, u.Name); err != nil {
+			return ErrInvalidValue
+		}
+	case attribute == "active":
+		active, err := unmarshalBool(value)
+		if err != nil {
+			return err
+		}
+		u.Active = &active
+	case attribute == "emails":
+		return setMultiValue(&u.Emails, path, value)
+	case attribute == "phonenumbers":
+		return setMultiValue(&u.PhoneNumbers, path, value)
+	}
+
+	return nil
+}
+
+// PatchMembers returns the members of a group after the operations of a PATCH request
+// have been applied, members are identified by the id of the user
+func PatchMembers(members []string, operations []PatchOperation) ([]string, error) {
+	result := append([]string{}, members...)
+
+	for _, o := range operations {
+		op := strings.ToLower(o.Op)
+		path := strings.ToLower(strings.TrimSpace(o.Path))
+
+		var values []Member
+		switch {
+		case path == "":
+			if op == "remove" {
+				return nil, ErrInvalidPath
+			}
+
+			group := Group{}
+			if err := json.Unmarshal(o.Value, &group); err != nil {
+				return nil, ErrInvalidValue
+			}
+			if group.Members == nil {
+				continue
+			}
+			values = group.Members
+		case path == "members":
+			if len(o.Value) > 0 {
+				if err := json.Unmarshal(o.Value, &values); err != nil {
+					return nil, ErrInvalidValue
+				}
+			}
+		case strings.HasPrefix(path, "members["):
+			// members[value eq "id"] selects a single member to remove
+			_, value, err := ParseFilter(strings.TrimSuffix(strings.TrimSpace(o.Path)[len("members["):], "]"))
+			if err != nil || op != "remove" {
+				return nil, ErrInvalidPath
+			}
+			values = []Member{{Value: value}}
+		case path == "displayname" || path == "externalid":
+			continue
+		default:
+			return nil, ErrInvalidPath
+		}
+
+		switch op {
+		case "add":
+			for _, m := range values {
+				if !slices.Contains(result, m.Value) {
+					result = append(result, m.Value)
+				}
+			}
+		case "replace":
+			result = []string{}
+			for _, m := range values {
+				if !slices.Contains(result, m.Value) {
+					result = append(result, m.Value)
+				}
+			}
+		case "remove":
+			if path == "members" && len(values) == 0 {
+				result = []string{}
+				continue
+			}
+			for _, m := range values {
+				result = slices.DeleteFunc(result, func(v string) bool { return v == m.Value })
+			}
+		default:
+			return nil, ErrInvalidOp
+		}
+	}
+
+	return result, nil
+}
+
+func primaryValue(values []MultiValue) string {
+	for _, v := range values {
+		if v.Primary {
+			return v.Value
+		}
+	}
+
+	if len(values) > 0 {
+		return values[0].Value
+	}
+
+	return ""
+}
+
+// setMultiValue replaces the emails or phone numbers, paths like emails[type eq "work"].value
+// set the value of that type only
+func setMultiValue(values *[]MultiValue, path string, value json.RawMessage) error {
+	open := strings.Index(path, "[")
+	if open == -1 {
+		*values = nil
+		if value == nil {
+			return nil
+		}
+		if err := json.Unmarshal(value, values); err != nil {
+			return ErrInvalidValue
+		}
+		return nil
+	}
+
+	end := strings.Index(path, "]")
+	if end < open {
+		return ErrInvalidPath
+	}
+
+	attribute, kind, err := ParseFilter(path[open+1 : end])
+	if err != nil || !strings.EqualFold(attribute, "type") {
+		return ErrInvalidPath
+	}
+
+	var v string
+	if err := unmarshalString(value, &v); err != nil {
+		return err
+	}
+
+	for i := range *values {
+		if strings.EqualFold((*values)[i].Type, kind) {
+			if v == "" {
+				*values = append((*values)[:i], (*values)[i+1:]...)
+			} else {
+				(*values)[i].Value = v
+			}
+			return nil
+		}
+	}
+
+	if v != "" {
+		*values = append(*values, MultiValue{Value: v, Type: kind, Primary: len(*values) == 0})
+	}
+	return nil
+}
+
+func unmarshalString(value json.RawMessage, s *string) error {
+	*s = ""
+	if value == nil {
+		return nil
+	}
+
+	if err := json.Unmarshal(value, s); err != nil {
+		return ErrInvalidValue
+	}
+	return nil
+}
+
+// unmarshalBool accepts booleans sent as strings, as some identity providers do
+func unmarshalBool(value json.RawMessage) (bool, error) {
+	if value == nil {
+		return false, nil
+	}
+
+	var b bool
+	if err := json.Unmarshal(value, &b); err == nil {
+		return b, nil
+	}
+
+	var s string
+	if err := json.Unmarshal(value, &s); err != nil {
+		return false, ErrInvalidValue
+	}
+
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, ErrInvalidValue
+	}
+	return b, nil
+}
@@ -0,0 +1,123 @@
+package scim
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func operations(t *testing.T, data string) []PatchOperation {
+	r := PatchRequest{}
+	assert.NoError(t, json.Unmarshal([]byte(data), &r))
+	return r.Operations
+}
+
+func TestParseFilter(t *testing.T) {
+	attribute, value, err := ParseFilter(`userName eq "jdoe@example.com"`)
+	assert.NoError(t, err)
+	assert.Equal(t, "userName", attribute)
+	assert.Equal(t, "jdoe@example.com", value)
+
+	_, value, err = ParseFilter(`displayName EQ "IT \"Ops\" team"`)
+	assert.NoError(t, err)
+	assert.Equal(t, `IT "Ops" team`, value)
+
+	_, _, err = ParseFilter(`userName sw "j"`)
+	assert.ErrorIs(t, err, ErrInvalidFilter)
+
+	_, _, err = ParseFilter(`userName eq jdoe`)
+	assert.ErrorIs(t, err, ErrInvalidFilter)
+}
+
+func TestMatches(t *testing.T) {
+	u := User{ID: "jdoe", UserName: "JDoe", Emails: []MultiValue{{Value: "jdoe@example.com"}}}
+
+	match, err := u.Matches("userName", "jdoe")
+	assert.NoError(t, err)
+	assert.True(t, match, "usernames should be compared without case")
+
+	match, err = u.Matches("emails.value", "JDOE@example.com")
+	assert.NoError(t, err)
+	assert.True(t, match)
+
+	match, err = u.Matches("externalId", "00u1")
+	assert.NoError(t, err)
+	assert.False(t, match)
+
+	_, err = u.Matches("title", "CEO")
+	assert.ErrorIs(t, err, ErrInvalidFilter)
+
+	match, err = Group{ID: "operator", DisplayName: "operator"}.Matches("displayName", "Operator")
+	assert.NoError(t, err)
+	assert.True(t, match)
+}
+
+func TestNewListResponse(t *testing.T) {
+	r := NewListResponse([]string{"a", "b", "c"}, 2, 1)
+	assert.Equal(t, 3, r.TotalResults)
+	assert.Equal(t, 2, r.StartIndex)
+	assert.Equal(t, []any{"b"}, r.Resources)
+
+	r = NewListResponse([]string{"a"}, 5, DefaultCount)
+	assert.Equal(t, 0, r.ItemsPerPage)
+	assert.NotNil(t, r.Resources, "resources must be an empty list, not null")
+}
+
+func TestUserApplyPatch(t *testing.T) {
+	u := User{UserName: "jdoe", DisplayName: "John Doe", Emails: []MultiValue{{Value: "jdoe@example.com", Type: "work", Primary: true}}}
+	assert.True(t, u.IsActive())
+
+	// Microsoft Entra ID sends the operation in capitals and the booleans as strings
+	err := u.ApplyPatch(operations(t, `{"Operations":[
+		{"op":"Replace","path":"active","value":"False"},
+		{"op":"Add","path":"emails[type eq \"work\"].value","value":"john.doe@example.com"},
+		{"op":"Add","path":"phoneNumbers[type eq \"mobile\"].value","value":"555-0100"}
+	]}`))
+	assert.NoError(t, err)
+	assert.False(t, u.IsActive())
+	assert.Equal(t, "john.doe@example.com", u.Email())
+	assert.Equal(t, "555-0100", u.Phone())
+
+	// Okta sends the attributes without path
+	err = u.ApplyPatch(operations(t, `{"Operations":[{"op":"replace","value":{"active":true,"name":{"givenName":"Johnny","familyName":"Doe"},"displayName":""}}]}`))
+	assert.NoError(t, err)
+	assert.True(t, u.IsActive())
+	assert.Equal(t, "Johnny Doe", u.FullName())
+
+	err = u.ApplyPatch(operations(t, `{"Operations":[{"op":"remove","path":"phoneNumbers[type eq \"mobile\"].value"}]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "", u.Phone())
+
+	err = u.ApplyPatch(operations(t, `{"Operations":[{"op":"move","path":"active","value":false}]}`))
+	assert.ErrorIs(t, err, ErrInvalidOp)
+
+	err = u.ApplyPatch(operations(t, `{"Operations":[{"op":"replace","path":"active","value":"maybe"}]}`))
+	assert.ErrorIs(t, err, ErrInvalidValue)
+}
+
+func TestPatchMembers(t *testing.T) {
+	members, err := PatchMembers([]string{"jdoe"}, operations(t, `{"Operations":[
+		{"op":"add","path":"members","value":[{"value":"asmith"},{"value":"jdoe"}]}
+	]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"jdoe", "asmith"}, members)
+
+	members, err = PatchMembers(members, operations(t, `{"Operations":[{"op":"remove","path":"members[value eq \"jdoe\"]"}]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"asmith"}, members)
+
+	members, err = PatchMembers(members, operations(t, `{"Operations":[
+		{"op":"Remove","path":"members","value":[{"value":"asmith"}]},
+		{"op":"replace","value":{"displayName":"operator","members":[{"value":"bwhite"}]}}
+	]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"bwhite"}, members)
+
+	members, err = PatchMembers(members, operations(t, `{"Operations":[{"op":"remove","path":"members"}]}`))
+	assert.NoError(t, err)
+	assert.Empty(t, members)
+
+	_, err = PatchMembers(members, operations(t, `{"Operations":[{"op":"add","path":"owners","value":[]}]}`))
+	assert.ErrorIs(t, err, ErrInvalidPath)
+}
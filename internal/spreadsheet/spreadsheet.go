@@ -0,0 +1,220 @@
+// Package spreadsheet writes list reports as XLSX workbooks with typed cells,
+// a frozen header row and an autofilter in every sheet.
+package spreadsheet
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// maxSheetName is the longest sheet name Excel accepts
+const maxSheetName = 31
+
+type Sheet struct {
+	Name    string
+	Columns []string
+	Rows    [][]any
+}
+
+// Group splits items in one sheet per name, sheets keep the order in which their
+// first item appears. row converts each item to the values of the columns. A sheet
+// named emptyName with only the header row is returned if there are no items
+func Group[T any](items []T, emptyName string, sheetName func(T) string, columns []string, row func(T) []any) []Sheet {
+	if len(items) == 0 {
+		return []Sheet{{Name: emptyName, Columns: columns}}
+	}
+
+	sheets := []Sheet{}
+	index := map[string]int{}
+	for _, item := range items {
+		name := sheetName(item)
+		i, ok := index[name]
+		if !ok {
+			i = len(sheets)
+			index[name] = i
+			sheets = append(sheets, Sheet{Name: name, Columns: columns})
+		}
+		sheets[i].Rows = append(sheets[i].Rows, row(item))
+	}
+	return sheets
+}
+
+// Write saves the sheets as a workbook in path
+func Write(path string, sheets []Sheet) error {
+	if len(sheets) == 0 {
+		return fmt.Errorf("the workbook has no sheets")
+	}
+
+	f := excelize.NewFile()
+	defer func() {
+		_ = f.Close()
+	}()
+
+	headerStyle, err := f.NewStyle(&excelize.Style{
+		Fill: excelize.Fill{Type: "pattern", Color: []string{"#007500"}, Pattern: 1},
+		Font: &excelize.Font{Color: "#FFFFFF", Bold: true},
+	})
+	if err != nil {
+		return err
+	}
+
+	dateStyle, err := f.NewStyle(&excelize.Style{NumFmt: 22})
+	if err != nil {
+		return err
+	}
+
+	used := map[string]bool{}
+	for i, s := range sheets {
+		name := SheetName(s.Name, used)
+		if i == 0 {
+			if err := f.SetSheetName("Sheet1", name); err != nil {
+				return err
+			}
+		} else if _, err := f.NewSheet(name); err != nil {
+			return err
+		}
+
+		if err := writeSheet(f, name, s, headerStyle, dateStyle); err != nil {
+			return fmt.Errorf("could not write sheet %s: %v", name, err)
+		}
+	}
+
+	f.SetActiveSheet(0)
+	return f.SaveAs(path)
+}
+
+func writeSheet(f *excelize.File, name string, s Sheet, headerStyle, dateStyle int) error {
+	if len(s.Columns) == 0 {
+		return nil
+	}
+
+	header := make([]any, len(s.Columns))
+	widths := make([]int, len(s.Columns))
+	for i, c := range s.Columns {
+		header[i] = c
+		widths[i] = utf8.RuneCountInString(c)
+	}
+	if err := f.SetSheetRow(name, "A1", &header); err != nil {
+		return err
+	}
+
+	lastCol, err := excelize.ColumnNumberToName(len(s.Columns))
+	if err != nil {
+		return err
+	}
+	if err := f.SetCellStyle(name, "A1", lastCol+"1", headerStyle); err != nil {
+		return err
+	}
+
+	for r, values := range s.Rows {
+		row := make([]any, len(values))
+		for i, v := range values {
+			row[i] = cellValue(v)
+			if i < len(widths) {
+				widths[i] = max(widths[i], cellWidth(row[i]))
+			}
+		}
+
+		cell, err := excelize.CoordinatesToCellName(1, r+2)
+		if err != nil {
+			return err
+		}
+		if err := f.SetSheetRow(name, cell, &row); err != nil {
+			return err
+		}
+
+		for i, v := range row {
+			if _, ok := v.(time.Time); !ok {
+				continue
+			}
+			cell, err := excelize.CoordinatesToCellName(i+1, r+2)
+			if err != nil {
+				return err
+			}
+			if err := f.SetCellStyle(name, cell, cell, dateStyle); err != nil {
+				return err
+			}
+		}
+	}
+
+	for i, w := range widths {
+		col, err := excelize.ColumnNumberToName(i + 1)
+		if err != nil {
+			return err
+		}
+		if err := f.SetColWidth(name, col, col, float64(min(w, 60)+2)); err != nil {
+			return err
+		}
+	}
+
+	if err := f.SetPanes(name, &excelize.Panes{
+		Freeze:      true,
+		YSplit:      1,
+		TopLeftCell: "A2",
+		ActivePane:  "bottomLeft",
+	}); err != nil {
+		return err
+	}
+
+	return f.AutoFilter(name, fmt.Sprintf("A1:%s%d", lastCol, len(s.Rows)+1), nil)
+}
+
+// cellValue leaves empty the cells of dates that are not set
+func cellValue(v any) any {
+	if t, ok := v.(time.Time); ok {
+		if t.IsZero() {
+			return nil
+		}
+		return t.Local()
+	}
+	return v
+}
+
+func cellWidth(v any) int {
+	switch v := v.(type) {
+	case nil:
+		return 0
+	case string:
+		return utf8.RuneCountInString(v)
+	case time.Time:
+		return 16
+	default:
+		return len(fmt.Sprint(v))
+	}
+}
+
+// SheetName returns a name Excel accepts, without the forbidden characters, not
+// longer than 31 characters and not used by another sheet
+func SheetName(name string, used map[string]bool) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '-'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	name = strings.Trim(name, "'")
+	if name == "" {
+		name = "Sheet"
+	}
+	name = truncate(name, maxSheetName)
+
+	candidate := name
+	for i := 2; used[strings.ToLower(candidate)]; i++ {
+		suffix := fmt.Sprintf(" (%d)", i)
+		candidate = strings.TrimSpace(truncate(name, maxSheetName-len(suffix))) + suffix
+	}
+	used[strings.ToLower(candidate)] = true
+
+	return candidate
+}
+
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
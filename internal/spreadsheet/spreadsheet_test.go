@@ -0,0 +1,87 @@
+package spreadsheet
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestSheetName(t *testing.T) {
+	used := map[string]bool{}
+	assert.Equal(t, "Madrid", SheetName("Madrid", used))
+	assert.Equal(t, "madrid (2)", SheetName("madrid", used))
+	assert.Equal(t, "HQ-Floor 1", SheetName("HQ/Floor 1", used))
+	assert.Equal(t, "Sheet", SheetName("  ", used))
+	assert.Equal(t, "A very long site name that does", SheetName("A very long site name that doesn't fit", used))
+	assert.Equal(t, "A very long site name that (2)", SheetName("A very long site name that doesn't fit either", used))
+}
+
+func TestGroup(t *testing.T) {
+	type computer struct {
+		name string
+		site string
+	}
+	computers := []computer{{"pc1", "Madrid"}, {"pc2", "Paris"}, {"pc3", "Madrid"}}
+
+	sheets := Group(computers, "Computers", func(c computer) string { return c.site }, []string{"Name"}, func(c computer) []any { return []any{c.name} })
+	assert.Equal(t, 2, len(sheets))
+	assert.Equal(t, "Madrid", sheets[0].Name)
+	assert.Equal(t, [][]any{{"pc1"}, {"pc3"}}, sheets[0].Rows)
+	assert.Equal(t, "Paris", sheets[1].Name)
+
+	sheets = Group([]computer{}, "Computers", func(c computer) string { return c.site }, []string{"Name"}, func(c computer) []any { return []any{c.name} })
+	assert.Equal(t, []Sheet{{Name: "Computers", Columns: []string{"Name"}}}, sheets)
+}
+
+func TestWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.xlsx")
+	lastContact := time.Date(2025, 1, 6, 7, 30, 0, 0, time.Local)
+
+	err := Write(path, []Sheet{
+		{Name: "Madrid", Columns: []string{"Name", "Last contact", "Apps", "Remote"}, Rows: [][]any{
+			{"pc1", lastContact, 42, true},
+			{"pc2", time.Time{}, 3, false},
+		}},
+		{Name: "Paris", Columns: []string{"Name", "Last contact", "Apps", "Remote"}},
+	})
+	assert.NoError(t, err, "should write workbook")
+
+	f, err := excelize.OpenFile(path)
+	assert.NoError(t, err, "should open workbook")
+	defer f.Close()
+
+	assert.Equal(t, []string{"Madrid", "Paris"}, f.GetSheetList())
+
+	cellType, err := f.GetCellType("Madrid", "B2")
+	assert.NoError(t, err)
+	assert.Equal(t, excelize.CellTypeUnset, cellType, "dates are saved as numbers with a date format")
+	value, err := f.GetCellValue("Madrid", "B2", excelize.Options{RawCellValue: true})
+	assert.NoError(t, err)
+	assert.NotEqual(t, "", value)
+	assert.NotContains(t, value, "/", "dates are not saved as text")
+
+	value, err = f.GetCellValue("Madrid", "B3")
+	assert.NoError(t, err)
+	assert.Equal(t, "", value, "dates not set are left empty")
+
+	value, err = f.GetCellValue("Madrid", "C2", excelize.Options{RawCellValue: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "42", value)
+
+	cellType, err = f.GetCellType("Madrid", "D2")
+	assert.NoError(t, err)
+	assert.Equal(t, excelize.CellTypeBool, cellType)
+
+	panes, err := f.GetPanes("Madrid")
+	assert.NoError(t, err)
+	assert.True(t, panes.Freeze, "header row should be frozen")
+	assert.Equal(t, 1, panes.YSplit)
+
+	names := f.GetDefinedName()
+	assert.Equal(t, 2, len(names), "every sheet should have an autofilter")
+
+	assert.Error(t, Write(path, nil), "should not write a workbook without sheets")
+}
@@ -11,9 +11,9 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-var globalNavbarTests = []string{"users", "sessions", "smtp", "sessions", "settings", "update-servers", "certificates"}
+var globalNavbarTests = []string{"users", "roles", "sessions", "api-tokens", "audit", "smtp", "webhooks", "sessions", "settings", "update-servers", "certificates"}
 
-var tenantNavbarTests = []string{"tags", "metadata", "settings", "update-agents"}
+var tenantNavbarTests = []string{"tags", "metadata", "settings", "update-agents", "webhooks", "alerts", "maintenance-windows", "compliance"}
 
 func TestTenantConfigNavbarTabs(t *testing.T) {
 	config := partials.CommonInfo{TenantID: "1"}
@@ -0,0 +1,54 @@
+package charts
+
+import (
+	"context"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/opts"
+	"github.com/go-echarts/go-echarts/v2/render"
+	"github.com/gohugoio/locales"
+	"github.com/invopop/ctxi18n/i18n"
+	"github.com/open-uem/openuem-console/internal/consoledb"
+	"github.com/open-uem/openuem-console/internal/metrics"
+)
+
+// FleetTrend shows the daily snapshots of the fleet counters as a line per counter
+func FleetTrend(ctx context.Context, l locales.Translator, items []consoledb.FleetMetrics) render.ChartSnippet {
+	line := charts.NewLine()
+
+	days := []string{}
+	for _, item := range items {
+		days = append(days, l.FmtDateShort(item.Day))
+	}
+	line.SetXAxis(days)
+
+	colors := opts.Colors{}
+	for _, kpi := range metrics.KPIs {
+		lineData := []opts.LineData{}
+		for _, value := range metrics.Series(items, kpi) {
+			lineData = append(lineData, opts.LineData{Value: value})
+		}
+		line.AddSeries(i18n.T(ctx, "fleet_metrics."+kpi), lineData)
+		colors = append(colors, metrics.KPIColors[kpi])
+	}
+
+	line.SetSeriesOptions(
+		charts.WithLineChartOpts(opts.LineChart{ShowSymbol: opts.Bool(len(items) == 1)}),
+	)
+
+	labelStyle := opts.TextStyle{Color: "#777"}
+
+	line.SetGlobalOptions(
+		charts.WithTitleOpts(opts.Title{Show: opts.Bool(false)}),
+		charts.WithTooltipOpts(opts.Tooltip{Show: opts.Bool(true), Trigger: "axis"}),
+		charts.WithLegendOpts(opts.Legend{Show: opts.Bool(true), TextStyle: &labelStyle, Type: "scroll", Bottom: "0"}),
+		charts.WithYAxisOpts(opts.YAxis{MinInterval: 1}),
+		charts.WithColorsOpts(colors),
+		charts.WithInitializationOpts(opts.Initialization{
+			Width:  "960px",
+			Height: "320px",
+		}),
+	)
+
+	return line.RenderSnippet()
+}
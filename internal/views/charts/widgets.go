@@ -0,0 +1,96 @@
+package charts
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/event"
+	"github.com/go-echarts/go-echarts/v2/opts"
+	"github.com/go-echarts/go-echarts/v2/render"
+	"github.com/open-uem/openuem-console/internal/models"
+)
+
+var widgetColors = opts.Colors{"#9e0142", "#f46d43", "#fdae61", "#fee08b", "#e6f598", "#abdda4", "#66c2a5", "#3288bd", "#5e4fa2"}
+
+// WidgetPie shows a breakdown of a custom dashboard. Clicking a slice opens its link, the
+// computers list filtered by the value
+func WidgetPie(name string, items []models.Breakdown, links map[string]string) render.ChartSnippet {
+	pie := charts.NewPie()
+
+	pieData := []opts.PieData{}
+	for _, item := range items {
+		pieData = append(pieData, opts.PieData{Name: item.Label, Value: item.Count})
+	}
+
+	pie.AddSeries(name, pieData).SetSeriesOptions(
+		charts.WithLabelOpts(opts.Label{Show: opts.Bool(false), Formatter: "{b}: {c}"}),
+		charts.WithPieChartOpts(opts.PieChart{
+			Radius: []string{"40%", "75%"},
+			Center: []string{"30%", "50%"},
+		}),
+	)
+
+	labelStyle := opts.TextStyle{Color: "#777"}
+
+	pie.SetGlobalOptions(
+		charts.WithTitleOpts(opts.Title{Show: opts.Bool(false)}),
+		charts.WithTooltipOpts(opts.Tooltip{Show: opts.Bool(true), Trigger: "item", Formatter: "{b}: {c} ({d}%)"}),
+		charts.WithLegendOpts(opts.Legend{Show: opts.Bool(true), TextStyle: &labelStyle, Type: "scroll", Orient: "vertical", X: "left", Y: "center", Left: "62%"}),
+		charts.WithColorsOpts(widgetColors),
+		charts.WithInitializationOpts(opts.Initialization{
+			Width:  "480px",
+			Height: "300px",
+		}),
+		charts.WithEventListeners(drillDownListener(links)),
+	)
+
+	return pie.RenderSnippet()
+}
+
+// WidgetBar shows a breakdown of a custom dashboard as bars. Clicking a bar opens its link,
+// the computers list filtered by the value
+func WidgetBar(name string, items []models.Breakdown, links map[string]string) render.ChartSnippet {
+	bar := charts.NewBar()
+
+	labels := []string{}
+	barData := []opts.BarData{}
+	for _, item := range items {
+		labels = append(labels, item.Label)
+		barData = append(barData, opts.BarData{Name: item.Label, Value: item.Count})
+	}
+
+	bar.SetXAxis(labels).AddSeries(name, barData).SetSeriesOptions(
+		charts.WithLabelOpts(opts.Label{Show: opts.Bool(true), Position: "top"}),
+	)
+
+	bar.SetGlobalOptions(
+		charts.WithTitleOpts(opts.Title{Show: opts.Bool(false)}),
+		charts.WithTooltipOpts(opts.Tooltip{Show: opts.Bool(true), Trigger: "item"}),
+		charts.WithLegendOpts(opts.Legend{Show: opts.Bool(false)}),
+		charts.WithXAxisOpts(opts.XAxis{AxisLabel: &opts.AxisLabel{Rotate: 30, Interval: "0"}}),
+		charts.WithYAxisOpts(opts.YAxis{MinInterval: 1}),
+		charts.WithColorsOpts(opts.Colors{"#3288bd"}),
+		charts.WithInitializationOpts(opts.Initialization{
+			Width:  "480px",
+			Height: "300px",
+		}),
+		charts.WithEventListeners(drillDownListener(links)),
+	)
+
+	return bar.RenderSnippet()
+}
+
+// drillDownListener opens the link of the value clicked in a chart, values without a
+// link are ignored
+func drillDownListener(links map[string]string) event.Listener {
+	data, err := json.Marshal(links)
+	if err != nil {
+		data = []byte("{}")
+	}
+
+	return event.Listener{
+		EventName: "click",
+		Handler:   opts.FuncOpts(fmt.Sprintf("(params) => { const links = %s; if (links[params.name]) { window.location.href = links[params.name]; } }", data)),
+	}
+}
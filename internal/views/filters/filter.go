@@ -35,6 +35,9 @@ type AgentFilter struct {
 	LastInstallFrom          string
 	LastInstallTo            string
 	PendingUpdateOptions     []string
+	HardwareAges             []string
+	Warranties               []string
+	Metadata                 map[int]string
 }
 
 type ApplicationsFilter struct {
@@ -59,6 +62,48 @@ type UserFilter struct {
 	RegisterOptions []string
 }
 
+type AuditFilter struct {
+	Username    string
+	Action      string
+	Target      string
+	IP          string
+	CreatedFrom string
+	CreatedTo   string
+}
+
+type AlertHistoryFilter struct {
+	Rule        string
+	Hostname    string
+	Status      string
+	CreatedFrom string
+	CreatedTo   string
+}
+
+type InventoryChangeFilter struct {
+	Hostname    string
+	Item        string
+	Types       []string
+	Actions     []string
+	CreatedFrom string
+	CreatedTo   string
+}
+
+type RolloutAgentFilter struct {
+	Hostname string
+	Statuses []string
+}
+
+type DeploymentJobTargetFilter struct {
+	Hostname string
+	Statuses []string
+}
+
+type MaintenanceQueueFilter struct {
+	Hostname string
+	Actions  []string
+	Statuses []string
+}
+
 type TenantFilter struct {
 	Name           string
 	DefaultOptions []string
@@ -155,3 +200,29 @@ func GetPaginationUrl(c echo.Context) string {
 
 	return c.Request().URL.Path
 }
+
+type ComplianceViolationFilter struct {
+	Hostname string
+	Policy   string
+	App      string
+	Reasons  []string
+}
+
+type VulnerabilityFindingFilter struct {
+	Hostname      string
+	Vulnerability string
+	Software      string
+	Severities    []string
+}
+
+type LicenseFilter struct {
+	Name       string
+	CostCenter string
+	Statuses   []string
+}
+
+type HardwareRefreshFilter struct {
+	Nickname   string
+	Ages       []string
+	Warranties []string
+}
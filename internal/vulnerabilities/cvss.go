@@ -0,0 +1,97 @@
+package vulnerabilities
+
+import (
+	"math"
+	"strings"
+)
+
+var cvss3Weights = map[string]map[string]float64{
+	"AV": {"N": 0.85, "A": 0.62, "L": 0.55, "P": 0.2},
+	"AC": {"L": 0.77, "H": 0.44},
+	"UI": {"N": 0.85, "R": 0.62},
+	"C":  {"H": 0.56, "L": 0.22, "N": 0},
+	"I":  {"H": 0.56, "L": 0.22, "N": 0},
+	"A":  {"H": 0.56, "L": 0.22, "N": 0},
+}
+
+// CVSS3BaseScore computes the base score of a CVSS v3.0 or v3.1 vector like
+// CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H, as explained in the
+// specification of CVSS v3.1. It reports false if the vector isn't valid
+func CVSS3BaseScore(vector string) (float64, bool) {
+	parts := strings.Split(vector, "/")
+	if len(parts) < 9 || (parts[0] != "CVSS:3.0" && parts[0] != "CVSS:3.1") {
+		return 0, false
+	}
+
+	metrics := map[string]string{}
+	for _, part := range parts[1:] {
+		name, value, ok := strings.Cut(part, ":")
+		if !ok {
+			return 0, false
+		}
+		metrics[name] = value
+	}
+
+	weights := map[string]float64{}
+	for name, values := range cvss3Weights {
+		weight, ok := values[metrics[name]]
+		if !ok {
+			return 0, false
+		}
+		weights[name] = weight
+	}
+
+	changed := false
+	switch metrics["S"] {
+	case "U":
+	case "C":
+		changed = true
+	default:
+		return 0, false
+	}
+
+	var privileges float64
+	switch metrics["PR"] {
+	case "N":
+		privileges = 0.85
+	case "L":
+		privileges = 0.62
+		if changed {
+			privileges = 0.68
+		}
+	case "H":
+		privileges = 0.27
+		if changed {
+			privileges = 0.5
+		}
+	default:
+		return 0, false
+	}
+
+	iss := 1 - (1-weights["C"])*(1-weights["I"])*(1-weights["A"])
+
+	impact := 6.42 * iss
+	if changed {
+		impact = 7.52*(iss-0.029) - 3.25*math.Pow(iss-0.02, 15)
+	}
+	if impact <= 0 {
+		return 0, true
+	}
+
+	exploitability := 8.22 * weights["AV"] * weights["AC"] * privileges * weights["UI"]
+
+	if changed {
+		return roundUp(math.Min(1.08*(impact+exploitability), 10)), true
+	}
+	return roundUp(math.Min(impact+exploitability, 10)), true
+}
+
+// roundUp returns the smallest number with one decimal that is equal or higher than
+// the value, avoiding floating point errors as the specification says
+func roundUp(value float64) float64 {
+	integer := int64(math.Round(value * 100000))
+	if integer%10000 == 0 {
+		return float64(integer) / 100000
+	}
+	return float64(integer/10000+1) / 10
+}
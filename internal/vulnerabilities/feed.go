@@ -0,0 +1,174 @@
+package vulnerabilities
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// LoadFeed reads the feed files of the folder: .json files, gzipped .json.gz
+// files and .zip archives of .json files, like the OSV dumps. Every file may be
+// an NVD JSON 2.0 file or OSV records
+func LoadFeed(dir string) (*Feed, error) {
+	files, err := feedFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	vulnerabilities := []Vulnerability{}
+	for _, file := range files {
+		items, err := readFeedFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(file), err)
+		}
+		vulnerabilities = append(vulnerabilities, items...)
+	}
+
+	return NewFeed(vulnerabilities, len(files)), nil
+}
+
+// Fingerprint identifies the files of the folder by their names, sizes and
+// modification times, so the feed is only loaded again when they change
+func Fingerprint(dir string) (string, error) {
+	files, err := feedFiles(dir)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(hash, "%s|%d|%d\n", filepath.Base(file), info.Size(), info.ModTime().UnixNano())
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func feedFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{}
+	for _, e := range entries {
+		name := strings.ToLower(e.Name())
+		if e.IsDir() || !(strings.HasSuffix(name, ".json") || strings.HasSuffix(name, ".json.gz") || strings.HasSuffix(name, ".zip")) {
+			continue
+		}
+		files = append(files, filepath.Join(dir, e.Name()))
+	}
+	sort.Strings(files)
+
+	return files, nil
+}
+
+func readFeedFile(file string) ([]Vulnerability, error) {
+	name := strings.ToLower(file)
+
+	if strings.HasSuffix(name, ".zip") {
+		return readZip(file)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(name, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(data)
+}
+
+func readZip(file string) ([]Vulnerability, error) {
+	archive, err := zip.OpenReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+
+	vulnerabilities := []Vulnerability{}
+	for _, entry := range archive.File {
+		if entry.FileInfo().IsDir() || !strings.HasSuffix(strings.ToLower(entry.Name), ".json") {
+			continue
+		}
+
+		items, err := readZipEntry(entry)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.Name, err)
+		}
+		vulnerabilities = append(vulnerabilities, items...)
+	}
+
+	return vulnerabilities, nil
+}
+
+func readZipEntry(entry *zip.File) ([]Vulnerability, error) {
+	r, err := entry.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(data)
+}
+
+// Parse reads the vulnerabilities of an NVD JSON 2.0 document or OSV records
+func Parse(data []byte) ([]Vulnerability, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	if data[0] == '[' {
+		return parseOSV(data)
+	}
+
+	var document struct {
+		Vulnerabilities json.RawMessage `json:"vulnerabilities"`
+		ID              string          `json:"id"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case document.Vulnerabilities != nil:
+		return parseNVD(data)
+	case document.ID != "":
+		return parseOSV(data)
+	default:
+		return nil, fmt.Errorf("unknown vulnerability feed format")
+	}
+}
@@ -0,0 +1,221 @@
+package vulnerabilities
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// nvdLayout is how the NVD writes dates, without time zone
+const nvdLayout = "2006-01-02T15:04:05"
+
+type nvdFeed struct {
+	Vulnerabilities []struct {
+		CVE nvdCVE `json:"cve"`
+	} `json:"vulnerabilities"`
+}
+
+type nvdCVE struct {
+	ID           string `json:"id"`
+	Published    string `json:"published"`
+	VulnStatus   string `json:"vulnStatus"`
+	Descriptions []struct {
+		Lang  string `json:"lang"`
+		Value string `json:"value"`
+	} `json:"descriptions"`
+	Metrics struct {
+		V40 []nvdMetric `json:"cvssMetricV40"`
+		V31 []nvdMetric `json:"cvssMetricV31"`
+		V30 []nvdMetric `json:"cvssMetricV30"`
+		V2  []nvdMetric `json:"cvssMetricV2"`
+	} `json:"metrics"`
+	Configurations []struct {
+		Nodes []struct {
+			Negate   bool `json:"negate"`
+			CPEMatch []struct {
+				Vulnerable            bool   `json:"vulnerable"`
+				Criteria              string `json:"criteria"`
+				VersionStartIncluding string `json:"versionStartIncluding"`
+				VersionStartExcluding string `json:"versionStartExcluding"`
+				VersionEndIncluding   string `json:"versionEndIncluding"`
+				VersionEndExcluding   string `json:"versionEndExcluding"`
+			} `json:"cpeMatch"`
+		} `json:"nodes"`
+	} `json:"configurations"`
+}
+
+type nvdMetric struct {
+	Type     string `json:"type"`
+	CVSSData struct {
+		BaseScore    float64 `json:"baseScore"`
+		BaseSeverity string  `json:"baseSeverity"`
+	} `json:"cvssData"`
+	BaseSeverity string `json:"baseSeverity"`
+}
+
+// parseNVD reads a file of the NVD CVE API 2.0 format, the rejected CVEs and the
+// ones without vulnerable applications or operating systems are skipped
+func parseNVD(data []byte) ([]Vulnerability, error) {
+	var feed nvdFeed
+	if err := json.Unmarshal(data, &feed); err != nil {
+		return nil, err
+	}
+
+	vulnerabilities := []Vulnerability{}
+	for _, item := range feed.Vulnerabilities {
+		cve := item.CVE
+		if cve.ID == "" || strings.EqualFold(cve.VulnStatus, "Rejected") {
+			continue
+		}
+
+		v := Vulnerability{ID: cve.ID, Published: parseTime(cve.Published)}
+
+		for _, d := range cve.Descriptions {
+			if d.Lang == "en" {
+				v.Summary = d.Value
+				break
+			}
+		}
+
+		v.Score, v.Severity = nvdScore(cve)
+
+		for _, configuration := range cve.Configurations {
+			for _, node := range configuration.Nodes {
+				if node.Negate {
+					continue
+				}
+				for _, match := range node.CPEMatch {
+					if !match.Vulnerable {
+						continue
+					}
+
+					p, ok := parseCPE(match.Criteria)
+					if !ok {
+						continue
+					}
+
+					switch {
+					case match.VersionStartIncluding != "":
+						p.Range.Start = match.VersionStartIncluding
+					case match.VersionStartExcluding != "":
+						p.Range.Start = match.VersionStartExcluding
+						p.Range.StartExcluded = true
+					}
+
+					switch {
+					case match.VersionEndIncluding != "":
+						p.Range.End = match.VersionEndIncluding
+						p.Range.EndIncluded = true
+					case match.VersionEndExcluding != "":
+						p.Range.End = match.VersionEndExcluding
+					}
+
+					v.Products = append(v.Products, p)
+				}
+			}
+		}
+
+		if len(v.Products) > 0 {
+			vulnerabilities = append(vulnerabilities, v)
+		}
+	}
+
+	return vulnerabilities, nil
+}
+
+// nvdScore returns the score of the newest CVSS version, preferring the one given
+// by the NVD to the one given by the CNA
+func nvdScore(cve nvdCVE) (float64, string) {
+	for _, metrics := range [][]nvdMetric{cve.Metrics.V40, cve.Metrics.V31, cve.Metrics.V30, cve.Metrics.V2} {
+		if len(metrics) == 0 {
+			continue
+		}
+
+		metric := metrics[0]
+		for _, m := range metrics {
+			if m.Type == "Primary" {
+				metric = m
+				break
+			}
+		}
+
+		if metric.CVSSData.BaseScore > 0 {
+			return metric.CVSSData.BaseScore, SeverityFromScore(metric.CVSSData.BaseScore)
+		}
+
+		severity := metric.CVSSData.BaseSeverity
+		if severity == "" {
+			severity = metric.BaseSeverity
+		}
+		return 0, normalizeSeverity(severity)
+	}
+
+	return 0, SeverityNone
+}
+
+// parseCPE returns the product of a CPE 2.3 name, only applications (a) and
+// operating systems (o) are used
+func parseCPE(cpe string) (Product, bool) {
+	parts := splitCPE(cpe)
+	if len(parts) < 6 || parts[0] != "cpe" || parts[1] != "2.3" {
+		return Product{}, false
+	}
+
+	p := Product{Vendor: parts[3], Name: parts[4]}
+	switch parts[2] {
+	case "a":
+		p.Kind = KindApp
+	case "o":
+		p.Kind = KindOS
+	default:
+		return Product{}, false
+	}
+
+	if p.Name == "" || p.Name == "*" || p.Name == "-" {
+		return Product{}, false
+	}
+	if p.Vendor == "*" || p.Vendor == "-" {
+		p.Vendor = ""
+	}
+
+	if version := parts[5]; version != "*" && version != "-" {
+		p.Version = version
+	}
+
+	return p, true
+}
+
+// splitCPE splits a CPE name at the colons that aren't escaped and removes the
+// escaping backslashes
+func splitCPE(cpe string) []string {
+	parts := []string{}
+	var current strings.Builder
+	escaped := false
+
+	for _, r := range cpe {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == ':':
+			parts = append(parts, current.String())
+			current.Reset()
+		default:
+			current.WriteRune(r)
+		}
+	}
+
+	return append(parts, current.String())
+}
+
+func parseTime(value string) time.Time {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t
+	}
+	if t, err := time.Parse(nvdLayout, value); err == nil {
+		return t
+	}
+	return time.Time{}
+}
@@ -0,0 +1,153 @@
+package vulnerabilities
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+type osvRecord struct {
+	ID        string        `json:"id"`
+	Aliases   []string      `json:"aliases"`
+	Summary   string        `json:"summary"`
+	Details   string        `json:"details"`
+	Published string        `json:"published"`
+	Withdrawn string        `json:"withdrawn"`
+	Severity  []osvSeverity `json:"severity"`
+	Affected  []struct {
+		Package struct {
+			Ecosystem string `json:"ecosystem"`
+			Name      string `json:"name"`
+		} `json:"package"`
+		Severity []osvSeverity `json:"severity"`
+		Ranges   []struct {
+			Type   string `json:"type"`
+			Events []struct {
+				Introduced   string `json:"introduced"`
+				Fixed        string `json:"fixed"`
+				LastAffected string `json:"last_affected"`
+			} `json:"events"`
+		} `json:"ranges"`
+		Versions         []string          `json:"versions"`
+		DatabaseSpecific osvDatabaseFields `json:"database_specific"`
+	} `json:"affected"`
+	DatabaseSpecific osvDatabaseFields `json:"database_specific"`
+}
+
+type osvSeverity struct {
+	Type  string `json:"type"`
+	Score string `json:"score"`
+}
+
+type osvDatabaseFields struct {
+	Severity string `json:"severity"`
+}
+
+// parseOSV reads an OSV record or an array of them, without leading spaces. The
+// records are identified by their CVE alias if they have one, so the same
+// vulnerability coming from the NVD and OSV is shown once for every software
+func parseOSV(data []byte) ([]Vulnerability, error) {
+	var records []osvRecord
+
+	if data[0] == '[' {
+		if err := json.Unmarshal(data, &records); err != nil {
+			return nil, err
+		}
+	} else {
+		var record osvRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+
+	vulnerabilities := []Vulnerability{}
+	for _, r := range records {
+		if r.ID == "" || r.Withdrawn != "" {
+			continue
+		}
+
+		v := Vulnerability{ID: r.ID, Summary: r.Summary, Published: parseTime(r.Published)}
+		for _, alias := range r.Aliases {
+			if strings.HasPrefix(alias, "CVE-") {
+				v.ID = alias
+				break
+			}
+		}
+		if v.Summary == "" {
+			v.Summary = r.Details
+		}
+
+		v.Score, v.Severity = osvScore(r.Severity, r.DatabaseSpecific.Severity)
+
+		for _, a := range r.Affected {
+			if a.Package.Name == "" {
+				continue
+			}
+
+			if v.Score == 0 && v.Severity == SeverityNone {
+				v.Score, v.Severity = osvScore(a.Severity, a.DatabaseSpecific.Severity)
+			}
+
+			product := Product{Kind: KindApp, Name: a.Package.Name}
+
+			for _, version := range a.Versions {
+				p := product
+				p.Version = version
+				v.Products = append(v.Products, p)
+			}
+
+			for _, rng := range a.Ranges {
+				if rng.Type == "GIT" {
+					continue
+				}
+
+				// every introduced event starts a range that ends with the next fixed
+				// or last affected event
+				var current *Product
+				for _, e := range rng.Events {
+					switch {
+					case e.Introduced != "":
+						p := product
+						if e.Introduced != "0" {
+							p.Range.Start = e.Introduced
+						}
+						current = &p
+					case e.Fixed != "" && current != nil:
+						current.Range.End = e.Fixed
+						v.Products = append(v.Products, *current)
+						current = nil
+					case e.LastAffected != "" && current != nil:
+						current.Range.End = e.LastAffected
+						current.Range.EndIncluded = true
+						v.Products = append(v.Products, *current)
+						current = nil
+					}
+				}
+				if current != nil {
+					v.Products = append(v.Products, *current)
+				}
+			}
+		}
+
+		if len(v.Products) > 0 {
+			vulnerabilities = append(vulnerabilities, v)
+		}
+	}
+
+	return vulnerabilities, nil
+}
+
+// osvScore computes the score of the CVSS v3 vector, the severity given by the
+// database is used for other vectors
+func osvScore(severities []osvSeverity, databaseSeverity string) (float64, string) {
+	for _, s := range severities {
+		if s.Type != "CVSS_V3" {
+			continue
+		}
+		if score, ok := CVSS3BaseScore(s.Score); ok {
+			return score, SeverityFromScore(score)
+		}
+	}
+
+	return 0, normalizeSeverity(databaseSeverity)
+}
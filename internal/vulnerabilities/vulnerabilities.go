@@ -0,0 +1,303 @@
+// Package vulnerabilities matches the applications and operating systems reported
+// by the agents against an offline vulnerability feed, made of NVD JSON 2.0 files
+// and OSV records placed in a folder.
+package vulnerabilities
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/open-uem/openuem-console/internal/compliance"
+)
+
+const (
+	KindApp = "app"
+	KindOS  = "os"
+)
+
+const (
+	SeverityCritical = "critical"
+	SeverityHigh     = "high"
+	SeverityMedium   = "medium"
+	SeverityLow      = "low"
+	SeverityNone     = "none"
+)
+
+// Severities contains the severities of a finding, the most severe first
+var Severities = []string{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow, SeverityNone}
+
+// RefreshInterval is how often the feed folder is checked and the agents are scanned
+const RefreshInterval = time.Hour
+
+// Vulnerability is an entry of the feed with the products it affects
+type Vulnerability struct {
+	ID        string
+	Summary   string
+	Score     float64
+	Severity  string
+	Published time.Time
+	Products  []Product
+}
+
+// Product is a vulnerable application or operating system. Version is an exact
+// vulnerable version, otherwise every version inside the range is vulnerable and
+// an empty range means every version
+type Product struct {
+	Kind    string
+	Vendor  string
+	Name    string
+	Version string
+	Range   Range
+}
+
+// Range limits the vulnerable versions of a product, an empty bound is unlimited
+type Range struct {
+	Start         string
+	StartExcluded bool
+	End           string
+	EndIncluded   bool
+}
+
+// Software is an application or the operating system of an agent
+type Software struct {
+	Kind      string
+	Name      string
+	Version   string
+	Publisher string
+}
+
+// Finding is a vulnerability that affects a software of an agent. FixedVersion is
+// empty when the feed doesn't know a version without the vulnerability
+type Finding struct {
+	VulnerabilityID string
+	Summary         string
+	Score           float64
+	Severity        string
+	Published       time.Time
+	Kind            string
+	Software        string
+	Version         string
+	FixedVersion    string
+}
+
+// Feed holds the vulnerabilities indexed by the first word of the product names
+type Feed struct {
+	Vulnerabilities []Vulnerability
+	Files           int
+	index           map[string][]productRef
+}
+
+type productRef struct {
+	vulnerability int
+	product       int
+	kind          string
+	tokens        []string
+	vendor        []string
+}
+
+// osGenericTokens are ignored in the names of operating systems, the feeds call
+// them ubuntu_linux or debian_linux but the agents report Ubuntu or Debian
+var osGenericTokens = []string{"linux"}
+
+func NewFeed(vulnerabilities []Vulnerability, files int) *Feed {
+	f := Feed{Vulnerabilities: vulnerabilities, Files: files, index: map[string][]productRef{}}
+
+	for i, v := range vulnerabilities {
+		for j, p := range v.Products {
+			tokens := tokenize(p.Name)
+			if p.Kind == KindOS {
+				tokens = removeTokens(tokens, osGenericTokens)
+			}
+			if len(tokens) == 0 {
+				continue
+			}
+			f.index[tokens[0]] = append(f.index[tokens[0]], productRef{vulnerability: i, product: j, kind: p.Kind, tokens: tokens, vendor: tokenize(p.Vendor)})
+		}
+	}
+
+	return &f
+}
+
+// Match returns the vulnerabilities that affect the software, the highest score first
+func (f *Feed) Match(software []Software) []Finding {
+	findings := []Finding{}
+	found := map[string]bool{}
+
+	for _, s := range software {
+		if s.Name == "" {
+			continue
+		}
+
+		name := tokenize(s.Name)
+		publisher := tokenize(s.Publisher)
+		seen := map[string]bool{}
+
+		for _, t := range name {
+			if seen[t] {
+				continue
+			}
+			seen[t] = true
+
+			for _, ref := range f.index[t] {
+				v := f.Vulnerabilities[ref.vulnerability]
+				p := v.Products[ref.product]
+
+				if ref.kind != s.Kind || !ref.matchesName(name, publisher) || !p.affects(s.Version) {
+					continue
+				}
+
+				key := v.ID + "\x00" + s.Kind + "\x00" + s.Name + "\x00" + s.Version
+				if found[key] {
+					continue
+				}
+				found[key] = true
+
+				fixed := ""
+				if p.Version == "" && p.Range.End != "" && !p.Range.EndIncluded {
+					fixed = p.Range.End
+				}
+
+				findings = append(findings, Finding{
+					VulnerabilityID: v.ID,
+					Summary:         v.Summary,
+					Score:           v.Score,
+					Severity:        v.Severity,
+					Published:       v.Published,
+					Kind:            s.Kind,
+					Software:        s.Name,
+					Version:         s.Version,
+					FixedVersion:    fixed,
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Score != findings[j].Score {
+			return findings[i].Score > findings[j].Score
+		}
+		return findings[i].VulnerabilityID < findings[j].VulnerabilityID
+	})
+
+	return findings
+}
+
+// matchesName reports if the product words appear in order in the name of the
+// software. Products without vendor, which come from package ecosystems, must have
+// the same name. Otherwise a word of the vendor must be in the name or the publisher
+// of the software, as vendors like notepad-plus-plus don't match the publisher
+// exactly. Operating systems have no publisher so their vendor isn't checked
+func (ref productRef) matchesName(name, publisher []string) bool {
+	if len(ref.vendor) == 0 {
+		return slices.Equal(ref.tokens, name)
+	}
+
+	if !containsSequence(name, ref.tokens) {
+		return false
+	}
+
+	if ref.kind == KindOS {
+		return true
+	}
+
+	for _, t := range ref.vendor {
+		if slices.Contains(name, t) || slices.Contains(publisher, t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// affects reports if the version is vulnerable, an unknown version is only
+// vulnerable when every version is
+func (p Product) affects(version string) bool {
+	if p.Version != "" {
+		return version != "" && compliance.CompareVersions(version, p.Version) == 0
+	}
+
+	if p.Range.Start == "" && p.Range.End == "" {
+		return true
+	}
+
+	if version == "" {
+		return false
+	}
+
+	if p.Range.Start != "" {
+		c := compliance.CompareVersions(version, p.Range.Start)
+		if c < 0 || (c == 0 && p.Range.StartExcluded) {
+			return false
+		}
+	}
+
+	if p.Range.End != "" {
+		c := compliance.CompareVersions(version, p.Range.End)
+		if c > 0 || (c == 0 && !p.Range.EndIncluded) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// SeverityFromScore returns the CVSS qualitative rating of a score
+func SeverityFromScore(score float64) string {
+	switch {
+	case score >= 9:
+		return SeverityCritical
+	case score >= 7:
+		return SeverityHigh
+	case score >= 4:
+		return SeverityMedium
+	case score > 0:
+		return SeverityLow
+	default:
+		return SeverityNone
+	}
+}
+
+// normalizeSeverity converts the severities used by the feeds to ours
+func normalizeSeverity(severity string) string {
+	switch strings.ToLower(severity) {
+	case "critical":
+		return SeverityCritical
+	case "high", "important":
+		return SeverityHigh
+	case "medium", "moderate":
+		return SeverityMedium
+	case "low":
+		return SeverityLow
+	default:
+		return SeverityNone
+	}
+}
+
+// tokenize splits a name in lowercase words of letters and digits
+func tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func removeTokens(tokens, remove []string) []string {
+	kept := []string{}
+	for _, t := range tokens {
+		if !slices.Contains(remove, t) {
+			kept = append(kept, t)
+		}
+	}
+	return kept
+}
+
+func containsSequence(tokens, sequence []string) bool {
+	for i := 0; i+len(sequence) <= len(tokens); i++ {
+		if slices.Equal(tokens[i:i+len(sequence)], sequence) {
+			return true
+		}
+	}
+	return false
+}
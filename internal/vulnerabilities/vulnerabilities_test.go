@@ -0,0 +1,210 @@
+package vulnerabilities
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const nvdDocument = `{
+  "format": "NVD_CVE",
+  "version": "2.0",
+  "vulnerabilities": [
+    {
+      "cve": {
+        "id": "CVE-2024-0001",
+        "published": "2024-07-09T15:15:11.853",
+        "vulnStatus": "Analyzed",
+        "descriptions": [{"lang": "es", "value": "Desbordamiento"}, {"lang": "en", "value": "Use after free in Firefox"}],
+        "metrics": {
+          "cvssMetricV31": [
+            {"source": "security@mozilla.org", "type": "Secondary", "cvssData": {"baseScore": 7.5, "baseSeverity": "HIGH"}},
+            {"source": "nvd@nist.gov", "type": "Primary", "cvssData": {"baseScore": 9.8, "baseSeverity": "CRITICAL"}}
+          ]
+        },
+        "configurations": [
+          {
+            "nodes": [
+              {
+                "operator": "OR",
+                "negate": false,
+                "cpeMatch": [
+                  {"vulnerable": true, "criteria": "cpe:2.3:a:mozilla:firefox:*:*:*:*:*:*:*:*", "versionEndExcluding": "128.0"},
+                  {"vulnerable": false, "criteria": "cpe:2.3:o:microsoft:windows:-:*:*:*:*:*:*:*"}
+                ]
+              }
+            ]
+          }
+        ]
+      }
+    },
+    {
+      "cve": {
+        "id": "CVE-2024-0002",
+        "published": "2024-01-02T10:00:00.000",
+        "vulnStatus": "Analyzed",
+        "descriptions": [{"lang": "en", "value": "Windows kernel elevation of privilege"}],
+        "metrics": {"cvssMetricV2": [{"type": "Primary", "cvssData": {"baseScore": 4.6}, "baseSeverity": "MEDIUM"}]},
+        "configurations": [
+          {"nodes": [{"cpeMatch": [{"vulnerable": true, "criteria": "cpe:2.3:o:microsoft:windows_10:*:*:*:*:*:*:*:*", "versionStartIncluding": "10.0.19041", "versionEndIncluding": "10.0.19045.4000"}]}]}
+        ]
+      }
+    },
+    {
+      "cve": {
+        "id": "CVE-2024-0003",
+        "vulnStatus": "Rejected",
+        "configurations": [{"nodes": [{"cpeMatch": [{"vulnerable": true, "criteria": "cpe:2.3:a:mozilla:firefox:*:*:*:*:*:*:*:*"}]}]}]
+      }
+    },
+    {
+      "cve": {
+        "id": "CVE-2024-0004",
+        "vulnStatus": "Analyzed",
+        "configurations": [{"nodes": [{"cpeMatch": [{"vulnerable": true, "criteria": "cpe:2.3:a:notepad-plus-plus:notepad\\+\\+:8.6.4:*:*:*:*:*:*:*"}]}]}]
+      }
+    }
+  ]
+}`
+
+const osvDocument = `[
+  {
+    "id": "DSA-5001-1",
+    "aliases": ["CVE-2024-1000"],
+    "summary": "Buffer overflow in curl",
+    "published": "2024-03-01T00:00:00Z",
+    "severity": [{"type": "CVSS_V3", "score": "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:N/A:N"}],
+    "affected": [
+      {
+        "package": {"ecosystem": "Debian:12", "name": "curl"},
+        "ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"fixed": "7.88.1-10+deb12u6"}]}]
+      }
+    ]
+  },
+  {
+    "id": "GHSA-xxxx",
+    "withdrawn": "2024-04-01T00:00:00Z",
+    "affected": [{"package": {"name": "curl"}, "versions": ["7.88.1"]}]
+  }
+]`
+
+func TestParse(t *testing.T) {
+	vulnerabilities, err := Parse([]byte(nvdDocument))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(vulnerabilities), "rejected CVEs should be skipped")
+
+	firefox := vulnerabilities[0]
+	assert.Equal(t, "CVE-2024-0001", firefox.ID)
+	assert.Equal(t, "Use after free in Firefox", firefox.Summary)
+	assert.Equal(t, 9.8, firefox.Score, "the primary metric should be used")
+	assert.Equal(t, SeverityCritical, firefox.Severity)
+	assert.Equal(t, time.Date(2024, 7, 9, 15, 15, 11, 853000000, time.UTC), firefox.Published)
+	assert.Equal(t, []Product{{Kind: KindApp, Vendor: "mozilla", Name: "firefox", Range: Range{End: "128.0"}}}, firefox.Products)
+
+	assert.Equal(t, SeverityMedium, vulnerabilities[1].Severity)
+	assert.Equal(t, KindOS, vulnerabilities[1].Products[0].Kind)
+	assert.Equal(t, "notepad++", vulnerabilities[2].Products[0].Name)
+	assert.Equal(t, "8.6.4", vulnerabilities[2].Products[0].Version)
+
+	vulnerabilities, err = Parse([]byte(osvDocument))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(vulnerabilities), "withdrawn records should be skipped")
+	assert.Equal(t, "CVE-2024-1000", vulnerabilities[0].ID)
+	assert.Equal(t, 7.5, vulnerabilities[0].Score)
+	assert.Equal(t, []Product{{Kind: KindApp, Name: "curl", Range: Range{End: "7.88.1-10+deb12u6"}}}, vulnerabilities[0].Products)
+
+	_, err = Parse([]byte(`{"foo": "bar"}`))
+	assert.Error(t, err)
+}
+
+func TestCVSS3BaseScore(t *testing.T) {
+	for vector, expected := range map[string]float64{
+		"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H": 9.8,
+		"CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N": 6.1,
+		"CVSS:3.0/AV:L/AC:L/PR:L/UI:N/S:U/C:H/I:H/A:H": 7.8,
+		"CVSS:3.1/AV:N/AC:H/PR:H/UI:R/S:U/C:N/I:N/A:N": 0,
+		"CVSS:3.1/AV:N/AC:L/PR:L/UI:N/S:C/C:H/I:H/A:H": 9.9,
+	} {
+		score, ok := CVSS3BaseScore(vector)
+		assert.True(t, ok, vector)
+		assert.Equal(t, expected, score, vector)
+	}
+
+	_, ok := CVSS3BaseScore("CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N")
+	assert.False(t, ok)
+	_, ok = CVSS3BaseScore("CVSS:3.1/AV:X/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H")
+	assert.False(t, ok)
+}
+
+func TestMatch(t *testing.T) {
+	nvd, err := Parse([]byte(nvdDocument))
+	assert.NoError(t, err)
+	osv, err := Parse([]byte(osvDocument))
+	assert.NoError(t, err)
+
+	feed := NewFeed(append(nvd, osv...), 2)
+
+	findings := feed.Match([]Software{
+		{Kind: KindApp, Name: "Mozilla Firefox (x64 es-ES)", Version: "127.0.2", Publisher: "Mozilla"},
+		{Kind: KindApp, Name: "Mozilla Firefox (x64 es-ES)", Version: "127.0.2", Publisher: "Mozilla"},
+		{Kind: KindApp, Name: "Firefox Send", Version: "1.0", Publisher: "Someone else"},
+		{Kind: KindApp, Name: "Notepad++ (64-bit x64)", Version: "8.6.4", Publisher: "Notepad++ Team"},
+		{Kind: KindApp, Name: "curl", Version: "7.88.1-10+deb12u5"},
+		{Kind: KindApp, Name: "libcurl4", Version: "7.88.1-10+deb12u5"},
+		{Kind: KindOS, Name: "Windows 10 Pro", Version: "10.0.19045.3930"},
+	})
+
+	assert.Equal(t, []Finding{
+		{VulnerabilityID: "CVE-2024-0001", Summary: "Use after free in Firefox", Score: 9.8, Severity: SeverityCritical, Published: nvd[0].Published, Kind: KindApp, Software: "Mozilla Firefox (x64 es-ES)", Version: "127.0.2", FixedVersion: "128.0"},
+		{VulnerabilityID: "CVE-2024-1000", Summary: "Buffer overflow in curl", Score: 7.5, Severity: SeverityHigh, Published: osv[0].Published, Kind: KindApp, Software: "curl", Version: "7.88.1-10+deb12u5", FixedVersion: "7.88.1-10+deb12u6"},
+		{VulnerabilityID: "CVE-2024-0002", Summary: "Windows kernel elevation of privilege", Score: 4.6, Severity: SeverityMedium, Published: nvd[1].Published, Kind: KindOS, Software: "Windows 10 Pro", Version: "10.0.19045.3930"},
+		{VulnerabilityID: "CVE-2024-0004", Severity: SeverityNone, Kind: KindApp, Software: "Notepad++ (64-bit x64)", Version: "8.6.4"},
+	}, findings)
+
+	assert.Empty(t, feed.Match([]Software{
+		{Kind: KindApp, Name: "Mozilla Firefox", Version: "128.0", Publisher: "Mozilla"},
+		{Kind: KindApp, Name: "Mozilla Firefox", Publisher: "Mozilla"},
+		{Kind: KindApp, Name: "curl", Version: "7.88.1-10+deb12u6"},
+		{Kind: KindApp, Name: "Windows 10 Pro", Version: "10.0.19045.3930"},
+		{Kind: KindOS, Name: "Windows 10 Pro", Version: "10.0.19045.4046"},
+		{Kind: KindOS, Name: "Windows 11 Pro", Version: "10.0.22631.3930"},
+	}))
+}
+
+func TestLoadFeed(t *testing.T) {
+	dir := t.TempDir()
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "nvdcve-2.0-2024.json"), []byte(nvdDocument), 0600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "README.txt"), []byte("not a feed"), 0600))
+
+	f, err := os.Create(filepath.Join(dir, "osv.zip"))
+	assert.NoError(t, err)
+	archive := zip.NewWriter(f)
+	w, err := archive.Create("DSA-5001-1.json")
+	assert.NoError(t, err)
+	_, err = w.Write([]byte(osvDocument))
+	assert.NoError(t, err)
+	assert.NoError(t, archive.Close())
+	assert.NoError(t, f.Close())
+
+	fingerprint, err := Fingerprint(dir)
+	assert.NoError(t, err)
+
+	feed, err := LoadFeed(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, feed.Files)
+	assert.Equal(t, 4, len(feed.Vulnerabilities))
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0600))
+
+	changed, err := Fingerprint(dir)
+	assert.NoError(t, err)
+	assert.NotEqual(t, fingerprint, changed)
+
+	_, err = LoadFeed(dir)
+	assert.ErrorContains(t, err, "broken.json")
+}
@@ -0,0 +1,152 @@
+// Package webhooks defines the console events that can be sent to HTTP endpoints,
+// the payload posted for each event and how requests are signed and retried.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	EventAgentWaitingAdmission = "agent.waiting_admission"
+	EventAgentAdmitted         = "agent.admitted"
+	EventAgentDisabled         = "agent.disabled"
+	EventAgentDeleted          = "agent.deleted"
+	EventDeploymentFailed      = "deployment.failed"
+	EventAntivirusDisabled     = "antivirus.disabled"
+	EventUpdatesPending        = "updates.pending"
+	EventCertificateExpiring   = "certificate.expiring"
+	EventProfileIssue          = "profile.issue"
+	// EventRolloutHalted is sent when too many agents fail to update in a staged rollout
+	EventRolloutHalted = "rollout.halted"
+	// EventTest is sent when an admin tests a webhook, webhooks can't subscribe to it
+	EventTest = "test"
+)
+
+// Events contains the events webhooks can subscribe to
+var Events = []string{
+	EventAgentWaitingAdmission,
+	EventAgentAdmitted,
+	EventAgentDisabled,
+	EventAgentDeleted,
+	EventDeploymentFailed,
+	EventAntivirusDisabled,
+	EventUpdatesPending,
+	EventCertificateExpiring,
+	EventProfileIssue,
+	EventRolloutHalted,
+}
+
+// Headers added to every request
+const (
+	HeaderEvent     = "X-OpenUEM-Event"
+	HeaderDelivery  = "X-OpenUEM-Delivery"
+	HeaderTimestamp = "X-OpenUEM-Timestamp"
+	HeaderSignature = "X-OpenUEM-Signature"
+)
+
+// MaxAttempts is the number of times a delivery is tried before it's marked as failed
+const MaxAttempts = 6
+
+// CertificateExpiryWarning is how long before the expiry date the certificate.expiring event is sent
+const CertificateExpiryWarning = 30 * 24 * time.Hour
+
+// Payload is the JSON body posted to the endpoints
+type Payload struct {
+	ID        string    `json:"id"`
+	Event     string    `json:"event"`
+	Timestamp time.Time `json:"timestamp"`
+	TenantID  int       `json:"tenant_id"`
+	Data      any       `json:"data,omitempty"`
+}
+
+// Subject is an object in a state that triggers an event, e.g. an agent with
+// its antivirus disabled. Key identifies the object so the event is only sent
+// when the object enters the state
+type Subject struct {
+	Key  string
+	Data map[string]any
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of the timestamp and the body joined
+// by a dot. Receivers must compute the same value to verify the request
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Backoff returns how long to wait before the next attempt, it doubles
+// after every failed attempt starting at one minute
+func Backoff(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	return time.Minute << min(attempts-1, 10)
+}
+
+// ValidURL reports if the endpoint is an absolute http or https URL
+func ValidURL(endpoint string) bool {
+	u, err := url.ParseRequestURI(endpoint)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// Send posts the payload to the endpoint and returns the HTTP status code.
+// Any status code outside the 2xx range is returned as an error
+func Send(ctx context.Context, client *http.Client, endpoint, secret string, payload []byte) (int, error) {
+	var p Payload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "OpenUEM-Console")
+	req.Header.Set(HeaderEvent, p.Event)
+	req.Header.Set(HeaderDelivery, p.ID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(secret, timestamp, payload))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("the endpoint returned %s", resp.Status)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// GenerateSecret returns a random secret used to sign the requests sent to a webhook
+func GenerateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,63 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSign(t *testing.T) {
+	// echo -n '1700000000.{}' | openssl dgst -sha256 -hmac secret
+	assert.Equal(t, "sha256=b8569b78799ff9e3cbff0fc2d63a33a2b57f3282abd07c37ae5e8e7d79a5f163", Sign("secret", 1700000000, []byte("{}")))
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, time.Minute, Backoff(0))
+	assert.Equal(t, time.Minute, Backoff(1))
+	assert.Equal(t, 2*time.Minute, Backoff(2))
+	assert.Equal(t, 16*time.Minute, Backoff(5))
+	assert.Equal(t, Backoff(11), Backoff(50), "backoff should have a limit")
+}
+
+func TestValidURL(t *testing.T) {
+	assert.True(t, ValidURL("https://hooks.example.com/openuem"))
+	assert.True(t, ValidURL("http://10.0.0.1:8080/hook"))
+	assert.False(t, ValidURL("ftp://example.com"))
+	assert.False(t, ValidURL("example.com/hook"))
+	assert.False(t, ValidURL(""))
+}
+
+func TestSend(t *testing.T) {
+	payload, err := json.Marshal(Payload{ID: "delivery1", Event: EventAgentAdmitted, Timestamp: time.Now(), TenantID: 1})
+	assert.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp, err := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+		if err != nil || r.Header.Get(HeaderSignature) != Sign("secret", timestamp, body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get(HeaderEvent) != EventAgentAdmitted || r.Header.Get(HeaderDelivery) != "delivery1" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	code, err := Send(context.Background(), server.Client(), server.URL, "secret", payload)
+	assert.NoError(t, err, "signed request should be accepted")
+	assert.Equal(t, http.StatusNoContent, code)
+
+	code, err = Send(context.Background(), server.Client(), server.URL, "wrong", payload)
+	assert.Error(t, err, "request signed with another secret should be rejected")
+	assert.Equal(t, http.StatusUnauthorized, code)
+}